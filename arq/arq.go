@@ -0,0 +1,658 @@
+// Package arq turns an unreliable datagram connection, such as an unordered WebRTC data channel without
+// retransmissions or WebTransport datagrams, into a reliable ordered byte stream.
+//
+// Data is split into sequenced packets that are acknowledged cumulatively with a selective acknowledgement
+// bitmap, retransmitted after an adaptive timeout or three duplicate acknowledgements, and optionally
+// protected by XOR forward error correction that recovers one lost packet per group without a round trip.
+// Applications choose per channel between the raw datagram connection for lowest latency and an arq.Conn
+// on top of it for reliability.
+package arq
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrClosed is returned when using a closed connection
+	ErrClosed = errors.New("arq: connection closed")
+	// ErrTimeout is returned when a packet is not acknowledged after the maximum number of retransmissions
+	ErrTimeout = errors.New("arq: retransmission limit reached")
+)
+
+const (
+	// defaultMTU is the default maximum datagram size, safe for WebTransport datagrams
+	defaultMTU = 1200
+	// defaultWindow is the default number of packets in flight
+	defaultWindow = 256
+	// defaultMaxRetransmits is the default number of retransmissions before the connection fails
+	defaultMaxRetransmits = 20
+	// initialRTO is the retransmission timeout before the first RTT sample
+	initialRTO = 300 * time.Millisecond
+	// minRTO bounds the retransmission timeout from below
+	minRTO = 50 * time.Millisecond
+	// maxRTO bounds the retransmission timeout from above
+	maxRTO = 5 * time.Second
+	// tickInterval is the resolution of the retransmission timer
+	tickInterval = 10 * time.Millisecond
+	// closeTimeout bounds the time Close waits for outstanding data to be acknowledged
+	closeTimeout = 5 * time.Second
+	// fastRetransmitThreshold is the number of duplicate acks triggering a retransmission
+	fastRetransmitThreshold = 3
+)
+
+// PacketConn is an unreliable datagram connection. Datagrams may be lost, duplicated or reordered.
+type PacketConn interface {
+	NextMessage() ([]byte, error)
+	Send(data []byte) error
+	Close() error
+}
+
+// Config configures a Conn. Both sides should use the same MTU and FEC settings.
+type Config struct {
+	// MTU is the maximum datagram size including headers (default 1200).
+	MTU int
+	// Window is the maximum number of unacknowledged packets (default 256).
+	Window int
+	// ReadBuffer bounds the received but unread bytes; its free space is advertised in acks and further packets
+	// are dropped and retried without counting toward MaxRetransmits until the reader catches up
+	// (default Window*MTU).
+	ReadBuffer int
+	// FEC enables forward error correction with one parity packet per FEC data packets (2–255, 0 disables).
+	FEC int
+	// MaxRetransmits is the number of retransmissions of a packet before the connection fails (default 20).
+	MaxRetransmits int
+}
+
+// outPacket is a sent, unacknowledged packet.
+type outPacket struct {
+	flags       byte
+	payload     []byte
+	sentAt      time.Time
+	retransmits int
+	probes      int // Retransmissions while the read buffer of the peer was full
+}
+
+// Conn is a reliable ordered byte stream over a PacketConn. It implements io.ReadWriteCloser.
+type Conn struct {
+	conn PacketConn
+	cfg  Config
+
+	mu   sync.Mutex
+	cond *sync.Cond // Signaled on every state change
+
+	// Sender state
+	sndNxt   uint32                // Next sequence number to assign
+	unacked  map[uint32]*outPacket // Sent, unacknowledged packets
+	srtt     time.Duration         // Smoothed round trip time, 0 before the first sample
+	rttvar   time.Duration         // Round trip time variation
+	rto      time.Duration         // Retransmission timeout
+	lastAck  uint32                // Cumulative ack of the last ack packet
+	peerWnd  uint32                // Free read buffer of the peer advertised by the last ack
+	dupAcks  int                   // Number of acks not advancing lastAck
+	finSent  bool                  // Whether the FIN packet was queued
+	fecFirst uint32                // First sequence number of the current FEC group
+	fecCount int                   // Number of packets in the current FEC group
+	fecBlock []byte                // Parity of the current FEC group
+
+	// Receiver state
+	rcvNxt   uint32                  // Next expected sequence number
+	ooo      map[uint32]dataPacket   // Received packets waiting for earlier ones
+	readBuf  bytes.Buffer            // In-order data not yet read
+	finRecv  bool                    // Whether the FIN packet was delivered
+	wndZero  bool                    // Whether the last ack advertised no room for a full packet
+	fecCache map[uint32]dataPacket   // Recently received packets kept for FEC recovery
+	parities map[uint32]parityPacket // Parity packets by first sequence number
+
+	err       error // Terminal error
+	closed    bool
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+// New starts a reliable stream over conn.
+func New(conn PacketConn, cfg Config) *Conn {
+	if cfg.MTU <= dataHeaderSize+blockHeaderSize {
+		cfg.MTU = defaultMTU
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.Window > 1<<20 {
+		cfg.Window = 1 << 20
+	}
+	if cfg.ReadBuffer <= 0 {
+		cfg.ReadBuffer = cfg.Window * cfg.MTU
+	}
+	if cfg.FEC < 2 || cfg.FEC > 255 {
+		cfg.FEC = 0
+	}
+	if cfg.MaxRetransmits <= 0 {
+		cfg.MaxRetransmits = defaultMaxRetransmits
+	}
+
+	c := &Conn{
+		conn:      conn,
+		cfg:       cfg,
+		unacked:   make(map[uint32]*outPacket),
+		rto:       initialRTO,
+		peerWnd:   math.MaxUint32,
+		ooo:       make(map[uint32]dataPacket),
+		fecCache:  make(map[uint32]dataPacket),
+		parities:  make(map[uint32]parityPacket),
+		closeChan: make(chan struct{}),
+	}
+	c.cond = sync.NewCond(&c.mu)
+
+	go c.readLoop()
+	go c.timerLoop()
+	return c
+}
+
+// Read reads in-order stream data. It returns io.EOF after the remote side closed the stream.
+func (c *Conn) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	for c.readBuf.Len() == 0 {
+		var err error
+		switch {
+		case c.finRecv:
+			err = io.EOF
+		case c.err != nil:
+			err = c.err
+		case c.closed:
+			err = ErrClosed
+		}
+		if err != nil {
+			c.mu.Unlock()
+			return 0, err
+		}
+		c.cond.Wait()
+	}
+	n, err := c.readBuf.Read(p)
+
+	// Reading made room for packets held back by a full read buffer
+	if len(c.ooo) > 0 {
+		c.drain()
+	}
+	// Tell a sender waiting for room instead of letting it find out with its next probe
+	var ack []byte
+	if c.wndZero && c.freeWindow() >= c.maxPayload() {
+		ack = c.ackPacket()
+	}
+	c.mu.Unlock()
+
+	if ack != nil {
+		c.conn.Send(ack)
+	}
+	return n, err
+}
+
+// Write sends p reliably, blocking while the send window is full.
+func (c *Conn) Write(p []byte) (int, error) {
+	maxPayload := c.maxPayload()
+	written := 0
+
+	for len(p) > 0 {
+		n := len(p)
+		if n > maxPayload {
+			n = maxPayload
+		}
+		if err := c.sendData(p[:n], 0); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Close sends the end of the stream, waits up to five seconds for outstanding data to be acknowledged
+// and closes the underlying connection.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	alreadyFinished := c.finSent || c.closed || c.err != nil
+	c.mu.Unlock()
+
+	if !alreadyFinished {
+		if err := c.sendData(nil, flagFIN); err == nil {
+			deadline := time.AfterFunc(closeTimeout, func() {
+				c.mu.Lock()
+				c.cond.Broadcast()
+				c.mu.Unlock()
+			})
+			start := time.Now()
+
+			c.mu.Lock()
+			for len(c.unacked) > 0 && c.err == nil && time.Since(start) < closeTimeout {
+				c.cond.Wait()
+			}
+			c.mu.Unlock()
+			deadline.Stop()
+		}
+	}
+
+	c.shutdown(ErrClosed)
+	return nil
+}
+
+// Err returns the terminal error of the connection, nil while it is healthy.
+func (c *Conn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// RTT returns the smoothed round trip time, 0 before the first sample.
+func (c *Conn) RTT() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.srtt
+}
+
+// maxPayload returns the largest payload of a data packet and its parity packet.
+func (c *Conn) maxPayload() int {
+	if c.cfg.FEC > 0 {
+		return c.cfg.MTU - parityHeaderSize - blockHeaderSize
+	}
+	return c.cfg.MTU - dataHeaderSize
+}
+
+// sendData queues and transmits one data packet, waiting for window space.
+func (c *Conn) sendData(payload []byte, flags byte) error {
+	c.mu.Lock()
+	for len(c.unacked) >= c.cfg.Window && c.err == nil && !c.closed {
+		c.cond.Wait()
+	}
+	switch {
+	case c.err != nil:
+		err := c.err
+		c.mu.Unlock()
+		return err
+	case c.closed || c.finSent:
+		c.mu.Unlock()
+		return ErrClosed
+	}
+
+	seq := c.sndNxt
+	c.sndNxt++
+	pkt := &outPacket{flags: flags, payload: append([]byte(nil), payload...), sentAt: time.Now()}
+	c.unacked[seq] = pkt
+	if flags&flagFIN != 0 {
+		c.finSent = true
+	}
+
+	// Accumulate parity over full groups of regular data packets
+	var parity []byte
+	if c.cfg.FEC > 0 {
+		if flags&flagFIN != 0 {
+			c.fecCount, c.fecBlock = 0, nil
+		} else {
+			if c.fecCount == 0 {
+				c.fecFirst = seq
+			}
+			c.fecBlock = xorBlock(c.fecBlock, flags, pkt.payload)
+			c.fecCount++
+			if c.fecCount == c.cfg.FEC {
+				parity = encodeParity(c.fecFirst, c.fecCount, c.fecBlock)
+				c.fecCount, c.fecBlock = 0, nil
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	if err := c.conn.Send(encodeData(seq, flags, pkt.payload)); err != nil {
+		c.shutdown(err)
+		return err
+	}
+	if parity != nil {
+		if err := c.conn.Send(parity); err != nil {
+			c.shutdown(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// readLoop processes incoming packets until the connection fails.
+func (c *Conn) readLoop() {
+	for {
+		data, err := c.conn.NextMessage()
+		if err != nil {
+			c.shutdown(err)
+			return
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		switch data[0] {
+		case typeData:
+			if pkt, err := decodeData(data); err == nil {
+				c.handleData(pkt)
+			}
+		case typeAck:
+			if ack, err := decodeAck(data); err == nil {
+				c.handleAck(ack)
+			}
+		case typeParity:
+			if parity, err := decodeParity(data); err == nil {
+				c.handleParity(parity)
+			}
+		}
+	}
+}
+
+// handleData stores a received data packet and acknowledges it.
+func (c *Conn) handleData(pkt dataPacket) {
+	c.mu.Lock()
+	accepted := c.receive(pkt)
+	if accepted && c.cfg.FEC > 0 {
+		c.fecCache[pkt.seq] = dataPacket{seq: pkt.seq, flags: pkt.flags, payload: append([]byte(nil), pkt.payload...)}
+		c.recover()
+	}
+	ack := c.ackPacket()
+	c.mu.Unlock()
+
+	c.conn.Send(ack)
+}
+
+// receive places a packet into the reorder buffer and delivers in-order data. It reports whether the
+// packet was accepted; duplicates and packets beyond the window or read buffer are not.
+// Must be called with c.mu held.
+func (c *Conn) receive(pkt dataPacket) bool {
+	if seqLess(pkt.seq, c.rcvNxt) || int(pkt.seq-c.rcvNxt) >= c.cfg.Window*2 {
+		return false
+	}
+	if _, ok := c.ooo[pkt.seq]; ok {
+		return false
+	}
+	if c.readBuf.Len()+len(pkt.payload) > c.cfg.ReadBuffer && pkt.seq == c.rcvNxt {
+		return false
+	}
+
+	c.ooo[pkt.seq] = dataPacket{seq: pkt.seq, flags: pkt.flags, payload: append([]byte(nil), pkt.payload...)}
+	c.drain()
+	return true
+}
+
+// drain moves in-order packets from the reorder buffer into the read buffer while it has room.
+// Must be called with c.mu held.
+func (c *Conn) drain() {
+	for {
+		next, ok := c.ooo[c.rcvNxt]
+		if !ok || c.readBuf.Len()+len(next.payload) > c.cfg.ReadBuffer {
+			break
+		}
+		delete(c.ooo, c.rcvNxt)
+		c.rcvNxt++
+		c.readBuf.Write(next.payload)
+		if next.flags&flagFIN != 0 {
+			c.finRecv = true
+		}
+	}
+	c.pruneFEC()
+	c.cond.Broadcast()
+}
+
+// handleParity stores a parity packet and attempts recovery.
+func (c *Conn) handleParity(parity parityPacket) {
+	if c.cfg.FEC == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	last := parity.first + uint32(parity.count)
+	if seqLess(c.rcvNxt, last) {
+		if _, ok := c.parities[parity.first]; !ok {
+			parity.block = append([]byte(nil), parity.block...)
+			c.parities[parity.first] = parity
+		}
+	}
+	recovered := c.recover()
+	var ack []byte
+	if recovered {
+		ack = c.ackPacket()
+	}
+	c.mu.Unlock()
+
+	if ack != nil {
+		c.conn.Send(ack)
+	}
+}
+
+// recover reconstructs lost packets of groups missing exactly one packet. It reports whether any packet
+// was recovered. Must be called with c.mu held.
+func (c *Conn) recover() bool {
+	recovered := false
+	for first, parity := range c.parities {
+		missing, missingSeq := 0, uint32(0)
+		block := append([]byte(nil), parity.block...)
+		for i := 0; i < parity.count; i++ {
+			seq := first + uint32(i)
+			pkt, ok := c.fecCache[seq]
+			if !ok {
+				missing++
+				missingSeq = seq
+				continue
+			}
+			block = xorBlock(block, pkt.flags, pkt.payload)
+		}
+
+		switch {
+		case missing == 0:
+			delete(c.parities, first)
+		case missing == 1:
+			delete(c.parities, first)
+			flags, payload, ok := parseBlock(block)
+			if !ok || seqLess(missingSeq, c.rcvNxt) {
+				continue
+			}
+			pkt := dataPacket{seq: missingSeq, flags: flags, payload: payload}
+			if c.receive(pkt) {
+				c.fecCache[missingSeq] = pkt
+				recovered = true
+			}
+		}
+	}
+	return recovered
+}
+
+// pruneFEC drops cached packets and parities that can no longer help recovery. Must be called with c.mu held.
+func (c *Conn) pruneFEC() {
+	if c.cfg.FEC == 0 {
+		return
+	}
+	horizon := c.rcvNxt - uint32(c.cfg.FEC)
+	for seq := range c.fecCache {
+		if seqLess(seq, horizon) {
+			delete(c.fecCache, seq)
+		}
+	}
+	for first, parity := range c.parities {
+		if !seqLess(c.rcvNxt, first+uint32(parity.count)) {
+			delete(c.parities, first)
+		}
+	}
+}
+
+// freeWindow returns the free space of the read buffer. Must be called with c.mu held.
+func (c *Conn) freeWindow() int {
+	return max(c.cfg.ReadBuffer-c.readBuf.Len(), 0)
+}
+
+// ackPacket builds an ack for the current receive state. Must be called with c.mu held.
+func (c *Conn) ackPacket() []byte {
+	var sack uint64
+	for i := uint32(0); i < 64; i++ {
+		if _, ok := c.ooo[c.rcvNxt+1+i]; ok {
+			sack |= 1 << i
+		}
+	}
+	wnd := c.freeWindow()
+	c.wndZero = wnd < c.maxPayload()
+	return encodeAck(c.rcvNxt, sack, uint32(min(int64(wnd), math.MaxUint32)))
+}
+
+// windowShut reports whether the peer has no room for the packet it expects next, so that retransmitting
+// it only probes for the window to open. Must be called with c.mu held.
+func (c *Conn) windowShut() bool {
+	pkt, ok := c.unacked[c.lastAck]
+	return ok && uint32(len(pkt.payload)) > c.peerWnd
+}
+
+// handleAck releases acknowledged packets, samples the round trip time and triggers fast retransmission.
+func (c *Conn) handleAck(ack ackPacket) {
+	c.mu.Lock()
+
+	now := time.Now()
+	release := func(seq uint32) {
+		pkt, ok := c.unacked[seq]
+		if !ok {
+			return
+		}
+		// Karn's algorithm: only sample packets that were not retransmitted
+		if pkt.retransmits == 0 && pkt.probes == 0 {
+			c.sampleRTT(now.Sub(pkt.sentAt))
+		}
+		delete(c.unacked, seq)
+	}
+
+	for seq := range c.unacked {
+		if seqLess(seq, ack.next) {
+			release(seq)
+		}
+	}
+	for i := uint32(0); i < 64; i++ {
+		if ack.sack&(1<<i) != 0 {
+			release(ack.next + 1 + i)
+		}
+	}
+
+	// Acks reordered behind a later one carry a stale window
+	if !seqLess(ack.next, c.lastAck) {
+		c.peerWnd = ack.wnd
+	}
+
+	var resend []byte
+	if ack.next == c.lastAck && ack.sack != 0 {
+		c.dupAcks++
+		if c.dupAcks == fastRetransmitThreshold {
+			if pkt, ok := c.unacked[ack.next]; ok && !c.windowShut() {
+				pkt.retransmits++
+				pkt.sentAt = now
+				resend = encodeData(ack.next, pkt.flags, pkt.payload)
+			}
+		}
+	} else if seqLess(c.lastAck, ack.next) {
+		c.lastAck = ack.next
+		c.dupAcks = 0
+	}
+
+	// A window update resends the packet the peer had no room for without waiting for the next probe
+	if pkt, ok := c.unacked[c.lastAck]; ok && resend == nil && pkt.probes > 0 && !c.windowShut() {
+		pkt.sentAt = now
+		resend = encodeData(c.lastAck, pkt.flags, pkt.payload)
+	}
+
+	c.cond.Broadcast()
+	c.mu.Unlock()
+
+	if resend != nil {
+		c.conn.Send(resend)
+	}
+}
+
+// sampleRTT updates the retransmission timeout from a round trip sample as in RFC 6298.
+// Must be called with c.mu held.
+func (c *Conn) sampleRTT(rtt time.Duration) {
+	if c.srtt == 0 {
+		c.srtt = rtt
+		c.rttvar = rtt / 2
+	} else {
+		c.rttvar = (3*c.rttvar + (c.srtt - rtt).Abs()) / 4
+		c.srtt = (7*c.srtt + rtt) / 8
+	}
+	c.rto = min(max(c.srtt+4*c.rttvar, minRTO), maxRTO)
+}
+
+// timerLoop retransmits packets whose timeout expired.
+func (c *Conn) timerLoop() {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.closeChan:
+			return
+		case <-ticker.C:
+		}
+
+		c.mu.Lock()
+		now := time.Now()
+		var resend [][]byte
+		var failed bool
+		shut := c.windowShut()
+		for seq, pkt := range c.unacked {
+			// Later packets may wait in the reorder buffer of the peer beyond the reach of selective acks,
+			// so they are held back until the window opens
+			if shut && seq != c.lastAck {
+				continue
+			}
+			timeout := c.rto << min(pkt.retransmits+pkt.probes, 6)
+			if timeout > maxRTO {
+				timeout = maxRTO
+			}
+			if now.Sub(pkt.sentAt) < timeout {
+				continue
+			}
+			// A packet the peer dropped for lack of room is not lost, it is retried until a slow reader
+			// catches up
+			switch {
+			case shut:
+				pkt.probes++
+			case pkt.retransmits >= c.cfg.MaxRetransmits:
+				failed = true
+			default:
+				pkt.retransmits++
+			}
+			if failed {
+				break
+			}
+			pkt.sentAt = now
+			resend = append(resend, encodeData(seq, pkt.flags, pkt.payload))
+		}
+		c.mu.Unlock()
+
+		if failed {
+			c.shutdown(ErrTimeout)
+			return
+		}
+		for _, data := range resend {
+			if err := c.conn.Send(data); err != nil {
+				c.shutdown(err)
+				return
+			}
+		}
+	}
+}
+
+// shutdown records the terminal error, wakes all waiters and closes the underlying connection.
+func (c *Conn) shutdown(err error) {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		if err == ErrClosed {
+			c.closed = true
+		} else {
+			c.err = err
+		}
+		c.cond.Broadcast()
+		c.mu.Unlock()
+
+		close(c.closeChan)
+		c.conn.Close()
+	})
+}
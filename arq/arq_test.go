@@ -0,0 +1,214 @@
+package arq
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pipeConn is one end of an in-memory datagram pipe.
+type pipeConn struct {
+	in, out chan []byte
+	// closed is closed when this end is closed, peerClosed when the other end is
+	closed, peerClosed chan struct{}
+	closeOnce          *sync.Once
+}
+
+// pipe returns the two ends of an in-memory datagram pipe buffering up to 1024 datagrams per direction.
+func pipe() (*pipeConn, *pipeConn) {
+	ab, ba := make(chan []byte, 1024), make(chan []byte, 1024)
+	ca, cb := make(chan struct{}), make(chan struct{})
+	return &pipeConn{in: ba, out: ab, closed: ca, peerClosed: cb, closeOnce: new(sync.Once)},
+		&pipeConn{in: ab, out: ba, closed: cb, peerClosed: ca, closeOnce: new(sync.Once)}
+}
+
+// NextMessage returns the next datagram, or io.EOF once the other end was closed and all its datagrams
+// were received.
+func (c *pipeConn) NextMessage() ([]byte, error) {
+	select {
+	case data := <-c.in:
+		return data, nil
+	case <-c.closed:
+		return nil, net.ErrClosed
+	case <-c.peerClosed:
+		select {
+		case data := <-c.in:
+			return data, nil
+		default:
+			return nil, io.EOF
+		}
+	}
+}
+
+// Send sends a copy of data. Datagrams sent to a closed end are discarded.
+func (c *pipeConn) Send(data []byte) error {
+	select {
+	case <-c.closed:
+		return net.ErrClosed
+	default:
+	}
+	select {
+	case c.out <- append([]byte(nil), data...):
+	case <-c.closed:
+		return net.ErrClosed
+	case <-c.peerClosed:
+	}
+	return nil
+}
+
+// Close closes this end.
+func (c *pipeConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+// lossyConn drops every dropEvery-th datagram it sends, or all of them once blackhole is set.
+type lossyConn struct {
+	*pipeConn
+	dropEvery int64
+	sent      atomic.Int64
+	blackhole atomic.Bool
+}
+
+// Send drops or sends data.
+func (c *lossyConn) Send(data []byte) error {
+	n := c.sent.Add(1)
+	if c.blackhole.Load() || (c.dropEvery > 0 && n%c.dropEvery == 0) {
+		return nil
+	}
+	return c.pipeConn.Send(data)
+}
+
+// newPair returns two connected Conns over an in-memory pipe whose datagrams are lost as configured.
+func newPair(t *testing.T, cfg Config, dropEvery int64) (a, b *Conn, la, lb *lossyConn) {
+	t.Helper()
+	pa, pb := pipe()
+	la, lb = &lossyConn{pipeConn: pa, dropEvery: dropEvery}, &lossyConn{pipeConn: pb, dropEvery: dropEvery}
+	a, b = New(la, cfg), New(lb, cfg)
+	t.Cleanup(func() {
+		a.shutdown(ErrClosed)
+		b.shutdown(ErrClosed)
+	})
+	return a, b, la, lb
+}
+
+// transfer writes data to a and closes it while reading b to the end.
+func transfer(t *testing.T, a, b *Conn, data []byte) {
+	t.Helper()
+	go func() {
+		if _, err := a.Write(data); err != nil {
+			t.Error(err)
+		}
+		a.Close()
+	}()
+	got, err := io.ReadAll(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("received %d bytes, want %d", len(got), len(data))
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 32<<10)
+	for name, tc := range map[string]struct {
+		cfg       Config
+		dropEvery int64
+	}{
+		"lossless":     {Config{}, 0},
+		"loss":         {Config{}, 10},
+		"loss and fec": {Config{FEC: 4}, 10},
+	} {
+		t.Run(name, func(t *testing.T) {
+			a, b, _, _ := newPair(t, tc.cfg, tc.dropEvery)
+			transfer(t, a, b, data)
+			if err := a.Err(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestSlowReader(t *testing.T) {
+	// Few retransmissions would fail the sender quickly if packets dropped by the full read buffer were
+	// counted as lost
+	a, b, _, _ := newPair(t, Config{ReadBuffer: 4 << 10, MaxRetransmits: 2}, 0)
+	data := bytes.Repeat([]byte("slow"), 64<<10)
+	go func() {
+		if _, err := a.Write(data); err != nil {
+			t.Error(err)
+		}
+		a.Close()
+	}()
+
+	time.Sleep(time.Second)
+	if err := a.Err(); err != nil {
+		t.Fatalf("sender failed while the reader was busy: %v", err)
+	}
+	got, err := io.ReadAll(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("received %d bytes, want %d", len(got), len(data))
+	}
+}
+
+func TestRetransmissionLimit(t *testing.T) {
+	a, _, la, _ := newPair(t, Config{MaxRetransmits: 2}, 0)
+	la.blackhole.Store(true)
+
+	if _, err := a.Write([]byte("lost")); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for a.Err() == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := a.Err(); err != ErrTimeout {
+		t.Fatalf("Err: %v, want ErrTimeout", err)
+	}
+	if _, err := a.Write([]byte("more")); err != ErrTimeout {
+		t.Fatalf("Write: %v, want ErrTimeout", err)
+	}
+}
+
+func TestClosed(t *testing.T) {
+	a, b, _, _ := newPair(t, Config{}, 0)
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Write([]byte("late")); err != ErrClosed {
+		t.Fatalf("Write: %v, want ErrClosed", err)
+	}
+	if _, err := b.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("Read: %v, want io.EOF", err)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for name, b := range map[string][]byte{
+		"short data":   {typeData, 0, 0},
+		"short ack":    encodeAck(1, 0, 0)[:ackSize-1],
+		"short parity": {typeParity, 0, 0, 0, 0, 2},
+		"parity count": encodeParity(0, 1, []byte{0, 0, 0}),
+	} {
+		var err error
+		switch b[0] {
+		case typeData:
+			_, err = decodeData(b)
+		case typeAck:
+			_, err = decodeAck(b)
+		case typeParity:
+			_, err = decodeParity(b)
+		}
+		if err != errInvalidPacket {
+			t.Errorf("%s: %v, want errInvalidPacket", name, err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package arq
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// errInvalidPacket is returned when decoding a malformed packet
+var errInvalidPacket = errors.New("arq: invalid packet")
+
+// Packet types.
+const (
+	typeData   byte = 1 // [type][flags][seq u32][payload]
+	typeAck    byte = 2 // [type][next expected seq u32][selective ack bitmap u64][receive window u32]
+	typeParity byte = 3 // [type][first seq u32][count u8][parity block]
+)
+
+// Data packet flags.
+const (
+	flagFIN byte = 1 << 0 // Last packet of the stream
+)
+
+const (
+	// dataHeaderSize is the size of the data packet header
+	dataHeaderSize = 6
+	// ackSize is the size of an ack packet
+	ackSize = 17
+	// parityHeaderSize is the size of the parity packet header
+	parityHeaderSize = 6
+	// blockHeaderSize is the size of the flags and length prefix of an FEC block
+	blockHeaderSize = 3
+)
+
+// dataPacket is a decoded data packet.
+type dataPacket struct {
+	seq     uint32
+	flags   byte
+	payload []byte
+}
+
+// ackPacket is a decoded ack packet.
+type ackPacket struct {
+	next uint32 // All packets before next were received
+	sack uint64 // Bit i is set if packet next+1+i was received
+	wnd  uint32 // Free bytes in the read buffer of the receiver
+}
+
+// parityPacket is a decoded parity packet.
+type parityPacket struct {
+	first uint32 // First sequence number of the protected group
+	count int    // Number of packets in the group
+	block []byte // XOR of the FEC blocks of the group
+}
+
+// encodeData encodes a data packet.
+func encodeData(seq uint32, flags byte, payload []byte) []byte {
+	b := make([]byte, dataHeaderSize+len(payload))
+	b[0] = typeData
+	b[1] = flags
+	binary.BigEndian.PutUint32(b[2:], seq)
+	copy(b[dataHeaderSize:], payload)
+	return b
+}
+
+// encodeAck encodes an ack packet.
+func encodeAck(next uint32, sack uint64, wnd uint32) []byte {
+	b := make([]byte, ackSize)
+	b[0] = typeAck
+	binary.BigEndian.PutUint32(b[1:], next)
+	binary.BigEndian.PutUint64(b[5:], sack)
+	binary.BigEndian.PutUint32(b[13:], wnd)
+	return b
+}
+
+// encodeParity encodes a parity packet.
+func encodeParity(first uint32, count int, block []byte) []byte {
+	b := make([]byte, parityHeaderSize+len(block))
+	b[0] = typeParity
+	binary.BigEndian.PutUint32(b[1:], first)
+	b[5] = byte(count)
+	copy(b[parityHeaderSize:], block)
+	return b
+}
+
+// decodeData decodes a data packet. The payload aliases b.
+func decodeData(b []byte) (dataPacket, error) {
+	if len(b) < dataHeaderSize {
+		return dataPacket{}, errInvalidPacket
+	}
+	return dataPacket{
+		flags:   b[1],
+		seq:     binary.BigEndian.Uint32(b[2:]),
+		payload: b[dataHeaderSize:],
+	}, nil
+}
+
+// decodeAck decodes an ack packet.
+func decodeAck(b []byte) (ackPacket, error) {
+	if len(b) != ackSize {
+		return ackPacket{}, errInvalidPacket
+	}
+	return ackPacket{
+		next: binary.BigEndian.Uint32(b[1:]),
+		sack: binary.BigEndian.Uint64(b[5:]),
+		wnd:  binary.BigEndian.Uint32(b[13:]),
+	}, nil
+}
+
+// decodeParity decodes a parity packet. The block aliases b.
+func decodeParity(b []byte) (parityPacket, error) {
+	if len(b) < parityHeaderSize+blockHeaderSize || b[5] < 2 {
+		return parityPacket{}, errInvalidPacket
+	}
+	return parityPacket{
+		first: binary.BigEndian.Uint32(b[1:]),
+		count: int(b[5]),
+		block: b[parityHeaderSize:],
+	}, nil
+}
+
+// xorBlock XORs the FEC block of a packet (flags, length, payload) into parity, growing parity as needed.
+func xorBlock(parity []byte, flags byte, payload []byte) []byte {
+	need := blockHeaderSize + len(payload)
+	for len(parity) < need {
+		parity = append(parity, 0)
+	}
+	parity[0] ^= flags
+	parity[1] ^= byte(len(payload) >> 8)
+	parity[2] ^= byte(len(payload))
+	for i, c := range payload {
+		parity[blockHeaderSize+i] ^= c
+	}
+	return parity
+}
+
+// parseBlock extracts flags and payload from a recovered FEC block.
+func parseBlock(block []byte) (flags byte, payload []byte, ok bool) {
+	if len(block) < blockHeaderSize {
+		return 0, nil, false
+	}
+	n := int(block[1])<<8 | int(block[2])
+	if blockHeaderSize+n > len(block) {
+		return 0, nil, false
+	}
+	return block[0], block[blockHeaderSize : blockHeaderSize+n], true
+}
+
+// seqLess reports whether a precedes b in 32-bit serial number arithmetic.
+func seqLess(a, b uint32) bool {
+	return int32(a-b) < 0
+}
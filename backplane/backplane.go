@@ -0,0 +1,110 @@
+// Package backplane connects the instances of a horizontally scaled service, such as relays behind a load
+// balancer, through a shared publish/subscribe bus. An instance subscribes to a channel for every client
+// connected to it, so the other instances find the client's session by publishing to that channel and forward
+// its messages there:
+//
+//	bp, err := backplane.NewRedis(backplane.RedisConfig{URL: "redis://redis.internal:6379"})
+//	...
+//	srv := rendezvous.NewServer(rendezvous.ServerConfig{Backplane: bp})
+//
+// Delivery is at most once, like Redis Pub/Sub: messages published while an instance is reconnecting are lost.
+package backplane
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosed is returned when using a backplane that has been closed
+var ErrClosed = errors.New("backplane closed")
+
+// Backplane is a publish/subscribe bus shared by the instances of a service.
+type Backplane interface {
+	// Publish sends msg to the current subscribers of channel and returns how many received it, zero if
+	// nobody subscribes to the channel.
+	Publish(ctx context.Context, channel string, msg []byte) (int, error)
+	// Subscribe calls fn with every message published to channel until cancel is called. It registers fn at
+	// once and subscribes with the bus in the background, re-subscribing after connection failures, so it
+	// neither blocks nor fails. fn must not block or retain msg.
+	Subscribe(channel string, fn func(msg []byte)) (cancel func())
+	// Close closes the backplane and drops every subscription.
+	Close() error
+}
+
+// Memory is a Backplane within a single process, e.g. for running several servers in one program or testing.
+type Memory struct {
+	mu     sync.Mutex
+	subs   map[string]map[*subscription]struct{}
+	closed bool
+}
+
+// subscription is a subscriber callback.
+type subscription struct {
+	fn func(msg []byte)
+}
+
+// NewMemory creates an in-process backplane.
+func NewMemory() *Memory {
+	return &Memory{subs: make(map[string]map[*subscription]struct{})}
+}
+
+// Publish calls the subscribers of channel with msg.
+func (m *Memory) Publish(ctx context.Context, channel string, msg []byte) (int, error) {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return 0, ErrClosed
+	}
+	subs := make([]*subscription, 0, len(m.subs[channel]))
+	for sub := range m.subs[channel] {
+		subs = append(subs, sub)
+	}
+	m.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.fn(msg)
+	}
+	return len(subs), nil
+}
+
+// Subscribe calls fn with every message published to channel until cancel is called.
+func (m *Memory) Subscribe(channel string, fn func(msg []byte)) (cancel func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return func() {}
+	}
+	sub := &subscription{fn: fn}
+	add(m.subs, channel, sub)
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		remove(m.subs, channel, sub)
+	}
+}
+
+// Close drops every subscription.
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	clear(m.subs)
+	return nil
+}
+
+// add adds a subscription to the subscriptions of channel.
+func add(subs map[string]map[*subscription]struct{}, channel string, sub *subscription) {
+	if subs[channel] == nil {
+		subs[channel] = make(map[*subscription]struct{})
+	}
+	subs[channel][sub] = struct{}{}
+}
+
+// remove removes a subscription from the subscriptions of channel.
+func remove(subs map[string]map[*subscription]struct{}, channel string, sub *subscription) {
+	delete(subs[channel], sub)
+	if len(subs[channel]) == 0 {
+		delete(subs, channel)
+	}
+}
@@ -0,0 +1,503 @@
+//go:build !js
+
+package backplane
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pkg.gfire.dev/supernet/logging"
+	"pkg.gfire.dev/supernet/retry"
+)
+
+var (
+	// ErrInvalidRedisURL is returned by NewRedis for malformed server URLs
+	ErrInvalidRedisURL = errors.New("invalid redis url")
+	// ErrRedis is returned when the Redis server answers a command with an error
+	ErrRedis = errors.New("redis error")
+)
+
+// errProtocol is returned for malformed replies of the Redis server
+var errProtocol = errors.New("redis protocol error")
+
+const (
+	// defaultRedisPrefix is the default prefix of channel names
+	defaultRedisPrefix = "supernet:"
+	// defaultRedisTimeout is the default time limit of connecting and of every command
+	defaultRedisTimeout = 5 * time.Second
+	// defaultRedisPort is the port of redis URLs without one
+	defaultRedisPort = "6379"
+	// redisPingInterval is the time between pings on the subscriber connection, which detect dead servers
+	redisPingInterval = 30 * time.Second
+	// maxBulkSize is the largest bulk string read from the server
+	maxBulkSize = 16 << 20
+	// maxArrayLen is the largest array read from the server
+	maxArrayLen = 1 << 16
+)
+
+// RedisConfig configures a Redis backplane.
+type RedisConfig struct {
+	// URL locates the server as redis://[[user]:password@]host[:port], or rediss:// for TLS. A database in
+	// the path is ignored, Pub/Sub channels span all databases.
+	URL string
+	// Prefix is prepended to every channel, separating deployments sharing a server (default "supernet:").
+	Prefix string
+	// TLS configures rediss:// connections. The server name defaults to the host of the URL.
+	TLS *tls.Config
+	// Timeout limits connecting and every command (default 5s).
+	Timeout time.Duration
+	// Logger receives connection failures. Defaults to logging.For("backplane").
+	Logger *slog.Logger
+}
+
+// Redis is a Backplane on Redis Pub/Sub. It publishes on one connection and keeps the subscriptions of all
+// channels on another, which is re-established with backoff when it fails. Publish returns the number of
+// instances subscribed to a channel, as every instance uses a single subscriber connection.
+type Redis struct {
+	cfg      RedisConfig
+	addr     string
+	user     string
+	password string
+	tls      *tls.Config
+
+	// pubMu serializes commands on pub, the connection used for PUBLISH, nil until needed
+	pubMu sync.Mutex
+	pub   *redisConn
+
+	mu sync.Mutex
+	// subs holds the subscriptions by channel, without the prefix
+	subs map[string]map[*subscription]struct{}
+	// wake tells the subscriber connection that the subscribed channels changed
+	wake chan struct{}
+
+	closeChan chan struct{}
+	closeOnce sync.Once
+	// done is closed when the subscriber connection has stopped
+	done chan struct{}
+}
+
+// NewRedis creates a Redis backplane. It connects in the background and keeps reconnecting until closed.
+func NewRedis(cfg RedisConfig) (*Redis, error) {
+	if cfg.Prefix == "" {
+		cfg.Prefix = defaultRedisPrefix
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultRedisTimeout
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logging.For("backplane")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRedisURL, err)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("%w: no host", ErrInvalidRedisURL)
+	}
+
+	r := &Redis{
+		cfg:       cfg,
+		addr:      u.Host,
+		subs:      make(map[string]map[*subscription]struct{}),
+		wake:      make(chan struct{}, 1),
+		closeChan: make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), defaultRedisPort)
+	}
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		r.tls = &tls.Config{}
+		if cfg.TLS != nil {
+			r.tls = cfg.TLS.Clone()
+		}
+		if r.tls.ServerName == "" {
+			r.tls.ServerName = u.Hostname()
+		}
+	default:
+		return nil, fmt.Errorf("%w: scheme %q", ErrInvalidRedisURL, u.Scheme)
+	}
+	if u.User != nil {
+		r.user = u.User.Username()
+		if password, ok := u.User.Password(); ok {
+			r.password = password
+		} else {
+			// redis://password@host is a common way to pass just a password
+			r.user, r.password = "", r.user
+		}
+	}
+
+	go r.run()
+	return r, nil
+}
+
+// Publish sends msg to the subscribers of channel and returns the number of instances that received it.
+func (r *Redis) Publish(ctx context.Context, channel string, msg []byte) (int, error) {
+	select {
+	case <-r.closeChan:
+		return 0, ErrClosed
+	default:
+	}
+
+	r.pubMu.Lock()
+	defer r.pubMu.Unlock()
+	if r.pub == nil {
+		c, err := r.dial(ctx)
+		if err != nil {
+			return 0, err
+		}
+		r.pub = c
+	}
+
+	deadline := time.Now().Add(r.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	r.pub.conn.SetDeadline(deadline)
+	conn := r.pub.conn
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	v, err := r.pub.do("PUBLISH", r.cfg.Prefix+channel, string(msg))
+	stop()
+	if err != nil {
+		// Server errors leave the connection usable; anything else may have left a reply unread
+		if !errors.Is(err, ErrRedis) {
+			r.pub.conn.Close()
+			r.pub = nil
+		}
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 0, err
+	}
+	n, ok := v.(int64)
+	if !ok {
+		return 0, errProtocol
+	}
+	return int(n), nil
+}
+
+// Subscribe calls fn with every message published to channel until cancel is called.
+func (r *Redis) Subscribe(channel string, fn func(msg []byte)) (cancel func()) {
+	select {
+	case <-r.closeChan:
+		return func() {}
+	default:
+	}
+
+	sub := &subscription{fn: fn}
+	r.mu.Lock()
+	add(r.subs, channel, sub)
+	r.mu.Unlock()
+	r.changed()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			remove(r.subs, channel, sub)
+			r.mu.Unlock()
+			r.changed()
+		})
+	}
+}
+
+// Close closes the connections and drops every subscription.
+func (r *Redis) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.closeChan)
+	})
+	<-r.done
+
+	r.pubMu.Lock()
+	defer r.pubMu.Unlock()
+	if r.pub != nil {
+		r.pub.conn.Close()
+		r.pub = nil
+	}
+	r.mu.Lock()
+	clear(r.subs)
+	r.mu.Unlock()
+	return nil
+}
+
+// changed wakes the subscriber connection to update its subscriptions.
+func (r *Redis) changed() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run keeps a subscriber connection open until the backplane is closed.
+func (r *Redis) run() {
+	defer close(r.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-r.closeChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	backoff := retry.Policy{Initial: 100 * time.Millisecond, Max: 10 * time.Second}.Backoff()
+	for {
+		c, err := r.dial(ctx)
+		if err == nil {
+			backoff.Reset()
+			err = r.session(c)
+		}
+		select {
+		case <-r.closeChan:
+			return
+		default:
+		}
+		r.cfg.Logger.Warn("backplane connection failed", "addr", r.addr, "err", err)
+		if !backoff.Wait(ctx) {
+			return
+		}
+	}
+}
+
+// session receives the messages of a subscriber connection until it fails.
+func (r *Redis) session(c *redisConn) error {
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		r.subscribe(c, stop)
+	})
+	defer wg.Wait()
+	defer close(stop)
+	defer c.conn.Close()
+
+	for {
+		// Pongs arrive at least every ping interval on a live connection
+		c.conn.SetReadDeadline(time.Now().Add(2 * redisPingInterval))
+		v, err := c.read()
+		if err != nil {
+			return err
+		}
+		// Messages are ["message", channel, payload]; subscription confirmations and pongs are ignored
+		msg, ok := v.([]any)
+		if !ok || len(msg) != 3 {
+			continue
+		}
+		kind, _ := msg[0].([]byte)
+		channel, _ := msg[1].([]byte)
+		payload, _ := msg[2].([]byte)
+		if string(kind) != "message" {
+			continue
+		}
+		if name, ok := strings.CutPrefix(string(channel), r.cfg.Prefix); ok {
+			r.dispatch(name, payload)
+		}
+	}
+}
+
+// subscribe keeps the channels subscribed on a connection in sync with subs and pings the server until stop
+// is closed or the backplane is closed.
+func (r *Redis) subscribe(c *redisConn, stop <-chan struct{}) {
+	ticker := time.NewTicker(redisPingInterval)
+	defer ticker.Stop()
+	subscribed := make(map[string]bool)
+	ping := false
+	for {
+		var add, remove []string
+		r.mu.Lock()
+		for channel := range r.subs {
+			if !subscribed[channel] {
+				subscribed[channel] = true
+				add = append(add, r.cfg.Prefix+channel)
+			}
+		}
+		for channel := range subscribed {
+			if r.subs[channel] == nil {
+				delete(subscribed, channel)
+				remove = append(remove, r.cfg.Prefix+channel)
+			}
+		}
+		r.mu.Unlock()
+
+		c.conn.SetWriteDeadline(time.Now().Add(r.cfg.Timeout))
+		var err error
+		if len(add) > 0 {
+			err = c.send(append([]string{"SUBSCRIBE"}, add...)...)
+		}
+		if err == nil && len(remove) > 0 {
+			err = c.send(append([]string{"UNSUBSCRIBE"}, remove...)...)
+		}
+		if err == nil && ping {
+			err = c.send("PING")
+		}
+		if err != nil {
+			c.conn.Close()
+			return
+		}
+
+		ping = false
+		select {
+		case <-r.wake:
+		case <-ticker.C:
+			ping = true
+		case <-stop:
+			return
+		case <-r.closeChan:
+			c.conn.Close()
+			return
+		}
+	}
+}
+
+// dispatch calls the subscribers of a channel with a message.
+func (r *Redis) dispatch(channel string, msg []byte) {
+	r.mu.Lock()
+	subs := make([]*subscription, 0, len(r.subs[channel]))
+	for sub := range r.subs[channel] {
+		subs = append(subs, sub)
+	}
+	r.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.fn(msg)
+	}
+}
+
+// dial connects and authenticates to the server.
+func (r *Redis) dial(ctx context.Context) (*redisConn, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.Timeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", r.addr)
+	if err != nil {
+		return nil, err
+	}
+	if r.tls != nil {
+		tc := tls.Client(conn, r.tls)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tc
+	}
+
+	c := &redisConn{conn: conn, r: bufio.NewReader(conn)}
+	if r.password != "" {
+		deadline, _ := ctx.Deadline()
+		conn.SetDeadline(deadline)
+		args := []string{"AUTH", r.password}
+		if r.user != "" {
+			args = []string{"AUTH", r.user, r.password}
+		}
+		if _, err := c.do(args...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn.SetDeadline(time.Time{})
+	}
+	return c, nil
+}
+
+// redisConn speaks RESP2, the Redis serialization protocol, on a connection.
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// do sends a command and reads its reply.
+func (c *redisConn) do(args ...string) (any, error) {
+	if err := c.send(args...); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// send writes a command as an array of bulk strings.
+func (c *redisConn) send(args ...string) error {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, "\r\n"...)
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+// read reads a reply: a string for simple strings, int64 for integers, []byte for bulk strings (nil for null),
+// []any for arrays and an error wrapping ErrRedis for error replies.
+func (c *redisConn) read() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errProtocol
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, fmt.Errorf("%w: %s", ErrRedis, body)
+	case ':':
+		n, err := strconv.ParseInt(body, 10, 64)
+		if err != nil {
+			return nil, errProtocol
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n > maxBulkSize {
+			return nil, errProtocol
+		}
+		if n < 0 {
+			return []byte(nil), nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		if buf[n] != '\r' || buf[n+1] != '\n' {
+			return nil, errProtocol
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n > maxArrayLen {
+			return nil, errProtocol
+		}
+		if n < 0 {
+			return []any(nil), nil
+		}
+		elems := make([]any, n)
+		for i := range elems {
+			if elems[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return elems, nil
+	}
+	return nil, errProtocol
+}
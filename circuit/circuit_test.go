@@ -0,0 +1,219 @@
+package circuit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/memtransport"
+)
+
+// testTimeout bounds every blocking call of a test
+const testTimeout = 10 * time.Second
+
+// node is a router with the record other routers dial it by.
+type node struct {
+	*Router
+	rec *identity.Record
+}
+
+// newNode creates a router that is closed when the test ends.
+func newNode(t *testing.T, cfg Config) *node {
+	t.Helper()
+	id, err := identity.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := id.NewRecord(nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := identity.OpenRecord(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Identity = id
+	r, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return &node{Router: r, rec: rec}
+}
+
+// connect links a and b over an in-memory pipe and returns a's end.
+func connect(t *testing.T, a, b *node) *memtransport.Conn {
+	t.Helper()
+	ca, cb := memtransport.Pipe(memtransport.Config{})
+	if err := a.AddLink(b.rec.ID, ca); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddLink(a.rec.ID, cb); err != nil {
+		t.Fatal(err)
+	}
+	return ca
+}
+
+// echo accepts circuits on n and echoes what they carry.
+func echo(n *node) {
+	go func() {
+		for {
+			conn, err := n.Accept(context.Background())
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+}
+
+// relayed returns the number of circuits r relays.
+func relayed(r *Router) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.relayed
+}
+
+// waitRelayed waits until r relays want circuits.
+func waitRelayed(t *testing.T, r *Router, want int) {
+	t.Helper()
+	deadline := time.Now().Add(testTimeout)
+	for relayed(r) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("relaying %d circuits, want %d", relayed(r), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	a, relay, b := newNode(t, Config{}), newNode(t, Config{}), newNode(t, Config{})
+	connect(t, a, relay)
+	connect(t, relay, b)
+	echo(b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	conn, err := a.Dial(ctx, b.rec, relay.rec.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if conn.RemoteID() != b.rec.ID {
+		t.Fatalf("connected to %s, want %s", conn.RemoteID(), b.rec.ID)
+	}
+
+	// More than a window, so transfers depend on credit flowing back through the relay
+	data := bytes.Repeat([]byte("0123456789abcdef"), defaultWindow/8)
+	go func() {
+		if _, err := conn.Write(data); err != nil {
+			t.Error(err)
+		}
+	}()
+	got := make([]byte, len(data))
+	conn.SetReadDeadline(time.Now().Add(testTimeout))
+	if _, err := io.ReadFull(conn, got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("echoed data differs")
+	}
+
+	conn.Close()
+	waitRelayed(t, relay.Router, 0)
+}
+
+func TestRoute(t *testing.T) {
+	relay, b := newNode(t, Config{}), newNode(t, Config{})
+	a := newNode(t, Config{Route: func(ctx context.Context, dst identity.ID) ([]identity.ID, error) {
+		if dst != b.rec.ID {
+			return nil, errors.New("unknown peer")
+		}
+		return []identity.ID{relay.rec.ID}, nil
+	}})
+	connect(t, a, relay)
+	connect(t, relay, b)
+	echo(b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	conn, err := a.Dial(ctx, b.rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if _, err := a.Dial(ctx, relay.rec, b.rec.ID, a.rec.ID, b.rec.ID, a.rec.ID, b.rec.ID); !errors.Is(err, ErrNoRoute) {
+		t.Fatalf("Dial over too many hops: %v, want ErrNoRoute", err)
+	}
+	if _, err := newNode(t, Config{}).Dial(ctx, b.rec); err != ErrNoRoute {
+		t.Fatalf("Dial without links: %v, want ErrNoRoute", err)
+	}
+}
+
+func TestRelayLimit(t *testing.T) {
+	a, relay, b := newNode(t, Config{}), newNode(t, Config{MaxRelayed: 1}), newNode(t, Config{})
+	connect(t, a, relay)
+	connect(t, relay, b)
+	echo(b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	first, err := a.Dial(ctx, b.rec, relay.rec.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Dial(ctx, b.rec, relay.rec.ID); !errors.Is(err, ErrRefused) || !strings.Contains(err.Error(), "relay capacity exceeded") {
+		t.Fatalf("Dial beyond the relay limit: %v, want ErrRefused", err)
+	}
+
+	// Closing the first circuit frees its slot
+	first.Close()
+	waitRelayed(t, relay.Router, 0)
+	second, err := a.Dial(ctx, b.rec, relay.rec.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second.Close()
+}
+
+func TestHopFailure(t *testing.T) {
+	a, relay, b := newNode(t, Config{}), newNode(t, Config{}), newNode(t, Config{})
+	connect(t, a, relay)
+	toB := connect(t, relay, b)
+	echo(b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	if _, err := a.Dial(ctx, a.rec, relay.rec.ID); !errors.Is(err, ErrRefused) || !strings.Contains(err.Error(), "no link to next hop") {
+		t.Fatalf("Dial through a relay without a link to the destination: %v, want ErrRefused", err)
+	}
+
+	conn, err := a.Dial(ctx, b.rec, relay.rec.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if relayed(relay.Router) != 1 {
+		t.Fatal("circuit not relayed")
+	}
+
+	// Losing the link behind the relay ends the circuit at both ends and frees the relay slot
+	toB.Close()
+	conn.SetReadDeadline(time.Now().Add(testTimeout))
+	if _, err := conn.Read(make([]byte, 1)); err == nil || errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Read after the link was lost: %v", err)
+	}
+	waitRelayed(t, relay.Router, 0)
+	if _, err := conn.Write([]byte("late")); err == nil {
+		t.Fatal("Write after the link was lost succeeded")
+	}
+}
@@ -0,0 +1,394 @@
+// Package circuit forwards streams through intermediate overlay nodes when two peers cannot connect
+// directly, e.g. browsers behind strict NATs. A circuit follows a path of hops chosen by its initiator
+// (source routing) or by Config.Route, e.g. from the DHT. Every hop only maps link-local circuit ids between
+// its neighbouring links, and the endpoints run a secure channel (Noise IK) bound to their identities over the
+// circuit, so relays can neither read nor forge the traffic they carry.
+package circuit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/p2p"
+	sncircuit "pkg.gfire.dev/supernet/proto/sncircuit/v1alpha1"
+)
+
+var (
+	// ErrClosed is returned when using a router that has been closed
+	ErrClosed = errors.New("circuit router closed")
+	// ErrCircuitClosed is returned when using a circuit that has been closed
+	ErrCircuitClosed = errors.New("circuit closed")
+	// ErrNoRoute is returned when no path to the destination is known
+	ErrNoRoute = errors.New("no route to peer")
+	// ErrRefused is returned when a hop or the destination refuses a circuit
+	ErrRefused = errors.New("circuit refused")
+	// ErrLinkLost is returned when a link along the circuit fails
+	ErrLinkLost = errors.New("circuit link lost")
+	// ErrFlowControl is returned when the remote end sends more data than granted
+	ErrFlowControl = errors.New("circuit flow control violation")
+)
+
+const (
+	// defaultMaxHops is the default limit of relays a circuit may pass through
+	defaultMaxHops = 4
+	// defaultMaxRelayed is the default limit of circuits a node relays for others
+	defaultMaxRelayed = 256
+	// defaultWindow is the default number of bytes buffered per circuit end
+	defaultWindow = 256 << 10
+	// defaultAcceptBacklog is the default number of incoming circuits waiting for Accept
+	defaultAcceptBacklog = 64
+	// defaultHandshakeTimeout is the default time limit of the end-to-end handshake
+	defaultHandshakeTimeout = 10 * time.Second
+	// maxData is the largest payload of a single data frame
+	maxData = 16 << 10
+)
+
+// Conn is an authenticated message connection to a neighbouring node.
+type Conn interface {
+	// NextMessage blocks until the next message is received or the connection is closed.
+	NextMessage() ([]byte, error)
+	// Send sends a single message.
+	Send(data []byte) error
+	// Close closes the connection.
+	Close() error
+}
+
+// RouteFunc returns the relays to pass through to reach dst, excluding dst itself.
+type RouteFunc func(ctx context.Context, dst identity.ID) ([]identity.ID, error)
+
+// Config configures a Router.
+type Config struct {
+	// Identity authenticates the node at circuit endpoints. Required.
+	Identity *identity.Identity
+	// Route finds relays to destinations without a direct link, e.g. through the DHT.
+	Route RouteFunc
+	// MaxHops limits the relays a circuit may pass through (default 4).
+	MaxHops int
+	// MaxRelayed limits the circuits relayed for other nodes (default 256).
+	MaxRelayed int
+	// Window is the number of bytes buffered per circuit end; all nodes must use the same value (default 256KiB).
+	Window int
+	// AcceptBacklog is the number of incoming circuits waiting for Accept (default 64).
+	AcceptBacklog int
+	// HandshakeTimeout bounds the end-to-end handshake of incoming circuits (default 10s).
+	HandshakeTimeout time.Duration
+}
+
+// Router relays circuits between its links and terminates circuits addressed to it.
+type Router struct {
+	self *identity.Identity
+	cfg  Config
+
+	mu sync.Mutex
+	// links holds the links to neighbouring nodes by peer ID
+	links map[identity.ID]*link
+	// relayed is the number of circuits relayed for others
+	relayed int
+
+	// incoming queues authenticated incoming circuits for Accept
+	incoming chan *SecureConn
+
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+// link is a connection to a neighbouring node.
+type link struct {
+	peer identity.ID
+	conn Conn
+	// nextID allocates ids for circuits opened on this link by the local node
+	nextID atomic.Uint64
+	// circuits maps circuit ids to their local end, guarded by Router.mu
+	circuits map[circuitKey]endpoint
+}
+
+// circuitKey identifies a circuit on a link.
+type circuitKey struct {
+	id     uint64
+	remote bool // whether the remote side of the link opened the circuit
+}
+
+// endpoint is the local end of a circuit on a link: either a stream or a relay to another link.
+type endpoint interface {
+	// receive handles a frame other than Open.
+	receive(f *sncircuit.Frame)
+	// fail terminates the endpoint after its link was lost.
+	fail(err error)
+}
+
+// relay forwards the frames of a circuit to another link.
+type relay struct {
+	r    *Router
+	out  *link
+	key  circuitKey
+	slot *relaySlot
+}
+
+// relaySlot is shared by both sides of a relayed circuit and releases its capacity once.
+type relaySlot struct {
+	released bool // guarded by Router.mu
+}
+
+// New creates a router.
+func New(cfg Config) (*Router, error) {
+	if cfg.Identity == nil {
+		return nil, errors.New("circuit: identity required")
+	}
+	if cfg.MaxHops <= 0 {
+		cfg.MaxHops = defaultMaxHops
+	}
+	if cfg.MaxRelayed <= 0 {
+		cfg.MaxRelayed = defaultMaxRelayed
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.AcceptBacklog <= 0 {
+		cfg.AcceptBacklog = defaultAcceptBacklog
+	}
+	if cfg.HandshakeTimeout <= 0 {
+		cfg.HandshakeTimeout = defaultHandshakeTimeout
+	}
+	return &Router{
+		self:      cfg.Identity,
+		cfg:       cfg,
+		links:     make(map[identity.ID]*link),
+		incoming:  make(chan *SecureConn, cfg.AcceptBacklog),
+		closeChan: make(chan struct{}),
+	}, nil
+}
+
+// AddLink serves circuits over a connection to a neighbouring node. The connection must already be
+// authenticated as belonging to peer, e.g. by the p2p or gossip handshake. A newer link to the same peer
+// replaces the old one, failing its circuits.
+func (r *Router) AddLink(peer identity.ID, conn Conn) error {
+	l := &link{peer: peer, conn: conn, circuits: make(map[circuitKey]endpoint)}
+
+	r.mu.Lock()
+	select {
+	case <-r.closeChan:
+		r.mu.Unlock()
+		conn.Close()
+		return ErrClosed
+	default:
+	}
+	old := r.links[peer]
+	r.links[peer] = l
+	r.mu.Unlock()
+
+	if old != nil {
+		old.conn.Close()
+	}
+	go r.serve(l)
+	return nil
+}
+
+// Links returns the peers with a link to the router.
+func (r *Router) Links() []identity.ID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]identity.ID, 0, len(r.links))
+	for id := range r.links {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Close closes all links and stops accepting circuits.
+func (r *Router) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.closeChan)
+
+		r.mu.Lock()
+		links := make([]*link, 0, len(r.links))
+		for _, l := range r.links {
+			links = append(links, l)
+		}
+		r.mu.Unlock()
+
+		for _, l := range links {
+			l.conn.Close()
+		}
+	})
+	return nil
+}
+
+// serve reads frames from a link until it fails, then tears down its circuits.
+func (r *Router) serve(l *link) {
+	defer r.dropLink(l)
+
+	for {
+		data, err := l.conn.NextMessage()
+		if err != nil {
+			return
+		}
+		f := &sncircuit.Frame{}
+		if err := f.UnmarshalVT(data); err != nil {
+			continue
+		}
+		r.handle(l, f)
+	}
+}
+
+// dropLink unregisters a failed link and tears down every circuit using it.
+func (r *Router) dropLink(l *link) {
+	l.conn.Close()
+
+	r.mu.Lock()
+	if r.links[l.peer] == l {
+		delete(r.links, l.peer)
+	}
+	eps := make([]endpoint, 0, len(l.circuits))
+	for key, ep := range l.circuits {
+		eps = append(eps, ep)
+		delete(l.circuits, key)
+	}
+	r.mu.Unlock()
+
+	for _, ep := range eps {
+		ep.fail(ErrLinkLost)
+	}
+}
+
+// handle dispatches a frame received on a link.
+func (r *Router) handle(l *link, f *sncircuit.Frame) {
+	key := circuitKey{id: f.Circuit, remote: f.Forward}
+	if open := f.GetOpen(); open != nil {
+		if key.remote {
+			r.handleOpen(l, key, open)
+		}
+		return
+	}
+
+	r.mu.Lock()
+	ep := l.circuits[key]
+	if f.GetClose() != nil {
+		delete(l.circuits, key)
+	}
+	r.mu.Unlock()
+
+	if ep != nil {
+		ep.receive(f)
+	}
+}
+
+// handleOpen accepts a circuit addressed to the router or extends it to the next hop.
+func (r *Router) handleOpen(l *link, key circuitKey, open *sncircuit.Open) {
+	if len(open.Path) == 0 {
+		r.accept(l, key)
+		return
+	}
+	if len(open.Path) > r.cfg.MaxHops {
+		sendFrame(l, key, &sncircuit.Frame{Body: closeBody("too many hops")})
+		return
+	}
+	next, err := p2p.IDFromBytes(open.Path[0])
+	if err != nil {
+		sendFrame(l, key, &sncircuit.Frame{Body: closeBody("invalid path")})
+		return
+	}
+
+	r.mu.Lock()
+	out := r.links[next]
+	var reason string
+	switch {
+	case out == nil || out == l:
+		reason = "no link to next hop"
+	case r.relayed >= r.cfg.MaxRelayed:
+		reason = "relay capacity exceeded"
+	case l.circuits[key] != nil:
+		reason = "duplicate circuit"
+	}
+	if reason != "" {
+		r.mu.Unlock()
+		sendFrame(l, key, &sncircuit.Frame{Body: closeBody(reason)})
+		return
+	}
+	outKey := circuitKey{id: out.nextID.Add(1)}
+	slot := &relaySlot{}
+	l.circuits[key] = &relay{r: r, out: out, key: outKey, slot: slot}
+	out.circuits[outKey] = &relay{r: r, out: l, key: key, slot: slot}
+	r.relayed++
+	r.mu.Unlock()
+
+	if err := sendFrame(out, outKey, &sncircuit.Frame{Body: &sncircuit.Frame_Open{Open: &sncircuit.Open{Path: open.Path[1:]}}}); err != nil {
+		r.mu.Lock()
+		delete(l.circuits, key)
+		r.unrelay(out, outKey, slot)
+		r.mu.Unlock()
+		sendFrame(l, key, &sncircuit.Frame{Body: closeBody("next hop unreachable")})
+	}
+}
+
+// unrelay removes the far side of a relayed circuit and releases its relay slot. The caller must hold mu.
+func (r *Router) unrelay(l *link, key circuitKey, slot *relaySlot) {
+	if rl, ok := l.circuits[key].(*relay); ok && rl.slot == slot {
+		delete(l.circuits, key)
+	}
+	if !slot.released {
+		slot.released = true
+		r.relayed--
+	}
+}
+
+// receive forwards a frame to the other side of the relay.
+func (rl *relay) receive(f *sncircuit.Frame) {
+	if f.GetClose() != nil {
+		rl.r.mu.Lock()
+		rl.r.unrelay(rl.out, rl.key, rl.slot)
+		rl.r.mu.Unlock()
+	}
+	sendFrame(rl.out, rl.key, f)
+}
+
+// fail closes the other side of the relay after this side's link was lost.
+func (rl *relay) fail(err error) {
+	rl.r.mu.Lock()
+	rl.r.unrelay(rl.out, rl.key, rl.slot)
+	rl.r.mu.Unlock()
+	sendFrame(rl.out, rl.key, &sncircuit.Frame{Body: closeBody(err.Error())})
+}
+
+// route returns the full path to dst: via if given, a direct link, or the relays found by Config.Route.
+func (r *Router) route(ctx context.Context, dst identity.ID, via []identity.ID) ([]identity.ID, error) {
+	if len(via) == 0 {
+		r.mu.Lock()
+		_, direct := r.links[dst]
+		r.mu.Unlock()
+		if !direct {
+			if r.cfg.Route == nil {
+				return nil, ErrNoRoute
+			}
+			var err error
+			if via, err = r.cfg.Route(ctx, dst); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrNoRoute, err)
+			}
+		}
+	}
+	if len(via) > r.cfg.MaxHops {
+		return nil, fmt.Errorf("%w: path exceeds %d hops", ErrNoRoute, r.cfg.MaxHops)
+	}
+	return append(append([]identity.ID(nil), via...), dst), nil
+}
+
+// closeBody returns a Close frame body with the given reason.
+func closeBody(reason string) *sncircuit.Frame_Close {
+	return &sncircuit.Frame_Close{Close: &sncircuit.Close{Error: reason}}
+}
+
+// sendFrame sends a frame for the circuit identified by key on l.
+func sendFrame(l *link, key circuitKey, f *sncircuit.Frame) error {
+	f.Circuit = key.id
+	f.Forward = !key.remote
+	data, err := f.MarshalVT()
+	if err != nil {
+		return err
+	}
+	return l.conn.Send(data)
+}
@@ -0,0 +1,126 @@
+package circuit
+
+import (
+	"context"
+	"net"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/msgconn"
+	sncircuit "pkg.gfire.dev/supernet/proto/sncircuit/v1alpha1"
+	"pkg.gfire.dev/supernet/secure"
+)
+
+// SecureConn is an end-to-end encrypted circuit to an authenticated peer.
+// Relays only see the channel's ciphertext.
+type SecureConn struct {
+	*secure.Conn
+}
+
+// Dial opens a circuit to remote through the relays in via. Without via, a direct link is used if there
+// is one, otherwise Config.Route is asked for relays. The remote record provides the key the end-to-end
+// handshake is authenticated against.
+func (r *Router) Dial(ctx context.Context, remote *identity.Record, via ...identity.ID) (*SecureConn, error) {
+	path, err := r.route(ctx, remote.ID, via)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	select {
+	case <-r.closeChan:
+		r.mu.Unlock()
+		return nil, ErrClosed
+	default:
+	}
+	l := r.links[path[0]]
+	if l == nil {
+		r.mu.Unlock()
+		return nil, ErrNoRoute
+	}
+	s := newStream(r, l, circuitKey{id: l.nextID.Add(1)})
+	r.mu.Unlock()
+
+	hops := make([][]byte, 0, len(path)-1)
+	for _, id := range path[1:] {
+		hops = append(hops, id.Bytes())
+	}
+	if err := sendFrame(l, s.key, &sncircuit.Frame{Body: &sncircuit.Frame_Open{Open: &sncircuit.Open{Path: hops}}}); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if err := s.waitOpened(ctx); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	nc := r.netConn(s, remote.ID)
+	conn, err := secure.Client(ctx, nc, secure.Config{Identity: r.self, RemoteRecord: remote})
+	if err != nil {
+		return nil, err
+	}
+	return &SecureConn{Conn: conn}, nil
+}
+
+// Accept waits for the next incoming circuit that completed its end-to-end handshake.
+func (r *Router) Accept(ctx context.Context) (*SecureConn, error) {
+	select {
+	case conn := <-r.incoming:
+		return conn, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-r.closeChan:
+		return nil, ErrClosed
+	}
+}
+
+// accept terminates a circuit addressed to the router and authenticates its initiator in the background.
+func (r *Router) accept(l *link, key circuitKey) {
+	r.mu.Lock()
+	if l.circuits[key] != nil {
+		r.mu.Unlock()
+		sendFrame(l, key, &sncircuit.Frame{Body: closeBody("duplicate circuit")})
+		return
+	}
+	s := newStream(r, l, key)
+	s.opened = true
+	r.mu.Unlock()
+
+	if err := sendFrame(l, key, &sncircuit.Frame{Body: &sncircuit.Frame_Opened{Opened: &sncircuit.Opened{}}}); err != nil {
+		s.Close()
+		return
+	}
+	go r.handshake(s)
+}
+
+// handshake runs the responder side of the end-to-end handshake and queues the connection for Accept.
+func (r *Router) handshake(s *stream) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.HandshakeTimeout)
+	defer cancel()
+
+	conn, err := secure.Server(ctx, r.netConn(s, identity.ID{}), secure.Config{Identity: r.self})
+	if err != nil {
+		return
+	}
+
+	sc := &SecureConn{Conn: conn}
+	select {
+	case r.incoming <- sc:
+	default:
+		// Accept is not keeping up
+		sc.Close()
+	}
+}
+
+// netConn adapts a stream to the net.Conn the handshake runs over.
+func (r *Router) netConn(s *stream, remote identity.ID) net.Conn {
+	return msgconn.NetConn(s, msgconn.Options{
+		LocalAddr:  msgconn.Addr{Net: "circuit", Addr: r.self.ID().String()},
+		RemoteAddr: msgconn.Addr{Net: "circuit", Addr: remote.String()},
+		MaxMessage: maxData,
+	})
+}
+
+// RemoteAddr returns the remote circuit address, the remote peer ID.
+func (c *SecureConn) RemoteAddr() net.Addr {
+	return msgconn.Addr{Net: "circuit", Addr: c.RemoteID().String()}
+}
@@ -0,0 +1,226 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	sncircuit "pkg.gfire.dev/supernet/proto/sncircuit/v1alpha1"
+)
+
+// stream is the local end of a circuit. It carries messages with end-to-end flow control: relays forward
+// frames as they arrive, so each end grants the other credit for what it is willing to buffer.
+type stream struct {
+	r    *Router
+	link *link
+	key  circuitKey
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// queue holds received messages not yet consumed
+	queue [][]byte
+	// buffered is the number of received bytes not yet consumed
+	buffered int
+	// unacked is the number of consumed bytes not yet granted back to the sender
+	unacked int
+	// credit is the number of bytes that may be sent before the remote end grants more
+	credit int
+	// opened is set once the destination accepted the circuit
+	opened bool
+	// closed is set once Close was called
+	closed bool
+	// err is set once the circuit was closed by the remote end or failed
+	err error
+	// changed is closed and replaced whenever data or credit arrives or the state changes
+	changed chan struct{}
+
+	// sendMu serializes senders waiting for credit
+	sendMu sync.Mutex
+}
+
+// newStream creates a stream for the circuit identified by key on l and registers it.
+// The caller must hold Router.mu.
+func newStream(r *Router, l *link, key circuitKey) *stream {
+	s := &stream{r: r, link: l, key: key, credit: r.cfg.Window, changed: make(chan struct{})}
+	l.circuits[key] = s
+	return s
+}
+
+// NextMessage blocks until the next message is received.
+// Returns io.EOF once the remote end closed the circuit and all messages were consumed.
+func (s *stream) NextMessage() ([]byte, error) {
+	s.mu.Lock()
+	for len(s.queue) == 0 {
+		if s.closed {
+			s.mu.Unlock()
+			return nil, ErrCircuitClosed
+		}
+		if s.err != nil {
+			err := s.err
+			s.mu.Unlock()
+			return nil, err
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		<-changed
+		s.mu.Lock()
+	}
+
+	msg := s.queue[0]
+	s.queue[0] = nil
+	s.queue = s.queue[1:]
+	s.buffered -= len(msg)
+	s.unacked += len(msg)
+
+	// Grant credit in batches of half the window to limit the number of credit frames
+	var grant int
+	if s.unacked >= s.r.cfg.Window/2 && s.err == nil {
+		grant, s.unacked = s.unacked, 0
+	}
+	s.mu.Unlock()
+
+	if grant > 0 {
+		sendFrame(s.link, s.key, &sncircuit.Frame{Body: &sncircuit.Frame_Credit{Credit: &sncircuit.Credit{Bytes: uint32(grant)}}})
+	}
+	return msg, nil
+}
+
+// Send sends a message of at most maxData bytes, blocking while the remote end has no room for it.
+func (s *stream) Send(data []byte) error {
+	if len(data) > maxData {
+		return errors.New("circuit: message too large")
+	}
+
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	s.mu.Lock()
+	for s.credit < len(data) || !s.opened {
+		if err := s.stateErr(); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		<-changed
+		s.mu.Lock()
+	}
+	if err := s.stateErr(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.credit -= len(data)
+	s.mu.Unlock()
+
+	return sendFrame(s.link, s.key, &sncircuit.Frame{Body: &sncircuit.Frame_Data{Data: data}})
+}
+
+// Close closes the circuit in both directions.
+func (s *stream) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	notify := s.err == nil
+	s.broadcast()
+	s.mu.Unlock()
+
+	s.r.mu.Lock()
+	if s.link.circuits[s.key] == s {
+		delete(s.link.circuits, s.key)
+	}
+	s.r.mu.Unlock()
+
+	if notify {
+		sendFrame(s.link, s.key, &sncircuit.Frame{Body: closeBody("")})
+	}
+	return nil
+}
+
+// waitOpened blocks until the destination accepted the circuit.
+func (s *stream) waitOpened(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() {
+		s.mu.Lock()
+		s.broadcast()
+		s.mu.Unlock()
+	})
+	defer stop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for !s.opened {
+		if err := s.stateErr(); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		<-changed
+		s.mu.Lock()
+	}
+	return nil
+}
+
+// receive handles a frame from the remote end.
+func (s *stream) receive(f *sncircuit.Frame) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch body := f.Body.(type) {
+	case *sncircuit.Frame_Opened:
+		s.opened = true
+	case *sncircuit.Frame_Data:
+		if s.closed || s.err != nil {
+			return
+		}
+		if s.buffered+len(body.Data) > s.r.cfg.Window {
+			s.err = ErrFlowControl
+			go s.Close()
+			break
+		}
+		s.queue = append(s.queue, body.Data)
+		s.buffered += len(body.Data)
+	case *sncircuit.Frame_Credit:
+		s.credit += int(body.Credit.Bytes)
+	case *sncircuit.Frame_Close:
+		if s.err == nil {
+			s.err = io.EOF
+			if reason := body.Close.Error; reason != "" {
+				s.err = errors.Join(ErrRefused, errors.New(reason))
+				if s.opened {
+					s.err = errors.Join(ErrLinkLost, errors.New(reason))
+				}
+			}
+		}
+	}
+	s.broadcast()
+}
+
+// fail terminates the stream after its link was lost.
+func (s *stream) fail(err error) {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.broadcast()
+	s.mu.Unlock()
+}
+
+// stateErr returns the error ending the circuit for senders, if any. The caller must hold mu.
+func (s *stream) stateErr() error {
+	if s.closed || s.err == io.EOF {
+		return ErrCircuitClosed
+	}
+	return s.err
+}
+
+// broadcast wakes all goroutines waiting for a change. The caller must hold mu.
+func (s *stream) broadcast() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
@@ -0,0 +1,381 @@
+//go:build !js
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+	"gopkg.in/yaml.v3"
+
+	"pkg.gfire.dev/supernet/backplane"
+	"pkg.gfire.dev/supernet/discovery"
+	"pkg.gfire.dev/supernet/mux"
+	"pkg.gfire.dev/supernet/policy"
+	"pkg.gfire.dev/supernet/relay"
+	"pkg.gfire.dev/supernet/rendezvous"
+	"pkg.gfire.dev/supernet/routing"
+	"pkg.gfire.dev/supernet/turn"
+)
+
+// config is the relay configuration file. It is YAML, or JSON as a subset of it.
+type config struct {
+	// Listen is the TCP address of the HTTP server
+	Listen string `json:"listen"`
+	// Path is the URL path of the relay WebSocket endpoint
+	Path string `json:"path"`
+	// TLS enables HTTPS when set
+	TLS *tlsConfig `json:"tls"`
+	// Auth configures client authentication; without it clients are anonymous and accounted by IP address
+	Auth *authConfig `json:"auth"`
+	// AllowedOrigins are the origin patterns of web pages allowed to connect, besides same-origin pages
+	AllowedOrigins []string `json:"allowed_origins"`
+	// Rules decide which targets may be reached; without rules or a rules file every target is denied
+	Rules json.RawMessage `json:"rules"`
+	// RulesFile is a JSON routing rules file used instead of Rules
+	RulesFile string `json:"rules_file"`
+	// Policy decides which client identities may reach which targets, on top of the rules
+	Policy json.RawMessage `json:"policy"`
+	// PolicyFile is a JSON or HCL (.hcl) policy file used instead of Policy
+	PolicyFile string `json:"policy_file"`
+	// Deny lists address ranges that may never be reached
+	Deny []string `json:"deny"`
+	// AllowPrivate permits loopback, private and link-local targets
+	AllowPrivate bool `json:"allow_private"`
+	// Limits bounds the resources of each client identity
+	Limits limitsConfig `json:"limits"`
+	// Mux configures the multiplexer; window and max_frame must match the clients
+	Mux muxConfig `json:"mux"`
+	// DialTimeout limits connecting to a target
+	DialTimeout duration `json:"dial_timeout"`
+	// ShutdownTimeout is the time connections may take to finish after a shutdown signal
+	ShutdownTimeout duration `json:"shutdown_timeout"`
+	// MetricsPath enables Prometheus metrics at this URL path of the HTTP server
+	MetricsPath string `json:"metrics_path"`
+	// MetricsPerTarget labels tunnel metrics with their target address
+	MetricsPerTarget bool `json:"metrics_per_target"`
+	// Tracing exports OpenTelemetry spans of relay tunnels when set
+	Tracing *tracingConfig `json:"tracing"`
+	// TURN issues credentials of a TURN server operated alongside the relay when set
+	TURN *turnConfig `json:"turn"`
+	// Tracker serves a discovery tracker for browser peers when set
+	Tracker *trackerConfig `json:"tracker"`
+	// Rendezvous serves a rendezvous server for browser peers when set
+	Rendezvous *rendezvousConfig `json:"rendezvous"`
+	// Backplane joins the rendezvous servers of several relay instances when set
+	Backplane *backplaneConfig `json:"backplane"`
+	// LogLevel is debug, info, warn or error
+	LogLevel string `json:"log_level"`
+	// LogLevels overrides the level of single packages, e.g. "mux=debug,p2p=info"
+	LogLevels string `json:"log_levels"`
+}
+
+type tlsConfig struct {
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+}
+
+type authConfig struct {
+	// TicketKey is the base64-encoded HMAC key relay tickets are signed with
+	TicketKey string `json:"ticket_key"`
+	// TicketKeyFile holds the raw key instead, keeping it out of the configuration
+	TicketKeyFile string `json:"ticket_key_file"`
+}
+
+type limitsConfig struct {
+	MaxConns    int      `json:"max_conns"`
+	MaxTunnels  int      `json:"max_tunnels"`
+	OpenRate    float64  `json:"open_rate"`
+	Bandwidth   int      `json:"bandwidth"`
+	Burst       int      `json:"burst"`
+	IdleTimeout duration `json:"idle_timeout"`
+}
+
+type tracingConfig struct {
+	// Endpoint is the URL of the OTLP/HTTP traces endpoint
+	Endpoint    string            `json:"endpoint"`
+	Headers     map[string]string `json:"headers"`
+	ServiceName string            `json:"service_name"`
+	SampleRatio float64           `json:"sample_ratio"`
+}
+
+type turnConfig struct {
+	// Path is the URL path credentials are issued at
+	Path string `json:"path"`
+	// URLs are the TURN server URLs handed out to clients
+	URLs []string `json:"urls"`
+	// STUN are STUN server URLs handed out without credentials
+	STUN []string `json:"stun"`
+	// Secret is the static-auth-secret shared with the TURN server
+	Secret string `json:"secret"`
+	// SecretFile holds the secret instead, keeping it out of the configuration
+	SecretFile string `json:"secret_file"`
+	// TTL is the validity period of issued credentials
+	TTL duration `json:"ttl"`
+}
+
+type trackerConfig struct {
+	// Path is the URL path of the tracker
+	Path string `json:"path"`
+	// TTL is the time announcements stay listed
+	TTL duration `json:"ttl"`
+	// MaxPeers limits the peers listed per topic
+	MaxPeers int `json:"max_peers"`
+	// MaxTopics limits the number of topics
+	MaxTopics int `json:"max_topics"`
+}
+
+type rendezvousConfig struct {
+	// Path is the URL path of the rendezvous WebSocket endpoint
+	Path string `json:"path"`
+	// MaxNamespaces limits the namespaces a client may register in and watch
+	MaxNamespaces int `json:"max_namespaces"`
+	// RequestRate limits the requests per second of a client
+	RequestRate float64 `json:"request_rate"`
+}
+
+type backplaneConfig struct {
+	// Redis is the URL of the Redis server, redis://[[user]:password@]host[:port] or rediss:// for TLS
+	Redis string `json:"redis"`
+	// Prefix separates deployments sharing a Redis server
+	Prefix string `json:"prefix"`
+}
+
+type muxConfig struct {
+	Window   int `json:"window"`
+	MaxFrame int `json:"max_frame"`
+}
+
+// duration is a time.Duration written as a string such as "30s".
+type duration time.Duration
+
+// UnmarshalJSON parses a duration string.
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+// defaultConfig returns the configuration used for settings missing from the file and flags.
+func defaultConfig() *config {
+	return &config{
+		Listen:          ":8080",
+		Path:            "/relay",
+		DialTimeout:     duration(10 * time.Second),
+		ShutdownTimeout: duration(30 * time.Second),
+		LogLevel:        "info",
+	}
+}
+
+// loadConfig reads a configuration file over cfg. The YAML is converted to JSON first, so the file uses
+// the JSON names throughout, including routing rules in the format of routing.Parse.
+func loadConfig(path string, cfg *config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if doc == nil {
+		return nil
+	}
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// serverConfig builds the relay server configuration.
+func (cfg *config) serverConfig(log *slog.Logger) (relay.Config, error) {
+	rc := relay.Config{
+		AllowPrivate: cfg.AllowPrivate,
+		Limits: relay.Limits{
+			MaxConns:    cfg.Limits.MaxConns,
+			MaxTunnels:  cfg.Limits.MaxTunnels,
+			OpenRate:    cfg.Limits.OpenRate,
+			Bandwidth:   cfg.Limits.Bandwidth,
+			Burst:       cfg.Limits.Burst,
+			IdleTimeout: time.Duration(cfg.Limits.IdleTimeout),
+		},
+		Dialer:        &net.Dialer{Timeout: time.Duration(cfg.DialTimeout)},
+		Mux:           mux.Config{Window: cfg.Mux.Window, MaxFrame: cfg.Mux.MaxFrame},
+		AcceptOptions: &websocket.AcceptOptions{OriginPatterns: cfg.AllowedOrigins},
+		Logger:        log,
+	}
+
+	switch {
+	case cfg.RulesFile != "" && cfg.Rules != nil:
+		return rc, errors.New("rules and rules_file are mutually exclusive")
+	case cfg.RulesFile != "":
+		rules, err := routing.Load(cfg.RulesFile)
+		if err != nil {
+			return rc, err
+		}
+		rc.Rules = rules
+	case cfg.Rules != nil:
+		rules, err := routing.Parse(bytes.NewReader(cfg.Rules))
+		if err != nil {
+			return rc, fmt.Errorf("rules: %w", err)
+		}
+		rc.Rules = rules
+	}
+	if rc.Rules == nil {
+		log.Warn("no routing rules configured, every target is denied")
+	}
+
+	switch {
+	case cfg.PolicyFile != "" && cfg.Policy != nil:
+		return rc, errors.New("policy and policy_file are mutually exclusive")
+	case cfg.PolicyFile != "":
+		p, err := policy.Load(cfg.PolicyFile)
+		if err != nil {
+			return rc, err
+		}
+		rc.Policy = policy.NewEnforcer(policy.Config{Policy: p})
+	case cfg.Policy != nil:
+		p, err := policy.Parse(bytes.NewReader(cfg.Policy))
+		if err != nil {
+			return rc, fmt.Errorf("policy: %w", err)
+		}
+		rc.Policy = policy.NewEnforcer(policy.Config{Policy: p})
+	}
+
+	for _, s := range cfg.Deny {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return rc, fmt.Errorf("deny: %w", err)
+		}
+		rc.Deny = append(rc.Deny, p)
+	}
+
+	if cfg.Auth != nil {
+		key, err := cfg.Auth.key()
+		if err != nil {
+			return rc, err
+		}
+		rc.Authenticate = relay.TicketAuth(key)
+	}
+	return rc, nil
+}
+
+// key returns the ticket key.
+func (a *authConfig) key() ([]byte, error) {
+	switch {
+	case a.TicketKey != "" && a.TicketKeyFile != "":
+		return nil, errors.New("auth: ticket_key and ticket_key_file are mutually exclusive")
+	case a.TicketKeyFile != "":
+		key, err := os.ReadFile(a.TicketKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("auth: %w", err)
+		}
+		if len(key) == 0 {
+			return nil, errors.New("auth: empty ticket key")
+		}
+		return key, nil
+	case a.TicketKey != "":
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(a.TicketKey))
+		if err != nil {
+			return nil, fmt.Errorf("auth: ticket_key: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, errors.New("auth: no ticket key configured")
+	}
+}
+
+// handlerConfig builds the configuration of the TURN credentials handler. Clients authenticate like
+// relay clients.
+func (t *turnConfig) handlerConfig(rc relay.Config, origins []string) (turn.HandlerConfig, error) {
+	hc := turn.HandlerConfig{
+		URLs:           t.URLs,
+		STUN:           t.STUN,
+		TTL:            time.Duration(t.TTL),
+		Authenticate:   rc.Authenticate,
+		AllowedOrigins: origins,
+	}
+	switch {
+	case len(t.URLs) == 0:
+		return hc, errors.New("turn: no urls configured")
+	case t.Secret != "" && t.SecretFile != "":
+		return hc, errors.New("turn: secret and secret_file are mutually exclusive")
+	case t.SecretFile != "":
+		secret, err := os.ReadFile(t.SecretFile)
+		if err != nil {
+			return hc, fmt.Errorf("turn: %w", err)
+		}
+		hc.Secret = bytes.TrimSpace(secret)
+	default:
+		hc.Secret = []byte(t.Secret)
+	}
+	if len(hc.Secret) == 0 {
+		return hc, errors.New("turn: no secret configured")
+	}
+	return hc, nil
+}
+
+// handlerConfig builds the configuration of the discovery tracker. Clients authenticate like relay clients.
+func (t *trackerConfig) handlerConfig(rc relay.Config, origins []string) discovery.TrackerConfig {
+	return discovery.TrackerConfig{
+		TTL:            time.Duration(t.TTL),
+		MaxPeers:       t.MaxPeers,
+		MaxTopics:      t.MaxTopics,
+		Authenticate:   rc.Authenticate,
+		AllowedOrigins: origins,
+	}
+}
+
+// serverConfig builds the rendezvous server configuration.
+func (r *rendezvousConfig) serverConfig(origins []string) rendezvous.ServerConfig {
+	return rendezvous.ServerConfig{
+		MaxNamespaces: r.MaxNamespaces,
+		RequestRate:   r.RequestRate,
+		AcceptOptions: &websocket.AcceptOptions{OriginPatterns: origins},
+	}
+}
+
+// open connects the backplane.
+func (b *backplaneConfig) open() (backplane.Backplane, error) {
+	if b.Redis == "" {
+		return nil, errors.New("backplane: no redis url configured")
+	}
+	bp, err := backplane.NewRedis(backplane.RedisConfig{URL: b.Redis, Prefix: b.Prefix})
+	if err != nil {
+		return nil, fmt.Errorf("backplane: %w", err)
+	}
+	return bp, nil
+}
+
+// logLevel parses the log level.
+func (cfg *config) logLevel() (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		return 0, fmt.Errorf("log_level: %w", err)
+	}
+	return level, nil
+}
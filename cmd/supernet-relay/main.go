@@ -0,0 +1,276 @@
+//go:build !js
+
+// Command supernet-relay runs a relay server tunneling TCP and UDP connections of browser clients, see
+// package relay.
+//
+// Usage:
+//
+//	supernet-relay [-config relay.yaml] [-listen :8443] [-tls-cert cert.pem -tls-key key.pem]
+//
+// The configuration file is YAML:
+//
+//	listen: ":8443"
+//	path: /relay
+//	tls:
+//	  cert: /etc/supernet/cert.pem
+//	  key: /etc/supernet/key.pem
+//	auth:
+//	  ticket_key_file: /etc/supernet/ticket.key
+//	allowed_origins: ["app.example.com"]
+//	rules:
+//	  rules:
+//	    - domains: ["*.corp.example"]
+//	      ports: ["22", "443"]
+//	  default: {reject: true}
+//	policy_file: /etc/supernet/policy.hcl
+//	deny: ["169.254.0.0/16"]
+//	limits:
+//	  max_conns: 4
+//	  max_tunnels: 64
+//	  open_rate: 10
+//	  bandwidth: 1048576
+//	  idle_timeout: 5m
+//	metrics_path: /metrics
+//	tracing:
+//	  endpoint: http://localhost:4318/v1/traces
+//	turn:
+//	  path: /turn
+//	  urls: ["turn:turn.example.com:3478", "turns:turn.example.com:5349?transport=tcp"]
+//	  secret_file: /etc/supernet/turn.secret
+//	  ttl: 1h
+//	tracker:
+//	  path: /tracker
+//	  ttl: 2m
+//	rendezvous:
+//	  path: /rendezvous
+//	backplane:
+//	  redis: redis://redis.internal:6379
+//	shutdown_timeout: 30s
+//	log_level: info
+//	log_levels: mux=debug
+//
+// Flags override the file. Relays behind a load balancer share a backplane, so peers registered at the
+// rendezvous endpoints of different instances can signal each other. On SIGINT or SIGTERM the relay stops accepting clients and gives open
+// connections shutdown_timeout to finish before closing them.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"pkg.gfire.dev/supernet/discovery"
+	"pkg.gfire.dev/supernet/logging"
+	"pkg.gfire.dev/supernet/metrics"
+	"pkg.gfire.dev/supernet/relay"
+	"pkg.gfire.dev/supernet/rendezvous"
+	"pkg.gfire.dev/supernet/tracing"
+	"pkg.gfire.dev/supernet/turn"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "supernet-relay:", err)
+		os.Exit(1)
+	}
+}
+
+// run loads the configuration and serves until a shutdown signal.
+func run() error {
+	var (
+		configPath = flag.String("config", "", "configuration file (YAML)")
+		listen     = flag.String("listen", "", "listen address (default :8080)")
+		path       = flag.String("path", "", "URL path of the relay endpoint (default /relay)")
+		tlsCert    = flag.String("tls-cert", "", "TLS certificate file, enables HTTPS with -tls-key")
+		tlsKey     = flag.String("tls-key", "", "TLS key file")
+		rulesFile  = flag.String("rules", "", "routing rules file (JSON)")
+		logLevel   = flag.String("log-level", "", "log level: debug, info, warn or error (default info)")
+	)
+	flag.Parse()
+
+	cfg := defaultConfig()
+	if *configPath != "" {
+		if err := loadConfig(*configPath, cfg); err != nil {
+			return err
+		}
+	}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "listen":
+			cfg.Listen = *listen
+		case "path":
+			cfg.Path = *path
+		case "tls-cert", "tls-key":
+			cfg.TLS = &tlsConfig{Cert: *tlsCert, Key: *tlsKey}
+		case "rules":
+			cfg.Rules, cfg.RulesFile = nil, *rulesFile
+		case "log-level":
+			cfg.LogLevel = *logLevel
+		}
+	})
+
+	level, err := cfg.logLevel()
+	if err != nil {
+		return err
+	}
+	// The handler passes everything; levels are applied per package by the logging package
+	log := logging.For("relay")
+	logging.SetHandler(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug - 4}))
+	logging.SetLevel("", level)
+	if err := logging.ParseLevels(cfg.LogLevels); err != nil {
+		return err
+	}
+	rc, err := cfg.serverConfig(log)
+	if err != nil {
+		return err
+	}
+
+	if cfg.MetricsPath != "" {
+		m, err := metrics.New(metrics.Config{PerTarget: cfg.MetricsPerTarget})
+		if err != nil {
+			return err
+		}
+		rc.Observer = m.Relay()
+	}
+
+	if cfg.Tracing != nil {
+		if cfg.Tracing.ServiceName == "" {
+			cfg.Tracing.ServiceName = "supernet-relay"
+		}
+		shutdown, err := tracing.Setup(tracing.Config{
+			ServiceName: cfg.Tracing.ServiceName,
+			Endpoint:    cfg.Tracing.Endpoint,
+			Headers:     cfg.Tracing.Headers,
+			SampleRatio: cfg.Tracing.SampleRatio,
+		})
+		if err != nil {
+			return fmt.Errorf("tracing: %w", err)
+		}
+		defer shutdown(context.Background())
+	}
+
+	// Optional endpoints served next to the relay, by URL path
+	handlers := make(map[string]http.Handler)
+	if cfg.TURN != nil {
+		if cfg.TURN.Path == "" {
+			cfg.TURN.Path = "/turn"
+		}
+		hc, err := cfg.TURN.handlerConfig(rc, cfg.AllowedOrigins)
+		if err != nil {
+			return err
+		}
+		handlers[cfg.TURN.Path] = turn.Handler(hc)
+	}
+	if cfg.Tracker != nil {
+		if cfg.Tracker.Path == "" {
+			cfg.Tracker.Path = "/tracker"
+		}
+		handlers[cfg.Tracker.Path] = discovery.TrackerHandler(cfg.Tracker.handlerConfig(rc, cfg.AllowedOrigins))
+	}
+	if cfg.Backplane != nil && cfg.Rendezvous == nil {
+		return errors.New("backplane: requires rendezvous")
+	}
+	if cfg.Rendezvous != nil {
+		if cfg.Rendezvous.Path == "" {
+			cfg.Rendezvous.Path = "/rendezvous"
+		}
+		sc := cfg.Rendezvous.serverConfig(cfg.AllowedOrigins)
+		if cfg.Backplane != nil {
+			bp, err := cfg.Backplane.open()
+			if err != nil {
+				return err
+			}
+			defer bp.Close()
+			sc.Backplane = bp
+		}
+		handlers[cfg.Rendezvous.Path] = rendezvous.NewServer(sc)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return serve(ctx, cfg, relay.NewServer(rc), handlers, log)
+}
+
+// serve runs the HTTP server until ctx ends, then shuts it down gracefully.
+func serve(ctx context.Context, cfg *config, rs *relay.Server, handlers map[string]http.Handler, log *slog.Logger) error {
+	// Relay connections are hijacked WebSockets that http.Server.Shutdown does not wait for, so they are
+	// tracked here and cancelled through their base context once the shutdown timeout expires
+	base, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var conns sync.WaitGroup
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(cfg.Path, func(w http.ResponseWriter, r *http.Request) {
+		conns.Add(1)
+		defer conns.Done()
+		rs.ServeHTTP(w, r)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	if cfg.MetricsPath != "" {
+		mux.Handle(cfg.MetricsPath, metrics.Handler(nil))
+	}
+	for path, h := range handlers {
+		mux.Handle(path, h)
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return base },
+	}
+	l, err := net.Listen("tcp", cfg.Listen)
+	if err != nil {
+		return err
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		if cfg.TLS != nil {
+			log.Info("relay listening", "addr", l.Addr().String(), "path", cfg.Path, "tls", true)
+			served <- srv.ServeTLS(l, cfg.TLS.Cert, cfg.TLS.Key)
+		} else {
+			log.Info("relay listening", "addr", l.Addr().String(), "path", cfg.Path, "tls", false)
+			served <- srv.Serve(l)
+		}
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info("relay shutting down", "timeout", time.Duration(cfg.ShutdownTimeout).String())
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout))
+	defer cancelShutdown()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		conns.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		log.Warn("closing remaining relay connections")
+		cancel()
+		<-done
+	}
+	log.Info("relay stopped")
+	return nil
+}
@@ -0,0 +1,50 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMessageTooLarge is returned by Reassembler.Add when a message outgrows its limit
+var ErrMessageTooLarge = errors.New("codec: message too large")
+
+// Reassembler joins the fragments of messages split across MuxDataMore and MuxData frames, or any
+// other scheme marking all but the last fragment of a message. The zero value is ready to use.
+type Reassembler struct {
+	// MaxMessage limits the size of a reassembled message, 0 for no limit.
+	MaxMessage int
+
+	// partial accumulates the fragments of the current message
+	partial []byte
+}
+
+// Add adds the next fragment and returns the complete message after its final fragment, or nil before.
+// A message of zero length is returned as an empty, non-nil slice. Fragments are copied, so the message
+// does not alias them.
+func (r *Reassembler) Add(fragment []byte, final bool) ([]byte, error) {
+	if r.MaxMessage > 0 && len(r.partial)+len(fragment) > r.MaxMessage {
+		r.partial = nil
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrMessageTooLarge, r.MaxMessage)
+	}
+	r.partial = append(r.partial, fragment...)
+	if !final {
+		return nil, nil
+	}
+
+	msg := r.partial
+	r.partial = nil
+	if msg == nil {
+		msg = []byte{}
+	}
+	return msg, nil
+}
+
+// Pending returns the number of bytes of the incomplete message.
+func (r *Reassembler) Pending() int {
+	return len(r.partial)
+}
+
+// Reset discards the incomplete message.
+func (r *Reassembler) Reset() {
+	r.partial = nil
+}
@@ -0,0 +1,62 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// FuzzReassembler splits data into fragments at the positions given by its first bytes and checks that
+// they reassemble into the rest of data.
+func FuzzReassembler(f *testing.F) {
+	f.Add([]byte("\x00single fragment"))
+	f.Add([]byte("\x02\x03\x04fragmented message"))
+	f.Add([]byte("\x03\x00\x00\x00empty fragments"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) == 0 {
+			return
+		}
+		cuts := int(data[0]) % 8
+		if len(data) < 1+cuts {
+			return
+		}
+		sizes, msg := data[1:1+cuts], data[1+cuts:]
+
+		var r Reassembler
+		rest := msg
+		for _, size := range sizes {
+			n := min(int(size), len(rest))
+			if out, err := r.Add(rest[:n], false); err != nil || out != nil {
+				t.Fatalf("reassembler completed a message early: %q, %v", out, err)
+			}
+			rest = rest[n:]
+		}
+		out, err := r.Add(rest, true)
+		if err != nil || !bytes.Equal(out, msg) || r.Pending() != 0 {
+			t.Fatalf("reassembled %q, want %q: %v", out, msg, err)
+		}
+	})
+}
+
+func TestReassemblerTooLarge(t *testing.T) {
+	r := Reassembler{MaxMessage: 8}
+	if _, err := r.Add([]byte("12345"), false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Add([]byte("6789"), true); !errors.Is(err, ErrMessageTooLarge) {
+		t.Fatalf("Add: %v, want ErrMessageTooLarge", err)
+	}
+	if r.Pending() != 0 {
+		t.Fatal("oversized message kept")
+	}
+
+	// The next message starts over
+	msg, err := r.Add([]byte("12345678"), true)
+	if err != nil || string(msg) != "12345678" {
+		t.Fatalf("Add: %q, %v", msg, err)
+	}
+	if msg, err := r.Add(nil, true); err != nil || msg == nil || len(msg) != 0 {
+		t.Fatalf("empty message: %q, %v", msg, err)
+	}
+}
@@ -0,0 +1,13 @@
+// Package codec holds the wire formats of supernet protocols as pure functions: multiplexer frames and
+// the reassembly of fragmented messages, relay handshakes, overlay envelopes and the version negotiation
+// shared by all of them (see Protocol). Nothing here performs
+// I/O, so every format can be tested and fuzzed without a transport (go test -fuzz FuzzMuxFrame).
+//
+// Decoders never panic, reject malformed input with an error wrapping ErrMalformed and return slices
+// aliasing their input where noted.
+package codec
+
+import "errors"
+
+// ErrMalformed is returned when decoding malformed input
+var ErrMalformed = errors.New("codec: malformed message")
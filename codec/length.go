@@ -0,0 +1,44 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// AppendLengthPrefixed appends msg preceded by its length as a uvarint, the framing of messages over
+// byte streams such as WebTransport streams.
+func AppendLengthPrefixed(b, msg []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(len(msg)))
+	return append(b, msg...)
+}
+
+// ReadLengthPrefixed reads a message framed by AppendLengthPrefixed. It returns io.EOF if r ends before
+// the message and io.ErrUnexpectedEOF if it ends within. Messages longer than max bytes fail with
+// ErrMessageTooLarge before they are read.
+func ReadLengthPrefixed(r interface {
+	io.Reader
+	io.ByteReader
+}, max int) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err == io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: bad length prefix", ErrMalformed)
+	}
+	if n > uint64(max) {
+		return nil, fmt.Errorf("%w: %d bytes", ErrMessageTooLarge, n)
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,45 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func FuzzLengthPrefixed(f *testing.F) {
+	f.Add(AppendLengthPrefixed(nil, nil))
+	f.Add(AppendLengthPrefixed(nil, []byte("message")))
+	f.Add(AppendLengthPrefixed(nil, bytes.Repeat([]byte{0xaa}, 300)))
+	f.Add(AppendLengthPrefixed(nil, []byte("message"))[:4])
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		msg, err := ReadLengthPrefixed(bytes.NewReader(data), len(data))
+		if err != nil {
+			return
+		}
+		again, err := ReadLengthPrefixed(bytes.NewReader(AppendLengthPrefixed(nil, msg)), len(msg))
+		if err != nil || !bytes.Equal(again, msg) {
+			t.Fatalf("length-prefixed message %q does not round-trip: %q, %v", msg, again, err)
+		}
+	})
+}
+
+func TestReadLengthPrefixedErrors(t *testing.T) {
+	msg := AppendLengthPrefixed(nil, []byte("message"))
+	for name, tc := range map[string]struct {
+		data []byte
+		max  int
+		want error
+	}{
+		"empty":     {nil, 16, io.EOF},
+		"truncated": {msg[:4], 16, io.ErrUnexpectedEOF},
+		"prefix":    {[]byte{0x80}, 16, io.ErrUnexpectedEOF},
+		"overflow":  {bytes.Repeat([]byte{0xff}, 11), 16, ErrMalformed},
+		"too large": {msg, 6, ErrMessageTooLarge},
+	} {
+		if _, err := ReadLengthPrefixed(bytes.NewReader(tc.data), tc.max); !errors.Is(err, tc.want) {
+			t.Errorf("%s: %v, want %v", name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// MuxFrameType identifies the kind of a mux frame.
+type MuxFrameType byte
+
+// Mux frame types. Every frame is a single message on the underlying connection:
+//
+//	type (1 byte) | stream ID (uvarint) | payload
+const (
+	// MuxOpen announces a new stream opened by the sender.
+	MuxOpen MuxFrameType = iota + 1
+	// MuxData carries the final (or only) fragment of a message.
+	MuxData
+	// MuxDataMore carries a message fragment that is continued by the next data frame.
+	MuxDataMore
+	// MuxWindow grants the sender additional receive window, the payload is a uvarint byte count.
+	MuxWindow
+	// MuxClose closes the stream in both directions.
+	MuxClose
+	// MuxReset aborts the stream, discarding buffered data.
+	MuxReset
+	// MuxHello announces the Protocol of the sender on stream 0, which is never opened. It is the first
+	// frame of a session; multiplexers predating it drop it like any frame of an unknown stream.
+	MuxHello
+)
+
+// MuxHelloStream is the stream ID of hello frames.
+const MuxHelloStream = 0
+
+// MuxFrame is a decoded mux frame.
+type MuxFrame struct {
+	Type    MuxFrameType // Frame type
+	Stream  uint64       // Stream ID
+	Payload []byte       // Message fragment or window update
+}
+
+// AppendMuxFrame appends the encoding of f to b.
+func AppendMuxFrame(b []byte, f MuxFrame) []byte {
+	b = append(b, byte(f.Type))
+	b = binary.AppendUvarint(b, f.Stream)
+	return append(b, f.Payload...)
+}
+
+// EncodeMuxFrame encodes a frame.
+func EncodeMuxFrame(f MuxFrame) []byte {
+	return AppendMuxFrame(make([]byte, 0, 1+binary.MaxVarintLen64+len(f.Payload)), f)
+}
+
+// DecodeMuxFrame decodes a frame. Unknown frame types are not rejected here, so the multiplexer can
+// tell them apart from truncated frames. The payload aliases msg.
+func DecodeMuxFrame(msg []byte) (MuxFrame, error) {
+	if len(msg) < 2 {
+		return MuxFrame{}, fmt.Errorf("%w: short mux frame", ErrMalformed)
+	}
+	id, n := binary.Uvarint(msg[1:])
+	if n <= 0 {
+		return MuxFrame{}, fmt.Errorf("%w: bad mux stream id", ErrMalformed)
+	}
+	return MuxFrame{Type: MuxFrameType(msg[0]), Stream: id, Payload: msg[1+n:]}, nil
+}
+
+// EncodeMuxWindow encodes the payload of a window update granting delta bytes.
+func EncodeMuxWindow(delta uint64) []byte {
+	return binary.AppendUvarint(nil, delta)
+}
+
+// DecodeMuxWindow decodes the payload of a window update.
+func DecodeMuxWindow(payload []byte) (uint64, error) {
+	delta, n := binary.Uvarint(payload)
+	if n <= 0 {
+		return 0, fmt.Errorf("%w: bad mux window update", ErrMalformed)
+	}
+	return delta, nil
+}
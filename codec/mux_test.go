@@ -0,0 +1,52 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func FuzzMuxFrame(f *testing.F) {
+	f.Add(EncodeMuxFrame(MuxFrame{Type: MuxOpen, Stream: 1}))
+	f.Add(EncodeMuxFrame(MuxFrame{Type: MuxData, Stream: 3, Payload: []byte("hello")}))
+	f.Add(EncodeMuxFrame(MuxFrame{Type: MuxDataMore, Stream: 1 << 40, Payload: []byte{0, 1, 2}}))
+	f.Add(EncodeMuxFrame(MuxFrame{Type: MuxWindow, Stream: 5, Payload: EncodeMuxWindow(256 << 10)}))
+	f.Add(EncodeMuxFrame(MuxFrame{Type: MuxReset, Stream: 7}))
+	f.Add(EncodeMuxFrame(MuxFrame{Type: MuxHello, Stream: MuxHelloStream, Payload: EncodeProtocol(Protocol{Version: 2, Capabilities: 1})}))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fr, err := DecodeMuxFrame(data)
+		if err != nil {
+			return
+		}
+		g, err := DecodeMuxFrame(EncodeMuxFrame(fr))
+		if err != nil || g.Type != fr.Type || g.Stream != fr.Stream || !bytes.Equal(g.Payload, fr.Payload) {
+			t.Fatalf("mux frame %+v does not round-trip: %+v, %v", fr, g, err)
+		}
+		if fr.Type != MuxWindow {
+			return
+		}
+		delta, err := DecodeMuxWindow(fr.Payload)
+		if err != nil {
+			return
+		}
+		if d, err := DecodeMuxWindow(EncodeMuxWindow(delta)); err != nil || d != delta {
+			t.Fatalf("mux window update %d does not round-trip: %d, %v", delta, d, err)
+		}
+	})
+}
+
+func TestMuxFrameMalformed(t *testing.T) {
+	for name, b := range map[string][]byte{
+		"empty":     nil,
+		"short":     {byte(MuxData)},
+		"stream id": {byte(MuxData), 0x80},
+	} {
+		if _, err := DecodeMuxFrame(b); !errors.Is(err, ErrMalformed) {
+			t.Errorf("%s: %v, want ErrMalformed", name, err)
+		}
+	}
+	if _, err := DecodeMuxWindow(nil); !errors.Is(err, ErrMalformed) {
+		t.Errorf("empty window update: %v, want ErrMalformed", err)
+	}
+}
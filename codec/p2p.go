@@ -0,0 +1,31 @@
+package codec
+
+import (
+	"fmt"
+
+	snp2p "pkg.gfire.dev/supernet/proto/snp2p/v1alpha1"
+)
+
+// peerIDLength is the length of an overlay peer ID, see p2p.IDLength
+const peerIDLength = 32
+
+// EncodeEnvelope encodes an overlay request or response envelope.
+func EncodeEnvelope(env *snp2p.Envelope) ([]byte, error) {
+	return env.MarshalVT()
+}
+
+// DecodeEnvelope decodes and validates an envelope: the source must be a peer ID and the destination
+// a peer ID or empty.
+func DecodeEnvelope(b []byte) (*snp2p.Envelope, error) {
+	env := &snp2p.Envelope{}
+	if err := env.UnmarshalVT(b); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	if len(env.Source) != peerIDLength {
+		return nil, fmt.Errorf("%w: bad envelope source", ErrMalformed)
+	}
+	if len(env.Destination) != 0 && len(env.Destination) != peerIDLength {
+		return nil, fmt.Errorf("%w: bad envelope destination", ErrMalformed)
+	}
+	return env, nil
+}
@@ -0,0 +1,59 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	snp2p "pkg.gfire.dev/supernet/proto/snp2p/v1alpha1"
+)
+
+func FuzzEnvelope(f *testing.F) {
+	source, destination := bytes.Repeat([]byte{1}, peerIDLength), bytes.Repeat([]byte{2}, peerIDLength)
+	for _, env := range []*snp2p.Envelope{
+		{Source: source, RequestId: 1, HopLimit: 8, Body: &snp2p.Envelope_Ping{Ping: &snp2p.Ping{}}},
+		{Source: source, Destination: destination, RequestId: 2, Response: true, Body: &snp2p.Envelope_Pong{Pong: &snp2p.Pong{}}},
+		{Source: source, Destination: destination, Body: &snp2p.Envelope_FindNode{FindNode: &snp2p.FindNode{Target: destination}}},
+	} {
+		b, err := EncodeEnvelope(env)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		env, err := DecodeEnvelope(data)
+		if err != nil {
+			return
+		}
+		b, err := EncodeEnvelope(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := DecodeEnvelope(b)
+		if err != nil || !proto.Equal(env, again) {
+			t.Fatalf("envelope %v does not round-trip: %v, %v", env, again, err)
+		}
+	})
+}
+
+func TestDecodeEnvelopeInvalid(t *testing.T) {
+	source := bytes.Repeat([]byte{1}, peerIDLength)
+	for name, env := range map[string]*snp2p.Envelope{
+		"source":      {Source: source[1:]},
+		"destination": {Source: source, Destination: source[1:]},
+	} {
+		b, err := EncodeEnvelope(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DecodeEnvelope(b); !errors.Is(err, ErrMalformed) {
+			t.Errorf("%s: %v, want ErrMalformed", name, err)
+		}
+	}
+	if _, err := DecodeEnvelope([]byte{0xff}); !errors.Is(err, ErrMalformed) {
+		t.Errorf("truncated envelope: %v, want ErrMalformed", err)
+	}
+}
@@ -0,0 +1,46 @@
+package codec
+
+import (
+	"fmt"
+
+	snrelay "pkg.gfire.dev/supernet/proto/snrelay/v1alpha1"
+)
+
+// maxRelayAddress bounds the target address of an OpenRequest, a DNS name of at most 253 characters
+// with a port
+const maxRelayAddress = 253 + len(":65535")
+
+// EncodeOpenRequest encodes the first message of a relay stream.
+func EncodeOpenRequest(req *snrelay.OpenRequest) ([]byte, error) {
+	return req.MarshalVT()
+}
+
+// DecodeOpenRequest decodes and validates an OpenRequest: the network must be known and the address
+// no longer than a DNS name with a port. Whether the address is reachable is up to the relay.
+func DecodeOpenRequest(b []byte) (*snrelay.OpenRequest, error) {
+	req := &snrelay.OpenRequest{}
+	if err := req.UnmarshalVT(b); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	if _, ok := snrelay.Network_name[int32(req.Network)]; !ok {
+		return nil, fmt.Errorf("%w: unknown relay network %d", ErrMalformed, req.Network)
+	}
+	if len(req.Address) > maxRelayAddress {
+		return nil, fmt.Errorf("%w: relay address too long", ErrMalformed)
+	}
+	return req, nil
+}
+
+// EncodeOpenResponse encodes the answer to an OpenRequest.
+func EncodeOpenResponse(resp *snrelay.OpenResponse) ([]byte, error) {
+	return resp.MarshalVT()
+}
+
+// DecodeOpenResponse decodes an OpenResponse.
+func DecodeOpenResponse(b []byte) (*snrelay.OpenResponse, error) {
+	resp := &snrelay.OpenResponse{}
+	if err := resp.UnmarshalVT(b); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	return resp, nil
+}
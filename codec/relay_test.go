@@ -0,0 +1,85 @@
+package codec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	snrelay "pkg.gfire.dev/supernet/proto/snrelay/v1alpha1"
+)
+
+func FuzzOpenRequest(f *testing.F) {
+	for _, req := range []*snrelay.OpenRequest{
+		{Network: snrelay.Network_TCP, Address: "example.com:443"},
+		{Network: snrelay.Network_UDP, Address: "[::1]:53", Version: 2, Capabilities: 1},
+		{Address: "10.0.0.1:80", TraceContext: map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}},
+	} {
+		b, err := EncodeOpenRequest(req)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		req, err := DecodeOpenRequest(data)
+		if err != nil {
+			return
+		}
+		b, err := EncodeOpenRequest(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := DecodeOpenRequest(b)
+		if err != nil || !proto.Equal(req, again) {
+			t.Fatalf("open request %v does not round-trip: %v, %v", req, again, err)
+		}
+	})
+}
+
+func FuzzOpenResponse(f *testing.F) {
+	for _, resp := range []*snrelay.OpenResponse{
+		{LocalAddress: "192.0.2.1:50000", RemoteAddress: "93.184.216.34:443", Version: 2},
+		{Error: "dial tcp: connection refused"},
+	} {
+		b, err := EncodeOpenResponse(resp)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		resp, err := DecodeOpenResponse(data)
+		if err != nil {
+			return
+		}
+		b, err := EncodeOpenResponse(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := DecodeOpenResponse(b)
+		if err != nil || !proto.Equal(resp, again) {
+			t.Fatalf("open response %v does not round-trip: %v, %v", resp, again, err)
+		}
+	})
+}
+
+func TestDecodeOpenRequestInvalid(t *testing.T) {
+	for name, req := range map[string]*snrelay.OpenRequest{
+		"network": {Network: snrelay.Network(7), Address: "example.com:443"},
+		"address": {Address: strings.Repeat("a", maxRelayAddress+1)},
+	} {
+		b, err := EncodeOpenRequest(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DecodeOpenRequest(b); !errors.Is(err, ErrMalformed) {
+			t.Errorf("%s: %v, want ErrMalformed", name, err)
+		}
+	}
+	if _, err := DecodeOpenResponse([]byte{0xff}); !errors.Is(err, ErrMalformed) {
+		t.Errorf("truncated response: %v, want ErrMalformed", err)
+	}
+}
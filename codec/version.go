@@ -0,0 +1,75 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrIncompatible is returned by Negotiate when the remote side only speaks versions older than accepted
+var ErrIncompatible = errors.New("codec: incompatible protocol version")
+
+// LegacyVersion is the version of peers predating negotiation, which announce nothing and are assumed to
+// speak the first version of a protocol without capabilities.
+const LegacyVersion = 1
+
+// Capabilities is a set of optional features of a protocol, one bit each. Bits are assigned by the
+// protocol; unknown bits are kept by Negotiate only if both sides set them.
+type Capabilities uint64
+
+// Has reports whether all capabilities of c2 are set in c.
+func (c Capabilities) Has(c2 Capabilities) bool {
+	return c&c2 == c2
+}
+
+// Protocol is what one side of a supernet protocol speaks, or what both sides agreed on.
+//
+// Every protocol announces its Protocol in its own handshake (mux hello frames, relay open messages,
+// overlay hellos, outbox hello frames) in a way that peers predating negotiation ignore, so either side
+// can be upgraded first: a missing announcement stands for LegacyVersion.
+type Protocol struct {
+	Version      uint32       // Highest version spoken, or the negotiated version
+	Capabilities Capabilities // Optional features supported, or supported by both sides
+}
+
+// Negotiate returns the protocol spoken between local and remote: the lower of both versions and the
+// capabilities both sides support. A remote Version of 0 stands for a peer predating negotiation. It fails
+// with ErrIncompatible if the result is below minVersion, 0 accepting every version.
+func Negotiate(local, remote Protocol, minVersion uint32) (Protocol, error) {
+	if remote.Version == 0 {
+		remote = Protocol{Version: LegacyVersion}
+	}
+	p := Protocol{
+		Version:      min(local.Version, remote.Version),
+		Capabilities: local.Capabilities & remote.Capabilities,
+	}
+	if p.Version < minVersion {
+		return Protocol{}, fmt.Errorf("%w: remote speaks version %d, at least %d required", ErrIncompatible, remote.Version, minVersion)
+	}
+	return p, nil
+}
+
+// AppendProtocol appends the encoding of p used by hello frames: uvarint version | uvarint capabilities.
+func AppendProtocol(b []byte, p Protocol) []byte {
+	b = binary.AppendUvarint(b, uint64(p.Version))
+	return binary.AppendUvarint(b, uint64(p.Capabilities))
+}
+
+// EncodeProtocol encodes p, see AppendProtocol.
+func EncodeProtocol(p Protocol) []byte {
+	return AppendProtocol(make([]byte, 0, 2*binary.MaxVarintLen64), p)
+}
+
+// DecodeProtocol decodes a Protocol. Trailing bytes are ignored, so later versions can extend the
+// announcement.
+func DecodeProtocol(b []byte) (Protocol, error) {
+	version, n := binary.Uvarint(b)
+	if n <= 0 || version == 0 || version > 1<<32-1 {
+		return Protocol{}, fmt.Errorf("%w: bad protocol version", ErrMalformed)
+	}
+	caps, m := binary.Uvarint(b[n:])
+	if m <= 0 {
+		return Protocol{}, fmt.Errorf("%w: bad protocol capabilities", ErrMalformed)
+	}
+	return Protocol{Version: uint32(version), Capabilities: Capabilities(caps)}, nil
+}
@@ -0,0 +1,58 @@
+package codec
+
+import (
+	"errors"
+	"testing"
+)
+
+func FuzzProtocol(f *testing.F) {
+	f.Add(EncodeProtocol(Protocol{Version: LegacyVersion}))
+	f.Add(EncodeProtocol(Protocol{Version: 2, Capabilities: 0b101}))
+	f.Add(EncodeProtocol(Protocol{Version: 1<<32 - 1, Capabilities: 1<<64 - 1}))
+	f.Add(append(EncodeProtocol(Protocol{Version: 3}), 0xff))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		p, err := DecodeProtocol(data)
+		if err != nil {
+			return
+		}
+		if q, err := DecodeProtocol(EncodeProtocol(p)); err != nil || q != p {
+			t.Fatalf("protocol %+v does not round-trip: %+v, %v", p, q, err)
+		}
+	})
+}
+
+func TestNegotiate(t *testing.T) {
+	local := Protocol{Version: 3, Capabilities: 0b011}
+	for _, tc := range []struct {
+		remote     Protocol
+		minVersion uint32
+		want       Protocol
+		err        error
+	}{
+		{Protocol{Version: 5, Capabilities: 0b110}, 0, Protocol{Version: 3, Capabilities: 0b010}, nil},
+		{Protocol{Version: 2, Capabilities: 0b001}, 2, Protocol{Version: 2, Capabilities: 0b001}, nil},
+		{Protocol{}, 0, Protocol{Version: LegacyVersion}, nil},
+		{Protocol{}, 2, Protocol{}, ErrIncompatible},
+		{Protocol{Version: 2}, 3, Protocol{}, ErrIncompatible},
+	} {
+		p, err := Negotiate(local, tc.remote, tc.minVersion)
+		if p != tc.want || !errors.Is(err, tc.err) || (err == nil) != (tc.err == nil) {
+			t.Errorf("Negotiate(%+v, %d): %+v, %v, want %+v, %v", tc.remote, tc.minVersion, p, err, tc.want, tc.err)
+		}
+	}
+}
+
+func TestDecodeProtocolMalformed(t *testing.T) {
+	for name, b := range map[string][]byte{
+		"empty":        nil,
+		"version 0":    {0, 0},
+		"version":      {0xff},
+		"large":        {0x80, 0x80, 0x80, 0x80, 0x10, 0},
+		"capabilities": {2},
+	} {
+		if _, err := DecodeProtocol(b); !errors.Is(err, ErrMalformed) {
+			t.Errorf("%s: %v, want ErrMalformed", name, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package supernet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/naming"
+	"pkg.gfire.dev/supernet/routing"
+)
+
+// ErrUnknownTransport is returned when a route names a transport or relay the dialer has no dial function for.
+var ErrUnknownTransport = errors.New("unknown transport")
+
+// tracerName is the instrumentation scope of dial spans
+const tracerName = "pkg.gfire.dev/supernet"
+
+// DialFunc dials addr over a specific transport.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// RelayFunc dials addr through the named relay, reaching the relay over the given transport dial function.
+type RelayFunc func(ctx context.Context, relay string, dial DialFunc, network, addr string) (net.Conn, error)
+
+// PeerFunc connects to a service of an overlay peer, e.g. over a circuit or a direct P2P connection.
+// The service is empty when the peer URL has no path.
+type PeerFunc func(ctx context.Context, id identity.ID, service string) (net.Conn, error)
+
+// Dialer dials connections, consulting routing rules to choose a transport or relay for every destination.
+type Dialer struct {
+	// Rules decides how each destination is reached. A nil rule set routes everything directly.
+	Rules *routing.Rules
+	// Transports maps transport names used in routes to dial functions. The routing.Direct transport
+	// falls back to a net.Dialer when not set.
+	Transports map[string]DialFunc
+	// Relay tunnels connections through the relay named by a route. Routes naming a relay fail with
+	// ErrUnknownTransport when nil.
+	Relay RelayFunc
+	// Peer connects to overlay peers named by peer URLs. Peer URLs fail with ErrUnknownTransport when nil.
+	Peer PeerFunc
+	// Resolver resolves names in peer URLs. Without it only peer IDs can be dialed.
+	Resolver *naming.Resolver
+}
+
+// DialContext connects to addr on the named network using the route the rules select for addr.
+// The dial is traced as a span recording the chosen route.
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (conn net.Conn, err error) {
+	ctx, span := startSpan(ctx, "supernet.Dial", attribute.String("network.transport", network), attribute.String("server.address", addr))
+	defer func() { endSpan(span, err) }()
+
+	route := d.Rules.RouteAddr(addr)
+	if route.Reject {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: routing.ErrRejected}
+	}
+	if route.Transport != "" {
+		span.SetAttributes(attribute.String("supernet.transport", route.Transport))
+	}
+	if route.Relay != "" {
+		span.SetAttributes(attribute.String("supernet.relay", route.Relay))
+	}
+
+	dial, err := d.transport(route.Transport)
+	if err != nil {
+		return nil, err
+	}
+	if route.Relay == "" {
+		return dial(ctx, network, addr)
+	}
+	if d.Relay == nil {
+		return nil, fmt.Errorf("%w: relay %q", ErrUnknownTransport, route.Relay)
+	}
+	return d.Relay(ctx, route.Relay, dial, network, addr)
+}
+
+// Dial connects to addr on the named network.
+func (d *Dialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+// transport returns the dial function for a transport name.
+func (d *Dialer) transport(name string) (DialFunc, error) {
+	if name == "" {
+		name = routing.Direct
+	}
+	if dial, ok := d.Transports[name]; ok {
+		return dial, nil
+	}
+	if name == routing.Direct {
+		var nd net.Dialer
+		return nd.DialContext, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownTransport, name)
+}
+
+// startSpan starts a client span for a dial.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endSpan ends a dial span, recording err.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
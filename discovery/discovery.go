@@ -0,0 +1,229 @@
+// Package discovery finds peers interested in the same topic without knowing their addresses up front. Native
+// nodes announce themselves on the local network with mDNS/DNS-SD, browser peers at an HTTP tracker, and every
+// source exchanges signed identity records, so discovered peers cannot be impersonated. Verified records are
+// fed into the peer store and the gossip layer, which dials them when it needs peers:
+//
+//	svc, err := discovery.New(discovery.Config{
+//		Identity:  id,
+//		Addrs:     []string{"wss://node.example.com/p2p"},
+//		Topic:     "chat",
+//		Sources:   []discovery.Source{&discovery.MDNS{}, &discovery.Tracker{URL: "https://relay.example.com/tracker"}},
+//		Peerstore: ps,
+//		Gossip:    node,
+//	})
+//	go svc.Run(ctx)
+package discovery
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/logging"
+	"pkg.gfire.dev/supernet/retry"
+)
+
+var (
+	// ErrInvalidTopic is returned for empty or overlong topics
+	ErrInvalidTopic = errors.New("invalid discovery topic")
+	// ErrNoSources is returned by New without any source configured
+	ErrNoSources = errors.New("no discovery sources configured")
+)
+
+const (
+	// maxTopicLen is the longest accepted topic
+	maxTopicLen = 128
+	// peerstoreTimeout bounds every peer store operation
+	peerstoreTimeout = 5 * time.Second
+)
+
+// Source announces the node under a topic and finds other peers announced there.
+type Source interface {
+	// Run announces the record returned by self under topic and passes the signed records of peers found
+	// there to found until ctx is done or the source fails. self returns a fresh record on every call.
+	// Records passed to found are verified by the caller.
+	Run(ctx context.Context, topic string, self func() []byte, found func(record []byte)) error
+}
+
+// Peerstore remembers discovered peers, see peerstore.Store.
+type Peerstore interface {
+	// Discovered stores the verified signed record of a discovered peer.
+	Discovered(ctx context.Context, record []byte) error
+}
+
+// Gossip learns discovered peers as group members, see gossip.Node.
+type Gossip interface {
+	// AddRecord adds the member described by a signed record.
+	AddRecord(record []byte) error
+}
+
+// Config configures a Service.
+type Config struct {
+	// Identity is announced to other peers.
+	Identity *identity.Identity
+	// Addrs lists the addresses announced in the node's record (typically empty for browser nodes, which
+	// are reached through relays or WebRTC).
+	Addrs []string
+	// Topic scopes discovery; only peers announcing the same topic are found.
+	Topic string
+	// Sources find peers, e.g. MDNS and Tracker. Each runs concurrently and is restarted with backoff
+	// when it fails.
+	Sources []Source
+	// Peerstore, when set, remembers every discovered peer.
+	Peerstore Peerstore
+	// Gossip, when set, learns every discovered peer as a member.
+	Gossip Gossip
+	// OnPeer is called when a peer is discovered or announces a newer record. It must not block.
+	OnPeer func(rec *identity.Record)
+	// Logger receives source failures. Defaults to logging.For("discovery").
+	Logger *slog.Logger
+}
+
+// Service runs discovery sources for a topic and collects the peers they find.
+type Service struct {
+	cfg Config
+
+	mu sync.Mutex
+	// record is the node's own signed record announced by the sources
+	record []byte
+	// renew is when record must be renewed
+	renew time.Time
+	// peers holds the latest record of every discovered peer
+	peers map[identity.ID]*identity.Record
+}
+
+// New creates a discovery service. It does nothing until Run is called.
+func New(cfg Config) (*Service, error) {
+	if cfg.Topic == "" || len(cfg.Topic) > maxTopicLen {
+		return nil, ErrInvalidTopic
+	}
+	if len(cfg.Sources) == 0 {
+		return nil, ErrNoSources
+	}
+	if cfg.Identity == nil {
+		id, err := identity.Generate()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Identity = id
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logging.For("discovery")
+	}
+	return &Service{cfg: cfg, peers: make(map[identity.ID]*identity.Record)}, nil
+}
+
+// Run runs all sources until ctx is done, restarting failed sources with backoff. It returns ctx.Err().
+func (s *Service) Run(ctx context.Context) error {
+	// Fail early if no record can be signed at all
+	if _, err := s.self(); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, src := range s.cfg.Sources {
+		wg.Go(func() {
+			s.run(ctx, src)
+		})
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// Peers returns the latest records of the discovered peers that have not expired.
+func (s *Service) Peers() []*identity.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	recs := make([]*identity.Record, 0, len(s.peers))
+	for id, rec := range s.peers {
+		if now.After(rec.Expires) {
+			delete(s.peers, id)
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+// run runs one source until ctx is done.
+func (s *Service) run(ctx context.Context, src Source) {
+	backoff := retry.Policy{Initial: time.Second, Max: time.Minute}.Backoff()
+	for {
+		start := time.Now()
+		err := src.Run(ctx, s.cfg.Topic, s.announcement, s.found)
+		if ctx.Err() != nil {
+			return
+		}
+		s.cfg.Logger.Warn("discovery source failed", "topic", s.cfg.Topic, "err", err)
+		// A source that ran for a while before failing starts over with short delays
+		if time.Since(start) > time.Minute {
+			backoff.Reset()
+		}
+		if !backoff.Wait(ctx) {
+			return
+		}
+	}
+}
+
+// announcement returns the node's record for sources, nil if it cannot be signed.
+func (s *Service) announcement() []byte {
+	rec, err := s.self()
+	if err != nil {
+		s.cfg.Logger.Warn("discovery record failed", "err", err)
+		return nil
+	}
+	return rec
+}
+
+// self returns the node's signed record, renewing it when half of its lifetime has passed.
+func (s *Service) self() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.record != nil && time.Now().Before(s.renew) {
+		return s.record, nil
+	}
+	rec, err := s.cfg.Identity.NewRecord(s.cfg.Addrs, identity.DefaultRecordTTL)
+	if err != nil {
+		return nil, err
+	}
+	s.record = rec
+	s.renew = time.Now().Add(identity.DefaultRecordTTL / 2)
+	return rec, nil
+}
+
+// found verifies a record reported by a source and passes new peers and newer records on.
+func (s *Service) found(raw []byte) {
+	rec, err := identity.OpenRecord(raw)
+	if err != nil || rec.ID == s.cfg.Identity.ID() {
+		return
+	}
+
+	s.mu.Lock()
+	old, ok := s.peers[rec.ID]
+	if ok && !rec.Newer(old) {
+		s.mu.Unlock()
+		return
+	}
+	s.peers[rec.ID] = rec
+	s.mu.Unlock()
+
+	s.cfg.Logger.Debug("discovery peer found", "topic", s.cfg.Topic, "peer", rec.ID.ShortString(), "addrs", rec.Addrs)
+	if s.cfg.Peerstore != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), peerstoreTimeout)
+		if err := s.cfg.Peerstore.Discovered(ctx, raw); err != nil {
+			s.cfg.Logger.Debug("discovery peerstore update failed", "err", err)
+		}
+		cancel()
+	}
+	if s.cfg.Gossip != nil {
+		s.cfg.Gossip.AddRecord(raw)
+	}
+	if s.cfg.OnPeer != nil {
+		s.cfg.OnPeer(rec)
+	}
+}
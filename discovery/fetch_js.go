@@ -0,0 +1,39 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/httpjs"
+)
+
+// fetch sends a request with fetch and decodes the JSON response into v unless it is nil. Fetch is aborted
+// when ctx ends.
+func fetch(ctx context.Context, method, url string, header map[string]string, body []byte, v any) error {
+	req := httpjs.NewRequest(method, url)
+	for k, val := range header {
+		req.SetHeader(k, val)
+	}
+	if body != nil {
+		req.SetHeader("Content-Type", "application/octet-stream")
+		req.SetBody(body)
+	}
+	resp, err := req.DoContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	if v == nil {
+		return nil
+	}
+	data, err := resp.ReadAll()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,208 @@
+//go:build !js
+
+package discovery
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"pkg.gfire.dev/supernet/identity"
+)
+
+const (
+	// defaultMDNSInterval is the default time between mDNS queries
+	defaultMDNSInterval = time.Minute
+	// mdnsService is the DNS-SD service type of supernet nodes
+	mdnsService = "_supernet._udp.local."
+	// mdnsTTL is the TTL of announced resource records in seconds
+	mdnsTTL = 120
+	// mdnsChunk is the size of the record chunks carried in TXT strings, which are limited to 255 bytes
+	mdnsChunk = 240
+	// mdnsMaxPacket is the largest mDNS packet read
+	mdnsMaxPacket = 9000
+)
+
+// mdnsGroup is the IPv4 mDNS multicast group
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// MDNS is a Source announcing the node on the local network with multicast DNS service discovery
+// (RFC 6762, RFC 6763). Nodes register the service instance <peer id>._supernet._udp.local under a subtype
+// derived from the topic, and carry their signed record in the instance's TXT record. Only records with
+// addresses are useful to peers, so nodes should announce the addresses they listen on.
+type MDNS struct {
+	// Interface is the network interface to use; the system chooses one when nil.
+	Interface *net.Interface
+	// Interval is the time between queries (default 1 minute).
+	Interval time.Duration
+}
+
+// Run announces the node and queries for the peers of topic every Interval until ctx is done or the socket
+// fails.
+func (m *MDNS) Run(ctx context.Context, topic string, self func() []byte, found func(record []byte)) error {
+	interval := m.Interval
+	if interval <= 0 {
+		interval = defaultMDNSInterval
+	}
+	conn, err := net.ListenMulticastUDP("udp4", m.Interface, mdnsGroup)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	sum := sha256.Sum256([]byte(topic))
+	subtype, err := dnsmessage.NewName("_" + hex.EncodeToString(sum[:8]) + "._sub." + mdnsService)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// Unsolicited announcements and queries let peers already running learn about the node at once
+		send(conn, response(subtype, self()))
+		send(conn, query(subtype))
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				send(conn, query(subtype))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	buf := make([]byte, mdnsMaxPacket)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		var msg dnsmessage.Message
+		if err := msg.Unpack(buf[:n]); err != nil {
+			continue
+		}
+		if !msg.Response {
+			if asks(&msg, subtype) {
+				send(conn, response(subtype, self()))
+			}
+			continue
+		}
+		for _, rec := range records(&msg, subtype) {
+			found(rec)
+		}
+	}
+}
+
+// send packs and multicasts a message, dropping it on failure like any lost datagram.
+func send(conn *net.UDPConn, msg *dnsmessage.Message) {
+	if msg == nil {
+		return
+	}
+	data, err := msg.Pack()
+	if err != nil {
+		return
+	}
+	conn.WriteToUDP(data, mdnsGroup)
+}
+
+// response builds the answer announcing the node's record under subtype, nil without a record.
+func response(subtype dnsmessage.Name, record []byte) *dnsmessage.Message {
+	if record == nil {
+		return nil
+	}
+	rec, err := identity.OpenRecord(record)
+	if err != nil {
+		return nil
+	}
+	instance, err := dnsmessage.NewName(rec.ID.String() + "." + mdnsService)
+	if err != nil {
+		return nil
+	}
+
+	var txt []string
+	for i := 0; len(record) > 0; i++ {
+		n := min(len(record), mdnsChunk)
+		txt = append(txt, "r"+strconv.Itoa(i)+"="+string(record[:n]))
+		record = record[n:]
+	}
+	return &dnsmessage.Message{
+		Header: dnsmessage.Header{Response: true, Authoritative: true},
+		Answers: []dnsmessage.Resource{
+			{
+				Header: dnsmessage.ResourceHeader{Name: subtype, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET, TTL: mdnsTTL},
+				Body:   &dnsmessage.PTRResource{PTR: instance},
+			},
+			{
+				// The cache-flush bit tells caches this is the only TXT record of the instance
+				Header: dnsmessage.ResourceHeader{Name: instance, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET | 1<<15, TTL: mdnsTTL},
+				Body:   &dnsmessage.TXTResource{TXT: txt},
+			},
+		},
+	}
+}
+
+// query builds a query for the instances registered under subtype.
+func query(subtype dnsmessage.Name) *dnsmessage.Message {
+	return &dnsmessage.Message{
+		Questions: []dnsmessage.Question{{Name: subtype, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}},
+	}
+}
+
+// asks reports whether a query asks for the instances registered under subtype.
+func asks(msg *dnsmessage.Message, subtype dnsmessage.Name) bool {
+	for _, q := range msg.Questions {
+		if (q.Type == dnsmessage.TypePTR || q.Type == dnsmessage.TypeALL) && strings.EqualFold(q.Name.String(), subtype.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// records extracts the signed records of the instances a response lists under subtype.
+func records(msg *dnsmessage.Message, subtype dnsmessage.Name) [][]byte {
+	resources := append(msg.Answers, msg.Additionals...)
+	instances := make(map[string]bool)
+	for _, r := range resources {
+		if ptr, ok := r.Body.(*dnsmessage.PTRResource); ok && strings.EqualFold(r.Header.Name.String(), subtype.String()) {
+			instances[strings.ToLower(ptr.PTR.String())] = true
+		}
+	}
+
+	var recs [][]byte
+	for _, r := range resources {
+		txt, ok := r.Body.(*dnsmessage.TXTResource)
+		if !ok || !instances[strings.ToLower(r.Header.Name.String())] {
+			continue
+		}
+		var rec []byte
+		for i, s := range txt.TXT {
+			chunk, ok := strings.CutPrefix(s, "r"+strconv.Itoa(i)+"=")
+			if !ok {
+				rec = nil
+				break
+			}
+			rec = append(rec, chunk...)
+		}
+		if rec != nil {
+			recs = append(recs, rec)
+		}
+	}
+	return recs
+}
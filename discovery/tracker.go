@@ -0,0 +1,283 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/internal/cors"
+)
+
+var (
+	// ErrTrackerFailed is returned when a tracker refuses an announcement or list request
+	ErrTrackerFailed = errors.New("discovery tracker request failed")
+	// ErrTrackerFull is reported to clients when a tracker has no room for an announcement
+	ErrTrackerFull = errors.New("discovery tracker full")
+)
+
+const (
+	// defaultTrackerInterval is the default time between announcements of a Tracker source
+	defaultTrackerInterval = 30 * time.Second
+	// defaultTrackerTTL is the default time announcements stay listed
+	defaultTrackerTTL = 2 * time.Minute
+	// defaultTrackerMaxPeers is the default limit of peers listed per topic
+	defaultTrackerMaxPeers = 1024
+	// defaultTrackerMaxTopics is the default limit of topics
+	defaultTrackerMaxTopics = 4096
+	// defaultTrackerLimit is the default number of records returned per list request
+	defaultTrackerLimit = 64
+	// maxRecordSize bounds announced records
+	maxRecordSize = 4 << 10
+)
+
+// Tracker is a Source announcing the node at a tracker served by TrackerHandler and polling it for the other
+// peers of the topic. It works in browsers, where mDNS is unavailable.
+type Tracker struct {
+	// URL is the URL of the TrackerHandler.
+	URL string
+	// Header holds additional request headers, e.g. an "Authorization: Bearer" relay ticket.
+	Header map[string]string
+	// Interval is the time between announcements and polls (default 30s). It must stay below the TTL of
+	// the tracker.
+	Interval time.Duration
+}
+
+// trackerList is the JSON response to list requests.
+type trackerList struct {
+	// Records are signed peer records, base64-encoded
+	Records [][]byte `json:"records"`
+}
+
+// Run announces the node and lists the peers of topic every Interval until ctx is done or a request fails.
+func (t *Tracker) Run(ctx context.Context, topic string, self func() []byte, found func(record []byte)) error {
+	interval := t.Interval
+	if interval <= 0 {
+		interval = defaultTrackerInterval
+	}
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("topic", topic)
+	u.RawQuery = q.Encode()
+	target := u.String()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if rec := self(); rec != nil {
+			if err := fetch(ctx, http.MethodPost, target, t.Header, rec, nil); err != nil {
+				return fmt.Errorf("%w: announce: %w", ErrTrackerFailed, err)
+			}
+		}
+		var list trackerList
+		if err := fetch(ctx, http.MethodGet, target, t.Header, nil, &list); err != nil {
+			return fmt.Errorf("%w: list: %w", ErrTrackerFailed, err)
+		}
+		for _, rec := range list.Records {
+			found(rec)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// TrackerConfig configures a tracker handler.
+type TrackerConfig struct {
+	// TTL is the time an announcement stays listed (default 2 minutes). Records expiring earlier are
+	// listed until they expire.
+	TTL time.Duration
+	// MaxPeers limits the peers listed per topic (default 1024).
+	MaxPeers int
+	// MaxTopics limits the number of topics (default 4096).
+	MaxTopics int
+	// Limit is the largest number of records returned by a list request, picked at random (default 64).
+	Limit int
+	// Authenticate decides whether a client may use the tracker, see relay.TicketAuth. Without it, the
+	// tracker is open to anyone.
+	Authenticate func(r *http.Request) (identity string, err error)
+	// AllowedOrigins are the host patterns of web pages on other origins allowed to use the tracker,
+	// as in websocket.AcceptOptions.OriginPatterns.
+	AllowedOrigins []string
+}
+
+// tracker holds the announcements of a TrackerHandler.
+type tracker struct {
+	cfg TrackerConfig
+
+	mu sync.Mutex
+	// topics holds the announced records and when they stop being listed, by topic and peer
+	topics map[string]map[identity.ID]announcement
+}
+
+// announcement is a record listed by a tracker.
+type announcement struct {
+	record  []byte
+	seq     uint64
+	expires time.Time
+}
+
+// TrackerHandler returns an HTTP handler letting peers announce themselves under a topic and list the others,
+// to be served next to the relay. Peers POST their signed record to ?topic=<topic> and GET the same URL for
+// a JSON object {"records": [...]} of base64-encoded records. A Tracker source is its client.
+func TrackerHandler(cfg TrackerConfig) http.Handler {
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultTrackerTTL
+	}
+	if cfg.MaxPeers <= 0 {
+		cfg.MaxPeers = defaultTrackerMaxPeers
+	}
+	if cfg.MaxTopics <= 0 {
+		cfg.MaxTopics = defaultTrackerMaxTopics
+	}
+	if cfg.Limit <= 0 {
+		cfg.Limit = defaultTrackerLimit
+	}
+	t := &tracker{cfg: cfg, topics: make(map[string]map[identity.ID]announcement)}
+	return http.HandlerFunc(t.serveHTTP)
+}
+
+// serveHTTP handles announcement and list requests.
+func (t *tracker) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	cors.Allow(w, r, t.cfg.AllowedOrigins, "Authorization, Content-Type")
+	switch r.Method {
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodGet, http.MethodPost:
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if t.cfg.Authenticate != nil {
+		if _, err := t.cfg.Authenticate(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+	topic := r.URL.Query().Get("topic")
+	if topic == "" || len(topic) > maxTopicLen {
+		http.Error(w, ErrInvalidTopic.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxRecordSize+1))
+		if err != nil {
+			return
+		}
+		if len(data) > maxRecordSize {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		rec, err := identity.OpenRecord(data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !t.announce(topic, rec, data) {
+			http.Error(w, ErrTrackerFull.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	limit := t.cfg.Limit
+	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 && n < limit {
+		limit = n
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(trackerList{Records: t.list(topic, limit)})
+}
+
+// announce lists a verified record under topic and reports whether there was room for it.
+func (t *tracker) announce(topic string, rec *identity.Record, raw []byte) bool {
+	now := time.Now()
+	expires := now.Add(t.cfg.TTL)
+	if rec.Expires.Before(expires) {
+		expires = rec.Expires
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	peers := t.topics[topic]
+	if peers == nil {
+		if len(t.topics) >= t.cfg.MaxTopics {
+			t.sweep(now)
+			if len(t.topics) >= t.cfg.MaxTopics {
+				return false
+			}
+		}
+		peers = make(map[identity.ID]announcement)
+		t.topics[topic] = peers
+	}
+	old, ok := peers[rec.ID]
+	if !ok && len(peers) >= t.cfg.MaxPeers {
+		expire(peers, now)
+		if len(peers) >= t.cfg.MaxPeers {
+			return false
+		}
+	}
+	// Replayed older records must not replace or extend the newer one
+	if ok && old.seq > rec.Seq {
+		return true
+	}
+	peers[rec.ID] = announcement{record: raw, seq: rec.Seq, expires: expires}
+	return true
+}
+
+// list returns up to limit random records announced under topic.
+func (t *tracker) list(topic string, limit int) [][]byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	peers := t.topics[topic]
+	expire(peers, time.Now())
+	if len(peers) == 0 {
+		delete(t.topics, topic)
+		return [][]byte{}
+	}
+	records := make([][]byte, 0, min(limit, len(peers)))
+	// Map iteration order is random enough for sampling
+	for _, a := range peers {
+		if len(records) == limit {
+			break
+		}
+		records = append(records, a.record)
+	}
+	return records
+}
+
+// sweep removes expired announcements and empty topics. The caller must hold mu.
+func (t *tracker) sweep(now time.Time) {
+	for topic, peers := range t.topics {
+		expire(peers, now)
+		if len(peers) == 0 {
+			delete(t.topics, topic)
+		}
+	}
+}
+
+// expire removes the expired announcements of a topic.
+func expire(peers map[identity.ID]announcement, now time.Time) {
+	for id, a := range peers {
+		if now.After(a.expires) {
+			delete(peers, id)
+		}
+	}
+}
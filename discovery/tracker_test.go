@@ -0,0 +1,176 @@
+//go:build !js
+
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+)
+
+// testTimeout bounds every blocking call of a test
+const testTimeout = 10 * time.Second
+
+// newRecord returns a signed record of a new identity, valid for ttl.
+func newRecord(t *testing.T, ttl time.Duration) (*identity.Identity, []byte) {
+	t.Helper()
+	id, err := identity.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := id.NewRecord(nil, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id, record
+}
+
+// serve sends a request with an optional body and Origin header to h and returns the response.
+func serve(h http.Handler, method, target string, body []byte, origin string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, bytes.NewReader(body))
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// list returns the records listed by h under topic.
+func list(t *testing.T, h http.Handler, topic string) [][]byte {
+	t.Helper()
+	w := serve(h, http.MethodGet, "/?topic="+topic, nil, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: %d %s", w.Code, w.Body)
+	}
+	var l trackerList
+	if err := json.Unmarshal(w.Body.Bytes(), &l); err != nil {
+		t.Fatal(err)
+	}
+	return l.Records
+}
+
+func TestTrackerAnnounce(t *testing.T) {
+	h := TrackerHandler(TrackerConfig{MaxPeers: 2})
+	id, older := newRecord(t, time.Hour)
+	newer, err := id.NewRecord([]string{"wss://peer.example.com"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := bytes.Clone(newer)
+	forged[len(forged)-1] ^= 1
+
+	for name, tc := range map[string]struct {
+		target string
+		body   []byte
+		code   int
+	}{
+		"announce":     {"/?topic=chat", newer, http.StatusNoContent},
+		"replay":       {"/?topic=chat", older, http.StatusNoContent},
+		"forged":       {"/?topic=chat", forged, http.StatusBadRequest},
+		"no topic":     {"/", newer, http.StatusBadRequest},
+		"large record": {"/?topic=chat", make([]byte, maxRecordSize+1), http.StatusRequestEntityTooLarge},
+	} {
+		if w := serve(h, http.MethodPost, tc.target, tc.body, ""); w.Code != tc.code {
+			t.Fatalf("%s: %d %s, want %d", name, w.Code, w.Body, tc.code)
+		}
+	}
+	// The replayed older record does not replace the newer one
+	if records := list(t, h, "chat"); len(records) != 1 || !bytes.Equal(records[0], newer) {
+		t.Fatalf("listed %d records, want the newer record only", len(records))
+	}
+	if records := list(t, h, "other"); len(records) != 0 {
+		t.Fatalf("listed %d records under another topic", len(records))
+	}
+
+	_, second := newRecord(t, time.Hour)
+	_, third := newRecord(t, time.Hour)
+	serve(h, http.MethodPost, "/?topic=chat", second, "")
+	if w := serve(h, http.MethodPost, "/?topic=chat", third, ""); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("announcement to a full topic: %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if w := serve(h, http.MethodDelete, "/?topic=chat", nil, ""); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("DELETE: %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestTrackerExpiry(t *testing.T) {
+	h := TrackerHandler(TrackerConfig{TTL: time.Hour})
+	_, short := newRecord(t, 100*time.Millisecond)
+	_, long := newRecord(t, time.Hour)
+	serve(h, http.MethodPost, "/?topic=chat", short, "")
+	serve(h, http.MethodPost, "/?topic=chat", long, "")
+	if records := list(t, h, "chat"); len(records) != 2 {
+		t.Fatalf("listed %d records, want 2", len(records))
+	}
+
+	// Records expiring before the TTL are listed until they expire
+	time.Sleep(150 * time.Millisecond)
+	if records := list(t, h, "chat"); len(records) != 1 || !bytes.Equal(records[0], long) {
+		t.Fatalf("listed %d records, want the unexpired one", len(records))
+	}
+}
+
+func TestTrackerLookup(t *testing.T) {
+	srv := httptest.NewServer(TrackerHandler(TrackerConfig{}))
+	defer srv.Close()
+	_, other := newRecord(t, time.Hour)
+	serve(srv.Config.Handler, http.MethodPost, "/?topic=chat", other, "")
+
+	// The source announces itself and reports every listed record, its own included
+	_, self := newRecord(t, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	found := make(map[string]bool)
+	tr := &Tracker{URL: srv.URL, Interval: time.Millisecond}
+	err := tr.Run(ctx, "chat", func() []byte { return self }, func(record []byte) {
+		found[string(record)] = true
+		if len(found) == 2 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run: %v, want context.Canceled", err)
+	}
+	if !found[string(self)] || !found[string(other)] {
+		t.Fatal("announced records not found")
+	}
+
+	rejecting := httptest.NewServer(TrackerHandler(TrackerConfig{Authenticate: func(r *http.Request) (string, error) {
+		return "", errors.New("invalid ticket")
+	}}))
+	defer rejecting.Close()
+	tr = &Tracker{URL: rejecting.URL, Header: map[string]string{"Authorization": "Bearer ticket"}}
+	if err := tr.Run(context.Background(), "chat", func() []byte { return self }, nil); !errors.Is(err, ErrTrackerFailed) {
+		t.Fatalf("Run against a rejecting tracker: %v, want ErrTrackerFailed", err)
+	}
+}
+
+func TestTrackerOrigin(t *testing.T) {
+	h := TrackerHandler(TrackerConfig{AllowedOrigins: []string{"*.Example.com"}})
+	for origin, allowed := range map[string]bool{
+		"https://app.example.com": true,
+		"https://APP.example.com": true,
+		"https://example.com":     false,
+		"https://app.example.org": false,
+		"http://%zz":              false,
+	} {
+		w := serve(h, http.MethodOptions, "/?topic=chat", nil, origin)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("preflight from %s: %d, want %d", origin, w.Code, http.StatusNoContent)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); allowed && got != origin || !allowed && got != "" {
+			t.Fatalf("preflight from %s allowed %q", origin, got)
+		}
+	}
+	if w := serve(h, http.MethodGet, "/?topic=chat", nil, ""); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatal("CORS headers set for a same-origin request")
+	}
+}
@@ -0,0 +1,245 @@
+// Package filesync mirrors a folder from one peer to another over any reliable message connection of the
+// overlay, such as a p2p stream, a circuit or a WebRTC data channel. Files are split into content-addressed
+// chunks listed in a manifest; a pulling peer compares the manifest with its own folder, reuses every chunk it
+// already holds anywhere in the folder and fetches only the rest. Downloads are staged in persistent files, so
+// an interrupted pull resumes where it stopped:
+//
+//	src, _ := filesync.New(filesync.Config{Storage: dir})
+//	go src.Serve(ctx, conn)
+//
+//	dst, _ := filesync.New(filesync.Config{Storage: replica, Delete: true})
+//	changes, err := dst.Pull(ctx, conn)
+//
+// Folders are stored in a directory natively (OpenDir) and in the origin private file system in browsers
+// (NewOPFS). Sync is one-way: the pulling folder is made to match the serving one.
+package filesync
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"pkg.gfire.dev/supernet/logging"
+	"pkg.gfire.dev/supernet/msgconn"
+)
+
+var (
+	// ErrInvalidManifest is returned when a peer sends an inconsistent manifest
+	ErrInvalidManifest = errors.New("invalid sync manifest")
+	// ErrInvalidPath is returned for paths that are not valid slash-separated relative paths
+	ErrInvalidPath = errors.New("invalid sync path")
+	// ErrChanged is returned by Pull when a file of the serving folder changed during the pull; pulling
+	// again picks up the new contents
+	ErrChanged = errors.New("sync source changed")
+	// ErrRemote is returned when the serving peer fails a request
+	ErrRemote = errors.New("sync peer failed")
+	// ErrUnexpectedMessage is returned when the remote side sends a message out of order
+	ErrUnexpectedMessage = msgconn.ErrUnexpected
+)
+
+const (
+	// DefaultChunkSize is the default chunk size; it keeps messages within the limits of all data channel implementations.
+	DefaultChunkSize = 64 << 10
+	// MaxChunkSize is the largest chunk size a puller accepts.
+	MaxChunkSize = 1 << 20
+	// defaultWindow is the default number of chunks requested at once
+	defaultWindow = 32
+	// maxEntryChunks is the largest number of chunk hashes sent in one manifest entry
+	maxEntryChunks = 2048
+	// maxManifestFiles is the largest number of files a puller accepts in a manifest
+	maxManifestFiles = 1 << 20
+	// stagingDir is the folder subdirectory holding partial downloads, excluded from manifests
+	stagingDir = ".supernet-sync"
+)
+
+// Conn is a message-oriented connection. Messages must be delivered reliably and in order.
+type Conn interface {
+	NextMessage() ([]byte, error)
+	Send(data []byte) error
+}
+
+// Storage holds the files of a folder. Paths are slash-separated and relative to the folder root.
+type Storage interface {
+	// Walk calls fn for every regular file of the folder, except the staging area.
+	Walk(ctx context.Context, fn func(info FileInfo) error) error
+	// Open opens a file for reading.
+	Open(ctx context.Context, name string) (File, error)
+	// Stage opens the staging file with the given key, creating it empty if it does not exist. Staging files
+	// keep their contents until committed, so interrupted downloads resume.
+	Stage(ctx context.Context, key string) (StagedFile, error)
+	// Commit replaces the file name with the closed staging file key, creating parent directories.
+	Commit(ctx context.Context, key, name string) error
+	// Remove deletes a file.
+	Remove(ctx context.Context, name string) error
+}
+
+// FileInfo describes a file of a folder.
+type FileInfo struct {
+	Path    string    // Slash-separated path relative to the folder root
+	Size    int64     // File size in bytes
+	ModTime time.Time // Modification time
+}
+
+// File is a file opened for reading.
+type File interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// StagedFile is a partial download. Written data is kept when it is closed.
+type StagedFile interface {
+	io.ReaderAt
+	io.WriterAt
+	io.Closer
+	// Truncate changes the size of the file.
+	Truncate(size int64) error
+}
+
+// Config configures a Folder.
+type Config struct {
+	// Storage holds the files of the folder. Required.
+	Storage Storage
+	// ChunkSize is the chunk size of served manifests (default 64 KiB, at most 1 MiB).
+	ChunkSize int
+	// Window is the number of chunks a pull requests at once (default 32).
+	Window int
+	// Delete makes pulls remove local files missing from the serving folder.
+	Delete bool
+	// Progress, if set, is called as a pull writes files.
+	Progress func(Progress)
+	// Logger receives sync events. Defaults to logging.For("filesync").
+	Logger *slog.Logger
+}
+
+// Progress reports the state of a pull.
+type Progress struct {
+	Files      int   // Files written
+	TotalFiles int   // Files to write
+	Done       int64 // Bytes written
+	Total      int64 // Bytes to write
+	Fetched    int64 // Bytes fetched from the peer; the rest was found locally
+}
+
+// Folder is a synced folder. It serves its manifest and chunks to peers and pulls from them.
+type Folder struct {
+	cfg Config
+
+	mu sync.Mutex
+	// hashed caches the chunk hashes of local files by path, reused while size and modification time match
+	hashed map[string]*FileEntry
+}
+
+// New creates a folder on a storage.
+func New(cfg Config) (*Folder, error) {
+	if cfg.Storage == nil {
+		return nil, errors.New("filesync: storage required")
+	}
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = DefaultChunkSize
+	}
+	if cfg.ChunkSize > MaxChunkSize {
+		cfg.ChunkSize = MaxChunkSize
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logging.For("filesync")
+	}
+	return &Folder{cfg: cfg, hashed: make(map[string]*FileEntry)}, nil
+}
+
+// Scan returns the manifest of the folder, hashing files added or modified since the last scan.
+func (f *Folder) Scan(ctx context.Context) (*Manifest, error) {
+	var infos []FileInfo
+	err := f.cfg.Storage.Walk(ctx, func(info FileInfo) error {
+		infos = append(infos, info)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{ChunkSize: f.cfg.ChunkSize}
+	seen := make(map[string]bool, len(infos))
+	buf := make([]byte, f.cfg.ChunkSize)
+	for _, info := range infos {
+		seen[info.Path] = true
+		f.mu.Lock()
+		e := f.hashed[info.Path]
+		f.mu.Unlock()
+		if e == nil || e.Size != info.Size || !e.ModTime.Equal(info.ModTime) {
+			if e, err = f.hash(ctx, info, buf); err != nil {
+				return nil, err
+			}
+			f.mu.Lock()
+			f.hashed[info.Path] = e
+			f.mu.Unlock()
+		}
+		m.Files = append(m.Files, e)
+	}
+
+	f.mu.Lock()
+	for name := range f.hashed {
+		if !seen[name] {
+			delete(f.hashed, name)
+		}
+	}
+	f.mu.Unlock()
+	m.sort()
+	return m, nil
+}
+
+// hash computes the chunk hashes of a file.
+func (f *Folder) hash(ctx context.Context, info FileInfo, buf []byte) (*FileEntry, error) {
+	file, err := f.cfg.Storage.Open(ctx, info.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	e := &FileEntry{Path: info.Path, Size: info.Size, ModTime: info.ModTime}
+	e.Chunks = make([][sha256.Size]byte, numChunks(info.Size, len(buf)))
+	for i := range e.Chunks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		off, n := chunkRange(info.Size, len(buf), i)
+		if _, err := file.ReadAt(buf[:n], off); err != nil && err != io.EOF {
+			return nil, err
+		}
+		e.Chunks[i] = sha256.Sum256(buf[:n])
+	}
+	return e, nil
+}
+
+// checkPath validates a path received from a peer.
+func checkPath(name string) error {
+	if !fs.ValidPath(name) || name == "." || strings.Contains(name, "\\") ||
+		name == stagingDir || strings.HasPrefix(name, stagingDir+"/") {
+		return fmt.Errorf("%w: %q", ErrInvalidPath, name)
+	}
+	return nil
+}
+
+// numChunks returns the number of chunks of a file.
+func numChunks(size int64, chunkSize int) int {
+	return int((size + int64(chunkSize) - 1) / int64(chunkSize))
+}
+
+// chunkRange returns the byte range of chunk i of a file.
+func chunkRange(size int64, chunkSize, i int) (off int64, n int) {
+	off = int64(i) * int64(chunkSize)
+	n = chunkSize
+	if rest := size - off; rest < int64(n) {
+		n = int(rest)
+	}
+	return off, n
+}
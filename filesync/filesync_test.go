@@ -0,0 +1,221 @@
+//go:build !js
+
+package filesync
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"pkg.gfire.dev/supernet/memtransport"
+	"pkg.gfire.dev/supernet/msgconn"
+	snsync "pkg.gfire.dev/supernet/proto/snsync/v1alpha1"
+)
+
+// testTimeout bounds every blocking call of a test
+const testTimeout = 10 * time.Second
+
+// testChunkSize keeps chunks small, so the test files span several of them
+const testChunkSize = 4
+
+// newFolder creates a folder on a new directory holding files, mapping slash-separated paths to contents.
+func newFolder(t *testing.T, cfg Config, files map[string]string) (*Folder, string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	storage, err := OpenDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	cfg.Storage = storage
+	cfg.ChunkSize = testChunkSize
+	f, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, dir
+}
+
+// pull pulls src into dst over a new connection and returns the changes applied.
+func pull(t *testing.T, src, dst *Folder) ([]Change, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	a, b := memtransport.Pipe(memtransport.Config{})
+	defer a.Close()
+	go src.Serve(ctx, b)
+	return dst.Pull(ctx, a)
+}
+
+// expectFiles compares the regular files below dir, outside the staging area, with want.
+func expectFiles(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+	got := make(map[string]string)
+	err := filepath.WalkDir(dir, func(p string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			if entry != nil && entry.Name() == stagingDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		got[filepath.ToSlash(rel)] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("folder holds %d files, want %d", len(got), len(want))
+	}
+	for name, data := range want {
+		if got[name] != data {
+			t.Fatalf("%s: %q, want %q", name, got[name], data)
+		}
+	}
+}
+
+// entry returns a manifest entry of a file with the given contents.
+func entry(name, data string) *FileEntry {
+	e := &FileEntry{Path: name, Size: int64(len(data))}
+	for off := 0; off < len(data); off += testChunkSize {
+		e.Chunks = append(e.Chunks, sha256.Sum256([]byte(data[off:min(off+testChunkSize, len(data))])))
+	}
+	return e
+}
+
+// ops summarizes changes as "op path" strings.
+func ops(changes []Change) []string {
+	var s []string
+	for _, c := range changes {
+		s = append(s, c.Op.String()+" "+c.Path)
+	}
+	return s
+}
+
+func TestDiff(t *testing.T) {
+	from := &Manifest{ChunkSize: testChunkSize, Files: []*FileEntry{
+		entry("a", "unchanged"),
+		entry("b", "modified"),
+		entry("c", "deleted"),
+		entry("d", "resized"),
+	}}
+	for name, tc := range map[string]struct {
+		to   *Manifest
+		want []string
+	}{
+		"identical": {from, nil},
+		"changes": {&Manifest{ChunkSize: testChunkSize, Files: []*FileEntry{
+			entry("a", "unchanged"),
+			entry("b", "MODIFIED"),
+			entry("d", "resized!"),
+			entry("e", "added"),
+		}}, []string{"modify b", "delete c", "modify d", "add e"}},
+		"touched": {&Manifest{ChunkSize: testChunkSize, Files: []*FileEntry{
+			{Path: "a", Size: 9, ModTime: time.Now(), Chunks: entry("a", "unchanged").Chunks},
+		}}, []string{"delete b", "delete c", "delete d"}},
+		"chunk size": {&Manifest{ChunkSize: 2 * testChunkSize, Files: from.Files}, []string{
+			"modify a", "modify b", "modify c", "modify d",
+		}},
+	} {
+		if got := ops(Diff(from, tc.to)); !slices.Equal(got, tc.want) {
+			t.Fatalf("%s: %q, want %q", name, got, tc.want)
+		}
+	}
+	if got := ops(Diff(&Manifest{}, from)); !slices.Equal(got, []string{"add a", "add b", "add c", "add d"}) {
+		t.Fatalf("diff from an empty folder: %q", got)
+	}
+}
+
+func TestPull(t *testing.T) {
+	files := map[string]string{
+		"a.txt":        "hello, world",
+		"dir/b.txt":    "nested file",
+		"dir/copy.txt": "hello, world",
+		"empty":        "",
+	}
+	src, _ := newFolder(t, Config{}, files)
+	var last Progress
+	dst, dir := newFolder(t, Config{Delete: true, Progress: func(p Progress) { last = p }}, map[string]string{
+		"a.txt":   "hello, there",
+		"old.txt": "removed",
+	})
+
+	changes, err := pull(t, src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"modify a.txt", "add dir/b.txt", "add dir/copy.txt", "add empty", "delete old.txt"}
+	if got := ops(changes); !slices.Equal(got, want) {
+		t.Fatalf("changes %q, want %q", got, want)
+	}
+	expectFiles(t, dir, files)
+	// The chunks dst already held and the duplicate file are not fetched again
+	if last.Files != 4 || last.Done != last.Total || last.Fetched >= last.Total {
+		t.Fatalf("progress %+v, want 4 files done with part of them found locally", last)
+	}
+
+	if changes, err := pull(t, src, dst); err != nil || len(changes) != 0 {
+		t.Fatalf("second pull: %q, %v, want no changes", ops(changes), err)
+	}
+
+	// Without Delete, local files missing from the serving folder are kept
+	keep, dir := newFolder(t, Config{}, map[string]string{"local.txt": "kept"})
+	if _, err := pull(t, src, keep); err != nil {
+		t.Fatal(err)
+	}
+	files["local.txt"] = "kept"
+	expectFiles(t, dir, files)
+}
+
+func TestPullUnexpected(t *testing.T) {
+	dst, _ := newFolder(t, Config{}, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for name, tc := range map[string]struct {
+		reply *snsync.Frame
+		err   error
+	}{
+		"out of order": {&snsync.Frame{Body: &snsync.Frame_GetChunks{GetChunks: &snsync.GetChunks{}}}, ErrUnexpectedMessage},
+		"remote error": {&snsync.Frame{Body: &snsync.Frame_Error{Error: &snsync.Error{Message: "scan failed"}}}, ErrRemote},
+	} {
+		a, b := memtransport.Pipe(memtransport.Config{})
+		go func() {
+			var req snsync.Frame
+			if msgconn.RecvVT(b, &req) == nil {
+				msgconn.SendVT(b, tc.reply)
+			}
+		}()
+		if _, err := dst.Pull(ctx, a); !errors.Is(err, tc.err) {
+			t.Fatalf("%s: %v, want %v", name, err, tc.err)
+		}
+		a.Close()
+	}
+
+	// A closed connection ends the serving side
+	src, _ := newFolder(t, Config{}, nil)
+	a, b := memtransport.Pipe(memtransport.Config{})
+	a.Close()
+	if err := src.Serve(ctx, b); err == nil {
+		t.Fatalf("Serve on a closed connection: %v, want the connection error", err)
+	}
+}
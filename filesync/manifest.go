@@ -0,0 +1,211 @@
+package filesync
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	snsync "pkg.gfire.dev/supernet/proto/snsync/v1alpha1"
+)
+
+// Manifest lists the files of a folder with the hashes of their chunks.
+type Manifest struct {
+	ChunkSize int          // Size of every chunk except the last of each file
+	Files     []*FileEntry // Files sorted by path
+}
+
+// FileEntry describes a file of a manifest.
+type FileEntry struct {
+	Path    string              // Slash-separated path relative to the folder root
+	Size    int64               // File size in bytes
+	ModTime time.Time           // Modification time
+	Chunks  [][sha256.Size]byte // SHA-256 of every chunk
+}
+
+// ID returns the SHA-256 over the chunk hashes, identifying the file content.
+func (e *FileEntry) ID() [sha256.Size]byte {
+	h := sha256.New()
+	for i := range e.Chunks {
+		h.Write(e.Chunks[i][:])
+	}
+	var id [sha256.Size]byte
+	h.Sum(id[:0])
+	return id
+}
+
+// Op is the kind of a change between two manifests.
+type Op int
+
+const (
+	Add    Op = iota // The file is new
+	Modify           // The file contents differ
+	Delete           // The file was removed
+)
+
+// String returns the lowercase name of the operation.
+func (op Op) String() string {
+	switch op {
+	case Add:
+		return "add"
+	case Modify:
+		return "modify"
+	case Delete:
+		return "delete"
+	}
+	return fmt.Sprintf("op(%d)", int(op))
+}
+
+// Change is a difference between two manifests.
+type Change struct {
+	Op   Op
+	Path string
+	// File is the entry of the new manifest, nil for deletions.
+	File *FileEntry
+}
+
+// Diff returns the changes turning the folder described by from into the one described by to, sorted by path.
+// Files are compared by content; modification times are ignored. Manifests with different chunk sizes
+// report every file as modified.
+func Diff(from, to *Manifest) []Change {
+	old := make(map[string]*FileEntry, len(from.Files))
+	for _, e := range from.Files {
+		old[e.Path] = e
+	}
+
+	var changes []Change
+	for _, e := range to.Files {
+		prev, ok := old[e.Path]
+		delete(old, e.Path)
+		switch {
+		case !ok:
+			changes = append(changes, Change{Op: Add, Path: e.Path, File: e})
+		case from.ChunkSize != to.ChunkSize || prev.Size != e.Size || prev.ID() != e.ID():
+			changes = append(changes, Change{Op: Modify, Path: e.Path, File: e})
+		}
+	}
+	for name := range old {
+		changes = append(changes, Change{Op: Delete, Path: name})
+	}
+	slices.SortFunc(changes, func(a, b Change) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return changes
+}
+
+// sort sorts the files by path.
+func (m *Manifest) sort() {
+	slices.SortFunc(m.Files, func(a, b *FileEntry) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+}
+
+// chunkRef locates a chunk in a file.
+type chunkRef struct {
+	path string
+	off  int64
+	n    int
+}
+
+// index maps the chunk hashes of the manifest to a location of each chunk.
+func (m *Manifest) index() map[[sha256.Size]byte]chunkRef {
+	index := make(map[[sha256.Size]byte]chunkRef)
+	for _, e := range m.Files {
+		for i, h := range e.Chunks {
+			if _, ok := index[h]; !ok {
+				off, n := chunkRange(e.Size, m.ChunkSize, i)
+				index[h] = chunkRef{path: e.Path, off: off, n: n}
+			}
+		}
+	}
+	return index
+}
+
+// entries converts a file entry into its wire form, splitting long chunk lists.
+func (e *FileEntry) entries() []*snsync.Entry {
+	var entries []*snsync.Entry
+	for first := 0; first == 0 || first < len(e.Chunks); first += maxEntryChunks {
+		chunks := e.Chunks[first:min(first+maxEntryChunks, len(e.Chunks))]
+		hashes := make([][]byte, len(chunks))
+		for i := range chunks {
+			hashes[i] = chunks[i][:]
+		}
+		entries = append(entries, &snsync.Entry{
+			Path:          e.Path,
+			Size:          e.Size,
+			ModTimeUnixMs: e.ModTime.UnixMilli(),
+			FirstChunk:    uint32(first),
+			Chunks:        hashes,
+		})
+	}
+	return entries
+}
+
+// manifestBuilder assembles a manifest from received entries.
+type manifestBuilder struct {
+	m    Manifest
+	last *FileEntry
+}
+
+// add adds a received entry, either a new file or the continuation of the last one.
+func (b *manifestBuilder) add(p *snsync.Entry) error {
+	if b.last != nil && p.Path == b.last.Path {
+		if int(p.FirstChunk) != len(b.last.Chunks) {
+			return ErrInvalidManifest
+		}
+	} else {
+		if err := b.complete(); err != nil {
+			return err
+		}
+		if err := checkPath(p.Path); err != nil {
+			return err
+		}
+		if p.Size < 0 || p.FirstChunk != 0 || len(b.m.Files) >= maxManifestFiles {
+			return ErrInvalidManifest
+		}
+		b.last = &FileEntry{Path: p.Path, Size: p.Size, ModTime: time.UnixMilli(p.ModTimeUnixMs)}
+	}
+	// Chunks hold at least one byte, which bounds the hashes of a file before the chunk size is known
+	if int64(len(b.last.Chunks)+len(p.Chunks)) > max(b.last.Size, 1) {
+		return ErrInvalidManifest
+	}
+	for _, h := range p.Chunks {
+		if len(h) != sha256.Size {
+			return ErrInvalidManifest
+		}
+		b.last.Chunks = append(b.last.Chunks, [sha256.Size]byte(h))
+	}
+	return nil
+}
+
+// complete adds the last file to the manifest.
+func (b *manifestBuilder) complete() error {
+	if b.last == nil {
+		return nil
+	}
+	if len(b.m.Files) > 0 && b.m.Files[len(b.m.Files)-1].Path >= b.last.Path {
+		// Files arrive sorted, which rules out duplicates
+		return ErrInvalidManifest
+	}
+	b.m.Files = append(b.m.Files, b.last)
+	b.last = nil
+	return nil
+}
+
+// finish validates the assembled manifest against its end marker.
+func (b *manifestBuilder) finish(end *snsync.ManifestEnd) (*Manifest, error) {
+	if err := b.complete(); err != nil {
+		return nil, err
+	}
+	if end.ChunkSize == 0 || end.ChunkSize > MaxChunkSize || int(end.Files) != len(b.m.Files) {
+		return nil, ErrInvalidManifest
+	}
+	b.m.ChunkSize = int(end.ChunkSize)
+	for _, e := range b.m.Files {
+		if len(e.Chunks) != numChunks(e.Size, b.m.ChunkSize) {
+			return nil, ErrInvalidManifest
+		}
+	}
+	return &b.m, nil
+}
@@ -0,0 +1,241 @@
+package filesync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"pkg.gfire.dev/supernet/msgconn"
+	snsync "pkg.gfire.dev/supernet/proto/snsync/v1alpha1"
+)
+
+// Pull fetches the manifest of the folder served on conn and makes the local folder match it, returning the
+// changes applied. Chunks are taken from partial downloads of an earlier pull and from any local file holding
+// the same content before they are fetched, so unchanged parts of modified files, renamed and duplicated
+// files cost no transfer; both sides should use the same chunk size for this to work. Local files missing
+// from the served folder are only removed with Config.Delete. After a failure, pull again to resume.
+func (f *Folder) Pull(ctx context.Context, conn Conn) ([]Change, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	frames := msgconn.NewReader(ctx, conn, msgconn.VT[snsync.Frame]())
+	remote, err := fetchManifest(ctx, conn, frames)
+	if err != nil {
+		return nil, err
+	}
+	local, err := f.Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := Diff(local, remote)
+	var p Progress
+	for _, c := range changes {
+		if c.File != nil {
+			p.TotalFiles++
+			p.Total += c.File.Size
+		}
+	}
+	f.report(p)
+
+	pl := &puller{
+		folder:    f,
+		conn:      conn,
+		frames:    frames,
+		chunkSize: remote.ChunkSize,
+		local:     local.index(),
+		reader:    newChunkReader(f.cfg.Storage),
+		progress:  p,
+	}
+	defer pl.reader.close()
+
+	// Files are written before any is deleted, so the chunks of renamed files are still found locally
+	var applied []Change
+	for _, c := range changes {
+		if c.Op == Delete {
+			continue
+		}
+		if err := pl.fetch(ctx, c.File); err != nil {
+			return applied, fmt.Errorf("%s: %w", c.Path, err)
+		}
+		f.cfg.Logger.Debug("filesync wrote file", "op", c.Op, "path", c.Path)
+		applied = append(applied, c)
+	}
+	for _, c := range changes {
+		if c.Op != Delete || !f.cfg.Delete {
+			continue
+		}
+		if err := f.cfg.Storage.Remove(ctx, c.Path); err != nil {
+			return applied, fmt.Errorf("%s: %w", c.Path, err)
+		}
+		f.cfg.Logger.Debug("filesync removed file", "path", c.Path)
+		applied = append(applied, c)
+	}
+	return applied, nil
+}
+
+// fetchManifest requests and receives the manifest of the served folder.
+func fetchManifest(ctx context.Context, conn Conn, frames *msgconn.Reader[*snsync.Frame]) (*Manifest, error) {
+	if err := msgconn.SendVT(conn, &snsync.Frame{Body: &snsync.Frame_GetManifest{GetManifest: &snsync.GetManifest{}}}); err != nil {
+		return nil, err
+	}
+	var b manifestBuilder
+	for {
+		frame, err := frames.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		switch body := frame.Body.(type) {
+		case *snsync.Frame_Entry:
+			if err := b.add(body.Entry); err != nil {
+				return nil, err
+			}
+		case *snsync.Frame_ManifestEnd:
+			return b.finish(body.ManifestEnd)
+		case *snsync.Frame_Error:
+			return nil, fmt.Errorf("%w: %s", ErrRemote, body.Error.Message)
+		default:
+			return nil, ErrUnexpectedMessage
+		}
+	}
+}
+
+// puller writes the files of a pull.
+type puller struct {
+	folder    *Folder
+	conn      Conn
+	frames    *msgconn.Reader[*snsync.Frame]
+	chunkSize int
+	// local locates the chunks of the local folder as scanned before the pull
+	local    map[[sha256.Size]byte]chunkRef
+	reader   *chunkReader
+	progress Progress
+}
+
+// fetch writes the new contents of a file into its staging file and commits it.
+func (pl *puller) fetch(ctx context.Context, e *FileEntry) error {
+	storage := pl.folder.cfg.Storage
+	key := stageKey(e.Path)
+	staged, err := storage.Stage(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if staged != nil {
+			staged.Close()
+		}
+	}()
+
+	// Collect the chunks found neither in the staging file nor elsewhere in the folder
+	buf := make([]byte, pl.chunkSize)
+	need := make(map[[sha256.Size]byte][]int)
+	var missing [][sha256.Size]byte
+	for i, h := range e.Chunks {
+		off, n := chunkRange(e.Size, pl.chunkSize, i)
+		if m, err := staged.ReadAt(buf[:n], off); m == n && (err == nil || err == io.EOF) && sha256.Sum256(buf[:n]) == h {
+			pl.progress.Done += int64(n)
+			continue
+		}
+		if ref, ok := pl.local[h]; ok {
+			if data, ok := pl.reader.read(ctx, ref, h, buf); ok {
+				if _, err := staged.WriteAt(data, off); err != nil {
+					return err
+				}
+				pl.progress.Done += int64(n)
+				continue
+			}
+		}
+		if need[h] == nil {
+			missing = append(missing, h)
+		}
+		need[h] = append(need[h], i)
+	}
+	pl.folder.report(pl.progress)
+
+	window := min(pl.folder.cfg.Window, maxChunkRequest)
+	for len(missing) > 0 {
+		batch := missing[:min(window, len(missing))]
+		missing = missing[len(batch):]
+		hashes := make([][]byte, len(batch))
+		for i := range batch {
+			hashes[i] = batch[i][:]
+		}
+		req := &snsync.GetChunks{Hashes: hashes}
+		if err := msgconn.SendVT(pl.conn, &snsync.Frame{Body: &snsync.Frame_GetChunks{GetChunks: req}}); err != nil {
+			return err
+		}
+
+		for _, h := range batch {
+			data, err := pl.receive(ctx, h)
+			if err != nil {
+				return err
+			}
+			for _, i := range need[h] {
+				off, _ := chunkRange(e.Size, pl.chunkSize, i)
+				if _, err := staged.WriteAt(data, off); err != nil {
+					return err
+				}
+				pl.progress.Done += int64(len(data))
+			}
+			pl.progress.Fetched += int64(len(data))
+			pl.folder.report(pl.progress)
+		}
+	}
+
+	if err := staged.Truncate(e.Size); err != nil {
+		return err
+	}
+	err = staged.Close()
+	staged = nil
+	if err != nil {
+		return err
+	}
+	if err := storage.Commit(ctx, key, e.Path); err != nil {
+		return err
+	}
+	pl.progress.Files++
+	pl.folder.report(pl.progress)
+	return nil
+}
+
+// receive receives and verifies the requested chunk with hash h.
+func (pl *puller) receive(ctx context.Context, h [sha256.Size]byte) ([]byte, error) {
+	frame, err := pl.frames.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch body := frame.Body.(type) {
+	case *snsync.Frame_Chunk:
+		chunk := body.Chunk
+		if !bytes.Equal(chunk.Hash, h[:]) {
+			return nil, ErrUnexpectedMessage
+		}
+		if chunk.Missing {
+			return nil, ErrChanged
+		}
+		if sha256.Sum256(chunk.Data) != h {
+			return nil, fmt.Errorf("%w: chunk does not match its hash", ErrUnexpectedMessage)
+		}
+		return chunk.Data, nil
+	case *snsync.Frame_Error:
+		return nil, fmt.Errorf("%w: %s", ErrRemote, body.Error.Message)
+	}
+	return nil, ErrUnexpectedMessage
+}
+
+// report calls the progress callback.
+func (f *Folder) report(p Progress) {
+	if f.cfg.Progress != nil {
+		f.cfg.Progress(p)
+	}
+}
+
+// stageKey returns the key of the staging file of a path. Keying by path lets a pull resume into the partial
+// download of an earlier version of the file, whose matching chunks are kept.
+func stageKey(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,135 @@
+package filesync
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"pkg.gfire.dev/supernet/msgconn"
+	snsync "pkg.gfire.dev/supernet/proto/snsync/v1alpha1"
+)
+
+// maxChunkRequest is the largest number of chunks served per GetChunks request
+const maxChunkRequest = 256
+
+// Serve answers the manifest and chunk requests of pulling peers on conn until it fails or ctx is done,
+// returning the error that ended it. Manifests are scanned afresh for every request, so peers pull the
+// current contents of the folder.
+func (f *Folder) Serve(ctx context.Context, conn Conn) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	frames := msgconn.NewReader(ctx, conn, msgconn.VT[snsync.Frame]())
+	var index map[[sha256.Size]byte]chunkRef
+	for {
+		frame, err := frames.Next(ctx)
+		if err != nil {
+			return err
+		}
+		switch body := frame.Body.(type) {
+		case *snsync.Frame_GetManifest:
+			m, err := f.Scan(ctx)
+			if err != nil {
+				f.cfg.Logger.Warn("filesync scan failed", "err", err)
+				if err := sendError(conn, err); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := sendManifest(conn, m); err != nil {
+				return err
+			}
+			index = m.index()
+		case *snsync.Frame_GetChunks:
+			if len(body.GetChunks.Hashes) > maxChunkRequest {
+				return fmt.Errorf("%w: %d chunks requested", ErrUnexpectedMessage, len(body.GetChunks.Hashes))
+			}
+			if err := f.sendChunks(ctx, conn, index, body.GetChunks.Hashes); err != nil {
+				return err
+			}
+		default:
+			return ErrUnexpectedMessage
+		}
+	}
+}
+
+// sendManifest sends the entries of a manifest and its end marker.
+func sendManifest(conn Conn, m *Manifest) error {
+	for _, e := range m.Files {
+		for _, entry := range e.entries() {
+			if err := msgconn.SendVT(conn, &snsync.Frame{Body: &snsync.Frame_Entry{Entry: entry}}); err != nil {
+				return err
+			}
+		}
+	}
+	end := &snsync.ManifestEnd{ChunkSize: uint32(m.ChunkSize), Files: uint32(len(m.Files))}
+	return msgconn.SendVT(conn, &snsync.Frame{Body: &snsync.Frame_ManifestEnd{ManifestEnd: end}})
+}
+
+// sendChunks answers a chunk request from the files of the last served manifest. Chunks whose file changed
+// since are reported missing.
+func (f *Folder) sendChunks(ctx context.Context, conn Conn, index map[[sha256.Size]byte]chunkRef, hashes [][]byte) error {
+	r := newChunkReader(f.cfg.Storage)
+	defer r.close()
+
+	buf := make([]byte, f.cfg.ChunkSize)
+	for _, h := range hashes {
+		chunk := &snsync.Chunk{Hash: h, Missing: true}
+		if len(h) == sha256.Size {
+			if ref, ok := index[[sha256.Size]byte(h)]; ok {
+				if data, ok := r.read(ctx, ref, [sha256.Size]byte(h), buf); ok {
+					chunk.Data, chunk.Missing = data, false
+				}
+			}
+		}
+		if err := msgconn.SendVT(conn, &snsync.Frame{Body: &snsync.Frame_Chunk{Chunk: chunk}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendError fails a request.
+func sendError(conn Conn, err error) error {
+	return msgconn.SendVT(conn, &snsync.Frame{Body: &snsync.Frame_Error{Error: &snsync.Error{Message: err.Error()}}})
+}
+
+// chunkReader reads verified chunks from the files of a folder, keeping the files it opened until closed.
+type chunkReader struct {
+	storage Storage
+	files   map[string]File
+}
+
+// newChunkReader creates a chunk reader on a storage.
+func newChunkReader(storage Storage) *chunkReader {
+	return &chunkReader{storage: storage, files: make(map[string]File)}
+}
+
+// read reads a chunk into buf and reports whether its contents still match hash.
+func (r *chunkReader) read(ctx context.Context, ref chunkRef, hash [sha256.Size]byte, buf []byte) ([]byte, bool) {
+	if ref.n > len(buf) {
+		return nil, false
+	}
+	file, ok := r.files[ref.path]
+	if !ok {
+		var err error
+		if file, err = r.storage.Open(ctx, ref.path); err != nil {
+			return nil, false
+		}
+		r.files[ref.path] = file
+	}
+	data := buf[:ref.n]
+	if n, err := file.ReadAt(data, ref.off); n != ref.n || (err != nil && err != io.EOF) {
+		return nil, false
+	}
+	return data, sha256.Sum256(data) == hash
+}
+
+// close closes the opened files.
+func (r *chunkReader) close() {
+	for _, file := range r.files {
+		file.Close()
+	}
+	clear(r.files)
+}
@@ -0,0 +1,209 @@
+package filesync
+
+import (
+	"context"
+	"io"
+	"path"
+	"strings"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/opfsjs"
+)
+
+// copyBufferSize is the size of the pieces staged files are copied in when committed
+const copyBufferSize = 1 << 20
+
+// OPFS is a Storage in a directory of the origin private file system. Partial downloads are staged in its
+// .supernet-sync subdirectory; their written chunks are kept when a pull ends, including by an error, but not
+// when the page is closed during the pull.
+type OPFS struct {
+	root *opfsjs.Dir
+}
+
+// NewOPFS returns a storage in an OPFS directory, e.g. opfsjs.Root or one of its subdirectories.
+func NewOPFS(root *opfsjs.Dir) *OPFS {
+	return &OPFS{root: root}
+}
+
+// Walk calls fn for every file below the directory, except the staging area.
+func (o *OPFS) Walk(ctx context.Context, fn func(info FileInfo) error) error {
+	return o.walk(ctx, o.root, "", fn)
+}
+
+// walk calls fn for every file below dir, whose path is prefix.
+func (o *OPFS) walk(ctx context.Context, dir *opfsjs.Dir, prefix string, fn func(info FileInfo) error) error {
+	entries, err := dir.Entries(ctx)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := path.Join(prefix, entry.Name)
+		if entry.Dir {
+			if name == stagingDir {
+				continue
+			}
+			sub, err := dir.Dir(ctx, entry.Name, false)
+			if err != nil {
+				return err
+			}
+			if err := o.walk(ctx, sub, name, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		file, err := dir.File(ctx, entry.Name, false)
+		if err != nil {
+			return err
+		}
+		size, err := file.Size(ctx)
+		if err != nil {
+			return err
+		}
+		modTime, err := file.ModTime(ctx)
+		if err != nil {
+			return err
+		}
+		if err := fn(FileInfo{Path: name, Size: size, ModTime: modTime}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Open opens a file for reading.
+func (o *OPFS) Open(ctx context.Context, name string) (File, error) {
+	dir, err := o.dir(ctx, path.Dir(name), false)
+	if err != nil {
+		return nil, err
+	}
+	file, err := dir.File(ctx, path.Base(name), false)
+	if err != nil {
+		return nil, err
+	}
+	return opfsFile{file}, nil
+}
+
+// Stage opens a staging file. Its writes become readable once it is closed.
+func (o *OPFS) Stage(ctx context.Context, key string) (StagedFile, error) {
+	dir, err := o.root.Dir(ctx, stagingDir, true)
+	if err != nil {
+		return nil, err
+	}
+	file, err := dir.File(ctx, key, true)
+	if err != nil {
+		return nil, err
+	}
+	w, err := file.Writable(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	return &opfsStaged{file: file, writable: w}, nil
+}
+
+// Commit copies a staging file into place and removes it, as OPFS cannot move files everywhere.
+func (o *OPFS) Commit(ctx context.Context, key, name string) error {
+	staging, err := o.root.Dir(ctx, stagingDir, false)
+	if err != nil {
+		return err
+	}
+	src, err := staging.File(ctx, key, false)
+	if err != nil {
+		return err
+	}
+	dir, err := o.dir(ctx, path.Dir(name), true)
+	if err != nil {
+		return err
+	}
+	dst, err := dir.File(ctx, path.Base(name), true)
+	if err != nil {
+		return err
+	}
+
+	// The destination changes atomically when the writable is closed
+	w, err := dst.Writable(ctx, false)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, copyBufferSize)
+	for off := int64(0); ; {
+		n, err := src.ReadAtContext(ctx, buf, off)
+		if n > 0 {
+			if _, err := w.WriteAtContext(ctx, buf[:n], off); err != nil {
+				w.Abort()
+				return err
+			}
+			off += int64(n)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			w.Abort()
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return staging.Remove(ctx, key, false)
+}
+
+// Remove deletes a file.
+func (o *OPFS) Remove(ctx context.Context, name string) error {
+	dir, err := o.dir(ctx, path.Dir(name), false)
+	if err != nil {
+		return err
+	}
+	return dir.Remove(ctx, path.Base(name), false)
+}
+
+// dir returns the directory at a slash-separated path, creating missing directories if create is set.
+func (o *OPFS) dir(ctx context.Context, name string, create bool) (*opfsjs.Dir, error) {
+	dir := o.root
+	if name == "." {
+		return dir, nil
+	}
+	for _, part := range strings.Split(name, "/") {
+		var err error
+		if dir, err = dir.Dir(ctx, part, create); err != nil {
+			return nil, err
+		}
+	}
+	return dir, nil
+}
+
+// opfsFile is an OPFS file opened for reading.
+type opfsFile struct {
+	*opfsjs.File
+}
+
+// Close does nothing; OPFS file handles hold no resources.
+func (opfsFile) Close() error {
+	return nil
+}
+
+// opfsStaged is an OPFS staging file being written.
+type opfsStaged struct {
+	file     *opfsjs.File
+	writable *opfsjs.Writable
+}
+
+// ReadAt reads the contents committed when the file was last closed.
+func (s *opfsStaged) ReadAt(p []byte, off int64) (int, error) {
+	return s.file.ReadAt(p, off)
+}
+
+// WriteAt writes data that becomes visible once the file is closed.
+func (s *opfsStaged) WriteAt(p []byte, off int64) (int, error) {
+	return s.writable.WriteAt(p, off)
+}
+
+// Truncate changes the size of the file.
+func (s *opfsStaged) Truncate(size int64) error {
+	return s.writable.Truncate(context.Background(), size)
+}
+
+// Close commits the written data.
+func (s *opfsStaged) Close() error {
+	return s.writable.Close()
+}
@@ -0,0 +1,95 @@
+//go:build !js
+
+package filesync
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+)
+
+// Dir is a Storage in a directory of the file system. Access is confined to the directory; partial downloads
+// are staged in its .supernet-sync subdirectory.
+type Dir struct {
+	root *os.Root
+}
+
+// OpenDir opens the directory at name as a storage, creating it if needed.
+func OpenDir(name string) (*Dir, error) {
+	if err := os.MkdirAll(name, 0o755); err != nil {
+		return nil, err
+	}
+	root, err := os.OpenRoot(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Dir{root: root}, nil
+}
+
+// Close closes the directory.
+func (d *Dir) Close() error {
+	return d.root.Close()
+}
+
+// Walk calls fn for every regular file below the directory, except the staging area.
+func (d *Dir) Walk(ctx context.Context, fn func(info FileInfo) error) error {
+	return fs.WalkDir(d.root.FS(), ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if name == stagingDir {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return fn(FileInfo{Path: name, Size: info.Size(), ModTime: info.ModTime()})
+	})
+}
+
+// Open opens a file for reading.
+func (d *Dir) Open(ctx context.Context, name string) (File, error) {
+	f, err := d.root.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Stage opens a staging file.
+func (d *Dir) Stage(ctx context.Context, key string) (StagedFile, error) {
+	if err := d.root.MkdirAll(stagingDir, 0o755); err != nil {
+		return nil, err
+	}
+	f, err := d.root.OpenFile(path.Join(stagingDir, key), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Commit moves a staging file into place.
+func (d *Dir) Commit(ctx context.Context, key, name string) error {
+	if dir := path.Dir(name); dir != "." {
+		if err := d.root.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return d.root.Rename(path.Join(stagingDir, key), name)
+}
+
+// Remove deletes a file.
+func (d *Dir) Remove(ctx context.Context, name string) error {
+	return d.root.Remove(name)
+}
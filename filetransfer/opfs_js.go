@@ -0,0 +1,68 @@
+package filetransfer
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/opfsjs"
+)
+
+// ErrInvalidName is returned when an offered file name cannot be used as an OPFS entry name
+var ErrInvalidName = errors.New("invalid file name")
+
+// OPFSSink is a Sink writing into a file of the origin private file system.
+// Written data is committed when the sink is closed, which Receive does on return, so an interrupted
+// transfer keeps its verified chunks for resumption.
+type OPFSSink struct {
+	file     *opfsjs.File
+	writable *opfsjs.Writable
+}
+
+// NewOPFSSink opens file for writing, keeping its existing contents.
+func NewOPFSSink(ctx context.Context, file *opfsjs.File) (*OPFSSink, error) {
+	w, err := file.Writable(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	return &OPFSSink{file: file, writable: w}, nil
+}
+
+// ReadAt reads committed data.
+func (s *OPFSSink) ReadAt(p []byte, off int64) (int, error) {
+	return s.file.ReadAt(p, off)
+}
+
+// WriteAt writes data that becomes visible once the sink is closed.
+func (s *OPFSSink) WriteAt(p []byte, off int64) (int, error) {
+	return s.writable.WriteAt(p, off)
+}
+
+// Close commits the written data.
+func (s *OPFSSink) Close() error {
+	return s.writable.Close()
+}
+
+// AcceptOPFS returns an AcceptFunc storing offered files in dir under their offered name.
+// If filter is set, offers for which it returns an error are rejected.
+func AcceptOPFS(dir *opfsjs.Dir, filter func(*Offer) error) AcceptFunc {
+	return func(ctx context.Context, offer *Offer) (Sink, error) {
+		if filter != nil {
+			if err := filter(offer); err != nil {
+				return nil, err
+			}
+		}
+
+		name := path.Base(offer.Name)
+		if name == "." || name == ".." || name == "/" || strings.ContainsAny(name, "/\\\x00") {
+			return nil, ErrInvalidName
+		}
+
+		file, err := dir.File(ctx, name, true)
+		if err != nil {
+			return nil, err
+		}
+		return NewOPFSSink(ctx, file)
+	}
+}
@@ -0,0 +1,133 @@
+package filetransfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+
+	"pkg.gfire.dev/supernet/msgconn"
+	snfile "pkg.gfire.dev/supernet/proto/snfile/v1alpha1"
+)
+
+// Sink stores a received file. ReadAt must return previously written data, which lets a resumed
+// transfer detect the chunks already present. Sinks implementing io.Closer are closed when Receive returns.
+type Sink interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// AcceptFunc decides whether to accept an offer and returns the sink to write it to.
+// Returning an error rejects the transfer with the error message as reason.
+type AcceptFunc func(ctx context.Context, offer *Offer) (Sink, error)
+
+// Receive waits for an offer on conn and receives the file into the sink returned by accept.
+// Chunks already present in the sink with matching hashes are skipped, so receiving the same offer
+// again after a disconnect resumes the transfer.
+func Receive(ctx context.Context, conn Conn, accept AcceptFunc, opts Options) (*Offer, error) {
+	opts = opts.withDefaults()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	frames := msgconn.NewReader(ctx, conn, msgconn.VT[snfile.Frame]())
+
+	frame, err := frames.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	body, ok := frame.Body.(*snfile.Frame_Offer)
+	if !ok {
+		return nil, ErrUnexpectedMessage
+	}
+	offer, err := offerFromProto(body.Offer)
+	if err != nil {
+		reject(conn, body.Offer.TransferId, err)
+		return nil, err
+	}
+
+	sink, err := accept(ctx, offer)
+	if err != nil {
+		reject(conn, offer.ID[:], err)
+		return offer, err
+	}
+	if closer, ok := sink.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	// Verify chunks already present in the sink
+	total := offer.NumChunks()
+	have := newBitmap(total)
+	buf := make([]byte, offer.ChunkSize)
+	for i := 0; i < total; i++ {
+		off, size := offer.chunkRange(i)
+		n, err := sink.ReadAt(buf[:size], off)
+		if n == size && (err == nil || err == io.EOF) && sha256.Sum256(buf[:size]) == offer.ChunkHashes[i] {
+			have.set(i)
+		}
+	}
+	done := have.count(total)
+	report(opts, offer, done)
+
+	accepted := &snfile.Accept{TransferId: offer.ID[:], Have: have}
+	if err := msgconn.SendVT(conn, &snfile.Frame{Body: &snfile.Frame_Accept{Accept: accepted}}); err != nil {
+		return offer, err
+	}
+
+	ackEvery := opts.Window / 2
+	received, unacked := 0, 0
+	var resend []uint32
+	for done < total {
+		frame, err := frames.Next(ctx)
+		if err != nil {
+			return offer, err
+		}
+		body, ok := frame.Body.(*snfile.Frame_Chunk)
+		if !ok {
+			return offer, ErrUnexpectedMessage
+		}
+		received++
+		unacked++
+
+		i := int(body.Chunk.Index)
+		if i < total && !have.has(i) {
+			off, size := offer.chunkRange(i)
+			if len(body.Chunk.Data) == size && sha256.Sum256(body.Chunk.Data) == offer.ChunkHashes[i] {
+				if _, err := sink.WriteAt(body.Chunk.Data, off); err != nil {
+					return offer, err
+				}
+				have.set(i)
+				done++
+				report(opts, offer, done)
+			} else {
+				resend = append(resend, uint32(i))
+			}
+		}
+
+		// Acknowledge periodically, immediately when chunks must be resent
+		if unacked >= ackEvery || len(resend) > 0 {
+			ack := &snfile.Ack{Received: uint32(received), Resend: resend}
+			if err := msgconn.SendVT(conn, &snfile.Frame{Body: &snfile.Frame_Ack{Ack: ack}}); err != nil {
+				return offer, err
+			}
+			unacked, resend = 0, nil
+		}
+	}
+
+	if err := msgconn.SendVT(conn, &snfile.Frame{Body: &snfile.Frame_Complete{Complete: &snfile.Complete{}}}); err != nil {
+		return offer, err
+	}
+	return offer, nil
+}
+
+// reject declines an offer, ignoring send errors since the transfer fails anyway.
+func reject(conn Conn, id []byte, reason error) {
+	frame := &snfile.Frame{Body: &snfile.Frame_Reject{Reject: &snfile.Reject{TransferId: id, Reason: reason.Error()}}}
+	msgconn.SendVT(conn, frame)
+}
+
+// report calls the progress callback with the number of chunks done.
+func report(opts Options, offer *Offer, chunks int) {
+	if opts.Progress != nil {
+		opts.Progress(progressOf(offer, chunks))
+	}
+}
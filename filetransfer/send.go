@@ -0,0 +1,156 @@
+package filetransfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"pkg.gfire.dev/supernet/msgconn"
+	snfile "pkg.gfire.dev/supernet/proto/snfile/v1alpha1"
+)
+
+// File is a file to send.
+type File struct {
+	Name     string      // File name suggested to the receiver
+	MIMEType string      // Media type, empty if unknown
+	Size     int64       // File size in bytes
+	Content  io.ReaderAt // File contents
+}
+
+// Sender sends one file, possibly over several connections in turn when resuming after disconnects.
+type Sender struct {
+	file  File
+	opts  Options
+	offer *Offer
+}
+
+// NewSender reads the file once to compute its chunk hashes.
+func NewSender(f File, opts Options) (*Sender, error) {
+	opts = opts.withDefaults()
+
+	offer := &Offer{
+		Name:      f.Name,
+		MIMEType:  f.MIMEType,
+		Size:      f.Size,
+		ChunkSize: opts.ChunkSize,
+	}
+	n := int((f.Size + int64(opts.ChunkSize) - 1) / int64(opts.ChunkSize))
+	offer.ChunkHashes = make([][sha256.Size]byte, n)
+
+	buf := make([]byte, opts.ChunkSize)
+	for i := 0; i < n; i++ {
+		off, size := offer.chunkRange(i)
+		if _, err := f.Content.ReadAt(buf[:size], off); err != nil && err != io.EOF {
+			return nil, err
+		}
+		offer.ChunkHashes[i] = sha256.Sum256(buf[:size])
+	}
+	offer.ID = transferID(offer.ChunkHashes)
+
+	return &Sender{file: f, opts: opts, offer: offer}, nil
+}
+
+// Offer returns the offer describing the file.
+func (s *Sender) Offer() *Offer {
+	return s.offer
+}
+
+// Send offers the file over conn and sends every chunk the receiver does not hold yet.
+// It returns nil once the receiver reports that all chunks are verified. After a connection failure,
+// call Send again with a new connection to resume the transfer.
+func (s *Sender) Send(ctx context.Context, conn Conn) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	frames := msgconn.NewReader(ctx, conn, msgconn.VT[snfile.Frame]())
+	offer := s.offer
+	total := offer.NumChunks()
+
+	// Offer the file and wait for the receiver to accept it
+	if err := msgconn.SendVT(conn, &snfile.Frame{Body: &snfile.Frame_Offer{Offer: offer.toProto()}}); err != nil {
+		return err
+	}
+	frame, err := frames.Next(ctx)
+	if err != nil {
+		return err
+	}
+	var have bitmap
+	switch body := frame.Body.(type) {
+	case *snfile.Frame_Accept:
+		have = bitmap(body.Accept.Have)
+	case *snfile.Frame_Reject:
+		return fmt.Errorf("%w: %s", ErrRejected, body.Reject.Reason)
+	default:
+		return ErrUnexpectedMessage
+	}
+
+	// Queue every chunk the receiver is missing
+	var queue []int
+	for i := 0; i < total; i++ {
+		if !have.has(i) {
+			queue = append(queue, i)
+		}
+	}
+	done := total - len(queue)
+	s.report(done)
+
+	buf := make([]byte, offer.ChunkSize)
+	sent, acked := 0, 0
+	for {
+		// Fill the window
+		for len(queue) > 0 && sent-acked < s.opts.Window {
+			i := queue[0]
+			queue = queue[1:]
+
+			off, size := offer.chunkRange(i)
+			if _, err := s.file.Content.ReadAt(buf[:size], off); err != nil && err != io.EOF {
+				return err
+			}
+			chunk := &snfile.Chunk{Index: uint32(i), Data: buf[:size]}
+			if err := msgconn.SendVT(conn, &snfile.Frame{Body: &snfile.Frame_Chunk{Chunk: chunk}}); err != nil {
+				return err
+			}
+			sent++
+		}
+
+		frame, err := frames.Next(ctx)
+		if err != nil {
+			return err
+		}
+		switch body := frame.Body.(type) {
+		case *snfile.Frame_Ack:
+			newly := int(body.Ack.Received) - acked
+			acked = int(body.Ack.Received)
+			for _, i := range body.Ack.Resend {
+				if int(i) < total {
+					queue = append(queue, int(i))
+				}
+			}
+			done += newly - len(body.Ack.Resend)
+			s.report(done)
+		case *snfile.Frame_Complete:
+			s.report(total)
+			return nil
+		default:
+			return ErrUnexpectedMessage
+		}
+	}
+}
+
+// report calls the progress callback with the number of chunks done.
+func (s *Sender) report(chunks int) {
+	if s.opts.Progress == nil {
+		return
+	}
+	s.opts.Progress(progressOf(s.offer, chunks))
+}
+
+// progressOf computes progress for a number of verified chunks, assuming the short last chunk is among the last done.
+func progressOf(o *Offer, chunks int) Progress {
+	done := int64(chunks) * int64(o.ChunkSize)
+	if done > o.Size {
+		done = o.Size
+	}
+	return Progress{Done: done, Total: o.Size, Chunks: chunks, TotalChunks: o.NumChunks()}
+}
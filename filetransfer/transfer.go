@@ -0,0 +1,181 @@
+// Package filetransfer sends files between peers over message-oriented connections such as WebRTC data
+// channels or WebSockets. Files are split into chunks that are verified against per-chunk SHA-256 hashes
+// announced up front, transfers resume after a disconnect by skipping chunks the receiver already holds,
+// and both sides report progress while the transfer runs.
+package filetransfer
+
+import (
+	"crypto/sha256"
+	"errors"
+
+	"pkg.gfire.dev/supernet/msgconn"
+	snfile "pkg.gfire.dev/supernet/proto/snfile/v1alpha1"
+)
+
+var (
+	// ErrRejected is returned when the receiver declines a transfer
+	ErrRejected = errors.New("file transfer rejected")
+	// ErrInvalidOffer is returned when an offer is inconsistent
+	ErrInvalidOffer = errors.New("invalid file offer")
+	// ErrUnexpectedMessage is returned when the remote side sends a message out of order
+	ErrUnexpectedMessage = msgconn.ErrUnexpected
+)
+
+const (
+	// DefaultChunkSize is the default chunk size; it keeps messages within the limits of all data channel implementations.
+	DefaultChunkSize = 64 << 10
+	// MaxChunkSize is the largest chunk size a receiver accepts.
+	MaxChunkSize = 1 << 20
+	// defaultWindow is the default number of unacknowledged chunks in flight
+	defaultWindow = 64
+)
+
+// Conn is a message-oriented connection, such as a webrtcjs.DataChannel or wsjs.Conn.
+// Messages must be delivered reliably and in order.
+type Conn interface {
+	NextMessage() ([]byte, error)
+	Send(data []byte) error
+}
+
+// Options configures the sending or receiving side of a transfer.
+type Options struct {
+	// ChunkSize is the chunk size used by the sender (default 64 KiB, at most 1 MiB).
+	ChunkSize int
+	// Window is the number of chunks the sender may have in flight before the receiver acknowledges them (default 64).
+	// The receiver acknowledges every Window/2 chunks; both sides should use the same value.
+	Window int
+	// Progress, if set, is called as chunks are acknowledged (sender) or verified (receiver).
+	Progress func(Progress)
+}
+
+// Progress reports the state of a transfer.
+type Progress struct {
+	Done        int64 // Bytes verified by the receiver
+	Total       int64 // File size
+	Chunks      int   // Chunks verified by the receiver
+	TotalChunks int   // Number of chunks
+}
+
+// Offer describes a file offered for transfer.
+type Offer struct {
+	ID          [sha256.Size]byte   // SHA-256 over the chunk hashes, identifying the file content
+	Name        string              // File name suggested by the sender
+	MIMEType    string              // Media type, empty if unknown
+	Size        int64               // File size in bytes
+	ChunkSize   int                 // Size of every chunk except the last
+	ChunkHashes [][sha256.Size]byte // SHA-256 of every chunk
+}
+
+// NumChunks returns the number of chunks.
+func (o *Offer) NumChunks() int {
+	return len(o.ChunkHashes)
+}
+
+// chunkRange returns the byte range of chunk i.
+func (o *Offer) chunkRange(i int) (off int64, n int) {
+	off = int64(i) * int64(o.ChunkSize)
+	n = o.ChunkSize
+	if rest := o.Size - off; rest < int64(n) {
+		n = int(rest)
+	}
+	return off, n
+}
+
+// toProto converts the offer into its wire form.
+func (o *Offer) toProto() *snfile.Offer {
+	hashes := make([][]byte, len(o.ChunkHashes))
+	for i := range o.ChunkHashes {
+		hashes[i] = o.ChunkHashes[i][:]
+	}
+	return &snfile.Offer{
+		TransferId:  o.ID[:],
+		Name:        o.Name,
+		Size:        o.Size,
+		ChunkSize:   uint32(o.ChunkSize),
+		ChunkHashes: hashes,
+		MimeType:    o.MIMEType,
+	}
+}
+
+// offerFromProto validates and converts a received offer.
+func offerFromProto(p *snfile.Offer) (*Offer, error) {
+	if p.Size < 0 || p.ChunkSize == 0 || p.ChunkSize > MaxChunkSize {
+		return nil, ErrInvalidOffer
+	}
+	chunks := (p.Size + int64(p.ChunkSize) - 1) / int64(p.ChunkSize)
+	if int64(len(p.ChunkHashes)) != chunks {
+		return nil, ErrInvalidOffer
+	}
+
+	o := &Offer{
+		Name:        p.Name,
+		MIMEType:    p.MimeType,
+		Size:        p.Size,
+		ChunkSize:   int(p.ChunkSize),
+		ChunkHashes: make([][sha256.Size]byte, len(p.ChunkHashes)),
+	}
+	for i, h := range p.ChunkHashes {
+		if len(h) != sha256.Size {
+			return nil, ErrInvalidOffer
+		}
+		copy(o.ChunkHashes[i][:], h)
+	}
+	if o.ID = transferID(o.ChunkHashes); string(o.ID[:]) != string(p.TransferId) {
+		return nil, ErrInvalidOffer
+	}
+	return o, nil
+}
+
+// transferID hashes the chunk hashes.
+func transferID(hashes [][sha256.Size]byte) [sha256.Size]byte {
+	h := sha256.New()
+	for i := range hashes {
+		h.Write(hashes[i][:])
+	}
+	var id [sha256.Size]byte
+	h.Sum(id[:0])
+	return id
+}
+
+// bitmap is a set of chunk indices, least significant bit first.
+type bitmap []byte
+
+// newBitmap allocates a bitmap for n chunks.
+func newBitmap(n int) bitmap {
+	return make(bitmap, (n+7)/8)
+}
+
+// has reports whether i is set.
+func (b bitmap) has(i int) bool {
+	return i/8 < len(b) && b[i/8]&(1<<(i%8)) != 0
+}
+
+// set sets i.
+func (b bitmap) set(i int) {
+	b[i/8] |= 1 << (i % 8)
+}
+
+// count returns the number of set indices below n.
+func (b bitmap) count(n int) int {
+	c := 0
+	for i := 0; i < n; i++ {
+		if b.has(i) {
+			c++
+		}
+	}
+	return c
+}
+
+// withDefaults fills in unset options.
+func (o Options) withDefaults() Options {
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = DefaultChunkSize
+	}
+	if o.ChunkSize > MaxChunkSize {
+		o.ChunkSize = MaxChunkSize
+	}
+	if o.Window <= 1 {
+		o.Window = defaultWindow
+	}
+	return o
+}
@@ -0,0 +1,599 @@
+// Package gossip maintains group membership over message connections. Every node keeps a small active view
+// of connected peers, which it probes SWIM-style with direct and indirect pings, and a larger set of known
+// members learned from piggybacked state updates and periodic peer exchange (shuffles), which it draws on to
+// refill its active view when peers fail.
+//
+// Connections are authenticated by the peer's identity during the handshake. Member state updates are
+// accepted from any authenticated peer, so the group should consist of cooperating nodes.
+package gossip
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/bits"
+	mrand "math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/msgconn"
+	sngossip "pkg.gfire.dev/supernet/proto/sngossip/v1alpha1"
+)
+
+var (
+	// ErrClosed is returned when using a node that has been closed
+	ErrClosed = errors.New("gossip node closed")
+	// ErrHandshakeFailed is returned when a remote peer fails to prove ownership of its identity
+	ErrHandshakeFailed = errors.New("gossip handshake failed")
+	// ErrNoDialer is returned by Join when no DialFunc is configured
+	ErrNoDialer = errors.New("no gossip dialer configured")
+
+	// errUnexpectedPeer reports that a member address led to a different peer
+	errUnexpectedPeer = errors.New("unexpected peer at member address")
+)
+
+const (
+	// defaultActiveSize is the default number of peers a node tries to stay connected to
+	defaultActiveSize = 5
+	// defaultProbeInterval is the default time between probes of active peers
+	defaultProbeInterval = time.Second
+	// defaultProbeTimeout is the default time to wait for a direct probe to be answered
+	defaultProbeTimeout = 500 * time.Millisecond
+	// defaultIndirectProbes is the default number of peers asked to probe an unresponsive peer
+	defaultIndirectProbes = 3
+	// defaultSuspicionTimeout is the default time a suspect has to refute before it is declared dead
+	defaultSuspicionTimeout = 5 * time.Second
+	// defaultShuffleInterval is the default time between peer exchanges
+	defaultShuffleInterval = 10 * time.Second
+	// defaultShuffleSize is the default number of member records exchanged per shuffle
+	defaultShuffleSize = 8
+	// defaultMaxMembers is the default limit of known members
+	defaultMaxMembers = 1024
+	// handshakeTimeout bounds the handshake of connections added by maintenance
+	handshakeTimeout = 10 * time.Second
+	// deadRetention is how long dead members are remembered to reject stale updates about them
+	deadRetention = time.Minute
+	// maxPiggyback is the largest number of updates carried by one message
+	maxPiggyback = 8
+	// peerstoreTimeout bounds every peer store operation
+	peerstoreTimeout = 5 * time.Second
+	// retransmitMult scales how often an update is retransmitted, multiplied by log2 of the group size
+	retransmitMult = 3
+	// helloSignaturePrefix domain-separates handshake signatures
+	helloSignaturePrefix = "supernet-gossip-hello:"
+)
+
+// Conn is a message connection to a peer.
+type Conn interface {
+	// NextMessage blocks until the next message is received or the connection is closed.
+	NextMessage() ([]byte, error)
+	// Send sends a single message.
+	Send(data []byte) error
+	// Close closes the connection.
+	Close() error
+}
+
+// DialFunc connects to a member address.
+type DialFunc func(ctx context.Context, addr string) (Conn, error)
+
+// Peerstore remembers the records of connected members across restarts, so a restarted node can refill its
+// active view without joining through a known address first. Package peerstore provides persistent
+// implementations. Its methods are called concurrently.
+type Peerstore interface {
+	// Records returns remembered signed member records; they are verified before use.
+	Records(ctx context.Context) ([][]byte, error)
+	// SetRecord remembers the signed record of a member that completed a handshake.
+	SetRecord(ctx context.Context, record []byte) error
+}
+
+// State is the liveness of a member.
+type State int
+
+const (
+	Alive   State = iota // The member answers probes
+	Suspect              // A probe failed; the member is declared dead unless it refutes in time
+	Dead                 // The member failed or left
+)
+
+// String returns the lowercase name of the state.
+func (s State) String() string {
+	switch s {
+	case Alive:
+		return "alive"
+	case Suspect:
+		return "suspect"
+	case Dead:
+		return "dead"
+	}
+	return "unknown"
+}
+
+// Member describes a member of the group.
+type Member struct {
+	ID          identity.ID      // Peer ID of the member
+	Record      *identity.Record // Latest signed record of the member
+	State       State            // Liveness of the member
+	Incarnation uint64           // Incarnation the state applies to
+	Connected   bool             // Whether the member is in the local active view
+}
+
+// Config configures a Node.
+type Config struct {
+	// Identity is the identity of the node; a new one is generated if nil.
+	Identity *identity.Identity
+	// Addrs lists the addresses other members can dial the node at (typically empty for browser nodes).
+	Addrs []string
+	// Dial connects to member addresses to join the group and refill the active view.
+	// Without it, the node only uses connections handed to AddConn.
+	Dial DialFunc
+	// Peerstore remembers members across restarts; the node starts out knowing the members it remembers.
+	Peerstore Peerstore
+	// ActiveSize is the number of peers the node dials to stay connected to (default 5).
+	ActiveSize int
+	// MaxPeers limits the connected peers including inbound connections (default 4 × ActiveSize).
+	MaxPeers int
+	// ProbeInterval is the time between probes of active peers (default 1s).
+	ProbeInterval time.Duration
+	// ProbeTimeout is the time to wait for a direct probe before probing indirectly (default 500ms).
+	ProbeTimeout time.Duration
+	// IndirectProbes is the number of peers asked to probe an unresponsive peer (default 3).
+	IndirectProbes int
+	// SuspicionTimeout is the time a suspect member has to refute before it is declared dead (default 5s).
+	SuspicionTimeout time.Duration
+	// ShuffleInterval is the time between peer exchanges (default 10s).
+	ShuffleInterval time.Duration
+	// ShuffleSize is the number of member records sent per peer exchange (default 8).
+	ShuffleSize int
+	// MaxMembers limits the number of known members (default 1024).
+	MaxMembers int
+	// OnChange is called whenever a member joins or changes state. It must not block.
+	OnChange func(Member)
+}
+
+// Node is a member of a gossip group.
+type Node struct {
+	self *identity.Identity
+	cfg  Config
+
+	mu sync.Mutex
+	// record is the node's own signed peer record
+	record []byte
+	// recordExpires is when record must be renewed
+	recordExpires time.Time
+	// incarnation is the node's own incarnation, raised to refute suspicion
+	incarnation uint64
+	// members holds the known members by ID, excluding the node itself
+	members map[identity.ID]*member
+	// peers holds the active view
+	peers map[identity.ID]*peer
+	// queue holds updates waiting to be piggybacked
+	queue []*queuedUpdate
+	// pending maps outstanding probe sequence numbers to the channel closed by their Ack
+	pending map[uint64]chan struct{}
+	// probeOrder is the shuffled list of peers probed in turn
+	probeOrder []identity.ID
+	// dialing is set while maintenance dials a new peer
+	dialing bool
+
+	seq atomic.Uint64
+
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+// member is the local view of a member.
+type member struct {
+	rec         *identity.Record
+	raw         []byte // encoded record, forwarded in updates and shuffles
+	state       State
+	incarnation uint64
+	changed     time.Time // when state last changed
+	dialFailed  time.Time // when dialing the member last failed
+}
+
+// peer is an authenticated connection in the active view.
+type peer struct {
+	id   identity.ID
+	conn Conn
+	// closing is set when the connection is closed deliberately, so its loss is not suspicious
+	closing atomic.Bool
+}
+
+// queuedUpdate is an update with the number of times it has been piggybacked.
+type queuedUpdate struct {
+	update *sngossip.Update
+	sent   int
+}
+
+// New creates a node and starts its probe and maintenance loop.
+func New(cfg Config) (*Node, error) {
+	if cfg.Identity == nil {
+		id, err := identity.Generate()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Identity = id
+	}
+	if cfg.ActiveSize <= 0 {
+		cfg.ActiveSize = defaultActiveSize
+	}
+	if cfg.MaxPeers <= 0 {
+		cfg.MaxPeers = 4 * cfg.ActiveSize
+	}
+	if cfg.ProbeInterval <= 0 {
+		cfg.ProbeInterval = defaultProbeInterval
+	}
+	if cfg.ProbeTimeout <= 0 {
+		cfg.ProbeTimeout = defaultProbeTimeout
+	}
+	if cfg.IndirectProbes <= 0 {
+		cfg.IndirectProbes = defaultIndirectProbes
+	}
+	if cfg.SuspicionTimeout <= 0 {
+		cfg.SuspicionTimeout = defaultSuspicionTimeout
+	}
+	if cfg.ShuffleInterval <= 0 {
+		cfg.ShuffleInterval = defaultShuffleInterval
+	}
+	if cfg.ShuffleSize <= 0 {
+		cfg.ShuffleSize = defaultShuffleSize
+	}
+	if cfg.MaxMembers <= 0 {
+		cfg.MaxMembers = defaultMaxMembers
+	}
+
+	n := &Node{
+		self: cfg.Identity,
+		cfg:  cfg,
+		// Incarnations start at the current time so a restarted node outranks its previous run
+		incarnation: uint64(time.Now().UnixMilli()),
+		members:     make(map[identity.ID]*member),
+		peers:       make(map[identity.ID]*peer),
+		pending:     make(map[uint64]chan struct{}),
+		closeChan:   make(chan struct{}),
+	}
+	if err := n.renewRecord(); err != nil {
+		return nil, err
+	}
+
+	go n.maintain()
+	return n, nil
+}
+
+// ID returns the peer ID of the node.
+func (n *Node) ID() identity.ID {
+	return n.self.ID()
+}
+
+// Join dials addr and adds the connection to the active view.
+func (n *Node) Join(ctx context.Context, addr string) (Member, error) {
+	if n.cfg.Dial == nil {
+		return Member{}, ErrNoDialer
+	}
+	conn, err := n.cfg.Dial(ctx, addr)
+	if err != nil {
+		return Member{}, err
+	}
+	return n.AddConn(ctx, conn)
+}
+
+// AddConn authenticates an established connection and adds it to the active view.
+// This is how nodes hand over inbound connections accepted by a listener.
+func (n *Node) AddConn(ctx context.Context, conn Conn) (Member, error) {
+	rec, raw, inc, err := n.handshake(ctx, conn)
+	if err != nil {
+		conn.Close()
+		return Member{}, err
+	}
+
+	p := &peer{id: rec.ID, conn: conn}
+	n.mu.Lock()
+	select {
+	case <-n.closeChan:
+		n.mu.Unlock()
+		conn.Close()
+		return Member{}, ErrClosed
+	default:
+	}
+	old := n.peers[rec.ID]
+	n.peers[rec.ID] = p
+	// A successful handshake is first-hand evidence the peer is alive
+	changed := n.apply(&sngossip.Update{Id: rec.ID.Bytes(), State: sngossip.State_ALIVE, Incarnation: inc, Record: raw})
+	m := n.memberLocked(rec.ID)
+	n.mu.Unlock()
+
+	// A newer connection to the same peer supersedes the old one
+	if old != nil {
+		old.closing.Store(true)
+		old.conn.Close()
+	}
+	n.notify(changed)
+	if n.cfg.Peerstore != nil {
+		// The record was verified by the handshake
+		ctx, cancel := context.WithTimeout(context.Background(), peerstoreTimeout)
+		n.cfg.Peerstore.SetRecord(ctx, raw)
+		cancel()
+	}
+	go n.serve(p)
+	n.trim()
+	return m, nil
+}
+
+// AddRecord adds the member described by a signed record learned elsewhere, e.g. through discovery, so
+// maintenance can dial it when the active view needs peers. Known members keep their state.
+func (n *Node) AddRecord(record []byte) error {
+	rec, err := identity.OpenRecord(record)
+	if err != nil {
+		return err
+	}
+	n.learn(rec, record)
+	return nil
+}
+
+// Members returns the known members that are not dead.
+func (n *Node) Members() []Member {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	members := make([]Member, 0, len(n.members))
+	for id, m := range n.members {
+		if m.state != Dead {
+			members = append(members, n.memberLocked(id))
+		}
+	}
+	return members
+}
+
+// Member returns the local view of a member.
+func (n *Node) Member(id identity.ID) (Member, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.members[id]; !ok {
+		return Member{}, false
+	}
+	return n.memberLocked(id), true
+}
+
+// Peers returns the IDs of the members in the active view.
+func (n *Node) Peers() []identity.ID {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	ids := make([]identity.ID, 0, len(n.peers))
+	for id := range n.peers {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Leave announces to the group that the node is leaving, then closes it.
+func (n *Node) Leave() error {
+	n.mu.Lock()
+	n.enqueue(&sngossip.Update{Id: n.self.ID().Bytes(), State: sngossip.State_DEAD, Incarnation: n.incarnation})
+	peers := n.activePeers()
+	n.mu.Unlock()
+
+	for _, p := range peers {
+		n.send(p, &sngossip.Message{})
+	}
+	return n.Close()
+}
+
+// Close closes all connections and stops the node.
+func (n *Node) Close() error {
+	n.closeOnce.Do(func() {
+		close(n.closeChan)
+
+		n.mu.Lock()
+		peers := n.activePeers()
+		n.mu.Unlock()
+
+		for _, p := range peers {
+			p.closing.Store(true)
+			p.conn.Close()
+		}
+	})
+	return nil
+}
+
+// handshake exchanges Hello messages and signatures over a new connection.
+func (n *Node) handshake(ctx context.Context, conn Conn) (*identity.Record, []byte, uint64, error) {
+	type result struct {
+		rec *identity.Record
+		raw []byte
+		inc uint64
+		err error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		rec, raw, inc, err := n.doHandshake(conn)
+		done <- result{rec, raw, inc, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.rec, r.raw, r.inc, r.err
+	case <-ctx.Done():
+		conn.Close()
+		return nil, nil, 0, ctx.Err()
+	}
+}
+
+// doHandshake runs the blocking part of the handshake.
+func (n *Node) doHandshake(conn Conn) (*identity.Record, []byte, uint64, error) {
+	nonce := make([]byte, 32)
+	rand.Read(nonce)
+
+	n.mu.Lock()
+	hello := &sngossip.Hello{Record: n.record, Nonce: nonce, Incarnation: n.incarnation}
+	n.mu.Unlock()
+	if err := msgconn.SendVT(conn, hello); err != nil {
+		return nil, nil, 0, err
+	}
+
+	remoteHello := &sngossip.Hello{}
+	if err := msgconn.RecvVT(conn, remoteHello); err != nil {
+		return nil, nil, 0, err
+	}
+	rec, err := identity.OpenRecord(remoteHello.Record)
+	if err != nil || len(remoteHello.Nonce) != 32 || rec.ID == n.self.ID() {
+		return nil, nil, 0, ErrHandshakeFailed
+	}
+
+	// Prove ownership of our identity by signing the remote nonce
+	sig := n.self.Sign(append([]byte(helloSignaturePrefix), remoteHello.Nonce...))
+	if err := msgconn.SendVT(conn, &sngossip.HelloProof{Signature: sig}); err != nil {
+		return nil, nil, 0, err
+	}
+
+	proof := &sngossip.HelloProof{}
+	if err := msgconn.RecvVT(conn, proof); err != nil {
+		return nil, nil, 0, err
+	}
+	if !identity.Verify(rec.PublicKey, append([]byte(helloSignaturePrefix), nonce...), proof.Signature) {
+		return nil, nil, 0, ErrHandshakeFailed
+	}
+	return rec, remoteHello.Record, remoteHello.Incarnation, nil
+}
+
+// serve reads messages from a peer until the connection fails, then removes it from the active view.
+func (n *Node) serve(p *peer) {
+	defer n.dropPeer(p)
+
+	for {
+		data, err := p.conn.NextMessage()
+		if err != nil {
+			return
+		}
+		msg := &sngossip.Message{}
+		if err := msg.UnmarshalVT(data); err != nil {
+			continue
+		}
+		n.handleMessage(p, msg)
+	}
+}
+
+// dropPeer closes and removes a connection from the active view. Losing a connection that was not closed
+// deliberately raises suspicion against the peer.
+func (n *Node) dropPeer(p *peer) {
+	p.conn.Close()
+
+	n.mu.Lock()
+	current := n.peers[p.id] == p
+	if current {
+		delete(n.peers, p.id)
+	}
+	var changed []Member
+	select {
+	case <-n.closeChan:
+	default:
+		if current && !p.closing.Load() {
+			changed = n.suspect(p.id)
+		}
+	}
+	n.mu.Unlock()
+
+	n.notify(changed)
+}
+
+// handleMessage applies piggybacked updates and dispatches the body of a message.
+func (n *Node) handleMessage(p *peer, msg *sngossip.Message) {
+	var changed []Member
+	n.mu.Lock()
+	for _, u := range msg.Updates {
+		changed = append(changed, n.apply(u)...)
+	}
+	n.mu.Unlock()
+	n.notify(changed)
+
+	switch body := msg.Body.(type) {
+	case *sngossip.Message_Ping:
+		n.send(p, &sngossip.Message{Body: &sngossip.Message_Ack{Ack: &sngossip.Ack{Seq: body.Ping.Seq}}})
+	case *sngossip.Message_Ack:
+		n.mu.Lock()
+		ch := n.pending[body.Ack.Seq]
+		delete(n.pending, body.Ack.Seq)
+		n.mu.Unlock()
+		if ch != nil {
+			close(ch)
+		}
+	case *sngossip.Message_PingReq:
+		go n.handlePingReq(p, body.PingReq)
+	case *sngossip.Message_Shuffle:
+		n.handleShuffle(p, body.Shuffle)
+	}
+}
+
+// send piggybacks pending updates on msg and sends it to a peer.
+func (n *Node) send(p *peer, msg *sngossip.Message) error {
+	n.mu.Lock()
+	msg.Updates = n.piggyback()
+	n.mu.Unlock()
+	return msgconn.SendVT(p.conn, msg)
+}
+
+// activePeers returns the peers of the active view. The caller must hold mu.
+func (n *Node) activePeers() []*peer {
+	peers := make([]*peer, 0, len(n.peers))
+	for _, p := range n.peers {
+		peers = append(peers, p)
+	}
+	return peers
+}
+
+// memberLocked returns the public view of a known member. The caller must hold mu.
+func (n *Node) memberLocked(id identity.ID) Member {
+	m := n.members[id]
+	_, connected := n.peers[id]
+	return Member{ID: id, Record: m.rec, State: m.state, Incarnation: m.incarnation, Connected: connected}
+}
+
+// notify reports member changes to OnChange.
+func (n *Node) notify(changed []Member) {
+	if n.cfg.OnChange == nil {
+		return
+	}
+	for _, m := range changed {
+		n.cfg.OnChange(m)
+	}
+}
+
+// renewRecord signs a fresh peer record for the node.
+func (n *Node) renewRecord() error {
+	rec, err := n.self.NewRecord(n.cfg.Addrs, identity.DefaultRecordTTL)
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	n.record = rec
+	n.recordExpires = time.Now().Add(identity.DefaultRecordTTL / 2)
+	n.mu.Unlock()
+	return nil
+}
+
+// retransmitLimit returns how often each update is piggybacked, growing with the logarithm of the group size.
+// The caller must hold mu.
+func (n *Node) retransmitLimit() int {
+	return retransmitMult * bits.Len(uint(len(n.members)+1))
+}
+
+// randomPeers returns up to k random peers of the active view other than exclude. The caller must hold mu.
+func (n *Node) randomPeers(k int, exclude identity.ID) []*peer {
+	peers := make([]*peer, 0, len(n.peers))
+	for id, p := range n.peers {
+		if id != exclude {
+			peers = append(peers, p)
+		}
+	}
+	mrand.Shuffle(len(peers), func(i, j int) {
+		peers[i], peers[j] = peers[j], peers[i]
+	})
+	if len(peers) > k {
+		peers = peers[:k]
+	}
+	return peers
+}
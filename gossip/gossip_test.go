@@ -0,0 +1,165 @@
+package gossip
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/memtransport"
+	sngossip "pkg.gfire.dev/supernet/proto/sngossip/v1alpha1"
+)
+
+// testTimeout bounds every blocking call of a test
+const testTimeout = 10 * time.Second
+
+// changes records the OnChange events of a node by member.
+type changes struct {
+	mu     sync.Mutex
+	events map[identity.ID][]State
+}
+
+// record is an OnChange callback.
+func (c *changes) record(m Member) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events[m.ID] = append(c.events[m.ID], m.State)
+}
+
+// of returns the states reported for id.
+func (c *changes) of(id identity.ID) []State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]State(nil), c.events[id]...)
+}
+
+// newNode creates a node probing quickly and never shuffling, closed when the test ends.
+func newNode(t *testing.T) (*Node, *changes) {
+	t.Helper()
+	c := &changes{events: make(map[identity.ID][]State)}
+	n, err := New(Config{ProbeInterval: 10 * time.Millisecond, ShuffleInterval: time.Hour, OnChange: c.record})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { n.Close() })
+	return n, c
+}
+
+// connect adds an in-memory connection between a and b to both active views.
+func connect(t *testing.T, a, b *Node) {
+	t.Helper()
+	ca, cb := memtransport.Pipe(memtransport.Config{})
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		_, err := b.AddConn(ctx, cb)
+		errc <- err
+	}()
+	if _, err := a.AddConn(ctx, ca); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(testTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting until %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	// A ring, so updates reach every node along two paths
+	const size = 6
+	nodes := make([]*Node, size)
+	events := make([]*changes, size)
+	for i := range nodes {
+		nodes[i], events[i] = newNode(t)
+	}
+	for i := range nodes {
+		connect(t, nodes[i], nodes[(i+1)%size])
+	}
+
+	joiner, _ := newNode(t)
+	connect(t, joiner, nodes[0])
+	id := joiner.ID()
+	for i, n := range nodes {
+		waitFor(t, "the joiner is known everywhere", func() bool {
+			m, ok := n.Member(id)
+			return ok && m.State == Alive
+		})
+		// Copies of the update arriving later change nothing
+		if states := events[i].of(id); len(states) != 1 || states[0] != Alive {
+			t.Fatalf("node %d reported %v for the joiner, want a single alive", i, states)
+		}
+	}
+
+	if err := joiner.Leave(); err != nil {
+		t.Fatal(err)
+	}
+	for i, n := range nodes {
+		waitFor(t, "the departure is known everywhere", func() bool {
+			m, _ := n.Member(id)
+			return m.State == Dead
+		})
+		if states := events[i].of(id); states[len(states)-1] != Dead {
+			t.Fatalf("node %d reported %v for the joiner, want dead last", i, states)
+		}
+	}
+}
+
+func TestDedup(t *testing.T) {
+	n, _ := newNode(t)
+	other, err := identity.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := other.NewRecord(nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alive := &sngossip.Update{Id: other.ID().Bytes(), State: sngossip.State_ALIVE, Incarnation: 1, Record: record}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	// Keep the node's own update out of the queue
+	n.queue = nil
+	if changed := n.apply(alive); len(changed) != 1 {
+		t.Fatalf("new member: %d changes, want 1", len(changed))
+	}
+	if changed := n.apply(alive); len(changed) != 0 || len(n.queue) != 1 {
+		t.Fatalf("repeated update: %d changes and %d queued, want none and 1", len(changed), len(n.queue))
+	}
+
+	// A newer rumour about the member replaces the queued one
+	suspect := &sngossip.Update{Id: alive.Id, State: sngossip.State_SUSPECT, Incarnation: 1}
+	if changed := n.apply(suspect); len(changed) != 1 || changed[0].State != Suspect {
+		t.Fatalf("suspicion: %+v", changed)
+	}
+	if len(n.queue) != 1 || n.queue[0].update.State != sngossip.State_SUSPECT {
+		t.Fatalf("queue holds %d updates, want the suspicion only", len(n.queue))
+	}
+	// An older incarnation is stale
+	if changed := n.apply(&sngossip.Update{Id: alive.Id, State: sngossip.State_DEAD}); len(changed) != 0 {
+		t.Fatal("stale update applied")
+	}
+
+	// Updates are retired after being piggybacked often enough
+	for i := range n.retransmitLimit() {
+		if updates := n.piggyback(); len(updates) != 1 {
+			t.Fatalf("piggyback %d: %d updates, want 1", i, len(updates))
+		}
+	}
+	if updates := n.piggyback(); len(updates) != 0 {
+		t.Fatalf("%d updates piggybacked beyond the retransmission limit", len(updates))
+	}
+}
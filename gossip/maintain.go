@@ -0,0 +1,325 @@
+package gossip
+
+import (
+	"context"
+	mrand "math/rand/v2"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/p2p"
+	sngossip "pkg.gfire.dev/supernet/proto/sngossip/v1alpha1"
+)
+
+// maintain runs the periodic probing, peer exchange and active view maintenance until the node is closed.
+func (n *Node) maintain() {
+	n.restore()
+
+	ticker := time.NewTicker(n.cfg.ProbeInterval)
+	defer ticker.Stop()
+
+	lastShuffle := time.Now()
+	for {
+		select {
+		case <-ticker.C:
+		case <-n.closeChan:
+			return
+		}
+		now := time.Now()
+
+		n.mu.Lock()
+		renew := now.After(n.recordExpires)
+		n.mu.Unlock()
+		if renew && n.renewRecord() == nil {
+			n.mu.Lock()
+			n.enqueue(n.selfUpdate())
+			n.mu.Unlock()
+		}
+
+		n.mu.Lock()
+		changed := n.expire(now)
+		target := n.nextProbeTarget()
+		n.mu.Unlock()
+		n.notify(changed)
+
+		if target != nil {
+			go n.probe(target)
+		}
+		if now.Sub(lastShuffle) >= n.cfg.ShuffleInterval {
+			lastShuffle = now
+			go n.shuffle()
+		}
+		n.refill()
+	}
+}
+
+// nextProbeTarget returns the next peer to probe, visiting the active view in a random order that is
+// reshuffled after every round. The caller must hold mu.
+func (n *Node) nextProbeTarget() *peer {
+	for {
+		if len(n.probeOrder) == 0 {
+			for id := range n.peers {
+				n.probeOrder = append(n.probeOrder, id)
+			}
+			if len(n.probeOrder) == 0 {
+				return nil
+			}
+			mrand.Shuffle(len(n.probeOrder), func(i, j int) {
+				n.probeOrder[i], n.probeOrder[j] = n.probeOrder[j], n.probeOrder[i]
+			})
+		}
+		id := n.probeOrder[0]
+		n.probeOrder = n.probeOrder[1:]
+		if p := n.peers[id]; p != nil {
+			return p
+		}
+	}
+}
+
+// probe checks the liveness of a peer, first directly and then through other peers, and suspects it if
+// neither succeeds.
+func (n *Node) probe(target *peer) {
+	if n.ping(target) {
+		return
+	}
+
+	n.mu.Lock()
+	helpers := n.randomPeers(n.cfg.IndirectProbes, target.id)
+	n.mu.Unlock()
+	if len(helpers) > 0 {
+		seq, acked := n.expectAck()
+		req := &sngossip.PingReq{Seq: seq, Target: target.id.Bytes()}
+		for _, h := range helpers {
+			n.send(h, &sngossip.Message{Body: &sngossip.Message_PingReq{PingReq: req}})
+		}
+		// Helpers need a full direct probe of their own before they can answer
+		if n.waitAck(seq, acked, 2*n.cfg.ProbeTimeout) {
+			return
+		}
+	}
+
+	n.mu.Lock()
+	changed := n.suspect(target.id)
+	n.mu.Unlock()
+	n.notify(changed)
+}
+
+// ping sends a Ping to a peer and reports whether it was answered within the probe timeout.
+func (n *Node) ping(p *peer) bool {
+	seq, acked := n.expectAck()
+	if err := n.send(p, &sngossip.Message{Body: &sngossip.Message_Ping{Ping: &sngossip.Ping{Seq: seq}}}); err != nil {
+		n.waitAck(seq, acked, 0)
+		return false
+	}
+	return n.waitAck(seq, acked, n.cfg.ProbeTimeout)
+}
+
+// expectAck registers a new probe sequence number and returns it with the channel closed by its Ack.
+func (n *Node) expectAck() (uint64, chan struct{}) {
+	seq := n.seq.Add(1)
+	acked := make(chan struct{})
+	n.mu.Lock()
+	n.pending[seq] = acked
+	n.mu.Unlock()
+	return seq, acked
+}
+
+// waitAck waits up to timeout for the Ack of seq and unregisters it.
+func (n *Node) waitAck(seq uint64, acked chan struct{}, timeout time.Duration) bool {
+	defer func() {
+		n.mu.Lock()
+		delete(n.pending, seq)
+		n.mu.Unlock()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-acked:
+		return true
+	case <-timer.C:
+		return false
+	case <-n.closeChan:
+		return false
+	}
+}
+
+// handlePingReq probes a peer on behalf of another one and acknowledges the request if it answers.
+func (n *Node) handlePingReq(from *peer, req *sngossip.PingReq) {
+	id, err := p2p.IDFromBytes(req.Target)
+	if err != nil {
+		return
+	}
+	n.mu.Lock()
+	target := n.peers[id]
+	n.mu.Unlock()
+
+	if target != nil && n.ping(target) {
+		n.send(from, &sngossip.Message{Body: &sngossip.Message_Ack{Ack: &sngossip.Ack{Seq: req.Seq}}})
+	}
+}
+
+// shuffle sends a sample of known members to a random peer, which answers with a sample of its own.
+func (n *Node) shuffle() {
+	n.mu.Lock()
+	peers := n.randomPeers(1, identity.ID{})
+	records := n.sample()
+	n.mu.Unlock()
+
+	if len(peers) == 1 {
+		n.send(peers[0], &sngossip.Message{Body: &sngossip.Message_Shuffle{Shuffle: &sngossip.Shuffle{Records: records}}})
+	}
+}
+
+// restore learns the members remembered by the configured Peerstore, which refill then dials.
+func (n *Node) restore() {
+	if n.cfg.Peerstore == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), peerstoreTimeout)
+	records, err := n.cfg.Peerstore.Records(ctx)
+	cancel()
+	if err != nil {
+		return
+	}
+
+	for _, raw := range records {
+		// Expired records are rejected like those received in shuffles
+		if rec, err := identity.OpenRecord(raw); err == nil {
+			n.learn(rec, raw)
+		}
+	}
+}
+
+// learn adds a member known from a verified record obtained outside the group, e.g. from the peer store or
+// discovery. Like shuffled records, it never overrides first-hand state of known members.
+func (n *Node) learn(rec *identity.Record, raw []byte) {
+	n.mu.Lock()
+	changed := n.apply(&sngossip.Update{
+		Id:     rec.ID.Bytes(),
+		State:  sngossip.State_ALIVE,
+		Record: raw,
+	})
+	n.mu.Unlock()
+	n.notify(changed)
+}
+
+// handleShuffle merges the members of a peer exchange and answers it with a sample of our own.
+func (n *Node) handleShuffle(from *peer, s *sngossip.Shuffle) {
+	var changed []Member
+	n.mu.Lock()
+	for _, raw := range s.Records {
+		rec, err := identity.OpenRecord(raw)
+		if err != nil {
+			continue
+		}
+		// Incarnation 0 never overrides first-hand state, so only unknown members and newer records are taken
+		changed = append(changed, n.apply(&sngossip.Update{
+			Id:     rec.ID.Bytes(),
+			State:  sngossip.State_ALIVE,
+			Record: raw,
+		})...)
+	}
+	var records [][]byte
+	if !s.Reply {
+		records = n.sample()
+	}
+	n.mu.Unlock()
+	n.notify(changed)
+
+	if !s.Reply {
+		n.send(from, &sngossip.Message{Body: &sngossip.Message_Shuffle{Shuffle: &sngossip.Shuffle{Records: records, Reply: true}}})
+	}
+}
+
+// sample returns the node's own record and the records of random alive members, ShuffleSize in total.
+// The caller must hold mu.
+func (n *Node) sample() [][]byte {
+	records := [][]byte{n.record}
+	for _, m := range n.members {
+		if len(records) >= n.cfg.ShuffleSize {
+			break
+		}
+		// Map iteration order is random enough for sampling
+		if m.state == Alive {
+			records = append(records, m.raw)
+		}
+	}
+	return records
+}
+
+// refill dials a random alive member with addresses while the active view is smaller than ActiveSize.
+func (n *Node) refill() {
+	if n.cfg.Dial == nil {
+		return
+	}
+
+	n.mu.Lock()
+	if n.dialing || len(n.peers) >= n.cfg.ActiveSize {
+		n.mu.Unlock()
+		return
+	}
+	var candidates []*identity.Record
+	now := time.Now()
+	for id, m := range n.members {
+		if _, connected := n.peers[id]; connected || m.state != Alive || len(m.rec.Addrs) == 0 {
+			continue
+		}
+		if now.Sub(m.dialFailed) < n.cfg.ShuffleInterval {
+			continue
+		}
+		candidates = append(candidates, m.rec)
+	}
+	if len(candidates) == 0 {
+		n.mu.Unlock()
+		return
+	}
+	rec := candidates[mrand.IntN(len(candidates))]
+	n.dialing = true
+	n.mu.Unlock()
+
+	go func() {
+		ok := n.connect(rec)
+
+		n.mu.Lock()
+		n.dialing = false
+		if m := n.members[rec.ID]; m != nil && !ok {
+			m.dialFailed = time.Now()
+		}
+		n.mu.Unlock()
+	}()
+}
+
+// connect dials the addresses of a member until one succeeds.
+func (n *Node) connect(rec *identity.Record) bool {
+	for _, addr := range rec.Addrs {
+		ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+		conn, err := n.cfg.Dial(ctx, addr)
+		if err == nil {
+			var m Member
+			if m, err = n.AddConn(ctx, conn); err == nil && m.ID != rec.ID {
+				// The address now belongs to someone else; keep the connection, it is still a valid peer
+				err = errUnexpectedPeer
+			}
+		}
+		cancel()
+		if err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// trim closes random connections while the active view exceeds MaxPeers.
+func (n *Node) trim() {
+	n.mu.Lock()
+	var victims []*peer
+	if excess := len(n.peers) - n.cfg.MaxPeers; excess > 0 {
+		victims = n.randomPeers(excess, identity.ID{})
+	}
+	n.mu.Unlock()
+
+	for _, p := range victims {
+		p.closing.Store(true)
+		p.conn.Close()
+	}
+}
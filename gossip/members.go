@@ -0,0 +1,157 @@
+package gossip
+
+import (
+	"slices"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/p2p"
+	sngossip "pkg.gfire.dev/supernet/proto/sngossip/v1alpha1"
+)
+
+// apply merges a member update into the local view, queues it for further dissemination if it changed
+// anything and returns the changed members. The caller must hold mu.
+func (n *Node) apply(u *sngossip.Update) []Member {
+	id, err := p2p.IDFromBytes(u.Id)
+	if err != nil {
+		return nil
+	}
+	state := State(u.State)
+	if state < Alive || state > Dead {
+		return nil
+	}
+
+	if id == n.self.ID() {
+		// Refute rumours of our failure by outranking them with a higher incarnation
+		if state != Alive && u.Incarnation >= n.incarnation {
+			n.incarnation = u.Incarnation + 1
+			n.enqueue(n.selfUpdate())
+		}
+		return nil
+	}
+
+	var rec *identity.Record
+	if len(u.Record) > 0 {
+		if rec, err = identity.OpenRecord(u.Record); err != nil || rec.ID != id {
+			return nil
+		}
+	}
+
+	m := n.members[id]
+	if m == nil {
+		// Members are only learned with a record telling how to reach them
+		if state == Dead || rec == nil || len(n.members) >= n.cfg.MaxMembers {
+			return nil
+		}
+		m = &member{rec: rec, raw: u.Record, state: state, incarnation: u.Incarnation, changed: time.Now()}
+		n.members[id] = m
+		n.enqueue(u)
+		return []Member{n.memberLocked(id)}
+	}
+
+	if rec != nil && rec.Newer(m.rec) {
+		m.rec, m.raw = rec, u.Record
+	}
+	if !overrides(m, state, u.Incarnation) {
+		return nil
+	}
+	m.state, m.incarnation, m.changed = state, u.Incarnation, time.Now()
+
+	fwd := &sngossip.Update{Id: u.Id, State: u.State, Incarnation: u.Incarnation}
+	if state == Alive {
+		fwd.Record = m.raw
+	}
+	n.enqueue(fwd)
+
+	// Stop talking to dead members
+	if p := n.peers[id]; p != nil && state == Dead {
+		p.closing.Store(true)
+		go p.conn.Close()
+	}
+	return []Member{n.memberLocked(id)}
+}
+
+// overrides reports whether an update with the given state and incarnation supersedes the state of m.
+// Within an incarnation, dead beats suspect beats alive; only the member itself raises its incarnation.
+func overrides(m *member, state State, incarnation uint64) bool {
+	switch state {
+	case Alive:
+		return incarnation > m.incarnation
+	case Suspect:
+		return incarnation > m.incarnation || (incarnation == m.incarnation && m.state == Alive)
+	default:
+		return incarnation > m.incarnation || (incarnation == m.incarnation && m.state != Dead)
+	}
+}
+
+// suspect marks an alive member as suspect. The caller must hold mu.
+func (n *Node) suspect(id identity.ID) []Member {
+	m := n.members[id]
+	if m == nil || m.state != Alive {
+		return nil
+	}
+	return n.apply(&sngossip.Update{Id: id.Bytes(), State: sngossip.State_SUSPECT, Incarnation: m.incarnation})
+}
+
+// expire declares suspects dead once their suspicion timeout has passed and forgets dead members and
+// members with expired records. The caller must hold mu.
+func (n *Node) expire(now time.Time) []Member {
+	var changed []Member
+	for id, m := range n.members {
+		_, connected := n.peers[id]
+		switch {
+		case m.state == Suspect && now.Sub(m.changed) > n.cfg.SuspicionTimeout:
+			changed = append(changed, n.apply(&sngossip.Update{
+				Id:          id.Bytes(),
+				State:       sngossip.State_DEAD,
+				Incarnation: m.incarnation,
+			})...)
+		case m.state == Dead && now.Sub(m.changed) > deadRetention:
+			delete(n.members, id)
+		case !connected && now.After(m.rec.Expires):
+			delete(n.members, id)
+		}
+	}
+	return changed
+}
+
+// selfUpdate returns an update announcing the node as alive. The caller must hold mu.
+func (n *Node) selfUpdate() *sngossip.Update {
+	return &sngossip.Update{
+		Id:          n.self.ID().Bytes(),
+		State:       sngossip.State_ALIVE,
+		Incarnation: n.incarnation,
+		Record:      n.record,
+	}
+}
+
+// enqueue queues an update for dissemination, replacing older updates about the same member.
+// The caller must hold mu.
+func (n *Node) enqueue(u *sngossip.Update) {
+	n.queue = slices.DeleteFunc(n.queue, func(q *queuedUpdate) bool {
+		return string(q.update.Id) == string(u.Id)
+	})
+	n.queue = append(n.queue, &queuedUpdate{update: u})
+}
+
+// piggyback returns the updates to attach to an outgoing message, preferring the least sent ones, and
+// retires updates that have been sent often enough. The caller must hold mu.
+func (n *Node) piggyback() []*sngossip.Update {
+	if len(n.queue) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(n.queue, func(a, b *queuedUpdate) int {
+		return a.sent - b.sent
+	})
+
+	limit := n.retransmitLimit()
+	updates := make([]*sngossip.Update, 0, min(len(n.queue), maxPiggyback))
+	for _, q := range n.queue[:min(len(n.queue), maxPiggyback)] {
+		updates = append(updates, q.update)
+		q.sent++
+	}
+	n.queue = slices.DeleteFunc(n.queue, func(q *queuedUpdate) bool {
+		return q.sent >= limit
+	})
+	return updates
+}
@@ -0,0 +1,37 @@
+package grpcnet
+
+import (
+	"context"
+	"net"
+
+	"pkg.gfire.dev/supernet/msgconn"
+	"pkg.gfire.dev/supernet/web/wasmlib/webtransportjs"
+	"pkg.gfire.dev/supernet/web/wasmlib/wsjs"
+)
+
+// DialWebSocket connects to the WebSocket URL target, e.g. "wss://example.com/grpc", served by a
+// wslisten.Listener. It is a DialFunc.
+func DialWebSocket(ctx context.Context, target string) (net.Conn, error) {
+	conn, err := wsjs.DialContext(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	return msgconn.NetConn(conn, msgconn.Options{
+		RemoteAddr: msgconn.Addr{Net: "websocket", Addr: target},
+	}), nil
+}
+
+// DialWebTransport returns a DialFunc that connects to the WebTransport URL target, e.g.
+// "https://example.com/grpc", served by a wtlisten.Listener passed to ServeWebTransport. Every connection
+// uses a session of its own.
+func DialWebTransport(opts webtransportjs.Options) DialFunc {
+	return func(ctx context.Context, target string) (net.Conn, error) {
+		conn, err := webtransportjs.DialConn(ctx, target, opts)
+		if err != nil {
+			return nil, err
+		}
+		return msgconn.NetConn(conn, msgconn.Options{
+			RemoteAddr: msgconn.Addr{Net: "webtransport", Addr: target},
+		}), nil
+	}
+}
@@ -0,0 +1,42 @@
+// Package grpcnet runs standard gRPC over supernet connections, so browser clients get full
+// bidirectional streaming instead of the unary and server-streaming calls of gRPC-Web.
+//
+// gRPC needs nothing but a byte stream: in the browser, NewClient dials the server over a WebSocket or
+// WebTransport connection, or through the overlay with supernet.Dialer.DialURL:
+//
+//	cc, err := grpcnet.NewClient("wss://example.com/grpc", grpcnet.DialWebSocket)
+//	cc, err := grpcnet.NewClient("peer://backend/grpc", dialer.DialURL)
+//	client := pb.NewChatClient(cc)
+//
+// On the server, a wslisten.Listener is a net.Listener for grpc.Server.Serve, and ServeWebTransport feeds
+// it the connections of WebTransport clients as well:
+//
+//	ln := wslisten.New(wslisten.Config{})
+//	http.Handle("/grpc", ln)
+//	go grpcnet.ServeWebTransport(ctx, wt, ln)
+//	grpcServer.Serve(ln)
+package grpcnet
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// DialFunc connects to the server named by the target of a client.
+type DialFunc func(ctx context.Context, target string) (net.Conn, error)
+
+// NewClient creates a gRPC client connecting to target with dial. The target is passed to dial as is,
+// without name resolution, and connections are made lazily on the first call like with grpc.NewClient.
+//
+// Transport credentials default to none, since the connections of wss:// and https:// URLs and of
+// supernet tunnels are secured already; pass grpc.WithTransportCredentials in opts to override.
+func NewClient(target string, dial DialFunc, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(dial),
+	}, opts...)
+	return grpc.NewClient("passthrough:///"+target, opts...)
+}
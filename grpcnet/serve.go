@@ -0,0 +1,45 @@
+//go:build !js
+
+package grpcnet
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"pkg.gfire.dev/supernet/wslisten"
+	"pkg.gfire.dev/supernet/wtlisten"
+)
+
+// ServeWebTransport accepts the sessions of wt and offers every stream their clients open, such as the
+// connections of DialWebTransport, to ln as a net.Conn. It returns when ctx ends or wt is closed; sessions
+// accepted before stay open until ln is closed.
+func ServeWebTransport(ctx context.Context, wt *wtlisten.Listener, ln *wslisten.Listener) error {
+	for {
+		sess, err := wt.Accept(ctx)
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := offerConns(ctx, sess, ln); errors.Is(err, net.ErrClosed) {
+				sess.Close()
+			}
+		}()
+	}
+}
+
+// offerConns offers the streams of sess to ln until the session ends or ln is closed.
+func offerConns(ctx context.Context, sess *wtlisten.Session, ln *wslisten.Listener) error {
+	for {
+		conn, err := sess.AcceptConn(ctx)
+		if err != nil {
+			return err
+		}
+		if err := ln.Offer(conn.NetConn()); err != nil {
+			conn.Close()
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package supernet
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"pkg.gfire.dev/supernet/naming"
+)
+
+// HTTPService is the service of a peer that HTTP requests to peer URLs are sent to.
+const HTTPService = "http"
+
+// NewTransport returns an http.Transport whose connections are dialed through d, so ordinary HTTP clients
+// reach services that are only accessible through relays or the overlay. Requests to peer URLs such as
+// "peer://alice/api/status" are sent as plain HTTP to the HTTPService of the peer, relying on the peer
+// connection for encryption. Proxy settings of the environment are ignored.
+//
+// On js/wasm the returned transport dials through d instead of using the browser's fetch API.
+func NewTransport(d *Dialer) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = d.DialContext
+
+	peers := t.Clone()
+	peers.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		name, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		return d.dialPeer(ctx, naming.Scheme+"://"+name+"/"+HTTPService)
+	}
+	t.RegisterProtocol(naming.Scheme, peerTransport{peers})
+	return t
+}
+
+// peerTransport sends requests for peer URLs over HTTP connections to the peers.
+type peerTransport struct {
+	t *http.Transport
+}
+
+// RoundTrip sends req to the peer named by its host.
+func (p peerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u := *req.URL
+	u.Scheme = "http"
+	req = req.Clone(req.Context())
+	req.URL = &u
+	return p.t.RoundTrip(req)
+}
@@ -0,0 +1,108 @@
+// Package identity manages node identities: an Ed25519 signing key whose SHA-256 digest is the stable peer
+// ID, and an X25519 key for key agreement that is bound to the identity through signed peer records.
+// Identities persist in a Store, a file natively or IndexedDB in browsers, so peer IDs survive restarts.
+package identity
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+
+	"pkg.gfire.dev/supernet/p2p"
+	snidentity "pkg.gfire.dev/supernet/proto/snidentity/v1alpha1"
+)
+
+// ErrInvalidKey is returned when parsing malformed private key material
+var ErrInvalidKey = errors.New("invalid identity key")
+
+// ID is a peer ID, the SHA-256 digest of the peer's Ed25519 public key.
+// It is the same ID used by the p2p overlay.
+type ID = p2p.ID
+
+// Identity is a node's private key material.
+type Identity struct {
+	// key is the Ed25519 signing key
+	key ed25519.PrivateKey
+	// static is the X25519 key agreement key
+	static *ecdh.PrivateKey
+	// id caches the peer ID derived from key
+	id ID
+}
+
+// Generate creates a new random identity.
+func Generate() (*Identity, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	static, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newIdentity(key, static), nil
+}
+
+// Parse decodes an identity encoded by Marshal.
+func Parse(data []byte) (*Identity, error) {
+	pk := &snidentity.PrivateKey{}
+	if err := pk.UnmarshalVT(data); err != nil {
+		return nil, ErrInvalidKey
+	}
+	if len(pk.Ed25519Seed) != ed25519.SeedSize {
+		return nil, ErrInvalidKey
+	}
+	static, err := ecdh.X25519().NewPrivateKey(pk.X25519Key)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	return newIdentity(ed25519.NewKeyFromSeed(pk.Ed25519Seed), static), nil
+}
+
+// newIdentity assembles an identity from its keys.
+func newIdentity(key ed25519.PrivateKey, static *ecdh.PrivateKey) *Identity {
+	return &Identity{
+		key:    key,
+		static: static,
+		id:     p2p.IDFromPublicKey(key.Public().(ed25519.PublicKey)),
+	}
+}
+
+// Marshal encodes the private key material for storage. Keep the result secret.
+func (i *Identity) Marshal() ([]byte, error) {
+	return (&snidentity.PrivateKey{
+		Ed25519Seed: i.key.Seed(),
+		X25519Key:   i.static.Bytes(),
+	}).MarshalVT()
+}
+
+// ID returns the peer ID of the identity.
+func (i *Identity) ID() ID {
+	return i.id
+}
+
+// PublicKey returns the Ed25519 public key of the identity.
+func (i *Identity) PublicKey() ed25519.PublicKey {
+	return i.key.Public().(ed25519.PublicKey)
+}
+
+// PrivateKey returns the Ed25519 signing key, e.g. for p2p.Config.
+func (i *Identity) PrivateKey() ed25519.PrivateKey {
+	return i.key
+}
+
+// StaticKey returns the X25519 key agreement key, e.g. for noise.Config.
+func (i *Identity) StaticKey() *ecdh.PrivateKey {
+	return i.static
+}
+
+// Sign signs data with the identity's Ed25519 key.
+// Callers should prefix data with a context string so signatures cannot be replayed across protocols.
+func (i *Identity) Sign(data []byte) []byte {
+	return ed25519.Sign(i.key, data)
+}
+
+// Verify reports whether sig is a valid signature of data by the peer with the given public key.
+func Verify(pub ed25519.PublicKey, data, sig []byte) bool {
+	return len(pub) == ed25519.PublicKeySize && ed25519.Verify(pub, data, sig)
+}
@@ -0,0 +1,102 @@
+package identity
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"errors"
+	"time"
+
+	"pkg.gfire.dev/supernet/p2p"
+	snidentity "pkg.gfire.dev/supernet/proto/snidentity/v1alpha1"
+)
+
+var (
+	// ErrInvalidRecord is returned for peer records that are malformed or carry a bad signature
+	ErrInvalidRecord = errors.New("invalid peer record")
+	// ErrRecordExpired is returned for peer records past their expiry
+	ErrRecordExpired = errors.New("peer record expired")
+)
+
+// recordSignaturePrefix separates peer record signatures from signatures made for other purposes
+const recordSignaturePrefix = "supernet-peer-record:"
+
+// DefaultRecordTTL is the lifetime of records created by NewRecord when no TTL is given.
+const DefaultRecordTTL = 24 * time.Hour
+
+// Record is a verified peer record.
+type Record struct {
+	ID        ID                // Peer ID derived from PublicKey
+	PublicKey ed25519.PublicKey // Ed25519 public key of the peer
+	StaticKey *ecdh.PublicKey   // X25519 key agreement key of the peer
+	Addrs     []string          // Addresses the peer can be dialed at
+	Seq       uint64            // Sequence number, higher is newer
+	Expires   time.Time         // Time after which the record must not be used
+}
+
+// NewRecord creates a signed peer record announcing addrs, valid for ttl (DefaultRecordTTL if zero).
+// The sequence number is the current time in nanoseconds, so newer records supersede older ones without
+// persisting a counter.
+func (i *Identity) NewRecord(addrs []string, ttl time.Duration) ([]byte, error) {
+	if ttl <= 0 {
+		ttl = DefaultRecordTTL
+	}
+	now := time.Now()
+	rec, err := (&snidentity.PeerRecord{
+		PublicKey:     i.PublicKey(),
+		StaticKey:     i.static.PublicKey().Bytes(),
+		Addresses:     addrs,
+		Seq:           uint64(now.UnixNano()),
+		ExpiresUnixMs: now.Add(ttl).UnixMilli(),
+	}).MarshalVT()
+	if err != nil {
+		return nil, err
+	}
+	return (&snidentity.SignedPeerRecord{
+		Record:    rec,
+		Signature: i.Sign(recordMessage(rec)),
+	}).MarshalVT()
+}
+
+// OpenRecord verifies a signed peer record and returns its contents.
+func OpenRecord(data []byte) (*Record, error) {
+	signed := &snidentity.SignedPeerRecord{}
+	if err := signed.UnmarshalVT(data); err != nil {
+		return nil, ErrInvalidRecord
+	}
+	pr := &snidentity.PeerRecord{}
+	if err := pr.UnmarshalVT(signed.Record); err != nil {
+		return nil, ErrInvalidRecord
+	}
+	pub := ed25519.PublicKey(pr.PublicKey)
+	if !Verify(pub, recordMessage(signed.Record), signed.Signature) {
+		return nil, ErrInvalidRecord
+	}
+	static, err := ecdh.X25519().NewPublicKey(pr.StaticKey)
+	if err != nil {
+		return nil, ErrInvalidRecord
+	}
+
+	rec := &Record{
+		ID:        p2p.IDFromPublicKey(pub),
+		PublicKey: pub,
+		StaticKey: static,
+		Addrs:     pr.Addresses,
+		Seq:       pr.Seq,
+		Expires:   time.UnixMilli(pr.ExpiresUnixMs),
+	}
+	if time.Now().After(rec.Expires) {
+		return nil, ErrRecordExpired
+	}
+	return rec, nil
+}
+
+// Newer reports whether r supersedes other, a record of the same peer.
+func (r *Record) Newer(other *Record) bool {
+	return bytes.Equal(r.PublicKey, other.PublicKey) && r.Seq > other.Seq
+}
+
+// recordMessage returns the signed message of an encoded record.
+func recordMessage(rec []byte) []byte {
+	return append([]byte(recordSignaturePrefix), rec...)
+}
@@ -0,0 +1,46 @@
+package identity
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned by Store.Load when no identity has been saved
+var ErrNotFound = errors.New("identity not found")
+
+// Store persists an encoded identity.
+type Store interface {
+	// Load returns the saved identity, or ErrNotFound.
+	Load(ctx context.Context) ([]byte, error)
+	// Create saves data unless an identity has been saved already, in which case the existing one is kept
+	// and no error is returned. This keeps concurrent first runs, e.g. in several tabs, on one identity.
+	Create(ctx context.Context, data []byte) error
+}
+
+// LoadOrGenerate loads the identity saved in store, generating and saving a new one on first use.
+// When several processes race on first use, all of them end up with the identity saved first.
+func LoadOrGenerate(ctx context.Context, store Store) (*Identity, error) {
+	data, err := store.Load(ctx)
+	if err == nil {
+		return Parse(data)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	id, err := Generate()
+	if err != nil {
+		return nil, err
+	}
+	if data, err = id.Marshal(); err != nil {
+		return nil, err
+	}
+	if err := store.Create(ctx, data); err != nil {
+		return nil, err
+	}
+	// Reload in case another process saved its identity first
+	if data, err = store.Load(ctx); err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
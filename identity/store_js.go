@@ -0,0 +1,93 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/idbjs"
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+const (
+	// idbVersion is the schema version of identity databases
+	idbVersion = 1
+	// idbStore is the object store holding identities
+	idbStore = "identity"
+	// idbKey is the key of the identity in idbStore
+	idbKey = "self"
+)
+
+var (
+	// _Uint8Array is a cached reference to the Uint8Array constructor for storing key material
+	_Uint8Array = js.Global().Get("Uint8Array")
+)
+
+// IDBStore stores an identity in the IndexedDB database with the given name, shared by all tabs of the origin.
+type IDBStore string
+
+// Load reads the identity from IndexedDB.
+func (s IDBStore) Load(ctx context.Context) ([]byte, error) {
+	db, err := s.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tx, err := db.Transaction(idbjs.ReadOnly, idbStore)
+	if err != nil {
+		return nil, err
+	}
+	store, err := tx.Store(idbStore)
+	if err != nil {
+		return nil, err
+	}
+	v, err := store.Get(js.ValueOf(idbKey)).Wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !v.InstanceOf(_Uint8Array) {
+		return nil, ErrNotFound
+	}
+	data := make([]byte, v.Length())
+	js.CopyBytesToGo(data, v)
+	return data, nil
+}
+
+// Create writes the identity to IndexedDB unless one is stored already.
+func (s IDBStore) Create(ctx context.Context, data []byte) error {
+	db, err := s.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Transaction(idbjs.ReadWrite, idbStore)
+	if err != nil {
+		return err
+	}
+	store, err := tx.Store(idbStore)
+	if err != nil {
+		return err
+	}
+	v := _Uint8Array.New(len(data))
+	js.CopyBytesToJS(v, data)
+	// Add fails with a ConstraintError and aborts the transaction if the key exists
+	store.Add(v, js.ValueOf(idbKey))
+	var jsErr *promisejs.Error
+	if err := tx.Done(ctx); err != nil && !(errors.As(err, &jsErr) && jsErr.Name == "ConstraintError") {
+		return err
+	}
+	return nil
+}
+
+// open opens the database, creating the identity store on first use.
+func (s IDBStore) open(ctx context.Context) (*idbjs.DB, error) {
+	return idbjs.Open(ctx, string(s), idbVersion, func(db *idbjs.DB, oldVersion, newVersion int) error {
+		if db.HasStore(idbStore) {
+			return nil
+		}
+		_, err := db.CreateStore(idbStore, idbjs.StoreOptions{})
+		return err
+	})
+}
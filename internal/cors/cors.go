@@ -0,0 +1,38 @@
+// Package cors lets web pages on other origins use the HTTP handlers served next to the relay, such as the
+// discovery tracker and the TURN credentials endpoint.
+package cors
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Allow sets the CORS headers of the response to r if its Origin matches one of patterns, permitting the
+// request headers listed in headers, e.g. "Authorization".
+func Allow(w http.ResponseWriter, r *http.Request, patterns []string, headers string) {
+	origin := r.Header.Get("Origin")
+	if origin == "" || !Match(origin, patterns) {
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Allow-Headers", headers)
+	w.Header().Add("Vary", "Origin")
+}
+
+// Match reports whether the host of origin matches one of patterns, as in
+// websocket.AcceptOptions.OriginPatterns.
+func Match(origin string, patterns []string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Host)
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), host); ok {
+			return true
+		}
+	}
+	return false
+}
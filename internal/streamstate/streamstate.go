@@ -0,0 +1,96 @@
+// Package streamstate tracks how a flow-controlled stream ends and wakes the goroutines blocked on it, so
+// the stream implementations of mux and migrate report the same errors once a stream is closed or fails.
+package streamstate
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// State is the lifecycle of a stream. The zero value is an open stream. State is not synchronized: the
+// stream guards it with the mutex that also protects its buffers, and all methods must be called with that
+// mutex held.
+type State struct {
+	// LocalClosed is set once the local side closed the stream
+	LocalClosed bool
+	// RemoteClosed is set once the remote side closed the stream
+	RemoteClosed bool
+	// Err is set when the stream failed
+	Err error
+
+	// changed is closed by the next Broadcast, created on demand
+	changed chan struct{}
+}
+
+// Changed returns a channel that is closed by the next Broadcast. Blocked readers and writers take it
+// before releasing the mutex and wait on it after.
+func (s *State) Changed() <-chan struct{} {
+	if s.changed == nil {
+		s.changed = make(chan struct{})
+	}
+	return s.changed
+}
+
+// Broadcast wakes all goroutines waiting on Changed, e.g. after data arrived, window was granted or the
+// state changed.
+func (s *State) Broadcast() {
+	if s.changed != nil {
+		close(s.changed)
+		s.changed = nil
+	}
+}
+
+// Fail ends the stream with err, unless it already failed, and wakes all waiters.
+func (s *State) Fail(err error) {
+	if s.Err == nil {
+		s.Err = err
+	}
+	s.Broadcast()
+}
+
+// ReadErr returns the error a read should report once no data is left: the failure, closed after a local
+// close, or io.EOF after the remote side closed the stream.
+func (s *State) ReadErr(closed error) error {
+	switch {
+	case s.Err != nil:
+		return s.Err
+	case s.LocalClosed:
+		return closed
+	case s.RemoteClosed:
+		return io.EOF
+	}
+	return nil
+}
+
+// WriteErr returns the error a write should report: the failure, or closed once either side closed the
+// stream.
+func (s *State) WriteErr(closed error) error {
+	switch {
+	case s.Err != nil:
+		return s.Err
+	case s.LocalClosed, s.RemoteClosed:
+		return closed
+	}
+	return nil
+}
+
+// Wait blocks until changed is closed or the deadline passes. A zero deadline waits indefinitely.
+func Wait(changed <-chan struct{}, deadline time.Time) error {
+	if deadline.IsZero() {
+		<-changed
+		return nil
+	}
+	d := time.Until(deadline)
+	if d <= 0 {
+		return os.ErrDeadlineExceeded
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-changed:
+		return nil
+	case <-timer.C:
+		return os.ErrDeadlineExceeded
+	}
+}
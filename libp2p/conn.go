@@ -0,0 +1,76 @@
+package libp2p
+
+import (
+	"net"
+
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+
+	"pkg.gfire.dev/supernet/msgconn"
+)
+
+// ErrClosed is returned when using a connection that has been closed
+var ErrClosed = msgconn.ErrClosed
+
+// MessageConn is a message-oriented connection such as wsjs.Conn or webrtcjs.DataChannel.
+type MessageConn interface {
+	NextMessage() ([]byte, error)
+	Send(data []byte) error
+	Close() error
+}
+
+// streamConn adapts a MessageConn into a manet.Conn byte stream so it can be handed to a libp2p upgrader,
+// which adds the security handshake and stream multiplexer on top. Reads, writes and deadlines are those of
+// msgconn.NetConn.
+type streamConn struct {
+	net.Conn
+
+	laddr ma.Multiaddr
+	raddr ma.Multiaddr
+}
+
+// newStreamConn wraps conn and starts pumping its messages.
+func newStreamConn(conn MessageConn, laddr, raddr ma.Multiaddr) *streamConn {
+	return &streamConn{
+		Conn: msgconn.NetConn(conn, msgconn.Options{
+			LocalAddr:  multiaddrNetAddr{laddr},
+			RemoteAddr: multiaddrNetAddr{raddr},
+		}),
+		laddr: laddr,
+		raddr: raddr,
+	}
+}
+
+// LocalMultiaddr returns the local multiaddr.
+func (c *streamConn) LocalMultiaddr() ma.Multiaddr {
+	return c.laddr
+}
+
+// RemoteMultiaddr returns the remote multiaddr.
+func (c *streamConn) RemoteMultiaddr() ma.Multiaddr {
+	return c.raddr
+}
+
+// multiaddrNetAddr exposes a multiaddr as a net.Addr for transports without a native net.Addr form.
+type multiaddrNetAddr struct {
+	addr ma.Multiaddr
+}
+
+// Network returns the name of the transport protocol of the address.
+func (a multiaddrNetAddr) Network() string {
+	if len(a.addr) == 0 {
+		return "supernet"
+	}
+	protocols := a.addr.Protocols()
+	return protocols[len(protocols)-1].Name
+}
+
+// String returns the multiaddr string.
+func (a multiaddrNetAddr) String() string {
+	if len(a.addr) == 0 {
+		return ""
+	}
+	return a.addr.String()
+}
+
+var _ manet.Conn = (*streamConn)(nil)
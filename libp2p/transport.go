@@ -0,0 +1,95 @@
+package libp2p
+
+import (
+	"context"
+	"errors"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/transport"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// ErrListenUnsupported is returned by Listen, since browser transports can only dial.
+var ErrListenUnsupported = errors.New("browser transports cannot listen")
+
+// DialFunc establishes a message connection to the given multiaddr.
+type DialFunc func(ctx context.Context, raddr ma.Multiaddr) (MessageConn, error)
+
+// MatchFunc reports whether a transport can dial the given multiaddr.
+type MatchFunc func(addr ma.Multiaddr) bool
+
+// Transport is a libp2p transport.Transport backed by supernet message connections.
+// Raw connections are upgraded with the host's Upgrader, so libp2p negotiates its usual
+// security (Noise/TLS) and multiplexer (yamux/mplex) on top of the browser connection.
+//
+// There is no WebTransport variant: libp2p's WebTransport transport does not go through the Upgrader but
+// authenticates sessions with a Noise handshake bound to the server's certificate hashes and multiplexes on
+// native WebTransport streams, so an upgraded webtransportjs connection would not interoperate with its
+// listeners.
+type Transport struct {
+	upgrader  transport.Upgrader
+	rcmgr     network.ResourceManager
+	protocols []int
+	match     MatchFunc
+	dial      DialFunc
+}
+
+// NewTransport creates a transport for the given multiaddr protocol codes.
+// match selects the addresses the transport can dial and dial establishes the raw message connection.
+// A nil rcmgr disables resource accounting.
+func NewTransport(upgrader transport.Upgrader, rcmgr network.ResourceManager, protocols []int, match MatchFunc, dial DialFunc) *Transport {
+	if rcmgr == nil {
+		rcmgr = &network.NullResourceManager{}
+	}
+	return &Transport{
+		upgrader:  upgrader,
+		rcmgr:     rcmgr,
+		protocols: protocols,
+		match:     match,
+		dial:      dial,
+	}
+}
+
+// Dial establishes a connection to the peer at raddr and upgrades it to a secured, multiplexed libp2p connection.
+func (t *Transport) Dial(ctx context.Context, raddr ma.Multiaddr, p peer.ID) (transport.CapableConn, error) {
+	scope, err := t.rcmgr.OpenConnection(network.DirOutbound, false, raddr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := t.dial(ctx, raddr)
+	if err != nil {
+		scope.Done()
+		return nil, err
+	}
+
+	capable, err := t.upgrader.Upgrade(ctx, t, newStreamConn(conn, nil, raddr), network.DirOutbound, p, scope)
+	if err != nil {
+		scope.Done()
+		return nil, err
+	}
+	return capable, nil
+}
+
+// CanDial reports whether the transport can dial the given multiaddr.
+func (t *Transport) CanDial(addr ma.Multiaddr) bool {
+	return t.match(addr)
+}
+
+// Listen always fails: browsers cannot accept incoming connections.
+func (t *Transport) Listen(laddr ma.Multiaddr) (transport.Listener, error) {
+	return nil, ErrListenUnsupported
+}
+
+// Protocols returns the multiaddr protocol codes handled by the transport.
+func (t *Transport) Protocols() []int {
+	return t.protocols
+}
+
+// Proxy reports false: the transport connects to the addressed peer itself.
+func (t *Transport) Proxy() bool {
+	return false
+}
+
+var _ transport.Transport = (*Transport)(nil)
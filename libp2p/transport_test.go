@@ -0,0 +1,141 @@
+//go:build !js
+
+package libp2p
+
+import (
+	"context"
+	"crypto/rand"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/sec"
+	"github.com/libp2p/go-libp2p/core/transport"
+	"github.com/libp2p/go-libp2p/p2p/muxer/yamux"
+	"github.com/libp2p/go-libp2p/p2p/net/upgrader"
+	"github.com/libp2p/go-libp2p/p2p/security/noise"
+	ma "github.com/multiformats/go-multiaddr"
+
+	"pkg.gfire.dev/supernet/memtransport"
+)
+
+// testTimeout bounds every blocking call of a test
+const testTimeout = 10 * time.Second
+
+// newUpgrader returns an upgrader securing connections with Noise and multiplexing them with yamux, and the
+// peer ID it authenticates as.
+func newUpgrader(t *testing.T) (transport.Upgrader, peer.ID) {
+	t.Helper()
+	key, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	muxers := []upgrader.StreamMuxer{{ID: yamux.ID, Muxer: yamux.DefaultTransport}}
+	security, err := noise.New(noise.ID, key, muxers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := upgrader.New([]sec.SecureTransport{security}, muxers, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u, id
+}
+
+// newMemTransport returns a transport dialing addr on n for every multiaddr.
+func newMemTransport(u transport.Upgrader, n *memtransport.Net, addr string) *Transport {
+	return NewTransport(u, nil, []int{ma.P_WS}, IsWebSocketAddr, func(ctx context.Context, raddr ma.Multiaddr) (MessageConn, error) {
+		return n.Dial(ctx, addr)
+	})
+}
+
+// serveEcho upgrades the connections accepted by l as server u and echoes every stream opened on them.
+func serveEcho(t *testing.T, u transport.Upgrader, l *memtransport.Listener) {
+	t.Helper()
+	laddr := ma.StringCast("/ip4/127.0.0.1/tcp/8080/ws")
+	tr := NewTransport(u, nil, []int{ma.P_WS}, IsWebSocketAddr, nil)
+	go func() {
+		for {
+			conn, err := l.Accept(context.Background())
+			if err != nil {
+				return
+			}
+			go func() {
+				scope, _ := (&network.NullResourceManager{}).OpenConnection(network.DirInbound, false, laddr)
+				capable, err := u.Upgrade(context.Background(), tr, newStreamConn(conn, laddr, nil), network.DirInbound, "", scope)
+				if err != nil {
+					return
+				}
+				defer capable.Close()
+				for {
+					s, err := capable.AcceptStream()
+					if err != nil {
+						return
+					}
+					go func() {
+						defer s.Close()
+						io.Copy(s, s)
+					}()
+				}
+			}()
+		}
+	}()
+}
+
+func TestDial(t *testing.T) {
+	n := memtransport.NewNet(memtransport.Config{})
+	l, err := n.Listen("server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	serverUpgrader, serverID := newUpgrader(t)
+	serveEcho(t, serverUpgrader, l)
+
+	clientUpgrader, clientID := newUpgrader(t)
+	tr := newMemTransport(clientUpgrader, n, "server")
+	raddr := ma.StringCast("/ip4/127.0.0.1/tcp/8080/ws")
+	if !tr.CanDial(raddr) || tr.CanDial(ma.StringCast("/ip4/127.0.0.1/tcp/8080")) {
+		t.Fatal("CanDial does not match WebSocket addresses only")
+	}
+	if _, err := tr.Listen(raddr); err != ErrListenUnsupported {
+		t.Fatalf("Listen: %v, want ErrListenUnsupported", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	conn, err := tr.Dial(ctx, raddr, serverID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if conn.RemotePeer() != serverID || !conn.RemoteMultiaddr().Equal(raddr) {
+		t.Fatalf("connected to %s at %s", conn.RemotePeer(), conn.RemoteMultiaddr())
+	}
+
+	s, err := conn.OpenStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.SetDeadline(time.Now().Add(testTimeout))
+	if _, err := s.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	s.CloseWrite()
+	if got, err := io.ReadAll(s); err != nil || string(got) != "hello" {
+		t.Fatalf("echoed %q, %v", got, err)
+	}
+
+	// The security handshake authenticates the expected peer
+	if _, err := tr.Dial(ctx, raddr, clientID); err == nil {
+		t.Fatal("dial authenticated the wrong peer")
+	}
+}
@@ -0,0 +1,70 @@
+package libp2p
+
+import (
+	"errors"
+	"net"
+	"strconv"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// ErrInvalidAddress is returned when a multiaddr cannot be converted into a WebSocket URL.
+var ErrInvalidAddress = errors.New("not a websocket multiaddr")
+
+// IsWebSocketAddr reports whether addr is a /ws or /wss multiaddr over TCP.
+func IsWebSocketAddr(addr ma.Multiaddr) bool {
+	_, err := WebSocketURL(addr)
+	return err == nil
+}
+
+// WebSocketURL converts a multiaddr such as /dns4/example.com/tcp/443/wss or
+// /ip4/127.0.0.1/tcp/8080/ws into a WebSocket URL. A trailing /p2p component is ignored.
+func WebSocketURL(addr ma.Multiaddr) (string, error) {
+	var host, port, scheme string
+	secure := false
+
+	for _, p := range addr.Protocols() {
+		value, _ := addr.ValueForProtocol(p.Code)
+		switch p.Code {
+		case ma.P_IP4, ma.P_DNS, ma.P_DNS4, ma.P_DNS6:
+			host = value
+		case ma.P_IP6:
+			host = "[" + value + "]"
+		case ma.P_TCP:
+			port = value
+		case ma.P_TLS:
+			secure = true
+		case ma.P_WS:
+			scheme = "ws"
+		case ma.P_WSS:
+			scheme = "wss"
+		case ma.P_P2P:
+		default:
+			return "", ErrInvalidAddress
+		}
+	}
+
+	if host == "" || port == "" || scheme == "" {
+		return "", ErrInvalidAddress
+	}
+	if secure {
+		scheme = "wss"
+	}
+	if _, err := strconv.Atoi(port); err != nil {
+		return "", ErrInvalidAddress
+	}
+
+	// Omit default ports so the browser sends the canonical Host header
+	if (scheme == "ws" && port == "80") || (scheme == "wss" && port == "443") {
+		return scheme + "://" + host, nil
+	}
+	return scheme + "://" + net.JoinHostPort(trimBrackets(host), port), nil
+}
+
+// trimBrackets removes IPv6 brackets, which net.JoinHostPort adds back.
+func trimBrackets(host string) string {
+	if len(host) > 1 && host[0] == '[' && host[len(host)-1] == ']' {
+		return host[1 : len(host)-1]
+	}
+	return host
+}
@@ -0,0 +1,45 @@
+package libp2p
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/transport"
+	ma "github.com/multiformats/go-multiaddr"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/webrtcjs"
+	"pkg.gfire.dev/supernet/web/wasmlib/wsjs"
+)
+
+// NewWebSocketTransport creates a libp2p transport dialing /ws and /wss multiaddrs through the
+// browser's WebSocket API. Pass it to the host with libp2p.Transport in place of the native WebSocket transport.
+func NewWebSocketTransport(upgrader transport.Upgrader, rcmgr network.ResourceManager) *Transport {
+	dial := func(ctx context.Context, raddr ma.Multiaddr) (MessageConn, error) {
+		url, err := WebSocketURL(raddr)
+		if err != nil {
+			return nil, err
+		}
+		return wsjs.DialContext(ctx, url)
+	}
+	return NewTransport(upgrader, rcmgr, []int{ma.P_WS, ma.P_WSS}, IsWebSocketAddr, dial)
+}
+
+// DataChannelDialFunc opens a WebRTC data channel to the peer at raddr, typically by exchanging an offer
+// and answer through an application-specific signaling path such as a relay or the supernet DHT.
+type DataChannelDialFunc func(ctx context.Context, raddr ma.Multiaddr) (*webrtcjs.DataChannel, error)
+
+// NewDataChannelTransport creates a libp2p transport carrying connections over WebRTC data channels.
+// Signaling is supplied by dial; match selects the multiaddrs it is responsible for.
+func NewDataChannelTransport(upgrader transport.Upgrader, rcmgr network.ResourceManager, protocols []int, match MatchFunc, dial DataChannelDialFunc) *Transport {
+	return NewTransport(upgrader, rcmgr, protocols, match, func(ctx context.Context, raddr ma.Multiaddr) (MessageConn, error) {
+		dc, err := dial(ctx, raddr)
+		if err != nil {
+			return nil, err
+		}
+		if err := dc.WaitOpenContext(ctx); err != nil {
+			dc.Close()
+			return nil, err
+		}
+		return dc, nil
+	})
+}
@@ -0,0 +1,146 @@
+// Package logging routes the log records of supernet packages to a single slog.Handler, with a minimum
+// level per package.
+//
+// Packages log through For, or through the Logger of their Config when one is set there. By default
+// records of level Warn and above go to the handler of slog.Default(); debugging a package in production
+// takes only a level change:
+//
+//	logging.SetHandler(consolejs.NewHandler(nil))
+//	logging.SetLevel("wsjs", slog.LevelDebug)
+//
+// or, from a flag or URL parameter, logging.ParseLevels("warn,wsjs=debug,relay=info").
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidLevels is returned by ParseLevels for malformed level specifications
+var ErrInvalidLevels = errors.New("logging: invalid levels")
+
+// defaultLevel is the minimum level of packages without a level of their own
+const defaultLevel = slog.LevelWarn
+
+var (
+	// mu protects the variables below
+	mu sync.RWMutex
+	// handler receives all records, nil for the handler of slog.Default()
+	handler slog.Handler
+	// levels holds the minimum level per package, "" being the default
+	levels = map[string]slog.Level{"": defaultLevel}
+)
+
+// SetHandler sets the handler receiving the records of all packages, including loggers obtained
+// earlier. A nil handler restores the handler of slog.Default().
+func SetHandler(h slog.Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	handler = h
+}
+
+// SetLevel sets the minimum level of a package, named by the last element of its import path such as
+// "wsjs" or "relay". The empty name sets the level of all packages without a level of their own.
+func SetLevel(pkg string, level slog.Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	levels[pkg] = level
+}
+
+// ParseLevels sets levels from a comma-separated list of "pkg=level" entries; an entry without a package
+// sets the default level. Levels are debug, info, warn or error, optionally with an offset such as
+// "debug-4".
+func ParseLevels(spec string) error {
+	parsed := make(map[string]slog.Level)
+	for entry := range strings.SplitSeq(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pkg, lvl, ok := strings.Cut(entry, "=")
+		if !ok {
+			pkg, lvl = "", entry
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(lvl))); err != nil {
+			return fmt.Errorf("%w: %q", ErrInvalidLevels, entry)
+		}
+		parsed[strings.TrimSpace(pkg)] = level
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for pkg, level := range parsed {
+		levels[pkg] = level
+	}
+	return nil
+}
+
+// For returns the logger of a package. Its records carry the package name as the "pkg" attribute.
+func For(pkg string) *slog.Logger {
+	return slog.New(&pkgHandler{pkg: pkg}).With("pkg", pkg)
+}
+
+// level returns the minimum level of pkg.
+func level(pkg string) slog.Level {
+	mu.RLock()
+	defer mu.RUnlock()
+	if l, ok := levels[pkg]; ok {
+		return l
+	}
+	return levels[""]
+}
+
+// current returns the handler receiving records.
+func current() slog.Handler {
+	mu.RLock()
+	h := handler
+	mu.RUnlock()
+	if h == nil {
+		return slog.Default().Handler()
+	}
+	return h
+}
+
+// pkgHandler filters records by the level of its package and passes them on to the current handler,
+// so that SetHandler and SetLevel apply to loggers created before.
+type pkgHandler struct {
+	pkg string
+	// derive applies the WithAttrs and WithGroup calls made on the handler, in order
+	derive []func(slog.Handler) slog.Handler
+}
+
+func (h *pkgHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return l >= level(h.pkg) && current().Enabled(ctx, l)
+}
+
+func (h *pkgHandler) Handle(ctx context.Context, r slog.Record) error {
+	target := current()
+	for _, derive := range h.derive {
+		target = derive(target)
+	}
+	return target.Handle(ctx, r)
+}
+
+func (h *pkgHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.with(func(t slog.Handler) slog.Handler { return t.WithAttrs(attrs) })
+}
+
+func (h *pkgHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(func(t slog.Handler) slog.Handler { return t.WithGroup(name) })
+}
+
+// with returns a copy of h with another derivation.
+func (h *pkgHandler) with(derive func(slog.Handler) slog.Handler) *pkgHandler {
+	return &pkgHandler{pkg: h.pkg, derive: append(h.derive[:len(h.derive):len(h.derive)], derive)}
+}
@@ -0,0 +1,201 @@
+// Package memtransport provides message connections that live entirely in memory, with configurable
+// latency and message size limits, so protocol layers such as mux, noise, secure or outbox can be tested
+// with go test on any platform, without a browser or network.
+//
+// Pipe returns a connected pair. Net simulates a network of listeners that can be dialed by address,
+// and Net.Transport implements p2p.Transport for direct peer connections. All connections follow the
+// NextMessage/Send/Close contract of wsjs.Conn and mux.Stream.
+package memtransport
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+
+	"pkg.gfire.dev/supernet/msgconn"
+)
+
+var (
+	// ErrClosed is returned when using a connection that has been closed locally
+	ErrClosed = errors.New("memtransport: connection closed")
+	// ErrMessageTooLarge is returned when sending a message above Config.MaxMessage
+	ErrMessageTooLarge = errors.New("memtransport: message too large")
+)
+
+// defaultBuffer is the default number of messages in flight per direction
+const defaultBuffer = 64
+
+// Network is the network name of memtransport addresses.
+const Network = "mem"
+
+// Config configures the connections of a pipe.
+type Config struct {
+	// Latency delays the delivery of every message.
+	Latency time.Duration
+	// Jitter adds a random delay of up to this duration to the latency. Messages are still delivered in
+	// order.
+	Jitter time.Duration
+	// MaxMessage makes sending larger messages fail with ErrMessageTooLarge. Zero is unlimited.
+	MaxMessage int
+	// Buffer is the number of messages in flight per direction before Send blocks (default 64).
+	Buffer int
+}
+
+// withDefaults fills in zero fields.
+func (cfg Config) withDefaults() Config {
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = defaultBuffer
+	}
+	return cfg
+}
+
+// message is a message in flight.
+type message struct {
+	data []byte
+	// at is the time the message is delivered
+	at time.Time
+}
+
+// direction carries the messages sent by one end of a pipe to the other.
+type direction struct {
+	msgs chan message
+	// eof is closed when the sender closed its end
+	eof chan struct{}
+
+	// mu protects last
+	mu sync.Mutex
+	// last is the delivery time of the latest message, which later messages may not precede
+	last time.Time
+}
+
+// Conn is one end of an in-memory connection.
+type Conn struct {
+	cfg           Config
+	in, out       *direction
+	local, remote net.Addr
+
+	// closed is closed when this end is closed
+	closed    chan struct{}
+	closeOnce sync.Once
+	// peer is the other end
+	peer *Conn
+}
+
+// Pipe returns the two ends of an in-memory connection.
+func Pipe(cfg Config) (*Conn, *Conn) {
+	return pipe(cfg, msgconn.Addr{Net: Network, Addr: "pipe"}, msgconn.Addr{Net: Network, Addr: "pipe"})
+}
+
+// pipe connects two ends with the given addresses.
+func pipe(cfg Config, a, b net.Addr) (*Conn, *Conn) {
+	cfg = cfg.withDefaults()
+	ab := &direction{msgs: make(chan message, cfg.Buffer), eof: make(chan struct{})}
+	ba := &direction{msgs: make(chan message, cfg.Buffer), eof: make(chan struct{})}
+	ca := &Conn{cfg: cfg, in: ba, out: ab, local: a, remote: b, closed: make(chan struct{})}
+	cb := &Conn{cfg: cfg, in: ab, out: ba, local: b, remote: a, closed: make(chan struct{})}
+	ca.peer, cb.peer = cb, ca
+	return ca, cb
+}
+
+// NextMessage blocks until the next message is delivered. Returns io.EOF once the other end was closed
+// and all messages it sent were delivered.
+func (c *Conn) NextMessage() ([]byte, error) {
+	var m message
+	select {
+	case m = <-c.in.msgs:
+	default:
+		select {
+		case m = <-c.in.msgs:
+		case <-c.in.eof:
+			// Messages sent before closing are still delivered
+			select {
+			case m = <-c.in.msgs:
+			default:
+				return nil, io.EOF
+			}
+		case <-c.closed:
+			return nil, ErrClosed
+		}
+	}
+
+	if wait := time.Until(m.at); wait > 0 {
+		t := time.NewTimer(wait)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-c.closed:
+			return nil, ErrClosed
+		}
+	}
+	return m.data, nil
+}
+
+// Send sends a copy of data, blocking while Config.Buffer messages are in flight. Messages sent to an
+// end that was closed are discarded.
+func (c *Conn) Send(data []byte) error {
+	if c.cfg.MaxMessage > 0 && len(data) > c.cfg.MaxMessage {
+		return ErrMessageTooLarge
+	}
+	select {
+	case <-c.closed:
+		return ErrClosed
+	default:
+	}
+
+	m := message{data: append([]byte(nil), data...), at: c.out.deliveryTime(c.cfg)}
+	select {
+	case c.out.msgs <- m:
+		return nil
+	case <-c.closed:
+		return ErrClosed
+	case <-c.peer.closed:
+		return nil
+	}
+}
+
+// Close closes this end. The other end receives the messages already sent, then io.EOF.
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		close(c.out.eof)
+	})
+	return nil
+}
+
+// LocalAddr returns the address of this end.
+func (c *Conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// RemoteAddr returns the address of the other end.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// NetConn returns the connection as a net.Conn carrying a byte stream.
+func (c *Conn) NetConn() net.Conn {
+	return msgconn.NetConn(c, msgconn.Options{LocalAddr: c.local, RemoteAddr: c.remote, MaxMessage: c.cfg.MaxMessage})
+}
+
+// deliveryTime returns the delivery time of a message sent now, keeping messages in order.
+func (d *direction) deliveryTime(cfg Config) time.Time {
+	delay := cfg.Latency
+	if cfg.Jitter > 0 {
+		delay += rand.N(cfg.Jitter)
+	}
+	if delay <= 0 {
+		return time.Time{}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	at := time.Now().Add(delay)
+	if at.Before(d.last) {
+		at = d.last
+	}
+	d.last = at
+	return at
+}
@@ -0,0 +1,154 @@
+package memtransport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestPipe(t *testing.T) {
+	a, b := Pipe(Config{})
+	defer b.Close()
+	if a.LocalAddr().Network() != Network || b.RemoteAddr().Network() != Network {
+		t.Fatalf("addresses %v and %v, want network %s", a.LocalAddr(), b.RemoteAddr(), Network)
+	}
+
+	// Both directions carry messages in order, and the sent buffer may be reused
+	buf := []byte("ping")
+	for i := range 10 {
+		buf = fmt.Appendf(buf[:0], "ping %d", i)
+		if err := a.Send(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range 10 {
+		data, err := b.NextMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("ping %d", i); string(data) != want {
+			t.Fatalf("received %q, want %q", data, want)
+		}
+		if err := b.Send([]byte("pong")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for range 10 {
+		if data, err := a.NextMessage(); err != nil || string(data) != "pong" {
+			t.Fatalf("NextMessage: %q, %v", data, err)
+		}
+	}
+
+	// Messages sent before closing are still delivered, then io.EOF
+	a.Send([]byte("last"))
+	a.Close()
+	if data, err := b.NextMessage(); err != nil || string(data) != "last" {
+		t.Fatalf("NextMessage after peer close: %q, %v", data, err)
+	}
+	if _, err := b.NextMessage(); err != io.EOF {
+		t.Fatalf("NextMessage: %v, want io.EOF", err)
+	}
+	if err := a.Send(nil); err != ErrClosed {
+		t.Fatalf("Send after close: %v, want ErrClosed", err)
+	}
+	if _, err := a.NextMessage(); err != ErrClosed {
+		t.Fatalf("NextMessage after close: %v, want ErrClosed", err)
+	}
+	if err := b.Send([]byte("discarded")); err != nil {
+		t.Fatalf("Send to closed peer: %v", err)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	const latency = 50 * time.Millisecond
+	a, b := Pipe(Config{Latency: latency, Jitter: 20 * time.Millisecond})
+	defer a.Close()
+	defer b.Close()
+
+	start := time.Now()
+	for i := range 20 {
+		if err := a.Send([]byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range 20 {
+		data, err := b.NextMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 && time.Since(start) < latency {
+			t.Fatalf("delivered after %v, want at least %v", time.Since(start), latency)
+		}
+		if data[0] != byte(i) {
+			t.Fatalf("received message %d, want %d", data[0], i)
+		}
+	}
+}
+
+func TestMessageSize(t *testing.T) {
+	a, b := Pipe(Config{MaxMessage: 16})
+	defer a.Close()
+	defer b.Close()
+	if err := a.Send(make([]byte, 17)); err != ErrMessageTooLarge {
+		t.Fatalf("Send: %v, want ErrMessageTooLarge", err)
+	}
+	if err := a.Send(make([]byte, 16)); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := b.NextMessage(); err != nil || len(data) != 16 {
+		t.Fatalf("NextMessage: %d bytes, %v", len(data), err)
+	}
+}
+
+func TestNet(t *testing.T) {
+	n := NewNet(Config{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := n.Dial(ctx, "server"); err == nil {
+		t.Fatal("dialed an address without a listener")
+	}
+	l, err := n.Listen("server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.Listen("server"); err == nil {
+		t.Fatal("listened twice on the same address")
+	}
+
+	c, err := n.Dial(ctx, "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	s, err := l.Accept(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if c.RemoteAddr().String() != "server" || s.RemoteAddr().String() != c.LocalAddr().String() {
+		t.Fatalf("addresses %v -> %v and %v -> %v", c.LocalAddr(), c.RemoteAddr(), s.LocalAddr(), s.RemoteAddr())
+	}
+
+	// NetConn carries a byte stream over the message connection
+	go func() {
+		nc := s.NetConn()
+		defer nc.Close()
+		io.Copy(nc, nc)
+	}()
+	nc := c.NetConn()
+	if _, err := nc.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	nc.SetReadDeadline(time.Now().Add(10 * time.Second))
+	if _, err := io.ReadFull(nc, buf); err != nil || string(buf) != "hello" {
+		t.Fatalf("read %q, %v", buf, err)
+	}
+
+	l.Close()
+	if _, err := l.Accept(ctx); err != ErrClosed {
+		t.Fatalf("Accept after close: %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,186 @@
+package memtransport
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"pkg.gfire.dev/supernet/msgconn"
+	"pkg.gfire.dev/supernet/p2p"
+)
+
+var (
+	// ErrAddrInUse is returned when listening on an address that already has a listener
+	ErrAddrInUse = errors.New("memtransport: address in use")
+	// ErrRefused is returned when dialing an address without a listener
+	ErrRefused = errors.New("memtransport: connection refused")
+	// ErrUnknownOffer is returned when accepting an offer that was not made on the same network
+	ErrUnknownOffer = errors.New("memtransport: unknown offer")
+)
+
+// defaultBacklog is the number of dialed connections waiting for Listener.Accept
+const defaultBacklog = 16
+
+// Net is an in-memory network. Connections established over it use its Config.
+type Net struct {
+	cfg Config
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// listeners holds the listener of every address
+	listeners map[string]*Listener
+	// offers holds the ends of connections offered by Transport.Connect until they are accepted
+	offers map[uint64]*Conn
+	// nextID numbers dialers and offers
+	nextID uint64
+}
+
+// NewNet creates an in-memory network.
+func NewNet(cfg Config) *Net {
+	return &Net{
+		cfg:       cfg,
+		listeners: make(map[string]*Listener),
+		offers:    make(map[uint64]*Conn),
+	}
+}
+
+// Listen creates a listener for addr, which may be any string.
+func (n *Net) Listen(addr string) (*Listener, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.listeners[addr]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrAddrInUse, addr)
+	}
+	l := &Listener{
+		n:      n,
+		addr:   addr,
+		conns:  make(chan *Conn, defaultBacklog),
+		closed: make(chan struct{}),
+	}
+	n.listeners[addr] = l
+	return l, nil
+}
+
+// Dial connects to the listener of addr.
+func (n *Net) Dial(ctx context.Context, addr string) (*Conn, error) {
+	n.mu.Lock()
+	l, ok := n.listeners[addr]
+	n.nextID++
+	local := msgconn.Addr{Net: Network, Addr: "dialer-" + strconv.FormatUint(n.nextID, 10)}
+	n.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRefused, addr)
+	}
+
+	c, s := pipe(n.cfg, local, msgconn.Addr{Net: Network, Addr: addr})
+	select {
+	case l.conns <- s:
+		return c, nil
+	case <-l.closed:
+		return nil, fmt.Errorf("%w: %s", ErrRefused, addr)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Listener accepts connections dialed to its address.
+type Listener struct {
+	n     *Net
+	addr  string
+	conns chan *Conn
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Accept waits for the next connection.
+func (l *Listener) Accept(ctx context.Context) (*Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Addr returns the address the listener accepts connections on.
+func (l *Listener) Addr() string {
+	return l.addr
+}
+
+// Close stops accepting connections and frees the address. Connections waiting for Accept are closed.
+func (l *Listener) Close() error {
+	l.closeOnce.Do(func() {
+		l.n.mu.Lock()
+		delete(l.n.listeners, l.addr)
+		l.n.mu.Unlock()
+		close(l.closed)
+		for {
+			select {
+			case c := <-l.conns:
+				c.Close()
+			default:
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// Transport returns a p2p.Transport that establishes direct connections over the network, with offers
+// and answers exchanged over the overlay like WebRTC signaling.
+func (n *Net) Transport() p2p.Transport {
+	return transport{n}
+}
+
+// transport implements p2p.Transport on a Net.
+type transport struct {
+	n *Net
+}
+
+// Connect offers a connection and returns it once the answer arrives.
+func (t transport) Connect(ctx context.Context, exchange func(ctx context.Context, offer []byte) ([]byte, error)) (p2p.Conn, error) {
+	t.n.mu.Lock()
+	t.n.nextID++
+	id := t.n.nextID
+	name := "peer-" + strconv.FormatUint(id, 10)
+	c, s := pipe(t.n.cfg, msgconn.Addr{Net: Network, Addr: name + "a"}, msgconn.Addr{Net: Network, Addr: name + "b"})
+	t.n.offers[id] = s
+	t.n.mu.Unlock()
+
+	if _, err := exchange(ctx, binary.BigEndian.AppendUint64(nil, id)); err != nil {
+		t.n.mu.Lock()
+		delete(t.n.offers, id)
+		t.n.mu.Unlock()
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Accept takes the connection offered and answers it.
+func (t transport) Accept(ctx context.Context, offer []byte, reply func(answer []byte) error) (p2p.Conn, error) {
+	if len(offer) != 8 {
+		return nil, ErrUnknownOffer
+	}
+	id := binary.BigEndian.Uint64(offer)
+	t.n.mu.Lock()
+	s, ok := t.n.offers[id]
+	delete(t.n.offers, id)
+	t.n.mu.Unlock()
+	if !ok {
+		return nil, ErrUnknownOffer
+	}
+
+	if err := reply(offer); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,101 @@
+package meter
+
+import (
+	"net"
+	"sync"
+)
+
+// MessageConn is a message-oriented connection, e.g. a mux.Stream or wsjs.Conn.
+type MessageConn interface {
+	// NextMessage blocks until the next message is received or the connection is closed.
+	NextMessage() ([]byte, error)
+	// Send sends a single message.
+	Send(data []byte) error
+	// Close closes the connection.
+	Close() error
+}
+
+// Conn is a metered message connection.
+type Conn struct {
+	conn   MessageConn
+	stream *Stream
+	once   sync.Once
+}
+
+// Conn meters conn as a stream of peer named name. Closing the returned connection closes conn and the
+// stream.
+func (m *Meter) Conn(peer, name string, conn MessageConn) *Conn {
+	return &Conn{conn: conn, stream: m.Stream(peer, name)}
+}
+
+// NextMessage returns the next message, closing the connection with ErrQuotaExceeded if it exceeds the
+// quota.
+func (c *Conn) NextMessage() ([]byte, error) {
+	msg, err := c.conn.NextMessage()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.stream.Received(len(msg)); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return msg, nil
+}
+
+// Send sends a message, or returns ErrQuotaExceeded if it exceeds the quota.
+func (c *Conn) Send(data []byte) error {
+	if err := c.stream.Sent(len(data)); err != nil {
+		return err
+	}
+	return c.conn.Send(data)
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	c.once.Do(c.stream.Close)
+	return c.conn.Close()
+}
+
+// Stream returns the accounting stream of the connection.
+func (c *Conn) Stream() *Stream {
+	return c.stream
+}
+
+// netConn is a metered net.Conn.
+type netConn struct {
+	net.Conn
+	stream *Stream
+	once   sync.Once
+}
+
+// NetConn meters conn as a stream of peer named name. Closing the returned connection closes conn and the
+// stream.
+func (m *Meter) NetConn(peer, name string, conn net.Conn) net.Conn {
+	return &netConn{Conn: conn, stream: m.Stream(peer, name)}
+}
+
+// Read reads from the connection, closing it with ErrQuotaExceeded if the data exceeds the quota.
+func (c *netConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		if qerr := c.stream.Received(n); qerr != nil {
+			c.Close()
+			return 0, qerr
+		}
+	}
+	return n, err
+}
+
+// Write writes to the connection, or returns ErrQuotaExceeded if p exceeds the quota.
+func (c *netConn) Write(p []byte) (int, error) {
+	if err := c.stream.Sent(len(p)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(p)
+}
+
+// Close closes the connection.
+func (c *netConn) Close() error {
+	c.once.Do(c.stream.Close)
+	return c.Conn.Close()
+}
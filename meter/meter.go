@@ -0,0 +1,286 @@
+// Package meter accounts the bytes exchanged with each peer, enforces per-peer quotas and reports usage,
+// e.g. for operators of shared relays who bill or cap their users.
+//
+// Traffic is accounted to a peer, identified by any string such as an identity.ID or a relay identity,
+// and within the peer to named streams. Connections are metered by wrapping them with Meter.Conn or
+// Meter.NetConn, or by reporting transfers to a Stream directly. Sends that would exceed the peer's
+// quota fail with ErrQuotaExceeded before anything is sent; received data that exceeds it is dropped and
+// the connection closed.
+package meter
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned when a transfer exceeds the quota of its peer
+var ErrQuotaExceeded = errors.New("meter: quota exceeded")
+
+// Quota bounds the traffic of a peer per accounting period. Zero fields are unlimited.
+type Quota struct {
+	In     uint64        // Bytes received from the peer
+	Out    uint64        // Bytes sent to the peer
+	Total  uint64        // Bytes in both directions
+	Period time.Duration // Length of an accounting period, starting with the first transfer; 0 for no reset
+}
+
+// allows reports whether usage stays within q.
+func (q Quota) allows(u Usage) bool {
+	return (q.In == 0 || u.In <= q.In) && (q.Out == 0 || u.Out <= q.Out) && (q.Total == 0 || u.Total() <= q.Total)
+}
+
+// Config configures a Meter.
+type Config struct {
+	// Quota returns the quota of a peer. It is called when a peer is first seen and at the start of each of
+	// its accounting periods, so changes apply from the next period. When nil, Default applies to everyone.
+	Quota func(peer string) Quota
+	// Default is the quota used when Quota is nil.
+	Default Quota
+}
+
+// Usage is an amount of traffic.
+type Usage struct {
+	In  uint64 // Bytes received
+	Out uint64 // Bytes sent
+}
+
+// Total returns the bytes transferred in both directions.
+func (u Usage) Total() uint64 {
+	return u.In + u.Out
+}
+
+// PeerUsage is a snapshot of the traffic of a peer.
+type PeerUsage struct {
+	Peer        string
+	Quota       Quota         // Quota of the current period
+	PeriodStart time.Time     // Start of the current period
+	Period      Usage         // Traffic in the current period
+	Lifetime    Usage         // Traffic since the peer was first seen
+	Streams     []StreamUsage // Open streams, oldest first
+}
+
+// StreamUsage is a snapshot of the traffic of an open stream.
+type StreamUsage struct {
+	Name   string
+	Opened time.Time
+	Usage
+}
+
+// Meter accounts traffic per peer. Peers are remembered until Forget, or for quotas with a period, until
+// they have no open streams at the end of a period.
+type Meter struct {
+	cfg Config
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// peers holds the accounting state of each peer
+	peers map[string]*peer
+}
+
+// peer is the accounting state of one peer, protected by Meter.mu.
+type peer struct {
+	quota    Quota
+	start    time.Time
+	period   Usage
+	lifetime Usage
+	// streams are the open streams in opening order
+	streams []*Stream
+}
+
+// New creates a meter.
+func New(cfg Config) *Meter {
+	return &Meter{
+		cfg:   cfg,
+		peers: make(map[string]*peer),
+	}
+}
+
+// Stream opens a stream accounted to peer. Name describes the stream in usage snapshots, e.g. the
+// service or target address; it need not be unique. The stream must be closed when done.
+func (m *Meter) Stream(peer, name string) *Stream {
+	st := &Stream{m: m, peer: peer, name: name, opened: time.Now()}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.lookup(peer, st.opened)
+	p.streams = append(p.streams, st)
+	return st
+}
+
+// Usage returns a snapshot of the traffic of peer, or false if it is unknown.
+func (m *Meter) Usage(peer string) (PeerUsage, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.peers[peer]
+	if !ok {
+		return PeerUsage{}, false
+	}
+	m.rollover(peer, p, time.Now())
+	return p.snapshot(peer), true
+}
+
+// Snapshot returns the traffic of all known peers, ordered by peer.
+func (m *Meter) Snapshot() []PeerUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	usage := make([]PeerUsage, 0, len(m.peers))
+	for name, p := range m.peers {
+		if m.expire(name, p, now) {
+			continue
+		}
+		m.rollover(name, p, now)
+		usage = append(usage, p.snapshot(name))
+	}
+	slices.SortFunc(usage, func(a, b PeerUsage) int {
+		return strings.Compare(a.Peer, b.Peer)
+	})
+	return usage
+}
+
+// Forget drops the accounting state of peer, starting a fresh period. Its open streams keep being
+// accounted to it.
+func (m *Meter) Forget(peer string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.peers[peer]
+	if !ok {
+		return
+	}
+	delete(m.peers, peer)
+	if len(p.streams) > 0 {
+		fresh := m.lookup(peer, time.Now())
+		fresh.streams = p.streams
+	}
+}
+
+// lookup returns the state of peer, creating it if needed. Must be called with mu held.
+func (m *Meter) lookup(name string, now time.Time) *peer {
+	p, ok := m.peers[name]
+	if !ok {
+		p = &peer{quota: m.quota(name), start: now}
+		m.peers[name] = p
+	}
+	m.rollover(name, p, now)
+	return p
+}
+
+// rollover starts a new period for p if the current one ended. Must be called with mu held.
+func (m *Meter) rollover(name string, p *peer, now time.Time) {
+	if p.quota.Period <= 0 || now.Sub(p.start) < p.quota.Period {
+		return
+	}
+	p.quota = m.quota(name)
+	p.start = now
+	p.period = Usage{}
+}
+
+// expire forgets p if its period ended without open streams. Must be called with mu held.
+func (m *Meter) expire(name string, p *peer, now time.Time) bool {
+	if p.quota.Period <= 0 || now.Sub(p.start) < p.quota.Period || len(p.streams) > 0 {
+		return false
+	}
+	delete(m.peers, name)
+	return true
+}
+
+// quota returns the configured quota of peer.
+func (m *Meter) quota(peer string) Quota {
+	if m.cfg.Quota != nil {
+		return m.cfg.Quota(peer)
+	}
+	return m.cfg.Default
+}
+
+// account adds u to the traffic of st, failing without accounting if the quota does not allow it.
+func (m *Meter) account(st *Stream, u Usage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p := m.lookup(st.peer, time.Now())
+	period := Usage{In: p.period.In + u.In, Out: p.period.Out + u.Out}
+	if !p.quota.allows(period) {
+		return ErrQuotaExceeded
+	}
+	p.period = period
+	p.lifetime.In += u.In
+	p.lifetime.Out += u.Out
+	st.usage.In += u.In
+	st.usage.Out += u.Out
+	return nil
+}
+
+// snapshot returns the usage of p. Must be called with Meter.mu held.
+func (p *peer) snapshot(name string) PeerUsage {
+	streams := make([]StreamUsage, len(p.streams))
+	for i, st := range p.streams {
+		streams[i] = StreamUsage{Name: st.name, Opened: st.opened, Usage: st.usage}
+	}
+	return PeerUsage{
+		Peer:        name,
+		Quota:       p.quota,
+		PeriodStart: p.start,
+		Period:      p.period,
+		Lifetime:    p.lifetime,
+		Streams:     streams,
+	}
+}
+
+// Stream accounts the traffic of one stream to its peer.
+type Stream struct {
+	m      *Meter
+	peer   string
+	name   string
+	opened time.Time
+	// usage is protected by Meter.mu
+	usage Usage
+	// closed is protected by Meter.mu
+	closed bool
+}
+
+// Peer returns the peer the stream is accounted to.
+func (st *Stream) Peer() string {
+	return st.peer
+}
+
+// Sent accounts n bytes about to be sent. Returns ErrQuotaExceeded, without accounting them, if they
+// exceed the quota.
+func (st *Stream) Sent(n int) error {
+	return st.m.account(st, Usage{Out: uint64(n)})
+}
+
+// Received accounts n received bytes. Returns ErrQuotaExceeded, without accounting them, if they exceed
+// the quota; the data should then be dropped.
+func (st *Stream) Received(n int) error {
+	return st.m.account(st, Usage{In: uint64(n)})
+}
+
+// Usage returns the traffic of the stream so far.
+func (st *Stream) Usage() Usage {
+	st.m.mu.Lock()
+	defer st.m.mu.Unlock()
+	return st.usage
+}
+
+// Close removes the stream from usage snapshots; its traffic stays accounted to the peer.
+func (st *Stream) Close() {
+	m := st.m
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if st.closed {
+		return
+	}
+	st.closed = true
+	if p, ok := m.peers[st.peer]; ok {
+		p.streams = slices.DeleteFunc(p.streams, func(other *Stream) bool {
+			return other == st
+		})
+	}
+}
@@ -0,0 +1,297 @@
+//go:build !js
+
+// Package metrics exports Prometheus metrics for the native components: relay servers, WebSocket
+// listeners and overlay nodes. Metrics observes them through their Observer hooks:
+//
+//	m, _ := metrics.New(metrics.Config{})
+//	srv := relay.NewServer(relay.Config{Observer: m.Relay(), ...})
+//	http.Handle("/metrics", metrics.Handler(nil))
+package metrics
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"pkg.gfire.dev/supernet/p2p"
+	"pkg.gfire.dev/supernet/relay"
+	"pkg.gfire.dev/supernet/wslisten"
+)
+
+// namespace prefixes all metric names
+const namespace = "supernet"
+
+// Config configures Metrics.
+type Config struct {
+	// Registerer registers the metrics (default prometheus.DefaultRegisterer).
+	Registerer prometheus.Registerer
+	// PerTarget labels relay tunnel and traffic metrics with the target address. Targets are chosen by
+	// clients, so this should only be enabled when the rules allow a small set of targets.
+	PerTarget bool
+}
+
+// Metrics holds the collectors of all components.
+type Metrics struct {
+	perTarget bool
+
+	relayConns       prometheus.Gauge
+	relayConnsTotal  prometheus.Counter
+	relayRejected    *prometheus.CounterVec
+	relayTunnels     *prometheus.GaugeVec
+	relayOpens       *prometheus.CounterVec
+	relayFailures    *prometheus.CounterVec
+	relayBytes       *prometheus.CounterVec
+	listenerConns    prometheus.Gauge
+	listenerAccepted prometheus.Counter
+	listenerRefused  prometheus.Counter
+	nodePeers        prometheus.Gauge
+	nodeHandshakes   prometheus.Counter
+	nodeBytes        *prometheus.CounterVec
+	nodeRelayedBytes *prometheus.CounterVec
+	nodeHolePunches  *prometheus.CounterVec
+}
+
+// New creates and registers the metrics.
+func New(cfg Config) (*Metrics, error) {
+	if cfg.Registerer == nil {
+		cfg.Registerer = prometheus.DefaultRegisterer
+	}
+
+	m := &Metrics{
+		perTarget: cfg.PerTarget,
+		relayConns: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "relay", Name: "connections",
+			Help: "Client connections currently served by the relay.",
+		}),
+		relayConnsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "relay", Name: "connections_total",
+			Help: "Client connections served by the relay.",
+		}),
+		relayRejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "relay", Name: "rejected_connections_total",
+			Help: "Client connections refused by the relay, by reason.",
+		}, []string{"reason"}),
+		relayTunnels: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "relay", Name: "tunnels",
+			Help: "Tunnels currently open.",
+		}, []string{"network", "target"}),
+		relayOpens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "relay", Name: "tunnels_total",
+			Help: "Tunnels opened.",
+		}, []string{"network", "target"}),
+		relayFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "relay", Name: "tunnel_failures_total",
+			Help: "Tunnels refused or failing to reach their target, by reason.",
+		}, []string{"network", "target", "reason"}),
+		relayBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "relay", Name: "bytes_total",
+			Help: "Bytes relayed; in is from clients to targets, out from targets to clients.",
+		}, []string{"network", "target", "direction"}),
+		listenerConns: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "listener", Name: "connections",
+			Help: "WebSocket listener connections currently open.",
+		}),
+		listenerAccepted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "listener", Name: "connections_total",
+			Help: "Connections accepted by WebSocket listeners.",
+		}),
+		listenerRefused: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "listener", Name: "refused_connections_total",
+			Help: "Connections refused by WebSocket listeners or failing their handshake.",
+		}),
+		nodePeers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "p2p", Name: "peers",
+			Help: "Peers directly connected to the overlay node.",
+		}),
+		nodeHandshakes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "p2p", Name: "handshake_failures_total",
+			Help: "Peer connections failing to authenticate.",
+		}),
+		nodeBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "p2p", Name: "bytes_total",
+			Help: "Bytes of overlay envelopes exchanged with directly connected peers.",
+		}, []string{"direction"}),
+		nodeRelayedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "p2p", Name: "relayed_bytes_total",
+			Help: "Bytes of overlay envelopes travelling through a relay, including those forwarded for other peers.",
+		}, []string{"direction"}),
+		nodeHolePunches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "p2p", Name: "hole_punches_total",
+			Help: "Attempts to replace relayed routes with direct connections, by result.",
+		}, []string{"result"}),
+	}
+
+	for _, c := range []prometheus.Collector{
+		m.relayConns, m.relayConnsTotal, m.relayRejected, m.relayTunnels, m.relayOpens, m.relayFailures,
+		m.relayBytes, m.listenerConns, m.listenerAccepted, m.listenerRefused, m.nodePeers, m.nodeHandshakes,
+		m.nodeBytes, m.nodeRelayedBytes, m.nodeHolePunches,
+	} {
+		if err := cfg.Registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Handler serves the metrics gathered by g in the Prometheus exposition format, or those of the default
+// registry if g is nil.
+func Handler(g prometheus.Gatherer) http.Handler {
+	if g == nil {
+		return promhttp.Handler()
+	}
+	return promhttp.HandlerFor(g, promhttp.HandlerOpts{})
+}
+
+// Relay returns the observer to set as relay.Config.Observer.
+func (m *Metrics) Relay() relay.Observer {
+	return relayObserver{m}
+}
+
+// Listener returns the observer to set as wslisten.Config.Observer.
+func (m *Metrics) Listener() wslisten.Observer {
+	return listenerObserver{m}
+}
+
+// Node returns the observer to set as p2p.Config.Observer.
+func (m *Metrics) Node() p2p.Observer {
+	return nodeObserver{m}
+}
+
+// relayObserver records relay events.
+type relayObserver struct {
+	m *Metrics
+}
+
+func (o relayObserver) Connected(string) {
+	o.m.relayConns.Inc()
+	o.m.relayConnsTotal.Inc()
+}
+
+func (o relayObserver) Disconnected(string) {
+	o.m.relayConns.Dec()
+}
+
+func (o relayObserver) Rejected(err error) {
+	o.m.relayRejected.WithLabelValues(rejectReason(err)).Inc()
+}
+
+func (o relayObserver) TunnelOpened(network, target string) {
+	target = o.target(target)
+	o.m.relayTunnels.WithLabelValues(network, target).Inc()
+	o.m.relayOpens.WithLabelValues(network, target).Inc()
+}
+
+func (o relayObserver) TunnelClosed(network, target string) {
+	o.m.relayTunnels.WithLabelValues(network, o.target(target)).Dec()
+}
+
+func (o relayObserver) TunnelFailed(network, target string, err error) {
+	o.m.relayFailures.WithLabelValues(network, o.target(target), tunnelReason(err)).Inc()
+}
+
+func (o relayObserver) Transferred(network, target string, in, out int) {
+	target = o.target(target)
+	if in > 0 {
+		o.m.relayBytes.WithLabelValues(network, target, "in").Add(float64(in))
+	}
+	if out > 0 {
+		o.m.relayBytes.WithLabelValues(network, target, "out").Add(float64(out))
+	}
+}
+
+// target returns the target label value, empty unless per-target metrics are enabled.
+func (o relayObserver) target(target string) string {
+	if !o.m.perTarget {
+		return ""
+	}
+	return target
+}
+
+// rejectReason classifies why a relay connection was refused.
+func rejectReason(err error) string {
+	switch {
+	case errors.Is(err, relay.ErrQuotaExceeded):
+		return "quota"
+	case errors.Is(err, relay.ErrMissingTicket), errors.Is(err, relay.ErrInvalidTicket), errors.Is(err, relay.ErrTicketExpired):
+		return "auth"
+	default:
+		return "other"
+	}
+}
+
+// tunnelReason classifies why a tunnel failed.
+func tunnelReason(err error) string {
+	switch {
+	case errors.Is(err, relay.ErrQuotaExceeded):
+		return "quota"
+	case errors.Is(err, relay.ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, relay.ErrTargetDenied):
+		return "denied"
+	default:
+		return "unreachable"
+	}
+}
+
+// listenerObserver records WebSocket listener events.
+type listenerObserver struct {
+	m *Metrics
+}
+
+func (o listenerObserver) Opened() {
+	o.m.listenerConns.Inc()
+	o.m.listenerAccepted.Inc()
+}
+
+func (o listenerObserver) Closed() {
+	o.m.listenerConns.Dec()
+}
+
+func (o listenerObserver) Refused(error) {
+	o.m.listenerRefused.Inc()
+}
+
+// nodeObserver records overlay node events.
+type nodeObserver struct {
+	m *Metrics
+}
+
+func (o nodeObserver) PeerConnected(p2p.ID) {
+	o.m.nodePeers.Inc()
+}
+
+func (o nodeObserver) PeerDisconnected(p2p.ID) {
+	o.m.nodePeers.Dec()
+}
+
+func (o nodeObserver) HandshakeFailed(error) {
+	o.m.nodeHandshakes.Inc()
+}
+
+func (o nodeObserver) Traffic(in, out int) {
+	if in > 0 {
+		o.m.nodeBytes.WithLabelValues("in").Add(float64(in))
+	}
+	if out > 0 {
+		o.m.nodeBytes.WithLabelValues("out").Add(float64(out))
+	}
+}
+
+func (o nodeObserver) Relayed(in, out int) {
+	if in > 0 {
+		o.m.nodeRelayedBytes.WithLabelValues("in").Add(float64(in))
+	}
+	if out > 0 {
+		o.m.nodeRelayedBytes.WithLabelValues("out").Add(float64(out))
+	}
+}
+
+func (o nodeObserver) HolePunch(_ p2p.ID, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	o.m.nodeHolePunches.WithLabelValues(result).Inc()
+}
@@ -0,0 +1,189 @@
+// Package migrate keeps a byte stream alive across changes of the underlying path, so a session
+// established over a relayed WebSocket can move to a direct WebRTC or WebTransport connection once hole
+// punching succeeds, or survive a dropped connection, without the application noticing.
+//
+// Every byte of the stream has an offset. The sender keeps data until the receiver acknowledges having
+// consumed it; attaching a new path starts with a hello exchange in which both sides report the offset
+// they received up to, and everything after it is retransmitted on the new path. The old path is closed
+// only once the new one is attached (make before break), and stale or duplicated data is discarded by
+// offset. The initiating side moves the session with Session.Migrate and redials through Config.Redial
+// after a failure; the responding Server attaches incoming paths to sessions by their ID.
+//
+// The session ID is the only credential for attaching a path, so paths should run over an authenticated
+// channel such as the secure package, or at least a transport that keeps it confidential.
+package migrate
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrClosed is returned when using a session that has been closed
+	ErrClosed = errors.New("migrate: session closed")
+	// ErrResumeTimeout is returned when no new path was attached in time after the previous one failed
+	ErrResumeTimeout = errors.New("migrate: no path to resume the session")
+	// ErrUnknownSession is returned when the responder does not know the session a path is attached to
+	ErrUnknownSession = errors.New("migrate: unknown session")
+	// ErrNotInitiator is returned when migrating a session from the responding side
+	ErrNotInitiator = errors.New("migrate: only the initiator can migrate a session")
+	// ErrProtocol is returned when the remote side violates the protocol
+	ErrProtocol = errors.New("migrate: protocol violation")
+)
+
+const (
+	// defaultWindow is the default amount of unacknowledged data a sender keeps
+	defaultWindow = 1 << 20
+	// defaultMaxMessage is the default size limit of data frames, safe for WebRTC data channels
+	defaultMaxMessage = 16 << 10
+	// defaultResumeTimeout is the default time a session waits for a new path after losing its path
+	defaultResumeTimeout = 30 * time.Second
+	// defaultBacklog is the default number of new sessions waiting for Server.Accept
+	defaultBacklog = 64
+)
+
+// Conn is a message-oriented path, e.g. a wsjs.Conn, webrtcjs.DataChannel or mux.Stream. It must deliver
+// messages reliably and in order while it is up.
+type Conn interface {
+	// NextMessage blocks until the next message is received or the connection is closed.
+	NextMessage() ([]byte, error)
+	// Send sends a single message.
+	Send(data []byte) error
+	// Close closes the connection.
+	Close() error
+}
+
+// Config configures a session.
+type Config struct {
+	// Window is the amount of sent data kept until the peer has consumed it; writes block while it is
+	// exhausted. It also bounds the data buffered by the receiver. Both sides use the smaller of their
+	// windows (default 1 MiB).
+	Window int
+	// MaxMessage limits the payload of a single data frame (default 16 KiB).
+	MaxMessage int
+	// ResumeTimeout is the time a session waits for a new path after losing its path before failing with
+	// ErrResumeTimeout (default 30s).
+	ResumeTimeout time.Duration
+	// Redial is used by the initiator to establish a new path after losing the current one; without it the
+	// session waits for Migrate. It is retried with backoff until ResumeTimeout.
+	Redial func(ctx context.Context) (Conn, error)
+	// Backlog is the number of new sessions waiting for Server.Accept (default 64).
+	Backlog int
+}
+
+// withDefaults fills in zero fields.
+func (cfg Config) withDefaults() Config {
+	if cfg.Window <= 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.MaxMessage <= 0 {
+		cfg.MaxMessage = defaultMaxMessage
+	}
+	if cfg.ResumeTimeout <= 0 {
+		cfg.ResumeTimeout = defaultResumeTimeout
+	}
+	if cfg.Backlog <= 0 {
+		cfg.Backlog = defaultBacklog
+	}
+	return cfg
+}
+
+// sessionID identifies a session across paths.
+type sessionID [16]byte
+
+// newSessionID returns a random session ID.
+func newSessionID() sessionID {
+	var id sessionID
+	rand.Read(id[:])
+	return id
+}
+
+// frameType identifies the kind of a frame.
+type frameType byte
+
+const (
+	frameHello  frameType = iota + 1 // Session ID and the offset the sender received up to
+	frameData                        // Stream offset and data
+	frameAck                         // Offset the sender consumed up to
+	frameClose                       // The sender closed the session
+	frameReject                      // The responder does not know the session
+)
+
+// frame is a decoded frame.
+type frame struct {
+	typ     frameType
+	id      sessionID // Hello only
+	resume  bool      // Hello only, set when attaching a further path to an existing session
+	offset  uint64    // Received offset for Hello, data offset for Data, consumed offset for Ack
+	window  uint64    // Hello only
+	payload []byte    // Data only
+}
+
+// encode appends the wire form of f to b.
+func (f frame) encode(b []byte) []byte {
+	b = append(b, byte(f.typ))
+	switch f.typ {
+	case frameHello:
+		b = append(b, f.id[:]...)
+		b = append(b, boolByte(f.resume))
+		b = binary.AppendUvarint(b, f.offset)
+		b = binary.AppendUvarint(b, f.window)
+	case frameData:
+		b = binary.AppendUvarint(b, f.offset)
+		b = append(b, f.payload...)
+	case frameAck:
+		b = binary.AppendUvarint(b, f.offset)
+	}
+	return b
+}
+
+// decodeFrame parses a frame. The payload of data frames aliases msg.
+func decodeFrame(msg []byte) (frame, error) {
+	if len(msg) == 0 {
+		return frame{}, ErrProtocol
+	}
+	f := frame{typ: frameType(msg[0])}
+	rest := msg[1:]
+	switch f.typ {
+	case frameHello:
+		if len(rest) < len(f.id)+1 || rest[len(f.id)] > 1 {
+			return frame{}, ErrProtocol
+		}
+		copy(f.id[:], rest)
+		f.resume = rest[len(f.id)] == 1
+		rest = rest[len(f.id)+1:]
+		fallthrough
+	case frameData, frameAck:
+		offset, n := binary.Uvarint(rest)
+		if n <= 0 {
+			return frame{}, ErrProtocol
+		}
+		f.offset = offset
+		rest = rest[n:]
+		switch f.typ {
+		case frameHello:
+			window, n := binary.Uvarint(rest)
+			if n <= 0 || window == 0 {
+				return frame{}, ErrProtocol
+			}
+			f.window = window
+		case frameData:
+			f.payload = rest
+		}
+	case frameClose, frameReject:
+	default:
+		return frame{}, ErrProtocol
+	}
+	return f, nil
+}
+
+// boolByte encodes b as 0 or 1.
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
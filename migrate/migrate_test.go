@@ -0,0 +1,188 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pkg.gfire.dev/supernet/memtransport"
+)
+
+// testTimeout bounds every blocking call of a test
+const testTimeout = 10 * time.Second
+
+// lossyConn is a path that silently drops every message sent once it is cut, like a connection failing
+// with data in flight.
+type lossyConn struct {
+	Conn
+	cut atomic.Bool
+}
+
+// Send sends data unless the path is cut.
+func (c *lossyConn) Send(data []byte) error {
+	if c.cut.Load() {
+		return nil
+	}
+	return c.Conn.Send(data)
+}
+
+// path returns the initiator's end of a new path served by srv.
+func path(t *testing.T, srv *Server) *lossyConn {
+	t.Helper()
+	a, b := memtransport.Pipe(memtransport.Config{})
+	go srv.Serve(context.Background(), b)
+	return &lossyConn{Conn: a}
+}
+
+// dial starts a session over a new path to srv and returns both ends and the initiator's path.
+func dial(t *testing.T, srv *Server, cfg Config) (client, server *Session, p *lossyConn) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	p = path(t, srv)
+	client, err := Dial(ctx, p, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	if server, err = srv.Accept(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return client, server, p
+}
+
+// newServer creates a server that is closed when the test ends.
+func newServer(t *testing.T, cfg Config) *Server {
+	srv := NewServer(cfg)
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+// expect reads len(want) bytes from s and compares them with want.
+func expect(t *testing.T, s *Session, want []byte) {
+	t.Helper()
+	s.SetReadDeadline(time.Now().Add(testTimeout))
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(s, got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("read %q, want %q", got, want)
+	}
+}
+
+// write writes data to s.
+func write(t *testing.T, s *Session, data []byte) {
+	t.Helper()
+	if _, err := s.Write(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	srv := newServer(t, Config{})
+	client, server, old := dial(t, srv, Config{})
+	write(t, client, []byte("before"))
+	expect(t, server, []byte("before"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	if err := client.Migrate(ctx, path(t, srv)); err != nil {
+		t.Fatal(err)
+	}
+	// The previous path is closed once the new one is attached
+	if err := old.Send(nil); err == nil {
+		t.Fatal("previous path still open")
+	}
+	write(t, client, []byte("after"))
+	expect(t, server, []byte("after"))
+	write(t, server, []byte("reply"))
+	expect(t, client, []byte("reply"))
+
+	if err := server.Migrate(ctx, path(t, srv)); err != ErrNotInitiator {
+		t.Fatalf("Migrate by the responder: %v, want ErrNotInitiator", err)
+	}
+	if err := client.Migrate(ctx, path(t, newServer(t, Config{}))); err != ErrUnknownSession {
+		t.Fatalf("Migrate to a server without the session: %v, want ErrUnknownSession", err)
+	}
+	if _, err := client.Write([]byte("late")); err != ErrUnknownSession {
+		t.Fatalf("Write after the session was rejected: %v, want ErrUnknownSession", err)
+	}
+}
+
+func TestResume(t *testing.T) {
+	srv := newServer(t, Config{})
+	redialed := make(chan struct{}, 1)
+	client, server, p := dial(t, srv, Config{Redial: func(ctx context.Context) (Conn, error) {
+		redialed <- struct{}{}
+		return path(t, srv), nil
+	}})
+
+	// A failed path is replaced through Redial without the application noticing
+	p.Close()
+	select {
+	case <-redialed:
+	case <-time.After(testTimeout):
+		t.Fatal("path not redialed")
+	}
+	write(t, client, []byte("resumed"))
+	expect(t, server, []byte("resumed"))
+	write(t, server, []byte("reply"))
+	expect(t, client, []byte("reply"))
+
+	client.Close()
+	server.SetReadDeadline(time.Now().Add(testTimeout))
+	if _, err := server.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("Read after the initiator closed: %v, want io.EOF", err)
+	}
+	if _, err := client.Read(make([]byte, 1)); err != ErrClosed {
+		t.Fatalf("Read after Close: %v, want ErrClosed", err)
+	}
+}
+
+func TestResumeTimeout(t *testing.T) {
+	srv := newServer(t, Config{ResumeTimeout: 50 * time.Millisecond})
+	client, _, p := dial(t, srv, Config{ResumeTimeout: 50 * time.Millisecond})
+	p.Close()
+	client.SetReadDeadline(time.Now().Add(testTimeout))
+	if _, err := client.Read(make([]byte, 1)); !errors.Is(err, ErrResumeTimeout) {
+		t.Fatalf("Read without a new path: %v, want ErrResumeTimeout", err)
+	}
+	select {
+	case <-client.Done():
+	default:
+		t.Fatal("session not ended")
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	srv := newServer(t, Config{MaxMessage: 4})
+	client, server, p := dial(t, srv, Config{MaxMessage: 4})
+	write(t, client, []byte("delivered "))
+	expect(t, server, []byte("delivered "))
+
+	// Data sent on a path that fails silently is retransmitted on the next one
+	p.cut.Store(true)
+	write(t, client, []byte("lost "))
+	write(t, client, []byte("and buffered"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	if err := client.Migrate(ctx, path(t, srv)); err != nil {
+		t.Fatal(err)
+	}
+	// The new path carries everything the responder did not receive, in order
+	expect(t, server, []byte("lost and buffered"))
+	write(t, client, []byte("!"))
+	expect(t, server, []byte("!"))
+
+	// Nothing is delivered twice
+	server.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if n, err := server.Read(make([]byte, 1)); err == nil {
+		t.Fatalf("read %d bytes that were never sent", n)
+	}
+}
@@ -0,0 +1,122 @@
+package migrate
+
+import (
+	"context"
+	"sync"
+)
+
+// Server is the responding side of sessions. It attaches incoming paths to the sessions they resume and
+// queues new sessions for Accept.
+type Server struct {
+	cfg Config
+
+	// mu protects sessions
+	mu       sync.Mutex
+	sessions map[sessionID]*Session
+
+	incoming  chan *Session
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+// NewServer creates a server. Config.Redial is not used by responders.
+func NewServer(cfg Config) *Server {
+	cfg = cfg.withDefaults()
+	return &Server{
+		cfg:       cfg,
+		sessions:  make(map[sessionID]*Session),
+		incoming:  make(chan *Session, cfg.Backlog),
+		closeChan: make(chan struct{}),
+	}
+}
+
+// Serve reads the hello on an incoming path and either starts a new session or moves the session it names
+// to path. It returns once path is attached; path is closed if the hello fails or ctx is cancelled first.
+func (srv *Server) Serve(ctx context.Context, path Conn) error {
+	hello, err := readFrame(ctx, path)
+	if err != nil {
+		path.Close()
+		return err
+	}
+	if hello.typ != frameHello {
+		path.Close()
+		return ErrProtocol
+	}
+
+	srv.mu.Lock()
+	select {
+	case <-srv.closeChan:
+		srv.mu.Unlock()
+		path.Close()
+		return ErrClosed
+	default:
+	}
+	s := srv.sessions[hello.id]
+	if s != nil {
+		srv.mu.Unlock()
+		return s.attach(path, hello, true)
+	}
+	if hello.resume {
+		// A resumption for a session that ended or never existed here
+		srv.mu.Unlock()
+		path.Send(frame{typ: frameReject}.encode(nil))
+		path.Close()
+		return ErrUnknownSession
+	}
+	s = newSession(hello.id, srv.cfg, false)
+	s.onClose = func() { srv.remove(s) }
+	srv.sessions[hello.id] = s
+	srv.mu.Unlock()
+
+	if err := s.attach(path, hello, true); err != nil {
+		s.fail(err)
+		return err
+	}
+	select {
+	case srv.incoming <- s:
+		return nil
+	default:
+		// Accept is not keeping up
+		s.Close()
+		return ErrClosed
+	}
+}
+
+// Accept waits for the next new session.
+func (srv *Server) Accept(ctx context.Context) (*Session, error) {
+	select {
+	case s := <-srv.incoming:
+		return s, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-srv.closeChan:
+		return nil, ErrClosed
+	}
+}
+
+// Close stops accepting paths and closes all sessions.
+func (srv *Server) Close() error {
+	srv.closeOnce.Do(func() {
+		srv.mu.Lock()
+		close(srv.closeChan)
+		sessions := make([]*Session, 0, len(srv.sessions))
+		for _, s := range srv.sessions {
+			sessions = append(sessions, s)
+		}
+		srv.mu.Unlock()
+
+		for _, s := range sessions {
+			s.Close()
+		}
+	})
+	return nil
+}
+
+// remove unregisters a session that ended.
+func (srv *Server) remove(s *Session) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.sessions[s.id] == s {
+		delete(srv.sessions, s.id)
+	}
+}
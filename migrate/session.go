@@ -0,0 +1,487 @@
+package migrate
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"pkg.gfire.dev/supernet/internal/streamstate"
+	"pkg.gfire.dev/supernet/msgconn"
+	"pkg.gfire.dev/supernet/retry"
+)
+
+// Session is a byte stream that survives changes of its path. It implements net.Conn.
+type Session struct {
+	id        sessionID
+	cfg       Config
+	initiator bool
+	// onClose is called once when the session ends, e.g. to unregister it from its server
+	onClose func()
+
+	// migrateMu serializes migrations so both sides attach paths in the same order
+	migrateMu sync.Mutex
+	// writeMu serializes Write so data is queued in order
+	writeMu sync.Mutex
+	// sendMu serializes sends on paths so retransmissions precede new data on a fresh path
+	sendMu sync.Mutex
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// path is the current path, nil while detached
+	path Conn
+	// pathGen increases with every attached path
+	pathGen uint64
+	// window is the smaller of the local and the remote window
+	window int
+	// sendBuf holds sent data from sendBase on, the offset the peer consumed up to
+	sendBuf  []byte
+	sendBase uint64
+	// recvBuf holds received data not yet read; received is the offset after its last byte
+	recvBuf  []byte
+	received uint64
+	// consumed is the offset read up to, acked the consumed offset last reported to the peer
+	consumed uint64
+	acked    uint64
+	// state records Close, the peer closing the session and failures, and wakes blocked reads and writes
+	// whenever data arrives, window is granted or the state changes
+	state streamstate.State
+	// readDeadline and writeDeadline bound blocking reads and writes
+	readDeadline  time.Time
+	writeDeadline time.Time
+
+	// done is closed once the session ended
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+// newSession creates a detached session.
+func newSession(id sessionID, cfg Config, initiator bool) *Session {
+	return &Session{
+		id:        id,
+		cfg:       cfg,
+		initiator: initiator,
+		window:    cfg.Window,
+		done:      make(chan struct{}),
+	}
+}
+
+// Dial starts a session over path as initiator.
+// If ctx is cancelled before the remote side answered, path is closed.
+func Dial(ctx context.Context, path Conn, cfg Config) (*Session, error) {
+	s := newSession(newSessionID(), cfg.withDefaults(), true)
+	if err := s.Migrate(ctx, path); err != nil {
+		s.fail(err)
+		return nil, err
+	}
+	return s, nil
+}
+
+// Migrate moves the session to path, e.g. a direct connection established after the session started
+// over a relay. Data not yet received by the remote side is retransmitted on path, and the previous path is
+// closed once path is attached. If ctx is cancelled before the remote side answered, path is closed and the
+// session stays on its current path.
+func (s *Session) Migrate(ctx context.Context, path Conn) error {
+	if !s.initiator {
+		path.Close()
+		return ErrNotInitiator
+	}
+	s.migrateMu.Lock()
+	defer s.migrateMu.Unlock()
+
+	s.mu.Lock()
+	err := s.state.WriteErr(ErrClosed)
+	hello := frame{typ: frameHello, id: s.id, resume: s.pathGen > 0, offset: s.received, window: uint64(s.cfg.Window)}
+	s.mu.Unlock()
+	if err != nil {
+		path.Close()
+		return err
+	}
+
+	if err := path.Send(hello.encode(nil)); err != nil {
+		path.Close()
+		return err
+	}
+	reply, err := readFrame(ctx, path)
+	if err != nil {
+		path.Close()
+		return err
+	}
+	switch {
+	case reply.typ == frameReject:
+		path.Close()
+		s.fail(ErrUnknownSession)
+		return ErrUnknownSession
+	case reply.typ != frameHello || reply.id != s.id:
+		path.Close()
+		return ErrProtocol
+	}
+	return s.attach(path, reply, false)
+}
+
+// attach makes path the current path, optionally answering the peer's hello first, and retransmits
+// everything the peer has not received on it.
+func (s *Session) attach(path Conn, peer frame, reply bool) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	s.mu.Lock()
+	if err := s.state.WriteErr(ErrClosed); err != nil {
+		s.mu.Unlock()
+		path.Close()
+		return err
+	}
+	sent := s.sendBase + uint64(len(s.sendBuf))
+	// A hello racing with a concurrent migration may report less than the peer already acknowledged
+	peerReceived := max(peer.offset, s.sendBase)
+	if peerReceived > sent {
+		s.mu.Unlock()
+		path.Close()
+		err := fmt.Errorf("%w: peer received up to %d of %d bytes", ErrProtocol, peerReceived, sent)
+		s.fail(err)
+		return err
+	}
+	old := s.path
+	s.path = path
+	s.pathGen++
+	s.window = int(min(peer.window, uint64(s.cfg.Window)))
+	hello := frame{typ: frameHello, id: s.id, offset: s.received, window: uint64(s.cfg.Window)}
+	pending := append([]byte(nil), s.sendBuf[peerReceived-s.sendBase:]...)
+	// Acknowledgements sent on the old path may have been lost, so repeat the last one
+	s.acked = s.consumed
+	ack := frame{typ: frameAck, offset: s.consumed}
+	s.state.Broadcast()
+	s.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	go s.readLoop(path)
+
+	if reply {
+		if err := path.Send(hello.encode(nil)); err != nil {
+			s.detach(path)
+			return nil
+		}
+	}
+	offset := peerReceived
+	for len(pending) > 0 {
+		n := min(len(pending), s.cfg.MaxMessage)
+		if err := path.Send(frame{typ: frameData, offset: offset, payload: pending[:n]}.encode(nil)); err != nil {
+			s.detach(path)
+			return nil
+		}
+		pending = pending[n:]
+		offset += uint64(n)
+	}
+	if err := path.Send(ack.encode(nil)); err != nil {
+		s.detach(path)
+	}
+	return nil
+}
+
+// detach drops path if it is still the current path and waits for a new one.
+func (s *Session) detach(path Conn) {
+	s.mu.Lock()
+	if s.path != path {
+		s.mu.Unlock()
+		return
+	}
+	s.path = nil
+	gen := s.pathGen
+	s.state.Broadcast()
+	s.mu.Unlock()
+	path.Close()
+
+	time.AfterFunc(s.cfg.ResumeTimeout, func() {
+		s.mu.Lock()
+		resumed := s.pathGen != gen
+		s.mu.Unlock()
+		if !resumed {
+			s.fail(ErrResumeTimeout)
+		}
+	})
+	if s.initiator && s.cfg.Redial != nil {
+		go s.redial()
+	}
+}
+
+// redial establishes a new path with Config.Redial until one is attached or the resume timeout passes.
+func (s *Session) redial() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ResumeTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	retry.Do(ctx, retry.Policy{}, func(ctx context.Context) error {
+		path, err := s.cfg.Redial(ctx)
+		if err != nil {
+			return err
+		}
+		err = s.Migrate(ctx, path)
+		if errors.Is(err, ErrUnknownSession) || errors.Is(err, ErrClosed) {
+			return retry.Stop(err)
+		}
+		return err
+	})
+}
+
+// readLoop receives frames from path until it fails or is replaced.
+func (s *Session) readLoop(path Conn) {
+	for {
+		msg, err := path.NextMessage()
+		if err != nil {
+			s.detach(path)
+			return
+		}
+		f, err := decodeFrame(msg)
+		if err != nil {
+			s.fail(err)
+			return
+		}
+
+		s.mu.Lock()
+		if s.path != path {
+			s.mu.Unlock()
+			return
+		}
+		err = s.handle(f)
+		s.mu.Unlock()
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		if f.typ == frameClose {
+			s.finish()
+			return
+		}
+	}
+}
+
+// handle applies a frame received on the current path. Must be called with mu held.
+func (s *Session) handle(f frame) error {
+	switch f.typ {
+	case frameData:
+		end := f.offset + uint64(len(f.payload))
+		if f.offset > s.received {
+			return fmt.Errorf("%w: data at %d, expected %d", ErrProtocol, f.offset, s.received)
+		}
+		if end <= s.received {
+			// Retransmission of data that arrived before the path changed
+			return nil
+		}
+		if end-s.consumed > uint64(s.window) {
+			return fmt.Errorf("%w: receive window exceeded", ErrProtocol)
+		}
+		s.recvBuf = append(s.recvBuf, f.payload[s.received-f.offset:]...)
+		s.received = end
+	case frameAck:
+		sent := s.sendBase + uint64(len(s.sendBuf))
+		if f.offset > sent {
+			return fmt.Errorf("%w: acknowledgement beyond sent data", ErrProtocol)
+		}
+		if f.offset > s.sendBase {
+			s.sendBuf = s.sendBuf[f.offset-s.sendBase:]
+			s.sendBase = f.offset
+		}
+	case frameClose:
+		s.state.RemoteClosed = true
+	default:
+		return fmt.Errorf("%w: unexpected frame type %d", ErrProtocol, f.typ)
+	}
+	s.state.Broadcast()
+	return nil
+}
+
+// send sends a frame on the current path, if any. A failed send detaches the path; the data stays queued
+// for retransmission.
+func (s *Session) send(f frame) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	s.mu.Lock()
+	path := s.path
+	s.mu.Unlock()
+	if path == nil {
+		return
+	}
+	if err := path.Send(f.encode(nil)); err != nil {
+		s.detach(path)
+	}
+}
+
+// Read reads received data, blocking until some is available.
+// It returns io.EOF once the remote side closed the session and all data was read.
+func (s *Session) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	for len(s.recvBuf) == 0 {
+		if err := s.state.ReadErr(ErrClosed); err != nil {
+			s.mu.Unlock()
+			return 0, err
+		}
+		changed, deadline := s.state.Changed(), s.readDeadline
+		s.mu.Unlock()
+		if err := streamstate.Wait(changed, deadline); err != nil {
+			return 0, err
+		}
+		s.mu.Lock()
+	}
+
+	n := copy(p, s.recvBuf)
+	s.recvBuf = s.recvBuf[n:]
+	s.consumed += uint64(n)
+	// Acknowledge in batches; the sender keeps data until then, so a quarter window bounds the overhead
+	var ack *frame
+	if s.consumed-s.acked >= uint64(s.window/4) {
+		s.acked = s.consumed
+		ack = &frame{typ: frameAck, offset: s.consumed}
+	}
+	s.mu.Unlock()
+
+	if ack != nil {
+		s.send(*ack)
+	}
+	return n, nil
+}
+
+// Write queues p for transmission, blocking while the window is exhausted. Data written while the session
+// is between paths is sent once a new path is attached.
+func (s *Session) Write(p []byte) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	n := 0
+	for n < len(p) {
+		s.mu.Lock()
+		space := 0
+		for {
+			if err := s.state.WriteErr(ErrClosed); err != nil {
+				s.mu.Unlock()
+				return n, err
+			}
+			if space = s.window - len(s.sendBuf); space > 0 {
+				break
+			}
+			changed, deadline := s.state.Changed(), s.writeDeadline
+			s.mu.Unlock()
+			if err := streamstate.Wait(changed, deadline); err != nil {
+				return n, err
+			}
+			s.mu.Lock()
+		}
+
+		chunk := p[n:min(len(p), n+space, n+s.cfg.MaxMessage)]
+		offset := s.sendBase + uint64(len(s.sendBuf))
+		s.sendBuf = append(s.sendBuf, chunk...)
+		s.mu.Unlock()
+
+		s.send(frame{typ: frameData, offset: offset, payload: chunk})
+		n += len(chunk)
+	}
+	return n, nil
+}
+
+// Close closes the session, telling the remote side. Data not yet delivered is discarded.
+// Safe to call multiple times.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	if s.state.LocalClosed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.state.LocalClosed = true
+	s.state.Broadcast()
+	s.mu.Unlock()
+
+	s.send(frame{typ: frameClose})
+	s.finish()
+	return nil
+}
+
+// fail ends the session with err.
+func (s *Session) fail(err error) {
+	s.mu.Lock()
+	s.state.Fail(err)
+	s.mu.Unlock()
+	s.finish()
+}
+
+// finish closes the current path and releases the session once it ended.
+func (s *Session) finish() {
+	s.doneOnce.Do(func() {
+		s.mu.Lock()
+		path := s.path
+		s.path = nil
+		close(s.done)
+		s.state.Broadcast()
+		s.mu.Unlock()
+
+		if path != nil {
+			path.Close()
+		}
+		if s.onClose != nil {
+			s.onClose()
+		}
+	})
+}
+
+// Done returns a channel that is closed when the session ends.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// LocalAddr returns the session address.
+func (s *Session) LocalAddr() net.Addr {
+	return msgconn.Addr{Net: "migrate", Addr: hex.EncodeToString(s.id[:])}
+}
+
+// RemoteAddr returns the session address, which is the same on both sides.
+func (s *Session) RemoteAddr() net.Addr {
+	return s.LocalAddr()
+}
+
+// SetDeadline sets both the read and write deadlines.
+func (s *Session) SetDeadline(t time.Time) error {
+	s.SetReadDeadline(t)
+	return s.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for pending and future Read calls.
+func (s *Session) SetReadDeadline(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readDeadline = t
+	s.state.Broadcast()
+	return nil
+}
+
+// SetWriteDeadline sets the deadline for pending and future Write calls.
+func (s *Session) SetWriteDeadline(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writeDeadline = t
+	s.state.Broadcast()
+	return nil
+}
+
+// readFrame reads one frame from path, closing path if ctx is cancelled first.
+func readFrame(ctx context.Context, path Conn) (frame, error) {
+	stop := context.AfterFunc(ctx, func() { path.Close() })
+	defer stop()
+	msg, err := path.NextMessage()
+	if err != nil {
+		if ctx.Err() != nil {
+			return frame{}, ctx.Err()
+		}
+		return frame{}, err
+	}
+	return decodeFrame(msg)
+}
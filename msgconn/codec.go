@@ -0,0 +1,124 @@
+package msgconn
+
+import (
+	"encoding/json"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encodes the messages of a TypedConn.
+type Codec[T any] interface {
+	// Marshal encodes v as a message.
+	Marshal(v T) ([]byte, error)
+	// Unmarshal decodes a message.
+	Unmarshal(data []byte) (T, error)
+}
+
+// JSON returns a Codec encoding messages as JSON.
+func JSON[T any]() Codec[T] {
+	return Marshaler[T](json.Marshal, json.Unmarshal)
+}
+
+// Marshaler returns a Codec calling functions shaped like json.Marshal and json.Unmarshal, which is how CBOR
+// and MessagePack libraries encode as well:
+//
+//	codec := msgconn.Marshaler[Event](cbor.Marshal, cbor.Unmarshal)
+func Marshaler[T any](marshal func(v any) ([]byte, error), unmarshal func(data []byte, v any) error) Codec[T] {
+	return marshaler[T]{marshal: marshal, unmarshal: unmarshal}
+}
+
+// marshaler is the Codec returned by Marshaler.
+type marshaler[T any] struct {
+	marshal   func(v any) ([]byte, error)
+	unmarshal func(data []byte, v any) error
+}
+
+// Marshal encodes v with the marshal function.
+func (m marshaler[T]) Marshal(v T) ([]byte, error) {
+	return m.marshal(v)
+}
+
+// Unmarshal decodes data into a new T with the unmarshal function.
+func (m marshaler[T]) Unmarshal(data []byte) (T, error) {
+	var v T
+	err := m.unmarshal(data, &v)
+	return v, err
+}
+
+// Proto returns a Codec encoding protobuf messages in the binary wire format. T is a pointer to a generated
+// message type, e.g. *pb.Envelope.
+func Proto[T proto.Message]() Codec[T] {
+	return protoCodec[T]{}
+}
+
+// protoCodec is the Codec returned by Proto.
+type protoCodec[T proto.Message] struct{}
+
+// Marshal encodes v.
+func (protoCodec[T]) Marshal(v T) ([]byte, error) {
+	return proto.Marshal(v)
+}
+
+// Unmarshal decodes data into a new message.
+func (protoCodec[T]) Unmarshal(data []byte) (T, error) {
+	// Generated messages report their type through a nil pointer as well
+	var zero T
+	v := zero.ProtoReflect().Type().New().Interface().(T)
+	if err := proto.Unmarshal(data, v); err != nil {
+		return zero, err
+	}
+	return v, nil
+}
+
+// VTMessage is implemented by protobuf messages generated with vtprotobuf, which marshal without reflection.
+type VTMessage interface {
+	MarshalVT() ([]byte, error)
+	UnmarshalVT([]byte) error
+}
+
+// SendVT marshals msg and sends it as one message, e.g. a step of a handshake.
+func SendVT(conn Sender, msg VTMessage) error {
+	data, err := msg.MarshalVT()
+	if err != nil {
+		return err
+	}
+	return conn.Send(data)
+}
+
+// RecvVT receives one message and unmarshals it into msg.
+func RecvVT(conn Receiver, msg VTMessage) error {
+	data, err := conn.NextMessage()
+	if err != nil {
+		return err
+	}
+	return msg.UnmarshalVT(data)
+}
+
+// VT returns a Codec encoding protobuf messages generated with vtprotobuf in the binary wire format, without
+// reflection. M is the generated message type, e.g. msgconn.VT[pb.Envelope]() encodes *pb.Envelope.
+func VT[M any, T interface {
+	*M
+	VTMessage
+}]() Codec[T] {
+	return vtCodec[M, T]{}
+}
+
+// vtCodec is the Codec returned by VT.
+type vtCodec[M any, T interface {
+	*M
+	VTMessage
+}] struct{}
+
+// Marshal encodes v.
+func (vtCodec[M, T]) Marshal(v T) ([]byte, error) {
+	return v.MarshalVT()
+}
+
+// Unmarshal decodes data into a new message.
+func (vtCodec[M, T]) Unmarshal(data []byte) (T, error) {
+	v := T(new(M))
+	if err := v.UnmarshalVT(data); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,241 @@
+// Package msgconn adapts message-oriented connections such as wsjs.Conn, webrtcjs.DataChannel and
+// mux.Stream to net.Conn, so they can be used with code written against the standard library. TypedConn
+// carries values of a Go type over the same connections instead, encoded by a Codec.
+package msgconn
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned when using a connection that has been closed
+var ErrClosed = errors.New("connection closed")
+
+// Conn is a message-oriented connection.
+type Conn interface {
+	// NextMessage blocks until the next message is received or the connection is closed.
+	NextMessage() ([]byte, error)
+	// Send sends a single message.
+	Send(data []byte) error
+	// Close closes the connection.
+	Close() error
+}
+
+// Sender is the sending side of a Conn, for helpers that only send.
+type Sender interface {
+	// Send sends a single message.
+	Send(data []byte) error
+}
+
+// Receiver is the receiving side of a Conn, for helpers that only receive.
+type Receiver interface {
+	// NextMessage blocks until the next message is received or the connection is closed.
+	NextMessage() ([]byte, error)
+}
+
+// Addr is a generic net.Addr.
+type Addr struct {
+	Net  string // Network name, e.g. "tcp" or "supernet"
+	Addr string // Address in the network's format
+}
+
+// Network returns the network name.
+func (a Addr) Network() string { return a.Net }
+
+// String returns the address.
+func (a Addr) String() string { return a.Addr }
+
+// Options configures the net.Conn adapter.
+type Options struct {
+	// LocalAddr and RemoteAddr are reported by the net.Conn methods of the same name.
+	LocalAddr, RemoteAddr net.Addr
+	// Datagram preserves message boundaries: every Write sends one message and every Read returns one
+	// message, discarding whatever does not fit into the buffer, like a connected UDP socket.
+	Datagram bool
+	// MaxMessage splits stream writes into messages of at most this size. Zero sends every Write as one
+	// message. It is ignored in datagram mode.
+	MaxMessage int
+}
+
+// NetConn wraps conn as a net.Conn. Closing the returned connection closes conn.
+func NetConn(conn Conn, opts Options) net.Conn {
+	if opts.LocalAddr == nil {
+		opts.LocalAddr = Addr{Net: "message"}
+	}
+	if opts.RemoteAddr == nil {
+		opts.RemoteAddr = Addr{Net: "message"}
+	}
+
+	c := &netConn{
+		conn:        conn,
+		opts:        opts,
+		messageChan: make(chan []byte),
+		pumpDone:    make(chan struct{}),
+		closeChan:   make(chan struct{}),
+	}
+	go c.pump()
+	return c
+}
+
+// netConn implements net.Conn over a Conn.
+type netConn struct {
+	conn Conn
+	opts Options
+
+	// messageChan receives messages pumped from the underlying connection
+	messageChan chan []byte
+	// readErr holds the error that terminated the pump, valid once pumpDone is closed
+	readErr  error
+	pumpDone chan struct{}
+
+	readMu        sync.Mutex
+	currentBuffer []byte // Remaining bytes from the last message that didn't fit in the read buffer
+
+	deadlineMu    sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+// pump moves messages from the blocking NextMessage into messageChan so reads can honor deadlines.
+func (c *netConn) pump() {
+	defer close(c.pumpDone)
+	for {
+		msg, err := c.conn.NextMessage()
+		if err != nil {
+			c.readErr = err
+			return
+		}
+		select {
+		case c.messageChan <- msg:
+		case <-c.closeChan:
+			return
+		}
+	}
+}
+
+// Read returns buffered message data first, then the next message.
+func (c *netConn) Read(p []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	if len(c.currentBuffer) > 0 {
+		n := copy(p, c.currentBuffer)
+		c.currentBuffer = c.currentBuffer[n:]
+		return n, nil
+	}
+
+	c.deadlineMu.Lock()
+	deadline := c.readDeadline
+	c.deadlineMu.Unlock()
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case msg := <-c.messageChan:
+		n := copy(p, msg)
+		if n < len(msg) && !c.opts.Datagram {
+			c.currentBuffer = msg[n:]
+		}
+		return n, nil
+	case <-c.pumpDone:
+		if c.readErr != nil {
+			return 0, io.EOF
+		}
+		return 0, ErrClosed
+	case <-c.closeChan:
+		return 0, ErrClosed
+	case <-timeout:
+		return 0, os.ErrDeadlineExceeded
+	}
+}
+
+// Write sends p as one message, or as several in stream mode when p exceeds MaxMessage.
+func (c *netConn) Write(p []byte) (int, error) {
+	select {
+	case <-c.closeChan:
+		return 0, ErrClosed
+	default:
+	}
+
+	c.deadlineMu.Lock()
+	deadline := c.writeDeadline
+	c.deadlineMu.Unlock()
+	if !deadline.IsZero() && time.Now().After(deadline) {
+		return 0, os.ErrDeadlineExceeded
+	}
+
+	if c.opts.Datagram || c.opts.MaxMessage <= 0 {
+		if err := c.conn.Send(p); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	n := 0
+	for n < len(p) {
+		chunk := p[n:]
+		if len(chunk) > c.opts.MaxMessage {
+			chunk = chunk[:c.opts.MaxMessage]
+		}
+		if err := c.conn.Send(chunk); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+	}
+	return n, nil
+}
+
+// Close closes the underlying connection. Safe to call multiple times.
+func (c *netConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closeChan)
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// LocalAddr returns the configured local address.
+func (c *netConn) LocalAddr() net.Addr {
+	return c.opts.LocalAddr
+}
+
+// RemoteAddr returns the configured remote address.
+func (c *netConn) RemoteAddr() net.Addr {
+	return c.opts.RemoteAddr
+}
+
+// SetDeadline sets both the read and write deadlines.
+func (c *netConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for pending and future Read calls.
+func (c *netConn) SetReadDeadline(t time.Time) error {
+	c.deadlineMu.Lock()
+	defer c.deadlineMu.Unlock()
+	c.readDeadline = t
+	return nil
+}
+
+// SetWriteDeadline sets the deadline for future Write calls.
+// Message sends are not interruptible, so in-flight writes are not affected.
+func (c *netConn) SetWriteDeadline(t time.Time) error {
+	c.deadlineMu.Lock()
+	defer c.deadlineMu.Unlock()
+	c.writeDeadline = t
+	return nil
+}
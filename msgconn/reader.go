@@ -0,0 +1,69 @@
+package msgconn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrUnexpected is returned by protocols running over a Reader when the remote side sends a message out of
+// order
+var ErrUnexpected = errors.New("msgconn: unexpected message")
+
+// readerBuffer is the number of decoded messages a Reader holds ahead of Next
+const readerBuffer = 16
+
+// Reader receives and decodes messages from a connection in the background so that waiting for the next one can
+// observe a context. Unlike TypedConn it serves a single exchange over a connection the caller keeps: it
+// stops once the context it was created with is done and never closes the connection.
+type Reader[T any] struct {
+	msgs chan T
+	// err holds the error that ended receiving, valid once msgs is closed
+	err error
+}
+
+// NewReader starts receiving messages of type T from conn until it fails, a message fails to decode or ctx
+// is done.
+func NewReader[T any](ctx context.Context, conn Receiver, codec Codec[T]) *Reader[T] {
+	r := &Reader[T]{msgs: make(chan T, readerBuffer)}
+	go func() {
+		defer close(r.msgs)
+		for {
+			data, err := conn.NextMessage()
+			if err != nil {
+				r.err = err
+				return
+			}
+			v, err := codec.Unmarshal(data)
+			if err != nil {
+				r.err = fmt.Errorf("%w: %w", ErrDecode, err)
+				return
+			}
+			select {
+			case r.msgs <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return r
+}
+
+// Next returns the next message. It returns the error that ended receiving once all received messages were
+// returned, and ctx.Err() once ctx is done.
+func (r *Reader[T]) Next(ctx context.Context) (T, error) {
+	var zero T
+	select {
+	case v, ok := <-r.msgs:
+		if !ok {
+			if r.err == nil {
+				return zero, io.ErrUnexpectedEOF
+			}
+			return zero, r.err
+		}
+		return v, nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
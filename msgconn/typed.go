@@ -0,0 +1,135 @@
+package msgconn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrDecode is returned when a received message cannot be decoded by the Codec of a TypedConn
+var ErrDecode = errors.New("msgconn: malformed message")
+
+// TypedConn sends and receives values of type T over a Conn, encoded by a Codec. Receiving is channel-like:
+// messages are decoded one at a time as C is drained, so a slow receiver holds back the connection.
+type TypedConn[T any] struct {
+	// C receives the decoded messages. It is closed once receiving ended; Err reports why.
+	C <-chan T
+
+	conn  Conn
+	codec Codec[T]
+
+	// c is the sending side of C
+	c chan T
+	// err holds the error that ended receiving, valid once done is closed
+	err  error
+	done chan struct{}
+
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+// NewTypedConn starts receiving values of type T from conn. Closing the returned connection closes conn.
+func NewTypedConn[T any](conn Conn, codec Codec[T]) *TypedConn[T] {
+	c := &TypedConn[T]{
+		conn:      conn,
+		codec:     codec,
+		c:         make(chan T),
+		done:      make(chan struct{}),
+		closeChan: make(chan struct{}),
+	}
+	c.C = c.c
+	go c.pump()
+	return c
+}
+
+// pump decodes messages into C until the connection ends or a message fails to decode, which closes it.
+func (c *TypedConn[T]) pump() {
+	defer close(c.done)
+	defer close(c.c)
+	for {
+		msg, err := c.conn.NextMessage()
+		if err != nil {
+			select {
+			case <-c.closeChan:
+				err = ErrClosed
+			default:
+			}
+			c.err = err
+			return
+		}
+		v, err := c.codec.Unmarshal(msg)
+		if err != nil {
+			c.err = fmt.Errorf("%w: %w", ErrDecode, err)
+			c.conn.Close()
+			return
+		}
+		select {
+		case c.c <- v:
+		case <-c.closeChan:
+			c.err = ErrClosed
+			return
+		}
+	}
+}
+
+// Conn returns the underlying connection.
+func (c *TypedConn[T]) Conn() Conn {
+	return c.conn
+}
+
+// Send encodes v and sends it as one message, blocking as long as the underlying Send does.
+func (c *TypedConn[T]) Send(v T) error {
+	select {
+	case <-c.closeChan:
+		return ErrClosed
+	default:
+	}
+	data, err := c.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.conn.Send(data)
+}
+
+// Recv blocks until the next value is received. Once receiving ended it returns Err.
+func (c *TypedConn[T]) Recv() (T, error) {
+	return c.RecvContext(context.Background())
+}
+
+// RecvContext is Recv bounded by ctx. It returns ctx.Err() once ctx is done, without losing a message.
+func (c *TypedConn[T]) RecvContext(ctx context.Context) (T, error) {
+	select {
+	case v, ok := <-c.c:
+		if !ok {
+			<-c.done
+			var zero T
+			return zero, c.err
+		}
+		return v, nil
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Err returns the error that ended receiving: the error of the underlying connection, an error wrapping
+// ErrDecode, or ErrClosed after Close. It is nil while C is open.
+func (c *TypedConn[T]) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// Close closes the underlying connection. Safe to call multiple times.
+func (c *TypedConn[T]) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closeChan)
+		err = c.conn.Close()
+	})
+	return err
+}
@@ -0,0 +1,32 @@
+package mux
+
+import (
+	"fmt"
+
+	"pkg.gfire.dev/supernet/codec"
+)
+
+// Frame types, see codec.MuxFrameType for the wire format.
+const (
+	typeOpen     = codec.MuxOpen
+	typeData     = codec.MuxData
+	typeDataMore = codec.MuxDataMore
+	typeWindow   = codec.MuxWindow
+	typeClose    = codec.MuxClose
+	typeReset    = codec.MuxReset
+	typeHello    = codec.MuxHello
+)
+
+// encodeFrame serializes a frame.
+func encodeFrame(typ codec.MuxFrameType, id uint64, payload []byte) []byte {
+	return codec.EncodeMuxFrame(codec.MuxFrame{Type: typ, Stream: id, Payload: payload})
+}
+
+// decodeFrame parses a frame. The payload aliases msg.
+func decodeFrame(msg []byte) (codec.MuxFrame, error) {
+	f, err := codec.DecodeMuxFrame(msg)
+	if err != nil {
+		return codec.MuxFrame{}, fmt.Errorf("%w: %w", ErrProtocol, err)
+	}
+	return f, nil
+}
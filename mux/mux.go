@@ -0,0 +1,491 @@
+// Package mux multiplexes independent, flow-controlled streams over a single message-oriented connection,
+// so many logical channels can share one WebSocket, WebTransport session or data channel.
+//
+// Streams preserve message boundaries: each Send is delivered as one NextMessage on the other side.
+// Messages are limited to half the receive window (Session.MaxMessage).
+// Streams also implement io.ReadWriter for byte-oriented use; Read and NextMessage must not be mixed.
+//
+// Outgoing data is scheduled by stream priority: higher classes go first, and streams of the same class
+// share the connection in proportion to their weights (Stream.SetPriority), so interactive streams are
+// not stuck behind bulk transfers. Window updates and stream resets bypass the queue.
+//
+// Both sides announce their Version and Config.Capabilities in a hello frame when the session starts, which
+// multiplexers predating it ignore; Session.Protocol reports what both sides speak.
+package mux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"pkg.gfire.dev/supernet/codec"
+	"pkg.gfire.dev/supernet/logging"
+)
+
+var (
+	// ErrClosed is returned when using a closed session
+	ErrClosed = errors.New("mux session closed")
+	// ErrStreamClosed is returned when using a stream closed by either side
+	ErrStreamClosed = errors.New("mux stream closed")
+	// ErrStreamReset is returned when the remote side reset a stream
+	ErrStreamReset = errors.New("mux stream reset")
+	// ErrMessageTooLarge is returned when sending a message larger than Session.MaxMessage
+	ErrMessageTooLarge = errors.New("mux message too large")
+	// ErrProtocol is returned when the remote side violates the framing protocol
+	ErrProtocol = errors.New("mux protocol violation")
+)
+
+const (
+	// defaultWindow is the default per-stream receive window in bytes
+	defaultWindow = 256 << 10
+	// defaultMaxFrame is the default maximum payload of a single data frame
+	defaultMaxFrame = 16 << 10
+	// defaultBacklog is the default number of incoming streams queued for AcceptStream
+	defaultBacklog = 64
+)
+
+// Version is the version of the mux protocol spoken by this package. Version 1 lacks hello frames.
+const Version = 2
+
+// Conn is a message-oriented connection. It matches the NextMessage/Send/Close contract of wsjs.Conn,
+// webrtcjs.DataChannel and p2p.Conn.
+type Conn interface {
+	// NextMessage blocks until the next message is received or the connection is closed.
+	NextMessage() ([]byte, error)
+	// Send sends a single message.
+	Send(data []byte) error
+	// Close closes the connection.
+	Close() error
+}
+
+// Config configures a Session. Both sides should use the same Window and MaxFrame.
+type Config struct {
+	// Server selects even stream IDs for locally opened streams; the other side must use false.
+	Server bool
+	// Window is the per-stream receive window in bytes (default 256 KiB).
+	Window int
+	// MaxFrame is the maximum data frame payload; larger messages are fragmented (default 16 KiB).
+	// It is capped at half the window.
+	MaxFrame int
+	// AcceptBacklog is the number of incoming streams queued for AcceptStream (default 64).
+	// Streams opened while the backlog is full are reset.
+	AcceptBacklog int
+	// Capabilities are announced to the remote side; Session.Protocol reports those both sides support.
+	Capabilities codec.Capabilities
+	// MinVersion is the lowest mux version accepted from the remote side, 0 for any. Sessions with older
+	// peers fail with an error matching ErrProtocol and codec.ErrIncompatible.
+	MinVersion uint32
+	// Logger receives session failures and refused streams. Defaults to logging.For("mux").
+	Logger *slog.Logger
+}
+
+// Session multiplexes streams over a Conn.
+type Session struct {
+	conn Conn
+	cfg  Config
+
+	// sendMu serializes writes to conn
+	sendMu sync.Mutex
+	// helloSent reports whether the hello frame was sent, which precedes every other frame; guarded by sendMu
+	helloSent bool
+	// sched orders queued data and close frames
+	sched *scheduler
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// streams holds the open streams by ID
+	streams map[uint64]*Stream
+	// nextID is the ID of the next locally opened stream
+	nextID uint64
+	// err is the reason the session was closed
+	err error
+	// protocol is the protocol spoken with the remote side, valid once negotiated is closed
+	protocol codec.Protocol
+
+	// negotiated is closed by the read loop once the protocol of the remote side is known
+	negotiated chan struct{}
+	// accept delivers streams opened by the remote side
+	accept chan *Stream
+	// closed is closed when the session is closed
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSession starts multiplexing over conn. The session owns conn and closes it on Close.
+func NewSession(conn Conn, cfg Config) *Session {
+	if cfg.Window <= 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.MaxFrame <= 0 {
+		cfg.MaxFrame = defaultMaxFrame
+	}
+	if cfg.MaxFrame > cfg.Window/2 {
+		cfg.MaxFrame = cfg.Window / 2
+	}
+	if cfg.AcceptBacklog <= 0 {
+		cfg.AcceptBacklog = defaultBacklog
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logging.For("mux")
+	}
+
+	s := &Session{
+		conn:       conn,
+		cfg:        cfg,
+		streams:    make(map[uint64]*Stream),
+		nextID:     1,
+		accept:     make(chan *Stream, cfg.AcceptBacklog),
+		negotiated: make(chan struct{}),
+		closed:     make(chan struct{}),
+		sched:      newScheduler(cfg.MaxFrame),
+	}
+	if cfg.Server {
+		s.nextID = 2
+	}
+	go s.readLoop()
+	go s.writeLoop()
+	return s
+}
+
+// OpenStream opens a new stream. The remote side receives it from AcceptStream.
+func (s *Session) OpenStream(ctx context.Context) (*Stream, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.err != nil {
+		s.mu.Unlock()
+		return nil, ErrClosed
+	}
+	st := newStream(s, s.nextID)
+	s.nextID += 2
+	s.streams[st.id] = st
+	s.mu.Unlock()
+
+	if err := s.writeFrame(typeOpen, st.id, nil); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// AcceptStream waits for the next stream opened by the remote side.
+func (s *Session) AcceptStream(ctx context.Context) (*Stream, error) {
+	select {
+	case st := <-s.accept:
+		return st, nil
+	case <-s.closed:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// MaxMessage returns the largest message a stream can send, half the receive window.
+// Bounding messages this way guarantees a message always fits once earlier ones are consumed.
+func (s *Session) MaxMessage() int {
+	return s.cfg.Window / 2
+}
+
+// Protocol waits until the protocol spoken with the remote side is known and returns it: the lower of
+// both versions and the capabilities both sides announced. A peer predating hello frames is recognized as
+// codec.LegacyVersion by its first frame, so Protocol waits until the remote side sends anything.
+func (s *Session) Protocol(ctx context.Context) (codec.Protocol, error) {
+	select {
+	case <-s.negotiated:
+	case <-s.closed:
+		select {
+		case <-s.negotiated:
+		default:
+			return codec.Protocol{}, ErrClosed
+		}
+	case <-ctx.Done():
+		return codec.Protocol{}, ctx.Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.protocol, nil
+}
+
+// NumStreams returns the number of open streams.
+func (s *Session) NumStreams() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.streams)
+}
+
+// Done returns a channel that is closed when the session is closed.
+func (s *Session) Done() <-chan struct{} {
+	return s.closed
+}
+
+// Err returns the reason the session was closed, or nil while it is open.
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close closes the session, all of its streams and the underlying connection.
+func (s *Session) Close() error {
+	s.fail(ErrClosed)
+	return nil
+}
+
+// fail closes the session with the given reason.
+func (s *Session) fail(err error) {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.err = err
+		streams := s.streams
+		s.streams = make(map[uint64]*Stream)
+		s.mu.Unlock()
+
+		s.conn.Close()
+		close(s.closed)
+		for _, st := range streams {
+			st.fail(ErrClosed)
+		}
+
+		switch {
+		case errors.Is(err, ErrProtocol):
+			s.cfg.Logger.Warn("mux session failed", "err", err, "streams", len(streams))
+		case err != ErrClosed:
+			s.cfg.Logger.Debug("mux session closed", "err", err, "streams", len(streams))
+		}
+	})
+}
+
+// readLoop dispatches incoming frames until the connection fails.
+func (s *Session) readLoop() {
+	for {
+		msg, err := s.conn.NextMessage()
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		if err := s.handle(msg); err != nil {
+			s.fail(err)
+			return
+		}
+	}
+}
+
+// handle dispatches a single frame.
+func (s *Session) handle(msg []byte) error {
+	f, err := decodeFrame(msg)
+	if err != nil {
+		return err
+	}
+	typ, id, payload := f.Type, f.Stream, f.Payload
+
+	if typ == typeHello {
+		return s.handleHello(id, payload)
+	}
+	select {
+	case <-s.negotiated:
+	default:
+		// Any other first frame comes from a peer predating hello frames
+		if err := s.negotiate(codec.Protocol{}); err != nil {
+			return err
+		}
+	}
+
+	if typ == typeOpen {
+		return s.handleOpen(id)
+	}
+
+	s.mu.Lock()
+	st := s.streams[id]
+	s.mu.Unlock()
+	if st == nil {
+		// Frames may still be in flight for streams closed locally
+		return nil
+	}
+
+	switch typ {
+	case typeData, typeDataMore:
+		return st.receive(payload, typ == typeData)
+	case typeWindow:
+		delta, err := codec.DecodeMuxWindow(payload)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrProtocol, err)
+		}
+		st.grant(delta)
+	case typeClose:
+		st.remoteClose()
+	case typeReset:
+		s.remove(id)
+		st.fail(ErrStreamReset)
+		s.discard(st, ErrStreamReset)
+	default:
+		return fmt.Errorf("%w: unknown frame type %d", ErrProtocol, typ)
+	}
+	return nil
+}
+
+// handleHello negotiates the protocol announced by the first frame of the remote side.
+func (s *Session) handleHello(id uint64, payload []byte) error {
+	if id != codec.MuxHelloStream {
+		return fmt.Errorf("%w: hello on stream %d", ErrProtocol, id)
+	}
+	select {
+	case <-s.negotiated:
+		return fmt.Errorf("%w: unexpected hello", ErrProtocol)
+	default:
+	}
+	remote, err := codec.DecodeProtocol(payload)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrProtocol, err)
+	}
+	return s.negotiate(remote)
+}
+
+// negotiate records the protocol spoken with a remote side announcing remote, the zero Protocol for a peer
+// predating hello frames.
+func (s *Session) negotiate(remote codec.Protocol) error {
+	p, err := codec.Negotiate(s.local(), remote, s.cfg.MinVersion)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrProtocol, err)
+	}
+	s.mu.Lock()
+	s.protocol = p
+	s.mu.Unlock()
+	close(s.negotiated)
+	return nil
+}
+
+// local returns the protocol announced to the remote side.
+func (s *Session) local() codec.Protocol {
+	return codec.Protocol{Version: Version, Capabilities: s.cfg.Capabilities}
+}
+
+// handleOpen registers a stream opened by the remote side.
+func (s *Session) handleOpen(id uint64) error {
+	// Remote streams use the opposite ID parity
+	remoteParity := uint64(0)
+	if s.cfg.Server {
+		remoteParity = 1
+	}
+	if id%2 != remoteParity {
+		return fmt.Errorf("%w: stream %d has wrong parity", ErrProtocol, id)
+	}
+
+	s.mu.Lock()
+	if _, ok := s.streams[id]; ok {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: stream %d opened twice", ErrProtocol, id)
+	}
+	st := newStream(s, id)
+	s.streams[id] = st
+	s.mu.Unlock()
+
+	select {
+	case s.accept <- st:
+	default:
+		s.cfg.Logger.Warn("mux accept backlog full, resetting stream", "stream", id)
+		s.remove(id)
+		return s.writeFrame(typeReset, id, nil)
+	}
+	return nil
+}
+
+// remove forgets a stream.
+func (s *Session) remove(id uint64) {
+	s.mu.Lock()
+	delete(s.streams, id)
+	s.mu.Unlock()
+}
+
+// writeLoop sends queued frames in scheduling order until the session is closed.
+func (s *Session) writeLoop() {
+	// Announce the protocol right away, the remote side may be waiting for it in Protocol
+	s.sendMu.Lock()
+	err := s.sendHello()
+	s.sendMu.Unlock()
+	if err != nil {
+		s.fail(err)
+		return
+	}
+
+	for {
+		st, f, ok := s.sched.pop()
+		if !ok {
+			select {
+			case <-s.sched.ready:
+				continue
+			case <-s.closed:
+				return
+			}
+		}
+
+		err := s.writeFrame(f.typ, st.id, f.payload)
+		if f.done != nil {
+			f.done <- err
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// enqueue queues a frame of st for the write loop and waits until it was sent if wait is set.
+func (s *Session) enqueue(st *Stream, typ codec.MuxFrameType, payload []byte, wait bool) error {
+	f := outFrame{typ: typ, payload: payload}
+	if wait {
+		f.done = make(chan error, 1)
+	}
+	s.sched.push(st, f)
+	if !wait {
+		return nil
+	}
+	select {
+	case err := <-f.done:
+		return err
+	case <-s.closed:
+		return ErrClosed
+	}
+}
+
+// discard drops the queued frames of st, failing their senders with err.
+func (s *Session) discard(st *Stream, err error) {
+	for _, f := range s.sched.drop(st) {
+		if f.done != nil {
+			f.done <- err
+		}
+	}
+}
+
+// writeFrame sends a frame, preceded by the hello frame if it was not sent yet, closing the session if the
+// connection fails.
+func (s *Session) writeFrame(typ codec.MuxFrameType, id uint64, payload []byte) error {
+	select {
+	case <-s.closed:
+		return ErrClosed
+	default:
+	}
+
+	s.sendMu.Lock()
+	err := s.sendHello()
+	if err == nil {
+		err = s.conn.Send(encodeFrame(typ, id, payload))
+	}
+	s.sendMu.Unlock()
+	if err != nil {
+		s.fail(err)
+		return err
+	}
+	return nil
+}
+
+// sendHello sends the hello frame unless it was sent already. The caller must hold sendMu.
+func (s *Session) sendHello() error {
+	if s.helloSent {
+		return nil
+	}
+	s.helloSent = true
+	return s.conn.Send(encodeFrame(typeHello, codec.MuxHelloStream, codec.EncodeProtocol(s.local())))
+}
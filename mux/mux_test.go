@@ -0,0 +1,281 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"pkg.gfire.dev/supernet/codec"
+)
+
+// testTimeout bounds every blocking call of a test
+const testTimeout = 10 * time.Second
+
+// pipeConn is one end of an in-memory message pipe.
+type pipeConn struct {
+	in, out chan []byte
+	// closed is closed when this end is closed, peerClosed when the other end is
+	closed, peerClosed chan struct{}
+	closeOnce          *sync.Once
+}
+
+// pipe returns the two ends of an in-memory message pipe.
+func pipe() (*pipeConn, *pipeConn) {
+	ab, ba := make(chan []byte, 64), make(chan []byte, 64)
+	ca, cb := make(chan struct{}), make(chan struct{})
+	return &pipeConn{in: ba, out: ab, closed: ca, peerClosed: cb, closeOnce: new(sync.Once)},
+		&pipeConn{in: ab, out: ba, closed: cb, peerClosed: ca, closeOnce: new(sync.Once)}
+}
+
+// NextMessage returns the next message, or io.EOF once the other end was closed and all its messages
+// were received.
+func (c *pipeConn) NextMessage() ([]byte, error) {
+	select {
+	case msg := <-c.in:
+		return msg, nil
+	case <-c.closed:
+		return nil, net.ErrClosed
+	case <-c.peerClosed:
+		select {
+		case msg := <-c.in:
+			return msg, nil
+		default:
+			return nil, io.EOF
+		}
+	}
+}
+
+// Send sends a copy of data. Messages sent to a closed end are discarded.
+func (c *pipeConn) Send(data []byte) error {
+	select {
+	case <-c.closed:
+		return net.ErrClosed
+	default:
+	}
+	select {
+	case c.out <- append([]byte(nil), data...):
+	case <-c.closed:
+		return net.ErrClosed
+	case <-c.peerClosed:
+	}
+	return nil
+}
+
+// Close closes this end.
+func (c *pipeConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+// newSessions returns a client and a server session connected over an in-memory pipe.
+func newSessions(t *testing.T, cfg Config) (client, server *Session) {
+	t.Helper()
+	a, b := pipe()
+	client = NewSession(a, cfg)
+	cfg.Server = true
+	server = NewSession(b, cfg)
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server
+}
+
+// openPair opens a stream on client and accepts it on server.
+func openPair(t *testing.T, client, server *Session) (*Stream, *Stream) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	local, err := client.OpenStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The open frame is sent before any data, so sending makes the stream known to the remote side
+	if err := local.Send([]byte("open")); err != nil {
+		t.Fatal(err)
+	}
+	remote, err := server.AcceptStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg, err := remote.NextMessage(); err != nil || string(msg) != "open" {
+		t.Fatalf("first message %q, %v", msg, err)
+	}
+	return local, remote
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	// A small window makes the messages below both fragmented and flow controlled
+	client, server := newSessions(t, Config{Window: 8 << 10, MaxFrame: 1 << 10})
+	local, remote := openPair(t, client, server)
+
+	msgs := [][]byte{
+		[]byte("hello"),
+		{},
+		bytes.Repeat([]byte{'a'}, 3<<10),
+		bytes.Repeat([]byte{'b'}, client.MaxMessage()),
+	}
+	go func() {
+		for range 16 {
+			for _, msg := range msgs {
+				if err := local.Send(msg); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}
+		local.Close()
+	}()
+
+	for range 16 {
+		for _, want := range msgs {
+			got, err := remote.NextMessage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("got %d bytes, want %d", len(got), len(want))
+			}
+		}
+	}
+	if _, err := remote.NextMessage(); err != io.EOF {
+		t.Fatalf("NextMessage after close: %v, want io.EOF", err)
+	}
+}
+
+func TestStreamReadWrite(t *testing.T) {
+	client, server := newSessions(t, Config{})
+	local, remote := openPair(t, client, server)
+
+	data := bytes.Repeat([]byte("0123456789"), 10<<10)
+	go func() {
+		if _, err := local.Write(data); err != nil {
+			t.Error(err)
+		}
+		local.Close()
+	}()
+	got, err := io.ReadAll(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("read %d bytes, want %d", len(got), len(data))
+	}
+}
+
+func TestProtocol(t *testing.T) {
+	a, b := pipe()
+	client := NewSession(a, Config{Capabilities: 0b11})
+	server := NewSession(b, Config{Server: true, Capabilities: 0b101})
+	defer client.Close()
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	want := codec.Protocol{Version: Version, Capabilities: 0b01}
+	for _, s := range []*Session{client, server} {
+		if p, err := s.Protocol(ctx); err != nil || p != want {
+			t.Fatalf("protocol %+v, %v, want %+v", p, err, want)
+		}
+	}
+}
+
+func TestMinVersion(t *testing.T) {
+	a, b := pipe()
+	s := NewSession(a, Config{MinVersion: Version, Logger: slog.New(slog.DiscardHandler)})
+	defer s.Close()
+
+	// A peer predating hello frames starts with an open frame
+	if err := b.Send(codec.EncodeMuxFrame(codec.MuxFrame{Type: codec.MuxOpen, Stream: 2})); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-s.Done():
+	case <-time.After(testTimeout):
+		t.Fatal("session not closed")
+	}
+	if err := s.Err(); !errors.Is(err, ErrProtocol) || !errors.Is(err, codec.ErrIncompatible) {
+		t.Fatalf("session error %v, want ErrProtocol and codec.ErrIncompatible", err)
+	}
+}
+
+func TestMessageTooLarge(t *testing.T) {
+	client, server := newSessions(t, Config{})
+	local, _ := openPair(t, client, server)
+
+	if err := local.Send(make([]byte, client.MaxMessage()+1)); err != ErrMessageTooLarge {
+		t.Fatalf("Send: %v, want ErrMessageTooLarge", err)
+	}
+}
+
+func TestStreamReset(t *testing.T) {
+	client, server := newSessions(t, Config{})
+	local, remote := openPair(t, client, server)
+
+	if err := remote.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := local.NextMessage(); err != ErrStreamReset {
+		t.Fatalf("NextMessage: %v, want ErrStreamReset", err)
+	}
+	if err := local.Send([]byte("late")); err == nil {
+		t.Fatal("Send on a reset stream succeeded")
+	}
+}
+
+func TestConnectionLost(t *testing.T) {
+	a, b := pipe()
+	client := NewSession(a, Config{})
+	server := NewSession(b, Config{Server: true})
+	defer client.Close()
+	defer server.Close()
+	local, remote := openPair(t, client, server)
+
+	b.Close()
+	if _, err := local.NextMessage(); err != ErrClosed {
+		t.Fatalf("NextMessage: %v, want ErrClosed", err)
+	}
+	if _, err := remote.NextMessage(); err != ErrClosed {
+		t.Fatalf("NextMessage: %v, want ErrClosed", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	if _, err := client.AcceptStream(ctx); err != ErrClosed {
+		t.Fatalf("AcceptStream: %v, want ErrClosed", err)
+	}
+	if _, err := client.OpenStream(ctx); err != ErrClosed {
+		t.Fatalf("OpenStream: %v, want ErrClosed", err)
+	}
+}
+
+func TestProtocolViolation(t *testing.T) {
+	for name, frame := range map[string][]byte{
+		"truncated":    {byte(codec.MuxData)},
+		"wrong parity": codec.EncodeMuxFrame(codec.MuxFrame{Type: codec.MuxOpen, Stream: 1}),
+		"hello stream": codec.EncodeMuxFrame(codec.MuxFrame{Type: codec.MuxHello, Stream: 1}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			a, b := pipe()
+			s := NewSession(a, Config{Logger: slog.New(slog.DiscardHandler)})
+			defer s.Close()
+
+			if err := b.Send(frame); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-s.Done():
+			case <-time.After(testTimeout):
+				t.Fatal("session not closed")
+			}
+			if err := s.Err(); !errors.Is(err, ErrProtocol) {
+				t.Fatalf("session error %v, want ErrProtocol", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package mux
+
+import (
+	"sync"
+
+	"pkg.gfire.dev/supernet/codec"
+)
+
+// Priority is the scheduling class of a stream. Queued frames of a higher class are always sent before
+// those of a lower class, so the interactive class suits low-volume, latency-sensitive traffic such as
+// RPC or terminals; a saturating stream in it starves lower classes.
+type Priority int
+
+const (
+	// PriorityInteractive is for latency-sensitive traffic.
+	PriorityInteractive Priority = iota
+	// PriorityDefault is the class of new streams.
+	PriorityDefault
+	// PriorityBulk is for background transfers that should yield to everything else.
+	PriorityBulk
+
+	numPriorities = int(PriorityBulk) + 1
+)
+
+// String returns the name of the class.
+func (p Priority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityDefault:
+		return "default"
+	case PriorityBulk:
+		return "bulk"
+	default:
+		return "unknown"
+	}
+}
+
+// maxWeight caps stream weights so deficits cannot overflow
+const maxWeight = 1 << 10
+
+// outFrame is a frame queued for sending.
+type outFrame struct {
+	typ     codec.MuxFrameType
+	payload []byte
+	// done receives the result once the frame was sent, if set
+	done chan error
+}
+
+// scheduler orders the queued frames of all streams: strictly by class, and within a class by deficit
+// round robin over the streams with queued frames, so each gets bandwidth in proportion to its weight.
+type scheduler struct {
+	// quantum is the number of bytes a weight-1 stream may send per round
+	quantum int
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// active holds the streams with queued frames per class, in round robin order
+	active [numPriorities][]*Stream
+	// ready is signalled when a frame was queued
+	ready chan struct{}
+}
+
+// newScheduler creates a scheduler granting quantum bytes per weight and round.
+func newScheduler(quantum int) *scheduler {
+	return &scheduler{
+		quantum: quantum,
+		ready:   make(chan struct{}, 1),
+	}
+}
+
+// push queues a frame of st.
+func (sc *scheduler) push(st *Stream, f outFrame) {
+	sc.mu.Lock()
+	st.sendQueue.frames = append(st.sendQueue.frames, f)
+	if !st.sendQueue.active {
+		// The deficit is kept while idle so streams sending one message at a time still get their share;
+		// it is bounded by a single round's credit
+		st.sendQueue.active = true
+		sc.active[st.sendQueue.priority] = append(sc.active[st.sendQueue.priority], st)
+	}
+	sc.mu.Unlock()
+
+	select {
+	case sc.ready <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns the next frame to send and the stream it belongs to, or false if nothing is queued.
+func (sc *scheduler) pop() (*Stream, outFrame, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	for p := range sc.active {
+		for len(sc.active[p]) > 0 {
+			st := sc.active[p][0]
+			q := &st.sendQueue
+			f := q.frames[0]
+			if q.deficit < len(f.payload) {
+				// Out of credit for this round: top up and move to the back
+				q.deficit += sc.quantum * q.weight
+				sc.active[p] = append(sc.active[p][1:], st)
+				continue
+			}
+
+			q.deficit -= len(f.payload)
+			q.frames[0] = outFrame{}
+			q.frames = q.frames[1:]
+			if len(q.frames) == 0 {
+				q.frames = nil
+				q.active = false
+				sc.active[p] = sc.active[p][1:]
+			}
+			return st, f, true
+		}
+	}
+	return nil, outFrame{}, false
+}
+
+// drop removes the queued frames of st and returns them.
+func (sc *scheduler) drop(st *Stream) []outFrame {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	frames := st.sendQueue.frames
+	st.sendQueue.frames = nil
+	if st.sendQueue.active {
+		st.sendQueue.active = false
+		sc.remove(st)
+	}
+	return frames
+}
+
+// setPriority moves st to another class and changes its weight.
+func (sc *scheduler) setPriority(st *Stream, p Priority, weight int) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if st.sendQueue.active && st.sendQueue.priority != p {
+		sc.remove(st)
+		sc.active[p] = append(sc.active[p], st)
+	}
+	st.sendQueue.priority = p
+	st.sendQueue.weight = weight
+}
+
+// priority returns the class and weight of st.
+func (sc *scheduler) priority(st *Stream) (Priority, int) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return st.sendQueue.priority, st.sendQueue.weight
+}
+
+// remove takes st out of its class's round robin. Must be called with mu held.
+func (sc *scheduler) remove(st *Stream) {
+	list := sc.active[st.sendQueue.priority]
+	for i, other := range list {
+		if other == st {
+			sc.active[st.sendQueue.priority] = append(list[:i], list[i+1:]...)
+			return
+		}
+	}
+}
+
+// streamQueue is the scheduling state of a stream, protected by the scheduler's mutex.
+type streamQueue struct {
+	frames   []outFrame
+	priority Priority
+	weight   int
+	// active is set while the stream is in its class's round robin
+	active bool
+	// deficit is the number of bytes the stream may still send in the current round
+	deficit int
+}
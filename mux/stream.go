@@ -0,0 +1,277 @@
+package mux
+
+import (
+	"fmt"
+	"sync"
+
+	"pkg.gfire.dev/supernet/codec"
+	"pkg.gfire.dev/supernet/internal/streamstate"
+)
+
+// Stream is a bidirectional, flow-controlled channel within a Session.
+// Close closes the stream in both directions; there is no half-close.
+type Stream struct {
+	id uint64
+	s  *Session
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// queue holds complete received messages not yet consumed
+	queue [][]byte
+	// partial accumulates the fragments of the message being received
+	partial codec.Reassembler
+	// buffered is the number of received bytes not yet consumed
+	buffered int
+	// unacked is the number of consumed bytes not yet granted back to the sender
+	unacked int
+	// sendWindow is the number of bytes that may be sent before a window update is needed
+	sendWindow int
+	// readBuf holds the unread remainder of the current message for Read
+	readBuf []byte
+	// state records Close, the remote close and failures such as a reset or the end of the session, and
+	// wakes blocked readers and writers whenever data arrives, window is granted or the state changes
+	state streamstate.State
+
+	// sendMu keeps the fragments of concurrent messages from interleaving
+	sendMu sync.Mutex
+
+	// sendQueue is the scheduling state, protected by the session's scheduler
+	sendQueue streamQueue
+}
+
+// newStream creates a stream with a full send window.
+func newStream(s *Session, id uint64) *Stream {
+	return &Stream{
+		id:         id,
+		s:          s,
+		sendWindow: s.cfg.Window,
+		sendQueue:  streamQueue{priority: PriorityDefault, weight: 1},
+	}
+}
+
+// ID returns the stream ID, unique within the session.
+func (st *Stream) ID() uint64 {
+	return st.id
+}
+
+// SetPriority sets the scheduling class of data sent on the stream, and its weight relative to other
+// streams of the class (1 to 1024). Weights divide the bandwidth among streams that keep data queued, so
+// they are most effective with large messages or concurrent senders. Each side schedules its own
+// sending direction.
+func (st *Stream) SetPriority(p Priority, weight int) {
+	p = min(max(p, PriorityInteractive), PriorityBulk)
+	weight = min(max(weight, 1), maxWeight)
+	st.s.sched.setPriority(st, p, weight)
+}
+
+// Priority returns the scheduling class and weight of the stream.
+func (st *Stream) Priority() (Priority, int) {
+	return st.s.sched.priority(st)
+}
+
+// NextMessage blocks until the next message is received.
+// Returns io.EOF once the remote side closed the stream and all messages were consumed.
+func (st *Stream) NextMessage() ([]byte, error) {
+	for {
+		st.mu.Lock()
+		if len(st.queue) > 0 {
+			msg := st.queue[0]
+			st.queue[0] = nil
+			st.queue = st.queue[1:]
+			delta := st.consumed(len(msg))
+			st.mu.Unlock()
+
+			if delta > 0 {
+				st.s.writeFrame(typeWindow, st.id, codec.EncodeMuxWindow(uint64(delta)))
+			}
+			return msg, nil
+		}
+		err := st.state.ReadErr(ErrStreamClosed)
+		changed := st.state.Changed()
+		st.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		<-changed
+	}
+}
+
+// Read reads from the current message, moving on to the next message once it is exhausted.
+func (st *Stream) Read(p []byte) (int, error) {
+	for len(st.readBuf) == 0 {
+		msg, err := st.NextMessage()
+		if err != nil {
+			return 0, err
+		}
+		st.readBuf = msg
+	}
+	n := copy(p, st.readBuf)
+	st.readBuf = st.readBuf[n:]
+	return n, nil
+}
+
+// Send sends data as a single message, blocking while the remote receive window is exhausted.
+// Messages larger than Session.MaxMessage are rejected with ErrMessageTooLarge.
+func (st *Stream) Send(data []byte) error {
+	if len(data) > st.s.MaxMessage() {
+		return ErrMessageTooLarge
+	}
+
+	st.sendMu.Lock()
+	defer st.sendMu.Unlock()
+
+	for first := true; first || len(data) > 0; first = false {
+		n, err := st.reserve(len(data))
+		if err != nil {
+			return err
+		}
+
+		// Only the final fragment is waited for; the stream's frames are sent in order
+		typ := typeData
+		if n < len(data) {
+			typ = typeDataMore
+		}
+		if err := st.s.enqueue(st, typ, data[:n], typ == typeData); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// Write sends p as one or more messages of at most the maximum frame size.
+func (st *Stream) Write(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		chunk := p[n:]
+		if len(chunk) > st.s.cfg.MaxFrame {
+			chunk = chunk[:st.s.cfg.MaxFrame]
+		}
+		if err := st.Send(chunk); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+	}
+	return n, nil
+}
+
+// Close closes the stream. Messages already received by the remote side can still be read there,
+// after which it receives io.EOF. Safe to call multiple times.
+func (st *Stream) Close() error {
+	st.mu.Lock()
+	if st.state.LocalClosed || st.state.Err != nil {
+		st.mu.Unlock()
+		return nil
+	}
+	st.state.LocalClosed = true
+	remoteClosed := st.state.RemoteClosed
+	st.queue = nil
+	st.partial.Reset()
+	st.state.Broadcast()
+	st.mu.Unlock()
+
+	if remoteClosed {
+		st.s.remove(st.id)
+		return nil
+	}
+	// The close frame is queued behind the data already sent
+	return st.s.enqueue(st, typeClose, nil, true)
+}
+
+// Reset aborts the stream, discarding data buffered on both sides.
+func (st *Stream) Reset() error {
+	st.s.remove(st.id)
+	st.fail(ErrStreamReset)
+	st.s.discard(st, ErrStreamReset)
+	return st.s.writeFrame(typeReset, st.id, nil)
+}
+
+// reserve waits for send window and takes up to n bytes of it, limited by the maximum frame size.
+// A zero n reserves nothing but still checks the stream state.
+func (st *Stream) reserve(n int) (int, error) {
+	if n > st.s.cfg.MaxFrame {
+		n = st.s.cfg.MaxFrame
+	}
+	for {
+		st.mu.Lock()
+		if err := st.state.WriteErr(ErrStreamClosed); err != nil {
+			st.mu.Unlock()
+			return 0, err
+		}
+		if n == 0 || st.sendWindow > 0 {
+			if n > st.sendWindow {
+				n = st.sendWindow
+			}
+			st.sendWindow -= n
+			st.mu.Unlock()
+			return n, nil
+		}
+		changed := st.state.Changed()
+		st.mu.Unlock()
+		<-changed
+	}
+}
+
+// receive buffers a data frame.
+func (st *Stream) receive(payload []byte, final bool) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.state.LocalClosed || st.state.Err != nil {
+		return nil
+	}
+	if st.buffered+len(payload) > st.s.cfg.Window {
+		return fmt.Errorf("%w: stream %d exceeded receive window", ErrProtocol, st.id)
+	}
+
+	st.buffered += len(payload)
+	// The window bounds the message size, so Add cannot fail
+	if msg, _ := st.partial.Add(payload, final); msg != nil {
+		st.queue = append(st.queue, msg)
+		st.state.Broadcast()
+	}
+	return nil
+}
+
+// consumed accounts for n consumed bytes and returns the window to grant back, if due.
+// Must be called with mu held.
+func (st *Stream) consumed(n int) int {
+	st.buffered -= n
+	st.unacked += n
+	if st.unacked < st.s.cfg.Window/2 {
+		return 0
+	}
+	delta := st.unacked
+	st.unacked = 0
+	return delta
+}
+
+// grant adds send window.
+func (st *Stream) grant(delta uint64) {
+	st.mu.Lock()
+	st.sendWindow += int(delta)
+	st.state.Broadcast()
+	st.mu.Unlock()
+}
+
+// remoteClose marks the stream closed by the remote side.
+func (st *Stream) remoteClose() {
+	st.mu.Lock()
+	st.state.RemoteClosed = true
+	localClosed := st.state.LocalClosed
+	st.state.Broadcast()
+	st.mu.Unlock()
+
+	if localClosed {
+		st.s.remove(st.id)
+	}
+}
+
+// fail terminates the stream with err.
+func (st *Stream) fail(err error) {
+	st.mu.Lock()
+	st.queue = nil
+	st.partial.Reset()
+	st.state.Fail(err)
+	st.mu.Unlock()
+}
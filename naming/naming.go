@@ -0,0 +1,166 @@
+// Package naming maps human-readable names to peer IDs, so applications can dial "peer://alice/ssh"
+// instead of hard-coding keys.
+//
+// Names are bound by signed name records published to a source such as the DHT, or claimed by registering
+// in the name's rendezvous namespace. Published names are first-come claims: by default a record is only
+// accepted when signed by the peer it names, and conflicting claims are reported as ErrAmbiguous rather
+// than guessed. Deployments that need ownership configure naming authorities whose signatures are then
+// required. Local petnames always take precedence over published names.
+package naming
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/p2p"
+	snnaming "pkg.gfire.dev/supernet/proto/snnaming/v1alpha1"
+)
+
+var (
+	// ErrInvalidName is returned for names that are not dot-separated labels of letters, digits and hyphens
+	ErrInvalidName = errors.New("invalid name")
+	// ErrInvalidRecord is returned for name records that are malformed or carry a bad signature
+	ErrInvalidRecord = errors.New("invalid name record")
+	// ErrRecordExpired is returned for name records past their expiry
+	ErrRecordExpired = errors.New("name record expired")
+	// ErrNotFound is returned when no trusted record binds a name
+	ErrNotFound = errors.New("name not found")
+	// ErrAmbiguous is returned when trusted records bind a name to different peers
+	ErrAmbiguous = errors.New("name claimed by several peers")
+)
+
+const (
+	// recordSignaturePrefix separates name record signatures from signatures made for other purposes
+	recordSignaturePrefix = "supernet-name-record:"
+	// keyPrefix derives the DHT key of a name
+	keyPrefix = "supernet-name:"
+	// namespacePrefix derives the rendezvous namespace of a name
+	namespacePrefix = "name/"
+	// maxNameLen is the maximum length of a name, as for DNS names
+	maxNameLen = 253
+	// maxLabelLen is the maximum length of a single label
+	maxLabelLen = 63
+)
+
+// DefaultRecordTTL is the lifetime of records created by NewRecord when no TTL is given.
+const DefaultRecordTTL = 24 * time.Hour
+
+// Record is a verified name record.
+type Record struct {
+	Name    string            // Name being bound
+	ID      identity.ID       // Peer the name resolves to
+	Signer  ed25519.PublicKey // Key that signed the record, the peer itself or a naming authority
+	Seq     uint64            // Sequence number, higher is newer
+	Expires time.Time         // Time after which the record must not be used
+}
+
+// NewRecord creates a name record binding name to target, signed by signer and valid for ttl
+// (DefaultRecordTTL if zero). Peers claim a name by signing a record that targets their own ID.
+func NewRecord(signer *identity.Identity, name string, target identity.ID, ttl time.Duration) ([]byte, error) {
+	name, err := CheckName(name)
+	if err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		ttl = DefaultRecordTTL
+	}
+	now := time.Now()
+	rec, err := (&snnaming.NameRecord{
+		Name:          name,
+		PeerId:        target.Bytes(),
+		Seq:           uint64(now.UnixNano()),
+		ExpiresUnixMs: now.Add(ttl).UnixMilli(),
+	}).MarshalVT()
+	if err != nil {
+		return nil, err
+	}
+	return (&snnaming.SignedNameRecord{
+		Record:    rec,
+		PublicKey: signer.PublicKey(),
+		Signature: signer.Sign(recordMessage(rec)),
+	}).MarshalVT()
+}
+
+// OpenRecord verifies the signature of a name record and returns its contents. Whether the signer is
+// trusted to bind the name is decided by the Resolver.
+func OpenRecord(data []byte) (*Record, error) {
+	signed := &snnaming.SignedNameRecord{}
+	if err := signed.UnmarshalVT(data); err != nil {
+		return nil, ErrInvalidRecord
+	}
+	nr := &snnaming.NameRecord{}
+	if err := nr.UnmarshalVT(signed.Record); err != nil {
+		return nil, ErrInvalidRecord
+	}
+	signer := ed25519.PublicKey(signed.PublicKey)
+	if !identity.Verify(signer, recordMessage(signed.Record), signed.Signature) {
+		return nil, ErrInvalidRecord
+	}
+	id, err := p2p.IDFromBytes(nr.PeerId)
+	if err != nil {
+		return nil, ErrInvalidRecord
+	}
+	name, err := CheckName(nr.Name)
+	if err != nil || name != nr.Name {
+		return nil, ErrInvalidRecord
+	}
+
+	rec := &Record{
+		Name:    name,
+		ID:      id,
+		Signer:  signer,
+		Seq:     nr.Seq,
+		Expires: time.UnixMilli(nr.ExpiresUnixMs),
+	}
+	if time.Now().After(rec.Expires) {
+		return nil, ErrRecordExpired
+	}
+	return rec, nil
+}
+
+// CheckName validates name and returns it in canonical lower-case form.
+func CheckName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if name == "" || len(name) > maxNameLen {
+		return "", fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	for label := range strings.SplitSeq(name, ".") {
+		if !validLabel(label) {
+			return "", fmt.Errorf("%w: %q", ErrInvalidName, name)
+		}
+	}
+	return name, nil
+}
+
+// Key returns the DHT key name records for name are stored under. The name must be canonical.
+func Key(name string) p2p.ID {
+	return p2p.KeyFor([]byte(keyPrefix + name))
+}
+
+// Namespace returns the rendezvous namespace in which peers claim name by registering.
+// The name must be canonical.
+func Namespace(name string) string {
+	return namespacePrefix + name
+}
+
+// validLabel reports whether label is 1-63 letters, digits and inner hyphens.
+func validLabel(label string) bool {
+	if label == "" || len(label) > maxLabelLen || label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for _, c := range []byte(label) {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// recordMessage returns the message signed for an encoded name record.
+func recordMessage(rec []byte) []byte {
+	return append([]byte(recordSignaturePrefix), rec...)
+}
@@ -0,0 +1,212 @@
+package naming
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/p2p"
+)
+
+// Scheme is the URL scheme of overlay addresses, as in "peer://alice/ssh".
+const Scheme = "peer"
+
+// defaultCacheTTL is the default time resolved names are cached
+const defaultCacheTTL = 5 * time.Minute
+
+// Config configures a Resolver.
+type Config struct {
+	// Sources are queried in order until one returns a trusted record.
+	Sources []Source
+	// Authorities are the keys of naming authorities. When set, only records signed by an authority are
+	// trusted and the newest one wins; otherwise records must be signed by the peer they name.
+	Authorities []ed25519.PublicKey
+	// CacheTTL is the time resolved names are cached, at most until their record expires (default 5m).
+	CacheTTL time.Duration
+}
+
+// Resolver resolves names to peer IDs, consulting local petnames first and then the configured sources.
+type Resolver struct {
+	cfg Config
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// petnames are local overrides, taking precedence over published names
+	petnames map[string]identity.ID
+	// cache holds recently resolved names
+	cache map[string]cacheEntry
+}
+
+// cacheEntry is a cached resolution.
+type cacheEntry struct {
+	id      identity.ID
+	expires time.Time
+}
+
+// NewResolver creates a resolver.
+func NewResolver(cfg Config) *Resolver {
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = defaultCacheTTL
+	}
+	return &Resolver{
+		cfg:      cfg,
+		petnames: make(map[string]identity.ID),
+		cache:    make(map[string]cacheEntry),
+	}
+}
+
+// SetPetname binds name to id locally, overriding whatever is published for it.
+func (r *Resolver) SetPetname(name string, id identity.ID) error {
+	name, err := CheckName(name)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.petnames[name] = id
+	return nil
+}
+
+// RemovePetname removes a local binding.
+func (r *Resolver) RemovePetname(name string) {
+	name, err := CheckName(name)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.petnames, name)
+}
+
+// Petnames returns a copy of the local bindings, e.g. to persist them.
+func (r *Resolver) Petnames() map[string]identity.ID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	petnames := make(map[string]identity.ID, len(r.petnames))
+	for name, id := range r.petnames {
+		petnames[name] = id
+	}
+	return petnames
+}
+
+// Resolve returns the peer ID name refers to. A name that parses as a peer ID resolves to itself.
+func (r *Resolver) Resolve(ctx context.Context, name string) (identity.ID, error) {
+	if id, err := p2p.ParseID(name); err == nil {
+		return id, nil
+	}
+	name, err := CheckName(name)
+	if err != nil {
+		return identity.ID{}, err
+	}
+
+	r.mu.Lock()
+	if id, ok := r.petnames[name]; ok {
+		r.mu.Unlock()
+		return id, nil
+	}
+	if e, ok := r.cache[name]; ok && time.Now().Before(e.expires) {
+		r.mu.Unlock()
+		return e.id, nil
+	}
+	r.mu.Unlock()
+
+	for _, src := range r.cfg.Sources {
+		records, err := src.Resolve(ctx, name)
+		if err != nil {
+			return identity.ID{}, err
+		}
+		rec, err := r.choose(name, records)
+		if err != nil {
+			return identity.ID{}, err
+		}
+		if rec == nil {
+			continue
+		}
+
+		expires := time.Now().Add(r.cfg.CacheTTL)
+		if rec.Expires.Before(expires) {
+			expires = rec.Expires
+		}
+		r.mu.Lock()
+		r.cache[name] = cacheEntry{id: rec.ID, expires: expires}
+		r.mu.Unlock()
+		return rec.ID, nil
+	}
+	return identity.ID{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// ResolveURL resolves the name in an overlay URL such as "peer://alice/ssh" and returns the peer ID and
+// the service named by the path, which may be empty.
+func (r *Resolver) ResolveURL(ctx context.Context, rawURL string) (identity.ID, string, error) {
+	name, service, err := ParseURL(rawURL)
+	if err != nil {
+		return identity.ID{}, "", err
+	}
+	id, err := r.Resolve(ctx, name)
+	if err != nil {
+		return identity.ID{}, "", err
+	}
+	return id, service, nil
+}
+
+// Forget drops the cached resolution of name, e.g. after dialing the peer failed.
+func (r *Resolver) Forget(name string) {
+	name, err := CheckName(name)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cache, name)
+}
+
+// choose picks the record name resolves to among records, or nil if none is trusted.
+func (r *Resolver) choose(name string, records []*Record) (*Record, error) {
+	var chosen *Record
+	for _, rec := range records {
+		if rec.Name != name || time.Now().After(rec.Expires) || !r.trusted(rec) {
+			continue
+		}
+		switch {
+		case chosen == nil:
+			chosen = rec
+		case len(r.cfg.Authorities) > 0:
+			// Authorities may reassign names, so the newest binding wins
+			if rec.Seq > chosen.Seq {
+				chosen = rec
+			}
+		case rec.ID != chosen.ID:
+			return nil, fmt.Errorf("%w: %s", ErrAmbiguous, name)
+		}
+	}
+	return chosen, nil
+}
+
+// trusted reports whether the signer of rec may bind its name.
+func (r *Resolver) trusted(rec *Record) bool {
+	if len(r.cfg.Authorities) > 0 {
+		return slices.ContainsFunc(r.cfg.Authorities, func(key ed25519.PublicKey) bool {
+			return bytes.Equal(key, rec.Signer)
+		})
+	}
+	return len(rec.Signer) == ed25519.PublicKeySize && p2p.IDFromPublicKey(rec.Signer) == rec.ID
+}
+
+// ParseURL splits an overlay URL such as "peer://alice/ssh" into the name and the service.
+func ParseURL(rawURL string) (name, service string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Scheme != Scheme || u.Host == "" || u.User != nil || u.Port() != "" {
+		return "", "", fmt.Errorf("%w: not a %s URL: %q", ErrInvalidName, Scheme, rawURL)
+	}
+	return u.Host, strings.Trim(u.Path, "/"), nil
+}
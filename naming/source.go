@@ -0,0 +1,85 @@
+package naming
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pkg.gfire.dev/supernet/p2p"
+	"pkg.gfire.dev/supernet/rendezvous"
+)
+
+// Source looks up published name records.
+type Source interface {
+	// Resolve returns the verified records published for a canonical name. It returns an empty result
+	// rather than an error when nothing is published.
+	Resolve(ctx context.Context, name string) ([]*Record, error)
+}
+
+// DHT publishes and resolves name records through the DHT of a p2p node. The DHT keeps one value per
+// key, so a later publication replaces an earlier one; rely on authorities or petnames where that matters.
+type DHT struct {
+	node *p2p.Node
+}
+
+// NewDHT returns a source backed by the DHT of node.
+func NewDHT(node *p2p.Node) *DHT {
+	return &DHT{node: node}
+}
+
+// Publish stores a signed name record created by NewRecord until it expires.
+// It must be republished before p2p.MaxValueTTL passes.
+func (d *DHT) Publish(ctx context.Context, record []byte) error {
+	rec, err := OpenRecord(record)
+	if err != nil {
+		return err
+	}
+	return d.node.PutValue(ctx, Key(rec.Name), record, time.Until(rec.Expires))
+}
+
+// Resolve returns the record stored for name, if any.
+func (d *DHT) Resolve(ctx context.Context, name string) ([]*Record, error) {
+	value, err := d.node.GetValue(ctx, Key(name))
+	if errors.Is(err, p2p.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rec, err := OpenRecord(value)
+	if err != nil || rec.Name != name {
+		// Invalid or stale values are not a lookup failure, there is just no usable record
+		return nil, nil
+	}
+	return []*Record{rec}, nil
+}
+
+// Rendezvous resolves names claimed by registering in their namespace on a rendezvous server: peers pass
+// Namespace(name) to rendezvous.Connect. Such claims are signed by the claiming peer only.
+type Rendezvous struct {
+	client *rendezvous.Client
+}
+
+// NewRendezvous returns a source backed by a rendezvous server connection.
+func NewRendezvous(client *rendezvous.Client) *Rendezvous {
+	return &Rendezvous{client: client}
+}
+
+// Resolve returns a record for every peer registered in the namespace of name.
+func (r *Rendezvous) Resolve(ctx context.Context, name string) ([]*Record, error) {
+	peers, err := r.client.Discover(ctx, Namespace(name), 0)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*Record, 0, len(peers))
+	for _, p := range peers {
+		records = append(records, &Record{
+			Name:    name,
+			ID:      p.ID,
+			Signer:  p.PublicKey,
+			Seq:     p.Seq,
+			Expires: p.Expires,
+		})
+	}
+	return records, nil
+}
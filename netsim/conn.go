@@ -0,0 +1,228 @@
+package netsim
+
+import (
+	"sync"
+	"time"
+
+	"pkg.gfire.dev/supernet/msgconn"
+)
+
+// Conn is a connection wrapped by a Link. It follows the NextMessage/Send/Close contract of the connection
+// it wraps and delivers messages in order, unless they are lost.
+type Conn struct {
+	l     *Link
+	inner msgconn.Conn
+
+	// out and in hold the transmission state of both directions, guarded by the mutex of the link
+	out, in direction
+
+	// sendMu keeps messages in the order they were scheduled and guards closing outq
+	sendMu sync.Mutex
+	// outq holds the messages waiting to be sent to inner
+	outq chan message
+	// inq holds the messages received from inner waiting for NextMessage
+	inq chan message
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// err is the reason the connection was closed
+	err error
+	// recvErr is the error that ended receiving from inner, returned once the messages before it were read
+	recvErr error
+
+	// closed is closed by Close or a disconnect, failing further calls
+	closed    chan struct{}
+	closeOnce sync.Once
+	// cut is closed by a disconnect, discarding the messages in flight
+	cut     chan struct{}
+	cutOnce sync.Once
+}
+
+// direction is the transmission state of one direction of a connection.
+type direction struct {
+	// busy is the time the link finishes transmitting the messages queued so far
+	busy time.Time
+	// last is the delivery time of the latest message, which later messages may not precede
+	last time.Time
+}
+
+// message is a message in flight.
+type message struct {
+	data []byte
+	// err ends the incoming messages when set
+	err error
+	// at is the time the message is delivered
+	at time.Time
+}
+
+// newConn wraps inner and starts moving its messages.
+func newConn(l *Link, inner msgconn.Conn) *Conn {
+	c := &Conn{
+		l:      l,
+		inner:  inner,
+		outq:   make(chan message, l.cfg.Queue),
+		inq:    make(chan message, l.cfg.Queue),
+		closed: make(chan struct{}),
+		cut:    make(chan struct{}),
+	}
+	go c.sendLoop()
+	go c.recvLoop()
+	return c
+}
+
+// NextMessage blocks until the next message is delivered or the connection is closed or cut.
+func (c *Conn) NextMessage() ([]byte, error) {
+	c.mu.Lock()
+	err := c.recvErr
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var m message
+	select {
+	case <-c.closed:
+		return nil, c.reason()
+	default:
+	}
+	select {
+	case m = <-c.inq:
+	case <-c.closed:
+		return nil, c.reason()
+	}
+	if !sleep(m.at, c.closed) {
+		return nil, c.reason()
+	}
+
+	if m.err != nil {
+		c.mu.Lock()
+		c.recvErr = m.err
+		c.mu.Unlock()
+		return nil, m.err
+	}
+	return m.data, nil
+}
+
+// Send queues a copy of data for delivery under the current conditions, blocking while Config.Queue
+// messages are in flight. Lost messages are reported as sent.
+func (c *Conn) Send(data []byte) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	select {
+	case <-c.closed:
+		return c.reason()
+	default:
+	}
+
+	at, lost := c.l.schedule(&c.out, len(data))
+	if lost {
+		return nil
+	}
+	select {
+	case c.outq <- message{data: append([]byte(nil), data...), at: at}:
+		return nil
+	case <-c.closed:
+		return c.reason()
+	}
+}
+
+// Close closes the connection. Messages already sent are still delivered before the wrapped connection
+// is closed.
+func (c *Conn) Close() error {
+	c.shutdown(ErrClosed)
+	return nil
+}
+
+// fail closes the connection with err and closes the wrapped connection right away, discarding the
+// messages in flight.
+func (c *Conn) fail(err error) {
+	c.shutdown(err)
+	c.cutOnce.Do(func() {
+		close(c.cut)
+		c.inner.Close()
+	})
+}
+
+// shutdown fails further calls with err and lets the send loop drain. Only the first call has an effect.
+func (c *Conn) shutdown(err error) {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		close(c.closed)
+
+		// Send releases sendMu once closed is closed
+		c.sendMu.Lock()
+		close(c.outq)
+		c.sendMu.Unlock()
+		c.l.remove(c)
+	})
+}
+
+// reason returns the reason the connection was closed.
+func (c *Conn) reason() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// sendLoop sends queued messages to inner at their delivery times, then closes inner once the connection
+// was closed and the queue is drained.
+func (c *Conn) sendLoop() {
+	for m := range c.outq {
+		if !sleep(m.at, c.cut) {
+			return
+		}
+		if err := c.inner.Send(m.data); err != nil {
+			c.fail(err)
+			return
+		}
+	}
+	c.inner.Close()
+}
+
+// recvLoop schedules the messages received from inner until it fails.
+func (c *Conn) recvLoop() {
+	for {
+		data, err := c.inner.NextMessage()
+		var m message
+		if err != nil {
+			// The error follows the messages in flight
+			c.l.mu.Lock()
+			m = message{err: err, at: c.in.last}
+			c.l.mu.Unlock()
+		} else {
+			at, lost := c.l.schedule(&c.in, len(data))
+			if lost {
+				continue
+			}
+			m = message{data: data, at: at}
+		}
+
+		select {
+		case c.inq <- m:
+		case <-c.closed:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// sleep waits until at and reports whether it did before done was closed.
+func sleep(at time.Time, done <-chan struct{}) bool {
+	wait := time.Until(at)
+	if wait <= 0 {
+		return true
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-done:
+		return false
+	}
+}
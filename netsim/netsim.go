@@ -0,0 +1,251 @@
+// Package netsim degrades message connections for tests: it adds latency and jitter, caps bandwidth, drops
+// messages and cuts connections, either under the control of the test or following a Scenario, so reconnect
+// logic, keepalives and reliability layers such as outbox or arq can be exercised with go test.
+//
+// A Link is the simulated network path. It wraps any connection following the NextMessage/Send/Close
+// contract, in both directions, and wraps p2p dialers and transports so every connection they establish
+// shares the same conditions:
+//
+//	link := netsim.NewLink(netsim.Config{Seed: 1, Scenario: netsim.MustParse(`
+//		0s   latency=40ms jitter=10ms bandwidth=256KiB
+//		2s   loss=20%
+//		4s   disconnect loss=0
+//	`)})
+//	defer link.Close()
+//	a, b := memtransport.Pipe(memtransport.Config{})
+//	conn := link.Wrap(a)
+//
+// Random decisions come from a generator seeded with Config.Seed, so a test sending the same messages
+// loses the same ones on every run.
+package netsim
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"pkg.gfire.dev/supernet/msgconn"
+	"pkg.gfire.dev/supernet/p2p"
+)
+
+var (
+	// ErrDisconnected is returned by connections cut by Link.Disconnect or a scenario step
+	ErrDisconnected = errors.New("netsim: disconnected")
+	// ErrClosed is returned when using a connection that has been closed locally
+	ErrClosed = errors.New("netsim: connection closed")
+)
+
+// defaultQueue is the default number of messages in flight per direction
+const defaultQueue = 256
+
+// Conditions describe the network path of a Link. The zero value is a perfect network.
+type Conditions struct {
+	// Latency delays the delivery of every message.
+	Latency time.Duration
+	// Jitter adds a random delay of up to this duration to the latency. Messages are still delivered in
+	// order.
+	Jitter time.Duration
+	// Bandwidth caps each direction of a connection to this many bytes per second, 0 for no cap. Messages
+	// queue behind each other like on a real link.
+	Bandwidth int
+	// Loss is the probability of dropping a message, from 0 to 1.
+	Loss float64
+}
+
+// Config configures a Link.
+type Config struct {
+	// Conditions apply from the start, until changed by Link.Set or the scenario.
+	Conditions Conditions
+	// Scenario changes the conditions over time, starting when the link is created.
+	Scenario Scenario
+	// Seed seeds the generator deciding which messages are lost and how they are jittered.
+	Seed uint64
+	// Queue is the number of messages in flight per direction of a connection before Send blocks (default 256).
+	Queue int
+}
+
+// Link is a simulated network path shared by the connections it wraps.
+type Link struct {
+	cfg Config
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// cond holds the current conditions
+	cond Conditions
+	// rng decides losses and jitter
+	rng *rand.Rand
+	// conns holds the open wrapped connections
+	conns map[*Conn]struct{}
+
+	// cancel stops the scenario
+	cancel context.CancelFunc
+	// done is closed when the scenario has ended
+	done chan struct{}
+}
+
+// NewLink creates a link and starts its scenario. Close stops the scenario.
+func NewLink(cfg Config) *Link {
+	if cfg.Queue <= 0 {
+		cfg.Queue = defaultQueue
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &Link{
+		cfg:    cfg,
+		cond:   cfg.Conditions,
+		rng:    rand.New(rand.NewPCG(cfg.Seed, cfg.Seed)),
+		conns:  make(map[*Conn]struct{}),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go l.run(ctx)
+	return l
+}
+
+// Conditions returns the current conditions.
+func (l *Link) Conditions() Conditions {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cond
+}
+
+// Set changes the conditions. Messages already in flight keep the conditions they were sent with.
+func (l *Link) Set(c Conditions) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cond = c
+}
+
+// Disconnect cuts all open connections of the link: they are closed, and their pending and later calls
+// fail with ErrDisconnected. Connections wrapped afterwards are not affected.
+func (l *Link) Disconnect() {
+	l.mu.Lock()
+	conns := l.conns
+	l.conns = make(map[*Conn]struct{})
+	l.mu.Unlock()
+
+	for c := range conns {
+		c.fail(ErrDisconnected)
+	}
+}
+
+// Wrap returns conn with the conditions of the link applied to both directions. The returned connection
+// owns conn and closes it on Close.
+func (l *Link) Wrap(conn msgconn.Conn) *Conn {
+	c := newConn(l, conn)
+	l.mu.Lock()
+	l.conns[c] = struct{}{}
+	l.mu.Unlock()
+	return c
+}
+
+// Dial returns a p2p.DialFunc wrapping the connections established by dial.
+func (l *Link) Dial(dial p2p.DialFunc) p2p.DialFunc {
+	return func(ctx context.Context, addr string) (p2p.Conn, error) {
+		conn, err := dial(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		return l.Wrap(conn), nil
+	}
+}
+
+// Transport returns a p2p.Transport wrapping the connections established by t. Signaling goes through the
+// overlay and is not affected.
+func (l *Link) Transport(t p2p.Transport) p2p.Transport {
+	return transport{l: l, t: t}
+}
+
+// Close stops the scenario and leaves the conditions as they are. Connections stay open.
+func (l *Link) Close() error {
+	l.cancel()
+	<-l.done
+	return nil
+}
+
+// run applies the steps of the scenario at their offsets.
+func (l *Link) run(ctx context.Context) {
+	defer close(l.done)
+
+	start := time.Now()
+	for _, step := range l.cfg.Scenario {
+		if wait := time.Until(start.Add(step.At)); wait > 0 {
+			t := time.NewTimer(wait)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return
+			}
+		}
+		if step.Disconnect {
+			l.Disconnect()
+		}
+		l.Set(step.Conditions)
+	}
+}
+
+// remove forgets a closed connection.
+func (l *Link) remove(c *Conn) {
+	l.mu.Lock()
+	delete(l.conns, c)
+	l.mu.Unlock()
+}
+
+// schedule decides the fate of a message of size bytes entering d now: whether it is lost, and otherwise
+// when it is delivered.
+func (l *Link) schedule(d *direction, size int) (at time.Time, lost bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c := l.cond
+	if c.Loss > 0 && l.rng.Float64() < c.Loss {
+		return time.Time{}, true
+	}
+
+	now := time.Now()
+	// The message is transmitted after the messages ahead of it, then travels for the latency
+	sent := now
+	if c.Bandwidth > 0 {
+		if d.busy.After(sent) {
+			sent = d.busy
+		}
+		sent = sent.Add(time.Duration(float64(size) / float64(c.Bandwidth) * float64(time.Second)))
+		d.busy = sent
+	}
+	delay := c.Latency
+	if c.Jitter > 0 {
+		delay += time.Duration(l.rng.Int64N(int64(c.Jitter)))
+	}
+	at = sent.Add(delay)
+	if at.Before(d.last) {
+		at = d.last
+	}
+	d.last = at
+	return at, false
+}
+
+// transport implements p2p.Transport by wrapping the connections of another transport.
+type transport struct {
+	l *Link
+	t p2p.Transport
+}
+
+// Connect implements p2p.Transport.
+func (t transport) Connect(ctx context.Context, exchange func(ctx context.Context, offer []byte) ([]byte, error)) (p2p.Conn, error) {
+	conn, err := t.t.Connect(ctx, exchange)
+	if err != nil {
+		return nil, err
+	}
+	return t.l.Wrap(conn), nil
+}
+
+// Accept implements p2p.Transport.
+func (t transport) Accept(ctx context.Context, offer []byte, reply func(answer []byte) error) (p2p.Conn, error) {
+	conn, err := t.t.Accept(ctx, offer, reply)
+	if err != nil {
+		return nil, err
+	}
+	return t.l.Wrap(conn), nil
+}
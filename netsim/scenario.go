@@ -0,0 +1,157 @@
+package netsim
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrSyntax is returned when parsing a malformed scenario script
+var ErrSyntax = errors.New("netsim: invalid scenario")
+
+// Step changes the conditions of a link at an offset from its creation.
+type Step struct {
+	// At is the offset from the creation of the link.
+	At time.Duration
+	// Disconnect cuts the connections open at At, see Link.Disconnect.
+	Disconnect bool
+	// Conditions apply from At on.
+	Conditions Conditions
+}
+
+// Scenario is a sequence of steps ordered by their offsets.
+type Scenario []Step
+
+// bandwidthUnits are the units of bandwidths in scenario scripts, in bytes per second
+var bandwidthUnits = []struct {
+	suffix string
+	scale  float64
+}{
+	{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10},
+	{"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3}, {"B", 1},
+}
+
+// Parse parses a scenario script. Every line is a step: an offset in the format of time.ParseDuration,
+// optionally "disconnect", and the conditions that change with key=value fields; the other conditions
+// are carried over from the previous step:
+//
+//	# offset  changes
+//	0s        latency=50ms jitter=10ms
+//	2s        bandwidth=64KiB loss=5%
+//	5s        disconnect
+//	6s        loss=0 bandwidth=0
+//
+// Keys are latency and jitter (durations), bandwidth (bytes per second with an optional B, KB, KiB, MB,
+// MiB, GB or GiB unit, 0 for unlimited) and loss (a fraction or a percentage). Empty lines and text after
+// # are ignored, and offsets must not decrease.
+func Parse(script string) (Scenario, error) {
+	var (
+		s    Scenario
+		cond Conditions
+		at   time.Duration
+	)
+	sc := bufio.NewScanner(strings.NewReader(script))
+	for line := 1; sc.Scan(); line++ {
+		text, _, _ := strings.Cut(sc.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+
+		offset, err := time.ParseDuration(fields[0])
+		if err != nil || offset < at {
+			return nil, fmt.Errorf("%w: line %d: bad offset %q", ErrSyntax, line, fields[0])
+		}
+		at = offset
+
+		step := Step{At: at}
+		for _, f := range fields[1:] {
+			if f == "disconnect" {
+				step.Disconnect = true
+				continue
+			}
+			if err := cond.set(f); err != nil {
+				return nil, fmt.Errorf("%w: line %d: %w", ErrSyntax, line, err)
+			}
+		}
+		step.Conditions = cond
+		s = append(s, step)
+	}
+	return s, nil
+}
+
+// MustParse is Parse panicking on malformed scripts, for scripts in tests.
+func MustParse(script string) Scenario {
+	s, err := Parse(script)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// set changes the condition named by a key=value field.
+func (c *Conditions) set(field string) error {
+	key, value, ok := strings.Cut(field, "=")
+	if !ok {
+		return fmt.Errorf("bad field %q", field)
+	}
+
+	var err error
+	switch key {
+	case "latency":
+		c.Latency, err = parseDuration(value)
+	case "jitter":
+		c.Jitter, err = parseDuration(value)
+	case "bandwidth":
+		c.Bandwidth, err = parseBandwidth(value)
+	case "loss":
+		c.Loss, err = parseLoss(value)
+	default:
+		return fmt.Errorf("unknown key %q", key)
+	}
+	if err != nil {
+		return fmt.Errorf("bad %s %q", key, value)
+	}
+	return nil
+}
+
+// parseDuration parses a non-negative duration.
+func parseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, ErrSyntax
+	}
+	return d, nil
+}
+
+// parseBandwidth parses a number of bytes per second with an optional unit.
+func parseBandwidth(s string) (int, error) {
+	scale := 1.0
+	for _, u := range bandwidthUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			s, scale = strings.TrimSuffix(s, u.suffix), u.scale
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, ErrSyntax
+	}
+	return int(v * scale), nil
+}
+
+// parseLoss parses a probability given as a fraction or a percentage.
+func parseLoss(s string) (float64, error) {
+	scale := 1.0
+	if p, ok := strings.CutSuffix(s, "%"); ok {
+		s, scale = p, 0.01
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 || v*scale > 1 {
+		return 0, ErrSyntax
+	}
+	return v * scale, nil
+}
@@ -0,0 +1,261 @@
+package noise
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+var (
+	// ErrPeerRejected is returned when Config.VerifyPeer rejects the remote static key or payload
+	ErrPeerRejected = errors.New("noise: peer rejected")
+	// ErrFrameTooLarge is returned when a received frame exceeds the Noise message size limit
+	ErrFrameTooLarge = errors.New("noise: frame too large")
+)
+
+const (
+	// MaxMessageSize is the maximum size of a Noise message on the wire
+	MaxMessageSize = 65535
+	// maxPlaintextSize is the largest plaintext carried by a single transport message
+	maxPlaintextSize = MaxMessageSize - tagLen
+)
+
+// GenerateKey generates a new X25519 static key.
+func GenerateKey() (*ecdh.PrivateKey, error) {
+	return ecdh.X25519().GenerateKey(rand.Reader)
+}
+
+// Config configures a Noise handshake.
+type Config struct {
+	// Pattern is the handshake pattern; both sides must use the same pattern.
+	Pattern Pattern
+	// StaticKey is the local X25519 static key.
+	StaticKey *ecdh.PrivateKey
+	// RemoteStatic is the responder's static public key, required by IK initiators.
+	RemoteStatic []byte
+	// Prologue is data both sides must agree on, e.g. a protocol identifier; it is authenticated by the handshake.
+	Prologue []byte
+	// Payload is sent to the peer encrypted during the handshake, e.g. an identity key signature
+	// binding the static key to a peer ID.
+	Payload []byte
+	// VerifyPeer is called with the remote static key and payload before the handshake completes.
+	// Returning an error aborts the handshake with ErrPeerRejected.
+	VerifyPeer func(remoteStatic, payload []byte) error
+}
+
+// Conn is an encrypted, mutually authenticated stream established by a Noise handshake.
+// Data is carried in messages framed with a 2-byte big-endian length prefix.
+type Conn struct {
+	rwc io.ReadWriteCloser
+
+	remoteStatic  []byte
+	remotePayload []byte
+	handshakeHash []byte
+
+	readMu        sync.Mutex
+	recv          *cipherState
+	currentBuffer []byte // Remaining plaintext from the last message that didn't fit in the read buffer
+	frame         []byte
+
+	writeMu sync.Mutex
+	send    *cipherState
+	out     []byte
+}
+
+// Client runs the handshake as initiator over rwc.
+// If ctx is cancelled before the handshake completes, rwc is closed.
+func Client(ctx context.Context, rwc io.ReadWriteCloser, cfg Config) (*Conn, error) {
+	return handshake(ctx, rwc, cfg, true)
+}
+
+// Server runs the handshake as responder over rwc.
+// If ctx is cancelled before the handshake completes, rwc is closed.
+func Server(ctx context.Context, rwc io.ReadWriteCloser, cfg Config) (*Conn, error) {
+	return handshake(ctx, rwc, cfg, false)
+}
+
+// handshake runs the handshake for either role and returns the transport connection.
+func handshake(ctx context.Context, rwc io.ReadWriteCloser, cfg Config, initiator bool) (*Conn, error) {
+	hs, err := newHandshakeState(cfg.Pattern, initiator, cfg.Prologue, cfg.StaticKey, cfg.RemoteStatic)
+	if err != nil {
+		return nil, err
+	}
+
+	// Abort blocking reads and writes by closing rwc when ctx is cancelled
+	stop := context.AfterFunc(ctx, func() { rwc.Close() })
+	defer stop()
+
+	c := &Conn{rwc: rwc}
+	for !hs.done() {
+		if hs.writing() {
+			// Our payload goes into the first message where it is encrypted: IK message 1, XX messages 2 and 3
+			var payload []byte
+			if hs.encryptsPayload() {
+				payload = cfg.Payload
+			}
+			msg, err := hs.writeMessage(payload)
+			if err != nil {
+				return nil, err
+			}
+			if err := c.writeFrame(msg); err != nil {
+				return nil, ctxErr(ctx, err)
+			}
+			continue
+		}
+
+		msg, err := c.readFrame()
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+		payload, err := hs.readMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		if len(payload) > 0 {
+			c.remotePayload = payload
+		}
+		// Verify the peer as soon as its static key is known, before revealing anything further
+		if hs.rs != nil && c.remoteStatic == nil {
+			c.remoteStatic = hs.rs
+			if cfg.VerifyPeer != nil {
+				if err := cfg.VerifyPeer(c.remoteStatic, c.remotePayload); err != nil {
+					return nil, errors.Join(ErrPeerRejected, err)
+				}
+			}
+		}
+	}
+
+	c.handshakeHash = append([]byte(nil), hs.ss.h[:]...)
+	c.send, c.recv = hs.split()
+	return c, nil
+}
+
+// RemoteStatic returns the remote peer's static X25519 public key.
+func (c *Conn) RemoteStatic() []byte {
+	return c.remoteStatic
+}
+
+// RemotePayload returns the payload the remote peer sent during the handshake.
+func (c *Conn) RemotePayload() []byte {
+	return c.remotePayload
+}
+
+// HandshakeHash returns the final handshake hash, a unique channel binding value for the session.
+func (c *Conn) HandshakeHash() []byte {
+	return c.handshakeHash
+}
+
+// Read decrypts the next message from the underlying stream into p.
+func (c *Conn) Read(p []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	for len(c.currentBuffer) == 0 {
+		frame, err := c.readFrame()
+		if err != nil {
+			return 0, err
+		}
+		plaintext, err := c.recv.decryptWithAd(frame[:0], nil, frame)
+		if err != nil {
+			return 0, err
+		}
+		c.currentBuffer = plaintext
+	}
+
+	n := copy(p, c.currentBuffer)
+	c.currentBuffer = c.currentBuffer[n:]
+	return n, nil
+}
+
+// Write encrypts p into one or more messages and writes them to the underlying stream.
+func (c *Conn) Write(p []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	written := 0
+	for written < len(p) {
+		chunk := p[written:]
+		if len(chunk) > maxPlaintextSize {
+			chunk = chunk[:maxPlaintextSize]
+		}
+
+		// Reserve the length prefix, encrypt after it, then fill in the length
+		out := append(c.out[:0], 0, 0)
+		out, err := c.send.encryptWithAd(out, nil, chunk)
+		if err != nil {
+			return written, err
+		}
+		binary.BigEndian.PutUint16(out, uint16(len(out)-2))
+		c.out = out
+
+		if _, err := c.rwc.Write(out); err != nil {
+			return written, err
+		}
+		written += len(chunk)
+	}
+	return written, nil
+}
+
+// Rekey replaces the sending key; the peer must call RekeyRecv at the same position in the stream.
+// Applications coordinate rekeying through their own protocol.
+func (c *Conn) Rekey() {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.send.rekey()
+}
+
+// RekeyRecv replaces the receiving key to match a Rekey performed by the peer.
+func (c *Conn) RekeyRecv() {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	c.recv.rekey()
+}
+
+// Close closes the underlying stream.
+func (c *Conn) Close() error {
+	return c.rwc.Close()
+}
+
+// writeFrame writes msg with a 2-byte length prefix in a single write.
+func (c *Conn) writeFrame(msg []byte) error {
+	if len(msg) > MaxMessageSize {
+		return ErrFrameTooLarge
+	}
+	frame := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(frame, uint16(len(msg)))
+	copy(frame[2:], msg)
+	_, err := c.rwc.Write(frame)
+	return err
+}
+
+// readFrame reads a length-prefixed message, reusing the connection's frame buffer.
+func (c *Conn) readFrame() ([]byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.rwc, header[:]); err != nil {
+		return nil, err
+	}
+	size := int(binary.BigEndian.Uint16(header[:]))
+	if cap(c.frame) < size {
+		c.frame = make([]byte, size)
+	}
+	frame := c.frame[:size]
+	if _, err := io.ReadFull(c.rwc, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return frame, nil
+}
+
+// ctxErr prefers the context error when the failure was caused by cancellation.
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
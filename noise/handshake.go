@@ -0,0 +1,255 @@
+package noise
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+)
+
+var (
+	// ErrMissingStaticKey is returned when a handshake requires a local static key that was not configured
+	ErrMissingStaticKey = errors.New("noise: missing local static key")
+	// ErrMissingRemoteStatic is returned when an IK initiator does not know the responder's static key
+	ErrMissingRemoteStatic = errors.New("noise: IK requires the remote static key")
+	// ErrUnknownPattern is returned for unsupported handshake patterns
+	ErrUnknownPattern = errors.New("noise: unknown handshake pattern")
+)
+
+// Pattern selects the Noise handshake pattern.
+type Pattern int
+
+const (
+	// XX mutually transmits static keys in three messages; neither side needs prior knowledge of the other.
+	XX Pattern = iota
+	// IK completes in two messages (0-RTT payload) when the initiator already knows the responder's static key.
+	IK
+)
+
+// String returns the Noise name of the pattern.
+func (p Pattern) String() string {
+	switch p {
+	case XX:
+		return "XX"
+	case IK:
+		return "IK"
+	default:
+		return "unknown"
+	}
+}
+
+// token is a handshake message token.
+type token int
+
+const (
+	tokE token = iota
+	tokS
+	tokEE
+	tokES
+	tokSE
+	tokSS
+)
+
+// messagePatterns returns the handshake message token sequences of a pattern, alternating initiator and responder.
+func messagePatterns(p Pattern) ([][]token, error) {
+	switch p {
+	case XX:
+		return [][]token{
+			{tokE},
+			{tokE, tokEE, tokS, tokES},
+			{tokS, tokSE},
+		}, nil
+	case IK:
+		return [][]token{
+			{tokE, tokES, tokS, tokSS},
+			{tokE, tokEE, tokSE},
+		}, nil
+	default:
+		return nil, ErrUnknownPattern
+	}
+}
+
+// handshakeState runs a Noise handshake (Noise section 5.3).
+type handshakeState struct {
+	ss        symmetricState
+	initiator bool
+	patterns  [][]token
+	step      int
+
+	s  *ecdh.PrivateKey // local static key
+	e  *ecdh.PrivateKey // local ephemeral key
+	rs []byte           // remote static public key
+	re []byte           // remote ephemeral public key
+}
+
+// newHandshakeState initializes a handshake for the given pattern and role.
+func newHandshakeState(pattern Pattern, initiator bool, prologue []byte, s *ecdh.PrivateKey, rs []byte) (*handshakeState, error) {
+	patterns, err := messagePatterns(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, ErrMissingStaticKey
+	}
+
+	hs := &handshakeState{
+		initiator: initiator,
+		patterns:  patterns,
+		s:         s,
+		rs:        rs,
+	}
+	hs.ss.initializeSymmetric("Noise_" + pattern.String() + "_25519_ChaChaPoly_SHA256")
+	hs.ss.mixHash(prologue)
+
+	// IK has the responder's static key as pre-message
+	if pattern == IK {
+		if initiator {
+			if len(rs) != dhLen {
+				return nil, ErrMissingRemoteStatic
+			}
+			hs.ss.mixHash(rs)
+		} else {
+			hs.ss.mixHash(s.PublicKey().Bytes())
+		}
+	}
+	return hs, nil
+}
+
+// done reports whether all handshake messages have been processed.
+func (hs *handshakeState) done() bool {
+	return hs.step >= len(hs.patterns)
+}
+
+// writing reports whether the local side sends the next handshake message.
+func (hs *handshakeState) writing() bool {
+	return (hs.step%2 == 0) == hs.initiator
+}
+
+// encryptsPayload reports whether the payload of the next message will be encrypted,
+// i.e. a key is already established or the message performs a Diffie-Hellman operation.
+func (hs *handshakeState) encryptsPayload() bool {
+	if hs.ss.cs.hasKey() {
+		return true
+	}
+	for _, tok := range hs.patterns[hs.step] {
+		if tok != tokE && tok != tokS {
+			return true
+		}
+	}
+	return false
+}
+
+// writeMessage produces the next handshake message carrying payload.
+func (hs *handshakeState) writeMessage(payload []byte) ([]byte, error) {
+	var out []byte
+	var err error
+
+	for _, tok := range hs.patterns[hs.step] {
+		switch tok {
+		case tokE:
+			hs.e, err = ecdh.X25519().GenerateKey(rand.Reader)
+			if err != nil {
+				return nil, err
+			}
+			pub := hs.e.PublicKey().Bytes()
+			out = append(out, pub...)
+			hs.ss.mixHash(pub)
+		case tokS:
+			out, err = hs.ss.encryptAndHash(out, hs.s.PublicKey().Bytes())
+			if err != nil {
+				return nil, err
+			}
+		default:
+			if err := hs.mixDH(tok); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	out, err = hs.ss.encryptAndHash(out, payload)
+	if err != nil {
+		return nil, err
+	}
+	hs.step++
+	return out, nil
+}
+
+// readMessage consumes the next handshake message and returns its payload.
+func (hs *handshakeState) readMessage(msg []byte) ([]byte, error) {
+	for _, tok := range hs.patterns[hs.step] {
+		switch tok {
+		case tokE:
+			if len(msg) < dhLen {
+				return nil, ErrShortMessage
+			}
+			hs.re = append([]byte(nil), msg[:dhLen]...)
+			msg = msg[dhLen:]
+			hs.ss.mixHash(hs.re)
+		case tokS:
+			n := dhLen
+			if hs.ss.cs.hasKey() {
+				n += tagLen
+			}
+			if len(msg) < n {
+				return nil, ErrShortMessage
+			}
+			rs, err := hs.ss.decryptAndHash(nil, msg[:n])
+			if err != nil {
+				return nil, err
+			}
+			hs.rs = rs
+			msg = msg[n:]
+		default:
+			if err := hs.mixDH(tok); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	payload, err := hs.ss.decryptAndHash(nil, msg)
+	if err != nil {
+		return nil, err
+	}
+	hs.step++
+	return payload, nil
+}
+
+// mixDH performs the Diffie-Hellman operation of a token from the local role's perspective.
+func (hs *handshakeState) mixDH(tok token) error {
+	var priv *ecdh.PrivateKey
+	var pub []byte
+
+	switch tok {
+	case tokEE:
+		priv, pub = hs.e, hs.re
+	case tokES:
+		if hs.initiator {
+			priv, pub = hs.e, hs.rs
+		} else {
+			priv, pub = hs.s, hs.re
+		}
+	case tokSE:
+		if hs.initiator {
+			priv, pub = hs.s, hs.re
+		} else {
+			priv, pub = hs.e, hs.rs
+		}
+	case tokSS:
+		priv, pub = hs.s, hs.rs
+	}
+
+	secret, err := dh(priv, pub)
+	if err != nil {
+		return err
+	}
+	hs.ss.mixKey(secret)
+	return nil
+}
+
+// split returns the send and receive cipher states for the local role.
+func (hs *handshakeState) split() (send, recv *cipherState) {
+	c1, c2 := hs.ss.split()
+	if hs.initiator {
+		return c1, c2
+	}
+	return c2, c1
+}
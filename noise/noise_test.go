@@ -0,0 +1,206 @@
+package noise
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// testTimeout bounds every handshake of a test
+const testTimeout = 10 * time.Second
+
+// generateKey returns a new static key or fails t.
+func generateKey(t *testing.T) *ecdh.PrivateKey {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// handshakePair runs the handshake over an in-memory pipe and returns both ends with their errors.
+func handshakePair(t *testing.T, clientCfg, serverCfg Config) (client, server *Conn, clientErr, serverErr error) {
+	t.Helper()
+	ca, cb := net.Pipe()
+	t.Cleanup(func() {
+		ca.Close()
+		cb.Close()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server, serverErr = Server(ctx, cb, serverCfg)
+		if serverErr != nil {
+			cb.Close()
+		}
+	}()
+	client, clientErr = Client(ctx, ca, clientCfg)
+	if clientErr != nil {
+		ca.Close()
+	}
+	<-done
+	return client, server, clientErr, serverErr
+}
+
+func TestHandshake(t *testing.T) {
+	clientKey, serverKey := generateKey(t), generateKey(t)
+	for _, pattern := range []Pattern{XX, IK} {
+		t.Run(pattern.String(), func(t *testing.T) {
+			var verified []byte
+			client, server, err1, err2 := handshakePair(t,
+				Config{Pattern: pattern, StaticKey: clientKey, RemoteStatic: serverKey.PublicKey().Bytes(), Prologue: []byte("test"), Payload: []byte("client")},
+				Config{Pattern: pattern, StaticKey: serverKey, Prologue: []byte("test"), Payload: []byte("server"), VerifyPeer: func(remoteStatic, payload []byte) error {
+					verified = payload
+					return nil
+				}},
+			)
+			if err := errors.Join(err1, err2); err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(client.RemoteStatic(), serverKey.PublicKey().Bytes()) || !bytes.Equal(server.RemoteStatic(), clientKey.PublicKey().Bytes()) {
+				t.Fatal("remote static keys differ from the peers' keys")
+			}
+			if string(client.RemotePayload()) != "server" || string(server.RemotePayload()) != "client" || string(verified) != "client" {
+				t.Fatalf("payloads %q and %q, verified %q", client.RemotePayload(), server.RemotePayload(), verified)
+			}
+			if !bytes.Equal(client.HandshakeHash(), server.HandshakeHash()) {
+				t.Fatal("handshake hashes differ")
+			}
+
+			// Messages larger than a Noise message are split
+			data := bytes.Repeat([]byte("0123456789abcdef"), 10<<10)
+			go func() {
+				if _, err := client.Write(data); err != nil {
+					t.Error(err)
+				}
+			}()
+			got := make([]byte, len(data))
+			if _, err := io.ReadFull(server, got); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatal("received data differs")
+			}
+		})
+	}
+}
+
+func TestRekey(t *testing.T) {
+	client, server, err1, err2 := handshakePair(t, Config{StaticKey: generateKey(t)}, Config{StaticKey: generateKey(t)})
+	if err := errors.Join(err1, err2); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes on a net.Pipe block until read
+	client.Rekey()
+	server.RekeyRecv()
+	go client.Write([]byte("after rekey"))
+	buf := make([]byte, 64)
+	n, err := server.Read(buf)
+	if err != nil || string(buf[:n]) != "after rekey" {
+		t.Fatalf("read %q, %v", buf[:n], err)
+	}
+
+	// A side that missed the rekey fails to authenticate
+	client.Rekey()
+	go client.Write([]byte("unexpected"))
+	if _, err := server.Read(buf); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("Read: %v, want ErrDecrypt", err)
+	}
+}
+
+func TestPeerRejected(t *testing.T) {
+	reject := errors.New("unknown peer")
+	_, _, _, err := handshakePair(t,
+		Config{StaticKey: generateKey(t)},
+		Config{StaticKey: generateKey(t), VerifyPeer: func(remoteStatic, payload []byte) error {
+			return reject
+		}},
+	)
+	if !errors.Is(err, ErrPeerRejected) || !errors.Is(err, reject) {
+		t.Fatalf("server error %v, want ErrPeerRejected", err)
+	}
+}
+
+func TestHandshakeMismatch(t *testing.T) {
+	serverKey := generateKey(t)
+	for name, cfgs := range map[string][2]Config{
+		"prologue":      {{StaticKey: generateKey(t), Prologue: []byte("a")}, {StaticKey: serverKey, Prologue: []byte("b")}},
+		"remote static": {{Pattern: IK, StaticKey: generateKey(t), RemoteStatic: generateKey(t).PublicKey().Bytes()}, {Pattern: IK, StaticKey: serverKey}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err1, err2 := handshakePair(t, cfgs[0], cfgs[1])
+			if !errors.Is(err1, ErrDecrypt) && !errors.Is(err2, ErrDecrypt) {
+				t.Fatalf("handshake errors %v and %v, want ErrDecrypt", err1, err2)
+			}
+		})
+	}
+}
+
+func TestTamperedMessage(t *testing.T) {
+	ca, cb := net.Pipe()
+	defer ca.Close()
+	defer cb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	errc := make(chan error, 1)
+	var server *Conn
+	go func() {
+		var err error
+		server, err = Server(ctx, cb, Config{StaticKey: generateKey(t)})
+		errc <- err
+	}()
+	if _, err := Client(ctx, ca, Config{StaticKey: generateKey(t)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	// A frame not encrypted by the client, as written by an attacker on the path
+	go ca.Write(append([]byte{0, tagLen + 4}, make([]byte, tagLen+4)...))
+	if _, err := server.Read(make([]byte, 64)); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("Read: %v, want ErrDecrypt", err)
+	}
+}
+
+func TestHandshakeCancelled(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	// The responder never answers
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := Client(ctx, a, Config{StaticKey: generateKey(t)}); err != context.DeadlineExceeded {
+		t.Fatalf("Client: %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	ctx := context.Background()
+	var conn net.Conn
+	for name, tc := range map[string]struct {
+		cfg  Config
+		want error
+	}{
+		"static key":    {Config{}, ErrMissingStaticKey},
+		"remote static": {Config{Pattern: IK, StaticKey: generateKey(t)}, ErrMissingRemoteStatic},
+		"pattern":       {Config{Pattern: Pattern(7), StaticKey: generateKey(t)}, ErrUnknownPattern},
+	} {
+		if _, err := Client(ctx, conn, tc.cfg); err != tc.want {
+			t.Errorf("%s: %v, want %v", name, err, tc.want)
+		}
+	}
+}
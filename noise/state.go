@@ -0,0 +1,194 @@
+package noise
+
+import (
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"math"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+var (
+	// ErrNonceExhausted is returned when a cipher state has encrypted 2^64-1 messages
+	ErrNonceExhausted = errors.New("noise: nonce exhausted")
+	// ErrDecrypt is returned when a message fails authentication
+	ErrDecrypt = errors.New("noise: message authentication failed")
+	// ErrShortMessage is returned when a handshake message is truncated
+	ErrShortMessage = errors.New("noise: short handshake message")
+)
+
+const (
+	// dhLen is the length of X25519 public keys and shared secrets
+	dhLen = 32
+	// hashLen is the output length of SHA-256
+	hashLen = sha256.Size
+	// tagLen is the length of the ChaCha20-Poly1305 authentication tag
+	tagLen = chacha20poly1305.Overhead
+)
+
+// cipherState holds a ChaCha20-Poly1305 key and the message nonce counter (Noise section 5.1).
+type cipherState struct {
+	aead cipher.AEAD
+	n    uint64
+}
+
+// initializeKey sets the key and resets the nonce.
+func (cs *cipherState) initializeKey(key []byte) {
+	aead, _ := chacha20poly1305.New(key)
+	cs.aead = aead
+	cs.n = 0
+}
+
+// hasKey reports whether a key has been set.
+func (cs *cipherState) hasKey() bool {
+	return cs.aead != nil
+}
+
+// nonce encodes the counter as 32 zero bits followed by a little-endian 64-bit integer.
+func (cs *cipherState) nonce() []byte {
+	var nonce [chacha20poly1305.NonceSize]byte
+	binary.LittleEndian.PutUint64(nonce[4:], cs.n)
+	return nonce[:]
+}
+
+// encryptWithAd encrypts plaintext, appending the result to out. Without a key, plaintext is passed through.
+func (cs *cipherState) encryptWithAd(out, ad, plaintext []byte) ([]byte, error) {
+	if !cs.hasKey() {
+		return append(out, plaintext...), nil
+	}
+	if cs.n == math.MaxUint64 {
+		return nil, ErrNonceExhausted
+	}
+	out = cs.aead.Seal(out, cs.nonce(), plaintext, ad)
+	cs.n++
+	return out, nil
+}
+
+// decryptWithAd decrypts ciphertext, appending the result to out. Without a key, ciphertext is passed through.
+func (cs *cipherState) decryptWithAd(out, ad, ciphertext []byte) ([]byte, error) {
+	if !cs.hasKey() {
+		return append(out, ciphertext...), nil
+	}
+	if cs.n == math.MaxUint64 {
+		return nil, ErrNonceExhausted
+	}
+	out, err := cs.aead.Open(out, cs.nonce(), ciphertext, ad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	cs.n++
+	return out, nil
+}
+
+// rekey replaces the key with the first 32 bytes of encrypting zeros under the maximum nonce (Noise section 4.2).
+func (cs *cipherState) rekey() {
+	var nonce [chacha20poly1305.NonceSize]byte
+	binary.LittleEndian.PutUint64(nonce[4:], math.MaxUint64)
+	key := cs.aead.Seal(nil, nonce[:], make([]byte, 32), nil)
+	n := cs.n
+	cs.initializeKey(key[:32])
+	cs.n = n
+}
+
+// symmetricState holds the chaining key and handshake hash (Noise section 5.2).
+type symmetricState struct {
+	cs cipherState
+	ck [hashLen]byte
+	h  [hashLen]byte
+}
+
+// initializeSymmetric derives the initial hash from the protocol name.
+func (ss *symmetricState) initializeSymmetric(protocolName string) {
+	if len(protocolName) <= hashLen {
+		copy(ss.h[:], protocolName)
+	} else {
+		ss.h = sha256.Sum256([]byte(protocolName))
+	}
+	ss.ck = ss.h
+}
+
+// mixKey mixes input key material into the chaining key and derives a new cipher key.
+func (ss *symmetricState) mixKey(ikm []byte) {
+	ck, k, _ := hkdf(ss.ck[:], ikm, 2)
+	copy(ss.ck[:], ck)
+	ss.cs.initializeKey(k)
+}
+
+// mixHash mixes data into the handshake hash.
+func (ss *symmetricState) mixHash(data []byte) {
+	h := sha256.New()
+	h.Write(ss.h[:])
+	h.Write(data)
+	h.Sum(ss.h[:0])
+}
+
+// encryptAndHash encrypts plaintext with the handshake hash as associated data and mixes the ciphertext.
+func (ss *symmetricState) encryptAndHash(out, plaintext []byte) ([]byte, error) {
+	start := len(out)
+	out, err := ss.cs.encryptWithAd(out, ss.h[:], plaintext)
+	if err != nil {
+		return nil, err
+	}
+	ss.mixHash(out[start:])
+	return out, nil
+}
+
+// decryptAndHash decrypts ciphertext with the handshake hash as associated data and mixes the ciphertext.
+func (ss *symmetricState) decryptAndHash(out, ciphertext []byte) ([]byte, error) {
+	out, err := ss.cs.decryptWithAd(out, ss.h[:], ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	ss.mixHash(ciphertext)
+	return out, nil
+}
+
+// split derives the two transport cipher states once the handshake completes.
+func (ss *symmetricState) split() (*cipherState, *cipherState) {
+	k1, k2, _ := hkdf(ss.ck[:], nil, 2)
+	c1, c2 := &cipherState{}, &cipherState{}
+	c1.initializeKey(k1)
+	c2.initializeKey(k2)
+	return c1, c2
+}
+
+// hkdf implements the Noise HKDF function returning two or three outputs.
+func hkdf(chainingKey, ikm []byte, outputs int) ([]byte, []byte, []byte) {
+	mac := func(key []byte) hash.Hash { return hmac.New(sha256.New, key) }
+
+	h := mac(chainingKey)
+	h.Write(ikm)
+	tempKey := h.Sum(nil)
+
+	h = mac(tempKey)
+	h.Write([]byte{0x01})
+	out1 := h.Sum(nil)
+
+	h = mac(tempKey)
+	h.Write(out1)
+	h.Write([]byte{0x02})
+	out2 := h.Sum(nil)
+
+	if outputs == 2 {
+		return out1, out2, nil
+	}
+
+	h = mac(tempKey)
+	h.Write(out2)
+	h.Write([]byte{0x03})
+	return out1, out2, h.Sum(nil)
+}
+
+// dh computes the X25519 shared secret between a private key and a raw public key.
+func dh(priv *ecdh.PrivateKey, pub []byte) ([]byte, error) {
+	remote, err := ecdh.X25519().NewPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return priv.ECDH(remote)
+}
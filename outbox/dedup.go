@@ -0,0 +1,101 @@
+package outbox
+
+import (
+	"sync"
+)
+
+// defaultDedupWindow is the default number of sequence numbers tracked beyond the cumulative acknowledgement
+const defaultDedupWindow = 1024
+
+// Dedup filters duplicate deliveries on the receiving side and computes cumulative acknowledgements.
+//
+// For each sender it tracks the highest sequence number below which everything was received, plus a
+// bitmap of the following window of sequence numbers. Messages beyond the window are rejected so that
+// the sender retransmits them once the gap has been filled.
+type Dedup struct {
+	window uint64
+
+	mu      sync.Mutex
+	senders map[string]*dedupState
+}
+
+// dedupState is the receive state of one sender.
+type dedupState struct {
+	cumulative uint64   // All sequence numbers up to and including cumulative were received
+	seen       []uint64 // Bitmap of received sequence numbers cumulative+1 .. cumulative+window
+}
+
+// NewDedup creates a filter tracking window sequence numbers per sender (default 1024 if window is 0).
+func NewDedup(window int) *Dedup {
+	if window <= 0 {
+		window = defaultDedupWindow
+	}
+	return &Dedup{
+		window:  uint64(window),
+		senders: make(map[string]*dedupState),
+	}
+}
+
+// Accept records the delivery of seq from sender. It reports whether the message is new and should
+// be processed, and returns the cumulative acknowledgement to send back.
+func (d *Dedup) Accept(sender string, seq uint64) (fresh bool, ack uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	st, ok := d.senders[sender]
+	if !ok {
+		st = &dedupState{seen: make([]uint64, (d.window+63)/64)}
+		d.senders[sender] = st
+	}
+
+	if seq <= st.cumulative || seq > st.cumulative+d.window {
+		return false, st.cumulative
+	}
+
+	off := seq - st.cumulative - 1
+	if st.seen[off/64]&(1<<(off%64)) != 0 {
+		return false, st.cumulative
+	}
+	st.seen[off/64] |= 1 << (off % 64)
+
+	// Advance the cumulative acknowledgement over the contiguous prefix
+	for st.seen[0]&1 != 0 {
+		st.cumulative++
+		shiftRight(st.seen)
+	}
+	return true, st.cumulative
+}
+
+// Reset sets the cumulative acknowledgement of sender, e.g. from persisted receiver state.
+func (d *Dedup) Reset(sender string, cumulative uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.senders[sender] = &dedupState{cumulative: cumulative, seen: make([]uint64, (d.window+63)/64)}
+}
+
+// Cumulative returns the cumulative acknowledgement of sender.
+func (d *Dedup) Cumulative(sender string) uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if st, ok := d.senders[sender]; ok {
+		return st.cumulative
+	}
+	return 0
+}
+
+// Forget drops the state of sender.
+func (d *Dedup) Forget(sender string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.senders, sender)
+}
+
+// shiftRight shifts a little-endian multiword bitmap right by one bit.
+func shiftRight(bits []uint64) {
+	for i := range bits {
+		bits[i] >>= 1
+		if i+1 < len(bits) {
+			bits[i] |= bits[i+1] << 63
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package outbox
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"pkg.gfire.dev/supernet/codec"
+)
+
+// ErrInvalidFrame is returned when decoding a malformed frame
+var ErrInvalidFrame = errors.New("invalid outbox frame")
+
+// FrameType identifies the kind of an outbox frame.
+type FrameType byte
+
+const (
+	// FrameData carries a message.
+	FrameData FrameType = 1
+	// FrameAck carries a cumulative acknowledgement.
+	FrameAck FrameType = 2
+	// FrameHello announces the protocol of its sender. It is encoded as a data frame with sequence number
+	// 0, which receivers predating it drop as a duplicate.
+	FrameHello FrameType = 3
+)
+
+// Version is the version of the outbox frame format. Version 1 lacks hello frames.
+const Version = 2
+
+// Frame is a decoded outbox frame.
+type Frame struct {
+	Type     FrameType      // Frame type
+	Sender   string         // Sender identity, data and hello frames only
+	Seq      uint64         // Message sequence number, or the cumulative acknowledgement
+	Payload  []byte         // Message payload, data frames only
+	Protocol codec.Protocol // Announced protocol, hello frames only
+}
+
+// EncodeData encodes a message from sender as a data frame:
+// type, uvarint sequence number, uvarint sender length, sender, payload.
+func EncodeData(sender string, msg Message) []byte {
+	b := make([]byte, 0, 1+2*binary.MaxVarintLen64+len(sender)+len(msg.Payload))
+	b = append(b, byte(FrameData))
+	b = binary.AppendUvarint(b, msg.Seq)
+	b = binary.AppendUvarint(b, uint64(len(sender)))
+	b = append(b, sender...)
+	return append(b, msg.Payload...)
+}
+
+// EncodeHello encodes a hello frame from sender announcing p, usually codec.Protocol{Version: Version}:
+// a data frame with sequence number 0 carrying the encoding of p as its payload. The sending side starts
+// every connection with it, and a receiver answers it with its own hello; both sides then speak the
+// result of codec.Negotiate. A sender receiving acknowledgements only talks to a receiver of Version 1.
+func EncodeHello(sender string, p codec.Protocol) []byte {
+	return EncodeData(sender, Message{Payload: codec.EncodeProtocol(p)})
+}
+
+// EncodeAck encodes a cumulative acknowledgement frame: type, uvarint sequence number.
+func EncodeAck(seq uint64) []byte {
+	return binary.AppendUvarint([]byte{byte(FrameAck)}, seq)
+}
+
+// DecodeFrame decodes a data, acknowledgement or hello frame. The payload aliases b.
+func DecodeFrame(b []byte) (Frame, error) {
+	if len(b) == 0 {
+		return Frame{}, ErrInvalidFrame
+	}
+	f := Frame{Type: FrameType(b[0])}
+	b = b[1:]
+
+	seq, n := binary.Uvarint(b)
+	if n <= 0 {
+		return Frame{}, ErrInvalidFrame
+	}
+	f.Seq = seq
+	b = b[n:]
+
+	switch f.Type {
+	case FrameAck:
+		if len(b) != 0 {
+			return Frame{}, ErrInvalidFrame
+		}
+	case FrameData:
+		l, n := binary.Uvarint(b)
+		if n <= 0 || l > uint64(len(b)-n) {
+			return Frame{}, ErrInvalidFrame
+		}
+		b = b[n:]
+		f.Sender = string(b[:l])
+		f.Payload = b[l:]
+		if f.Seq == 0 {
+			p, err := codec.DecodeProtocol(f.Payload)
+			if err != nil {
+				return Frame{}, ErrInvalidFrame
+			}
+			f.Type, f.Payload, f.Protocol = FrameHello, nil, p
+		}
+	default:
+		return Frame{}, ErrInvalidFrame
+	}
+	return f, nil
+}
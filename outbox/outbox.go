@@ -0,0 +1,320 @@
+// Package outbox persists outbound messages with sequence numbers and delivers them at least once
+// across reconnects and restarts, retransmitting until the receiver acknowledges them.
+//
+// The sending side pushes messages into an Outbox backed by a Store (in memory, or IndexedDB under js/wasm)
+// and runs Deliver for every connection it establishes. The receiving side filters retransmissions with a
+// Dedup window and answers with cumulative acknowledgements. EncodeData and EncodeAck define a compact frame
+// format for carrying both over message-oriented transports such as WebSockets, and EncodeHello lets both
+// sides negotiate its Version without breaking receivers that predate it.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"pkg.gfire.dev/supernet/retry"
+)
+
+var (
+	// ErrClosed is returned when using a closed outbox
+	ErrClosed = errors.New("outbox closed")
+	// ErrFull is returned by Push when the number of unacknowledged messages reaches the limit
+	ErrFull = errors.New("outbox full")
+)
+
+const (
+	// defaultRetryInterval is the default time before unacknowledged messages are sent again
+	defaultRetryInterval = 5 * time.Second
+	// defaultMaxPending is the default limit of unacknowledged messages
+	defaultMaxPending = 10000
+)
+
+// Message is an outbound message.
+type Message struct {
+	Seq     uint64    // Sequence number, starting at 1 and increasing by one per message
+	Payload []byte    // Application payload
+	Created time.Time // Time the message was pushed
+}
+
+// Store persists the messages of an outbox.
+type Store interface {
+	// Load returns the next sequence number to assign and all stored messages in sequence order.
+	Load(ctx context.Context) (next uint64, pending []Message, err error)
+	// Put durably stores a message and records Seq+1 as the next sequence number.
+	Put(ctx context.Context, msg Message) error
+	// Delete removes all messages with sequence numbers up to and including seq.
+	Delete(ctx context.Context, seq uint64) error
+}
+
+// SendFunc transmits a message over the current connection.
+// A returned error ends the delivery run; messages stay pending until acknowledged.
+type SendFunc func(ctx context.Context, msg Message) error
+
+// Config configures an Outbox.
+type Config struct {
+	// Store persists messages. Defaults to an in-memory store that does not survive restarts.
+	Store Store
+	// RetryInterval is the time after which unacknowledged messages are sent again on the same connection (default 5s).
+	RetryInterval time.Duration
+	// Backoff spaces out further retransmissions while acknowledgements keep stalling, starting over once
+	// the receiver makes progress. Its attempt and time limits are ignored. When nil, messages are
+	// retransmitted every RetryInterval.
+	Backoff *retry.Policy
+	// MaxPending limits the number of unacknowledged messages (default 10000).
+	MaxPending int
+}
+
+// Outbox queues messages until they are acknowledged.
+type Outbox struct {
+	cfg Config
+
+	mu      sync.Mutex
+	next    uint64    // Next sequence number to assign
+	pending []Message // Unacknowledged messages in sequence order
+	acked   uint64    // Highest acknowledged sequence number
+	notify  chan struct{}
+	closed  bool
+}
+
+// Open loads the outbox state from cfg.Store.
+func Open(ctx context.Context, cfg Config) (*Outbox, error) {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = defaultRetryInterval
+	}
+	if cfg.MaxPending <= 0 {
+		cfg.MaxPending = defaultMaxPending
+	}
+
+	next, pending, err := cfg.Store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if next == 0 {
+		next = 1
+	}
+
+	o := &Outbox{
+		cfg:     cfg,
+		next:    next,
+		pending: pending,
+		notify:  make(chan struct{}),
+	}
+	if len(pending) > 0 {
+		o.acked = pending[0].Seq - 1
+	} else {
+		o.acked = next - 1
+	}
+	return o, nil
+}
+
+// Push stores payload as a new message and returns its sequence number.
+// The message is persisted before Push returns.
+func (o *Outbox) Push(ctx context.Context, payload []byte) (uint64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return 0, ErrClosed
+	}
+	if len(o.pending) >= o.cfg.MaxPending {
+		return 0, ErrFull
+	}
+
+	msg := Message{
+		Seq:     o.next,
+		Payload: append([]byte(nil), payload...),
+		Created: time.Now(),
+	}
+	if err := o.cfg.Store.Put(ctx, msg); err != nil {
+		return 0, err
+	}
+	o.next++
+	o.pending = append(o.pending, msg)
+	o.wake()
+	return msg.Seq, nil
+}
+
+// Ack acknowledges all messages up to and including seq, removing them from the store.
+func (o *Outbox) Ack(ctx context.Context, seq uint64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if seq <= o.acked {
+		return nil
+	}
+	if seq >= o.next {
+		seq = o.next - 1
+	}
+	if err := o.cfg.Store.Delete(ctx, seq); err != nil {
+		return err
+	}
+
+	o.acked = seq
+	i := 0
+	for i < len(o.pending) && o.pending[i].Seq <= seq {
+		i++
+	}
+	o.pending = append(o.pending[:0:0], o.pending[i:]...)
+	o.wake()
+	return nil
+}
+
+// Pending returns the number of unacknowledged messages.
+func (o *Outbox) Pending() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.pending)
+}
+
+// Acked returns the highest acknowledged sequence number.
+func (o *Outbox) Acked() uint64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.acked
+}
+
+// Deliver sends pending messages with send until ctx is done, send fails or the outbox is closed.
+// It starts with the oldest unacknowledged message, so calling it for each new connection retransmits
+// everything the receiver may have missed. Messages not acknowledged within RetryInterval are sent again.
+func (o *Outbox) Deliver(ctx context.Context, send SendFunc) error {
+	var sent uint64 // Highest sequence number sent on this connection
+	lastProgress := time.Now()
+	interval := o.cfg.RetryInterval
+	backoff := o.backoff()
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		o.mu.Lock()
+		if o.closed {
+			o.mu.Unlock()
+			return ErrClosed
+		}
+
+		// Retransmit from the first unacknowledged message if acknowledgements stalled
+		if sent > o.acked && time.Since(lastProgress) >= interval {
+			sent = o.acked
+			lastProgress = time.Now()
+			if d, ok := backoff.Next(); ok {
+				interval = d
+			}
+		}
+		if sent < o.acked {
+			sent = o.acked
+		}
+
+		var batch []Message
+		for _, msg := range o.pending {
+			if msg.Seq > sent {
+				batch = append(batch, msg)
+			}
+		}
+		notify := o.notify
+		acked := o.acked
+		o.mu.Unlock()
+
+		for _, msg := range batch {
+			if err := send(ctx, msg); err != nil {
+				return err
+			}
+			sent = msg.Seq
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(interval)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+			o.mu.Lock()
+			if o.acked > acked {
+				lastProgress = time.Now()
+				interval = o.cfg.RetryInterval
+				backoff.Reset()
+			}
+			o.mu.Unlock()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the retransmission delay sequence, a fixed RetryInterval unless Backoff is configured.
+func (o *Outbox) backoff() *retry.Backoff {
+	policy := retry.Policy{Initial: o.cfg.RetryInterval, Multiplier: 1, Jitter: -1}
+	if o.cfg.Backoff != nil {
+		policy = *o.cfg.Backoff
+	}
+	policy.MaxAttempts, policy.MaxElapsed = 0, 0
+	return policy.Backoff()
+}
+
+// Close stops all delivery runs. Pending messages remain in the store.
+func (o *Outbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.closed {
+		o.closed = true
+		o.wake()
+	}
+	return nil
+}
+
+// wake signals delivery runs that the state changed. Must be called with o.mu held.
+func (o *Outbox) wake() {
+	close(o.notify)
+	o.notify = make(chan struct{})
+}
+
+// MemoryStore is a Store keeping messages in memory.
+type MemoryStore struct {
+	mu       sync.Mutex
+	next     uint64
+	messages []Message
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{next: 1}
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(ctx context.Context) (uint64, []Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next, append([]Message(nil), s.messages...), nil
+}
+
+// Put implements Store.
+func (s *MemoryStore) Put(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	s.next = msg.Seq + 1
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(ctx context.Context, seq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := 0
+	for i < len(s.messages) && s.messages[i].Seq <= seq {
+		i++
+	}
+	s.messages = append(s.messages[:0:0], s.messages[i:]...)
+	return nil
+}
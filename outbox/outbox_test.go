@@ -0,0 +1,271 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"pkg.gfire.dev/supernet/codec"
+)
+
+// testTimeout bounds every blocking call of a test
+const testTimeout = 10 * time.Second
+
+// receiver is the receiving side of a test, recording fresh messages in order.
+type receiver struct {
+	dedup *Dedup
+
+	mu       sync.Mutex
+	received []string
+	// drop reports whether a data frame is lost before reaching the receiver
+	drop func(seq uint64) bool
+}
+
+// serve receives frames from data and acknowledges them on acks, hanging up after limit data frames if
+// limit > 0.
+func (r *receiver) serve(data <-chan []byte, acks chan<- []byte, limit int) {
+	defer close(acks)
+	for n := 0; limit <= 0 || n < limit; {
+		b, ok := <-data
+		if !ok {
+			return
+		}
+		f, err := DecodeFrame(b)
+		if err != nil || f.Type != FrameData {
+			return
+		}
+		if r.drop != nil && r.drop(f.Seq) {
+			continue
+		}
+		n++
+		fresh, ack := r.dedup.Accept(f.Sender, f.Seq)
+		if fresh {
+			r.mu.Lock()
+			r.received = append(r.received, string(f.Payload))
+			r.mu.Unlock()
+		}
+		acks <- EncodeAck(ack)
+	}
+}
+
+// deliver runs one connection of o to r until the receiver hangs up or ctx is done.
+func deliver(ctx context.Context, o *Outbox, r *receiver, limit int) error {
+	data, acks := make(chan []byte, 64), make(chan []byte, 64)
+	go r.serve(data, acks, limit)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer close(data)
+
+	go func() {
+		defer cancel()
+		for b := range acks {
+			if f, err := DecodeFrame(b); err == nil && f.Type == FrameAck {
+				o.Ack(ctx, f.Seq)
+			}
+		}
+	}()
+	return o.Deliver(ctx, func(ctx context.Context, msg Message) error {
+		select {
+		case data <- EncodeData("sender", msg):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// waitAcked waits until o has no pending messages.
+func waitAcked(t *testing.T, o *Outbox) {
+	t.Helper()
+	deadline := time.Now().Add(testTimeout)
+	for o.Pending() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d messages still pending", o.Pending())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	o, err := Open(context.Background(), Config{RetryInterval: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+	const count = 100
+	for i := range count {
+		if _, err := o.Push(context.Background(), fmt.Appendf(nil, "message %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The first connection loses the first transmission of some messages and breaks halfway; the second
+	// retransmits everything unacknowledged
+	var dropped sync.Map
+	r := &receiver{dedup: NewDedup(0), drop: func(seq uint64) bool {
+		_, loaded := dropped.LoadOrStore(seq, true)
+		return seq%7 == 0 && !loaded
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	deliver(ctx, o, r, count/2)
+	if o.Pending() == 0 {
+		t.Fatal("all messages acknowledged by the first connection")
+	}
+	go deliver(ctx, o, r, 0)
+	waitAcked(t, o)
+
+	if o.Acked() != count {
+		t.Fatalf("acknowledged %d, want %d", o.Acked(), count)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.received) != count {
+		t.Fatalf("received %d messages, want %d", len(r.received), count)
+	}
+	seen := make(map[string]bool)
+	for _, msg := range r.received {
+		if seen[msg] {
+			t.Fatalf("%q received twice", msg)
+		}
+		seen[msg] = true
+	}
+}
+
+func TestReopen(t *testing.T) {
+	store := NewMemoryStore()
+	o, err := Open(context.Background(), Config{Store: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"a", "b", "c"} {
+		if _, err := o.Push(context.Background(), []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := o.Ack(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	o.Close()
+
+	// The pending messages survive, and numbering continues
+	o, err = Open(context.Background(), Config{Store: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+	if o.Pending() != 2 || o.Acked() != 1 {
+		t.Fatalf("reopened with %d pending and %d acknowledged, want 2 and 1", o.Pending(), o.Acked())
+	}
+	if seq, err := o.Push(context.Background(), []byte("d")); err != nil || seq != 4 {
+		t.Fatalf("Push: %d, %v, want 4", seq, err)
+	}
+}
+
+func TestFull(t *testing.T) {
+	o, err := Open(context.Background(), Config{MaxPending: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+	for range 2 {
+		if _, err := o.Push(context.Background(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := o.Push(context.Background(), nil); err != ErrFull {
+		t.Fatalf("Push: %v, want ErrFull", err)
+	}
+	o.Ack(context.Background(), 1)
+	if _, err := o.Push(context.Background(), nil); err != nil {
+		t.Fatalf("Push after ack: %v", err)
+	}
+}
+
+func TestClosed(t *testing.T) {
+	o, err := Open(context.Background(), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- o.Deliver(context.Background(), func(ctx context.Context, msg Message) error {
+			return nil
+		})
+	}()
+	o.Close()
+	select {
+	case err := <-errc:
+		if err != ErrClosed {
+			t.Fatalf("Deliver: %v, want ErrClosed", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("Deliver not stopped by Close")
+	}
+	if _, err := o.Push(context.Background(), nil); err != ErrClosed {
+		t.Fatalf("Push: %v, want ErrClosed", err)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	d := NewDedup(64)
+	for _, tc := range []struct {
+		seq   uint64
+		fresh bool
+		ack   uint64
+	}{
+		{2, true, 0},
+		{1, true, 2},
+		{2, false, 2},
+		{67, false, 2}, // beyond the window
+		{66, true, 2},
+		{3, true, 3},
+	} {
+		if fresh, ack := d.Accept("a", tc.seq); fresh != tc.fresh || ack != tc.ack {
+			t.Fatalf("Accept(%d): %v, %d, want %v, %d", tc.seq, fresh, ack, tc.fresh, tc.ack)
+		}
+	}
+	if d.Cumulative("b") != 0 {
+		t.Fatal("senders share state")
+	}
+	d.Reset("a", 100)
+	if fresh, _ := d.Accept("a", 66); fresh {
+		t.Fatal("sequence number below the reset acknowledgement accepted")
+	}
+}
+
+func TestFrames(t *testing.T) {
+	p := codec.Protocol{Version: Version, Capabilities: 5}
+	for _, tc := range []struct {
+		data []byte
+		want Frame
+	}{
+		{EncodeData("alice", Message{Seq: 7, Payload: []byte("hi")}), Frame{Type: FrameData, Sender: "alice", Seq: 7, Payload: []byte("hi")}},
+		{EncodeAck(300), Frame{Type: FrameAck, Seq: 300}},
+		{EncodeHello("alice", p), Frame{Type: FrameHello, Sender: "alice", Protocol: p}},
+	} {
+		f, err := DecodeFrame(tc.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Type != tc.want.Type || f.Sender != tc.want.Sender || f.Seq != tc.want.Seq ||
+			string(f.Payload) != string(tc.want.Payload) || f.Protocol != tc.want.Protocol {
+			t.Fatalf("decoded %+v, want %+v", f, tc.want)
+		}
+	}
+
+	for name, b := range map[string][]byte{
+		"empty":          nil,
+		"type":           {9, 1},
+		"truncated seq":  {byte(FrameAck), 0x80},
+		"ack trailer":    append(EncodeAck(1), 0),
+		"sender length":  {byte(FrameData), 1, 5, 'a'},
+		"hello protocol": {byte(FrameData), 0, 0, 0xff},
+	} {
+		if _, err := DecodeFrame(b); err != ErrInvalidFrame {
+			t.Errorf("%s: %v, want ErrInvalidFrame", name, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"syscall/js"
+	"time"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/idbjs"
+)
+
+const (
+	// idbVersion is the schema version of outbox databases
+	idbVersion = 1
+	// idbMessages is the object store holding messages keyed by sequence number
+	idbMessages = "messages"
+	// idbMeta is the object store holding the next sequence number
+	idbMeta = "meta"
+	// idbNextKey is the key of the next sequence number in the meta store
+	idbNextKey = "next"
+)
+
+var (
+	// _Uint8Array is a cached reference to the JavaScript Uint8Array constructor for payload storage
+	_Uint8Array = js.Global().Get("Uint8Array")
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+)
+
+// IndexedDBStore is a Store persisting messages in an IndexedDB database, so pending messages survive
+// page reloads. Each outbox needs its own database name.
+type IndexedDBStore struct {
+	db *idbjs.DB
+}
+
+// OpenIndexedDBStore opens or creates the named outbox database.
+func OpenIndexedDBStore(ctx context.Context, name string) (*IndexedDBStore, error) {
+	db, err := idbjs.Open(ctx, name, idbVersion, func(db *idbjs.DB, oldVersion, newVersion int) error {
+		if _, err := db.CreateStore(idbMessages, idbjs.StoreOptions{KeyPath: "seq"}); err != nil {
+			return err
+		}
+		_, err := db.CreateStore(idbMeta, idbjs.StoreOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &IndexedDBStore{db: db}, nil
+}
+
+// Load implements Store.
+func (s *IndexedDBStore) Load(ctx context.Context) (uint64, []Message, error) {
+	tx, err := s.db.Transaction(idbjs.ReadOnly, idbMessages, idbMeta)
+	if err != nil {
+		return 0, nil, err
+	}
+	messages, err := tx.Store(idbMessages)
+	if err != nil {
+		return 0, nil, err
+	}
+	meta, err := tx.Store(idbMeta)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Issue both requests before waiting so the transaction stays active
+	allReq := messages.GetAll(js.Undefined(), 0)
+	nextReq := meta.Get(js.ValueOf(idbNextKey))
+
+	all, err := allReq.Wait(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	next, err := nextReq.Wait(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	pending := make([]Message, all.Length())
+	for i := range pending {
+		v := all.Index(i)
+		payload := make([]byte, v.Get("payload").Length())
+		js.CopyBytesToGo(payload, v.Get("payload"))
+		pending[i] = Message{
+			Seq:     uint64(v.Get("seq").Float()),
+			Payload: payload,
+			Created: time.UnixMilli(int64(v.Get("created").Float())),
+		}
+	}
+
+	n := uint64(1)
+	if next.Type() == js.TypeNumber {
+		n = uint64(next.Float())
+	}
+	return n, pending, nil
+}
+
+// Put implements Store.
+func (s *IndexedDBStore) Put(ctx context.Context, msg Message) error {
+	if msg.Seq >= 1<<53 {
+		return errors.New("outbox: sequence number exceeds IndexedDB key precision")
+	}
+
+	tx, err := s.db.Transaction(idbjs.ReadWrite, idbMessages, idbMeta)
+	if err != nil {
+		return err
+	}
+	messages, err := tx.Store(idbMessages)
+	if err != nil {
+		return err
+	}
+	meta, err := tx.Store(idbMeta)
+	if err != nil {
+		return err
+	}
+
+	payload := _Uint8Array.New(len(msg.Payload))
+	js.CopyBytesToJS(payload, msg.Payload)
+
+	record := _Object.New()
+	record.Set("seq", float64(msg.Seq))
+	record.Set("payload", payload)
+	record.Set("created", float64(msg.Created.UnixMilli()))
+
+	messages.Put(record, js.Undefined())
+	meta.Put(js.ValueOf(float64(msg.Seq+1)), js.ValueOf(idbNextKey))
+	tx.Commit()
+	return tx.Done(ctx)
+}
+
+// Delete implements Store.
+func (s *IndexedDBStore) Delete(ctx context.Context, seq uint64) error {
+	tx, err := s.db.Transaction(idbjs.ReadWrite, idbMessages)
+	if err != nil {
+		return err
+	}
+	messages, err := tx.Store(idbMessages)
+	if err != nil {
+		return err
+	}
+
+	messages.Delete(idbjs.UpperBound(float64(seq), false))
+	tx.Commit()
+	return tx.Done(ctx)
+}
+
+// Close closes the database.
+func (s *IndexedDBStore) Close() error {
+	return s.db.Close()
+}
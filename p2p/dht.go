@@ -0,0 +1,494 @@
+package p2p
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	snp2p "pkg.gfire.dev/supernet/proto/snp2p/v1alpha1"
+)
+
+var (
+	// ErrNotFound is returned when a lookup does not find the requested peer or value
+	ErrNotFound = errors.New("not found")
+	// ErrValueTooLarge is returned when storing a value larger than MaxValueSize
+	ErrValueTooLarge = errors.New("value too large")
+)
+
+const (
+	// Alpha is the number of concurrent requests issued by iterative lookups
+	Alpha = 3
+	// MaxValueSize is the largest value accepted by PutValue
+	MaxValueSize = 64 << 10
+	// MaxValueTTL caps the lifetime of stored values
+	MaxValueTTL = 24 * time.Hour
+	// refreshInterval is the period between routing table refreshes
+	refreshInterval = 10 * time.Minute
+)
+
+// Bootstrap connects to the given bootstrap addresses and to peers remembered by the configured Peerstore,
+// then populates the routing table by looking up the node's own ID. It succeeds if at least one bootstrap
+// node or remembered peer is reachable.
+func (n *Node) Bootstrap(ctx context.Context, addrs ...string) error {
+	var errs []error
+	connected := n.reconnect(ctx)
+	for _, addr := range addrs {
+		if _, err := n.Dial(ctx, addr); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		connected++
+	}
+	if connected == 0 && len(addrs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	_, err := n.ClosestPeers(ctx, n.id)
+	return err
+}
+
+// Ping measures the round trip time to a peer.
+func (n *Node) Ping(ctx context.Context, id ID) (time.Duration, error) {
+	start := time.Now()
+	resp, err := n.request(ctx, id, ID{}, &snp2p.Envelope{Body: &snp2p.Envelope_Ping{Ping: &snp2p.Ping{}}})
+	if err != nil {
+		return 0, err
+	}
+	if resp.GetPong() == nil {
+		return 0, ErrUnexpectedResponse
+	}
+	rtt := time.Since(start)
+	n.storePeer(func(ctx context.Context, ps Peerstore) error {
+		return ps.AddLatency(ctx, id, rtt)
+	})
+	return rtt, nil
+}
+
+// FindPeer looks up a peer by ID. The peer is reachable through the returned route,
+// either directly or relayed.
+func (n *Node) FindPeer(ctx context.Context, id ID) (PeerInfo, error) {
+	if info, ok := n.table.Find(id); ok {
+		return info, nil
+	}
+	peers, err := n.ClosestPeers(ctx, id)
+	if err != nil {
+		return PeerInfo{}, err
+	}
+	if len(peers) > 0 && peers[0].ID == id {
+		return peers[0], nil
+	}
+	return PeerInfo{}, ErrNotFound
+}
+
+// ClosestPeers performs an iterative lookup for the BucketSize peers closest to key.
+func (n *Node) ClosestPeers(ctx context.Context, key ID) ([]PeerInfo, error) {
+	peers, _, err := n.lookup(ctx, key, false)
+	return peers, err
+}
+
+// PutValue stores a value under key on the peers closest to it, including this node if it is among them.
+// The ttl is capped at MaxValueTTL; records must be republished before they expire.
+func (n *Node) PutValue(ctx context.Context, key ID, value []byte, ttl time.Duration) error {
+	if len(value) > MaxValueSize {
+		return ErrValueTooLarge
+	}
+	if ttl <= 0 || ttl > MaxValueTTL {
+		ttl = MaxValueTTL
+	}
+
+	peers, err := n.ClosestPeers(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	// Keep a copy locally if this node is closer than the farthest of the closest peers
+	if len(peers) < BucketSize || key.Closer(n.id, peers[len(peers)-1].ID) {
+		n.store.put(key, value, ttl)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	stored := 0
+	for _, p := range peers {
+		wg.Add(1)
+		go func(p PeerInfo) {
+			defer wg.Done()
+			req := &snp2p.Envelope{Body: &snp2p.Envelope_PutValue{PutValue: &snp2p.PutValue{
+				Key:        key.Bytes(),
+				Value:      value,
+				TtlSeconds: int64(ttl / time.Second),
+			}}}
+			if resp, err := n.request(ctx, p.ID, p.Via, req); err == nil && resp.GetAck() != nil {
+				mu.Lock()
+				stored++
+				mu.Unlock()
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	if stored == 0 && len(peers) > 0 {
+		return errors.New("value was not stored by any peer")
+	}
+	return nil
+}
+
+// GetValue retrieves the value stored under key, querying the closest peers iteratively.
+func (n *Node) GetValue(ctx context.Context, key ID) ([]byte, error) {
+	if value, ok := n.store.get(key); ok {
+		return value, nil
+	}
+	_, value, err := n.lookup(ctx, key, true)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, ErrNotFound
+	}
+	return value, nil
+}
+
+// ConnectPeer establishes a direct connection to a peer found through the DHT using the configured
+// Transport, signaling through the overlay. If no transport is configured or the direct connection
+// fails, the peer stays reachable through its relay and the error is returned.
+func (n *Node) ConnectPeer(ctx context.Context, id ID) error {
+	if n.Connected(id) {
+		return nil
+	}
+	if n.cfg.Transport == nil {
+		return ErrNoTransport
+	}
+
+	info, err := n.FindPeer(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	exchange := func(ctx context.Context, offer []byte) ([]byte, error) {
+		req := &snp2p.Envelope{Body: &snp2p.Envelope_Signal{Signal: &snp2p.Signal{Payload: offer}}}
+		resp, err := n.request(ctx, id, info.Via, req)
+		if err != nil {
+			return nil, err
+		}
+		signal := resp.GetSignal()
+		if signal == nil {
+			return nil, ErrUnexpectedResponse
+		}
+		return signal.Payload, nil
+	}
+
+	conn, err := n.cfg.Transport.Connect(ctx, exchange)
+	if err != nil {
+		return err
+	}
+	_, err = n.addConn(ctx, conn, id, false)
+	return err
+}
+
+// handleRequest serves a request received from src.
+func (n *Node) handleRequest(src ID, req *snp2p.Envelope) {
+	resp := &snp2p.Envelope{}
+
+	switch body := req.Body.(type) {
+	case *snp2p.Envelope_Ping:
+		resp.Body = &snp2p.Envelope_Pong{Pong: &snp2p.Pong{}}
+
+	case *snp2p.Envelope_FindNode:
+		target, err := IDFromBytes(body.FindNode.Target)
+		if err != nil {
+			resp.Body = errorBody(err)
+			break
+		}
+		resp.Body = &snp2p.Envelope_Peers{Peers: &snp2p.Peers{Peers: n.closestRecords(target, src)}}
+
+	case *snp2p.Envelope_PutValue:
+		key, err := IDFromBytes(body.PutValue.Key)
+		if err != nil {
+			resp.Body = errorBody(err)
+			break
+		}
+		if len(body.PutValue.Value) > MaxValueSize {
+			resp.Body = errorBody(ErrValueTooLarge)
+			break
+		}
+		ttl := time.Duration(body.PutValue.TtlSeconds) * time.Second
+		if ttl <= 0 || ttl > MaxValueTTL {
+			ttl = MaxValueTTL
+		}
+		n.store.put(key, body.PutValue.Value, ttl)
+		resp.Body = &snp2p.Envelope_Ack{Ack: &snp2p.Ack{}}
+
+	case *snp2p.Envelope_GetValue:
+		key, err := IDFromBytes(body.GetValue.Key)
+		if err != nil {
+			resp.Body = errorBody(err)
+			break
+		}
+		value := &snp2p.ValueResult{CloserPeers: n.closestRecords(key, src)}
+		if v, ok := n.store.get(key); ok {
+			value.Value = v
+		}
+		resp.Body = &snp2p.Envelope_ValueResult{ValueResult: value}
+
+	case *snp2p.Envelope_Signal:
+		if n.cfg.Transport == nil {
+			resp.Body = errorBody(ErrNoTransport)
+			break
+		}
+		// The response is sent by the transport once the answer is ready
+		go n.acceptSignal(src, req, body.Signal.Payload)
+		return
+
+	case *snp2p.Envelope_HolePunch:
+		if n.cfg.Transport == nil {
+			resp.Body = errorBody(ErrNoTransport)
+			break
+		}
+		// The response is sent once the direct connection is established or has failed
+		go n.acceptHolePunch(src, req)
+		return
+
+	default:
+		resp.Body = errorBody(ErrUnexpectedResponse)
+	}
+
+	n.respond(src, req, resp)
+}
+
+// acceptSignal answers a connection offer from src and registers the resulting connection.
+func (n *Node) acceptSignal(src ID, req *snp2p.Envelope, offer []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), 4*n.cfg.RequestTimeout)
+	defer cancel()
+
+	reply := func(answer []byte) error {
+		n.respond(src, req, &snp2p.Envelope{Body: &snp2p.Envelope_Signal{Signal: &snp2p.Signal{Payload: answer}}})
+		return nil
+	}
+
+	conn, err := n.cfg.Transport.Accept(ctx, offer, reply)
+	if err != nil {
+		n.respond(src, req, &snp2p.Envelope{Body: errorBody(err)})
+		return
+	}
+	n.addConn(ctx, conn, src, false)
+}
+
+// closestRecords returns the closest known peers to target as records, excluding the requester.
+func (n *Node) closestRecords(target, exclude ID) []*snp2p.PeerRecord {
+	peers := n.table.Closest(target, BucketSize+1)
+	records := make([]*snp2p.PeerRecord, 0, len(peers))
+	for _, p := range peers {
+		if p.ID == exclude || len(records) == BucketSize {
+			continue
+		}
+		records = append(records, &snp2p.PeerRecord{
+			PeerId:    p.ID.Bytes(),
+			PublicKey: p.PublicKey,
+			Addresses: p.Addrs,
+		})
+	}
+	return records
+}
+
+// lookupCandidate is a peer discovered during an iterative lookup.
+type lookupCandidate struct {
+	info    PeerInfo
+	queried bool
+	failed  bool
+}
+
+// lookup runs an iterative Kademlia lookup for key. When getValue is set, peers are asked for the value
+// and the lookup stops as soon as one returns it.
+func (n *Node) lookup(ctx context.Context, key ID, getValue bool) ([]PeerInfo, []byte, error) {
+	candidates := make(map[ID]*lookupCandidate)
+	for _, p := range n.table.Closest(key, BucketSize) {
+		candidates[p.ID] = &lookupCandidate{info: p}
+	}
+	if len(candidates) == 0 {
+		return nil, nil, ErrNoRoute
+	}
+
+	type queryResult struct {
+		from  ID
+		peers []*snp2p.PeerRecord
+		value []byte
+		err   error
+	}
+
+	// sorted returns the non-failed candidates ordered by distance to key
+	sorted := func() []*lookupCandidate {
+		list := make([]*lookupCandidate, 0, len(candidates))
+		for _, c := range candidates {
+			if !c.failed {
+				list = append(list, c)
+			}
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return key.Closer(list[i].info.ID, list[j].info.ID)
+		})
+		return list
+	}
+
+	results := make(chan queryResult, Alpha)
+	inflight := 0
+
+	for {
+		// Issue queries to the closest unqueried candidates among the BucketSize closest
+		list := sorted()
+		for i := 0; i < len(list) && i < BucketSize && inflight < Alpha; i++ {
+			c := list[i]
+			if c.queried {
+				continue
+			}
+			c.queried = true
+			inflight++
+
+			go func(info PeerInfo) {
+				req := &snp2p.Envelope{Body: &snp2p.Envelope_FindNode{FindNode: &snp2p.FindNode{Target: key.Bytes()}}}
+				if getValue {
+					req.Body = &snp2p.Envelope_GetValue{GetValue: &snp2p.GetValue{Key: key.Bytes()}}
+				}
+				resp, err := n.request(ctx, info.ID, info.Via, req)
+				r := queryResult{from: info.ID, err: err}
+				if err == nil {
+					switch body := resp.Body.(type) {
+					case *snp2p.Envelope_Peers:
+						r.peers = body.Peers.Peers
+					case *snp2p.Envelope_ValueResult:
+						r.peers = body.ValueResult.CloserPeers
+						r.value = body.ValueResult.Value
+					default:
+						r.err = ErrUnexpectedResponse
+					}
+				}
+				results <- r
+			}(c.info)
+		}
+
+		if inflight == 0 {
+			break
+		}
+
+		var r queryResult
+		select {
+		case r = <-results:
+			inflight--
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+
+		if r.err != nil {
+			candidates[r.from].failed = true
+			continue
+		}
+		if getValue && r.value != nil {
+			return nil, r.value, nil
+		}
+
+		// Merge newly learned peers, reachable through the peer that reported them
+		for _, record := range r.peers {
+			id, err := IDFromBytes(record.PeerId)
+			if err != nil || id == n.id || candidates[id] != nil {
+				continue
+			}
+			if len(record.PublicKey) != ed25519.PublicKeySize || IDFromPublicKey(record.PublicKey) != id {
+				continue
+			}
+			info := PeerInfo{ID: id, PublicKey: record.PublicKey, Addrs: record.Addresses, Via: r.from}
+			if known, ok := n.table.Find(id); ok {
+				info = known
+			}
+			candidates[id] = &lookupCandidate{info: info}
+		}
+	}
+
+	list := sorted()
+	if len(list) > BucketSize {
+		list = list[:BucketSize]
+	}
+	peers := make([]PeerInfo, 0, len(list))
+	for _, c := range list {
+		peers = append(peers, c.info)
+		// Remember responsive peers as relayed routes so later requests can reach them
+		if c.queried && c.info.Relayed() {
+			n.table.Update(c.info)
+		}
+	}
+	return peers, nil, nil
+}
+
+// maintain periodically expires stored values and refreshes the routing table.
+func (n *Node) maintain() {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			n.store.expire()
+			n.expireUpgrades()
+			if n.table.Size() > 0 {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				n.ClosestPeers(ctx, RandomID())
+				cancel()
+			}
+		case <-n.closeChan:
+			return
+		}
+	}
+}
+
+// errorBody wraps an error as an Envelope error body.
+func errorBody(err error) *snp2p.Envelope_Error {
+	return &snp2p.Envelope_Error{Error: &snp2p.Error{Message: err.Error()}}
+}
+
+// storedValue is a value held on behalf of the DHT.
+type storedValue struct {
+	value   []byte
+	expires time.Time
+}
+
+// valueStore holds DHT values with expiry.
+type valueStore struct {
+	mu     sync.Mutex
+	values map[ID]storedValue
+}
+
+// newValueStore creates an empty value store.
+func newValueStore() *valueStore {
+	return &valueStore{values: make(map[ID]storedValue)}
+}
+
+// put stores a value for ttl.
+func (s *valueStore) put(key ID, value []byte, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = storedValue{value: append([]byte(nil), value...), expires: time.Now().Add(ttl)}
+}
+
+// get returns an unexpired value.
+func (s *valueStore) get(key ID) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[key]
+	if !ok || time.Now().After(v.expires) {
+		return nil, false
+	}
+	return v.value, true
+}
+
+// expire removes all expired values.
+func (s *valueStore) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for key, v := range s.values {
+		if now.After(v.expires) {
+			delete(s.values, key)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !js
+
+package p2p
+
+// defaultDial is used when Config.Dial is not set; native nodes have no default dialer.
+var defaultDial DialFunc
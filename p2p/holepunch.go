@@ -0,0 +1,152 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	snp2p "pkg.gfire.dev/supernet/proto/snp2p/v1alpha1"
+)
+
+const (
+	// holePunchAttempts is the number of direct connection attempts of a background upgrade
+	holePunchAttempts = 3
+	// holePunchRetry is the delay before the second attempt, growing linearly with every attempt
+	holePunchRetry = 5 * time.Second
+	// holePunchBackoff is the time a peer stays on the relay after a failed upgrade before it is tried again
+	holePunchBackoff = 10 * time.Minute
+)
+
+// punch is a hole punching attempt in progress, shared by concurrent callers.
+type punch struct {
+	done chan struct{}
+	err  error
+}
+
+// HolePunch replaces the relayed route to a peer with a direct connection over the configured
+// Transport. Both peers take part: the one with the lower ID sends the offer through the relay, the other
+// one asks it to, so simultaneous attempts of both sides meet in a single connection. When punching
+// fails the peer stays reachable through the relay and the error is returned.
+func (n *Node) HolePunch(ctx context.Context, id ID) error {
+	if n.Connected(id) {
+		return nil
+	}
+	if n.cfg.Transport == nil {
+		return ErrNoTransport
+	}
+
+	n.mu.Lock()
+	p, ok := n.punching[id]
+	if !ok {
+		p = &punch{done: make(chan struct{})}
+		n.punching[id] = p
+	}
+	n.mu.Unlock()
+	if !ok {
+		p.err = n.holePunch(ctx, id)
+		n.mu.Lock()
+		delete(n.punching, id)
+		n.mu.Unlock()
+		close(p.done)
+		n.cfg.Observer.HolePunch(id, p.err)
+		if p.err != nil {
+			n.cfg.Logger.Debug("p2p hole punching failed", "peer", id.ShortString(), "err", p.err)
+		}
+		return p.err
+	}
+
+	select {
+	case <-p.done:
+		return p.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// holePunch runs one attempt of HolePunch.
+func (n *Node) holePunch(ctx context.Context, id ID) error {
+	if n.initiates(id) {
+		return n.ConnectPeer(ctx, id)
+	}
+	info, err := n.FindPeer(ctx, id)
+	if err != nil {
+		return err
+	}
+	// The peer answers once its offer has turned into a connection, which takes longer than a request
+	req := &snp2p.Envelope{Body: &snp2p.Envelope_HolePunch{HolePunch: &snp2p.HolePunch{}}}
+	if _, err := n.requestWithin(ctx, 5*n.cfg.RequestTimeout, id, info.Via, req); err != nil {
+		return err
+	}
+	if !n.Connected(id) {
+		return ErrNoRoute
+	}
+	return nil
+}
+
+// acceptHolePunch serves the hole punching request of a peer with a higher ID by sending it an offer.
+func (n *Node) acceptHolePunch(src ID, req *snp2p.Envelope) {
+	resp := &snp2p.Envelope{Body: &snp2p.Envelope_Ack{Ack: &snp2p.Ack{}}}
+	if !n.initiates(src) {
+		resp.Body = errorBody(ErrUnexpectedResponse)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 4*n.cfg.RequestTimeout)
+		defer cancel()
+		if err := n.HolePunch(ctx, src); err != nil {
+			resp.Body = errorBody(err)
+		}
+	}
+	n.respond(src, req, resp)
+}
+
+// upgrade starts hole punching in the background for a peer whose envelopes arrive through a relay,
+// unless disabled, already running or backing off after failed attempts.
+func (n *Node) upgrade(id ID) {
+	if !n.cfg.HolePunch || n.cfg.Transport == nil || n.Connected(id) {
+		return
+	}
+	n.mu.Lock()
+	if until, ok := n.upgrades[id]; ok && time.Now().Before(until) {
+		n.mu.Unlock()
+		return
+	}
+	n.upgrades[id] = time.Now().Add(holePunchBackoff)
+	n.mu.Unlock()
+
+	go func() {
+		for attempt := 1; ; attempt++ {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*n.cfg.RequestTimeout)
+			err := n.HolePunch(ctx, id)
+			cancel()
+			if err == nil || attempt == holePunchAttempts {
+				if err == nil {
+					n.mu.Lock()
+					delete(n.upgrades, id)
+					n.mu.Unlock()
+				}
+				return
+			}
+			select {
+			case <-time.After(time.Duration(attempt) * holePunchRetry):
+			case <-n.closeChan:
+				return
+			}
+		}
+	}()
+}
+
+// expireUpgrades forgets the peers whose upgrade backoff has passed.
+func (n *Node) expireUpgrades() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	now := time.Now()
+	for id, until := range n.upgrades {
+		if now.After(until) {
+			delete(n.upgrades, id)
+		}
+	}
+}
+
+// initiates reports whether this node sends the offer of a direct connection to id.
+func (n *Node) initiates(id ID) bool {
+	return bytes.Compare(n.id[:], id[:]) < 0
+}
@@ -0,0 +1,109 @@
+package p2p
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"math/bits"
+	"strings"
+)
+
+// IDLength is the length of a peer ID in bytes.
+const IDLength = 32
+
+// ErrInvalidID is returned when parsing a malformed peer ID.
+var ErrInvalidID = errors.New("invalid peer id")
+
+// idEncoding is the textual encoding of peer IDs: lowercase base32 without padding.
+var idEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ID is a 256-bit identifier in the DHT key space.
+// Peer IDs are the SHA-256 digest of the peer's Ed25519 public key, so they are stable across
+// restarts as long as the key is kept, and cannot be claimed without the private key.
+type ID [IDLength]byte
+
+// IDFromPublicKey derives the peer ID of an Ed25519 public key.
+func IDFromPublicKey(pub ed25519.PublicKey) ID {
+	return ID(sha256.Sum256(pub))
+}
+
+// KeyFor derives a DHT key from arbitrary data, e.g. a record name.
+func KeyFor(data []byte) ID {
+	return ID(sha256.Sum256(data))
+}
+
+// RandomID returns a uniformly random ID, used for refreshing routing table buckets.
+func RandomID() ID {
+	var id ID
+	rand.Read(id[:])
+	return id
+}
+
+// IDFromBytes converts a 32-byte slice into an ID.
+func IDFromBytes(b []byte) (ID, error) {
+	if len(b) != IDLength {
+		return ID{}, ErrInvalidID
+	}
+	return ID(b), nil
+}
+
+// ParseID parses the textual form of an ID produced by String.
+func ParseID(s string) (ID, error) {
+	b, err := idEncoding.DecodeString(strings.ToUpper(s))
+	if err != nil {
+		return ID{}, ErrInvalidID
+	}
+	return IDFromBytes(b)
+}
+
+// String returns the lowercase base32 form of the ID.
+func (id ID) String() string {
+	return strings.ToLower(idEncoding.EncodeToString(id[:]))
+}
+
+// ShortString returns an abbreviated form of the ID for logs.
+func (id ID) ShortString() string {
+	return id.String()[:10]
+}
+
+// Bytes returns the ID as a byte slice.
+func (id ID) Bytes() []byte {
+	return id[:]
+}
+
+// IsZero reports whether the ID is the zero value.
+func (id ID) IsZero() bool {
+	return id == ID{}
+}
+
+// Distance returns the XOR distance between two IDs.
+func (id ID) Distance(other ID) ID {
+	var d ID
+	for i := range id {
+		d[i] = id[i] ^ other[i]
+	}
+	return d
+}
+
+// Closer reports whether a is strictly closer to id than b in XOR distance.
+func (id ID) Closer(a, b ID) bool {
+	for i := range id {
+		da, db := id[i]^a[i], id[i]^b[i]
+		if da != db {
+			return da < db
+		}
+	}
+	return false
+}
+
+// CommonPrefixLen returns the number of leading bits a and b have in common.
+func CommonPrefixLen(a, b ID) int {
+	for i := range a {
+		if x := a[i] ^ b[i]; x != 0 {
+			return i*8 + bits.LeadingZeros8(x)
+		}
+	}
+	return IDLength * 8
+}
@@ -0,0 +1,606 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pkg.gfire.dev/supernet/codec"
+	"pkg.gfire.dev/supernet/logging"
+	"pkg.gfire.dev/supernet/msgconn"
+	snp2p "pkg.gfire.dev/supernet/proto/snp2p/v1alpha1"
+)
+
+var (
+	// ErrClosed is returned when using a node that has been closed
+	ErrClosed = errors.New("p2p node closed")
+	// ErrNoRoute is returned when a peer is neither connected nor reachable through a relay
+	ErrNoRoute = errors.New("no route to peer")
+	// ErrHandshakeFailed is returned when a remote peer fails to prove ownership of its ID
+	ErrHandshakeFailed = errors.New("p2p handshake failed")
+	// ErrUnexpectedPeer is returned when a connection turns out to belong to a different peer than expected
+	ErrUnexpectedPeer = errors.New("unexpected peer id")
+	// ErrUnexpectedResponse is returned when a peer answers a request with the wrong message type
+	ErrUnexpectedResponse = errors.New("unexpected response")
+	// ErrNoTransport is returned when a direct connection is requested but no transport is configured
+	ErrNoTransport = errors.New("no direct transport configured")
+)
+
+const (
+	// defaultMaxConns is the default limit of simultaneously open direct connections
+	defaultMaxConns = 64
+	// defaultRequestTimeout is the default time to wait for a response to a request
+	defaultRequestTimeout = 10 * time.Second
+	// defaultHopLimit is the number of times an envelope may be forwarded
+	defaultHopLimit = 4
+	// helloSignaturePrefix domain-separates handshake signatures
+	helloSignaturePrefix = "supernet-p2p-hello:"
+)
+
+// Version is the version of the overlay protocol spoken by this package, announced in the handshake.
+// Version 1 predates negotiation.
+const Version = 2
+
+// Config configures a Node.
+type Config struct {
+	// PrivateKey is the Ed25519 identity key of the node; a new key is generated if nil.
+	PrivateKey ed25519.PrivateKey
+	// Addrs lists the addresses the node can be dialed at directly (typically empty for browser nodes).
+	Addrs []string
+	// Dial connects to peer addresses, e.g. bootstrap nodes. Defaults to WebSocket dialing under js/wasm.
+	Dial DialFunc
+	// Transport establishes direct connections to peers found through the DHT, e.g. over WebRTC.
+	// Without a transport, such peers are reached through relays only.
+	Transport Transport
+	// HolePunch upgrades peers whose envelopes arrive through a relay to direct connections over
+	// Transport in the background, falling back to the relay when that fails. See Node.HolePunch.
+	HolePunch bool
+	// Peerstore remembers connected peers and their round trip times; Bootstrap redials them after a restart.
+	Peerstore Peerstore
+	// MaxConns limits the number of direct connections (default 64).
+	MaxConns int
+	// RequestTimeout bounds the time waiting for each response (default 10s).
+	RequestTimeout time.Duration
+	// Capabilities are announced to connecting peers; Node.Protocol reports those both sides support.
+	Capabilities codec.Capabilities
+	// MinVersion is the lowest overlay protocol version accepted from peers, 0 for any. Handshakes with
+	// older peers fail with an error matching ErrHandshakeFailed and codec.ErrIncompatible.
+	MinVersion uint32
+	// Observer receives connection and traffic events, e.g. for metrics.
+	Observer Observer
+	// Logger receives connection events. Defaults to logging.For("p2p").
+	Logger *slog.Logger
+}
+
+// Node is a participant of the peer-to-peer overlay.
+// It authenticates connections by peer ID, routes request/response envelopes directly or through relays,
+// maintains a Kademlia routing table and serves DHT queries from other nodes.
+type Node struct {
+	key  ed25519.PrivateKey
+	id   ID
+	cfg  Config
+	dial DialFunc
+
+	table *RoutingTable
+	store *valueStore
+
+	mu sync.Mutex
+	// conns holds the direct connections by peer ID
+	conns map[ID]*peerConn
+	// pending maps outstanding request IDs to the channel awaiting the response
+	pending map[uint64]chan *snp2p.Envelope
+	// punching holds the hole punching attempts in progress by peer ID
+	punching map[ID]*punch
+	// upgrades holds the time until which background hole punching to a peer is not started again
+	upgrades map[ID]time.Time
+
+	nextRequestID atomic.Uint64
+
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+// peerConn is an authenticated direct connection.
+type peerConn struct {
+	info      PeerInfo
+	conn      Conn
+	protocol  codec.Protocol
+	protected bool
+	lastUsed  atomic.Int64
+}
+
+// NewNode creates a node with the given configuration and starts its maintenance loop.
+func NewNode(cfg Config) (*Node, error) {
+	if cfg.PrivateKey == nil {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		cfg.PrivateKey = priv
+	}
+	if cfg.MaxConns <= 0 {
+		cfg.MaxConns = defaultMaxConns
+	}
+	if cfg.RequestTimeout <= 0 {
+		cfg.RequestTimeout = defaultRequestTimeout
+	}
+	if cfg.Observer == nil {
+		cfg.Observer = nopObserver{}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logging.For("p2p")
+	}
+
+	dial := cfg.Dial
+	if dial == nil {
+		dial = defaultDial
+	}
+
+	id := IDFromPublicKey(cfg.PrivateKey.Public().(ed25519.PublicKey))
+	n := &Node{
+		key:       cfg.PrivateKey,
+		id:        id,
+		cfg:       cfg,
+		dial:      dial,
+		table:     NewRoutingTable(id),
+		store:     newValueStore(),
+		conns:     make(map[ID]*peerConn),
+		pending:   make(map[uint64]chan *snp2p.Envelope),
+		punching:  make(map[ID]*punch),
+		upgrades:  make(map[ID]time.Time),
+		closeChan: make(chan struct{}),
+	}
+
+	go n.maintain()
+	return n, nil
+}
+
+// ID returns the peer ID of the node.
+func (n *Node) ID() ID {
+	return n.id
+}
+
+// PublicKey returns the Ed25519 public key of the node.
+func (n *Node) PublicKey() ed25519.PublicKey {
+	return n.key.Public().(ed25519.PublicKey)
+}
+
+// Info returns the PeerInfo describing this node.
+func (n *Node) Info() PeerInfo {
+	return PeerInfo{ID: n.id, PublicKey: n.PublicKey(), Addrs: n.cfg.Addrs}
+}
+
+// RoutingTable returns the routing table of the node.
+func (n *Node) RoutingTable() *RoutingTable {
+	return n.table
+}
+
+// Peers returns the IDs of all directly connected peers.
+func (n *Node) Peers() []ID {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	ids := make([]ID, 0, len(n.conns))
+	for id := range n.conns {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Connected reports whether there is a direct connection to the peer.
+func (n *Node) Connected(id ID) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, ok := n.conns[id]
+	return ok
+}
+
+// Protocol returns the overlay protocol negotiated with a directly connected peer: the lower of both
+// versions and the capabilities both sides announced, codec.LegacyVersion for peers predating negotiation.
+func (n *Node) Protocol(id ID) (codec.Protocol, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	pc, ok := n.conns[id]
+	if !ok {
+		return codec.Protocol{}, false
+	}
+	return pc.protocol, true
+}
+
+// Dial connects to a peer address, authenticates the peer and adds the connection to the node.
+// Connections created by Dial are protected from trimming, which makes them suitable for bootstrap nodes.
+func (n *Node) Dial(ctx context.Context, addr string) (PeerInfo, error) {
+	if n.dial == nil {
+		return PeerInfo{}, ErrNoTransport
+	}
+	conn, err := n.dial(ctx, addr)
+	if err != nil {
+		n.cfg.Logger.Debug("p2p dial failed", "addr", addr, "err", err)
+		return PeerInfo{}, err
+	}
+	info, err := n.addConn(ctx, conn, ID{}, true)
+	if err != nil {
+		return PeerInfo{}, err
+	}
+	if len(info.Addrs) == 0 {
+		info.Addrs = []string{addr}
+		// Remember the dialed address of peers that announce none, e.g. bootstrap nodes behind a proxy
+		n.storePeer(func(ctx context.Context, ps Peerstore) error {
+			return ps.Seen(ctx, info)
+		})
+	}
+	return info, nil
+}
+
+// AddConn performs the handshake on an established connection and serves it.
+// This is how native nodes hand over inbound connections accepted by a listener.
+func (n *Node) AddConn(ctx context.Context, conn Conn) (PeerInfo, error) {
+	return n.addConn(ctx, conn, ID{}, false)
+}
+
+// addConn authenticates conn, optionally checking the remote ID, registers it and starts serving it.
+func (n *Node) addConn(ctx context.Context, conn Conn, expect ID, protected bool) (PeerInfo, error) {
+	info, protocol, err := n.handshake(ctx, conn)
+	if err != nil {
+		n.cfg.Logger.Debug("p2p handshake failed", "err", err)
+		n.cfg.Observer.HandshakeFailed(err)
+		conn.Close()
+		return PeerInfo{}, err
+	}
+	if !expect.IsZero() && info.ID != expect {
+		conn.Close()
+		return PeerInfo{}, ErrUnexpectedPeer
+	}
+
+	pc := &peerConn{info: info, conn: conn, protocol: protocol, protected: protected}
+	pc.lastUsed.Store(time.Now().UnixNano())
+
+	n.mu.Lock()
+	select {
+	case <-n.closeChan:
+		n.mu.Unlock()
+		conn.Close()
+		return PeerInfo{}, ErrClosed
+	default:
+	}
+	old := n.conns[info.ID]
+	n.conns[info.ID] = pc
+	n.mu.Unlock()
+
+	// A newer connection to the same peer supersedes the old one
+	if old != nil {
+		old.conn.Close()
+	} else {
+		n.cfg.Logger.Debug("p2p peer connected", "peer", info.ID.ShortString())
+		n.cfg.Observer.PeerConnected(info.ID)
+	}
+
+	n.table.Update(info)
+	n.storePeer(func(ctx context.Context, ps Peerstore) error {
+		return ps.Seen(ctx, info)
+	})
+	go n.serve(pc)
+	n.trim()
+	return info, nil
+}
+
+// handshake exchanges Hello messages and signatures over a new connection and returns the peer and the
+// negotiated protocol.
+func (n *Node) handshake(ctx context.Context, conn Conn) (PeerInfo, codec.Protocol, error) {
+	type result struct {
+		info     PeerInfo
+		protocol codec.Protocol
+		err      error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		info, protocol, err := n.doHandshake(conn)
+		done <- result{info, protocol, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.info, r.protocol, r.err
+	case <-ctx.Done():
+		conn.Close()
+		return PeerInfo{}, codec.Protocol{}, ctx.Err()
+	}
+}
+
+// doHandshake runs the blocking part of the handshake.
+func (n *Node) doHandshake(conn Conn) (PeerInfo, codec.Protocol, error) {
+	nonce := make([]byte, 32)
+	rand.Read(nonce)
+
+	local := codec.Protocol{Version: Version, Capabilities: n.cfg.Capabilities}
+	hello := &snp2p.Hello{
+		PublicKey:    n.PublicKey(),
+		Nonce:        nonce,
+		Addresses:    n.cfg.Addrs,
+		Version:      local.Version,
+		Capabilities: uint64(local.Capabilities),
+	}
+	if err := msgconn.SendVT(conn, hello); err != nil {
+		return PeerInfo{}, codec.Protocol{}, err
+	}
+
+	remoteHello := &snp2p.Hello{}
+	if err := msgconn.RecvVT(conn, remoteHello); err != nil {
+		return PeerInfo{}, codec.Protocol{}, err
+	}
+	if len(remoteHello.PublicKey) != ed25519.PublicKeySize || len(remoteHello.Nonce) != 32 {
+		return PeerInfo{}, codec.Protocol{}, ErrHandshakeFailed
+	}
+	// Peers predating negotiation leave the version out
+	remote := codec.Protocol{Version: remoteHello.Version, Capabilities: codec.Capabilities(remoteHello.Capabilities)}
+	protocol, err := codec.Negotiate(local, remote, n.cfg.MinVersion)
+	if err != nil {
+		return PeerInfo{}, codec.Protocol{}, fmt.Errorf("%w: %w", ErrHandshakeFailed, err)
+	}
+
+	// Prove ownership of our key by signing the remote nonce
+	sig := ed25519.Sign(n.key, append([]byte(helloSignaturePrefix), remoteHello.Nonce...))
+	if err := msgconn.SendVT(conn, &snp2p.HelloProof{Signature: sig}); err != nil {
+		return PeerInfo{}, codec.Protocol{}, err
+	}
+
+	proof := &snp2p.HelloProof{}
+	if err := msgconn.RecvVT(conn, proof); err != nil {
+		return PeerInfo{}, codec.Protocol{}, err
+	}
+	pub := ed25519.PublicKey(remoteHello.PublicKey)
+	if !ed25519.Verify(pub, append([]byte(helloSignaturePrefix), nonce...), proof.Signature) {
+		return PeerInfo{}, codec.Protocol{}, ErrHandshakeFailed
+	}
+
+	id := IDFromPublicKey(pub)
+	if id == n.id {
+		return PeerInfo{}, codec.Protocol{}, ErrHandshakeFailed
+	}
+	return PeerInfo{ID: id, PublicKey: pub, Addrs: remoteHello.Addresses}, protocol, nil
+}
+
+// serve reads envelopes from a connection until it fails, then unregisters it.
+func (n *Node) serve(pc *peerConn) {
+	defer n.dropConn(pc)
+
+	for {
+		data, err := pc.conn.NextMessage()
+		if err != nil {
+			n.cfg.Logger.Debug("p2p peer connection lost", "peer", pc.info.ID.ShortString(), "err", err)
+			return
+		}
+		pc.lastUsed.Store(time.Now().UnixNano())
+		n.cfg.Observer.Traffic(len(data), 0)
+
+		env, err := codec.DecodeEnvelope(data)
+		if err != nil {
+			n.cfg.Logger.Debug("p2p malformed envelope", "peer", pc.info.ID.ShortString(), "err", err)
+			continue
+		}
+		if !bytes.Equal(env.Source, pc.info.ID[:]) {
+			n.cfg.Observer.Relayed(len(data), 0)
+		}
+		n.handleEnvelope(pc.info.ID, env)
+	}
+}
+
+// dropConn closes and unregisters a connection, removing the peer and every peer relayed through it from the table.
+func (n *Node) dropConn(pc *peerConn) {
+	pc.conn.Close()
+
+	n.mu.Lock()
+	current := n.conns[pc.info.ID] == pc
+	if current {
+		delete(n.conns, pc.info.ID)
+	}
+	n.mu.Unlock()
+
+	if current {
+		n.table.Remove(pc.info.ID)
+		n.cfg.Logger.Debug("p2p peer disconnected", "peer", pc.info.ID.ShortString())
+		n.cfg.Observer.PeerDisconnected(pc.info.ID)
+	}
+}
+
+// handleEnvelope dispatches an envelope received from a directly connected peer.
+func (n *Node) handleEnvelope(from ID, env *snp2p.Envelope) {
+	src, err := IDFromBytes(env.Source)
+	if err != nil {
+		return
+	}
+
+	// Forward envelopes addressed to other peers; this is what makes relayed peers reachable
+	if dst, err := IDFromBytes(env.Destination); err == nil && dst != n.id {
+		if env.HopLimit == 0 {
+			return
+		}
+		env.HopLimit--
+		n.send(dst, env)
+		return
+	}
+
+	// Learn a relayed route to the source if it is not directly connected
+	if src != from {
+		n.table.Update(PeerInfo{ID: src, Via: from})
+		n.upgrade(src)
+	}
+
+	if env.Response {
+		n.mu.Lock()
+		ch := n.pending[env.RequestId]
+		n.mu.Unlock()
+		if ch != nil {
+			select {
+			case ch <- env:
+			default:
+			}
+		}
+		return
+	}
+
+	go n.handleRequest(src, env)
+}
+
+// request sends a request envelope to dst, relaying through via if dst is not routable, and waits for the response.
+func (n *Node) request(ctx context.Context, dst, via ID, env *snp2p.Envelope) (*snp2p.Envelope, error) {
+	return n.requestWithin(ctx, n.cfg.RequestTimeout, dst, via, env)
+}
+
+// requestWithin is request waiting up to timeout for the response.
+func (n *Node) requestWithin(ctx context.Context, timeout time.Duration, dst, via ID, env *snp2p.Envelope) (*snp2p.Envelope, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	id := n.nextRequestID.Add(1)
+	env.Source = n.id.Bytes()
+	env.Destination = dst.Bytes()
+	env.RequestId = id
+	env.HopLimit = defaultHopLimit
+
+	ch := make(chan *snp2p.Envelope, 1)
+	n.mu.Lock()
+	n.pending[id] = ch
+	n.mu.Unlock()
+	defer func() {
+		n.mu.Lock()
+		delete(n.pending, id)
+		n.mu.Unlock()
+	}()
+
+	if err := n.sendVia(dst, via, env); err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-ch:
+		if e := resp.GetError(); e != nil {
+			return nil, errors.New(e.Message)
+		}
+		return resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-n.closeChan:
+		return nil, ErrClosed
+	}
+}
+
+// respond sends a response envelope for the given request.
+func (n *Node) respond(dst ID, req *snp2p.Envelope, resp *snp2p.Envelope) {
+	resp.Source = n.id.Bytes()
+	resp.Destination = dst.Bytes()
+	resp.RequestId = req.RequestId
+	resp.Response = true
+	resp.HopLimit = defaultHopLimit
+	n.send(dst, resp)
+}
+
+// sendVia sends an envelope to dst, falling back to the relay via when no route to dst is known.
+func (n *Node) sendVia(dst, via ID, env *snp2p.Envelope) error {
+	err := n.send(dst, env)
+	if err == ErrNoRoute && !via.IsZero() && via != n.id {
+		return n.send(via, env)
+	}
+	return err
+}
+
+// send delivers an envelope to the next hop towards dst.
+func (n *Node) send(dst ID, env *snp2p.Envelope) error {
+	pc := n.route(dst)
+	if pc == nil {
+		return ErrNoRoute
+	}
+
+	data, err := codec.EncodeEnvelope(env)
+	if err != nil {
+		return err
+	}
+	pc.lastUsed.Store(time.Now().UnixNano())
+	n.cfg.Observer.Traffic(0, len(data))
+	if pc.info.ID != dst || !bytes.Equal(env.Source, n.id[:]) {
+		n.cfg.Observer.Relayed(0, len(data))
+	}
+	return pc.conn.Send(data)
+}
+
+// route returns the direct connection used as next hop towards dst.
+func (n *Node) route(dst ID) *peerConn {
+	n.mu.Lock()
+	pc := n.conns[dst]
+	n.mu.Unlock()
+	if pc != nil {
+		return pc
+	}
+
+	info, ok := n.table.Find(dst)
+	if !ok || !info.Relayed() {
+		return nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.conns[info.Via]
+}
+
+// trim closes the least recently used unprotected connections while the connection limit is exceeded.
+func (n *Node) trim() {
+	n.mu.Lock()
+	var victims []*peerConn
+	for len(n.conns)-len(victims) > n.cfg.MaxConns {
+		var oldest *peerConn
+		for _, pc := range n.conns {
+			if pc.protected || contains(victims, pc) {
+				continue
+			}
+			if oldest == nil || pc.lastUsed.Load() < oldest.lastUsed.Load() {
+				oldest = pc
+			}
+		}
+		if oldest == nil {
+			break
+		}
+		victims = append(victims, oldest)
+	}
+	n.mu.Unlock()
+
+	for _, pc := range victims {
+		n.dropConn(pc)
+	}
+}
+
+// Close closes all connections and stops the node.
+func (n *Node) Close() error {
+	n.closeOnce.Do(func() {
+		close(n.closeChan)
+
+		n.mu.Lock()
+		conns := make([]*peerConn, 0, len(n.conns))
+		for _, pc := range n.conns {
+			conns = append(conns, pc)
+		}
+		n.mu.Unlock()
+
+		for _, pc := range conns {
+			n.dropConn(pc)
+		}
+	})
+	return nil
+}
+
+// contains reports whether pc is in list.
+func contains(list []*peerConn, pc *peerConn) bool {
+	for _, p := range list {
+		if p == pc {
+			return true
+		}
+	}
+	return false
+}
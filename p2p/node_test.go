@@ -0,0 +1,250 @@
+package p2p_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"log/slog"
+	"sort"
+	"testing"
+	"time"
+
+	"pkg.gfire.dev/supernet/codec"
+	"pkg.gfire.dev/supernet/memtransport"
+	"pkg.gfire.dev/supernet/p2p"
+	snp2p "pkg.gfire.dev/supernet/proto/snp2p/v1alpha1"
+)
+
+// testTimeout bounds every blocking call of a test
+const testTimeout = 10 * time.Second
+
+// newNode creates a node that is closed when the test ends.
+func newNode(t *testing.T, cfg p2p.Config) *p2p.Node {
+	t.Helper()
+	cfg.Logger = slog.New(slog.DiscardHandler)
+	n, err := p2p.NewNode(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { n.Close() })
+	return n
+}
+
+// connect connects a and b directly over an in-memory pipe.
+func connect(t *testing.T, a, b *p2p.Node) {
+	t.Helper()
+	ca, cb := memtransport.Pipe(memtransport.Config{})
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		_, err := b.AddConn(ctx, cb)
+		errc <- err
+	}()
+	if _, err := a.AddConn(ctx, ca); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDial(t *testing.T) {
+	network := memtransport.NewNet(memtransport.Config{})
+	l, err := network.Listen("bootstrap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	server := newNode(t, p2p.Config{Capabilities: 3})
+	go func() {
+		for {
+			conn, err := l.Accept(context.Background())
+			if err != nil {
+				return
+			}
+			go server.AddConn(context.Background(), conn)
+		}
+	}()
+	client := newNode(t, p2p.Config{
+		Capabilities: 1,
+		Dial: func(ctx context.Context, addr string) (p2p.Conn, error) {
+			return network.Dial(ctx, addr)
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	info, err := client.Dial(ctx, "bootstrap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != server.ID() || len(info.Addrs) != 1 || info.Addrs[0] != "bootstrap" {
+		t.Fatalf("dialed %+v", info)
+	}
+	if p, ok := client.Protocol(server.ID()); !ok || p.Version != p2p.Version || p.Capabilities != 1 {
+		t.Fatalf("protocol %+v, %v", p, ok)
+	}
+	if _, err := client.Ping(ctx, server.ID()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := client.RoutingTable().Find(server.ID()); !ok {
+		t.Fatal("dialed peer missing from the routing table")
+	}
+}
+
+func TestFindNode(t *testing.T) {
+	// Every node knows the hub only; lookups learn the others from it and reach them through it
+	hub := newNode(t, p2p.Config{})
+	client := newNode(t, p2p.Config{})
+	connect(t, client, hub)
+	for range 2 * p2p.BucketSize {
+		connect(t, newNode(t, p2p.Config{}), hub)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	target := p2p.RandomID()
+	peers, err := client.ClosestPeers(ctx, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The lookup finds the hub and the peers in its table, which may have dropped some from full buckets
+	known := []p2p.ID{hub.ID()}
+	for _, p := range hub.RoutingTable().Closest(target, 3*p2p.BucketSize) {
+		if p.ID != client.ID() {
+			known = append(known, p.ID)
+		}
+	}
+	sort.Slice(known, func(i, j int) bool { return target.Closer(known[i], known[j]) })
+	if len(peers) != p2p.BucketSize {
+		t.Fatalf("found %d peers, want %d", len(peers), p2p.BucketSize)
+	}
+	for i, p := range peers {
+		if p.ID != known[i] {
+			t.Fatalf("peer %d is %s, want %s", i, p.ID.ShortString(), known[i].ShortString())
+		}
+	}
+
+	// A peer beyond the closest is found by its own lookup, relayed through the hub
+	far := known[len(known)-1]
+	if far == hub.ID() {
+		far = known[len(known)-2]
+	}
+	info, err := client.FindPeer(ctx, far)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Via != hub.ID() {
+		t.Fatalf("found %s via %s, want via the hub", far.ShortString(), info.Via.ShortString())
+	}
+	if _, err := client.FindPeer(ctx, p2p.RandomID()); err != p2p.ErrNotFound {
+		t.Fatalf("FindPeer of an unknown ID: %v, want ErrNotFound", err)
+	}
+}
+
+// fakePeer answers the handshake of a node with hello and proof, each skipped if nil.
+func fakePeer(conn *memtransport.Conn, hello *snp2p.Hello, proof *snp2p.HelloProof) {
+	if _, err := conn.NextMessage(); err != nil {
+		return
+	}
+	if hello == nil {
+		return
+	}
+	data, _ := hello.MarshalVT()
+	conn.Send(data)
+	if _, err := conn.NextMessage(); err != nil || proof == nil {
+		return
+	}
+	data, _ = proof.MarshalVT()
+	conn.Send(data)
+}
+
+func TestHandshakeErrors(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, 32)
+	hello := &snp2p.Hello{PublicKey: pub, Nonce: nonce, Version: p2p.Version}
+
+	for name, tc := range map[string]struct {
+		hello *snp2p.Hello
+		proof *snp2p.HelloProof
+		want  error
+	}{
+		"short key": {&snp2p.Hello{PublicKey: pub[:16], Nonce: nonce, Version: p2p.Version}, nil, p2p.ErrHandshakeFailed},
+		"version":   {&snp2p.Hello{PublicKey: pub, Nonce: nonce, Version: 1}, nil, codec.ErrIncompatible},
+		"proof":     {hello, &snp2p.HelloProof{Signature: make([]byte, ed25519.SignatureSize)}, p2p.ErrHandshakeFailed},
+		"silent":    {nil, nil, context.DeadlineExceeded},
+	} {
+		t.Run(name, func(t *testing.T) {
+			n := newNode(t, p2p.Config{MinVersion: p2p.Version})
+			a, b := memtransport.Pipe(memtransport.Config{})
+			defer b.Close()
+			go fakePeer(b, tc.hello, tc.proof)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			if _, err := n.AddConn(ctx, a); !errors.Is(err, tc.want) {
+				t.Fatalf("AddConn: %v, want %v", err, tc.want)
+			}
+			if len(n.Peers()) != 0 {
+				t.Fatal("failed connection registered")
+			}
+		})
+	}
+}
+
+func TestConnErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	// Both ends of a connection to itself present the same key
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := newNode(t, p2p.Config{PrivateKey: key}), newNode(t, p2p.Config{PrivateKey: key})
+	ca, cb := memtransport.Pipe(memtransport.Config{})
+	go b.AddConn(ctx, cb)
+	if _, err := a.AddConn(ctx, ca); err != p2p.ErrHandshakeFailed {
+		t.Fatalf("AddConn to itself: %v, want ErrHandshakeFailed", err)
+	}
+
+	// A closed connection fails the handshake
+	ca, cb = memtransport.Pipe(memtransport.Config{})
+	cb.Close()
+	if _, err := a.AddConn(ctx, ca); err == nil {
+		t.Fatal("handshake over a closed connection succeeded")
+	}
+
+	// Losing the connection removes the peer and the routes through it
+	c := newNode(t, p2p.Config{})
+	connect(t, a, c)
+	if _, err := a.Ping(ctx, c.ID()); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	deadline := time.Now().Add(testTimeout)
+	for a.Connected(c.ID()) {
+		if time.Now().After(deadline) {
+			t.Fatal("closed peer still connected")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := a.Ping(ctx, c.ID()); err != p2p.ErrNoRoute {
+		t.Fatalf("Ping after disconnect: %v, want ErrNoRoute", err)
+	}
+
+	// A closed node refuses connections
+	d := newNode(t, p2p.Config{})
+	a.Close()
+	ca, cb = memtransport.Pipe(memtransport.Config{})
+	go d.AddConn(ctx, cb)
+	if _, err := a.AddConn(ctx, ca); err != p2p.ErrClosed {
+		t.Fatalf("AddConn after Close: %v, want ErrClosed", err)
+	}
+}
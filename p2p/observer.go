@@ -0,0 +1,32 @@
+package p2p
+
+// Observer receives node events, e.g. to export metrics. Its methods are called concurrently and must
+// not block.
+type Observer interface {
+	// PeerConnected is called when a direct connection to a peer was authenticated, unless it replaces an
+	// existing connection to the same peer.
+	PeerConnected(id ID)
+	// PeerDisconnected is called when the direct connection to a peer ended.
+	PeerDisconnected(id ID)
+	// HandshakeFailed is called when a new connection failed to authenticate.
+	HandshakeFailed(err error)
+	// Traffic is called for every envelope sent to or received from a directly connected peer, with its
+	// size in bytes.
+	Traffic(in, out int)
+	// Relayed is called for the part of that traffic travelling through a relay: envelopes exchanged
+	// with peers that are not directly connected, and envelopes forwarded for other peers.
+	Relayed(in, out int)
+	// HolePunch is called when an attempt to replace the relayed route to a peer with a direct
+	// connection ended, with a nil error on success.
+	HolePunch(id ID, err error)
+}
+
+// nopObserver ignores all events.
+type nopObserver struct{}
+
+func (nopObserver) PeerConnected(ID)      {}
+func (nopObserver) PeerDisconnected(ID)   {}
+func (nopObserver) HandshakeFailed(error) {}
+func (nopObserver) Traffic(int, int)      {}
+func (nopObserver) Relayed(int, int)      {}
+func (nopObserver) HolePunch(ID, error)   {}
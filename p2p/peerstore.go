@@ -0,0 +1,97 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// reconnectPeers is the number of remembered peers Bootstrap redials
+	reconnectPeers = 8
+	// peerstoreTimeout bounds every peer store operation
+	peerstoreTimeout = 5 * time.Second
+)
+
+// Peerstore remembers peers across restarts, so nodes reconnect to the peers they knew instead of
+// rediscovering the network through bootstrap nodes. Package peerstore provides persistent implementations.
+// Its methods are called concurrently.
+type Peerstore interface {
+	// Known returns remembered peers with addresses to dial, the most promising first.
+	Known(ctx context.Context) ([]PeerInfo, error)
+	// Seen records a successful connection to a peer. info.Addrs is empty if the peer did not announce any.
+	Seen(ctx context.Context, info PeerInfo) error
+	// AddLatency records a round trip time measured to a peer.
+	AddLatency(ctx context.Context, id ID, rtt time.Duration) error
+	// Failed records a failed attempt to connect to a remembered peer.
+	Failed(ctx context.Context, id ID) error
+}
+
+// reconnect dials up to reconnectPeers remembered peers concurrently and returns the number reached.
+func (n *Node) reconnect(ctx context.Context) int {
+	if n.cfg.Peerstore == nil || n.dial == nil {
+		return 0
+	}
+	known, err := n.cfg.Peerstore.Known(ctx)
+	if err != nil {
+		n.cfg.Logger.Warn("p2p peerstore read failed", "err", err)
+		return 0
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		connected int
+		started   int
+	)
+	for _, info := range known {
+		if started == reconnectPeers {
+			break
+		}
+		if info.ID == n.id || n.Connected(info.ID) {
+			continue
+		}
+		started++
+		wg.Go(func() {
+			if n.redial(ctx, info) {
+				mu.Lock()
+				connected++
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	return connected
+}
+
+// redial connects to a remembered peer at its stored addresses and reports whether it succeeded.
+func (n *Node) redial(ctx context.Context, info PeerInfo) bool {
+	for _, addr := range info.Addrs {
+		conn, err := n.dial(ctx, addr)
+		if err != nil {
+			continue
+		}
+		// The address may belong to another peer by now, which addConn rejects
+		if _, err := n.addConn(ctx, conn, info.ID, false); err == nil {
+			return true
+		}
+	}
+	n.cfg.Logger.Debug("p2p reconnect failed", "peer", info.ID.ShortString())
+	n.storePeer(func(ctx context.Context, ps Peerstore) error {
+		return ps.Failed(ctx, info.ID)
+	})
+	return false
+}
+
+// storePeer runs fn against the configured peer store, if any, logging failures.
+// It does not inherit the caller's context so that updates complete even when the caller gives up.
+func (n *Node) storePeer(fn func(context.Context, Peerstore) error) {
+	if n.cfg.Peerstore == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), peerstoreTimeout)
+	defer cancel()
+	if err := fn(ctx, n.cfg.Peerstore); err != nil {
+		n.cfg.Logger.Debug("p2p peerstore update failed", "err", err)
+	}
+}
@@ -0,0 +1,161 @@
+package p2p
+
+import (
+	"crypto/ed25519"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BucketSize is the maximum number of peers per k-bucket (Kademlia's k).
+const BucketSize = 20
+
+// PeerInfo describes a peer known to the routing table.
+type PeerInfo struct {
+	ID        ID                // Peer ID
+	PublicKey ed25519.PublicKey // Ed25519 public key the ID was derived from
+	Addrs     []string          // Addresses the peer can be dialed at directly
+	Via       ID                // Peer relaying traffic to this peer, zero for direct connections
+}
+
+// Relayed reports whether the peer is only reachable through another peer.
+func (p PeerInfo) Relayed() bool {
+	return !p.Via.IsZero()
+}
+
+// tableEntry is a routing table slot.
+type tableEntry struct {
+	info     PeerInfo
+	lastSeen time.Time
+}
+
+// RoutingTable is a Kademlia routing table of k-buckets indexed by common prefix length with the local ID.
+// Each bucket is ordered from least to most recently seen. The table only contains peers that are
+// currently reachable, either over a direct connection or relayed through a directly connected peer.
+type RoutingTable struct {
+	self ID
+
+	mu      sync.RWMutex
+	buckets [IDLength*8 + 1][]*tableEntry
+}
+
+// NewRoutingTable creates an empty routing table for the given local ID.
+func NewRoutingTable(self ID) *RoutingTable {
+	return &RoutingTable{self: self}
+}
+
+// Update inserts a peer or refreshes it as most recently seen.
+// Direct entries replace relayed entries of the same peer, never the other way round.
+// Returns false if the bucket is full and the peer was not added.
+func (t *RoutingTable) Update(info PeerInfo) bool {
+	if info.ID == t.self {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	idx := CommonPrefixLen(t.self, info.ID)
+	bucket := t.buckets[idx]
+
+	for i, e := range bucket {
+		if e.info.ID != info.ID {
+			continue
+		}
+		if info.Relayed() && !e.info.Relayed() {
+			info.Via = ID{}
+		}
+		if len(info.Addrs) == 0 {
+			info.Addrs = e.info.Addrs
+		}
+		e.info = info
+		e.lastSeen = time.Now()
+		// Move the entry to the tail of the bucket
+		copy(bucket[i:], bucket[i+1:])
+		bucket[len(bucket)-1] = e
+		return true
+	}
+
+	if len(bucket) >= BucketSize {
+		// Prefer a direct peer over the least recently seen relayed entry
+		if info.Relayed() {
+			return false
+		}
+		for i, e := range bucket {
+			if e.info.Relayed() {
+				bucket = append(bucket[:i], bucket[i+1:]...)
+				break
+			}
+		}
+		if len(bucket) >= BucketSize {
+			return false
+		}
+	}
+
+	t.buckets[idx] = append(bucket, &tableEntry{info: info, lastSeen: time.Now()})
+	return true
+}
+
+// Remove deletes a peer and every entry relayed through it.
+func (t *RoutingTable) Remove(id ID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for idx, bucket := range t.buckets {
+		kept := bucket[:0]
+		for _, e := range bucket {
+			if e.info.ID != id && e.info.Via != id {
+				kept = append(kept, e)
+			}
+		}
+		for i := len(kept); i < len(bucket); i++ {
+			bucket[i] = nil
+		}
+		t.buckets[idx] = kept
+	}
+}
+
+// Find returns the entry of the given peer.
+func (t *RoutingTable) Find(id ID) (PeerInfo, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, e := range t.buckets[CommonPrefixLen(t.self, id)] {
+		if e.info.ID == id {
+			return e.info, true
+		}
+	}
+	return PeerInfo{}, false
+}
+
+// Closest returns up to n peers closest to target in XOR distance, nearest first.
+func (t *RoutingTable) Closest(target ID, n int) []PeerInfo {
+	t.mu.RLock()
+	peers := make([]PeerInfo, 0, n)
+	for _, bucket := range t.buckets {
+		for _, e := range bucket {
+			peers = append(peers, e.info)
+		}
+	}
+	t.mu.RUnlock()
+
+	sort.Slice(peers, func(i, j int) bool {
+		return target.Closer(peers[i].ID, peers[j].ID)
+	})
+	if len(peers) > n {
+		peers = peers[:n]
+	}
+	return peers
+}
+
+// Size returns the number of peers in the table.
+func (t *RoutingTable) Size() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	n := 0
+	for _, bucket := range t.buckets {
+		n += len(bucket)
+	}
+	return n
+}
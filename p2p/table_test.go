@@ -0,0 +1,90 @@
+package p2p
+
+import "testing"
+
+// bucketID returns an ID sharing no prefix with the zero ID, so all such IDs land in the same bucket of a
+// table for the zero ID.
+func bucketID(i int) ID {
+	var id ID
+	id[0] = 0x80
+	id[IDLength-2], id[IDLength-1] = byte(i>>8), byte(i)
+	return id
+}
+
+func TestBucketInsertion(t *testing.T) {
+	table := NewRoutingTable(ID{})
+	if table.Update(PeerInfo{ID: ID{}}) {
+		t.Fatal("added the local ID")
+	}
+	for i := range BucketSize {
+		if !table.Update(PeerInfo{ID: bucketID(i)}) {
+			t.Fatalf("peer %d not added", i)
+		}
+	}
+	if table.Update(PeerInfo{ID: bucketID(BucketSize)}) {
+		t.Fatal("added a peer to a full bucket")
+	}
+	if !table.Update(PeerInfo{ID: bucketID(0)}) {
+		t.Fatal("known peer not refreshed in a full bucket")
+	}
+
+	// Other buckets have room of their own
+	other := bucketID(BucketSize)
+	other[0] = 0x40
+	if !table.Update(PeerInfo{ID: other}) {
+		t.Fatal("peer of another bucket not added")
+	}
+	if n := table.Size(); n != BucketSize+1 {
+		t.Fatalf("size %d, want %d", n, BucketSize+1)
+	}
+}
+
+func TestBucketRelayed(t *testing.T) {
+	table := NewRoutingTable(ID{})
+	via := bucketID(0)
+	via[0] = 0x40
+	table.Update(PeerInfo{ID: via})
+	for i := range BucketSize {
+		table.Update(PeerInfo{ID: bucketID(i), Via: via})
+	}
+
+	if table.Update(PeerInfo{ID: bucketID(BucketSize), Via: via}) {
+		t.Fatal("relayed peer added to a full bucket")
+	}
+	// A direct peer evicts the least recently seen relayed entry
+	if !table.Update(PeerInfo{ID: bucketID(BucketSize)}) {
+		t.Fatal("direct peer not added to a bucket of relayed peers")
+	}
+	if _, ok := table.Find(bucketID(0)); ok {
+		t.Fatal("least recently seen relayed entry kept")
+	}
+
+	// A relayed route never replaces a direct one
+	table.Update(PeerInfo{ID: bucketID(BucketSize), Addrs: []string{"addr"}})
+	table.Update(PeerInfo{ID: bucketID(BucketSize), Via: via})
+	if info, _ := table.Find(bucketID(BucketSize)); info.Relayed() || len(info.Addrs) != 1 {
+		t.Fatalf("direct entry became %+v", info)
+	}
+
+	table.Remove(via)
+	if n := table.Size(); n != 1 {
+		t.Fatalf("size %d after removing the relay, want 1", n)
+	}
+}
+
+func TestClosest(t *testing.T) {
+	table := NewRoutingTable(ID{})
+	for range 3 * BucketSize {
+		table.Update(PeerInfo{ID: RandomID()})
+	}
+	target := RandomID()
+	peers := table.Closest(target, BucketSize)
+	if len(peers) != BucketSize {
+		t.Fatalf("%d peers, want %d", len(peers), BucketSize)
+	}
+	for i := 1; i < len(peers); i++ {
+		if target.Closer(peers[i].ID, peers[i-1].ID) {
+			t.Fatalf("peer %d closer than peer %d", i, i-1)
+		}
+	}
+}
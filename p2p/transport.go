@@ -0,0 +1,32 @@
+package p2p
+
+import (
+	"context"
+)
+
+// Conn is a message-oriented, bidirectional connection to a remote peer.
+// It matches the NextMessage/Send/Close contract of wsjs.Conn and webrtcjs.DataChannel,
+// so those types can be used directly.
+type Conn interface {
+	// NextMessage blocks until the next message is received or the connection is closed.
+	NextMessage() ([]byte, error)
+	// Send sends a single message.
+	Send(data []byte) error
+	// Close closes the connection.
+	Close() error
+}
+
+// DialFunc establishes a connection to a peer address such as "wss://bootstrap.example.com/p2p".
+type DialFunc func(ctx context.Context, addr string) (Conn, error)
+
+// Transport establishes direct connections between peers that can only reach each other
+// through the overlay, such as browser nodes using WebRTC data channels.
+// Connection setup messages are exchanged over the overlay using a single offer/answer round trip.
+type Transport interface {
+	// Connect initiates a direct connection. exchange delivers the local offer to the remote peer
+	// and returns its answer.
+	Connect(ctx context.Context, exchange func(ctx context.Context, offer []byte) ([]byte, error)) (Conn, error)
+	// Accept answers an offer received from a remote peer. reply delivers the answer back to it;
+	// the connection is returned once it is established.
+	Accept(ctx context.Context, offer []byte, reply func(answer []byte) error) (Conn, error)
+}
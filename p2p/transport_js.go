@@ -0,0 +1,130 @@
+package p2p
+
+import (
+	"context"
+	"slices"
+
+	"pkg.gfire.dev/supernet/turn"
+	"pkg.gfire.dev/supernet/web/wasmlib/webrtcjs"
+	"pkg.gfire.dev/supernet/web/wasmlib/wsjs"
+)
+
+// dataChannelLabel is the label of the data channel carrying overlay traffic.
+const dataChannelLabel = "supernet-p2p"
+
+// defaultDial connects to bootstrap and relay nodes over WebSocket.
+var defaultDial DialFunc = func(ctx context.Context, addr string) (Conn, error) {
+	return wsjs.DialContext(ctx, addr)
+}
+
+// WebRTCTransport establishes direct browser-to-browser connections over WebRTC data channels.
+type WebRTCTransport struct {
+	// Config configures the RTCPeerConnection, in particular the STUN/TURN servers.
+	Config webrtcjs.Config
+	// TURN provides TURN servers with fresh credentials, added to Config.ICEServers for every connection.
+	// ICE falls back to relaying through them when the peers cannot reach each other directly.
+	TURN *turn.Source
+}
+
+// newPeerConnection creates a peer connection with the configured and the current TURN servers.
+func (t *WebRTCTransport) newPeerConnection(ctx context.Context) (*webrtcjs.PeerConnection, error) {
+	cfg := t.Config
+	if t.TURN != nil {
+		servers, err := t.TURN.ICEServers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ICEServers = slices.Clone(cfg.ICEServers)
+		for _, s := range servers {
+			cfg.ICEServers = append(cfg.ICEServers, webrtcjs.ICEServer(s))
+		}
+	}
+	return webrtcjs.NewPeerConnection(cfg)
+}
+
+// Connect creates an offer with a data channel, exchanges it for an answer and waits for the channel to open.
+func (t *WebRTCTransport) Connect(ctx context.Context, exchange func(ctx context.Context, offer []byte) ([]byte, error)) (Conn, error) {
+	pc, err := t.newPeerConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dc := pc.CreateDataChannel(dataChannelLabel, webrtcjs.DataChannelOptions{})
+	offer, err := pc.CreateOfferContext(ctx)
+	if err != nil {
+		pc.Close()
+		return nil, err
+	}
+
+	answer, err := exchange(ctx, []byte(offer))
+	if err != nil {
+		pc.Close()
+		return nil, err
+	}
+	if err := pc.AcceptAnswer(string(answer)); err != nil {
+		pc.Close()
+		return nil, err
+	}
+
+	return waitOpen(ctx, pc, dc)
+}
+
+// Accept answers an offer and waits for the remote peer's data channel to open.
+func (t *WebRTCTransport) Accept(ctx context.Context, offer []byte, reply func(answer []byte) error) (Conn, error) {
+	pc, err := t.newPeerConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	answer, err := pc.AcceptOfferContext(ctx, string(offer))
+	if err != nil {
+		pc.Close()
+		return nil, err
+	}
+	if err := reply([]byte(answer)); err != nil {
+		pc.Close()
+		return nil, err
+	}
+
+	dc, err := pc.AcceptDataChannelContext(ctx)
+	if err != nil {
+		pc.Close()
+		return nil, err
+	}
+	return waitOpen(ctx, pc, dc)
+}
+
+// waitOpen waits until dc is open, the connection fails or ctx is done.
+func waitOpen(ctx context.Context, pc *webrtcjs.PeerConnection, dc *webrtcjs.DataChannel) (Conn, error) {
+	// A failed connection never opens the channel, so failing ends the wait like ctx
+	waitCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		select {
+		case <-pc.Failed():
+			cancel(webrtcjs.ErrConnectionFailed)
+		case <-waitCtx.Done():
+		}
+	}()
+
+	if err := dc.WaitOpenContext(waitCtx); err != nil {
+		pc.Close()
+		if cause := context.Cause(waitCtx); cause != nil {
+			return nil, cause
+		}
+		return nil, err
+	}
+	return &rtcConn{DataChannel: dc, pc: pc}, nil
+}
+
+// rtcConn is a data channel that also owns its peer connection.
+type rtcConn struct {
+	*webrtcjs.DataChannel
+	pc *webrtcjs.PeerConnection
+}
+
+// Close closes the data channel and its peer connection.
+func (c *rtcConn) Close() error {
+	c.DataChannel.Close()
+	return c.pc.Close()
+}
@@ -0,0 +1,333 @@
+// Package peerstore persists the peers a node has met, so it can reconnect quickly after a restart instead of
+// rediscovering the network through bootstrap nodes. It records the addresses, public keys, signed records,
+// connection failures and recent round trip times of known peers.
+//
+// Native nodes keep the store in a bbolt database file, browser nodes in an IndexedDB database. A Store
+// implements both p2p.Peerstore and gossip.Peerstore and may be shared by the two layers:
+//
+//	ps, err := peerstore.Open(ctx, "peers.db")
+//	if err != nil {
+//		return err
+//	}
+//	defer ps.Close()
+//	node, err := p2p.NewNode(p2p.Config{Peerstore: ps})
+package peerstore
+
+import (
+	"cmp"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/p2p"
+	snpeerstore "pkg.gfire.dev/supernet/proto/snpeerstore/v1alpha1"
+)
+
+var (
+	// ErrNotFound is returned when a peer is not in the store
+	ErrNotFound = errors.New("peer not in store")
+	// ErrClosed is returned when using a store that has been closed
+	ErrClosed = errors.New("peerstore closed")
+)
+
+const (
+	// maxLatencySamples is the number of round trip times kept per peer
+	maxLatencySamples = 16
+	// maxKnown is the number of peers returned by Known
+	maxKnown = 64
+)
+
+// Peer is the stored state of a known peer.
+type Peer struct {
+	ID        p2p.ID            // Peer ID
+	PublicKey ed25519.PublicKey // Ed25519 public key, nil if not learned yet
+	Addrs     []string          // Last known addresses the peer can be dialed at
+	Record    []byte            // Latest signed identity record, as exchanged by gossip
+	LastSeen  time.Time         // Time of the last successful connection
+	Failures  int               // Consecutive failed connection attempts since LastSeen
+	Latency   []Sample          // Recent round trip times, oldest first
+}
+
+// Sample is a round trip time measured to a peer.
+type Sample struct {
+	At  time.Time     // Time of the measurement
+	RTT time.Duration // Round trip time
+}
+
+// RTT returns the mean of the recent round trip times of the peer, or 0 if none were measured.
+func (p *Peer) RTT() time.Duration {
+	if len(p.Latency) == 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, s := range p.Latency {
+		sum += s.RTT
+	}
+	return sum / time.Duration(len(p.Latency))
+}
+
+// backend is a key-value store holding encoded peers by peer ID.
+type backend interface {
+	// get returns the value stored under key, or ErrNotFound.
+	get(ctx context.Context, key []byte) ([]byte, error)
+	// put stores value under key.
+	put(ctx context.Context, key, value []byte) error
+	// delete removes key; missing keys are not an error.
+	delete(ctx context.Context, key []byte) error
+	// each calls fn with every key and value.
+	each(ctx context.Context, fn func(key, value []byte) error) error
+	// close releases the backend.
+	close() error
+}
+
+// Store is a persistent set of known peers. It is safe for concurrent use.
+type Store struct {
+	// mu serializes read-modify-write updates of peers
+	mu sync.Mutex
+	b  backend
+}
+
+// Get returns the stored state of a peer, or ErrNotFound.
+func (s *Store) Get(ctx context.Context, id p2p.ID) (Peer, error) {
+	data, err := s.b.get(ctx, id[:])
+	if err != nil {
+		return Peer{}, err
+	}
+	return decode(id, data)
+}
+
+// Peers returns all stored peers, most recently seen first.
+func (s *Store) Peers(ctx context.Context) ([]Peer, error) {
+	var peers []Peer
+	err := s.b.each(ctx, func(key, value []byte) error {
+		id, err := p2p.IDFromBytes(key)
+		if err != nil {
+			// Skip foreign keys rather than failing the whole listing
+			return nil
+		}
+		p, err := decode(id, value)
+		if err != nil {
+			return nil
+		}
+		peers = append(peers, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(peers, func(a, b Peer) int {
+		return b.LastSeen.Compare(a.LastSeen)
+	})
+	return peers, nil
+}
+
+// Delete removes a peer from the store.
+func (s *Store) Delete(ctx context.Context, id p2p.ID) error {
+	return s.b.delete(ctx, id[:])
+}
+
+// Prune removes the peers not seen within maxAge and returns how many were removed.
+func (s *Store) Prune(ctx context.Context, maxAge time.Duration) (int, error) {
+	peers, err := s.Peers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-maxAge)
+	pruned := 0
+	for _, p := range peers {
+		if p.LastSeen.After(cutoff) {
+			continue
+		}
+		if err := s.b.delete(ctx, p.ID[:]); err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+// Close closes the underlying database.
+func (s *Store) Close() error {
+	return s.b.close()
+}
+
+// Known returns the most recently seen peers that have addresses to dial, peers with fewer failed attempts
+// first. It implements p2p.Peerstore.
+func (s *Store) Known(ctx context.Context) ([]p2p.PeerInfo, error) {
+	peers, err := s.Peers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	peers = slices.DeleteFunc(peers, func(p Peer) bool {
+		return len(p.Addrs) == 0 || len(p.PublicKey) != ed25519.PublicKeySize
+	})
+	// The sort is stable, so peers with equal failures stay ordered by LastSeen
+	slices.SortStableFunc(peers, func(a, b Peer) int {
+		return cmp.Compare(a.Failures, b.Failures)
+	})
+	if len(peers) > maxKnown {
+		peers = peers[:maxKnown]
+	}
+
+	infos := make([]p2p.PeerInfo, len(peers))
+	for i, p := range peers {
+		infos[i] = p2p.PeerInfo{ID: p.ID, PublicKey: p.PublicKey, Addrs: p.Addrs}
+	}
+	return infos, nil
+}
+
+// Seen records a successful connection to a peer, keeping the stored addresses if info has none.
+// It implements p2p.Peerstore.
+func (s *Store) Seen(ctx context.Context, info p2p.PeerInfo) error {
+	return s.update(ctx, info.ID, func(p *Peer) {
+		if len(info.PublicKey) == ed25519.PublicKeySize {
+			p.PublicKey = info.PublicKey
+		}
+		if len(info.Addrs) > 0 {
+			p.Addrs = info.Addrs
+		}
+		p.LastSeen = time.Now()
+		p.Failures = 0
+	})
+}
+
+// AddLatency records a round trip time measured to a peer, keeping the most recent samples.
+// It implements p2p.Peerstore.
+func (s *Store) AddLatency(ctx context.Context, id p2p.ID, rtt time.Duration) error {
+	return s.update(ctx, id, func(p *Peer) {
+		p.Latency = append(p.Latency, Sample{At: time.Now(), RTT: rtt})
+		if excess := len(p.Latency) - maxLatencySamples; excess > 0 {
+			p.Latency = slices.Delete(p.Latency, 0, excess)
+		}
+	})
+}
+
+// Failed records a failed attempt to connect to a peer. It implements p2p.Peerstore.
+func (s *Store) Failed(ctx context.Context, id p2p.ID) error {
+	return s.update(ctx, id, func(p *Peer) {
+		p.Failures++
+	})
+}
+
+// Records returns the signed identity records of the stored peers, most recently seen first.
+// It implements gossip.Peerstore.
+func (s *Store) Records(ctx context.Context) ([][]byte, error) {
+	peers, err := s.Peers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var records [][]byte
+	for _, p := range peers {
+		if len(p.Record) > 0 {
+			records = append(records, p.Record)
+		}
+	}
+	return records, nil
+}
+
+// SetRecord stores the latest signed identity record of a connected peer, together with its public key and
+// addresses. Records older than the stored one are ignored. It implements gossip.Peerstore.
+func (s *Store) SetRecord(ctx context.Context, record []byte) error {
+	rec, err := identity.OpenRecord(record)
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, rec.ID, func(p *Peer) {
+		p.LastSeen = time.Now()
+		p.Failures = 0
+		// Stored records that no longer open, e.g. expired ones, are replaced by any valid record
+		if stored, err := identity.OpenRecord(p.Record); err == nil && stored.Newer(rec) {
+			return
+		}
+		p.PublicKey = rec.PublicKey
+		p.Record = record
+		if len(rec.Addrs) > 0 {
+			p.Addrs = rec.Addrs
+		}
+	})
+}
+
+// Discovered stores the signed identity record of a peer found through discovery, without marking the peer
+// as seen. Records older than the stored one are ignored. It implements discovery.Peerstore.
+func (s *Store) Discovered(ctx context.Context, record []byte) error {
+	rec, err := identity.OpenRecord(record)
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, rec.ID, func(p *Peer) {
+		if stored, err := identity.OpenRecord(p.Record); err == nil && !rec.Newer(stored) {
+			return
+		}
+		p.PublicKey = rec.PublicKey
+		p.Record = record
+		if len(rec.Addrs) > 0 {
+			p.Addrs = rec.Addrs
+		}
+	})
+}
+
+// update applies fn to the stored state of a peer, creating it if the peer is unknown.
+func (s *Store) update(ctx context.Context, id p2p.ID, fn func(*Peer)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		p, err = Peer{ID: id}, nil
+	}
+	if err != nil {
+		return err
+	}
+	fn(&p)
+	data, err := encode(&p)
+	if err != nil {
+		return err
+	}
+	return s.b.put(ctx, id[:], data)
+}
+
+// encode marshals the stored state of a peer.
+func encode(p *Peer) ([]byte, error) {
+	msg := &snpeerstore.Peer{
+		PublicKey: p.PublicKey,
+		Addresses: p.Addrs,
+		Record:    p.Record,
+		Failures:  uint32(p.Failures),
+	}
+	if !p.LastSeen.IsZero() {
+		msg.LastSeenUnixMs = p.LastSeen.UnixMilli()
+	}
+	for _, s := range p.Latency {
+		msg.Latency = append(msg.Latency, &snpeerstore.LatencySample{
+			AtUnixMs: s.At.UnixMilli(),
+			RttUs:    s.RTT.Microseconds(),
+		})
+	}
+	return msg.MarshalVT()
+}
+
+// decode unmarshals the stored state of a peer.
+func decode(id p2p.ID, data []byte) (Peer, error) {
+	var msg snpeerstore.Peer
+	if err := msg.UnmarshalVT(data); err != nil {
+		return Peer{}, err
+	}
+	p := Peer{
+		ID:        id,
+		PublicKey: msg.PublicKey,
+		Addrs:     msg.Addresses,
+		Record:    msg.Record,
+		Failures:  int(msg.Failures),
+	}
+	if msg.LastSeenUnixMs != 0 {
+		p.LastSeen = time.UnixMilli(msg.LastSeenUnixMs)
+	}
+	for _, s := range msg.Latency {
+		p.Latency = append(p.Latency, Sample{At: time.UnixMilli(s.AtUnixMs), RTT: time.Duration(s.RttUs) * time.Microsecond})
+	}
+	return p, nil
+}
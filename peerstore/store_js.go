@@ -0,0 +1,149 @@
+package peerstore
+
+import (
+	"context"
+	"encoding/hex"
+	"sync/atomic"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/idbjs"
+)
+
+const (
+	// idbVersion is the schema version of peer store databases
+	idbVersion = 1
+	// idbStore is the object store holding peers, keyed by the hex peer ID in their id property
+	idbStore = "peers"
+)
+
+var (
+	// _Object is a cached reference to the Object constructor for stored values
+	_Object = js.Global().Get("Object")
+	// _Uint8Array is a cached reference to the Uint8Array constructor for encoded peers
+	_Uint8Array = js.Global().Get("Uint8Array")
+)
+
+// Open opens the peer store in the IndexedDB database with the given name, shared by all tabs of the origin.
+func Open(ctx context.Context, name string) (*Store, error) {
+	db, err := idbjs.Open(ctx, name, idbVersion, func(db *idbjs.DB, oldVersion, newVersion int) error {
+		if db.HasStore(idbStore) {
+			return nil
+		}
+		_, err := db.CreateStore(idbStore, idbjs.StoreOptions{KeyPath: "id"})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{b: &idbBackend{db: db}}, nil
+}
+
+// idbBackend stores peers in an IndexedDB object store as {id, peer} objects.
+type idbBackend struct {
+	db     *idbjs.DB
+	closed atomic.Bool
+}
+
+func (b *idbBackend) get(ctx context.Context, key []byte) ([]byte, error) {
+	store, tx, err := b.store(idbjs.ReadOnly)
+	if err != nil {
+		return nil, err
+	}
+	v, err := store.Get(js.ValueOf(hex.EncodeToString(key))).Wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Done(ctx); err != nil {
+		return nil, err
+	}
+	if v.IsUndefined() {
+		return nil, ErrNotFound
+	}
+	return peerBytes(v)
+}
+
+func (b *idbBackend) put(ctx context.Context, key, value []byte) error {
+	store, tx, err := b.store(idbjs.ReadWrite)
+	if err != nil {
+		return err
+	}
+	data := _Uint8Array.New(len(value))
+	js.CopyBytesToJS(data, value)
+	obj := _Object.New()
+	obj.Set("id", hex.EncodeToString(key))
+	obj.Set("peer", data)
+	store.Put(obj, js.Undefined())
+	return tx.Done(ctx)
+}
+
+func (b *idbBackend) delete(ctx context.Context, key []byte) error {
+	store, tx, err := b.store(idbjs.ReadWrite)
+	if err != nil {
+		return err
+	}
+	store.Delete(js.ValueOf(hex.EncodeToString(key)))
+	return tx.Done(ctx)
+}
+
+func (b *idbBackend) each(ctx context.Context, fn func(key, value []byte) error) error {
+	store, tx, err := b.store(idbjs.ReadOnly)
+	if err != nil {
+		return err
+	}
+	all, err := store.GetAll(js.Undefined(), 0).Wait(ctx)
+	if err != nil {
+		return err
+	}
+	if err := tx.Done(ctx); err != nil {
+		return err
+	}
+	for i := range all.Length() {
+		v := all.Index(i)
+		key, err := hex.DecodeString(v.Get("id").String())
+		if err != nil {
+			continue
+		}
+		value, err := peerBytes(v)
+		if err != nil {
+			continue
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *idbBackend) close() error {
+	if b.closed.Swap(true) {
+		return nil
+	}
+	return b.db.Close()
+}
+
+// store starts a transaction on the peers object store.
+func (b *idbBackend) store(mode idbjs.Mode) (*idbjs.Store, *idbjs.Tx, error) {
+	if b.closed.Load() {
+		return nil, nil, ErrClosed
+	}
+	tx, err := b.db.Transaction(mode, idbStore)
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := tx.Store(idbStore)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store, tx, nil
+}
+
+// peerBytes copies the encoded peer out of a stored object.
+func peerBytes(v js.Value) ([]byte, error) {
+	data := v.Get("peer")
+	if !data.InstanceOf(_Uint8Array) {
+		return nil, ErrNotFound
+	}
+	value := make([]byte, data.Length())
+	js.CopyBytesToGo(value, data)
+	return value, nil
+}
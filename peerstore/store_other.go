@@ -0,0 +1,90 @@
+//go:build !js
+
+package peerstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	bolterrors "go.etcd.io/bbolt/errors"
+)
+
+const (
+	// boltBucket is the bucket holding peers by ID
+	boltBucket = "peers"
+	// boltOpenTimeout bounds waiting for the file lock held by another process using the store
+	boltOpenTimeout = 5 * time.Second
+)
+
+// Open opens the peer store in the bbolt database file at path, creating the file and its directory if needed.
+func Open(ctx context.Context, path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(boltBucket))
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{b: boltBackend{db}}, nil
+}
+
+// boltBackend stores peers in a bbolt bucket.
+type boltBackend struct {
+	db *bolt.DB
+}
+
+func (b boltBackend) get(ctx context.Context, key []byte) ([]byte, error) {
+	var value []byte
+	err := b.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket([]byte(boltBucket)).Get(key)
+		if v == nil {
+			return ErrNotFound
+		}
+		// Values are only valid within the transaction
+		value = append([]byte(nil), v...)
+		return nil
+	})
+	return value, boltError(err)
+}
+
+func (b boltBackend) put(ctx context.Context, key, value []byte) error {
+	return boltError(b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(boltBucket)).Put(key, value)
+	}))
+}
+
+func (b boltBackend) delete(ctx context.Context, key []byte) error {
+	return boltError(b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(boltBucket)).Delete(key)
+	}))
+}
+
+func (b boltBackend) each(ctx context.Context, fn func(key, value []byte) error) error {
+	return boltError(b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(boltBucket)).ForEach(fn)
+	}))
+}
+
+func (b boltBackend) close() error {
+	return b.db.Close()
+}
+
+// boltError maps the error of a closed database to ErrClosed.
+func boltError(err error) error {
+	if errors.Is(err, bolterrors.ErrDatabaseNotOpen) {
+		return ErrClosed
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package policy
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"pkg.gfire.dev/supernet/logging"
+)
+
+// Config configures an Enforcer.
+type Config struct {
+	// Policy decides the requests; without one every request is denied.
+	Policy *Policy
+	// Logger is the audit log receiving every denial at info level. Defaults to logging.For("policy").
+	Logger *slog.Logger
+}
+
+// Enforcer checks requests against a policy and records denials in an audit log.
+// It is safe for concurrent use, including replacing the policy with SetPolicy.
+type Enforcer struct {
+	policy atomic.Pointer[Policy]
+	log    *slog.Logger
+}
+
+// NewEnforcer creates an enforcer.
+func NewEnforcer(cfg Config) *Enforcer {
+	if cfg.Logger == nil {
+		cfg.Logger = logging.For("policy")
+	}
+	e := &Enforcer{log: cfg.Logger}
+	e.policy.Store(cfg.Policy)
+	return e
+}
+
+// SetPolicy replaces the policy, e.g. after its file changed. Requests checked afterwards use the new policy.
+func (e *Enforcer) SetPolicy(p *Policy) {
+	e.policy.Store(p)
+}
+
+// Check returns nil if the policy allows the request, or an error wrapping ErrDenied naming the deciding rule.
+// A nil Enforcer allows every request, so enforcement stays optional for its users.
+func (e *Enforcer) Check(req Request) error {
+	if e == nil {
+		return nil
+	}
+	d := Decision{Action: Deny}
+	if p := e.policy.Load(); p != nil {
+		d = p.Evaluate(req)
+	}
+	if d.Action == Allow {
+		return nil
+	}
+
+	attrs := []any{"subject", req.Subject, "service", req.Service}
+	if req.Target != "" {
+		attrs = append(attrs, "network", req.Network, "target", req.Target)
+	}
+	if d.Rule != "" {
+		e.log.Info("policy denied", append(attrs, "rule", d.Rule)...)
+		return fmt.Errorf("%w: rule %s", ErrDenied, d.Rule)
+	}
+	e.log.Info("policy denied", append(attrs, "rule", "default")...)
+	return ErrDenied
+}
+
+// Authorizer returns a function for the Authorize hook of listeners, such as wslisten and wtlisten, that
+// checks whether the client of a request may use service. identify returns the subject of a request, e.g.
+// relay.TicketAuth; its errors refuse the request. When nil, the subject is the client's IP address.
+func (e *Enforcer) Authorizer(service string, identify func(r *http.Request) (string, error)) func(r *http.Request) error {
+	return func(r *http.Request) error {
+		id, err := subject(r, identify)
+		if err != nil {
+			return err
+		}
+		return e.Check(Request{Subject: id, Service: service})
+	}
+}
+
+// subject returns the subject of a request as identified by identify, or the client's IP address if
+// identify is nil.
+func subject(r *http.Request, identify func(r *http.Request) (string, error)) (string, error) {
+	if identify != nil {
+		return identify(r)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr, nil
+	}
+	return host, nil
+}
@@ -0,0 +1,317 @@
+// Package policy decides which clients may use which services and reach which targets. A policy is an ordered
+// list of allow and deny rules over subjects (relay ticket subjects, peer IDs or IP addresses) and named groups
+// of them; the first matching rule decides. Relays check every tunnel against it, and listeners check every
+// connection through their Authorize hook. Denials are written to an audit log.
+//
+// Policies are JSON:
+//
+//	{
+//	  "groups": {"admins": ["alice", "bob"], "staff": ["group:admins", "carol"]},
+//	  "rules": [
+//	    {"name": "ssh", "action": "allow", "from": ["group:admins"], "domains": ["*.corp.example"], "ports": ["22"]},
+//	    {"name": "grpc", "action": "allow", "from": ["group:staff"], "services": ["grpc"]}
+//	  ],
+//	  "default": "deny"
+//	}
+//
+// or HCL:
+//
+//	group "admins" {
+//	  members = ["alice", "bob"]
+//	}
+//	rule "ssh" {
+//	  action  = "allow"
+//	  from    = ["group:admins"]
+//	  domains = ["*.corp.example"]
+//	  ports   = ["22"]
+//	}
+//	default = "deny"
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsimple"
+
+	"pkg.gfire.dev/supernet/routing"
+)
+
+var (
+	// ErrDenied is returned by Enforcer.Check for requests the policy does not allow
+	ErrDenied = errors.New("denied by policy")
+	// ErrInvalidPolicy is returned when a policy cannot be parsed
+	ErrInvalidPolicy = errors.New("invalid policy")
+)
+
+// groupPrefix marks group references in subject lists
+const groupPrefix = "group:"
+
+// Action is the outcome of a rule.
+type Action string
+
+const (
+	Allow Action = "allow" // Permit the request
+	Deny  Action = "deny"  // Refuse the request
+)
+
+// Request describes an access to be checked.
+type Request struct {
+	// Subject identifies the client, e.g. a relay ticket subject, a peer ID or an IP address.
+	Subject string
+	// Service names what the client uses, e.g. "relay" or the name of a listener.
+	Service string
+	// Network is "tcp" or "udp" for requests with a target.
+	Network string
+	// Target is the "host:port" address the client wants to reach, empty for connections to the service
+	// itself. Rules with domain, CIDR or port conditions only match requests with a target.
+	Target string
+}
+
+// Decision is the outcome of evaluating a request.
+type Decision struct {
+	Action Action // Whether the request is allowed
+	Rule   string // Name or index of the deciding rule, empty for the default
+}
+
+// Rule applies its action to requests matching all of its conditions. Empty conditions match everything.
+type Rule struct {
+	// Name identifies the rule in audit logs, optional.
+	Name string `json:"name,omitempty" hcl:"name,label"`
+	// Action is allow or deny.
+	Action Action `json:"action" hcl:"action"`
+	// From lists the subjects the rule applies to: subjects, "group:<name>" references and "*" for everyone.
+	From []string `json:"from,omitempty" hcl:"from,optional"`
+	// Services lists the services the rule applies to, "*" for all.
+	Services []string `json:"services,omitempty" hcl:"services,optional"`
+	// Networks lists the networks of the target, "tcp" or "udp".
+	Networks []string `json:"networks,omitempty" hcl:"networks,optional"`
+	// Domains, CIDRs and Ports restrict the target like the conditions of a routing.Rule.
+	Domains []string `json:"domains,omitempty" hcl:"domains,optional"`
+	CIDRs   []string `json:"cidrs,omitempty" hcl:"cidrs,optional"`
+	Ports   []string `json:"ports,omitempty" hcl:"ports,optional"`
+
+	// target matches the target conditions, nil without any
+	target *routing.Rule
+}
+
+// Policy is an ordered list of rules. The first matching rule decides; Default applies when none matches.
+type Policy struct {
+	// Groups maps group names to their members, which may reference other groups.
+	Groups map[string][]string `json:"groups,omitempty"`
+	Rules  []*Rule             `json:"rules"`
+	// Default is the action for requests no rule matches (default deny).
+	Default Action `json:"default,omitempty"`
+
+	// members holds the subjects of every group with nested groups resolved
+	members map[string]map[string]bool
+}
+
+// hclPolicy is the HCL form of Policy, with groups as labelled blocks.
+type hclPolicy struct {
+	Groups []struct {
+		Name    string   `hcl:"name,label"`
+		Members []string `hcl:"members"`
+	} `hcl:"group,block"`
+	Rules   []*Rule `hcl:"rule,block"`
+	Default Action  `hcl:"default,optional"`
+}
+
+// New compiles a policy from groups and rules, returning an error wrapping ErrInvalidPolicy for malformed
+// rules or group references.
+func New(groups map[string][]string, def Action, rules ...*Rule) (*Policy, error) {
+	p := &Policy{Groups: groups, Rules: rules, Default: def}
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Parse reads a JSON policy.
+func Parse(r io.Reader) (*Policy, error) {
+	var p Policy
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ParseHCL reads an HCL policy. filename is used in error messages.
+func ParseHCL(src []byte, filename string) (*Policy, error) {
+	var hp hclPolicy
+	// hclsimple picks the syntax by extension
+	if err := hclsimple.Decode(strings.TrimSuffix(filename, filepath.Ext(filename))+".hcl", src, nil, &hp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+	p := &Policy{Rules: hp.Rules, Default: hp.Default}
+	for _, g := range hp.Groups {
+		if _, ok := p.Groups[g.Name]; ok {
+			return nil, fmt.Errorf("%w: group %s defined twice", ErrInvalidPolicy, g.Name)
+		}
+		if p.Groups == nil {
+			p.Groups = make(map[string][]string)
+		}
+		p.Groups[g.Name] = g.Members
+	}
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Load reads a policy file, HCL if its name ends in ".hcl" and JSON otherwise.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p *Policy
+	if filepath.Ext(path) == ".hcl" {
+		p, err = ParseHCL(data, path)
+	} else {
+		p, err = Parse(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Evaluate returns the decision of the policy for a request.
+func (p *Policy) Evaluate(req Request) Decision {
+	host, port := "", uint16(0)
+	if req.Target != "" {
+		h, portStr, err := net.SplitHostPort(req.Target)
+		if err != nil {
+			h = req.Target
+		}
+		n, _ := strconv.ParseUint(portStr, 10, 16)
+		host, port = h, uint16(n)
+	}
+
+	for i, r := range p.Rules {
+		if !r.match(p, req, host, port) {
+			continue
+		}
+		name := r.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		return Decision{Action: r.Action, Rule: name}
+	}
+	return Decision{Action: p.Default}
+}
+
+// compile validates the actions, resolves the groups and parses the target conditions of every rule.
+func (p *Policy) compile() error {
+	switch p.Default {
+	case "":
+		p.Default = Deny
+	case Allow, Deny:
+	default:
+		return fmt.Errorf("%w: default action %q", ErrInvalidPolicy, p.Default)
+	}
+
+	p.members = make(map[string]map[string]bool, len(p.Groups))
+	for name := range p.Groups {
+		if _, err := p.resolve(name, nil); err != nil {
+			return err
+		}
+	}
+
+	for i, r := range p.Rules {
+		name := strconv.Itoa(i)
+		if r == nil {
+			return fmt.Errorf("%w: rule %s is empty", ErrInvalidPolicy, name)
+		}
+		if r.Name != "" {
+			name = r.Name
+		}
+		if r.Action != Allow && r.Action != Deny {
+			return fmt.Errorf("%w: rule %s: action %q", ErrInvalidPolicy, name, r.Action)
+		}
+		for _, s := range r.From {
+			if group, ok := strings.CutPrefix(s, groupPrefix); ok && p.Groups[group] == nil {
+				return fmt.Errorf("%w: rule %s: unknown group %s", ErrInvalidPolicy, name, group)
+			}
+		}
+		r.target = nil
+		if len(r.Domains) > 0 || len(r.CIDRs) > 0 || len(r.Ports) > 0 {
+			target := &routing.Rule{Domains: r.Domains, CIDRs: r.CIDRs, Ports: r.Ports}
+			if _, err := routing.New(routing.Route{}, target); err != nil {
+				return fmt.Errorf("%w: rule %s: %v", ErrInvalidPolicy, name, err)
+			}
+			r.target = target
+		}
+	}
+	return nil
+}
+
+// resolve returns the subjects of a group, expanding nested groups. path holds the groups being resolved
+// to detect cycles.
+func (p *Policy) resolve(name string, path []string) (map[string]bool, error) {
+	if m, ok := p.members[name]; ok {
+		return m, nil
+	}
+	if slices.Contains(path, name) {
+		return nil, fmt.Errorf("%w: group %s contains itself", ErrInvalidPolicy, name)
+	}
+	entries, ok := p.Groups[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown group %s", ErrInvalidPolicy, name)
+	}
+
+	m := make(map[string]bool, len(entries))
+	for _, s := range entries {
+		group, ok := strings.CutPrefix(s, groupPrefix)
+		if !ok {
+			m[s] = true
+			continue
+		}
+		nested, err := p.resolve(group, append(path, name))
+		if err != nil {
+			return nil, err
+		}
+		for s := range nested {
+			m[s] = true
+		}
+	}
+	p.members[name] = m
+	return m, nil
+}
+
+// match reports whether the rule applies to a request with the given target host and port.
+func (r *Rule) match(p *Policy, req Request, host string, port uint16) bool {
+	if len(r.From) > 0 && !slices.ContainsFunc(r.From, func(s string) bool {
+		if group, ok := strings.CutPrefix(s, groupPrefix); ok {
+			return p.members[group][req.Subject]
+		}
+		return s == "*" || s == req.Subject
+	}) {
+		return false
+	}
+	if len(r.Services) > 0 && !slices.Contains(r.Services, "*") && !slices.Contains(r.Services, req.Service) {
+		return false
+	}
+	if len(r.Networks) > 0 && !slices.Contains(r.Networks, req.Network) {
+		return false
+	}
+	if r.target != nil && (req.Target == "" || !r.target.Match(host, port)) {
+		return false
+	}
+	return true
+}
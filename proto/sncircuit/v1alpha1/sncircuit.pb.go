@@ -0,0 +1,431 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: proto/sncircuit/v1alpha1/sncircuit.proto
+
+package sncircuit
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Open starts a circuit. The receiver forwards it to the first remaining hop, or accepts the circuit if
+// no hops remain.
+type Open struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          [][]byte               `protobuf:"bytes,1,rep,name=path,proto3" json:"path,omitempty"` // 256-bit peer ids of the remaining hops, the last is the destination
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Open) Reset() {
+	*x = Open{}
+	mi := &file_proto_sncircuit_v1alpha1_sncircuit_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Open) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Open) ProtoMessage() {}
+
+func (x *Open) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sncircuit_v1alpha1_sncircuit_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Open.ProtoReflect.Descriptor instead.
+func (*Open) Descriptor() ([]byte, []int) {
+	return file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Open) GetPath() [][]byte {
+	if x != nil {
+		return x.Path
+	}
+	return nil
+}
+
+// Opened confirms that the destination accepted a circuit. It travels back along the circuit.
+type Opened struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Opened) Reset() {
+	*x = Opened{}
+	mi := &file_proto_sncircuit_v1alpha1_sncircuit_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Opened) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Opened) ProtoMessage() {}
+
+func (x *Opened) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sncircuit_v1alpha1_sncircuit_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Opened.ProtoReflect.Descriptor instead.
+func (*Opened) Descriptor() ([]byte, []int) {
+	return file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDescGZIP(), []int{1}
+}
+
+// Credit allows the peer at the other end of the circuit to send more data.
+type Credit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bytes         uint32                 `protobuf:"varint,1,opt,name=bytes,proto3" json:"bytes,omitempty"` // Additional bytes the receiver is willing to buffer
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Credit) Reset() {
+	*x = Credit{}
+	mi := &file_proto_sncircuit_v1alpha1_sncircuit_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Credit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Credit) ProtoMessage() {}
+
+func (x *Credit) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sncircuit_v1alpha1_sncircuit_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Credit.ProtoReflect.Descriptor instead.
+func (*Credit) Descriptor() ([]byte, []int) {
+	return file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Credit) GetBytes() uint32 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+// Close tears a circuit down. It travels along the circuit in the direction it was sent.
+type Close struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Error         string                 `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"` // Reason the circuit failed, empty for a regular close
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Close) Reset() {
+	*x = Close{}
+	mi := &file_proto_sncircuit_v1alpha1_sncircuit_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Close) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Close) ProtoMessage() {}
+
+func (x *Close) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sncircuit_v1alpha1_sncircuit_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Close.ProtoReflect.Descriptor instead.
+func (*Close) Descriptor() ([]byte, []int) {
+	return file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Close) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Frame is a single message on a link between neighbouring nodes.
+// Circuit ids are chosen by the node that opened the circuit on the link, so each side of a link has its
+// own id space; forward tells the receiver whose space the id belongs to.
+type Frame struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Circuit uint64                 `protobuf:"varint,1,opt,name=circuit,proto3" json:"circuit,omitempty"` // Link-local circuit id
+	Forward bool                   `protobuf:"varint,2,opt,name=forward,proto3" json:"forward,omitempty"` // Whether the sender opened the circuit on this link
+	// Types that are valid to be assigned to Body:
+	//
+	//	*Frame_Open
+	//	*Frame_Opened
+	//	*Frame_Data
+	//	*Frame_Credit
+	//	*Frame_Close
+	Body          isFrame_Body `protobuf_oneof:"body"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Frame) Reset() {
+	*x = Frame{}
+	mi := &file_proto_sncircuit_v1alpha1_sncircuit_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Frame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Frame) ProtoMessage() {}
+
+func (x *Frame) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sncircuit_v1alpha1_sncircuit_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Frame.ProtoReflect.Descriptor instead.
+func (*Frame) Descriptor() ([]byte, []int) {
+	return file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Frame) GetCircuit() uint64 {
+	if x != nil {
+		return x.Circuit
+	}
+	return 0
+}
+
+func (x *Frame) GetForward() bool {
+	if x != nil {
+		return x.Forward
+	}
+	return false
+}
+
+func (x *Frame) GetBody() isFrame_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *Frame) GetOpen() *Open {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Open); ok {
+			return x.Open
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetOpened() *Opened {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Opened); ok {
+			return x.Opened
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetData() []byte {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Data); ok {
+			return x.Data
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetCredit() *Credit {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Credit); ok {
+			return x.Credit
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetClose() *Close {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Close); ok {
+			return x.Close
+		}
+	}
+	return nil
+}
+
+type isFrame_Body interface {
+	isFrame_Body()
+}
+
+type Frame_Open struct {
+	Open *Open `protobuf:"bytes,10,opt,name=open,proto3,oneof"`
+}
+
+type Frame_Opened struct {
+	Opened *Opened `protobuf:"bytes,11,opt,name=opened,proto3,oneof"`
+}
+
+type Frame_Data struct {
+	Data []byte `protobuf:"bytes,12,opt,name=data,proto3,oneof"` // End-to-end payload
+}
+
+type Frame_Credit struct {
+	Credit *Credit `protobuf:"bytes,13,opt,name=credit,proto3,oneof"`
+}
+
+type Frame_Close struct {
+	Close *Close `protobuf:"bytes,14,opt,name=close,proto3,oneof"`
+}
+
+func (*Frame_Open) isFrame_Body() {}
+
+func (*Frame_Opened) isFrame_Body() {}
+
+func (*Frame_Data) isFrame_Body() {}
+
+func (*Frame_Credit) isFrame_Body() {}
+
+func (*Frame_Close) isFrame_Body() {}
+
+var File_proto_sncircuit_v1alpha1_sncircuit_proto protoreflect.FileDescriptor
+
+const file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDesc = "" +
+	"\n" +
+	"(proto/sncircuit/v1alpha1/sncircuit.proto\x12\tsncircuit\"\x1a\n" +
+	"\x04Open\x12\x12\n" +
+	"\x04path\x18\x01 \x03(\fR\x04path\"\b\n" +
+	"\x06Opened\"\x1e\n" +
+	"\x06Credit\x12\x14\n" +
+	"\x05bytes\x18\x01 \x01(\rR\x05bytes\"\x1d\n" +
+	"\x05Close\x12\x14\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error\"\x84\x02\n" +
+	"\x05Frame\x12\x18\n" +
+	"\acircuit\x18\x01 \x01(\x04R\acircuit\x12\x18\n" +
+	"\aforward\x18\x02 \x01(\bR\aforward\x12%\n" +
+	"\x04open\x18\n" +
+	" \x01(\v2\x0f.sncircuit.OpenH\x00R\x04open\x12+\n" +
+	"\x06opened\x18\v \x01(\v2\x11.sncircuit.OpenedH\x00R\x06opened\x12\x14\n" +
+	"\x04data\x18\f \x01(\fH\x00R\x04data\x12+\n" +
+	"\x06credit\x18\r \x01(\v2\x11.sncircuit.CreditH\x00R\x06credit\x12(\n" +
+	"\x05close\x18\x0e \x01(\v2\x10.sncircuit.CloseH\x00R\x05closeB\x06\n" +
+	"\x04bodyB\x9e\x01\n" +
+	"\rcom.sncircuitB\x0eSncircuitProtoP\x01Z9pkg.gfire.dev/supernet/proto/sncircuit/v1alpha1;sncircuit\xa2\x02\x03SXX\xaa\x02\tSncircuit\xca\x02\tSncircuit\xe2\x02\x15Sncircuit\\GPBMetadata\xea\x02\tSncircuitb\x06proto3"
+
+var (
+	file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDescOnce sync.Once
+	file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDescData []byte
+)
+
+func file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDescGZIP() []byte {
+	file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDescOnce.Do(func() {
+		file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDesc), len(file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDesc)))
+	})
+	return file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDescData
+}
+
+var file_proto_sncircuit_v1alpha1_sncircuit_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_proto_sncircuit_v1alpha1_sncircuit_proto_goTypes = []any{
+	(*Open)(nil),   // 0: sncircuit.Open
+	(*Opened)(nil), // 1: sncircuit.Opened
+	(*Credit)(nil), // 2: sncircuit.Credit
+	(*Close)(nil),  // 3: sncircuit.Close
+	(*Frame)(nil),  // 4: sncircuit.Frame
+}
+var file_proto_sncircuit_v1alpha1_sncircuit_proto_depIdxs = []int32{
+	0, // 0: sncircuit.Frame.open:type_name -> sncircuit.Open
+	1, // 1: sncircuit.Frame.opened:type_name -> sncircuit.Opened
+	2, // 2: sncircuit.Frame.credit:type_name -> sncircuit.Credit
+	3, // 3: sncircuit.Frame.close:type_name -> sncircuit.Close
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_proto_sncircuit_v1alpha1_sncircuit_proto_init() }
+func file_proto_sncircuit_v1alpha1_sncircuit_proto_init() {
+	if File_proto_sncircuit_v1alpha1_sncircuit_proto != nil {
+		return
+	}
+	file_proto_sncircuit_v1alpha1_sncircuit_proto_msgTypes[4].OneofWrappers = []any{
+		(*Frame_Open)(nil),
+		(*Frame_Opened)(nil),
+		(*Frame_Data)(nil),
+		(*Frame_Credit)(nil),
+		(*Frame_Close)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDesc), len(file_proto_sncircuit_v1alpha1_sncircuit_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_sncircuit_v1alpha1_sncircuit_proto_goTypes,
+		DependencyIndexes: file_proto_sncircuit_v1alpha1_sncircuit_proto_depIdxs,
+		MessageInfos:      file_proto_sncircuit_v1alpha1_sncircuit_proto_msgTypes,
+	}.Build()
+	File_proto_sncircuit_v1alpha1_sncircuit_proto = out.File
+	file_proto_sncircuit_v1alpha1_sncircuit_proto_goTypes = nil
+	file_proto_sncircuit_v1alpha1_sncircuit_proto_depIdxs = nil
+}
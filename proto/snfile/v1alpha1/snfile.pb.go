@@ -0,0 +1,612 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: proto/snfile/v1alpha1/snfile.proto
+
+package snfile
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Offer announces a file to the receiver. It is sent again after every reconnect to resume the transfer.
+type Offer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransferId    []byte                 `protobuf:"bytes,1,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`    // SHA-256 over the chunk hashes, identifying the file content
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`                                  // File name suggested by the sender
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`                                 // File size in bytes
+	ChunkSize     uint32                 `protobuf:"varint,4,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`      // Size of every chunk except the last
+	ChunkHashes   [][]byte               `protobuf:"bytes,5,rep,name=chunk_hashes,json=chunkHashes,proto3" json:"chunk_hashes,omitempty"` // SHA-256 of every chunk, in order
+	MimeType      string                 `protobuf:"bytes,6,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`          // Media type of the file, empty if unknown
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Offer) Reset() {
+	*x = Offer{}
+	mi := &file_proto_snfile_v1alpha1_snfile_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Offer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Offer) ProtoMessage() {}
+
+func (x *Offer) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snfile_v1alpha1_snfile_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Offer.ProtoReflect.Descriptor instead.
+func (*Offer) Descriptor() ([]byte, []int) {
+	return file_proto_snfile_v1alpha1_snfile_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Offer) GetTransferId() []byte {
+	if x != nil {
+		return x.TransferId
+	}
+	return nil
+}
+
+func (x *Offer) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Offer) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Offer) GetChunkSize() uint32 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
+}
+
+func (x *Offer) GetChunkHashes() [][]byte {
+	if x != nil {
+		return x.ChunkHashes
+	}
+	return nil
+}
+
+func (x *Offer) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+// Accept answers an Offer and lists the chunks the receiver already holds.
+type Accept struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransferId    []byte                 `protobuf:"bytes,1,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"` // Transfer being accepted
+	Have          []byte                 `protobuf:"bytes,2,opt,name=have,proto3" json:"have,omitempty"`                               // Bitmap of verified chunks, least significant bit first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Accept) Reset() {
+	*x = Accept{}
+	mi := &file_proto_snfile_v1alpha1_snfile_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Accept) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Accept) ProtoMessage() {}
+
+func (x *Accept) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snfile_v1alpha1_snfile_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Accept.ProtoReflect.Descriptor instead.
+func (*Accept) Descriptor() ([]byte, []int) {
+	return file_proto_snfile_v1alpha1_snfile_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Accept) GetTransferId() []byte {
+	if x != nil {
+		return x.TransferId
+	}
+	return nil
+}
+
+func (x *Accept) GetHave() []byte {
+	if x != nil {
+		return x.Have
+	}
+	return nil
+}
+
+// Reject declines an Offer.
+type Reject struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransferId    []byte                 `protobuf:"bytes,1,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"` // Transfer being rejected
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`                           // Human readable reason
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Reject) Reset() {
+	*x = Reject{}
+	mi := &file_proto_snfile_v1alpha1_snfile_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reject) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reject) ProtoMessage() {}
+
+func (x *Reject) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snfile_v1alpha1_snfile_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reject.ProtoReflect.Descriptor instead.
+func (*Reject) Descriptor() ([]byte, []int) {
+	return file_proto_snfile_v1alpha1_snfile_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Reject) GetTransferId() []byte {
+	if x != nil {
+		return x.TransferId
+	}
+	return nil
+}
+
+func (x *Reject) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// Chunk carries the data of one chunk.
+type Chunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         uint32                 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"` // Chunk index
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`    // Chunk data
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chunk) Reset() {
+	*x = Chunk{}
+	mi := &file_proto_snfile_v1alpha1_snfile_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chunk) ProtoMessage() {}
+
+func (x *Chunk) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snfile_v1alpha1_snfile_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return file_proto_snfile_v1alpha1_snfile_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Chunk) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *Chunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// Ack reports receiver progress and releases the sender's flow control window.
+type Ack struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Received      uint32                 `protobuf:"varint,1,opt,name=received,proto3" json:"received,omitempty"`    // Number of chunks received since Accept, including failed ones
+	Resend        []uint32               `protobuf:"varint,2,rep,packed,name=resend,proto3" json:"resend,omitempty"` // Chunks that failed verification and must be sent again
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ack) Reset() {
+	*x = Ack{}
+	mi := &file_proto_snfile_v1alpha1_snfile_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ack) ProtoMessage() {}
+
+func (x *Ack) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snfile_v1alpha1_snfile_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ack.ProtoReflect.Descriptor instead.
+func (*Ack) Descriptor() ([]byte, []int) {
+	return file_proto_snfile_v1alpha1_snfile_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Ack) GetReceived() uint32 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+func (x *Ack) GetResend() []uint32 {
+	if x != nil {
+		return x.Resend
+	}
+	return nil
+}
+
+// Complete reports that all chunks were received and verified.
+type Complete struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Complete) Reset() {
+	*x = Complete{}
+	mi := &file_proto_snfile_v1alpha1_snfile_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Complete) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Complete) ProtoMessage() {}
+
+func (x *Complete) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snfile_v1alpha1_snfile_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Complete.ProtoReflect.Descriptor instead.
+func (*Complete) Descriptor() ([]byte, []int) {
+	return file_proto_snfile_v1alpha1_snfile_proto_rawDescGZIP(), []int{5}
+}
+
+// Frame is the envelope of all file transfer messages.
+type Frame struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Body:
+	//
+	//	*Frame_Offer
+	//	*Frame_Accept
+	//	*Frame_Reject
+	//	*Frame_Chunk
+	//	*Frame_Ack
+	//	*Frame_Complete
+	Body          isFrame_Body `protobuf_oneof:"body"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Frame) Reset() {
+	*x = Frame{}
+	mi := &file_proto_snfile_v1alpha1_snfile_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Frame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Frame) ProtoMessage() {}
+
+func (x *Frame) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snfile_v1alpha1_snfile_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Frame.ProtoReflect.Descriptor instead.
+func (*Frame) Descriptor() ([]byte, []int) {
+	return file_proto_snfile_v1alpha1_snfile_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Frame) GetBody() isFrame_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *Frame) GetOffer() *Offer {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Offer); ok {
+			return x.Offer
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetAccept() *Accept {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Accept); ok {
+			return x.Accept
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetReject() *Reject {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Reject); ok {
+			return x.Reject
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetChunk() *Chunk {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetAck() *Ack {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Ack); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetComplete() *Complete {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Complete); ok {
+			return x.Complete
+		}
+	}
+	return nil
+}
+
+type isFrame_Body interface {
+	isFrame_Body()
+}
+
+type Frame_Offer struct {
+	Offer *Offer `protobuf:"bytes,1,opt,name=offer,proto3,oneof"`
+}
+
+type Frame_Accept struct {
+	Accept *Accept `protobuf:"bytes,2,opt,name=accept,proto3,oneof"`
+}
+
+type Frame_Reject struct {
+	Reject *Reject `protobuf:"bytes,3,opt,name=reject,proto3,oneof"`
+}
+
+type Frame_Chunk struct {
+	Chunk *Chunk `protobuf:"bytes,4,opt,name=chunk,proto3,oneof"`
+}
+
+type Frame_Ack struct {
+	Ack *Ack `protobuf:"bytes,5,opt,name=ack,proto3,oneof"`
+}
+
+type Frame_Complete struct {
+	Complete *Complete `protobuf:"bytes,6,opt,name=complete,proto3,oneof"`
+}
+
+func (*Frame_Offer) isFrame_Body() {}
+
+func (*Frame_Accept) isFrame_Body() {}
+
+func (*Frame_Reject) isFrame_Body() {}
+
+func (*Frame_Chunk) isFrame_Body() {}
+
+func (*Frame_Ack) isFrame_Body() {}
+
+func (*Frame_Complete) isFrame_Body() {}
+
+var File_proto_snfile_v1alpha1_snfile_proto protoreflect.FileDescriptor
+
+const file_proto_snfile_v1alpha1_snfile_proto_rawDesc = "" +
+	"\n" +
+	"\"proto/snfile/v1alpha1/snfile.proto\x12\x06snfile\"\xaf\x01\n" +
+	"\x05Offer\x12\x1f\n" +
+	"\vtransfer_id\x18\x01 \x01(\fR\n" +
+	"transferId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x1d\n" +
+	"\n" +
+	"chunk_size\x18\x04 \x01(\rR\tchunkSize\x12!\n" +
+	"\fchunk_hashes\x18\x05 \x03(\fR\vchunkHashes\x12\x1b\n" +
+	"\tmime_type\x18\x06 \x01(\tR\bmimeType\"=\n" +
+	"\x06Accept\x12\x1f\n" +
+	"\vtransfer_id\x18\x01 \x01(\fR\n" +
+	"transferId\x12\x12\n" +
+	"\x04have\x18\x02 \x01(\fR\x04have\"A\n" +
+	"\x06Reject\x12\x1f\n" +
+	"\vtransfer_id\x18\x01 \x01(\fR\n" +
+	"transferId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"1\n" +
+	"\x05Chunk\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\rR\x05index\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"9\n" +
+	"\x03Ack\x12\x1a\n" +
+	"\breceived\x18\x01 \x01(\rR\breceived\x12\x16\n" +
+	"\x06resend\x18\x02 \x03(\rR\x06resend\"\n" +
+	"\n" +
+	"\bComplete\"\x82\x02\n" +
+	"\x05Frame\x12%\n" +
+	"\x05offer\x18\x01 \x01(\v2\r.snfile.OfferH\x00R\x05offer\x12(\n" +
+	"\x06accept\x18\x02 \x01(\v2\x0e.snfile.AcceptH\x00R\x06accept\x12(\n" +
+	"\x06reject\x18\x03 \x01(\v2\x0e.snfile.RejectH\x00R\x06reject\x12%\n" +
+	"\x05chunk\x18\x04 \x01(\v2\r.snfile.ChunkH\x00R\x05chunk\x12\x1f\n" +
+	"\x03ack\x18\x05 \x01(\v2\v.snfile.AckH\x00R\x03ack\x12.\n" +
+	"\bcomplete\x18\x06 \x01(\v2\x10.snfile.CompleteH\x00R\bcompleteB\x06\n" +
+	"\x04bodyB\x86\x01\n" +
+	"\n" +
+	"com.snfileB\vSnfileProtoP\x01Z3pkg.gfire.dev/supernet/proto/snfile/v1alpha1;snfile\xa2\x02\x03SXX\xaa\x02\x06Snfile\xca\x02\x06Snfile\xe2\x02\x12Snfile\\GPBMetadata\xea\x02\x06Snfileb\x06proto3"
+
+var (
+	file_proto_snfile_v1alpha1_snfile_proto_rawDescOnce sync.Once
+	file_proto_snfile_v1alpha1_snfile_proto_rawDescData []byte
+)
+
+func file_proto_snfile_v1alpha1_snfile_proto_rawDescGZIP() []byte {
+	file_proto_snfile_v1alpha1_snfile_proto_rawDescOnce.Do(func() {
+		file_proto_snfile_v1alpha1_snfile_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_snfile_v1alpha1_snfile_proto_rawDesc), len(file_proto_snfile_v1alpha1_snfile_proto_rawDesc)))
+	})
+	return file_proto_snfile_v1alpha1_snfile_proto_rawDescData
+}
+
+var file_proto_snfile_v1alpha1_snfile_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_proto_snfile_v1alpha1_snfile_proto_goTypes = []any{
+	(*Offer)(nil),    // 0: snfile.Offer
+	(*Accept)(nil),   // 1: snfile.Accept
+	(*Reject)(nil),   // 2: snfile.Reject
+	(*Chunk)(nil),    // 3: snfile.Chunk
+	(*Ack)(nil),      // 4: snfile.Ack
+	(*Complete)(nil), // 5: snfile.Complete
+	(*Frame)(nil),    // 6: snfile.Frame
+}
+var file_proto_snfile_v1alpha1_snfile_proto_depIdxs = []int32{
+	0, // 0: snfile.Frame.offer:type_name -> snfile.Offer
+	1, // 1: snfile.Frame.accept:type_name -> snfile.Accept
+	2, // 2: snfile.Frame.reject:type_name -> snfile.Reject
+	3, // 3: snfile.Frame.chunk:type_name -> snfile.Chunk
+	4, // 4: snfile.Frame.ack:type_name -> snfile.Ack
+	5, // 5: snfile.Frame.complete:type_name -> snfile.Complete
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_proto_snfile_v1alpha1_snfile_proto_init() }
+func file_proto_snfile_v1alpha1_snfile_proto_init() {
+	if File_proto_snfile_v1alpha1_snfile_proto != nil {
+		return
+	}
+	file_proto_snfile_v1alpha1_snfile_proto_msgTypes[6].OneofWrappers = []any{
+		(*Frame_Offer)(nil),
+		(*Frame_Accept)(nil),
+		(*Frame_Reject)(nil),
+		(*Frame_Chunk)(nil),
+		(*Frame_Ack)(nil),
+		(*Frame_Complete)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_snfile_v1alpha1_snfile_proto_rawDesc), len(file_proto_snfile_v1alpha1_snfile_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_snfile_v1alpha1_snfile_proto_goTypes,
+		DependencyIndexes: file_proto_snfile_v1alpha1_snfile_proto_depIdxs,
+		MessageInfos:      file_proto_snfile_v1alpha1_snfile_proto_msgTypes,
+	}.Build()
+	File_proto_snfile_v1alpha1_snfile_proto = out.File
+	file_proto_snfile_v1alpha1_snfile_proto_goTypes = nil
+	file_proto_snfile_v1alpha1_snfile_proto_depIdxs = nil
+}
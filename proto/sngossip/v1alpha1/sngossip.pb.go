@@ -0,0 +1,677 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: proto/sngossip/v1alpha1/sngossip.proto
+
+package sngossip
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// State is the liveness of a member as seen by the group.
+type State int32
+
+const (
+	State_ALIVE   State = 0 // The member answers probes
+	State_SUSPECT State = 1 // A probe failed; the member is declared dead unless it refutes in time
+	State_DEAD    State = 2 // The member failed or left
+)
+
+// Enum value maps for State.
+var (
+	State_name = map[int32]string{
+		0: "ALIVE",
+		1: "SUSPECT",
+		2: "DEAD",
+	}
+	State_value = map[string]int32{
+		"ALIVE":   0,
+		"SUSPECT": 1,
+		"DEAD":    2,
+	}
+)
+
+func (x State) Enum() *State {
+	p := new(State)
+	*p = x
+	return p
+}
+
+func (x State) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (State) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_sngossip_v1alpha1_sngossip_proto_enumTypes[0].Descriptor()
+}
+
+func (State) Type() protoreflect.EnumType {
+	return &file_proto_sngossip_v1alpha1_sngossip_proto_enumTypes[0]
+}
+
+func (x State) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use State.Descriptor instead.
+func (State) EnumDescriptor() ([]byte, []int) {
+	return file_proto_sngossip_v1alpha1_sngossip_proto_rawDescGZIP(), []int{0}
+}
+
+// Hello is the first message sent by both sides of a new connection.
+type Hello struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Record        []byte                 `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`            // Signed peer record of the sender
+	Nonce         []byte                 `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`              // Random challenge the remote peer must sign
+	Incarnation   uint64                 `protobuf:"varint,3,opt,name=incarnation,proto3" json:"incarnation,omitempty"` // Current incarnation of the sender
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hello) Reset() {
+	*x = Hello{}
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hello) ProtoMessage() {}
+
+func (x *Hello) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hello.ProtoReflect.Descriptor instead.
+func (*Hello) Descriptor() ([]byte, []int) {
+	return file_proto_sngossip_v1alpha1_sngossip_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Hello) GetRecord() []byte {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *Hello) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+func (x *Hello) GetIncarnation() uint64 {
+	if x != nil {
+		return x.Incarnation
+	}
+	return 0
+}
+
+// HelloProof proves ownership of the key in the Hello record.
+type HelloProof struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Signature     []byte                 `protobuf:"bytes,1,opt,name=signature,proto3" json:"signature,omitempty"` // Ed25519 signature over the remote peer's nonce
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HelloProof) Reset() {
+	*x = HelloProof{}
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloProof) ProtoMessage() {}
+
+func (x *HelloProof) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloProof.ProtoReflect.Descriptor instead.
+func (*HelloProof) Descriptor() ([]byte, []int) {
+	return file_proto_sngossip_v1alpha1_sngossip_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HelloProof) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+// Update announces the state of a member.
+type Update struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            []byte                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                            // 256-bit peer id of the member
+	State         State                  `protobuf:"varint,2,opt,name=state,proto3,enum=sngossip.State" json:"state,omitempty"` // New state of the member
+	Incarnation   uint64                 `protobuf:"varint,3,opt,name=incarnation,proto3" json:"incarnation,omitempty"`         // Incarnation the state applies to; only the member itself increases it
+	Record        []byte                 `protobuf:"bytes,4,opt,name=record,proto3" json:"record,omitempty"`                    // Signed peer record of the member, set for ALIVE
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Update) Reset() {
+	*x = Update{}
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Update) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Update) ProtoMessage() {}
+
+func (x *Update) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Update.ProtoReflect.Descriptor instead.
+func (*Update) Descriptor() ([]byte, []int) {
+	return file_proto_sngossip_v1alpha1_sngossip_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Update) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *Update) GetState() State {
+	if x != nil {
+		return x.State
+	}
+	return State_ALIVE
+}
+
+func (x *Update) GetIncarnation() uint64 {
+	if x != nil {
+		return x.Incarnation
+	}
+	return 0
+}
+
+func (x *Update) GetRecord() []byte {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+// Ping asks a peer to prove its liveness with an Ack.
+type Ping struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"` // Sequence number echoed by the Ack
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ping) Reset() {
+	*x = Ping{}
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ping) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ping) ProtoMessage() {}
+
+func (x *Ping) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ping.ProtoReflect.Descriptor instead.
+func (*Ping) Descriptor() ([]byte, []int) {
+	return file_proto_sngossip_v1alpha1_sngossip_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Ping) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+// Ack answers a Ping, or a PingReq once the target has answered.
+type Ack struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"` // Sequence number of the Ping or PingReq
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ack) Reset() {
+	*x = Ack{}
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ack) ProtoMessage() {}
+
+func (x *Ack) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ack.ProtoReflect.Descriptor instead.
+func (*Ack) Descriptor() ([]byte, []int) {
+	return file_proto_sngossip_v1alpha1_sngossip_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Ack) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+// PingReq asks a peer to ping target on behalf of the sender, which failed to reach it directly.
+type PingReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`      // Sequence number echoed by the Ack
+	Target        []byte                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"` // 256-bit peer id of the member to probe
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingReq) Reset() {
+	*x = PingReq{}
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingReq) ProtoMessage() {}
+
+func (x *PingReq) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingReq.ProtoReflect.Descriptor instead.
+func (*PingReq) Descriptor() ([]byte, []int) {
+	return file_proto_sngossip_v1alpha1_sngossip_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PingReq) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *PingReq) GetTarget() []byte {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+// Shuffle exchanges samples of known members to spread membership through the group.
+type Shuffle struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Records       [][]byte               `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"` // Signed peer records
+	Reply         bool                   `protobuf:"varint,2,opt,name=reply,proto3" json:"reply,omitempty"`    // Whether this answers a Shuffle, which is not answered again
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Shuffle) Reset() {
+	*x = Shuffle{}
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Shuffle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shuffle) ProtoMessage() {}
+
+func (x *Shuffle) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shuffle.ProtoReflect.Descriptor instead.
+func (*Shuffle) Descriptor() ([]byte, []int) {
+	return file_proto_sngossip_v1alpha1_sngossip_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Shuffle) GetRecords() [][]byte {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+func (x *Shuffle) GetReply() bool {
+	if x != nil {
+		return x.Reply
+	}
+	return false
+}
+
+// Message frames everything exchanged after the handshake. Updates are piggybacked on every message.
+type Message struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Body:
+	//
+	//	*Message_Ping
+	//	*Message_Ack
+	//	*Message_PingReq
+	//	*Message_Shuffle
+	Body          isMessage_Body `protobuf_oneof:"body"`
+	Updates       []*Update      `protobuf:"bytes,10,rep,name=updates,proto3" json:"updates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_proto_sngossip_v1alpha1_sngossip_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Message) GetBody() isMessage_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *Message) GetPing() *Ping {
+	if x != nil {
+		if x, ok := x.Body.(*Message_Ping); ok {
+			return x.Ping
+		}
+	}
+	return nil
+}
+
+func (x *Message) GetAck() *Ack {
+	if x != nil {
+		if x, ok := x.Body.(*Message_Ack); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+func (x *Message) GetPingReq() *PingReq {
+	if x != nil {
+		if x, ok := x.Body.(*Message_PingReq); ok {
+			return x.PingReq
+		}
+	}
+	return nil
+}
+
+func (x *Message) GetShuffle() *Shuffle {
+	if x != nil {
+		if x, ok := x.Body.(*Message_Shuffle); ok {
+			return x.Shuffle
+		}
+	}
+	return nil
+}
+
+func (x *Message) GetUpdates() []*Update {
+	if x != nil {
+		return x.Updates
+	}
+	return nil
+}
+
+type isMessage_Body interface {
+	isMessage_Body()
+}
+
+type Message_Ping struct {
+	Ping *Ping `protobuf:"bytes,1,opt,name=ping,proto3,oneof"`
+}
+
+type Message_Ack struct {
+	Ack *Ack `protobuf:"bytes,2,opt,name=ack,proto3,oneof"`
+}
+
+type Message_PingReq struct {
+	PingReq *PingReq `protobuf:"bytes,3,opt,name=ping_req,json=pingReq,proto3,oneof"`
+}
+
+type Message_Shuffle struct {
+	Shuffle *Shuffle `protobuf:"bytes,4,opt,name=shuffle,proto3,oneof"`
+}
+
+func (*Message_Ping) isMessage_Body() {}
+
+func (*Message_Ack) isMessage_Body() {}
+
+func (*Message_PingReq) isMessage_Body() {}
+
+func (*Message_Shuffle) isMessage_Body() {}
+
+var File_proto_sngossip_v1alpha1_sngossip_proto protoreflect.FileDescriptor
+
+const file_proto_sngossip_v1alpha1_sngossip_proto_rawDesc = "" +
+	"\n" +
+	"&proto/sngossip/v1alpha1/sngossip.proto\x12\bsngossip\"W\n" +
+	"\x05Hello\x12\x16\n" +
+	"\x06record\x18\x01 \x01(\fR\x06record\x12\x14\n" +
+	"\x05nonce\x18\x02 \x01(\fR\x05nonce\x12 \n" +
+	"\vincarnation\x18\x03 \x01(\x04R\vincarnation\"*\n" +
+	"\n" +
+	"HelloProof\x12\x1c\n" +
+	"\tsignature\x18\x01 \x01(\fR\tsignature\"y\n" +
+	"\x06Update\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\fR\x02id\x12%\n" +
+	"\x05state\x18\x02 \x01(\x0e2\x0f.sngossip.StateR\x05state\x12 \n" +
+	"\vincarnation\x18\x03 \x01(\x04R\vincarnation\x12\x16\n" +
+	"\x06record\x18\x04 \x01(\fR\x06record\"\x18\n" +
+	"\x04Ping\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\"\x17\n" +
+	"\x03Ack\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\"3\n" +
+	"\aPingReq\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\fR\x06target\"9\n" +
+	"\aShuffle\x12\x18\n" +
+	"\arecords\x18\x01 \x03(\fR\arecords\x12\x14\n" +
+	"\x05reply\x18\x02 \x01(\bR\x05reply\"\xe5\x01\n" +
+	"\aMessage\x12$\n" +
+	"\x04ping\x18\x01 \x01(\v2\x0e.sngossip.PingH\x00R\x04ping\x12!\n" +
+	"\x03ack\x18\x02 \x01(\v2\r.sngossip.AckH\x00R\x03ack\x12.\n" +
+	"\bping_req\x18\x03 \x01(\v2\x11.sngossip.PingReqH\x00R\apingReq\x12-\n" +
+	"\ashuffle\x18\x04 \x01(\v2\x11.sngossip.ShuffleH\x00R\ashuffle\x12*\n" +
+	"\aupdates\x18\n" +
+	" \x03(\v2\x10.sngossip.UpdateR\aupdatesB\x06\n" +
+	"\x04body*)\n" +
+	"\x05State\x12\t\n" +
+	"\x05ALIVE\x10\x00\x12\v\n" +
+	"\aSUSPECT\x10\x01\x12\b\n" +
+	"\x04DEAD\x10\x02B\x96\x01\n" +
+	"\fcom.sngossipB\rSngossipProtoP\x01Z7pkg.gfire.dev/supernet/proto/sngossip/v1alpha1;sngossip\xa2\x02\x03SXX\xaa\x02\bSngossip\xca\x02\bSngossip\xe2\x02\x14Sngossip\\GPBMetadata\xea\x02\bSngossipb\x06proto3"
+
+var (
+	file_proto_sngossip_v1alpha1_sngossip_proto_rawDescOnce sync.Once
+	file_proto_sngossip_v1alpha1_sngossip_proto_rawDescData []byte
+)
+
+func file_proto_sngossip_v1alpha1_sngossip_proto_rawDescGZIP() []byte {
+	file_proto_sngossip_v1alpha1_sngossip_proto_rawDescOnce.Do(func() {
+		file_proto_sngossip_v1alpha1_sngossip_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_sngossip_v1alpha1_sngossip_proto_rawDesc), len(file_proto_sngossip_v1alpha1_sngossip_proto_rawDesc)))
+	})
+	return file_proto_sngossip_v1alpha1_sngossip_proto_rawDescData
+}
+
+var file_proto_sngossip_v1alpha1_sngossip_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_proto_sngossip_v1alpha1_sngossip_proto_goTypes = []any{
+	(State)(0),         // 0: sngossip.State
+	(*Hello)(nil),      // 1: sngossip.Hello
+	(*HelloProof)(nil), // 2: sngossip.HelloProof
+	(*Update)(nil),     // 3: sngossip.Update
+	(*Ping)(nil),       // 4: sngossip.Ping
+	(*Ack)(nil),        // 5: sngossip.Ack
+	(*PingReq)(nil),    // 6: sngossip.PingReq
+	(*Shuffle)(nil),    // 7: sngossip.Shuffle
+	(*Message)(nil),    // 8: sngossip.Message
+}
+var file_proto_sngossip_v1alpha1_sngossip_proto_depIdxs = []int32{
+	0, // 0: sngossip.Update.state:type_name -> sngossip.State
+	4, // 1: sngossip.Message.ping:type_name -> sngossip.Ping
+	5, // 2: sngossip.Message.ack:type_name -> sngossip.Ack
+	6, // 3: sngossip.Message.ping_req:type_name -> sngossip.PingReq
+	7, // 4: sngossip.Message.shuffle:type_name -> sngossip.Shuffle
+	3, // 5: sngossip.Message.updates:type_name -> sngossip.Update
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_proto_sngossip_v1alpha1_sngossip_proto_init() }
+func file_proto_sngossip_v1alpha1_sngossip_proto_init() {
+	if File_proto_sngossip_v1alpha1_sngossip_proto != nil {
+		return
+	}
+	file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes[7].OneofWrappers = []any{
+		(*Message_Ping)(nil),
+		(*Message_Ack)(nil),
+		(*Message_PingReq)(nil),
+		(*Message_Shuffle)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_sngossip_v1alpha1_sngossip_proto_rawDesc), len(file_proto_sngossip_v1alpha1_sngossip_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_sngossip_v1alpha1_sngossip_proto_goTypes,
+		DependencyIndexes: file_proto_sngossip_v1alpha1_sngossip_proto_depIdxs,
+		EnumInfos:         file_proto_sngossip_v1alpha1_sngossip_proto_enumTypes,
+		MessageInfos:      file_proto_sngossip_v1alpha1_sngossip_proto_msgTypes,
+	}.Build()
+	File_proto_sngossip_v1alpha1_sngossip_proto = out.File
+	file_proto_sngossip_v1alpha1_sngossip_proto_goTypes = nil
+	file_proto_sngossip_v1alpha1_sngossip_proto_depIdxs = nil
+}
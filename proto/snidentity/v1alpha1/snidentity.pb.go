@@ -0,0 +1,283 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: proto/snidentity/v1alpha1/snidentity.proto
+
+package snidentity
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// PrivateKey is the persisted form of a node identity.
+type PrivateKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ed25519Seed   []byte                 `protobuf:"bytes,1,opt,name=ed25519_seed,json=ed25519Seed,proto3" json:"ed25519_seed,omitempty"` // 32-byte Ed25519 seed of the signing key
+	X25519Key     []byte                 `protobuf:"bytes,2,opt,name=x25519_key,json=x25519Key,proto3" json:"x25519_key,omitempty"`       // 32-byte X25519 private key used for key agreement
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PrivateKey) Reset() {
+	*x = PrivateKey{}
+	mi := &file_proto_snidentity_v1alpha1_snidentity_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PrivateKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PrivateKey) ProtoMessage() {}
+
+func (x *PrivateKey) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snidentity_v1alpha1_snidentity_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PrivateKey.ProtoReflect.Descriptor instead.
+func (*PrivateKey) Descriptor() ([]byte, []int) {
+	return file_proto_snidentity_v1alpha1_snidentity_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PrivateKey) GetEd25519Seed() []byte {
+	if x != nil {
+		return x.Ed25519Seed
+	}
+	return nil
+}
+
+func (x *PrivateKey) GetX25519Key() []byte {
+	if x != nil {
+		return x.X25519Key
+	}
+	return nil
+}
+
+// PeerRecord describes how to reach a peer and binds its key agreement key to its identity.
+type PeerRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PublicKey     []byte                 `protobuf:"bytes,1,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`                // Ed25519 public key, the peer ID is its SHA-256 digest
+	StaticKey     []byte                 `protobuf:"bytes,2,opt,name=static_key,json=staticKey,proto3" json:"static_key,omitempty"`                // X25519 public key for key agreement, e.g. Noise handshakes
+	Addresses     []string               `protobuf:"bytes,3,rep,name=addresses,proto3" json:"addresses,omitempty"`                                 // Addresses the peer can be dialed at
+	Seq           uint64                 `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`                                            // Increases with every new record of the peer, newer records replace older ones
+	ExpiresUnixMs int64                  `protobuf:"varint,5,opt,name=expires_unix_ms,json=expiresUnixMs,proto3" json:"expires_unix_ms,omitempty"` // Time after which the record must not be used
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeerRecord) Reset() {
+	*x = PeerRecord{}
+	mi := &file_proto_snidentity_v1alpha1_snidentity_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeerRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerRecord) ProtoMessage() {}
+
+func (x *PeerRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snidentity_v1alpha1_snidentity_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeerRecord.ProtoReflect.Descriptor instead.
+func (*PeerRecord) Descriptor() ([]byte, []int) {
+	return file_proto_snidentity_v1alpha1_snidentity_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PeerRecord) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *PeerRecord) GetStaticKey() []byte {
+	if x != nil {
+		return x.StaticKey
+	}
+	return nil
+}
+
+func (x *PeerRecord) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *PeerRecord) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *PeerRecord) GetExpiresUnixMs() int64 {
+	if x != nil {
+		return x.ExpiresUnixMs
+	}
+	return 0
+}
+
+// SignedPeerRecord carries an encoded PeerRecord and the peer's signature over it.
+type SignedPeerRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Record        []byte                 `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`       // Encoded PeerRecord
+	Signature     []byte                 `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"` // Ed25519 signature by record.public_key over the signature prefix and record
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignedPeerRecord) Reset() {
+	*x = SignedPeerRecord{}
+	mi := &file_proto_snidentity_v1alpha1_snidentity_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignedPeerRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignedPeerRecord) ProtoMessage() {}
+
+func (x *SignedPeerRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snidentity_v1alpha1_snidentity_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignedPeerRecord.ProtoReflect.Descriptor instead.
+func (*SignedPeerRecord) Descriptor() ([]byte, []int) {
+	return file_proto_snidentity_v1alpha1_snidentity_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SignedPeerRecord) GetRecord() []byte {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *SignedPeerRecord) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+var File_proto_snidentity_v1alpha1_snidentity_proto protoreflect.FileDescriptor
+
+const file_proto_snidentity_v1alpha1_snidentity_proto_rawDesc = "" +
+	"\n" +
+	"*proto/snidentity/v1alpha1/snidentity.proto\x12\n" +
+	"snidentity\"N\n" +
+	"\n" +
+	"PrivateKey\x12!\n" +
+	"\fed25519_seed\x18\x01 \x01(\fR\ved25519Seed\x12\x1d\n" +
+	"\n" +
+	"x25519_key\x18\x02 \x01(\fR\tx25519Key\"\xa2\x01\n" +
+	"\n" +
+	"PeerRecord\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x01 \x01(\fR\tpublicKey\x12\x1d\n" +
+	"\n" +
+	"static_key\x18\x02 \x01(\fR\tstaticKey\x12\x1c\n" +
+	"\taddresses\x18\x03 \x03(\tR\taddresses\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x04R\x03seq\x12&\n" +
+	"\x0fexpires_unix_ms\x18\x05 \x01(\x03R\rexpiresUnixMs\"H\n" +
+	"\x10SignedPeerRecord\x12\x16\n" +
+	"\x06record\x18\x01 \x01(\fR\x06record\x12\x1c\n" +
+	"\tsignature\x18\x02 \x01(\fR\tsignatureB\xa6\x01\n" +
+	"\x0ecom.snidentityB\x0fSnidentityProtoP\x01Z;pkg.gfire.dev/supernet/proto/snidentity/v1alpha1;snidentity\xa2\x02\x03SXX\xaa\x02\n" +
+	"Snidentity\xca\x02\n" +
+	"Snidentity\xe2\x02\x16Snidentity\\GPBMetadata\xea\x02\n" +
+	"Snidentityb\x06proto3"
+
+var (
+	file_proto_snidentity_v1alpha1_snidentity_proto_rawDescOnce sync.Once
+	file_proto_snidentity_v1alpha1_snidentity_proto_rawDescData []byte
+)
+
+func file_proto_snidentity_v1alpha1_snidentity_proto_rawDescGZIP() []byte {
+	file_proto_snidentity_v1alpha1_snidentity_proto_rawDescOnce.Do(func() {
+		file_proto_snidentity_v1alpha1_snidentity_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_snidentity_v1alpha1_snidentity_proto_rawDesc), len(file_proto_snidentity_v1alpha1_snidentity_proto_rawDesc)))
+	})
+	return file_proto_snidentity_v1alpha1_snidentity_proto_rawDescData
+}
+
+var file_proto_snidentity_v1alpha1_snidentity_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_proto_snidentity_v1alpha1_snidentity_proto_goTypes = []any{
+	(*PrivateKey)(nil),       // 0: snidentity.PrivateKey
+	(*PeerRecord)(nil),       // 1: snidentity.PeerRecord
+	(*SignedPeerRecord)(nil), // 2: snidentity.SignedPeerRecord
+}
+var file_proto_snidentity_v1alpha1_snidentity_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_proto_snidentity_v1alpha1_snidentity_proto_init() }
+func file_proto_snidentity_v1alpha1_snidentity_proto_init() {
+	if File_proto_snidentity_v1alpha1_snidentity_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_snidentity_v1alpha1_snidentity_proto_rawDesc), len(file_proto_snidentity_v1alpha1_snidentity_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_snidentity_v1alpha1_snidentity_proto_goTypes,
+		DependencyIndexes: file_proto_snidentity_v1alpha1_snidentity_proto_depIdxs,
+		MessageInfos:      file_proto_snidentity_v1alpha1_snidentity_proto_msgTypes,
+	}.Build()
+	File_proto_snidentity_v1alpha1_snidentity_proto = out.File
+	file_proto_snidentity_v1alpha1_snidentity_proto_goTypes = nil
+	file_proto_snidentity_v1alpha1_snidentity_proto_depIdxs = nil
+}
@@ -0,0 +1,219 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: proto/snnaming/v1alpha1/snnaming.proto
+
+package snnaming
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// NameRecord binds a human-readable name to a peer ID.
+type NameRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                                           // Name being bound, e.g. "alice"
+	PeerId        []byte                 `protobuf:"bytes,2,opt,name=peer_id,json=peerId,proto3" json:"peer_id,omitempty"`                         // 32-byte peer ID the name resolves to
+	Seq           uint64                 `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`                                            // Increases with every new record for the name, newer records replace older ones
+	ExpiresUnixMs int64                  `protobuf:"varint,4,opt,name=expires_unix_ms,json=expiresUnixMs,proto3" json:"expires_unix_ms,omitempty"` // Time after which the record must not be used
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NameRecord) Reset() {
+	*x = NameRecord{}
+	mi := &file_proto_snnaming_v1alpha1_snnaming_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NameRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NameRecord) ProtoMessage() {}
+
+func (x *NameRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snnaming_v1alpha1_snnaming_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NameRecord.ProtoReflect.Descriptor instead.
+func (*NameRecord) Descriptor() ([]byte, []int) {
+	return file_proto_snnaming_v1alpha1_snnaming_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *NameRecord) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *NameRecord) GetPeerId() []byte {
+	if x != nil {
+		return x.PeerId
+	}
+	return nil
+}
+
+func (x *NameRecord) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *NameRecord) GetExpiresUnixMs() int64 {
+	if x != nil {
+		return x.ExpiresUnixMs
+	}
+	return 0
+}
+
+// SignedNameRecord carries an encoded NameRecord and the signer's signature over it.
+type SignedNameRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Record        []byte                 `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`                        // Encoded NameRecord
+	PublicKey     []byte                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"` // Ed25519 public key of the signer, the named peer itself or a naming authority
+	Signature     []byte                 `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`                  // Ed25519 signature by public_key over the signature prefix and record
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignedNameRecord) Reset() {
+	*x = SignedNameRecord{}
+	mi := &file_proto_snnaming_v1alpha1_snnaming_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignedNameRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignedNameRecord) ProtoMessage() {}
+
+func (x *SignedNameRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snnaming_v1alpha1_snnaming_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignedNameRecord.ProtoReflect.Descriptor instead.
+func (*SignedNameRecord) Descriptor() ([]byte, []int) {
+	return file_proto_snnaming_v1alpha1_snnaming_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SignedNameRecord) GetRecord() []byte {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *SignedNameRecord) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *SignedNameRecord) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+var File_proto_snnaming_v1alpha1_snnaming_proto protoreflect.FileDescriptor
+
+const file_proto_snnaming_v1alpha1_snnaming_proto_rawDesc = "" +
+	"\n" +
+	"&proto/snnaming/v1alpha1/snnaming.proto\x12\bsnnaming\"s\n" +
+	"\n" +
+	"NameRecord\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x17\n" +
+	"\apeer_id\x18\x02 \x01(\fR\x06peerId\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x04R\x03seq\x12&\n" +
+	"\x0fexpires_unix_ms\x18\x04 \x01(\x03R\rexpiresUnixMs\"g\n" +
+	"\x10SignedNameRecord\x12\x16\n" +
+	"\x06record\x18\x01 \x01(\fR\x06record\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\fR\tpublicKey\x12\x1c\n" +
+	"\tsignature\x18\x03 \x01(\fR\tsignatureB\x96\x01\n" +
+	"\fcom.snnamingB\rSnnamingProtoP\x01Z7pkg.gfire.dev/supernet/proto/snnaming/v1alpha1;snnaming\xa2\x02\x03SXX\xaa\x02\bSnnaming\xca\x02\bSnnaming\xe2\x02\x14Snnaming\\GPBMetadata\xea\x02\bSnnamingb\x06proto3"
+
+var (
+	file_proto_snnaming_v1alpha1_snnaming_proto_rawDescOnce sync.Once
+	file_proto_snnaming_v1alpha1_snnaming_proto_rawDescData []byte
+)
+
+func file_proto_snnaming_v1alpha1_snnaming_proto_rawDescGZIP() []byte {
+	file_proto_snnaming_v1alpha1_snnaming_proto_rawDescOnce.Do(func() {
+		file_proto_snnaming_v1alpha1_snnaming_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_snnaming_v1alpha1_snnaming_proto_rawDesc), len(file_proto_snnaming_v1alpha1_snnaming_proto_rawDesc)))
+	})
+	return file_proto_snnaming_v1alpha1_snnaming_proto_rawDescData
+}
+
+var file_proto_snnaming_v1alpha1_snnaming_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_snnaming_v1alpha1_snnaming_proto_goTypes = []any{
+	(*NameRecord)(nil),       // 0: snnaming.NameRecord
+	(*SignedNameRecord)(nil), // 1: snnaming.SignedNameRecord
+}
+var file_proto_snnaming_v1alpha1_snnaming_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_proto_snnaming_v1alpha1_snnaming_proto_init() }
+func file_proto_snnaming_v1alpha1_snnaming_proto_init() {
+	if File_proto_snnaming_v1alpha1_snnaming_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_snnaming_v1alpha1_snnaming_proto_rawDesc), len(file_proto_snnaming_v1alpha1_snnaming_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_snnaming_v1alpha1_snnaming_proto_goTypes,
+		DependencyIndexes: file_proto_snnaming_v1alpha1_snnaming_proto_depIdxs,
+		MessageInfos:      file_proto_snnaming_v1alpha1_snnaming_proto_msgTypes,
+	}.Build()
+	File_proto_snnaming_v1alpha1_snnaming_proto = out.File
+	file_proto_snnaming_v1alpha1_snnaming_proto_goTypes = nil
+	file_proto_snnaming_v1alpha1_snnaming_proto_depIdxs = nil
+}
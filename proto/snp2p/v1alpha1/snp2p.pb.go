@@ -0,0 +1,1121 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: proto/snp2p/v1alpha1/snp2p.proto
+
+package snp2p
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// PeerRecord describes a peer known to the DHT.
+type PeerRecord struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PeerId        []byte                 `protobuf:"bytes,1,opt,name=peer_id,json=peerId,proto3" json:"peer_id,omitempty"`          // 256-bit peer id, SHA-256 of the public key
+	PublicKey     []byte                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"` // Ed25519 public key of the peer
+	Addresses     []string               `protobuf:"bytes,3,rep,name=addresses,proto3" json:"addresses,omitempty"`                  // Addresses the peer can be dialed at directly, e.g. "wss://example.com/p2p"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PeerRecord) Reset() {
+	*x = PeerRecord{}
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PeerRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerRecord) ProtoMessage() {}
+
+func (x *PeerRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeerRecord.ProtoReflect.Descriptor instead.
+func (*PeerRecord) Descriptor() ([]byte, []int) {
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PeerRecord) GetPeerId() []byte {
+	if x != nil {
+		return x.PeerId
+	}
+	return nil
+}
+
+func (x *PeerRecord) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *PeerRecord) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+// Hello is the first message sent by both sides of a new connection.
+type Hello struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PublicKey     []byte                 `protobuf:"bytes,1,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"` // Ed25519 public key of the sender
+	Nonce         []byte                 `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`                          // Random challenge the remote peer must sign
+	Addresses     []string               `protobuf:"bytes,3,rep,name=addresses,proto3" json:"addresses,omitempty"`                  // Addresses the sender can be dialed at directly
+	Version       uint32                 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`                     // Highest overlay protocol version of the sender, 0 for peers predating negotiation
+	Capabilities  uint64                 `protobuf:"varint,5,opt,name=capabilities,proto3" json:"capabilities,omitempty"`           // Overlay capabilities of the sender
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hello) Reset() {
+	*x = Hello{}
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hello) ProtoMessage() {}
+
+func (x *Hello) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hello.ProtoReflect.Descriptor instead.
+func (*Hello) Descriptor() ([]byte, []int) {
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Hello) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *Hello) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+func (x *Hello) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *Hello) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Hello) GetCapabilities() uint64 {
+	if x != nil {
+		return x.Capabilities
+	}
+	return 0
+}
+
+// HelloProof proves ownership of the public key announced in Hello.
+type HelloProof struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Signature     []byte                 `protobuf:"bytes,1,opt,name=signature,proto3" json:"signature,omitempty"` // Ed25519 signature over the remote peer's nonce
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HelloProof) Reset() {
+	*x = HelloProof{}
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloProof) ProtoMessage() {}
+
+func (x *HelloProof) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloProof.ProtoReflect.Descriptor instead.
+func (*HelloProof) Descriptor() ([]byte, []int) {
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *HelloProof) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+// Ping checks the liveness of a peer.
+type Ping struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ping) Reset() {
+	*x = Ping{}
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ping) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ping) ProtoMessage() {}
+
+func (x *Ping) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ping.ProtoReflect.Descriptor instead.
+func (*Ping) Descriptor() ([]byte, []int) {
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP(), []int{3}
+}
+
+// Pong is the response to Ping.
+type Pong struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Pong) Reset() {
+	*x = Pong{}
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pong) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pong) ProtoMessage() {}
+
+func (x *Pong) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pong.ProtoReflect.Descriptor instead.
+func (*Pong) Descriptor() ([]byte, []int) {
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP(), []int{4}
+}
+
+// FindNode asks a peer for the peers it knows closest to target.
+type FindNode struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Target        []byte                 `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"` // 256-bit key to find the closest peers to
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindNode) Reset() {
+	*x = FindNode{}
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindNode) ProtoMessage() {}
+
+func (x *FindNode) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindNode.ProtoReflect.Descriptor instead.
+func (*FindNode) Descriptor() ([]byte, []int) {
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FindNode) GetTarget() []byte {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+// Peers is the response to FindNode and carries the closest peers known to the responder.
+type Peers struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Peers         []*PeerRecord          `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Peers) Reset() {
+	*x = Peers{}
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Peers) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Peers) ProtoMessage() {}
+
+func (x *Peers) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Peers.ProtoReflect.Descriptor instead.
+func (*Peers) Descriptor() ([]byte, []int) {
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Peers) GetPeers() []*PeerRecord {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+// PutValue stores a value under a key on the receiving peer.
+type PutValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           []byte                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`                                  // 256-bit key
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`                              // Opaque value
+	TtlSeconds    int64                  `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"` // Requested lifetime of the value, capped by the receiver
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PutValue) Reset() {
+	*x = PutValue{}
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutValue) ProtoMessage() {}
+
+func (x *PutValue) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutValue.ProtoReflect.Descriptor instead.
+func (*PutValue) Descriptor() ([]byte, []int) {
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PutValue) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *PutValue) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *PutValue) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+// GetValue asks a peer for the value stored under key.
+type GetValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           []byte                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"` // 256-bit key
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetValue) Reset() {
+	*x = GetValue{}
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetValue) ProtoMessage() {}
+
+func (x *GetValue) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetValue.ProtoReflect.Descriptor instead.
+func (*GetValue) Descriptor() ([]byte, []int) {
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetValue) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+// ValueResult is the response to GetValue.
+type ValueResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         []byte                 `protobuf:"bytes,1,opt,name=value,proto3,oneof" json:"value,omitempty"`                          // Stored value, absent if the peer does not hold the key
+	CloserPeers   []*PeerRecord          `protobuf:"bytes,2,rep,name=closer_peers,json=closerPeers,proto3" json:"closer_peers,omitempty"` // Peers closer to the key, for continuing the lookup
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValueResult) Reset() {
+	*x = ValueResult{}
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValueResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValueResult) ProtoMessage() {}
+
+func (x *ValueResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValueResult.ProtoReflect.Descriptor instead.
+func (*ValueResult) Descriptor() ([]byte, []int) {
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ValueResult) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *ValueResult) GetCloserPeers() []*PeerRecord {
+	if x != nil {
+		return x.CloserPeers
+	}
+	return nil
+}
+
+// Signal carries an opaque connection setup payload (e.g. a WebRTC offer or answer) between two peers.
+type Signal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payload       []byte                 `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Signal) Reset() {
+	*x = Signal{}
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Signal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Signal) ProtoMessage() {}
+
+func (x *Signal) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Signal.ProtoReflect.Descriptor instead.
+func (*Signal) Descriptor() ([]byte, []int) {
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Signal) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+// HolePunch asks a peer reached through a relay to open a direct connection. Only the peer with the lower
+// id sends connection offers, so simultaneous attempts of both peers cannot collide; the other peer sends
+// this request instead and is answered once the connection is established or has failed.
+type HolePunch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HolePunch) Reset() {
+	*x = HolePunch{}
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HolePunch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HolePunch) ProtoMessage() {}
+
+func (x *HolePunch) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HolePunch.ProtoReflect.Descriptor instead.
+func (*HolePunch) Descriptor() ([]byte, []int) {
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP(), []int{11}
+}
+
+// Ack is an empty successful response.
+type Ack struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ack) Reset() {
+	*x = Ack{}
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ack) ProtoMessage() {}
+
+func (x *Ack) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ack.ProtoReflect.Descriptor instead.
+func (*Ack) Descriptor() ([]byte, []int) {
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP(), []int{12}
+}
+
+// Error is a failed response.
+type Error struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Error) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// Envelope frames every message exchanged between peers.
+// Requests and responses are matched by request_id; envelopes whose destination is not the
+// receiving peer are forwarded by it, which provides relayed delivery between unconnected peers.
+type Envelope struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Source      []byte                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`                         // 256-bit peer id of the original sender
+	Destination []byte                 `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`               // 256-bit peer id of the final recipient
+	RequestId   uint64                 `protobuf:"varint,3,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"` // Request identifier chosen by the requester
+	Response    bool                   `protobuf:"varint,4,opt,name=response,proto3" json:"response,omitempty"`                    // Whether this envelope is a response to request_id
+	HopLimit    uint32                 `protobuf:"varint,5,opt,name=hop_limit,json=hopLimit,proto3" json:"hop_limit,omitempty"`    // Remaining number of times the envelope may be forwarded
+	// Types that are valid to be assigned to Body:
+	//
+	//	*Envelope_Ping
+	//	*Envelope_Pong
+	//	*Envelope_FindNode
+	//	*Envelope_Peers
+	//	*Envelope_PutValue
+	//	*Envelope_GetValue
+	//	*Envelope_ValueResult
+	//	*Envelope_Signal
+	//	*Envelope_Ack
+	//	*Envelope_Error
+	//	*Envelope_HolePunch
+	Body          isEnvelope_Body `protobuf_oneof:"body"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Envelope) GetSource() []byte {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *Envelope) GetDestination() []byte {
+	if x != nil {
+		return x.Destination
+	}
+	return nil
+}
+
+func (x *Envelope) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *Envelope) GetResponse() bool {
+	if x != nil {
+		return x.Response
+	}
+	return false
+}
+
+func (x *Envelope) GetHopLimit() uint32 {
+	if x != nil {
+		return x.HopLimit
+	}
+	return 0
+}
+
+func (x *Envelope) GetBody() isEnvelope_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *Envelope) GetPing() *Ping {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_Ping); ok {
+			return x.Ping
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetPong() *Pong {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_Pong); ok {
+			return x.Pong
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetFindNode() *FindNode {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_FindNode); ok {
+			return x.FindNode
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetPeers() *Peers {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_Peers); ok {
+			return x.Peers
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetPutValue() *PutValue {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_PutValue); ok {
+			return x.PutValue
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetGetValue() *GetValue {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_GetValue); ok {
+			return x.GetValue
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetValueResult() *ValueResult {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_ValueResult); ok {
+			return x.ValueResult
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetSignal() *Signal {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_Signal); ok {
+			return x.Signal
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetAck() *Ack {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_Ack); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetError() *Error {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetHolePunch() *HolePunch {
+	if x != nil {
+		if x, ok := x.Body.(*Envelope_HolePunch); ok {
+			return x.HolePunch
+		}
+	}
+	return nil
+}
+
+type isEnvelope_Body interface {
+	isEnvelope_Body()
+}
+
+type Envelope_Ping struct {
+	Ping *Ping `protobuf:"bytes,10,opt,name=ping,proto3,oneof"`
+}
+
+type Envelope_Pong struct {
+	Pong *Pong `protobuf:"bytes,11,opt,name=pong,proto3,oneof"`
+}
+
+type Envelope_FindNode struct {
+	FindNode *FindNode `protobuf:"bytes,12,opt,name=find_node,json=findNode,proto3,oneof"`
+}
+
+type Envelope_Peers struct {
+	Peers *Peers `protobuf:"bytes,13,opt,name=peers,proto3,oneof"`
+}
+
+type Envelope_PutValue struct {
+	PutValue *PutValue `protobuf:"bytes,14,opt,name=put_value,json=putValue,proto3,oneof"`
+}
+
+type Envelope_GetValue struct {
+	GetValue *GetValue `protobuf:"bytes,15,opt,name=get_value,json=getValue,proto3,oneof"`
+}
+
+type Envelope_ValueResult struct {
+	ValueResult *ValueResult `protobuf:"bytes,16,opt,name=value_result,json=valueResult,proto3,oneof"`
+}
+
+type Envelope_Signal struct {
+	Signal *Signal `protobuf:"bytes,17,opt,name=signal,proto3,oneof"`
+}
+
+type Envelope_Ack struct {
+	Ack *Ack `protobuf:"bytes,18,opt,name=ack,proto3,oneof"`
+}
+
+type Envelope_Error struct {
+	Error *Error `protobuf:"bytes,19,opt,name=error,proto3,oneof"`
+}
+
+type Envelope_HolePunch struct {
+	HolePunch *HolePunch `protobuf:"bytes,20,opt,name=hole_punch,json=holePunch,proto3,oneof"`
+}
+
+func (*Envelope_Ping) isEnvelope_Body() {}
+
+func (*Envelope_Pong) isEnvelope_Body() {}
+
+func (*Envelope_FindNode) isEnvelope_Body() {}
+
+func (*Envelope_Peers) isEnvelope_Body() {}
+
+func (*Envelope_PutValue) isEnvelope_Body() {}
+
+func (*Envelope_GetValue) isEnvelope_Body() {}
+
+func (*Envelope_ValueResult) isEnvelope_Body() {}
+
+func (*Envelope_Signal) isEnvelope_Body() {}
+
+func (*Envelope_Ack) isEnvelope_Body() {}
+
+func (*Envelope_Error) isEnvelope_Body() {}
+
+func (*Envelope_HolePunch) isEnvelope_Body() {}
+
+var File_proto_snp2p_v1alpha1_snp2p_proto protoreflect.FileDescriptor
+
+const file_proto_snp2p_v1alpha1_snp2p_proto_rawDesc = "" +
+	"\n" +
+	" proto/snp2p/v1alpha1/snp2p.proto\x12\x05snp2p\"b\n" +
+	"\n" +
+	"PeerRecord\x12\x17\n" +
+	"\apeer_id\x18\x01 \x01(\fR\x06peerId\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\fR\tpublicKey\x12\x1c\n" +
+	"\taddresses\x18\x03 \x03(\tR\taddresses\"\x98\x01\n" +
+	"\x05Hello\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x01 \x01(\fR\tpublicKey\x12\x14\n" +
+	"\x05nonce\x18\x02 \x01(\fR\x05nonce\x12\x1c\n" +
+	"\taddresses\x18\x03 \x03(\tR\taddresses\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\rR\aversion\x12\"\n" +
+	"\fcapabilities\x18\x05 \x01(\x04R\fcapabilities\"*\n" +
+	"\n" +
+	"HelloProof\x12\x1c\n" +
+	"\tsignature\x18\x01 \x01(\fR\tsignature\"\x06\n" +
+	"\x04Ping\"\x06\n" +
+	"\x04Pong\"\"\n" +
+	"\bFindNode\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\fR\x06target\"0\n" +
+	"\x05Peers\x12'\n" +
+	"\x05peers\x18\x01 \x03(\v2\x11.snp2p.PeerRecordR\x05peers\"S\n" +
+	"\bPutValue\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\fR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x12\x1f\n" +
+	"\vttl_seconds\x18\x03 \x01(\x03R\n" +
+	"ttlSeconds\"\x1c\n" +
+	"\bGetValue\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\fR\x03key\"h\n" +
+	"\vValueResult\x12\x19\n" +
+	"\x05value\x18\x01 \x01(\fH\x00R\x05value\x88\x01\x01\x124\n" +
+	"\fcloser_peers\x18\x02 \x03(\v2\x11.snp2p.PeerRecordR\vcloserPeersB\b\n" +
+	"\x06_value\"\"\n" +
+	"\x06Signal\x12\x18\n" +
+	"\apayload\x18\x01 \x01(\fR\apayload\"\v\n" +
+	"\tHolePunch\"\x05\n" +
+	"\x03Ack\"!\n" +
+	"\x05Error\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"\xfb\x04\n" +
+	"\bEnvelope\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\fR\x06source\x12 \n" +
+	"\vdestination\x18\x02 \x01(\fR\vdestination\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x03 \x01(\x04R\trequestId\x12\x1a\n" +
+	"\bresponse\x18\x04 \x01(\bR\bresponse\x12\x1b\n" +
+	"\thop_limit\x18\x05 \x01(\rR\bhopLimit\x12!\n" +
+	"\x04ping\x18\n" +
+	" \x01(\v2\v.snp2p.PingH\x00R\x04ping\x12!\n" +
+	"\x04pong\x18\v \x01(\v2\v.snp2p.PongH\x00R\x04pong\x12.\n" +
+	"\tfind_node\x18\f \x01(\v2\x0f.snp2p.FindNodeH\x00R\bfindNode\x12$\n" +
+	"\x05peers\x18\r \x01(\v2\f.snp2p.PeersH\x00R\x05peers\x12.\n" +
+	"\tput_value\x18\x0e \x01(\v2\x0f.snp2p.PutValueH\x00R\bputValue\x12.\n" +
+	"\tget_value\x18\x0f \x01(\v2\x0f.snp2p.GetValueH\x00R\bgetValue\x127\n" +
+	"\fvalue_result\x18\x10 \x01(\v2\x12.snp2p.ValueResultH\x00R\vvalueResult\x12'\n" +
+	"\x06signal\x18\x11 \x01(\v2\r.snp2p.SignalH\x00R\x06signal\x12\x1e\n" +
+	"\x03ack\x18\x12 \x01(\v2\n" +
+	".snp2p.AckH\x00R\x03ack\x12$\n" +
+	"\x05error\x18\x13 \x01(\v2\f.snp2p.ErrorH\x00R\x05error\x121\n" +
+	"\n" +
+	"hole_punch\x18\x14 \x01(\v2\x10.snp2p.HolePunchH\x00R\tholePunchB\x06\n" +
+	"\x04bodyB~\n" +
+	"\tcom.snp2pB\n" +
+	"Snp2pProtoP\x01Z1pkg.gfire.dev/supernet/proto/snp2p/v1alpha1;snp2p\xa2\x02\x03SXX\xaa\x02\x05Snp2p\xca\x02\x05Snp2p\xe2\x02\x11Snp2p\\GPBMetadata\xea\x02\x05Snp2pb\x06proto3"
+
+var (
+	file_proto_snp2p_v1alpha1_snp2p_proto_rawDescOnce sync.Once
+	file_proto_snp2p_v1alpha1_snp2p_proto_rawDescData []byte
+)
+
+func file_proto_snp2p_v1alpha1_snp2p_proto_rawDescGZIP() []byte {
+	file_proto_snp2p_v1alpha1_snp2p_proto_rawDescOnce.Do(func() {
+		file_proto_snp2p_v1alpha1_snp2p_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_snp2p_v1alpha1_snp2p_proto_rawDesc), len(file_proto_snp2p_v1alpha1_snp2p_proto_rawDesc)))
+	})
+	return file_proto_snp2p_v1alpha1_snp2p_proto_rawDescData
+}
+
+var file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_proto_snp2p_v1alpha1_snp2p_proto_goTypes = []any{
+	(*PeerRecord)(nil),  // 0: snp2p.PeerRecord
+	(*Hello)(nil),       // 1: snp2p.Hello
+	(*HelloProof)(nil),  // 2: snp2p.HelloProof
+	(*Ping)(nil),        // 3: snp2p.Ping
+	(*Pong)(nil),        // 4: snp2p.Pong
+	(*FindNode)(nil),    // 5: snp2p.FindNode
+	(*Peers)(nil),       // 6: snp2p.Peers
+	(*PutValue)(nil),    // 7: snp2p.PutValue
+	(*GetValue)(nil),    // 8: snp2p.GetValue
+	(*ValueResult)(nil), // 9: snp2p.ValueResult
+	(*Signal)(nil),      // 10: snp2p.Signal
+	(*HolePunch)(nil),   // 11: snp2p.HolePunch
+	(*Ack)(nil),         // 12: snp2p.Ack
+	(*Error)(nil),       // 13: snp2p.Error
+	(*Envelope)(nil),    // 14: snp2p.Envelope
+}
+var file_proto_snp2p_v1alpha1_snp2p_proto_depIdxs = []int32{
+	0,  // 0: snp2p.Peers.peers:type_name -> snp2p.PeerRecord
+	0,  // 1: snp2p.ValueResult.closer_peers:type_name -> snp2p.PeerRecord
+	3,  // 2: snp2p.Envelope.ping:type_name -> snp2p.Ping
+	4,  // 3: snp2p.Envelope.pong:type_name -> snp2p.Pong
+	5,  // 4: snp2p.Envelope.find_node:type_name -> snp2p.FindNode
+	6,  // 5: snp2p.Envelope.peers:type_name -> snp2p.Peers
+	7,  // 6: snp2p.Envelope.put_value:type_name -> snp2p.PutValue
+	8,  // 7: snp2p.Envelope.get_value:type_name -> snp2p.GetValue
+	9,  // 8: snp2p.Envelope.value_result:type_name -> snp2p.ValueResult
+	10, // 9: snp2p.Envelope.signal:type_name -> snp2p.Signal
+	12, // 10: snp2p.Envelope.ack:type_name -> snp2p.Ack
+	13, // 11: snp2p.Envelope.error:type_name -> snp2p.Error
+	11, // 12: snp2p.Envelope.hole_punch:type_name -> snp2p.HolePunch
+	13, // [13:13] is the sub-list for method output_type
+	13, // [13:13] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_proto_snp2p_v1alpha1_snp2p_proto_init() }
+func file_proto_snp2p_v1alpha1_snp2p_proto_init() {
+	if File_proto_snp2p_v1alpha1_snp2p_proto != nil {
+		return
+	}
+	file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[9].OneofWrappers = []any{}
+	file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes[14].OneofWrappers = []any{
+		(*Envelope_Ping)(nil),
+		(*Envelope_Pong)(nil),
+		(*Envelope_FindNode)(nil),
+		(*Envelope_Peers)(nil),
+		(*Envelope_PutValue)(nil),
+		(*Envelope_GetValue)(nil),
+		(*Envelope_ValueResult)(nil),
+		(*Envelope_Signal)(nil),
+		(*Envelope_Ack)(nil),
+		(*Envelope_Error)(nil),
+		(*Envelope_HolePunch)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_snp2p_v1alpha1_snp2p_proto_rawDesc), len(file_proto_snp2p_v1alpha1_snp2p_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_snp2p_v1alpha1_snp2p_proto_goTypes,
+		DependencyIndexes: file_proto_snp2p_v1alpha1_snp2p_proto_depIdxs,
+		MessageInfos:      file_proto_snp2p_v1alpha1_snp2p_proto_msgTypes,
+	}.Build()
+	File_proto_snp2p_v1alpha1_snp2p_proto = out.File
+	file_proto_snp2p_v1alpha1_snp2p_proto_goTypes = nil
+	file_proto_snp2p_v1alpha1_snp2p_proto_depIdxs = nil
+}
@@ -0,0 +1,229 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: proto/snpeerstore/v1alpha1/snpeerstore.proto
+
+package snpeerstore
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Peer is the persisted state of a known peer, keyed by its peer ID.
+type Peer struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	PublicKey      []byte                 `protobuf:"bytes,1,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`                     // Ed25519 public key of the peer
+	Addresses      []string               `protobuf:"bytes,2,rep,name=addresses,proto3" json:"addresses,omitempty"`                                      // Last known addresses the peer can be dialed at
+	Record         []byte                 `protobuf:"bytes,3,opt,name=record,proto3" json:"record,omitempty"`                                            // Latest signed peer record, as exchanged by gossip
+	LastSeenUnixMs int64                  `protobuf:"varint,4,opt,name=last_seen_unix_ms,json=lastSeenUnixMs,proto3" json:"last_seen_unix_ms,omitempty"` // Time of the last successful connection
+	Failures       uint32                 `protobuf:"varint,5,opt,name=failures,proto3" json:"failures,omitempty"`                                       // Consecutive failed connection attempts since then
+	Latency        []*LatencySample       `protobuf:"bytes,6,rep,name=latency,proto3" json:"latency,omitempty"`                                          // Recent round trip times, oldest first
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Peer) Reset() {
+	*x = Peer{}
+	mi := &file_proto_snpeerstore_v1alpha1_snpeerstore_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Peer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Peer) ProtoMessage() {}
+
+func (x *Peer) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snpeerstore_v1alpha1_snpeerstore_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Peer.ProtoReflect.Descriptor instead.
+func (*Peer) Descriptor() ([]byte, []int) {
+	return file_proto_snpeerstore_v1alpha1_snpeerstore_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Peer) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *Peer) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *Peer) GetRecord() []byte {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *Peer) GetLastSeenUnixMs() int64 {
+	if x != nil {
+		return x.LastSeenUnixMs
+	}
+	return 0
+}
+
+func (x *Peer) GetFailures() uint32 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *Peer) GetLatency() []*LatencySample {
+	if x != nil {
+		return x.Latency
+	}
+	return nil
+}
+
+// LatencySample is a round trip time measured to a peer.
+type LatencySample struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AtUnixMs      int64                  `protobuf:"varint,1,opt,name=at_unix_ms,json=atUnixMs,proto3" json:"at_unix_ms,omitempty"` // Time of the measurement
+	RttUs         int64                  `protobuf:"varint,2,opt,name=rtt_us,json=rttUs,proto3" json:"rtt_us,omitempty"`            // Round trip time in microseconds
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LatencySample) Reset() {
+	*x = LatencySample{}
+	mi := &file_proto_snpeerstore_v1alpha1_snpeerstore_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LatencySample) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LatencySample) ProtoMessage() {}
+
+func (x *LatencySample) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snpeerstore_v1alpha1_snpeerstore_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LatencySample.ProtoReflect.Descriptor instead.
+func (*LatencySample) Descriptor() ([]byte, []int) {
+	return file_proto_snpeerstore_v1alpha1_snpeerstore_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LatencySample) GetAtUnixMs() int64 {
+	if x != nil {
+		return x.AtUnixMs
+	}
+	return 0
+}
+
+func (x *LatencySample) GetRttUs() int64 {
+	if x != nil {
+		return x.RttUs
+	}
+	return 0
+}
+
+var File_proto_snpeerstore_v1alpha1_snpeerstore_proto protoreflect.FileDescriptor
+
+const file_proto_snpeerstore_v1alpha1_snpeerstore_proto_rawDesc = "" +
+	"\n" +
+	",proto/snpeerstore/v1alpha1/snpeerstore.proto\x12\vsnpeerstore\"\xd8\x01\n" +
+	"\x04Peer\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x01 \x01(\fR\tpublicKey\x12\x1c\n" +
+	"\taddresses\x18\x02 \x03(\tR\taddresses\x12\x16\n" +
+	"\x06record\x18\x03 \x01(\fR\x06record\x12)\n" +
+	"\x11last_seen_unix_ms\x18\x04 \x01(\x03R\x0elastSeenUnixMs\x12\x1a\n" +
+	"\bfailures\x18\x05 \x01(\rR\bfailures\x124\n" +
+	"\alatency\x18\x06 \x03(\v2\x1a.snpeerstore.LatencySampleR\alatency\"D\n" +
+	"\rLatencySample\x12\x1c\n" +
+	"\n" +
+	"at_unix_ms\x18\x01 \x01(\x03R\batUnixMs\x12\x15\n" +
+	"\x06rtt_us\x18\x02 \x01(\x03R\x05rttUsB\xae\x01\n" +
+	"\x0fcom.snpeerstoreB\x10SnpeerstoreProtoP\x01Z=pkg.gfire.dev/supernet/proto/snpeerstore/v1alpha1;snpeerstore\xa2\x02\x03SXX\xaa\x02\vSnpeerstore\xca\x02\vSnpeerstore\xe2\x02\x17Snpeerstore\\GPBMetadata\xea\x02\vSnpeerstoreb\x06proto3"
+
+var (
+	file_proto_snpeerstore_v1alpha1_snpeerstore_proto_rawDescOnce sync.Once
+	file_proto_snpeerstore_v1alpha1_snpeerstore_proto_rawDescData []byte
+)
+
+func file_proto_snpeerstore_v1alpha1_snpeerstore_proto_rawDescGZIP() []byte {
+	file_proto_snpeerstore_v1alpha1_snpeerstore_proto_rawDescOnce.Do(func() {
+		file_proto_snpeerstore_v1alpha1_snpeerstore_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_snpeerstore_v1alpha1_snpeerstore_proto_rawDesc), len(file_proto_snpeerstore_v1alpha1_snpeerstore_proto_rawDesc)))
+	})
+	return file_proto_snpeerstore_v1alpha1_snpeerstore_proto_rawDescData
+}
+
+var file_proto_snpeerstore_v1alpha1_snpeerstore_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_snpeerstore_v1alpha1_snpeerstore_proto_goTypes = []any{
+	(*Peer)(nil),          // 0: snpeerstore.Peer
+	(*LatencySample)(nil), // 1: snpeerstore.LatencySample
+}
+var file_proto_snpeerstore_v1alpha1_snpeerstore_proto_depIdxs = []int32{
+	1, // 0: snpeerstore.Peer.latency:type_name -> snpeerstore.LatencySample
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_proto_snpeerstore_v1alpha1_snpeerstore_proto_init() }
+func file_proto_snpeerstore_v1alpha1_snpeerstore_proto_init() {
+	if File_proto_snpeerstore_v1alpha1_snpeerstore_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_snpeerstore_v1alpha1_snpeerstore_proto_rawDesc), len(file_proto_snpeerstore_v1alpha1_snpeerstore_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_snpeerstore_v1alpha1_snpeerstore_proto_goTypes,
+		DependencyIndexes: file_proto_snpeerstore_v1alpha1_snpeerstore_proto_depIdxs,
+		MessageInfos:      file_proto_snpeerstore_v1alpha1_snpeerstore_proto_msgTypes,
+	}.Build()
+	File_proto_snpeerstore_v1alpha1_snpeerstore_proto = out.File
+	file_proto_snpeerstore_v1alpha1_snpeerstore_proto_goTypes = nil
+	file_proto_snpeerstore_v1alpha1_snpeerstore_proto_depIdxs = nil
+}
@@ -0,0 +1,305 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: proto/snrelay/v1alpha1/snrelay.proto
+
+package snrelay
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Network selects the protocol used to reach a relay target.
+type Network int32
+
+const (
+	// TCP tunnels a byte stream; stream messages carry arbitrary chunks of it.
+	Network_TCP Network = 0
+	// UDP tunnels datagrams; every stream message carries exactly one datagram.
+	Network_UDP Network = 1
+)
+
+// Enum value maps for Network.
+var (
+	Network_name = map[int32]string{
+		0: "TCP",
+		1: "UDP",
+	}
+	Network_value = map[string]int32{
+		"TCP": 0,
+		"UDP": 1,
+	}
+)
+
+func (x Network) Enum() *Network {
+	p := new(Network)
+	*p = x
+	return p
+}
+
+func (x Network) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Network) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_snrelay_v1alpha1_snrelay_proto_enumTypes[0].Descriptor()
+}
+
+func (Network) Type() protoreflect.EnumType {
+	return &file_proto_snrelay_v1alpha1_snrelay_proto_enumTypes[0]
+}
+
+func (x Network) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Network.Descriptor instead.
+func (Network) EnumDescriptor() ([]byte, []int) {
+	return file_proto_snrelay_v1alpha1_snrelay_proto_rawDescGZIP(), []int{0}
+}
+
+// OpenRequest is the first message on a relay stream and names the target to connect to.
+type OpenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Network       Network                `protobuf:"varint,1,opt,name=network,proto3,enum=snrelay.Network" json:"network,omitempty"`                                                                                   // Target protocol
+	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`                                                                                                         // Target "host:port"
+	TraceContext  map[string]string      `protobuf:"bytes,3,rep,name=trace_context,json=traceContext,proto3" json:"trace_context,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // W3C trace context of the dialing span, e.g. "traceparent"
+	Version       uint32                 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`                                                                                                        // Highest relay protocol version of the client, 0 for clients predating negotiation
+	Capabilities  uint64                 `protobuf:"varint,5,opt,name=capabilities,proto3" json:"capabilities,omitempty"`                                                                                              // Relay capabilities of the client
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OpenRequest) Reset() {
+	*x = OpenRequest{}
+	mi := &file_proto_snrelay_v1alpha1_snrelay_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpenRequest) ProtoMessage() {}
+
+func (x *OpenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snrelay_v1alpha1_snrelay_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpenRequest.ProtoReflect.Descriptor instead.
+func (*OpenRequest) Descriptor() ([]byte, []int) {
+	return file_proto_snrelay_v1alpha1_snrelay_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *OpenRequest) GetNetwork() Network {
+	if x != nil {
+		return x.Network
+	}
+	return Network_TCP
+}
+
+func (x *OpenRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetTraceContext() map[string]string {
+	if x != nil {
+		return x.TraceContext
+	}
+	return nil
+}
+
+func (x *OpenRequest) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetCapabilities() uint64 {
+	if x != nil {
+		return x.Capabilities
+	}
+	return 0
+}
+
+// OpenResponse answers an OpenRequest. Tunneled data follows on the same stream if error is empty.
+type OpenResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Error         string                 `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`                                      // Reason the target could not be reached, empty on success
+	LocalAddress  string                 `protobuf:"bytes,2,opt,name=local_address,json=localAddress,proto3" json:"local_address,omitempty"`    // Relay-side local address of the target connection
+	RemoteAddress string                 `protobuf:"bytes,3,opt,name=remote_address,json=remoteAddress,proto3" json:"remote_address,omitempty"` // Resolved target address
+	Version       uint32                 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`                                 // Negotiated relay protocol version, 0 for relays predating negotiation
+	Capabilities  uint64                 `protobuf:"varint,5,opt,name=capabilities,proto3" json:"capabilities,omitempty"`                       // Relay capabilities supported by both sides
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OpenResponse) Reset() {
+	*x = OpenResponse{}
+	mi := &file_proto_snrelay_v1alpha1_snrelay_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpenResponse) ProtoMessage() {}
+
+func (x *OpenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snrelay_v1alpha1_snrelay_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpenResponse.ProtoReflect.Descriptor instead.
+func (*OpenResponse) Descriptor() ([]byte, []int) {
+	return file_proto_snrelay_v1alpha1_snrelay_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *OpenResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *OpenResponse) GetLocalAddress() string {
+	if x != nil {
+		return x.LocalAddress
+	}
+	return ""
+}
+
+func (x *OpenResponse) GetRemoteAddress() string {
+	if x != nil {
+		return x.RemoteAddress
+	}
+	return ""
+}
+
+func (x *OpenResponse) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *OpenResponse) GetCapabilities() uint64 {
+	if x != nil {
+		return x.Capabilities
+	}
+	return 0
+}
+
+var File_proto_snrelay_v1alpha1_snrelay_proto protoreflect.FileDescriptor
+
+const file_proto_snrelay_v1alpha1_snrelay_proto_rawDesc = "" +
+	"\n" +
+	"$proto/snrelay/v1alpha1/snrelay.proto\x12\asnrelay\"\x9f\x02\n" +
+	"\vOpenRequest\x12*\n" +
+	"\anetwork\x18\x01 \x01(\x0e2\x10.snrelay.NetworkR\anetwork\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12K\n" +
+	"\rtrace_context\x18\x03 \x03(\v2&.snrelay.OpenRequest.TraceContextEntryR\ftraceContext\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\rR\aversion\x12\"\n" +
+	"\fcapabilities\x18\x05 \x01(\x04R\fcapabilities\x1a?\n" +
+	"\x11TraceContextEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xae\x01\n" +
+	"\fOpenResponse\x12\x14\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error\x12#\n" +
+	"\rlocal_address\x18\x02 \x01(\tR\flocalAddress\x12%\n" +
+	"\x0eremote_address\x18\x03 \x01(\tR\rremoteAddress\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\rR\aversion\x12\"\n" +
+	"\fcapabilities\x18\x05 \x01(\x04R\fcapabilities*\x1b\n" +
+	"\aNetwork\x12\a\n" +
+	"\x03TCP\x10\x00\x12\a\n" +
+	"\x03UDP\x10\x01B\x8e\x01\n" +
+	"\vcom.snrelayB\fSnrelayProtoP\x01Z5pkg.gfire.dev/supernet/proto/snrelay/v1alpha1;snrelay\xa2\x02\x03SXX\xaa\x02\aSnrelay\xca\x02\aSnrelay\xe2\x02\x13Snrelay\\GPBMetadata\xea\x02\aSnrelayb\x06proto3"
+
+var (
+	file_proto_snrelay_v1alpha1_snrelay_proto_rawDescOnce sync.Once
+	file_proto_snrelay_v1alpha1_snrelay_proto_rawDescData []byte
+)
+
+func file_proto_snrelay_v1alpha1_snrelay_proto_rawDescGZIP() []byte {
+	file_proto_snrelay_v1alpha1_snrelay_proto_rawDescOnce.Do(func() {
+		file_proto_snrelay_v1alpha1_snrelay_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_snrelay_v1alpha1_snrelay_proto_rawDesc), len(file_proto_snrelay_v1alpha1_snrelay_proto_rawDesc)))
+	})
+	return file_proto_snrelay_v1alpha1_snrelay_proto_rawDescData
+}
+
+var file_proto_snrelay_v1alpha1_snrelay_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_snrelay_v1alpha1_snrelay_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_proto_snrelay_v1alpha1_snrelay_proto_goTypes = []any{
+	(Network)(0),         // 0: snrelay.Network
+	(*OpenRequest)(nil),  // 1: snrelay.OpenRequest
+	(*OpenResponse)(nil), // 2: snrelay.OpenResponse
+	nil,                  // 3: snrelay.OpenRequest.TraceContextEntry
+}
+var file_proto_snrelay_v1alpha1_snrelay_proto_depIdxs = []int32{
+	0, // 0: snrelay.OpenRequest.network:type_name -> snrelay.Network
+	3, // 1: snrelay.OpenRequest.trace_context:type_name -> snrelay.OpenRequest.TraceContextEntry
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_proto_snrelay_v1alpha1_snrelay_proto_init() }
+func file_proto_snrelay_v1alpha1_snrelay_proto_init() {
+	if File_proto_snrelay_v1alpha1_snrelay_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_snrelay_v1alpha1_snrelay_proto_rawDesc), len(file_proto_snrelay_v1alpha1_snrelay_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_snrelay_v1alpha1_snrelay_proto_goTypes,
+		DependencyIndexes: file_proto_snrelay_v1alpha1_snrelay_proto_depIdxs,
+		EnumInfos:         file_proto_snrelay_v1alpha1_snrelay_proto_enumTypes,
+		MessageInfos:      file_proto_snrelay_v1alpha1_snrelay_proto_msgTypes,
+	}.Build()
+	File_proto_snrelay_v1alpha1_snrelay_proto = out.File
+	file_proto_snrelay_v1alpha1_snrelay_proto_goTypes = nil
+	file_proto_snrelay_v1alpha1_snrelay_proto_depIdxs = nil
+}
@@ -0,0 +1,1020 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: proto/snrendezvous/v1alpha1/snrendezvous.proto
+
+package snrendezvous
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// SignalKind is the type of a signaling message.
+type SignalKind int32
+
+const (
+	SignalKind_OFFER     SignalKind = 0 // Connection offer, e.g. a WebRTC session description
+	SignalKind_ANSWER    SignalKind = 1 // Answer to an offer of the same session
+	SignalKind_CANDIDATE SignalKind = 2 // Additional connectivity candidate of a session
+	SignalKind_BYE       SignalKind = 3 // Abandons a session
+)
+
+// Enum value maps for SignalKind.
+var (
+	SignalKind_name = map[int32]string{
+		0: "OFFER",
+		1: "ANSWER",
+		2: "CANDIDATE",
+		3: "BYE",
+	}
+	SignalKind_value = map[string]int32{
+		"OFFER":     0,
+		"ANSWER":    1,
+		"CANDIDATE": 2,
+		"BYE":       3,
+	}
+)
+
+func (x SignalKind) Enum() *SignalKind {
+	p := new(SignalKind)
+	*p = x
+	return p
+}
+
+func (x SignalKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SignalKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_snrendezvous_v1alpha1_snrendezvous_proto_enumTypes[0].Descriptor()
+}
+
+func (SignalKind) Type() protoreflect.EnumType {
+	return &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_enumTypes[0]
+}
+
+func (x SignalKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SignalKind.Descriptor instead.
+func (SignalKind) EnumDescriptor() ([]byte, []int) {
+	return file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescGZIP(), []int{0}
+}
+
+// Challenge is the first message of the server and must be signed by the client to register.
+type Challenge struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nonce         []byte                 `protobuf:"bytes,1,opt,name=nonce,proto3" json:"nonce,omitempty"` // Random challenge
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Challenge) Reset() {
+	*x = Challenge{}
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Challenge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Challenge) ProtoMessage() {}
+
+func (x *Challenge) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Challenge.ProtoReflect.Descriptor instead.
+func (*Challenge) Descriptor() ([]byte, []int) {
+	return file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Challenge) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+// Register announces the client in namespaces and proves ownership of its identity.
+type Register struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Record        []byte                 `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`         // Signed peer record of the client
+	Signature     []byte                 `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`   // Ed25519 signature over the signature prefix and the challenge nonce
+	Namespaces    []string               `protobuf:"bytes,3,rep,name=namespaces,proto3" json:"namespaces,omitempty"` // Namespaces the client is discoverable in
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Register) Reset() {
+	*x = Register{}
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Register) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Register) ProtoMessage() {}
+
+func (x *Register) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Register.ProtoReflect.Descriptor instead.
+func (*Register) Descriptor() ([]byte, []int) {
+	return file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Register) GetRecord() []byte {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *Register) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *Register) GetNamespaces() []string {
+	if x != nil {
+		return x.Namespaces
+	}
+	return nil
+}
+
+// Discover asks for peers registered in a namespace.
+type Discover struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Limit         uint32                 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"` // Maximum number of peers to return, capped by the server
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Discover) Reset() {
+	*x = Discover{}
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Discover) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Discover) ProtoMessage() {}
+
+func (x *Discover) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Discover.ProtoReflect.Descriptor instead.
+func (*Discover) Descriptor() ([]byte, []int) {
+	return file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Discover) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *Discover) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// Peers answers Discover.
+type Peers struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Records       [][]byte               `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"` // Signed peer records
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Peers) Reset() {
+	*x = Peers{}
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Peers) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Peers) ProtoMessage() {}
+
+func (x *Peers) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Peers.ProtoReflect.Descriptor instead.
+func (*Peers) Descriptor() ([]byte, []int) {
+	return file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Peers) GetRecords() [][]byte {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+// Watch subscribes to presence changes in a namespace.
+type Watch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Watch) Reset() {
+	*x = Watch{}
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Watch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Watch) ProtoMessage() {}
+
+func (x *Watch) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Watch.ProtoReflect.Descriptor instead.
+func (*Watch) Descriptor() ([]byte, []int) {
+	return file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Watch) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+// Presence reports a peer joining or leaving a watched namespace.
+type Presence struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Record        []byte                 `protobuf:"bytes,2,opt,name=record,proto3" json:"record,omitempty"`  // Signed peer record of the peer
+	Online        bool                   `protobuf:"varint,3,opt,name=online,proto3" json:"online,omitempty"` // Whether the peer joined or left
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Presence) Reset() {
+	*x = Presence{}
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Presence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Presence) ProtoMessage() {}
+
+func (x *Presence) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Presence.ProtoReflect.Descriptor instead.
+func (*Presence) Descriptor() ([]byte, []int) {
+	return file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Presence) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *Presence) GetRecord() []byte {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *Presence) GetOnline() bool {
+	if x != nil {
+		return x.Online
+	}
+	return false
+}
+
+// Signal is relayed by the server between two registered peers.
+type Signal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Peer          []byte                 `protobuf:"bytes,1,opt,name=peer,proto3" json:"peer,omitempty"`        // 256-bit peer id of the recipient when sent, of the sender when received
+	Session       uint64                 `protobuf:"varint,2,opt,name=session,proto3" json:"session,omitempty"` // Session chosen by the offering peer, groups the signals of one connection attempt
+	Kind          SignalKind             `protobuf:"varint,3,opt,name=kind,proto3,enum=snrendezvous.SignalKind" json:"kind,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"` // Opaque signaling data
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Signal) Reset() {
+	*x = Signal{}
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Signal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Signal) ProtoMessage() {}
+
+func (x *Signal) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Signal.ProtoReflect.Descriptor instead.
+func (*Signal) Descriptor() ([]byte, []int) {
+	return file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Signal) GetPeer() []byte {
+	if x != nil {
+		return x.Peer
+	}
+	return nil
+}
+
+func (x *Signal) GetSession() uint64 {
+	if x != nil {
+		return x.Session
+	}
+	return 0
+}
+
+func (x *Signal) GetKind() SignalKind {
+	if x != nil {
+		return x.Kind
+	}
+	return SignalKind_OFFER
+}
+
+func (x *Signal) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+// Ok is an empty successful response.
+type Ok struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ok) Reset() {
+	*x = Ok{}
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ok) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ok) ProtoMessage() {}
+
+func (x *Ok) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ok.ProtoReflect.Descriptor instead.
+func (*Ok) Descriptor() ([]byte, []int) {
+	return file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescGZIP(), []int{7}
+}
+
+// Error is a failed response.
+type Error struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Error) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// ClientMessage frames every message sent by clients.
+type ClientMessage struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	RequestId uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"` // Echoed in the response
+	// Types that are valid to be assigned to Body:
+	//
+	//	*ClientMessage_Register
+	//	*ClientMessage_Discover
+	//	*ClientMessage_Watch
+	//	*ClientMessage_Signal
+	Body          isClientMessage_Body `protobuf_oneof:"body"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClientMessage) Reset() {
+	*x = ClientMessage{}
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClientMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientMessage) ProtoMessage() {}
+
+func (x *ClientMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientMessage.ProtoReflect.Descriptor instead.
+func (*ClientMessage) Descriptor() ([]byte, []int) {
+	return file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ClientMessage) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *ClientMessage) GetBody() isClientMessage_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetRegister() *Register {
+	if x != nil {
+		if x, ok := x.Body.(*ClientMessage_Register); ok {
+			return x.Register
+		}
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetDiscover() *Discover {
+	if x != nil {
+		if x, ok := x.Body.(*ClientMessage_Discover); ok {
+			return x.Discover
+		}
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetWatch() *Watch {
+	if x != nil {
+		if x, ok := x.Body.(*ClientMessage_Watch); ok {
+			return x.Watch
+		}
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetSignal() *Signal {
+	if x != nil {
+		if x, ok := x.Body.(*ClientMessage_Signal); ok {
+			return x.Signal
+		}
+	}
+	return nil
+}
+
+type isClientMessage_Body interface {
+	isClientMessage_Body()
+}
+
+type ClientMessage_Register struct {
+	Register *Register `protobuf:"bytes,10,opt,name=register,proto3,oneof"`
+}
+
+type ClientMessage_Discover struct {
+	Discover *Discover `protobuf:"bytes,11,opt,name=discover,proto3,oneof"`
+}
+
+type ClientMessage_Watch struct {
+	Watch *Watch `protobuf:"bytes,12,opt,name=watch,proto3,oneof"`
+}
+
+type ClientMessage_Signal struct {
+	Signal *Signal `protobuf:"bytes,13,opt,name=signal,proto3,oneof"`
+}
+
+func (*ClientMessage_Register) isClientMessage_Body() {}
+
+func (*ClientMessage_Discover) isClientMessage_Body() {}
+
+func (*ClientMessage_Watch) isClientMessage_Body() {}
+
+func (*ClientMessage_Signal) isClientMessage_Body() {}
+
+// ServerMessage frames every message sent by the server. Responses carry the request_id of their request;
+// pushed messages have request_id 0.
+type ServerMessage struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	RequestId uint64                 `protobuf:"varint,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// Types that are valid to be assigned to Body:
+	//
+	//	*ServerMessage_Challenge
+	//	*ServerMessage_Ok
+	//	*ServerMessage_Error
+	//	*ServerMessage_Peers
+	//	*ServerMessage_Presence
+	//	*ServerMessage_Signal
+	Body          isServerMessage_Body `protobuf_oneof:"body"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerMessage) Reset() {
+	*x = ServerMessage{}
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerMessage) ProtoMessage() {}
+
+func (x *ServerMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerMessage.ProtoReflect.Descriptor instead.
+func (*ServerMessage) Descriptor() ([]byte, []int) {
+	return file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ServerMessage) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *ServerMessage) GetBody() isServerMessage_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetChallenge() *Challenge {
+	if x != nil {
+		if x, ok := x.Body.(*ServerMessage_Challenge); ok {
+			return x.Challenge
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetOk() *Ok {
+	if x != nil {
+		if x, ok := x.Body.(*ServerMessage_Ok); ok {
+			return x.Ok
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetError() *Error {
+	if x != nil {
+		if x, ok := x.Body.(*ServerMessage_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetPeers() *Peers {
+	if x != nil {
+		if x, ok := x.Body.(*ServerMessage_Peers); ok {
+			return x.Peers
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetPresence() *Presence {
+	if x != nil {
+		if x, ok := x.Body.(*ServerMessage_Presence); ok {
+			return x.Presence
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetSignal() *Signal {
+	if x != nil {
+		if x, ok := x.Body.(*ServerMessage_Signal); ok {
+			return x.Signal
+		}
+	}
+	return nil
+}
+
+type isServerMessage_Body interface {
+	isServerMessage_Body()
+}
+
+type ServerMessage_Challenge struct {
+	Challenge *Challenge `protobuf:"bytes,10,opt,name=challenge,proto3,oneof"`
+}
+
+type ServerMessage_Ok struct {
+	Ok *Ok `protobuf:"bytes,11,opt,name=ok,proto3,oneof"`
+}
+
+type ServerMessage_Error struct {
+	Error *Error `protobuf:"bytes,12,opt,name=error,proto3,oneof"`
+}
+
+type ServerMessage_Peers struct {
+	Peers *Peers `protobuf:"bytes,13,opt,name=peers,proto3,oneof"`
+}
+
+type ServerMessage_Presence struct {
+	Presence *Presence `protobuf:"bytes,14,opt,name=presence,proto3,oneof"`
+}
+
+type ServerMessage_Signal struct {
+	Signal *Signal `protobuf:"bytes,15,opt,name=signal,proto3,oneof"`
+}
+
+func (*ServerMessage_Challenge) isServerMessage_Body() {}
+
+func (*ServerMessage_Ok) isServerMessage_Body() {}
+
+func (*ServerMessage_Error) isServerMessage_Body() {}
+
+func (*ServerMessage_Peers) isServerMessage_Body() {}
+
+func (*ServerMessage_Presence) isServerMessage_Body() {}
+
+func (*ServerMessage_Signal) isServerMessage_Body() {}
+
+// Forward carries a server message between rendezvous servers sharing a backplane.
+type Forward struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Instance      []byte                 `protobuf:"bytes,1,opt,name=instance,proto3" json:"instance,omitempty"` // Random id of the sending server
+	Message       *ServerMessage         `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`   // Message for the receiving server's clients, absent when the peer registered elsewhere
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Forward) Reset() {
+	*x = Forward{}
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Forward) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Forward) ProtoMessage() {}
+
+func (x *Forward) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Forward.ProtoReflect.Descriptor instead.
+func (*Forward) Descriptor() ([]byte, []int) {
+	return file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Forward) GetInstance() []byte {
+	if x != nil {
+		return x.Instance
+	}
+	return nil
+}
+
+func (x *Forward) GetMessage() *ServerMessage {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+var File_proto_snrendezvous_v1alpha1_snrendezvous_proto protoreflect.FileDescriptor
+
+const file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDesc = "" +
+	"\n" +
+	".proto/snrendezvous/v1alpha1/snrendezvous.proto\x12\fsnrendezvous\"!\n" +
+	"\tChallenge\x12\x14\n" +
+	"\x05nonce\x18\x01 \x01(\fR\x05nonce\"`\n" +
+	"\bRegister\x12\x16\n" +
+	"\x06record\x18\x01 \x01(\fR\x06record\x12\x1c\n" +
+	"\tsignature\x18\x02 \x01(\fR\tsignature\x12\x1e\n" +
+	"\n" +
+	"namespaces\x18\x03 \x03(\tR\n" +
+	"namespaces\">\n" +
+	"\bDiscover\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\rR\x05limit\"!\n" +
+	"\x05Peers\x12\x18\n" +
+	"\arecords\x18\x01 \x03(\fR\arecords\"%\n" +
+	"\x05Watch\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\"X\n" +
+	"\bPresence\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x16\n" +
+	"\x06record\x18\x02 \x01(\fR\x06record\x12\x16\n" +
+	"\x06online\x18\x03 \x01(\bR\x06online\"~\n" +
+	"\x06Signal\x12\x12\n" +
+	"\x04peer\x18\x01 \x01(\fR\x04peer\x12\x18\n" +
+	"\asession\x18\x02 \x01(\x04R\asession\x12,\n" +
+	"\x04kind\x18\x03 \x01(\x0e2\x18.snrendezvous.SignalKindR\x04kind\x12\x18\n" +
+	"\apayload\x18\x04 \x01(\fR\apayload\"\x04\n" +
+	"\x02Ok\"!\n" +
+	"\x05Error\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"\xff\x01\n" +
+	"\rClientMessage\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x124\n" +
+	"\bregister\x18\n" +
+	" \x01(\v2\x16.snrendezvous.RegisterH\x00R\bregister\x124\n" +
+	"\bdiscover\x18\v \x01(\v2\x16.snrendezvous.DiscoverH\x00R\bdiscover\x12+\n" +
+	"\x05watch\x18\f \x01(\v2\x13.snrendezvous.WatchH\x00R\x05watch\x12.\n" +
+	"\x06signal\x18\r \x01(\v2\x14.snrendezvous.SignalH\x00R\x06signalB\x06\n" +
+	"\x04body\"\xd3\x02\n" +
+	"\rServerMessage\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\x04R\trequestId\x127\n" +
+	"\tchallenge\x18\n" +
+	" \x01(\v2\x17.snrendezvous.ChallengeH\x00R\tchallenge\x12\"\n" +
+	"\x02ok\x18\v \x01(\v2\x10.snrendezvous.OkH\x00R\x02ok\x12+\n" +
+	"\x05error\x18\f \x01(\v2\x13.snrendezvous.ErrorH\x00R\x05error\x12+\n" +
+	"\x05peers\x18\r \x01(\v2\x13.snrendezvous.PeersH\x00R\x05peers\x124\n" +
+	"\bpresence\x18\x0e \x01(\v2\x16.snrendezvous.PresenceH\x00R\bpresence\x12.\n" +
+	"\x06signal\x18\x0f \x01(\v2\x14.snrendezvous.SignalH\x00R\x06signalB\x06\n" +
+	"\x04body\"\\\n" +
+	"\aForward\x12\x1a\n" +
+	"\binstance\x18\x01 \x01(\fR\binstance\x125\n" +
+	"\amessage\x18\x02 \x01(\v2\x1b.snrendezvous.ServerMessageR\amessage*;\n" +
+	"\n" +
+	"SignalKind\x12\t\n" +
+	"\x05OFFER\x10\x00\x12\n" +
+	"\n" +
+	"\x06ANSWER\x10\x01\x12\r\n" +
+	"\tCANDIDATE\x10\x02\x12\a\n" +
+	"\x03BYE\x10\x03B\xb6\x01\n" +
+	"\x10com.snrendezvousB\x11SnrendezvousProtoP\x01Z?pkg.gfire.dev/supernet/proto/snrendezvous/v1alpha1;snrendezvous\xa2\x02\x03SXX\xaa\x02\fSnrendezvous\xca\x02\fSnrendezvous\xe2\x02\x18Snrendezvous\\GPBMetadata\xea\x02\fSnrendezvousb\x06proto3"
+
+var (
+	file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescOnce sync.Once
+	file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescData []byte
+)
+
+func file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescGZIP() []byte {
+	file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescOnce.Do(func() {
+		file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDesc), len(file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDesc)))
+	})
+	return file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDescData
+}
+
+var file_proto_snrendezvous_v1alpha1_snrendezvous_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_proto_snrendezvous_v1alpha1_snrendezvous_proto_goTypes = []any{
+	(SignalKind)(0),       // 0: snrendezvous.SignalKind
+	(*Challenge)(nil),     // 1: snrendezvous.Challenge
+	(*Register)(nil),      // 2: snrendezvous.Register
+	(*Discover)(nil),      // 3: snrendezvous.Discover
+	(*Peers)(nil),         // 4: snrendezvous.Peers
+	(*Watch)(nil),         // 5: snrendezvous.Watch
+	(*Presence)(nil),      // 6: snrendezvous.Presence
+	(*Signal)(nil),        // 7: snrendezvous.Signal
+	(*Ok)(nil),            // 8: snrendezvous.Ok
+	(*Error)(nil),         // 9: snrendezvous.Error
+	(*ClientMessage)(nil), // 10: snrendezvous.ClientMessage
+	(*ServerMessage)(nil), // 11: snrendezvous.ServerMessage
+	(*Forward)(nil),       // 12: snrendezvous.Forward
+}
+var file_proto_snrendezvous_v1alpha1_snrendezvous_proto_depIdxs = []int32{
+	0,  // 0: snrendezvous.Signal.kind:type_name -> snrendezvous.SignalKind
+	2,  // 1: snrendezvous.ClientMessage.register:type_name -> snrendezvous.Register
+	3,  // 2: snrendezvous.ClientMessage.discover:type_name -> snrendezvous.Discover
+	5,  // 3: snrendezvous.ClientMessage.watch:type_name -> snrendezvous.Watch
+	7,  // 4: snrendezvous.ClientMessage.signal:type_name -> snrendezvous.Signal
+	1,  // 5: snrendezvous.ServerMessage.challenge:type_name -> snrendezvous.Challenge
+	8,  // 6: snrendezvous.ServerMessage.ok:type_name -> snrendezvous.Ok
+	9,  // 7: snrendezvous.ServerMessage.error:type_name -> snrendezvous.Error
+	4,  // 8: snrendezvous.ServerMessage.peers:type_name -> snrendezvous.Peers
+	6,  // 9: snrendezvous.ServerMessage.presence:type_name -> snrendezvous.Presence
+	7,  // 10: snrendezvous.ServerMessage.signal:type_name -> snrendezvous.Signal
+	11, // 11: snrendezvous.Forward.message:type_name -> snrendezvous.ServerMessage
+	12, // [12:12] is the sub-list for method output_type
+	12, // [12:12] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_proto_snrendezvous_v1alpha1_snrendezvous_proto_init() }
+func file_proto_snrendezvous_v1alpha1_snrendezvous_proto_init() {
+	if File_proto_snrendezvous_v1alpha1_snrendezvous_proto != nil {
+		return
+	}
+	file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[9].OneofWrappers = []any{
+		(*ClientMessage_Register)(nil),
+		(*ClientMessage_Discover)(nil),
+		(*ClientMessage_Watch)(nil),
+		(*ClientMessage_Signal)(nil),
+	}
+	file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes[10].OneofWrappers = []any{
+		(*ServerMessage_Challenge)(nil),
+		(*ServerMessage_Ok)(nil),
+		(*ServerMessage_Error)(nil),
+		(*ServerMessage_Peers)(nil),
+		(*ServerMessage_Presence)(nil),
+		(*ServerMessage_Signal)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDesc), len(file_proto_snrendezvous_v1alpha1_snrendezvous_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_snrendezvous_v1alpha1_snrendezvous_proto_goTypes,
+		DependencyIndexes: file_proto_snrendezvous_v1alpha1_snrendezvous_proto_depIdxs,
+		EnumInfos:         file_proto_snrendezvous_v1alpha1_snrendezvous_proto_enumTypes,
+		MessageInfos:      file_proto_snrendezvous_v1alpha1_snrendezvous_proto_msgTypes,
+	}.Build()
+	File_proto_snrendezvous_v1alpha1_snrendezvous_proto = out.File
+	file_proto_snrendezvous_v1alpha1_snrendezvous_proto_goTypes = nil
+	file_proto_snrendezvous_v1alpha1_snrendezvous_proto_depIdxs = nil
+}
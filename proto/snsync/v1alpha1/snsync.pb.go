@@ -0,0 +1,593 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: proto/snsync/v1alpha1/snsync.proto
+
+package snsync
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// GetManifest asks for the manifest of the served folder. It is answered by Entry frames and a ManifestEnd.
+type GetManifest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetManifest) Reset() {
+	*x = GetManifest{}
+	mi := &file_proto_snsync_v1alpha1_snsync_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetManifest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetManifest) ProtoMessage() {}
+
+func (x *GetManifest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snsync_v1alpha1_snsync_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetManifest.ProtoReflect.Descriptor instead.
+func (*GetManifest) Descriptor() ([]byte, []int) {
+	return file_proto_snsync_v1alpha1_snsync_proto_rawDescGZIP(), []int{0}
+}
+
+// Entry describes a file of the manifest. The chunk hashes of large files are split across several entries
+// of the same path, in order.
+type Entry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`                                             // Slash-separated path relative to the folder root
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`                                            // File size in bytes
+	ModTimeUnixMs int64                  `protobuf:"varint,3,opt,name=mod_time_unix_ms,json=modTimeUnixMs,proto3" json:"mod_time_unix_ms,omitempty"` // Modification time
+	FirstChunk    uint32                 `protobuf:"varint,4,opt,name=first_chunk,json=firstChunk,proto3" json:"first_chunk,omitempty"`              // Index of the first chunk hash in this entry
+	Chunks        [][]byte               `protobuf:"bytes,5,rep,name=chunks,proto3" json:"chunks,omitempty"`                                         // SHA-256 of the chunks, in order
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Entry) Reset() {
+	*x = Entry{}
+	mi := &file_proto_snsync_v1alpha1_snsync_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Entry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Entry) ProtoMessage() {}
+
+func (x *Entry) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snsync_v1alpha1_snsync_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Entry.ProtoReflect.Descriptor instead.
+func (*Entry) Descriptor() ([]byte, []int) {
+	return file_proto_snsync_v1alpha1_snsync_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Entry) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Entry) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Entry) GetModTimeUnixMs() int64 {
+	if x != nil {
+		return x.ModTimeUnixMs
+	}
+	return 0
+}
+
+func (x *Entry) GetFirstChunk() uint32 {
+	if x != nil {
+		return x.FirstChunk
+	}
+	return 0
+}
+
+func (x *Entry) GetChunks() [][]byte {
+	if x != nil {
+		return x.Chunks
+	}
+	return nil
+}
+
+// ManifestEnd completes a manifest.
+type ManifestEnd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChunkSize     uint32                 `protobuf:"varint,1,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"` // Size of every chunk except the last of each file
+	Files         uint32                 `protobuf:"varint,2,opt,name=files,proto3" json:"files,omitempty"`                          // Number of files in the manifest
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ManifestEnd) Reset() {
+	*x = ManifestEnd{}
+	mi := &file_proto_snsync_v1alpha1_snsync_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ManifestEnd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ManifestEnd) ProtoMessage() {}
+
+func (x *ManifestEnd) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snsync_v1alpha1_snsync_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ManifestEnd.ProtoReflect.Descriptor instead.
+func (*ManifestEnd) Descriptor() ([]byte, []int) {
+	return file_proto_snsync_v1alpha1_snsync_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ManifestEnd) GetChunkSize() uint32 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
+}
+
+func (x *ManifestEnd) GetFiles() uint32 {
+	if x != nil {
+		return x.Files
+	}
+	return 0
+}
+
+// GetChunks asks for chunks by hash. Each is answered by a Chunk frame, in order.
+type GetChunks struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hashes        [][]byte               `protobuf:"bytes,1,rep,name=hashes,proto3" json:"hashes,omitempty"` // SHA-256 of the chunks
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetChunks) Reset() {
+	*x = GetChunks{}
+	mi := &file_proto_snsync_v1alpha1_snsync_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetChunks) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetChunks) ProtoMessage() {}
+
+func (x *GetChunks) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snsync_v1alpha1_snsync_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetChunks.ProtoReflect.Descriptor instead.
+func (*GetChunks) Descriptor() ([]byte, []int) {
+	return file_proto_snsync_v1alpha1_snsync_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetChunks) GetHashes() [][]byte {
+	if x != nil {
+		return x.Hashes
+	}
+	return nil
+}
+
+// Chunk carries the data of a chunk.
+type Chunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          []byte                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`        // SHA-256 of the data
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`        // Chunk data
+	Missing       bool                   `protobuf:"varint,3,opt,name=missing,proto3" json:"missing,omitempty"` // Whether the chunk is no longer available, e.g. because its file changed
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chunk) Reset() {
+	*x = Chunk{}
+	mi := &file_proto_snsync_v1alpha1_snsync_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chunk) ProtoMessage() {}
+
+func (x *Chunk) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snsync_v1alpha1_snsync_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return file_proto_snsync_v1alpha1_snsync_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Chunk) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+func (x *Chunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Chunk) GetMissing() bool {
+	if x != nil {
+		return x.Missing
+	}
+	return false
+}
+
+// Error reports a failed request.
+type Error struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_proto_snsync_v1alpha1_snsync_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snsync_v1alpha1_snsync_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_proto_snsync_v1alpha1_snsync_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Error) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// Frame is the envelope of all sync messages.
+type Frame struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Body:
+	//
+	//	*Frame_GetManifest
+	//	*Frame_Entry
+	//	*Frame_ManifestEnd
+	//	*Frame_GetChunks
+	//	*Frame_Chunk
+	//	*Frame_Error
+	Body          isFrame_Body `protobuf_oneof:"body"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Frame) Reset() {
+	*x = Frame{}
+	mi := &file_proto_snsync_v1alpha1_snsync_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Frame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Frame) ProtoMessage() {}
+
+func (x *Frame) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_snsync_v1alpha1_snsync_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Frame.ProtoReflect.Descriptor instead.
+func (*Frame) Descriptor() ([]byte, []int) {
+	return file_proto_snsync_v1alpha1_snsync_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Frame) GetBody() isFrame_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *Frame) GetGetManifest() *GetManifest {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_GetManifest); ok {
+			return x.GetManifest
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetEntry() *Entry {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Entry); ok {
+			return x.Entry
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetManifestEnd() *ManifestEnd {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_ManifestEnd); ok {
+			return x.ManifestEnd
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetGetChunks() *GetChunks {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_GetChunks); ok {
+			return x.GetChunks
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetChunk() *Chunk {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetError() *Error {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isFrame_Body interface {
+	isFrame_Body()
+}
+
+type Frame_GetManifest struct {
+	GetManifest *GetManifest `protobuf:"bytes,1,opt,name=get_manifest,json=getManifest,proto3,oneof"`
+}
+
+type Frame_Entry struct {
+	Entry *Entry `protobuf:"bytes,2,opt,name=entry,proto3,oneof"`
+}
+
+type Frame_ManifestEnd struct {
+	ManifestEnd *ManifestEnd `protobuf:"bytes,3,opt,name=manifest_end,json=manifestEnd,proto3,oneof"`
+}
+
+type Frame_GetChunks struct {
+	GetChunks *GetChunks `protobuf:"bytes,4,opt,name=get_chunks,json=getChunks,proto3,oneof"`
+}
+
+type Frame_Chunk struct {
+	Chunk *Chunk `protobuf:"bytes,5,opt,name=chunk,proto3,oneof"`
+}
+
+type Frame_Error struct {
+	Error *Error `protobuf:"bytes,6,opt,name=error,proto3,oneof"`
+}
+
+func (*Frame_GetManifest) isFrame_Body() {}
+
+func (*Frame_Entry) isFrame_Body() {}
+
+func (*Frame_ManifestEnd) isFrame_Body() {}
+
+func (*Frame_GetChunks) isFrame_Body() {}
+
+func (*Frame_Chunk) isFrame_Body() {}
+
+func (*Frame_Error) isFrame_Body() {}
+
+var File_proto_snsync_v1alpha1_snsync_proto protoreflect.FileDescriptor
+
+const file_proto_snsync_v1alpha1_snsync_proto_rawDesc = "" +
+	"\n" +
+	"\"proto/snsync/v1alpha1/snsync.proto\x12\x06snsync\"\r\n" +
+	"\vGetManifest\"\x91\x01\n" +
+	"\x05Entry\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12'\n" +
+	"\x10mod_time_unix_ms\x18\x03 \x01(\x03R\rmodTimeUnixMs\x12\x1f\n" +
+	"\vfirst_chunk\x18\x04 \x01(\rR\n" +
+	"firstChunk\x12\x16\n" +
+	"\x06chunks\x18\x05 \x03(\fR\x06chunks\"B\n" +
+	"\vManifestEnd\x12\x1d\n" +
+	"\n" +
+	"chunk_size\x18\x01 \x01(\rR\tchunkSize\x12\x14\n" +
+	"\x05files\x18\x02 \x01(\rR\x05files\"#\n" +
+	"\tGetChunks\x12\x16\n" +
+	"\x06hashes\x18\x01 \x03(\fR\x06hashes\"I\n" +
+	"\x05Chunk\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\fR\x04hash\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\x12\x18\n" +
+	"\amissing\x18\x03 \x01(\bR\amissing\"!\n" +
+	"\x05Error\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"\xac\x02\n" +
+	"\x05Frame\x128\n" +
+	"\fget_manifest\x18\x01 \x01(\v2\x13.snsync.GetManifestH\x00R\vgetManifest\x12%\n" +
+	"\x05entry\x18\x02 \x01(\v2\r.snsync.EntryH\x00R\x05entry\x128\n" +
+	"\fmanifest_end\x18\x03 \x01(\v2\x13.snsync.ManifestEndH\x00R\vmanifestEnd\x122\n" +
+	"\n" +
+	"get_chunks\x18\x04 \x01(\v2\x11.snsync.GetChunksH\x00R\tgetChunks\x12%\n" +
+	"\x05chunk\x18\x05 \x01(\v2\r.snsync.ChunkH\x00R\x05chunk\x12%\n" +
+	"\x05error\x18\x06 \x01(\v2\r.snsync.ErrorH\x00R\x05errorB\x06\n" +
+	"\x04bodyB\x86\x01\n" +
+	"\n" +
+	"com.snsyncB\vSnsyncProtoP\x01Z3pkg.gfire.dev/supernet/proto/snsync/v1alpha1;snsync\xa2\x02\x03SXX\xaa\x02\x06Snsync\xca\x02\x06Snsync\xe2\x02\x12Snsync\\GPBMetadata\xea\x02\x06Snsyncb\x06proto3"
+
+var (
+	file_proto_snsync_v1alpha1_snsync_proto_rawDescOnce sync.Once
+	file_proto_snsync_v1alpha1_snsync_proto_rawDescData []byte
+)
+
+func file_proto_snsync_v1alpha1_snsync_proto_rawDescGZIP() []byte {
+	file_proto_snsync_v1alpha1_snsync_proto_rawDescOnce.Do(func() {
+		file_proto_snsync_v1alpha1_snsync_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_snsync_v1alpha1_snsync_proto_rawDesc), len(file_proto_snsync_v1alpha1_snsync_proto_rawDesc)))
+	})
+	return file_proto_snsync_v1alpha1_snsync_proto_rawDescData
+}
+
+var file_proto_snsync_v1alpha1_snsync_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_proto_snsync_v1alpha1_snsync_proto_goTypes = []any{
+	(*GetManifest)(nil), // 0: snsync.GetManifest
+	(*Entry)(nil),       // 1: snsync.Entry
+	(*ManifestEnd)(nil), // 2: snsync.ManifestEnd
+	(*GetChunks)(nil),   // 3: snsync.GetChunks
+	(*Chunk)(nil),       // 4: snsync.Chunk
+	(*Error)(nil),       // 5: snsync.Error
+	(*Frame)(nil),       // 6: snsync.Frame
+}
+var file_proto_snsync_v1alpha1_snsync_proto_depIdxs = []int32{
+	0, // 0: snsync.Frame.get_manifest:type_name -> snsync.GetManifest
+	1, // 1: snsync.Frame.entry:type_name -> snsync.Entry
+	2, // 2: snsync.Frame.manifest_end:type_name -> snsync.ManifestEnd
+	3, // 3: snsync.Frame.get_chunks:type_name -> snsync.GetChunks
+	4, // 4: snsync.Frame.chunk:type_name -> snsync.Chunk
+	5, // 5: snsync.Frame.error:type_name -> snsync.Error
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_proto_snsync_v1alpha1_snsync_proto_init() }
+func file_proto_snsync_v1alpha1_snsync_proto_init() {
+	if File_proto_snsync_v1alpha1_snsync_proto != nil {
+		return
+	}
+	file_proto_snsync_v1alpha1_snsync_proto_msgTypes[6].OneofWrappers = []any{
+		(*Frame_GetManifest)(nil),
+		(*Frame_Entry)(nil),
+		(*Frame_ManifestEnd)(nil),
+		(*Frame_GetChunks)(nil),
+		(*Frame_Chunk)(nil),
+		(*Frame_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_snsync_v1alpha1_snsync_proto_rawDesc), len(file_proto_snsync_v1alpha1_snsync_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_snsync_v1alpha1_snsync_proto_goTypes,
+		DependencyIndexes: file_proto_snsync_v1alpha1_snsync_proto_depIdxs,
+		MessageInfos:      file_proto_snsync_v1alpha1_snsync_proto_msgTypes,
+	}.Build()
+	File_proto_snsync_v1alpha1_snsync_proto = out.File
+	file_proto_snsync_v1alpha1_snsync_proto_goTypes = nil
+	file_proto_snsync_v1alpha1_snsync_proto_depIdxs = nil
+}
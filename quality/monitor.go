@@ -0,0 +1,469 @@
+// Package quality continuously scores network paths from keepalive round trips and throughput samples,
+// publishing events when a path's quality level changes.
+package quality
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrClosed is returned when using a closed monitor
+	ErrClosed = errors.New("quality monitor closed")
+	// ErrDuplicatePath is returned when adding a path under a name that is already monitored
+	ErrDuplicatePath = errors.New("path already monitored")
+)
+
+const (
+	// defaultInterval is the default interval between keepalive probes
+	defaultInterval = 5 * time.Second
+	// defaultTimeout is the default time after which a probe counts as lost
+	defaultTimeout = 3 * time.Second
+	// defaultWindow is the default number of probes statistics are computed over
+	defaultWindow = 20
+	// throughputAlpha is the smoothing factor of the throughput moving average
+	throughputAlpha = 0.3
+)
+
+// Path is a monitored network path, e.g. a transport connection.
+type Path interface {
+	// Ping performs a keepalive round trip. A returned error or an exceeded deadline counts as a lost probe.
+	Ping(ctx context.Context) error
+}
+
+// PingFunc adapts a function to the Path interface.
+type PingFunc func(ctx context.Context) error
+
+// Ping calls f.
+func (f PingFunc) Ping(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Level is a coarse quality rating suitable for a connection indicator.
+type Level int
+
+const (
+	// Unknown means no probe has completed yet.
+	Unknown Level = iota
+	// Bad paths are unusable for interactive traffic (score below 50).
+	Bad
+	// Poor paths have noticeable delay or loss (score 50–59).
+	Poor
+	// Fair paths are usable with some degradation (score 60–69).
+	Fair
+	// Good paths have little impairment (score 70–79).
+	Good
+	// Excellent paths show no noticeable impairment (score 80 and above).
+	Excellent
+)
+
+// String returns the lower case level name.
+func (l Level) String() string {
+	switch l {
+	case Bad:
+		return "bad"
+	case Poor:
+		return "poor"
+	case Fair:
+		return "fair"
+	case Good:
+		return "good"
+	case Excellent:
+		return "excellent"
+	default:
+		return "unknown"
+	}
+}
+
+// Stats summarizes the recent quality of a path.
+type Stats struct {
+	RTT        time.Duration // Mean round trip time of successful probes in the window
+	Jitter     time.Duration // Mean absolute difference between consecutive round trips
+	Loss       float64       // Fraction of lost probes in the window, 0–1
+	Throughput float64       // Smoothed throughput in bits per second, 0 without samples
+	Score      float64       // Quality score 0–100 derived from RTT, jitter and loss
+	Level      Level         // Quality level derived from Score
+	Probes     int           // Number of probes in the window
+	LastSeen   time.Time     // Time of the last successful probe
+}
+
+// Event reports a change of the quality level of a path.
+type Event struct {
+	Path     string // Path name
+	Previous Level  // Level before the change
+	Stats    Stats  // Current statistics
+}
+
+// Config configures a Monitor.
+type Config struct {
+	// Interval between keepalive probes of each path (default 5s).
+	Interval time.Duration
+	// Timeout after which a probe counts as lost (default 3s).
+	Timeout time.Duration
+	// Window is the number of recent probes statistics are computed over (default 20).
+	Window int
+}
+
+// Monitor probes a set of named paths and keeps rolling quality statistics for each.
+type Monitor struct {
+	cfg Config
+
+	mu          sync.Mutex
+	paths       map[string]*pathState
+	subscribers map[chan Event]struct{}
+	closed      bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// pathState holds the probe history of a path.
+type pathState struct {
+	name       string
+	path       Path
+	cancel     context.CancelFunc
+	rtts       []time.Duration // Ring buffer of probe results, negative for lost probes
+	next       int             // Next ring buffer index
+	throughput float64
+	lastSeen   time.Time
+	level      Level
+}
+
+// NewMonitor creates a monitor without paths.
+func NewMonitor(cfg Config) *Monitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultWindow
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Monitor{
+		cfg:         cfg,
+		paths:       make(map[string]*pathState),
+		subscribers: make(map[chan Event]struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Add starts probing path under name. A nil path is tracked without probing; its statistics come
+// only from ObserveRTT, ObserveLoss and ObserveThroughput.
+func (m *Monitor) Add(name string, path Path) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+	if _, ok := m.paths[name]; ok {
+		return ErrDuplicatePath
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	ps := &pathState{name: name, path: path, cancel: cancel}
+	m.paths[name] = ps
+
+	if path != nil {
+		m.wg.Add(1)
+		go m.probeLoop(ctx, ps)
+	}
+	return nil
+}
+
+// Remove stops monitoring the named path.
+func (m *Monitor) Remove(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if ps, ok := m.paths[name]; ok {
+		ps.cancel()
+		delete(m.paths, name)
+	}
+}
+
+// ObserveRTT records a round trip measured outside the monitor, e.g. by an application-level ping.
+func (m *Monitor) ObserveRTT(name string, rtt time.Duration) {
+	m.record(name, rtt)
+}
+
+// ObserveLoss records a lost round trip measured outside the monitor.
+func (m *Monitor) ObserveLoss(name string) {
+	m.record(name, -1)
+}
+
+// ObserveThroughput records a throughput sample of n bytes transferred in d.
+func (m *Monitor) ObserveThroughput(name string, n int64, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	rate := float64(n) * 8 / d.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ps, ok := m.paths[name]
+	if !ok {
+		return
+	}
+	if ps.throughput == 0 {
+		ps.throughput = rate
+	} else {
+		ps.throughput += throughputAlpha * (rate - ps.throughput)
+	}
+}
+
+// Stats returns the statistics of the named path.
+func (m *Monitor) Stats(name string) (Stats, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ps, ok := m.paths[name]
+	if !ok {
+		return Stats{}, false
+	}
+	return ps.stats(), true
+}
+
+// All returns the statistics of all paths by name.
+func (m *Monitor) All() map[string]Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	all := make(map[string]Stats, len(m.paths))
+	for name, ps := range m.paths {
+		all[name] = ps.stats()
+	}
+	return all
+}
+
+// Best returns the name of the path with the highest score, preferring higher throughput on ties.
+// It returns false if no path has completed a probe.
+func (m *Monitor) Best() (string, Stats, bool) {
+	all := m.All()
+	names := make([]string, 0, len(all))
+	for name, s := range all {
+		if s.Level != Unknown {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", Stats{}, false
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		a, b := all[names[i]], all[names[j]]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Throughput != b.Throughput {
+			return a.Throughput > b.Throughput
+		}
+		return names[i] < names[j]
+	})
+	return names[0], all[names[0]], true
+}
+
+// Subscribe returns a channel receiving level change events and a function to unsubscribe.
+// Events are dropped if the channel buffer is full.
+func (m *Monitor) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+
+	m.mu.Lock()
+	if m.closed {
+		close(ch)
+	} else {
+		m.subscribers[ch] = struct{}{}
+	}
+	m.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if _, ok := m.subscribers[ch]; ok {
+				delete(m.subscribers, ch)
+				close(ch)
+			}
+		})
+	}
+}
+
+// Close stops all probes and closes all subscriptions.
+func (m *Monitor) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	m.cancel()
+	m.mu.Unlock()
+
+	m.wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for ch := range m.subscribers {
+		close(ch)
+	}
+	m.subscribers = nil
+	return nil
+}
+
+// probeLoop pings a path every interval until ctx is canceled.
+func (m *Monitor) probeLoop(ctx context.Context, ps *pathState) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		probeCtx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+		start := time.Now()
+		err := ps.path.Ping(probeCtx)
+		rtt := time.Since(start)
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			rtt = -1
+		}
+		m.record(ps.name, rtt)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// record adds a probe result (negative for loss) and publishes an event if the level changed.
+func (m *Monitor) record(name string, rtt time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ps, ok := m.paths[name]
+	if !ok {
+		return
+	}
+
+	if len(ps.rtts) < m.cfg.Window {
+		ps.rtts = append(ps.rtts, rtt)
+	} else {
+		ps.rtts[ps.next] = rtt
+	}
+	ps.next = (ps.next + 1) % m.cfg.Window
+	if rtt >= 0 {
+		ps.lastSeen = time.Now()
+	}
+
+	stats := ps.stats()
+	if stats.Level == ps.level {
+		return
+	}
+	event := Event{Path: name, Previous: ps.level, Stats: stats}
+	ps.level = stats.Level
+
+	for ch := range m.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// stats computes statistics over the probe window.
+func (ps *pathState) stats() Stats {
+	s := Stats{
+		Throughput: ps.throughput,
+		Probes:     len(ps.rtts),
+		LastSeen:   ps.lastSeen,
+	}
+	if len(ps.rtts) == 0 {
+		return s
+	}
+
+	// Walk the ring buffer in chronological order; once full, the oldest entry is at next
+	start := 0
+	if ps.next < len(ps.rtts) {
+		start = ps.next
+	}
+
+	var total, totalJitter time.Duration
+	var received, lost, pairs int
+	prev := time.Duration(-1)
+	for i := 0; i < len(ps.rtts); i++ {
+		rtt := ps.rtts[(start+i)%len(ps.rtts)]
+		if rtt < 0 {
+			lost++
+			continue
+		}
+		received++
+		total += rtt
+		if prev >= 0 {
+			totalJitter += (rtt - prev).Abs()
+			pairs++
+		}
+		prev = rtt
+	}
+
+	s.Loss = float64(lost) / float64(len(ps.rtts))
+	if received > 0 {
+		s.RTT = total / time.Duration(received)
+	}
+	if pairs > 0 {
+		s.Jitter = totalJitter / time.Duration(pairs)
+	}
+	s.Score = score(s.RTT, s.Jitter, s.Loss, received > 0)
+	s.Level = levelOf(s.Score)
+	return s
+}
+
+// score rates a path from 0 to 100 with a simplified ITU-T G.107 E-model transmission rating,
+// where one-way delay is approximated as half the round trip plus twice the jitter.
+func score(rtt, jitter time.Duration, loss float64, reachable bool) float64 {
+	if !reachable {
+		return 0
+	}
+	delay := float64(rtt/2+2*jitter)/float64(time.Millisecond) + 10
+
+	r := 93.2
+	if delay < 160 {
+		r -= delay / 40
+	} else {
+		r -= (delay - 120) / 10
+	}
+	r -= loss * 100 * 2.5
+
+	return math.Max(0, math.Min(100, r))
+}
+
+// levelOf maps a score to a level.
+func levelOf(score float64) Level {
+	switch {
+	case score >= 80:
+		return Excellent
+	case score >= 70:
+		return Good
+	case score >= 60:
+		return Fair
+	case score >= 50:
+		return Poor
+	default:
+		return Bad
+	}
+}
@@ -0,0 +1,136 @@
+package relay
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidTicket is returned for tickets that are malformed or carry a bad signature
+	ErrInvalidTicket = errors.New("invalid relay ticket")
+	// ErrTicketExpired is returned for tickets outside of their validity period
+	ErrTicketExpired = errors.New("relay ticket expired")
+	// ErrMissingTicket is returned by TicketAuth for requests without a ticket
+	ErrMissingTicket = errors.New("missing relay ticket")
+)
+
+// ticketLeeway is the clock skew tolerated when checking ticket validity periods
+const ticketLeeway = 30 * time.Second
+
+// ticketHeader is the encoded JOSE header of every ticket, {"alg":"HS256","typ":"JWT"}
+var ticketHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims are the contents of a relay ticket.
+type Claims struct {
+	Subject   string    // Identity the connection's quotas are accounted to
+	Expires   time.Time // End of the validity period, required
+	NotBefore time.Time // Start of the validity period, optional
+}
+
+// jwtClaims is the JSON form of Claims
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	Expires   int64  `json:"exp"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+}
+
+// IssueTicket creates a ticket for the given claims signed with key.
+// Tickets are HS256 JSON Web Tokens, so any JWT library sharing the key can issue them as well.
+func IssueTicket(key []byte, c Claims) (string, error) {
+	if c.Subject == "" || c.Expires.IsZero() {
+		return "", ErrInvalidTicket
+	}
+	jc := jwtClaims{
+		Subject:  c.Subject,
+		Expires:  c.Expires.Unix(),
+		IssuedAt: time.Now().Unix(),
+	}
+	if !c.NotBefore.IsZero() {
+		jc.NotBefore = c.NotBefore.Unix()
+	}
+	payload, err := json.Marshal(jc)
+	if err != nil {
+		return "", err
+	}
+	signed := ticketHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign(key, signed)), nil
+}
+
+// VerifyTicket checks the signature and validity period of a ticket and returns its claims.
+func VerifyTicket(key []byte, ticket string) (Claims, error) {
+	parts := strings.Split(ticket, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrInvalidTicket
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, sign(key, parts[0]+"."+parts[1])) {
+		return Claims{}, ErrInvalidTicket
+	}
+
+	// The signature is valid, but only accept the algorithm this package issues
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return Claims{}, ErrInvalidTicket
+	}
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &h); err != nil || h.Alg != "HS256" {
+		return Claims{}, ErrInvalidTicket
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, ErrInvalidTicket
+	}
+	var jc jwtClaims
+	if err := json.Unmarshal(payload, &jc); err != nil || jc.Subject == "" || jc.Expires == 0 {
+		return Claims{}, ErrInvalidTicket
+	}
+
+	c := Claims{Subject: jc.Subject, Expires: time.Unix(jc.Expires, 0)}
+	if jc.NotBefore != 0 {
+		c.NotBefore = time.Unix(jc.NotBefore, 0)
+	}
+	now := time.Now()
+	if now.After(c.Expires.Add(ticketLeeway)) || (!c.NotBefore.IsZero() && now.Add(ticketLeeway).Before(c.NotBefore)) {
+		return Claims{}, ErrTicketExpired
+	}
+	return c, nil
+}
+
+// TicketAuth returns an authentication function for Config.Authenticate that accepts tickets signed with key.
+// Browsers cannot set headers on WebSocket requests, so the ticket is read from the "ticket" query parameter,
+// or from an "Authorization: Bearer" header for native clients. The identity is the ticket's subject.
+func TicketAuth(key []byte) func(r *http.Request) (string, error) {
+	return func(r *http.Request) (string, error) {
+		ticket := r.URL.Query().Get("ticket")
+		if ticket == "" {
+			if auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+				ticket = auth
+			}
+		}
+		if ticket == "" {
+			return "", ErrMissingTicket
+		}
+		c, err := VerifyTicket(key, ticket)
+		if err != nil {
+			return "", err
+		}
+		return c.Subject, nil
+	}
+}
+
+// sign computes the HMAC-SHA256 signature of data.
+func sign(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,137 @@
+//go:build !js
+
+package relay
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+var (
+	// ErrQuotaExceeded is reported when an identity has reached its connection or tunnel quota
+	ErrQuotaExceeded = errors.New("relay quota exceeded")
+	// ErrRateLimited is reported when an identity opens tunnels faster than allowed
+	ErrRateLimited = errors.New("relay rate limit exceeded")
+)
+
+// Limits bounds the resources a single identity may use. Zero fields are unlimited.
+type Limits struct {
+	MaxConns    int           // Concurrent WebSocket connections
+	MaxTunnels  int           // Concurrent tunnels across all connections
+	OpenRate    float64       // Tunnels opened per second, with bursts of up to MaxTunnels (at least 1)
+	Bandwidth   int           // Bytes per second across all tunnels and both directions
+	Burst       int           // Bytes that may be transferred at once above Bandwidth (default one second's worth)
+	IdleTimeout time.Duration // Tunnels without traffic in either direction for this long are closed
+}
+
+// account tracks the resource usage of one identity.
+type account struct {
+	identity string        // identity the account belongs to
+	conns    int           // open connections, guarded by accounts.mu
+	tunnels  int           // open tunnels, guarded by accounts.mu
+	opens    *rate.Limiter // tunnel open rate, nil when unlimited
+	bytes    *rate.Limiter // bandwidth, nil when unlimited
+}
+
+// accounts holds the accounts of identities with open connections.
+type accounts struct {
+	limits Limits
+
+	mu sync.Mutex
+	m  map[string]*account
+}
+
+// connect accounts a new connection for identity, returning nil when MaxConns is reached.
+func (a *accounts) connect(identity string) *account {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	acc := a.m[identity]
+	if acc == nil {
+		acc = &account{identity: identity}
+		if a.limits.OpenRate > 0 {
+			acc.opens = rate.NewLimiter(rate.Limit(a.limits.OpenRate), max(a.limits.MaxTunnels, 1))
+		}
+		if a.limits.Bandwidth > 0 {
+			burst := a.limits.Burst
+			if burst <= 0 {
+				burst = a.limits.Bandwidth
+			}
+			acc.bytes = rate.NewLimiter(rate.Limit(a.limits.Bandwidth), burst)
+		}
+		a.m[identity] = acc
+	}
+	if a.limits.MaxConns > 0 && acc.conns >= a.limits.MaxConns {
+		return nil
+	}
+	acc.conns++
+	return acc
+}
+
+// disconnect releases a connection, forgetting the account once it has none left.
+func (a *accounts) disconnect(identity string, acc *account) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	acc.conns--
+	if acc.conns == 0 {
+		delete(a.m, identity)
+	}
+}
+
+// open accounts a new tunnel.
+func (a *accounts) open(acc *account) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.limits.MaxTunnels > 0 && acc.tunnels >= a.limits.MaxTunnels {
+		return ErrQuotaExceeded
+	}
+	if acc.opens != nil && !acc.opens.Allow() {
+		return ErrRateLimited
+	}
+	acc.tunnels++
+	return nil
+}
+
+// close releases a tunnel.
+func (a *accounts) close(acc *account) {
+	a.mu.Lock()
+	acc.tunnels--
+	a.mu.Unlock()
+}
+
+// wait blocks until n bytes may be transferred under the account's bandwidth limit.
+func (acc *account) wait(ctx context.Context, n int) error {
+	if acc.bytes == nil {
+		return nil
+	}
+	// WaitN refuses requests above the burst size, so larger transfers are paced in burst sized steps
+	for burst := acc.bytes.Burst(); n > 0; n -= burst {
+		if err := acc.bytes.WaitN(ctx, min(n, burst)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// denied reports whether a resolved target address may not be reached. Loopback, private, link-local,
+// multicast and unspecified addresses are denied unless allowPrivate is set; deny prefixes always apply.
+func denied(addr netip.Addr, deny []netip.Prefix, allowPrivate bool) bool {
+	addr = addr.Unmap()
+	if !allowPrivate && (addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified()) {
+		return true
+	}
+	for _, p := range deny {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
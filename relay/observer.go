@@ -0,0 +1,34 @@
+//go:build !js
+
+package relay
+
+// Observer receives relay server events, e.g. to export metrics. Its methods are called concurrently and
+// must not block. Targets are the addresses requested by clients, so their number is bounded by the rules
+// rather than by the relay.
+type Observer interface {
+	// Connected is called when a client connection is served, Disconnected when it ended.
+	Connected(identity string)
+	Disconnected(identity string)
+	// Rejected is called when a client connection is refused before it is served, e.g. with
+	// ErrQuotaExceeded or an authentication error.
+	Rejected(err error)
+	// TunnelOpened is called when a tunnel to a target was established, TunnelClosed when it ended.
+	TunnelOpened(network, target string)
+	TunnelClosed(network, target string)
+	// TunnelFailed is called when a tunnel was refused or its target could not be reached.
+	TunnelFailed(network, target string, err error)
+	// Transferred is called for data passed through a tunnel: in was received from the client and written
+	// to the target, out was read from the target and sent to the client.
+	Transferred(network, target string, in, out int)
+}
+
+// nopObserver ignores all events.
+type nopObserver struct{}
+
+func (nopObserver) Connected(string)                     {}
+func (nopObserver) Disconnected(string)                  {}
+func (nopObserver) Rejected(error)                       {}
+func (nopObserver) TunnelOpened(string, string)          {}
+func (nopObserver) TunnelClosed(string, string)          {}
+func (nopObserver) TunnelFailed(string, string, error)   {}
+func (nopObserver) Transferred(string, string, int, int) {}
@@ -0,0 +1,171 @@
+// Package relay tunnels TCP and UDP connections from browsers through a native relay server. A client
+// multiplexes tunnels over a single message connection, usually a WebSocket, with package mux; every tunnel
+// is one stream that starts with an OpenRequest naming the target, answered by an OpenResponse, after which
+// stream messages carry the tunneled bytes (TCP) or datagrams (UDP). The open messages carry the relay
+// protocol Version of both sides, which clients and relays predating it leave out and ignore, so either
+// side can be upgraded first.
+//
+// Server is the native http.Handler side; Client works anywhere, including js/wasm over wsjs.
+package relay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"pkg.gfire.dev/supernet/codec"
+	"pkg.gfire.dev/supernet/msgconn"
+	"pkg.gfire.dev/supernet/mux"
+	snrelay "pkg.gfire.dev/supernet/proto/snrelay/v1alpha1"
+)
+
+var (
+	// ErrRefused is returned when the relay refuses or fails to connect to a target
+	ErrRefused = errors.New("relay refused connection")
+	// ErrUnsupportedNetwork is returned for networks other than tcp and udp
+	ErrUnsupportedNetwork = errors.New("unsupported relay network")
+)
+
+// tracerName is the instrumentation scope of relay spans
+const tracerName = "pkg.gfire.dev/supernet/relay"
+
+// Version is the version of the relay protocol spoken by this package. Version 1 predates negotiation.
+const Version = 2
+
+// protocol is the relay protocol announced by clients and servers of this package; no capabilities are
+// defined yet
+var protocol = codec.Protocol{Version: Version}
+
+// Client opens tunnels through a relay server.
+type Client struct {
+	session *mux.Session
+
+	// mu protects protocol
+	mu sync.Mutex
+	// protocol is the relay protocol answered to the latest tunnel
+	protocol codec.Protocol
+}
+
+// NewClient starts a relay client over conn, which must be connected to a relay Server.
+// Both sides must use the same mux Window and MaxFrame; Server is forced to false.
+func NewClient(conn mux.Conn, cfg mux.Config) *Client {
+	cfg.Server = false
+	return &Client{session: mux.NewSession(conn, cfg)}
+}
+
+// Dial connects to address through the relay. Network is "tcp", "tcp4", "tcp6", "udp", "udp4" or "udp6".
+// UDP connections preserve datagram boundaries like a connected UDP socket.
+//
+// The dial is traced as a client span whose context is sent to the relay, which continues the trace
+// with a span for the tunnel.
+func (c *Client) Dial(ctx context.Context, network, address string) (conn net.Conn, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "relay.Dial",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("network.transport", network), attribute.String("server.address", address)),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	var n snrelay.Network
+	switch {
+	case strings.HasPrefix(network, "tcp"):
+		n = snrelay.Network_TCP
+	case strings.HasPrefix(network, "udp"):
+		n = snrelay.Network_UDP
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedNetwork, network)
+	}
+
+	st, err := c.session.OpenStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Abort the handshake if ctx ends while waiting for the response
+	stop := context.AfterFunc(ctx, func() {
+		st.Reset()
+	})
+	req := &snrelay.OpenRequest{
+		Network:      n,
+		Address:      address,
+		TraceContext: make(map[string]string),
+		Version:      protocol.Version,
+		Capabilities: uint64(protocol.Capabilities),
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(req.TraceContext))
+	resp, err := handshake(st, req)
+	if !stop() {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		st.Reset()
+		return nil, err
+	}
+	// Relays predating negotiation answer without a version
+	p, _ := codec.Negotiate(protocol, codec.Protocol{Version: resp.Version, Capabilities: codec.Capabilities(resp.Capabilities)}, 0)
+	c.mu.Lock()
+	c.protocol = p
+	c.mu.Unlock()
+	if resp.Error != "" {
+		st.Close()
+		return nil, fmt.Errorf("%w: %s", ErrRefused, resp.Error)
+	}
+
+	remote := resp.RemoteAddress
+	if remote == "" {
+		remote = address
+	}
+	return msgconn.NetConn(st, msgconn.Options{
+		LocalAddr:  msgconn.Addr{Net: network, Addr: resp.LocalAddress},
+		RemoteAddr: msgconn.Addr{Net: network, Addr: remote},
+		Datagram:   n == snrelay.Network_UDP,
+		MaxMessage: c.session.MaxMessage(),
+	}), nil
+}
+
+// Protocol returns the relay protocol spoken with the relay as answered to the latest tunnel, the zero
+// Protocol before any tunnel was answered.
+func (c *Client) Protocol() codec.Protocol {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.protocol
+}
+
+// Done returns a channel that is closed when the connection to the relay is lost.
+func (c *Client) Done() <-chan struct{} {
+	return c.session.Done()
+}
+
+// Close closes all tunnels and the connection to the relay.
+func (c *Client) Close() error {
+	return c.session.Close()
+}
+
+// handshake sends the open request and reads the response.
+func handshake(st *mux.Stream, req *snrelay.OpenRequest) (*snrelay.OpenResponse, error) {
+	data, err := codec.EncodeOpenRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := st.Send(data); err != nil {
+		return nil, err
+	}
+	msg, err := st.NextMessage()
+	if err != nil {
+		return nil, err
+	}
+	return codec.DecodeOpenResponse(msg)
+}
@@ -0,0 +1,266 @@
+//go:build !js
+
+package relay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"pkg.gfire.dev/supernet/mux"
+	"pkg.gfire.dev/supernet/routing"
+)
+
+// testTimeout bounds every blocking call of a test
+const testTimeout = 10 * time.Second
+
+// loopbackRules permits loopback targets only.
+func loopbackRules(t *testing.T) *routing.Rules {
+	t.Helper()
+	rules, err := routing.New(routing.Route{Reject: true}, &routing.Rule{CIDRs: []string{"127.0.0.0/8"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rules
+}
+
+// serve starts an HTTP server for srv and returns its WebSocket URL.
+func serve(t *testing.T, srv *Server) string {
+	t.Helper()
+	hs := httptest.NewServer(srv)
+	t.Cleanup(hs.Close)
+	return "ws" + strings.TrimPrefix(hs.URL, "http")
+}
+
+// newClient connects a relay client to the WebSocket URL.
+func newClient(t *testing.T, url string) *Client {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	ws, _, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(newWSConn(context.Background(), ws, mux.Config{}), mux.Config{})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// newServer returns a server that may reach loopback targets only.
+func newServer(t *testing.T, cfg Config) *Server {
+	t.Helper()
+	cfg.Rules = loopbackRules(t)
+	cfg.AllowPrivate = true
+	cfg.Logger = slog.New(slog.DiscardHandler)
+	return NewServer(cfg)
+}
+
+// echoTCP starts a TCP echo server and returns its address.
+func echoTCP(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				io.Copy(c, c)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+// echoUDP starts a UDP echo server and returns its address.
+func echoUDP(t *testing.T) string {
+	t.Helper()
+	c, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	go func() {
+		buf := make([]byte, maxUDPDatagram)
+		for {
+			n, addr, err := c.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			c.WriteTo(buf[:n], addr)
+		}
+	}()
+	return c.LocalAddr().String()
+}
+
+func TestTCPTunnel(t *testing.T) {
+	target := echoTCP(t)
+	client := newClient(t, serve(t, newServer(t, Config{})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	conn, err := client.Dial(ctx, "tcp", target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if p := client.Protocol(); p.Version != Version {
+		t.Fatalf("protocol version %d, want %d", p.Version, Version)
+	}
+	if conn.RemoteAddr().String() != target {
+		t.Fatalf("remote address %s, want %s", conn.RemoteAddr(), target)
+	}
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), 16<<10)
+	go func() {
+		if _, err := conn.Write(data); err != nil {
+			t.Error(err)
+		}
+	}()
+	got := make([]byte, len(data))
+	conn.SetReadDeadline(time.Now().Add(testTimeout))
+	if _, err := io.ReadFull(conn, got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("echoed data differs")
+	}
+}
+
+func TestUDPTunnel(t *testing.T) {
+	target := echoUDP(t)
+	client := newClient(t, serve(t, newServer(t, Config{})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	conn, err := client.Dial(ctx, "udp", target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Datagram boundaries are preserved
+	buf := make([]byte, maxUDPDatagram)
+	conn.SetReadDeadline(time.Now().Add(testTimeout))
+	for _, msg := range []string{"first", "second datagram"} {
+		if _, err := conn.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf[:n]) != msg {
+			t.Fatalf("read %q, want %q", buf[:n], msg)
+		}
+	}
+}
+
+func TestRefused(t *testing.T) {
+	target := echoTCP(t)
+	for name, tc := range map[string]struct {
+		cfg     Config
+		address string
+		want    string
+	}{
+		"rules":   {Config{}, "192.0.2.1:80", ErrTargetDenied.Error()},
+		"deny":    {Config{Deny: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}}, target, ErrTargetDenied.Error()},
+		"version": {Config{MinVersion: Version + 1}, target, "incompatible"},
+		"address": {Config{}, "127.0.0.1", "missing port"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := newClient(t, serve(t, newServer(t, tc.cfg)))
+			ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+			defer cancel()
+			_, err := client.Dial(ctx, "tcp", tc.address)
+			if !errors.Is(err, ErrRefused) || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("Dial: %v, want ErrRefused with %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestPrivateDenied(t *testing.T) {
+	srv := NewServer(Config{Rules: loopbackRules(t), Logger: slog.New(slog.DiscardHandler)})
+	client := newClient(t, serve(t, srv))
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	if _, err := client.Dial(ctx, "tcp", echoTCP(t)); !errors.Is(err, ErrRefused) || !strings.Contains(err.Error(), ErrTargetDenied.Error()) {
+		t.Fatalf("Dial: %v, want ErrRefused with ErrTargetDenied", err)
+	}
+}
+
+func TestUnsupportedNetwork(t *testing.T) {
+	client := newClient(t, serve(t, newServer(t, Config{})))
+	if _, err := client.Dial(context.Background(), "unix", "/tmp/relay.sock"); !errors.Is(err, ErrUnsupportedNetwork) {
+		t.Fatalf("Dial: %v, want ErrUnsupportedNetwork", err)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	target := echoTCP(t)
+	url := serve(t, newServer(t, Config{Limits: Limits{MaxConns: 1, MaxTunnels: 1}}))
+	client := newClient(t, url)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	conn, err := client.Dial(ctx, "tcp", target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := client.Dial(ctx, "tcp", target); !errors.Is(err, ErrRefused) || !strings.Contains(err.Error(), ErrQuotaExceeded.Error()) {
+		t.Fatalf("second tunnel: %v, want ErrRefused with ErrQuotaExceeded", err)
+	}
+
+	if _, resp, err := websocket.Dial(ctx, url, nil); err == nil || resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("second connection: %v, want %d", err, http.StatusTooManyRequests)
+	}
+}
+
+func TestTicket(t *testing.T) {
+	key := []byte("secret")
+	ticket, err := IssueTicket(key, Claims{Subject: "alice", Expires: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := VerifyTicket(key, ticket)
+	if err != nil || c.Subject != "alice" {
+		t.Fatalf("claims %+v, %v", c, err)
+	}
+
+	expired, err := IssueTicket(key, Claims{Subject: "alice", Expires: time.Now().Add(-time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tc := range map[string]struct {
+		key    []byte
+		ticket string
+		want   error
+	}{
+		"key":       {[]byte("other"), ticket, ErrInvalidTicket},
+		"truncated": {key, ticket[:strings.LastIndexByte(ticket, '.')], ErrInvalidTicket},
+		"expired":   {key, expired, ErrTicketExpired},
+	} {
+		if _, err := VerifyTicket(tc.key, tc.ticket); err != tc.want {
+			t.Errorf("%s: %v, want %v", name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,498 @@
+//go:build !js
+
+package relay
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/coder/websocket"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"pkg.gfire.dev/supernet/codec"
+	"pkg.gfire.dev/supernet/logging"
+	"pkg.gfire.dev/supernet/meter"
+	"pkg.gfire.dev/supernet/mux"
+	"pkg.gfire.dev/supernet/policy"
+	snrelay "pkg.gfire.dev/supernet/proto/snrelay/v1alpha1"
+	"pkg.gfire.dev/supernet/routing"
+)
+
+// ErrTargetDenied is reported to clients for targets not permitted by the connection's rules
+var ErrTargetDenied = errors.New("target not allowed")
+
+// PolicyService is the service name tunnels are checked as against Config.Policy
+const PolicyService = "relay"
+
+const (
+	// defaultDialTimeout is the default time limit for connecting to a target
+	defaultDialTimeout = 10 * time.Second
+	// handshakeTimeout is how long a new stream may take to send its OpenRequest
+	handshakeTimeout = 10 * time.Second
+	// maxUDPDatagram is the largest datagram read from UDP targets
+	maxUDPDatagram = 65535
+)
+
+// Config configures a Server.
+type Config struct {
+	// Authenticate returns the identity of the client making a WebSocket request, see TicketAuth.
+	// Returning an error rejects the connection with 401 Unauthorized. When nil, clients are anonymous and
+	// their quotas are accounted to their IP address.
+	Authenticate func(r *http.Request) (identity string, err error)
+	// Targets returns the rules deciding which targets a connection may reach; routes with Reject set deny.
+	// It is called once per WebSocket connection, so rules can depend on the identity.
+	// Returning an error rejects the connection with 403 Forbidden. When nil, Rules applies to everyone.
+	Targets func(r *http.Request, identity string) (*routing.Rules, error)
+	// Rules are the target rules used when Targets is nil. Without either, every target is denied.
+	Rules *routing.Rules
+	// Policy, when set, additionally decides which identities may reach which targets. Tunnels are checked
+	// as PolicyService with the connection's identity as subject; denials are audit logged by the policy.
+	Policy *policy.Enforcer
+	// Deny lists address ranges that may never be reached, checked after host names are resolved.
+	Deny []netip.Prefix
+	// AllowPrivate permits loopback, private, link-local and multicast targets, which are denied by default
+	// so rules allowing host names cannot be used to reach the relay's own network.
+	AllowPrivate bool
+	// Limits bounds the connections, tunnels and bandwidth of each identity.
+	Limits Limits
+	// Meter, when set, accounts the tunneled bytes to the identity, with one stream per tunnel named after
+	// its target. Tunnels exceeding the identity's quota are closed.
+	Meter *meter.Meter
+	// Dialer connects to targets. Defaults to a net.Dialer with a 10s timeout.
+	Dialer *net.Dialer
+	// MinVersion is the lowest relay protocol version accepted from clients, 0 for any. Tunnels of older
+	// clients are refused with an error naming the required version.
+	MinVersion uint32
+	// Mux configures the multiplexer; Window and MaxFrame must match the clients. Server is forced to true.
+	Mux mux.Config
+	// AcceptOptions configures the WebSocket handshake, e.g. allowed origins.
+	AcceptOptions *websocket.AcceptOptions
+	// Logger receives connection and tunnel events. Defaults to logging.For("relay").
+	Logger *slog.Logger
+	// Observer receives connection, tunnel and traffic events, e.g. for metrics.
+	Observer Observer
+}
+
+// Server is an http.Handler accepting relay clients over WebSockets.
+type Server struct {
+	cfg Config
+	// dialer is cfg.Dialer with the address checks installed
+	dialer *net.Dialer
+	// accounts tracks the resource usage per identity
+	accounts *accounts
+}
+
+// NewServer creates a relay server.
+func NewServer(cfg Config) *Server {
+	if cfg.Dialer == nil {
+		cfg.Dialer = &net.Dialer{Timeout: defaultDialTimeout}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logging.For("relay")
+	}
+	if cfg.Observer == nil {
+		cfg.Observer = nopObserver{}
+	}
+	cfg.Mux.Server = true
+
+	// Check every resolved address right before connecting, which also covers DNS rebinding
+	dialer := *cfg.Dialer
+	control := dialer.Control
+	dialer.Control = func(network, address string, c syscall.RawConn) error {
+		ap, err := netip.ParseAddrPort(address)
+		if err != nil || denied(ap.Addr(), cfg.Deny, cfg.AllowPrivate) {
+			return ErrTargetDenied
+		}
+		if control != nil {
+			return control(network, address, c)
+		}
+		return nil
+	}
+
+	return &Server{
+		cfg:      cfg,
+		dialer:   &dialer,
+		accounts: &accounts{limits: cfg.Limits, m: make(map[string]*account)},
+	}
+}
+
+// ServeHTTP authenticates the request, upgrades it to a WebSocket and serves tunnels over it until the
+// client disconnects.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	identity, err := s.identify(r)
+	if err != nil {
+		s.cfg.Observer.Rejected(err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	rules := s.cfg.Rules
+	if s.cfg.Targets != nil {
+		if rules, err = s.cfg.Targets(r, identity); err != nil {
+			s.cfg.Observer.Rejected(err)
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	acc := s.accounts.connect(identity)
+	if acc == nil {
+		s.cfg.Observer.Rejected(ErrQuotaExceeded)
+		http.Error(w, ErrQuotaExceeded.Error(), http.StatusTooManyRequests)
+		return
+	}
+	defer s.accounts.disconnect(identity, acc)
+
+	ws, err := websocket.Accept(w, r, s.cfg.AcceptOptions)
+	if err != nil {
+		return
+	}
+	conn := newWSConn(r.Context(), ws, s.cfg.Mux)
+	s.serve(r.Context(), conn, rules, acc, s.cfg.Logger.With("identity", identity, "client", r.RemoteAddr))
+}
+
+// Serve serves tunnels over an already established message connection until it fails or ctx is done.
+// Tunnels may only reach targets permitted by rules and are subject to the limits of identity.
+// Returns ErrQuotaExceeded without serving when identity has reached its connection quota.
+func (s *Server) Serve(ctx context.Context, conn mux.Conn, rules *routing.Rules, identity string) error {
+	acc := s.accounts.connect(identity)
+	if acc == nil {
+		s.cfg.Observer.Rejected(ErrQuotaExceeded)
+		conn.Close()
+		return ErrQuotaExceeded
+	}
+	defer s.accounts.disconnect(identity, acc)
+
+	s.serve(ctx, conn, rules, acc, s.cfg.Logger.With("identity", identity))
+	return nil
+}
+
+// identify authenticates r, falling back to the client's IP address.
+func (s *Server) identify(r *http.Request) (string, error) {
+	if s.cfg.Authenticate != nil {
+		return s.cfg.Authenticate(r)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr, nil
+	}
+	return host, nil
+}
+
+// serve accepts tunnels of one connection.
+func (s *Server) serve(ctx context.Context, conn mux.Conn, rules *routing.Rules, acc *account, log *slog.Logger) {
+	session := mux.NewSession(conn, s.cfg.Mux)
+	defer session.Close()
+
+	log.Debug("relay client connected")
+	defer log.Debug("relay client disconnected")
+	s.cfg.Observer.Connected(acc.identity)
+	defer s.cfg.Observer.Disconnected(acc.identity)
+
+	for {
+		st, err := session.AcceptStream(ctx)
+		if err != nil {
+			return
+		}
+		go s.serveStream(ctx, session, st, rules, acc, log)
+	}
+}
+
+// serveStream handles the handshake of one tunnel and pipes it to its target.
+func (s *Server) serveStream(ctx context.Context, session *mux.Session, st *mux.Stream, rules *routing.Rules, acc *account, log *slog.Logger) {
+	defer st.Close()
+
+	timer := time.AfterFunc(handshakeTimeout, func() {
+		st.Reset()
+	})
+	msg, err := st.NextMessage()
+	timer.Stop()
+	if err != nil {
+		return
+	}
+	req, err := codec.DecodeOpenRequest(msg)
+	if err != nil {
+		log.Debug("relay malformed open request", "err", err)
+		st.Reset()
+		return
+	}
+
+	network := "tcp"
+	if req.Network == snrelay.Network_UDP {
+		network = "udp"
+	}
+	log = log.With("network", network, "target", req.Address)
+
+	// Clients predating negotiation send no version and ignore the one answered
+	p, err := codec.Negotiate(protocol, codec.Protocol{Version: req.Version, Capabilities: codec.Capabilities(req.Capabilities)}, s.cfg.MinVersion)
+	if err != nil {
+		log.Debug("relay tunnel refused", "err", err)
+		s.cfg.Observer.TunnelFailed(network, req.Address, err)
+		respond(st, protocol, &snrelay.OpenResponse{Error: err.Error()})
+		return
+	}
+
+	// Continue the trace of the client's dial, spanning the tunnel's lifetime
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(req.TraceContext))
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "relay.Tunnel",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("network.transport", network), attribute.String("server.address", req.Address)),
+	)
+	defer span.End()
+
+	if err := s.cfg.Policy.Check(policy.Request{Subject: acc.identity, Service: PolicyService, Network: network, Target: req.Address}); err != nil {
+		s.cfg.Observer.TunnelFailed(network, req.Address, err)
+		span.SetStatus(codes.Error, err.Error())
+		respond(st, p, &snrelay.OpenResponse{Error: err.Error()})
+		return
+	}
+
+	if err := s.accounts.open(acc); err != nil {
+		log.Debug("relay tunnel refused", "err", err)
+		s.cfg.Observer.TunnelFailed(network, req.Address, err)
+		span.SetStatus(codes.Error, err.Error())
+		respond(st, p, &snrelay.OpenResponse{Error: err.Error()})
+		return
+	}
+	defer s.accounts.close(acc)
+
+	target, err := s.dial(ctx, rules, network, req.Address)
+	if err != nil {
+		log.Debug("relay target refused", "err", err)
+		s.cfg.Observer.TunnelFailed(network, req.Address, err)
+		span.SetStatus(codes.Error, err.Error())
+		respond(st, p, &snrelay.OpenResponse{Error: err.Error()})
+		return
+	}
+	defer target.Close()
+	span.AddEvent("connected", trace.WithAttributes(attribute.String("network.peer.address", target.RemoteAddr().String())))
+
+	if err := respond(st, p, &snrelay.OpenResponse{
+		LocalAddress:  target.LocalAddr().String(),
+		RemoteAddress: target.RemoteAddr().String(),
+	}); err != nil {
+		return
+	}
+	log.Debug("relay tunnel opened", "version", p.Version)
+	s.cfg.Observer.TunnelOpened(network, req.Address)
+	defer s.cfg.Observer.TunnelClosed(network, req.Address)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// Tear the tunnel down when it is cancelled, idle or the session ends, unblocking both copy loops
+	stop := context.AfterFunc(ctx, func() {
+		target.Close()
+		st.Reset()
+	})
+	defer stop()
+
+	t := &tunnel{ctx: ctx, st: st, target: target, acc: acc, idle: s.cfg.Limits.IdleTimeout}
+	var bytesIn, bytesOut atomic.Int64
+	t.observe = func(in, out int) {
+		bytesIn.Add(int64(in))
+		bytesOut.Add(int64(out))
+		s.cfg.Observer.Transferred(network, req.Address, in, out)
+	}
+	defer func() {
+		span.SetAttributes(attribute.Int64("relay.bytes_in", bytesIn.Load()), attribute.Int64("relay.bytes_out", bytesOut.Load()))
+	}()
+	if s.cfg.Meter != nil {
+		t.usage = s.cfg.Meter.Stream(acc.identity, network+" "+req.Address)
+		defer t.usage.Close()
+	}
+	if t.idle > 0 {
+		t.timer = time.AfterFunc(t.idle, func() {
+			// Transfers waiting for bandwidth are not idle
+			if t.busy.Load() > 0 {
+				t.timer.Reset(t.idle)
+				return
+			}
+			cancel()
+		})
+		defer t.timer.Stop()
+	}
+
+	bufSize := session.MaxMessage()
+	if network == "udp" {
+		bufSize = maxUDPDatagram
+	}
+	toDone := make(chan error, 1)
+	fromDone := make(chan error, 1)
+	go func() {
+		toDone <- t.toStream(bufSize)
+	}()
+	go func() {
+		fromDone <- t.fromStream()
+	}()
+	select {
+	case <-toDone:
+	case err := <-fromDone:
+		// A clean half-close leaves the target's response to finish
+		if err == nil {
+			<-toDone
+		}
+	}
+	log.Debug("relay tunnel closed")
+}
+
+// dial checks the target against rules and connects to it.
+func (s *Server) dial(ctx context.Context, rules *routing.Rules, network, address string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil || rules.Route(host, uint16(port)).Reject {
+		return nil, ErrTargetDenied
+	}
+	conn, err := s.dialer.DialContext(ctx, network, address)
+	if errors.Is(err, ErrTargetDenied) {
+		// Hide the resolved address from the client
+		return nil, ErrTargetDenied
+	}
+	return conn, err
+}
+
+// respond sends an OpenResponse announcing the relay protocol p.
+func respond(st *mux.Stream, p codec.Protocol, resp *snrelay.OpenResponse) error {
+	resp.Version, resp.Capabilities = p.Version, uint64(p.Capabilities)
+	data, err := codec.EncodeOpenResponse(resp)
+	if err != nil {
+		return err
+	}
+	return st.Send(data)
+}
+
+// tunnel pipes one stream to its target.
+type tunnel struct {
+	ctx    context.Context
+	st     *mux.Stream
+	target net.Conn
+	acc    *account
+	usage  *meter.Stream // traffic accounting, nil when unmetered
+	// observe reports the bytes transferred in each direction
+	observe func(in, out int)
+
+	idle  time.Duration // idle timeout, 0 when disabled
+	timer *time.Timer   // idle timer, nil when disabled
+	busy  atomic.Int32  // number of transfers in progress
+}
+
+// transfer passes n bytes through the bandwidth limit and write, postponing the idle timeout.
+func (t *tunnel) transfer(n int, write func() error) error {
+	if t.timer == nil {
+		if err := t.acc.wait(t.ctx, n); err != nil {
+			return err
+		}
+		return write()
+	}
+
+	t.busy.Add(1)
+	defer func() {
+		t.busy.Add(-1)
+		t.timer.Reset(t.idle)
+	}()
+	if err := t.acc.wait(t.ctx, n); err != nil {
+		return err
+	}
+	return write()
+}
+
+// toStream forwards reads from the target as stream messages.
+func (t *tunnel) toStream(bufSize int) error {
+	buf := make([]byte, bufSize)
+	for {
+		n, err := t.target.Read(buf)
+		if n > 0 {
+			if t.usage != nil {
+				if merr := t.usage.Sent(n); merr != nil {
+					return merr
+				}
+			}
+			if serr := t.transfer(n, func() error { return t.st.Send(buf[:n]) }); serr != nil {
+				return serr
+			}
+			t.observe(0, n)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// fromStream writes stream messages to the target.
+func (t *tunnel) fromStream() error {
+	for {
+		msg, err := t.st.NextMessage()
+		if err != nil {
+			if err == io.EOF {
+				// Half-close TCP targets so they can finish their response
+				if tcp, ok := t.target.(*net.TCPConn); ok {
+					tcp.CloseWrite()
+					return nil
+				}
+			}
+			return err
+		}
+		if t.usage != nil {
+			if err := t.usage.Received(len(msg)); err != nil {
+				return err
+			}
+		}
+		if err := t.transfer(len(msg), func() error {
+			_, err := t.target.Write(msg)
+			return err
+		}); err != nil {
+			return err
+		}
+		t.observe(len(msg), 0)
+	}
+}
+
+// wsConn adapts a WebSocket connection to the message contract used by mux.
+type wsConn struct {
+	ctx context.Context
+	ws  *websocket.Conn
+}
+
+// newWSConn wraps ws, raising its read limit to fit the largest mux frame.
+func newWSConn(ctx context.Context, ws *websocket.Conn, cfg mux.Config) *wsConn {
+	limit := int64(cfg.MaxFrame)
+	if limit <= 0 || limit < 1<<20 {
+		limit = 1 << 20
+	}
+	ws.SetReadLimit(limit + 64)
+	return &wsConn{ctx: ctx, ws: ws}
+}
+
+// NextMessage reads the next WebSocket message.
+func (c *wsConn) NextMessage() ([]byte, error) {
+	_, data, err := c.ws.Read(c.ctx)
+	return data, err
+}
+
+// Send writes a binary WebSocket message.
+func (c *wsConn) Send(data []byte) error {
+	return c.ws.Write(c.ctx, websocket.MessageBinary, data)
+}
+
+// Close closes the WebSocket with a normal closure status.
+func (c *wsConn) Close() error {
+	return c.ws.Close(websocket.StatusNormalClosure, "")
+}
@@ -0,0 +1,120 @@
+//go:build !js
+
+package rendezvous
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	snrendezvous "pkg.gfire.dev/supernet/proto/snrendezvous/v1alpha1"
+)
+
+// backplaneTimeout bounds publishing a message on the backplane
+const backplaneTimeout = 5 * time.Second
+
+// peerChannel returns the backplane channel of the server a peer is registered at.
+func peerChannel(id identity.ID) string {
+	return "rendezvous/peer/" + id.String()
+}
+
+// namespaceChannel returns the backplane channel of the servers with watchers of a namespace.
+func namespaceChannel(ns string) string {
+	return "rendezvous/ns/" + ns
+}
+
+// attachPeer subscribes to the backplane channel of a peer that registered at the server. The caller must
+// hold mu.
+func (s *Server) attachPeer(id identity.ID) {
+	s.attach(peerChannel(id), func(fwd *snrendezvous.Forward) {
+		s.mu.Lock()
+		sess := s.peers[id]
+		if sess == nil {
+			s.mu.Unlock()
+			return
+		}
+		if fwd.Message == nil {
+			s.evict(sess)
+			s.mu.Unlock()
+			s.cfg.Logger.Debug("rendezvous peer registered at another server", "peer", id.ShortString())
+			sess.conn.Close()
+			return
+		}
+		s.mu.Unlock()
+		sess.push(fwd.Message)
+	})
+}
+
+// attachNamespace subscribes to the presence changes of a namespace at other servers for the local watchers.
+// The caller must hold mu.
+func (s *Server) attachNamespace(ns string) {
+	s.attach(namespaceChannel(ns), func(fwd *snrendezvous.Forward) {
+		if fwd.Message.GetPresence() == nil {
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for w := range s.watchers[ns] {
+			w.push(fwd.Message)
+		}
+	})
+}
+
+// attach subscribes to a backplane channel, passing on messages forwarded by other servers. The caller must
+// hold mu.
+func (s *Server) attach(channel string, fn func(fwd *snrendezvous.Forward)) {
+	if s.cfg.Backplane == nil || s.subscriptions[channel] != nil {
+		return
+	}
+	s.subscriptions[channel] = s.cfg.Backplane.Subscribe(channel, func(data []byte) {
+		fwd := &snrendezvous.Forward{}
+		if err := fwd.UnmarshalVT(data); err != nil || bytes.Equal(fwd.Instance, s.instance) {
+			return
+		}
+		fn(fwd)
+	})
+}
+
+// detach cancels the subscription of a backplane channel. The caller must hold mu.
+func (s *Server) detach(channel string) {
+	if cancel := s.subscriptions[channel]; cancel != nil {
+		cancel()
+		delete(s.subscriptions, channel)
+	}
+}
+
+// evict removes the session of a peer that registered at another server, which announces the peer itself.
+// The caller must hold mu.
+func (s *Server) evict(sess *session) {
+	delete(s.peers, sess.rec.ID)
+	s.detach(peerChannel(sess.rec.ID))
+	for _, ns := range sess.namespaces {
+		if s.namespaces[ns][sess.rec.ID] == sess {
+			delete(s.namespaces[ns], sess.rec.ID)
+			if len(s.namespaces[ns]) == 0 {
+				delete(s.namespaces, ns)
+			}
+		}
+	}
+}
+
+// forward publishes a message for the clients of other servers on a backplane channel and returns the number
+// of servers that received it. A nil message takes over the registration of a peer. Without a backplane it
+// does nothing.
+func (s *Server) forward(channel string, msg *snrendezvous.ServerMessage) (int, error) {
+	if s.cfg.Backplane == nil {
+		return 0, nil
+	}
+	data, err := (&snrendezvous.Forward{Instance: s.instance, Message: msg}).MarshalVT()
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+	n, err := s.cfg.Backplane.Publish(ctx, channel, data)
+	if err != nil {
+		s.cfg.Logger.Warn("rendezvous backplane publish failed", "channel", channel, "err", err)
+	}
+	return n, err
+}
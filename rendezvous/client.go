@@ -0,0 +1,367 @@
+package rendezvous
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/msgconn"
+	"pkg.gfire.dev/supernet/p2p"
+	snrendezvous "pkg.gfire.dev/supernet/proto/snrendezvous/v1alpha1"
+)
+
+// signalBuffer is the number of incoming signals and presence events buffered for the application
+const signalBuffer = 64
+
+// Signal is a signaling message received from another peer.
+type Signal struct {
+	From    identity.ID // Peer that sent the signal
+	Session uint64      // Session chosen by the offering peer
+	Kind    Kind        // Type of the signal
+	Payload []byte      // Opaque signaling data
+}
+
+// PresenceEvent reports a peer joining or leaving a watched namespace.
+type PresenceEvent struct {
+	Namespace string           // Watched namespace
+	Record    *identity.Record // Peer that joined or left
+	Online    bool             // Whether the peer joined
+}
+
+// Client is a peer's connection to a rendezvous server.
+type Client struct {
+	conn Conn
+	self *identity.Identity
+
+	mu sync.Mutex
+	// pending maps request IDs to the channel awaiting the response
+	pending map[uint64]chan *snrendezvous.ServerMessage
+	// answers maps offering sessions to the channel awaiting the answer
+	answers map[sessionKey]chan []byte
+	// err is set once the connection failed
+	err error
+
+	nextRequestID atomic.Uint64
+
+	signals  chan Signal
+	presence chan PresenceEvent
+	done     chan struct{}
+}
+
+// sessionKey identifies a signaling session with a peer.
+type sessionKey struct {
+	peer    identity.ID
+	session uint64
+}
+
+// Connect registers the identity in namespaces over an established connection to a rendezvous server.
+// The connection is closed if registration fails.
+func Connect(ctx context.Context, conn Conn, self *identity.Identity, namespaces ...string) (*Client, error) {
+	for _, ns := range namespaces {
+		if err := checkNamespace(ns); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	c := &Client{
+		conn:     conn,
+		self:     self,
+		pending:  make(map[uint64]chan *snrendezvous.ServerMessage),
+		answers:  make(map[sessionKey]chan []byte),
+		signals:  make(chan Signal, signalBuffer),
+		presence: make(chan PresenceEvent, signalBuffer),
+		done:     make(chan struct{}),
+	}
+
+	// Abort the blocking handshake by closing the connection when ctx ends
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	err := c.register(namespaces)
+	if !stop() {
+		conn.Close()
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	go c.serve()
+	return c, nil
+}
+
+// register answers the server's challenge with a registration.
+func (c *Client) register(namespaces []string) error {
+	msg := &snrendezvous.ServerMessage{}
+	if err := msgconn.RecvVT(c.conn, msg); err != nil {
+		return err
+	}
+	challenge := msg.GetChallenge()
+	if challenge == nil {
+		return ErrRegistrationFailed
+	}
+
+	record, err := c.self.NewRecord(nil, 0)
+	if err != nil {
+		return err
+	}
+	if err := msgconn.SendVT(c.conn, &snrendezvous.ClientMessage{
+		RequestId: c.nextRequestID.Add(1),
+		Body: &snrendezvous.ClientMessage_Register{Register: &snrendezvous.Register{
+			Record:     record,
+			Signature:  c.self.Sign(registerMessage(challenge.Nonce)),
+			Namespaces: namespaces,
+		}},
+	}); err != nil {
+		return err
+	}
+
+	if err := msgconn.RecvVT(c.conn, msg); err != nil {
+		return err
+	}
+	if e := msg.GetError(); e != nil {
+		return fmt.Errorf("%w: %s", ErrRegistrationFailed, e.Message)
+	}
+	if msg.GetOk() == nil {
+		return ErrRegistrationFailed
+	}
+	return nil
+}
+
+// Discover returns up to limit peers registered in a namespace, excluding the client itself.
+// A zero limit asks for as many as the server allows.
+func (c *Client) Discover(ctx context.Context, namespace string, limit int) ([]*identity.Record, error) {
+	if err := checkNamespace(namespace); err != nil {
+		return nil, err
+	}
+	resp, err := c.request(ctx, &snrendezvous.ClientMessage{Body: &snrendezvous.ClientMessage_Discover{
+		Discover: &snrendezvous.Discover{Namespace: namespace, Limit: uint32(max(limit, 0))},
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	peers := resp.GetPeers()
+	records := make([]*identity.Record, 0, len(peers.GetRecords()))
+	for _, raw := range peers.GetRecords() {
+		if rec, err := identity.OpenRecord(raw); err == nil && rec.ID != c.self.ID() {
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
+
+// Watch subscribes to presence changes in a namespace, delivered through Presence.
+func (c *Client) Watch(ctx context.Context, namespace string) error {
+	if err := checkNamespace(namespace); err != nil {
+		return err
+	}
+	_, err := c.request(ctx, &snrendezvous.ClientMessage{Body: &snrendezvous.ClientMessage_Watch{
+		Watch: &snrendezvous.Watch{Namespace: namespace},
+	}})
+	return err
+}
+
+// Presence returns the channel of presence events for watched namespaces.
+// Events are dropped while the channel is full.
+func (c *Client) Presence() <-chan PresenceEvent {
+	return c.presence
+}
+
+// Signals returns the channel of incoming signals, except answers consumed by Exchange.
+// Signals are dropped while the channel is full. The channel is closed when the connection ends.
+func (c *Client) Signals() <-chan Signal {
+	return c.signals
+}
+
+// Send relays a signal to a peer. Returns ErrPeerOffline if the peer is not connected to the server.
+func (c *Client) Send(ctx context.Context, to identity.ID, session uint64, kind Kind, payload []byte) error {
+	_, err := c.request(ctx, &snrendezvous.ClientMessage{Body: &snrendezvous.ClientMessage_Signal{
+		Signal: &snrendezvous.Signal{
+			Peer:    to.Bytes(),
+			Session: session,
+			Kind:    snrendezvous.SignalKind(kind),
+			Payload: payload,
+		},
+	}})
+	return err
+}
+
+// Exchange sends an offer to a peer in a new session and waits for its answer.
+func (c *Client) Exchange(ctx context.Context, to identity.ID, offer []byte) ([]byte, error) {
+	var b [8]byte
+	rand.Read(b[:])
+	key := sessionKey{peer: to, session: binary.LittleEndian.Uint64(b[:])}
+
+	ch := make(chan []byte, 1)
+	c.mu.Lock()
+	c.answers[key] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.answers, key)
+		c.mu.Unlock()
+	}()
+
+	if err := c.Send(ctx, to, key.session, Offer, offer); err != nil {
+		return nil, err
+	}
+	select {
+	case answer := <-ch:
+		return answer, nil
+	case <-ctx.Done():
+		c.Send(context.Background(), to, key.session, Bye, nil)
+		return nil, ctx.Err()
+	case <-c.done:
+		return nil, c.closeErr()
+	}
+}
+
+// Dial establishes a direct connection to a peer with transport, exchanging the offer and answer through
+// the server.
+func (c *Client) Dial(ctx context.Context, transport p2p.Transport, to identity.ID) (p2p.Conn, error) {
+	return transport.Connect(ctx, func(ctx context.Context, offer []byte) ([]byte, error) {
+		return c.Exchange(ctx, to, offer)
+	})
+}
+
+// Answer accepts an offer signal with transport and returns the established connection.
+func (c *Client) Answer(ctx context.Context, transport p2p.Transport, offer Signal) (p2p.Conn, error) {
+	if offer.Kind != Offer {
+		return nil, fmt.Errorf("rendezvous: cannot answer %s signal", offer.Kind)
+	}
+	return transport.Accept(ctx, offer.Payload, func(answer []byte) error {
+		return c.Send(ctx, offer.From, offer.Session, Answer, answer)
+	})
+}
+
+// Done returns a channel that is closed when the connection to the server ends.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close closes the connection to the server, unregistering the client.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// request sends a request and waits for its response, converting Error responses.
+func (c *Client) request(ctx context.Context, msg *snrendezvous.ClientMessage) (*snrendezvous.ServerMessage, error) {
+	id := c.nextRequestID.Add(1)
+	msg.RequestId = id
+
+	ch := make(chan *snrendezvous.ServerMessage, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := msgconn.SendVT(c.conn, msg); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-ch:
+		if e := resp.GetError(); e != nil {
+			if e.Message == ErrPeerOffline.Error() {
+				return nil, ErrPeerOffline
+			}
+			return nil, errors.New(e.Message)
+		}
+		return resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.done:
+		return nil, c.closeErr()
+	}
+}
+
+// serve dispatches server messages until the connection fails.
+func (c *Client) serve() {
+	var err error
+	defer func() {
+		c.mu.Lock()
+		c.err = errors.Join(ErrClosed, err)
+		c.mu.Unlock()
+		close(c.done)
+		close(c.signals)
+		close(c.presence)
+	}()
+
+	for {
+		msg := &snrendezvous.ServerMessage{}
+		if err = msgconn.RecvVT(c.conn, msg); err != nil {
+			return
+		}
+
+		if msg.RequestId != 0 {
+			c.mu.Lock()
+			ch := c.pending[msg.RequestId]
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- msg
+			}
+			continue
+		}
+
+		switch body := msg.Body.(type) {
+		case *snrendezvous.ServerMessage_Signal:
+			c.handleSignal(body.Signal)
+		case *snrendezvous.ServerMessage_Presence:
+			rec, err := identity.OpenRecord(body.Presence.Record)
+			if err != nil {
+				continue
+			}
+			select {
+			case c.presence <- PresenceEvent{Namespace: body.Presence.Namespace, Record: rec, Online: body.Presence.Online}:
+			default:
+			}
+		}
+	}
+}
+
+// handleSignal hands answers to their Exchange and queues all other signals.
+func (c *Client) handleSignal(s *snrendezvous.Signal) {
+	from, err := p2p.IDFromBytes(s.Peer)
+	if err != nil {
+		return
+	}
+	sig := Signal{From: from, Session: s.Session, Kind: Kind(s.Kind), Payload: s.Payload}
+
+	if sig.Kind == Answer {
+		c.mu.Lock()
+		ch := c.answers[sessionKey{peer: from, session: s.Session}]
+		c.mu.Unlock()
+		if ch != nil {
+			select {
+			case ch <- sig.Payload:
+			default:
+			}
+			return
+		}
+	}
+
+	select {
+	case c.signals <- sig:
+	default:
+	}
+}
+
+// closeErr returns the error that ended the connection.
+func (c *Client) closeErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
@@ -0,0 +1,17 @@
+package rendezvous
+
+import (
+	"context"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/web/wasmlib/wsjs"
+)
+
+// Dial connects to a rendezvous server over a WebSocket and registers the identity in namespaces.
+func Dial(ctx context.Context, url string, self *identity.Identity, namespaces ...string) (*Client, error) {
+	conn, err := wsjs.DialContext(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return Connect(ctx, conn, self, namespaces...)
+}
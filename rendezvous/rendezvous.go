@@ -0,0 +1,78 @@
+// Package rendezvous is the bootstrap path of browser nodes: a server where peers register their identity
+// in namespaces, discover each other, watch presence and relay signaling messages such as WebRTC offers,
+// answers and candidates to establish direct connections.
+//
+// Clients connect over any message connection, usually a WebSocket. The server opens with a challenge the
+// client signs with its identity key when registering, so peers cannot register under foreign peer IDs.
+package rendezvous
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrClosed is returned when using a client whose connection to the server has ended
+	ErrClosed = errors.New("rendezvous closed")
+	// ErrRegistrationFailed is returned when the server rejects a registration
+	ErrRegistrationFailed = errors.New("rendezvous registration failed")
+	// ErrPeerOffline is returned when signaling a peer that is not connected to the server
+	ErrPeerOffline = errors.New("peer not connected to rendezvous")
+	// ErrInvalidNamespace is returned for empty or overlong namespaces
+	ErrInvalidNamespace = errors.New("invalid rendezvous namespace")
+)
+
+const (
+	// maxNamespaceLen is the longest accepted namespace
+	maxNamespaceLen = 128
+	// registerSignaturePrefix domain-separates registration signatures
+	registerSignaturePrefix = "supernet-rendezvous-register:"
+)
+
+// Conn is a message connection between a client and the server.
+type Conn interface {
+	// NextMessage blocks until the next message is received or the connection is closed.
+	NextMessage() ([]byte, error)
+	// Send sends a single message.
+	Send(data []byte) error
+	// Close closes the connection.
+	Close() error
+}
+
+// Kind is the type of a signaling message.
+type Kind int
+
+const (
+	Offer     Kind = iota // Connection offer, e.g. a WebRTC session description
+	Answer                // Answer to an offer of the same session
+	Candidate             // Additional connectivity candidate of a session
+	Bye                   // Abandons a session
+)
+
+// String returns the lowercase name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case Offer:
+		return "offer"
+	case Answer:
+		return "answer"
+	case Candidate:
+		return "candidate"
+	case Bye:
+		return "bye"
+	}
+	return fmt.Sprintf("kind(%d)", int(k))
+}
+
+// checkNamespace validates a namespace.
+func checkNamespace(ns string) error {
+	if ns == "" || len(ns) > maxNamespaceLen {
+		return fmt.Errorf("%w: %q", ErrInvalidNamespace, ns)
+	}
+	return nil
+}
+
+// registerMessage returns the message signed to register against a challenge nonce.
+func registerMessage(nonce []byte) []byte {
+	return append([]byte(registerSignaturePrefix), nonce...)
+}
@@ -0,0 +1,217 @@
+//go:build !js
+
+package rendezvous
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/memtransport"
+	"pkg.gfire.dev/supernet/msgconn"
+	snrendezvous "pkg.gfire.dev/supernet/proto/snrendezvous/v1alpha1"
+)
+
+// testTimeout bounds every blocking call of a test
+const testTimeout = 10 * time.Second
+
+// newServer creates a server allowing requests as fast as tests poll.
+func newServer(cfg ServerConfig) *Server {
+	cfg.RequestRate = 1000
+	cfg.Logger = slog.New(slog.DiscardHandler)
+	return NewServer(cfg)
+}
+
+// serve connects a new in-memory connection to s and returns the client's end.
+func serve(t *testing.T, s *Server) *memtransport.Conn {
+	t.Helper()
+	client, server := memtransport.Pipe(memtransport.Config{})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go s.Serve(ctx, server)
+	return client
+}
+
+// newIdentity generates an identity.
+func newIdentity(t *testing.T) *identity.Identity {
+	t.Helper()
+	id, err := identity.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// connect registers a client with a new identity in namespaces, closed when the test ends.
+func connect(t *testing.T, s *Server, namespaces ...string) *Client {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	c, err := Connect(ctx, serve(t, s), newIdentity(t), namespaces...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// register runs the registration of record in namespaces by hand, answering the challenge with signer's key,
+// and returns the connection and the server's response.
+func register(t *testing.T, s *Server, signer *identity.Identity, record []byte, namespaces ...string) (*memtransport.Conn, *snrendezvous.ServerMessage) {
+	t.Helper()
+	conn := serve(t, s)
+	t.Cleanup(func() { conn.Close() })
+	challenge := &snrendezvous.ServerMessage{}
+	if err := msgconn.RecvVT(conn, challenge); err != nil {
+		t.Fatal(err)
+	}
+	if err := msgconn.SendVT(conn, &snrendezvous.ClientMessage{
+		RequestId: 1,
+		Body: &snrendezvous.ClientMessage_Register{Register: &snrendezvous.Register{
+			Record:     record,
+			Signature:  signer.Sign(registerMessage(challenge.GetChallenge().GetNonce())),
+			Namespaces: namespaces,
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp := &snrendezvous.ServerMessage{}
+	if err := msgconn.RecvVT(conn, resp); err != nil {
+		t.Fatal(err)
+	}
+	return conn, resp
+}
+
+// discover polls Discover until it lists want peers, since registrations complete at the server after the
+// client is answered.
+func discover(t *testing.T, c *Client, namespace string, limit, want int) []identity.ID {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	for {
+		records, err := c.Discover(ctx, namespace, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) == want {
+			ids := make([]identity.ID, len(records))
+			for i, rec := range records {
+				ids[i] = rec.ID
+			}
+			return ids
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("discovered %d peers in %q, want %d", len(records), namespace, want)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	s := newServer(ServerConfig{MaxNamespaces: 2})
+	observer := connect(t, s)
+	self := newIdentity(t)
+	record, err := self.NewRecord(nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range map[string]struct {
+		signer     *identity.Identity
+		namespaces []string
+		err        string
+	}{
+		"foreign key":         {signer: newIdentity(t), namespaces: []string{"room"}, err: ErrRegistrationFailed.Error()},
+		"too many namespaces": {signer: self, namespaces: []string{"a", "b", "c"}, err: errTooManyNamespaces.Error()},
+		"invalid namespace":   {signer: self, namespaces: []string{""}, err: ErrInvalidNamespace.Error()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, resp := register(t, s, tc.signer, record, tc.namespaces...); !strings.HasPrefix(resp.GetError().GetMessage(), tc.err) {
+				t.Fatalf("registration answered %v, want %q", resp, tc.err)
+			}
+		})
+	}
+	discover(t, observer, "room", 0, 0)
+
+	// A second registration of the same peer replaces the first
+	first, resp := register(t, s, self, record, "room")
+	if resp.GetOk() == nil {
+		t.Fatalf("registration answered %v", resp)
+	}
+	if ids := discover(t, observer, "room", 0, 1); ids[0] != self.ID() {
+		t.Fatalf("discovered %s, want %s", ids[0], self.ID())
+	}
+	if _, resp := register(t, s, self, record, "lobby"); resp.GetOk() == nil {
+		t.Fatalf("second registration answered %v", resp)
+	}
+	if _, err := first.NextMessage(); err == nil {
+		t.Fatal("replaced registration still connected")
+	}
+	discover(t, observer, "room", 0, 0)
+	discover(t, observer, "lobby", 0, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	if _, err := Connect(ctx, serve(t, s), self, "a", "b", "c"); !errors.Is(err, ErrRegistrationFailed) {
+		t.Fatalf("Connect in too many namespaces: %v, want ErrRegistrationFailed", err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	s := newServer(ServerConfig{DiscoverLimit: 3})
+	self := connect(t, s, "room")
+	var want []identity.ID
+	for range 4 {
+		want = append(want, connect(t, s, "room").self.ID())
+	}
+	connect(t, s, "other")
+
+	// The server caps the sample, and the client is never part of it
+	ids := discover(t, self, "room", 0, 3)
+	for _, id := range ids {
+		if !slices.Contains(want, id) {
+			t.Fatalf("discovered %s, which is not in the namespace", id)
+		}
+	}
+	discover(t, self, "room", 1, 1)
+	discover(t, self, "empty", 0, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	if _, err := self.Discover(ctx, "", 0); !errors.Is(err, ErrInvalidNamespace) {
+		t.Fatalf("Discover in an empty namespace: %v, want ErrInvalidNamespace", err)
+	}
+	self.Close()
+	<-self.Done()
+	if _, err := self.Discover(ctx, "room", 0); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Discover after Close: %v, want ErrClosed", err)
+	}
+}
+
+func TestRecordExpiry(t *testing.T) {
+	s := newServer(ServerConfig{})
+	observer := connect(t, s)
+	self := newIdentity(t)
+	const ttl = 200 * time.Millisecond
+	record, err := self.NewRecord(nil, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, resp := register(t, s, self, record, "room"); resp.GetOk() == nil {
+		t.Fatalf("registration answered %v", resp)
+	}
+	discover(t, observer, "room", 0, 1)
+
+	// Once its record expires the peer is no longer discovered, even though it is still connected
+	time.Sleep(ttl)
+	discover(t, observer, "room", 0, 0)
+	if _, resp := register(t, s, self, record, "room"); resp.GetError().GetMessage() != identity.ErrRecordExpired.Error() {
+		t.Fatalf("registration with an expired record answered %v", resp)
+	}
+}
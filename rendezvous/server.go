@@ -0,0 +1,472 @@
+//go:build !js
+
+package rendezvous
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"log/slog"
+	mrand "math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"golang.org/x/time/rate"
+
+	"pkg.gfire.dev/supernet/backplane"
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/logging"
+	"pkg.gfire.dev/supernet/msgconn"
+	"pkg.gfire.dev/supernet/p2p"
+	snrendezvous "pkg.gfire.dev/supernet/proto/snrendezvous/v1alpha1"
+)
+
+var (
+	// errRateLimited is reported to clients sending requests faster than allowed
+	errRateLimited = errors.New("rate limit exceeded")
+	// errTooManyNamespaces is reported to clients registering or watching too many namespaces
+	errTooManyNamespaces = errors.New("too many namespaces")
+	// errNotRegistered is reported to clients sending requests before registering
+	errNotRegistered = errors.New("not registered")
+)
+
+const (
+	// defaultMaxNamespaces is the default number of namespaces a client may register in and watch
+	defaultMaxNamespaces = 16
+	// defaultDiscoverLimit is the default maximum number of peers returned by Discover
+	defaultDiscoverLimit = 100
+	// defaultRequestRate is the default number of requests per second a client may send
+	defaultRequestRate = 20
+	// defaultRegisterTimeout is the default time a client has to register after connecting
+	defaultRegisterTimeout = 10 * time.Second
+	// outboxSize is the number of messages queued for a client before it is disconnected as too slow
+	outboxSize = 256
+	// maxMessageSize is the largest message accepted from clients
+	maxMessageSize = 64 << 10
+)
+
+// ServerConfig configures a Server.
+type ServerConfig struct {
+	// MaxNamespaces limits the namespaces a client may register in and watch (default 16).
+	MaxNamespaces int
+	// DiscoverLimit caps the number of peers returned by Discover (default 100).
+	DiscoverLimit int
+	// RequestRate limits the requests per second of a client, with bursts of the same size (default 20).
+	RequestRate float64
+	// RegisterTimeout is the time a client has to register after connecting (default 10s).
+	RegisterTimeout time.Duration
+	// AcceptOptions configures the WebSocket handshake, e.g. allowed origins.
+	AcceptOptions *websocket.AcceptOptions
+	// Backplane, when set, joins the servers sharing it behind a load balancer: signals reach peers registered
+	// at any of them, presence changes reach the watchers at all of them, and a peer registering at one server
+	// replaces its registration at the others. Discover only lists the peers registered at the same server.
+	Backplane backplane.Backplane
+	// Logger receives connection events. Defaults to logging.For("rendezvous").
+	Logger *slog.Logger
+}
+
+// Server is a rendezvous server. It is an http.Handler accepting clients over WebSockets.
+type Server struct {
+	cfg ServerConfig
+
+	mu sync.Mutex
+	// peers holds the registered clients by peer ID
+	peers map[identity.ID]*session
+	// namespaces holds the registered clients per namespace
+	namespaces map[string]map[identity.ID]*session
+	// watchers holds the clients watching each namespace
+	watchers map[string]map[*session]struct{}
+
+	// instance identifies the server on the backplane
+	instance []byte
+	// subscriptions holds the cancel functions of the backplane subscriptions by channel
+	subscriptions map[string]func()
+}
+
+// session is a registered client.
+type session struct {
+	conn       Conn
+	rec        *identity.Record
+	raw        []byte
+	namespaces []string
+	watching   []string
+	limiter    *rate.Limiter
+
+	// outbox queues messages for the writer goroutine
+	outbox chan *snrendezvous.ServerMessage
+	// done is closed when the session ends
+	done chan struct{}
+}
+
+// NewServer creates a rendezvous server.
+func NewServer(cfg ServerConfig) *Server {
+	if cfg.MaxNamespaces <= 0 {
+		cfg.MaxNamespaces = defaultMaxNamespaces
+	}
+	if cfg.DiscoverLimit <= 0 {
+		cfg.DiscoverLimit = defaultDiscoverLimit
+	}
+	if cfg.RequestRate <= 0 {
+		cfg.RequestRate = defaultRequestRate
+	}
+	if cfg.RegisterTimeout <= 0 {
+		cfg.RegisterTimeout = defaultRegisterTimeout
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logging.For("rendezvous")
+	}
+	instance := make([]byte, 16)
+	rand.Read(instance)
+	return &Server{
+		cfg:           cfg,
+		peers:         make(map[identity.ID]*session),
+		namespaces:    make(map[string]map[identity.ID]*session),
+		watchers:      make(map[string]map[*session]struct{}),
+		instance:      instance,
+		subscriptions: make(map[string]func()),
+	}
+}
+
+// ServeHTTP upgrades the request to a WebSocket and serves the client until it disconnects.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws, err := websocket.Accept(w, r, s.cfg.AcceptOptions)
+	if err != nil {
+		return
+	}
+	ws.SetReadLimit(maxMessageSize)
+	s.Serve(r.Context(), &wsConn{ctx: r.Context(), ws: ws})
+}
+
+// Serve serves a client over an established message connection until it disconnects or ctx is done.
+func (s *Server) Serve(ctx context.Context, conn Conn) {
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	sess, err := s.register(conn)
+	if err != nil {
+		s.cfg.Logger.Debug("rendezvous registration failed", "err", err)
+		return
+	}
+	log := s.cfg.Logger.With("peer", sess.rec.ID.ShortString())
+	log.Debug("rendezvous peer registered", "namespaces", sess.namespaces)
+	defer log.Debug("rendezvous peer left")
+
+	go s.write(sess)
+	defer s.unregister(sess)
+
+	for {
+		msg := &snrendezvous.ClientMessage{}
+		if err := msgconn.RecvVT(conn, msg); err != nil {
+			return
+		}
+		if !sess.limiter.Allow() {
+			sess.push(&snrendezvous.ServerMessage{RequestId: msg.RequestId, Body: errorBody(errRateLimited)})
+			continue
+		}
+		s.handle(sess, msg)
+	}
+}
+
+// register runs the challenge and registration of a new client and adds it to its namespaces.
+func (s *Server) register(conn Conn) (*session, error) {
+	timer := time.AfterFunc(s.cfg.RegisterTimeout, func() { conn.Close() })
+	defer timer.Stop()
+
+	nonce := make([]byte, 32)
+	rand.Read(nonce)
+	if err := msgconn.SendVT(conn, &snrendezvous.ServerMessage{Body: &snrendezvous.ServerMessage_Challenge{
+		Challenge: &snrendezvous.Challenge{Nonce: nonce},
+	}}); err != nil {
+		return nil, err
+	}
+
+	msg := &snrendezvous.ClientMessage{}
+	if err := msgconn.RecvVT(conn, msg); err != nil {
+		return nil, err
+	}
+	reply := func(err error) error {
+		msgconn.SendVT(conn, &snrendezvous.ServerMessage{RequestId: msg.RequestId, Body: errorBody(err)})
+		return err
+	}
+
+	reg := msg.GetRegister()
+	if reg == nil {
+		return nil, reply(errNotRegistered)
+	}
+	rec, err := identity.OpenRecord(reg.Record)
+	if err != nil {
+		return nil, reply(err)
+	}
+	if !identity.Verify(rec.PublicKey, registerMessage(nonce), reg.Signature) {
+		return nil, reply(ErrRegistrationFailed)
+	}
+	if len(reg.Namespaces) > s.cfg.MaxNamespaces {
+		return nil, reply(errTooManyNamespaces)
+	}
+	for _, ns := range reg.Namespaces {
+		if err := checkNamespace(ns); err != nil {
+			return nil, reply(err)
+		}
+	}
+
+	sess := &session{
+		conn:       conn,
+		rec:        rec,
+		raw:        reg.Record,
+		namespaces: reg.Namespaces,
+		limiter:    rate.NewLimiter(rate.Limit(s.cfg.RequestRate), int(max(s.cfg.RequestRate, 1))),
+		outbox:     make(chan *snrendezvous.ServerMessage, outboxSize),
+		done:       make(chan struct{}),
+	}
+	if err := msgconn.SendVT(conn, &snrendezvous.ServerMessage{
+		RequestId: msg.RequestId,
+		Body:      &snrendezvous.ServerMessage_Ok{Ok: &snrendezvous.Ok{}},
+	}); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	// A new registration of the same peer replaces the old one
+	old := s.peers[rec.ID]
+	s.peers[rec.ID] = sess
+	if old == nil {
+		s.attachPeer(rec.ID)
+	}
+	for _, ns := range sess.namespaces {
+		if s.namespaces[ns] == nil {
+			s.namespaces[ns] = make(map[identity.ID]*session)
+		}
+		s.namespaces[ns][rec.ID] = sess
+		s.announce(ns, sess, true)
+	}
+	s.mu.Unlock()
+
+	if old != nil {
+		old.conn.Close()
+	}
+	// Registrations at other servers are replaced as well
+	s.forward(peerChannel(rec.ID), nil)
+	for _, ns := range sess.namespaces {
+		s.forward(namespaceChannel(ns), presence(ns, sess, true))
+	}
+	return sess, nil
+}
+
+// unregister removes a session from the server and announces its departure.
+func (s *Server) unregister(sess *session) {
+	close(sess.done)
+
+	s.mu.Lock()
+	if s.peers[sess.rec.ID] == sess {
+		delete(s.peers, sess.rec.ID)
+		s.detach(peerChannel(sess.rec.ID))
+	}
+	var left []string
+	for _, ns := range sess.namespaces {
+		if s.namespaces[ns][sess.rec.ID] == sess {
+			delete(s.namespaces[ns], sess.rec.ID)
+			if len(s.namespaces[ns]) == 0 {
+				delete(s.namespaces, ns)
+			}
+			s.announce(ns, sess, false)
+			left = append(left, ns)
+		}
+	}
+	for _, ns := range sess.watching {
+		delete(s.watchers[ns], sess)
+		if len(s.watchers[ns]) == 0 {
+			delete(s.watchers, ns)
+			s.detach(namespaceChannel(ns))
+		}
+	}
+	s.mu.Unlock()
+
+	for _, ns := range left {
+		s.forward(namespaceChannel(ns), presence(ns, sess, false))
+	}
+}
+
+// handle answers a request of a registered client.
+func (s *Server) handle(sess *session, msg *snrendezvous.ClientMessage) {
+	var resp *snrendezvous.ServerMessage
+	switch body := msg.Body.(type) {
+	case *snrendezvous.ClientMessage_Discover:
+		resp = s.discover(sess, body.Discover)
+	case *snrendezvous.ClientMessage_Watch:
+		resp = s.watch(sess, body.Watch)
+	case *snrendezvous.ClientMessage_Signal:
+		resp = s.signal(sess, body.Signal)
+	default:
+		resp = failed(errors.New("unexpected request"))
+	}
+	resp.RequestId = msg.RequestId
+	sess.push(resp)
+}
+
+// discover returns a random sample of the peers registered in a namespace.
+func (s *Server) discover(sess *session, d *snrendezvous.Discover) *snrendezvous.ServerMessage {
+	limit := s.cfg.DiscoverLimit
+	if d.Limit > 0 && int(d.Limit) < limit {
+		limit = int(d.Limit)
+	}
+
+	s.mu.Lock()
+	records := make([][]byte, 0, len(s.namespaces[d.Namespace]))
+	for id, peer := range s.namespaces[d.Namespace] {
+		if id != sess.rec.ID {
+			records = append(records, peer.raw)
+		}
+	}
+	s.mu.Unlock()
+
+	mrand.Shuffle(len(records), func(i, j int) {
+		records[i], records[j] = records[j], records[i]
+	})
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	return &snrendezvous.ServerMessage{Body: &snrendezvous.ServerMessage_Peers{Peers: &snrendezvous.Peers{Records: records}}}
+}
+
+// watch subscribes a session to presence changes in a namespace.
+func (s *Server) watch(sess *session, w *snrendezvous.Watch) *snrendezvous.ServerMessage {
+	if err := checkNamespace(w.Namespace); err != nil {
+		return failed(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.watchers[w.Namespace][sess]; ok {
+		return okResponse()
+	}
+	if len(sess.watching) >= s.cfg.MaxNamespaces {
+		return failed(errTooManyNamespaces)
+	}
+	if s.watchers[w.Namespace] == nil {
+		s.watchers[w.Namespace] = make(map[*session]struct{})
+		s.attachNamespace(w.Namespace)
+	}
+	s.watchers[w.Namespace][sess] = struct{}{}
+	sess.watching = append(sess.watching, w.Namespace)
+	return okResponse()
+}
+
+// signal relays a signal to its recipient, replacing the recipient with the sender.
+func (s *Server) signal(sess *session, sig *snrendezvous.Signal) *snrendezvous.ServerMessage {
+	to, err := p2p.IDFromBytes(sig.Peer)
+	if err != nil {
+		return failed(err)
+	}
+
+	msg := &snrendezvous.ServerMessage{Body: &snrendezvous.ServerMessage_Signal{Signal: &snrendezvous.Signal{
+		Peer:    sess.rec.ID.Bytes(),
+		Session: sig.Session,
+		Kind:    sig.Kind,
+		Payload: sig.Payload,
+	}}}
+
+	s.mu.Lock()
+	peer := s.peers[to]
+	s.mu.Unlock()
+	if peer != nil {
+		peer.push(msg)
+		return okResponse()
+	}
+	if s.cfg.Backplane == nil {
+		return failed(ErrPeerOffline)
+	}
+	// The peer may be registered at another server
+	n, err := s.forward(peerChannel(to), msg)
+	if err != nil {
+		return failed(err)
+	}
+	if n == 0 {
+		return failed(ErrPeerOffline)
+	}
+	return okResponse()
+}
+
+// announce pushes a presence change to the watchers of a namespace. The caller must hold mu.
+func (s *Server) announce(ns string, sess *session, online bool) {
+	for w := range s.watchers[ns] {
+		if w == sess {
+			continue
+		}
+		w.push(presence(ns, sess, online))
+	}
+}
+
+// presence returns a Presence message announcing a change of a session in a namespace.
+func presence(ns string, sess *session, online bool) *snrendezvous.ServerMessage {
+	return &snrendezvous.ServerMessage{Body: &snrendezvous.ServerMessage_Presence{Presence: &snrendezvous.Presence{
+		Namespace: ns,
+		Record:    sess.raw,
+		Online:    online,
+	}}}
+}
+
+// write sends queued messages to the client until the session ends.
+func (s *Server) write(sess *session) {
+	for {
+		select {
+		case msg := <-sess.outbox:
+			if err := msgconn.SendVT(sess.conn, msg); err != nil {
+				sess.conn.Close()
+				return
+			}
+		case <-sess.done:
+			return
+		}
+	}
+}
+
+// push queues a message for the client, disconnecting it if it does not keep up.
+func (sess *session) push(msg *snrendezvous.ServerMessage) {
+	select {
+	case sess.outbox <- msg:
+	case <-sess.done:
+	default:
+		sess.conn.Close()
+	}
+}
+
+// errorBody returns an Error server message body.
+func errorBody(err error) *snrendezvous.ServerMessage_Error {
+	return &snrendezvous.ServerMessage_Error{Error: &snrendezvous.Error{Message: err.Error()}}
+}
+
+// okResponse returns an Ok response.
+func okResponse() *snrendezvous.ServerMessage {
+	return &snrendezvous.ServerMessage{Body: &snrendezvous.ServerMessage_Ok{Ok: &snrendezvous.Ok{}}}
+}
+
+// failed returns an Error response.
+func failed(err error) *snrendezvous.ServerMessage {
+	return &snrendezvous.ServerMessage{Body: errorBody(err)}
+}
+
+// wsConn adapts a WebSocket connection to Conn.
+type wsConn struct {
+	ctx context.Context
+	ws  *websocket.Conn
+}
+
+// NextMessage reads the next WebSocket message.
+func (c *wsConn) NextMessage() ([]byte, error) {
+	_, data, err := c.ws.Read(c.ctx)
+	return data, err
+}
+
+// Send writes a binary WebSocket message.
+func (c *wsConn) Send(data []byte) error {
+	return c.ws.Write(c.ctx, websocket.MessageBinary, data)
+}
+
+// Close closes the WebSocket with a normal closure status.
+func (c *wsConn) Close() error {
+	return c.ws.Close(websocket.StatusNormalClosure, "")
+}
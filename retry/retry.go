@@ -0,0 +1,303 @@
+// Package retry runs operations with exponential backoff and jitter. Policies bound retries by attempts,
+// elapsed time and an optional shared budget, and classify errors so permanent failures stop immediately.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExhausted is wrapped around the last error when a retry budget denies another attempt.
+var ErrBudgetExhausted = errors.New("retry budget exhausted")
+
+const (
+	// defaultInitial is the default delay before the first retry
+	defaultInitial = 100 * time.Millisecond
+	// defaultMax is the default upper bound of a single delay
+	defaultMax = 30 * time.Second
+	// defaultMultiplier is the default growth factor between delays
+	defaultMultiplier = 2
+	// defaultJitter is the default randomization fraction applied to every delay
+	defaultJitter = 0.2
+)
+
+// Class is the classification of an error.
+type Class int
+
+const (
+	// Retryable errors are retried according to the policy.
+	Retryable Class = iota
+	// Permanent errors stop retrying immediately.
+	Permanent
+)
+
+// Policy configures retries. The zero value retries forever with 100ms initial delay, doubling up to 30s,
+// with 20% jitter.
+type Policy struct {
+	// Initial is the delay before the first retry (default 100ms).
+	Initial time.Duration
+	// Max caps a single delay (default 30s).
+	Max time.Duration
+	// Multiplier is the growth factor between consecutive delays (default 2). Use 1 for a fixed interval.
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction in either direction (default 0.2). Use a negative
+	// value to disable jitter.
+	Jitter float64
+	// MaxAttempts limits the total number of attempts including the first. Zero means unlimited.
+	MaxAttempts int
+	// MaxElapsed limits the total time spent retrying. Zero means unlimited.
+	MaxElapsed time.Duration
+	// Budget, if set, is shared between operations and stops retries when too many of them fail.
+	Budget *Budget
+	// Classify decides whether an error is worth retrying. Errors marked with Stop and context errors
+	// are always permanent; by default everything else is retryable.
+	Classify func(err error) Class
+	// OnRetry is called before sleeping ahead of each retry, e.g. for logging.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// withDefaults fills in zero fields.
+func (p Policy) withDefaults() Policy {
+	if p.Initial <= 0 {
+		p.Initial = defaultInitial
+	}
+	if p.Max <= 0 {
+		p.Max = defaultMax
+	}
+	if p.Max < p.Initial {
+		p.Max = p.Initial
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaultMultiplier
+	}
+	if p.Jitter == 0 {
+		p.Jitter = defaultJitter
+	}
+	if p.Jitter < 0 {
+		p.Jitter = 0
+	}
+	if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+	return p
+}
+
+// Do calls fn until it succeeds, returns a permanent error, the policy gives up or ctx is done.
+// The last error from fn is returned; if ctx ends while waiting, ctx.Err() is returned.
+func Do(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	_, err := DoValue(ctx, p, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// DoValue is Do for operations returning a value.
+func DoValue[T any](ctx context.Context, p Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	b := p.Backoff()
+	for {
+		v, err := fn(ctx)
+		if err == nil {
+			if p.Budget != nil {
+				p.Budget.Success()
+			}
+			return v, nil
+		}
+		if p.classify(err) == Permanent {
+			return v, unwrapStop(err)
+		}
+		if p.Budget != nil && !p.Budget.Failure() {
+			return v, fmt.Errorf("%w: %w", ErrBudgetExhausted, err)
+		}
+
+		delay, ok := b.Next()
+		if !ok {
+			return v, err
+		}
+		if hint, ok := afterHint(err); ok {
+			delay = hint
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(b.Attempt(), err, delay)
+		}
+		if serr := sleep(ctx, delay); serr != nil {
+			return v, serr
+		}
+	}
+}
+
+// classify applies the built-in rules and the policy's Classify hook.
+func (p Policy) classify(err error) Class {
+	var stop *stopError
+	if errors.As(err, &stop) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return Permanent
+	}
+	if p.Classify != nil {
+		return p.Classify(err)
+	}
+	return Retryable
+}
+
+// Backoff returns a delay sequence following the policy, for loops that manage their own attempts such as
+// reconnect loops.
+func (p Policy) Backoff() *Backoff {
+	return &Backoff{policy: p.withDefaults(), start: time.Now()}
+}
+
+// Backoff produces successive retry delays. It is not safe for concurrent use.
+type Backoff struct {
+	policy  Policy
+	start   time.Time
+	attempt int
+}
+
+// Next returns the delay before the next attempt, or false when the policy's attempt or time limit is
+// reached.
+func (b *Backoff) Next() (time.Duration, bool) {
+	p := b.policy
+	b.attempt++
+	if p.MaxAttempts > 0 && b.attempt >= p.MaxAttempts {
+		return 0, false
+	}
+
+	delay := float64(p.Initial) * math.Pow(p.Multiplier, float64(b.attempt-1))
+	if delay > float64(p.Max) {
+		delay = float64(p.Max)
+	}
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	d := time.Duration(delay)
+
+	if p.MaxElapsed > 0 && time.Since(b.start)+d > p.MaxElapsed {
+		return 0, false
+	}
+	return d, true
+}
+
+// Wait sleeps for the next delay. It returns false when the policy gives up or ctx is done.
+func (b *Backoff) Wait(ctx context.Context) bool {
+	d, ok := b.Next()
+	if !ok {
+		return false
+	}
+	return sleep(ctx, d) == nil
+}
+
+// Attempt returns the number of delays produced so far.
+func (b *Backoff) Attempt() int {
+	return b.attempt
+}
+
+// Reset starts the sequence over, e.g. after a connection was established successfully.
+func (b *Backoff) Reset() {
+	b.attempt = 0
+	b.start = time.Now()
+}
+
+// Budget limits retries across many operations, following the gRPC retry throttling scheme: every failure
+// costs a token, every success refunds Ratio tokens, and retries are only allowed while more than half of
+// the tokens remain. This keeps a failing dependency from being hammered by retries.
+type Budget struct {
+	mu     sync.Mutex
+	max    float64
+	ratio  float64
+	tokens float64
+}
+
+// NewBudget creates a budget holding up to max tokens, refunding ratio tokens per success.
+func NewBudget(max, ratio float64) *Budget {
+	return &Budget{max: max, ratio: ratio, tokens: max}
+}
+
+// Failure records a failed attempt and reports whether a retry is allowed.
+func (b *Budget) Failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Max(b.tokens-1, 0)
+	return b.tokens > b.max/2
+}
+
+// Success records a successful attempt.
+func (b *Budget) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.tokens+b.ratio, b.max)
+}
+
+// Tokens returns the current number of tokens.
+func (b *Budget) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
+
+// stopError marks an error as permanent.
+type stopError struct {
+	err error
+}
+
+func (e *stopError) Error() string { return e.err.Error() }
+func (e *stopError) Unwrap() error { return e.err }
+
+// Stop marks err as permanent so Do returns it without retrying. Do returns the unwrapped error.
+func Stop(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &stopError{err: err}
+}
+
+// unwrapStop removes a Stop marker.
+func unwrapStop(err error) error {
+	if stop, ok := err.(*stopError); ok {
+		return stop.err
+	}
+	return err
+}
+
+// afterError carries a server-provided retry delay.
+type afterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *afterError) Error() string { return e.err.Error() }
+func (e *afterError) Unwrap() error { return e.err }
+
+// After annotates err with the delay to wait before retrying, overriding the backoff, e.g. from an HTTP
+// Retry-After header.
+func After(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &afterError{err: err, delay: delay}
+}
+
+// afterHint extracts a delay set with After.
+func afterHint(err error) (time.Duration, bool) {
+	var after *afterError
+	if errors.As(err, &after) {
+		return after.delay, true
+	}
+	return 0, false
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
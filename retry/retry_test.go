@@ -0,0 +1,158 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+var errFailed = errors.New("failed")
+
+// noWait is a policy whose backoff would outlast any test, so tests only pass if no delay is slept.
+var noWait = Policy{Initial: time.Hour, Jitter: -1}
+
+func TestMaxAttempts(t *testing.T) {
+	p := noWait
+	p.MaxAttempts = 3
+	attempts := 0
+	err := Do(context.Background(), p, func(ctx context.Context) error {
+		attempts++
+		// A zero delay keeps the test from waiting on the backoff
+		return After(errFailed, 0)
+	})
+	if !errors.Is(err, errFailed) || attempts != 3 {
+		t.Fatalf("Do: %v after %d attempts, want errFailed after 3", err, attempts)
+	}
+}
+
+func TestMaxElapsed(t *testing.T) {
+	p := noWait
+	p.MaxElapsed = time.Minute
+	attempts := 0
+	err := Do(context.Background(), p, func(ctx context.Context) error {
+		attempts++
+		return errFailed
+	})
+	// The first delay would exceed MaxElapsed, so Do gives up instead of sleeping
+	if err != errFailed || attempts != 1 {
+		t.Fatalf("Do: %v after %d attempts, want errFailed after 1", err, attempts)
+	}
+}
+
+func TestAfter(t *testing.T) {
+	p := noWait
+	var delays []time.Duration
+	p.OnRetry = func(attempt int, err error, delay time.Duration) {
+		delays = append(delays, delay)
+	}
+	attempts := 0
+	v, err := DoValue(context.Background(), p, func(ctx context.Context) (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, After(errFailed, time.Duration(attempts)*time.Millisecond)
+		}
+		return attempts, nil
+	})
+	if err != nil || v != 3 {
+		t.Fatalf("DoValue: %d, %v, want 3", v, err)
+	}
+	if len(delays) != 2 || delays[0] != time.Millisecond || delays[1] != 2*time.Millisecond {
+		t.Fatalf("delays %v, want the hints 1ms and 2ms", delays)
+	}
+	if After(nil, time.Second) != nil {
+		t.Fatal("After annotated a nil error")
+	}
+}
+
+func TestContextErrors(t *testing.T) {
+	for name, err := range map[string]error{
+		"canceled": context.Canceled,
+		"deadline": context.DeadlineExceeded,
+		"wrapped":  fmt.Errorf("dial: %w", context.DeadlineExceeded),
+		"after":    After(context.Canceled, 0),
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := noWait
+			// Not even a classifier retrying everything retries context errors
+			p.Classify = func(error) Class { return Retryable }
+			attempts := 0
+			got := Do(context.Background(), p, func(ctx context.Context) error {
+				attempts++
+				return err
+			})
+			if got != err || attempts != 1 {
+				t.Fatalf("Do: %v after %d attempts, want %v after 1", got, attempts, err)
+			}
+		})
+	}
+
+	// Cancelling the context interrupts the backoff
+	ctx, cancel := context.WithCancel(context.Background())
+	p := noWait
+	p.OnRetry = func(int, error, time.Duration) { cancel() }
+	if err := Do(ctx, p, func(ctx context.Context) error { return errFailed }); err != context.Canceled {
+		t.Fatalf("Do: %v, want context.Canceled", err)
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	attempts := 0
+	err := Do(context.Background(), noWait, func(ctx context.Context) error {
+		attempts++
+		return Stop(errFailed)
+	})
+	if err != errFailed || attempts != 1 {
+		t.Fatalf("Do: %v after %d attempts, want errFailed after 1", err, attempts)
+	}
+
+	p := noWait
+	p.Classify = func(err error) Class { return Permanent }
+	if err := Do(context.Background(), p, func(ctx context.Context) error { return errFailed }); err != errFailed {
+		t.Fatalf("Do: %v, want errFailed", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	b := Policy{Initial: 100 * time.Millisecond, Max: time.Second, Jitter: -1, MaxAttempts: 7}.Backoff()
+	for i, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		if d, ok := b.Next(); !ok || d != want*time.Millisecond {
+			t.Fatalf("delay %d: %v, %v, want %v", i, d, ok, want*time.Millisecond)
+		}
+	}
+	if _, ok := b.Next(); ok {
+		t.Fatal("delay beyond MaxAttempts")
+	}
+	b.Reset()
+	if d, ok := b.Next(); !ok || d != 100*time.Millisecond {
+		t.Fatalf("delay after Reset: %v, %v", d, ok)
+	}
+
+	b = Policy{Initial: time.Second}.Backoff()
+	for range 100 {
+		if d, _ := b.Next(); d < 800*time.Millisecond || d > 1200*time.Millisecond {
+			t.Fatalf("jittered delay %v beyond 20%% of 1s", d)
+		}
+		b.Reset()
+	}
+}
+
+func TestBudget(t *testing.T) {
+	budget := NewBudget(4, 1)
+	p := noWait
+	p.Budget = budget
+	attempts := 0
+	err := Do(context.Background(), p, func(ctx context.Context) error {
+		attempts++
+		return After(errFailed, 0)
+	})
+	// Retries stop once half of the tokens are spent
+	if !errors.Is(err, ErrBudgetExhausted) || !errors.Is(err, errFailed) || attempts != 2 {
+		t.Fatalf("Do: %v after %d attempts, want ErrBudgetExhausted after 2", err, attempts)
+	}
+	budget.Success()
+	if tokens := budget.Tokens(); tokens != 3 {
+		t.Fatalf("%v tokens after a success, want 3", tokens)
+	}
+}
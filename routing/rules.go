@@ -0,0 +1,249 @@
+// Package routing selects a transport or relay for an outgoing connection from an ordered list of rules,
+// in the spirit of proxy auto-config files. Rules match on destination host names, IP ranges and ports, and
+// can be loaded from JSON so deployments can change routing without code changes.
+package routing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrRejected is returned by dialers for destinations routed to a reject rule
+	ErrRejected = errors.New("destination rejected by routing rules")
+	// ErrInvalidRule is returned when a rule cannot be parsed
+	ErrInvalidRule = errors.New("invalid routing rule")
+)
+
+// Direct is the transport name of a plain connection without relay.
+const Direct = "direct"
+
+// Route is the outcome of a routing decision.
+type Route struct {
+	// Transport names the transport used to reach the destination or relay, Direct when empty.
+	Transport string `json:"transport,omitempty"`
+	// Relay names the relay the connection is tunneled through, empty for a direct connection.
+	Relay string `json:"relay,omitempty"`
+	// Reject refuses the connection.
+	Reject bool `json:"reject,omitempty"`
+}
+
+// String returns a short description such as "direct", "relay a via ws" or "reject".
+func (r Route) String() string {
+	if r.Reject {
+		return "reject"
+	}
+	transport := r.Transport
+	if transport == "" {
+		transport = Direct
+	}
+	if r.Relay != "" {
+		return "relay " + r.Relay + " via " + transport
+	}
+	return transport
+}
+
+// Rule routes destinations matching all of its conditions.
+// A rule matches a destination if its host matches any of Domains or CIDRs (or both lists are empty) and
+// its port matches any of Ports (or Ports is empty).
+type Rule struct {
+	// Name identifies the rule in logs, optional.
+	Name string `json:"name,omitempty"`
+	// Domains are host name patterns: "example.com" matches only that name, "*.example.com" matches
+	// subdomains and ".example.com" matches the name and its subdomains. "*" matches every host.
+	Domains []string `json:"domains,omitempty"`
+	// CIDRs are IP prefixes such as "10.0.0.0/8" matched against IP literal destinations.
+	// Host names are not resolved.
+	CIDRs []string `json:"cidrs,omitempty"`
+	// Ports are single ports ("443") or inclusive ranges ("8000-8999").
+	Ports []string `json:"ports,omitempty"`
+
+	Route
+
+	// prefixes holds the parsed CIDRs
+	prefixes []netip.Prefix
+	// ports holds the parsed port ranges
+	ports [][2]uint16
+}
+
+// Rules is an ordered rule list. The first matching rule decides; Default applies when none matches.
+type Rules struct {
+	Rules   []*Rule `json:"rules"`
+	Default Route   `json:"default"`
+}
+
+// New compiles the given rules, returning an error wrapping ErrInvalidRule for malformed conditions.
+func New(def Route, rules ...*Rule) (*Rules, error) {
+	rs := &Rules{Rules: rules, Default: def}
+	if err := rs.compile(); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// Parse reads rules from JSON of the form
+//
+//	{"rules": [{"domains": ["*.corp.example"], "cidrs": ["10.0.0.0/8"], "relay": "a"}],
+//	 "default": {"transport": "direct"}}
+func Parse(r io.Reader) (*Rules, error) {
+	var rs Rules
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rs); err != nil {
+		return nil, fmt.Errorf("routing: %w", err)
+	}
+	if err := rs.compile(); err != nil {
+		return nil, err
+	}
+	return &rs, nil
+}
+
+// Load reads rules from a JSON file.
+func Load(path string) (*Rules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// compile parses the CIDR and port conditions of every rule.
+func (rs *Rules) compile() error {
+	for i, r := range rs.Rules {
+		if r == nil {
+			return fmt.Errorf("%w: rule %d is empty", ErrInvalidRule, i)
+		}
+		if err := r.compile(); err != nil {
+			name := r.Name
+			if name == "" {
+				name = strconv.Itoa(i)
+			}
+			return fmt.Errorf("%w: rule %s: %v", ErrInvalidRule, name, err)
+		}
+	}
+	return nil
+}
+
+// compile parses the CIDR and port conditions of the rule.
+func (r *Rule) compile() error {
+	r.prefixes = r.prefixes[:0]
+	for _, s := range r.CIDRs {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			// Accept bare addresses as single-host prefixes
+			addr, aerr := netip.ParseAddr(s)
+			if aerr != nil {
+				return err
+			}
+			p = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		r.prefixes = append(r.prefixes, p.Masked())
+	}
+
+	r.ports = r.ports[:0]
+	for _, s := range r.Ports {
+		lo, hi, isRange := strings.Cut(s, "-")
+		first, err := strconv.ParseUint(strings.TrimSpace(lo), 10, 16)
+		if err != nil {
+			return fmt.Errorf("port %q: %v", s, err)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.ParseUint(strings.TrimSpace(hi), 10, 16); err != nil {
+				return fmt.Errorf("port %q: %v", s, err)
+			}
+		}
+		if last < first {
+			return fmt.Errorf("port range %q is reversed", s)
+		}
+		r.ports = append(r.ports, [2]uint16{uint16(first), uint16(last)})
+	}
+	return nil
+}
+
+// Match reports whether the rule applies to the destination host and port.
+func (r *Rule) Match(host string, port uint16) bool {
+	return r.matchHost(host) && r.matchPort(port)
+}
+
+// matchHost checks the domain and CIDR conditions.
+func (r *Rule) matchHost(host string) bool {
+	if len(r.Domains) == 0 && len(r.prefixes) == 0 {
+		return true
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		addr = addr.Unmap()
+		for _, p := range r.prefixes {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+	}
+
+	for _, pattern := range r.Domains {
+		if matchDomain(strings.ToLower(pattern), host) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPort checks the port condition.
+func (r *Rule) matchPort(port uint16) bool {
+	if len(r.ports) == 0 {
+		return true
+	}
+	for _, pr := range r.ports {
+		if port >= pr[0] && port <= pr[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDomain matches a host name against a single domain pattern.
+func matchDomain(pattern, host string) bool {
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasPrefix(pattern, "*."):
+		return strings.HasSuffix(host, pattern[1:])
+	case strings.HasPrefix(pattern, "."):
+		return host == pattern[1:] || strings.HasSuffix(host, pattern)
+	default:
+		return host == pattern
+	}
+}
+
+// Route returns the route for a destination host and port.
+func (rs *Rules) Route(host string, port uint16) Route {
+	if rs == nil {
+		return Route{}
+	}
+	for _, r := range rs.Rules {
+		if r.Match(host, port) {
+			return r.Route
+		}
+	}
+	return rs.Default
+}
+
+// RouteAddr returns the route for a "host:port" address. Addresses without a valid port match as port 0.
+func (rs *Rules) RouteAddr(addr string) Route {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return rs.Route(addr, 0)
+	}
+	port, _ := strconv.ParseUint(portStr, 10, 16)
+	return rs.Route(host, uint16(port))
+}
@@ -0,0 +1,273 @@
+package secure
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/msgconn"
+	"pkg.gfire.dev/supernet/noise"
+)
+
+const (
+	// recordHeaderSize is the size of the record type and length preceding every record payload
+	recordHeaderSize = 3
+	// noiseTagSize is the size of the authentication tag of a Noise transport message
+	noiseTagSize = 16
+	// MaxMessage is the largest message Send accepts; Write splits larger writes into several records.
+	// A record always fits into a single Noise transport message.
+	MaxMessage = noise.MaxMessageSize - noiseTagSize - recordHeaderSize
+)
+
+// recordType distinguishes application data from in-band control records.
+type recordType byte
+
+const (
+	recordData  recordType = iota // Application data
+	recordRekey                   // The sender rotated its key; everything after is encrypted with the next key
+)
+
+// Conn is an established secure channel. It is a net.Conn for stream use and also implements the
+// NextMessage/Send/Close message contract; the two styles must not be mixed on one connection.
+type Conn struct {
+	// rwc is the underlying connection
+	rwc io.ReadWriteCloser
+	// protocol is the protocol the channel runs
+	protocol Protocol
+	// remote is the authenticated peer record of the other end
+	remote *identity.Record
+	// binding is a value unique to the session, derived from the handshake
+	binding []byte
+	// noise is the Noise transport, nil for TLS channels
+	noise *noise.Conn
+	// tls is the TLS transport, nil for Noise channels
+	tls *tls.Conn
+	// rekeyBytes and rekeyInterval trigger rotation of the sending key
+	rekeyBytes    int64
+	rekeyInterval time.Duration
+
+	readMu  sync.Mutex
+	in      []byte // Record buffer, reused across reads
+	readBuf []byte // Unread remainder of the current record for Read
+
+	writeMu   sync.Mutex
+	out       []byte    // Record buffer, reused across writes
+	sent      int64     // Bytes sent since the last rekey
+	rekeyedAt time.Time // Time of the last rekey
+}
+
+// newConn returns a channel over an established transport.
+func newConn(rwc io.ReadWriteCloser, cfg Config, remote *identity.Record, binding []byte) *Conn {
+	return &Conn{
+		rwc:           rwc,
+		remote:        remote,
+		binding:       binding,
+		rekeyBytes:    cfg.RekeyBytes,
+		rekeyInterval: cfg.RekeyInterval,
+		in:            make([]byte, MaxMessage),
+		rekeyedAt:     time.Now(),
+	}
+}
+
+// Protocol returns the protocol the channel runs.
+func (c *Conn) Protocol() Protocol {
+	return c.protocol
+}
+
+// RemoteID returns the authenticated peer ID of the other end.
+func (c *Conn) RemoteID() identity.ID {
+	return c.remote.ID
+}
+
+// RemoteRecord returns the authenticated peer record of the other end.
+func (c *Conn) RemoteRecord() *identity.Record {
+	return c.remote
+}
+
+// ChannelBinding returns a value unique to the session, e.g. to bind application-level tokens to it.
+func (c *Conn) ChannelBinding() []byte {
+	return c.binding
+}
+
+// Read reads application data, moving on to the next record once the current one is exhausted.
+func (c *Conn) Read(p []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	for len(c.readBuf) == 0 {
+		payload, err := c.readRecord()
+		if err != nil {
+			return 0, err
+		}
+		c.readBuf = payload
+	}
+	n := copy(p, c.readBuf)
+	c.readBuf = c.readBuf[n:]
+	return n, nil
+}
+
+// NextMessage blocks until the next message sent with Send is received.
+func (c *Conn) NextMessage() ([]byte, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	payload, err := c.readRecord()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{}, payload...), nil
+}
+
+// Write sends p in records of at most MaxMessage bytes.
+func (c *Conn) Write(p []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	n := 0
+	for n < len(p) {
+		chunk := p[n:]
+		if len(chunk) > MaxMessage {
+			chunk = chunk[:MaxMessage]
+		}
+		if err := c.writeRecord(recordData, chunk); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+	}
+	return n, nil
+}
+
+// Send sends data as a single message of at most MaxMessage bytes.
+func (c *Conn) Send(data []byte) error {
+	if len(data) > MaxMessage {
+		return ErrMessageTooLarge
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.writeRecord(recordData, data)
+}
+
+// Close closes the channel and the underlying connection.
+func (c *Conn) Close() error {
+	if c.tls != nil {
+		return c.tls.Close()
+	}
+	return c.rwc.Close()
+}
+
+// readRecord reads records until one carries application data and returns its payload, which is valid
+// until the next call. Must be called with readMu held.
+func (c *Conn) readRecord() ([]byte, error) {
+	r := c.transport()
+	for {
+		var header [recordHeaderSize]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, err
+		}
+		size := int(binary.BigEndian.Uint16(header[1:]))
+		if size > MaxMessage {
+			return nil, fmt.Errorf("%w: record of %d bytes", ErrProtocol, size)
+		}
+		payload := c.in[:size]
+		if _, err := io.ReadFull(r, payload); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		switch recordType(header[0]) {
+		case recordData:
+			return payload, nil
+		case recordRekey:
+			// The rekey record ends its Noise message, so nothing was decrypted with the old key beyond it
+			if c.noise == nil || size != 0 {
+				return nil, fmt.Errorf("%w: unexpected rekey record", ErrProtocol)
+			}
+			c.noise.RekeyRecv()
+		default:
+			return nil, fmt.Errorf("%w: unknown record type %d", ErrProtocol, header[0])
+		}
+	}
+}
+
+// writeRecord writes one record in a single transport write and rotates the sending key when due.
+// Must be called with writeMu held.
+func (c *Conn) writeRecord(typ recordType, payload []byte) error {
+	out := append(c.out[:0], byte(typ), 0, 0)
+	binary.BigEndian.PutUint16(out[1:], uint16(len(payload)))
+	out = append(out, payload...)
+	c.out = out
+	if _, err := c.transport().Write(out); err != nil {
+		return err
+	}
+
+	if c.noise == nil || typ != recordData {
+		return nil
+	}
+	c.sent += int64(len(payload))
+	if c.sent < c.rekeyBytes && time.Since(c.rekeyedAt) < c.rekeyInterval {
+		return nil
+	}
+	if err := c.writeRecord(recordRekey, nil); err != nil {
+		return err
+	}
+	c.noise.Rekey()
+	c.sent, c.rekeyedAt = 0, time.Now()
+	return nil
+}
+
+// transport returns the encrypting transport.
+func (c *Conn) transport() io.ReadWriter {
+	if c.tls != nil {
+		return c.tls
+	}
+	return c.noise
+}
+
+// LocalAddr returns the local address of the underlying connection, if it is a net.Conn.
+func (c *Conn) LocalAddr() net.Addr {
+	if nc, ok := c.rwc.(net.Conn); ok {
+		return nc.LocalAddr()
+	}
+	return msgconn.Addr{Net: "secure"}
+}
+
+// RemoteAddr returns the remote address of the underlying connection, if it is a net.Conn, otherwise the
+// remote peer ID.
+func (c *Conn) RemoteAddr() net.Addr {
+	if nc, ok := c.rwc.(net.Conn); ok {
+		return nc.RemoteAddr()
+	}
+	return msgconn.Addr{Net: "secure", Addr: c.remote.ID.String()}
+}
+
+// SetDeadline sets the read and write deadlines of the underlying connection, if it is a net.Conn.
+func (c *Conn) SetDeadline(t time.Time) error {
+	if nc, ok := c.rwc.(net.Conn); ok {
+		return nc.SetDeadline(t)
+	}
+	return os.ErrNoDeadline
+}
+
+// SetReadDeadline sets the read deadline of the underlying connection, if it is a net.Conn.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	if nc, ok := c.rwc.(net.Conn); ok {
+		return nc.SetReadDeadline(t)
+	}
+	return os.ErrNoDeadline
+}
+
+// SetWriteDeadline sets the write deadline of the underlying connection, if it is a net.Conn.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	if nc, ok := c.rwc.(net.Conn); ok {
+		return nc.SetWriteDeadline(t)
+	}
+	return os.ErrNoDeadline
+}
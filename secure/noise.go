@@ -0,0 +1,63 @@
+package secure
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/noise"
+)
+
+// noiseHandshake runs a Noise handshake in which both sides send their signed peer record as payload.
+func noiseHandshake(ctx context.Context, rwc io.ReadWriteCloser, cfg Config, m mode, initiator bool) (*Conn, error) {
+	record, err := cfg.Identity.NewRecord(nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var remote *identity.Record
+	ncfg := noise.Config{
+		Pattern:   noise.XX,
+		StaticKey: cfg.Identity.StaticKey(),
+		// The announced mode is authenticated along with the handshake, so it cannot be downgraded
+		Prologue: append(append([]byte(nil), magic...), byte(m)),
+		Payload:  record,
+		VerifyPeer: func(remoteStatic, payload []byte) error {
+			rec, err := identity.OpenRecord(payload)
+			if err != nil {
+				return err
+			}
+			// The record binds the handshake key to the peer's identity
+			if !bytes.Equal(rec.StaticKey.Bytes(), remoteStatic) {
+				return ErrPeerMismatch
+			}
+			if err := cfg.verify(rec); err != nil {
+				return err
+			}
+			remote = rec
+			return nil
+		},
+	}
+	if m == modeNoiseIK {
+		ncfg.Pattern = noise.IK
+		if initiator {
+			ncfg.RemoteStatic = cfg.RemoteRecord.StaticKey.Bytes()
+		}
+	}
+
+	var conn *noise.Conn
+	if initiator {
+		conn, err = noise.Client(ctx, rwc, ncfg)
+	} else {
+		conn, err = noise.Server(ctx, rwc, ncfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := newConn(rwc, cfg, remote, conn.HandshakeHash())
+	c.protocol = Noise
+	c.noise = conn
+	return c, nil
+}
@@ -0,0 +1,238 @@
+// Package secure wraps overlay connections in an end-to-end encrypted channel bound to peer identities.
+// The same layer runs over every transport: stream connections are used directly, while message
+// connections such as wsjs.Conn, WebTransport streams, webrtcjs.DataChannel or mux.Stream are adapted with
+// msgconn.NetConn (Transport does this for transport.DialFunc).
+//
+// Two protocols are available. Noise (the default) runs XX, or IK when the initiator already knows the
+// responder's peer record; both sides send their signed peer record as handshake payload, and the record
+// must carry the static key used in the handshake. TLS runs TLS 1.3 with self-signed certificates whose key
+// is the identity's Ed25519 key and which embed the signed peer record. In both cases the remote peer ID is
+// derived from the authenticated key, never from claims in the data stream.
+//
+// Noise channels rotate their keys in-band after Config.RekeyBytes bytes or Config.RekeyInterval; TLS
+// relies on TLS 1.3 key updates. Every transport message is authenticated with an implicit, strictly
+// increasing nonce, so replayed, reordered or dropped messages fail decryption and terminate the channel,
+// and the fresh ephemeral keys of every handshake keep messages of one session from being replayed into
+// another.
+package secure
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/msgconn"
+	"pkg.gfire.dev/supernet/transport"
+)
+
+var (
+	// ErrPeerMismatch is returned when the peer record does not match the key authenticated by the handshake
+	ErrPeerMismatch = errors.New("secure: peer record does not match handshake key")
+	// ErrUnexpectedPeer is returned when the remote peer is not the one that was expected
+	ErrUnexpectedPeer = errors.New("secure: unexpected remote peer")
+	// ErrUnsupportedProtocol is returned when the peers do not agree on a protocol
+	ErrUnsupportedProtocol = errors.New("secure: unsupported protocol")
+	// ErrProtocol is returned when the remote peer violates the channel protocol
+	ErrProtocol = errors.New("secure: protocol violation")
+	// ErrMessageTooLarge is returned by Send for messages exceeding MaxMessage
+	ErrMessageTooLarge = errors.New("secure: message too large")
+)
+
+const (
+	// defaultRekeyBytes is the default amount of data sent before the sending key is rotated
+	defaultRekeyBytes = 256 << 20
+	// defaultRekeyInterval is the default time after which the sending key is rotated
+	defaultRekeyInterval = 10 * time.Minute
+)
+
+// Protocol selects the handshake and record protocol.
+type Protocol int
+
+const (
+	// Noise runs a Noise XX or IK handshake with the identity's X25519 static key.
+	Noise Protocol = iota
+	// TLS runs TLS 1.3 with a certificate signed by the identity's Ed25519 key.
+	TLS
+)
+
+// String returns the protocol name.
+func (p Protocol) String() string {
+	switch p {
+	case Noise:
+		return "noise"
+	case TLS:
+		return "tls"
+	default:
+		return "unknown"
+	}
+}
+
+// Config configures a secure channel.
+type Config struct {
+	// Identity authenticates the local side; required.
+	Identity *identity.Identity
+	// Protocol selects the protocol a client uses. Servers accept every protocol.
+	Protocol Protocol
+	// Remote is the peer a client expects; zero accepts any authenticated peer.
+	Remote identity.ID
+	// RemoteRecord is the peer record of the server, if known. Noise clients use it for a two-message IK
+	// handshake, and it implies Remote.
+	RemoteRecord *identity.Record
+	// VerifyPeer is called with the authenticated record of the remote peer; returning an error aborts the
+	// handshake, e.g. to apply an allow list.
+	VerifyPeer func(*identity.Record) error
+	// RekeyBytes is the amount of data after which a Noise channel rotates its sending key. Defaults to 256 MiB.
+	RekeyBytes int64
+	// RekeyInterval is the time after which a Noise channel rotates its sending key at the next write.
+	// Defaults to 10 minutes.
+	RekeyInterval time.Duration
+}
+
+// mode is the first byte a client sends, announcing the handshake that follows.
+type mode byte
+
+const (
+	modeNoiseXX mode = 'X'
+	modeNoiseIK mode = 'K'
+	modeTLS     mode = 'T'
+)
+
+// magic starts every channel so mismatched endpoints fail fast; the version is part of it.
+var magic = []byte("snsc/1")
+
+// Client establishes a channel as initiator over rwc.
+// If ctx is cancelled before the handshake completes, rwc is closed.
+func Client(ctx context.Context, rwc io.ReadWriteCloser, cfg Config) (*Conn, error) {
+	cfg, err := cfg.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	var m mode
+	switch {
+	case cfg.Protocol == TLS:
+		m = modeTLS
+	case cfg.Protocol != Noise:
+		return nil, ErrUnsupportedProtocol
+	case cfg.RemoteRecord != nil:
+		m = modeNoiseIK
+	default:
+		m = modeNoiseXX
+	}
+
+	header := append(append([]byte(nil), magic...), byte(m))
+	err = withContext(ctx, rwc, func() error {
+		_, err := rwc.Write(header)
+		return err
+	})
+	if err != nil {
+		rwc.Close()
+		return nil, err
+	}
+	return handshake(ctx, rwc, cfg, m, true)
+}
+
+// Server establishes a channel as responder over rwc, using whichever protocol the client selected.
+// If ctx is cancelled before the handshake completes, rwc is closed.
+func Server(ctx context.Context, rwc io.ReadWriteCloser, cfg Config) (*Conn, error) {
+	cfg, err := cfg.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, len(magic)+1)
+	err = withContext(ctx, rwc, func() error {
+		_, err := io.ReadFull(rwc, header)
+		return err
+	})
+	if err != nil {
+		rwc.Close()
+		return nil, err
+	}
+	if !bytes.Equal(header[:len(magic)], magic) {
+		rwc.Close()
+		return nil, ErrUnsupportedProtocol
+	}
+	return handshake(ctx, rwc, cfg, mode(header[len(magic)]), false)
+}
+
+// handshake runs the handshake announced by m for either role.
+func handshake(ctx context.Context, rwc io.ReadWriteCloser, cfg Config, m mode, initiator bool) (*Conn, error) {
+	var (
+		c   *Conn
+		err error
+	)
+	switch m {
+	case modeNoiseXX, modeNoiseIK:
+		c, err = noiseHandshake(ctx, rwc, cfg, m, initiator)
+	case modeTLS:
+		c, err = tlsHandshake(ctx, rwc, cfg, initiator)
+	default:
+		err = ErrUnsupportedProtocol
+	}
+	if err != nil {
+		rwc.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// withDefaults validates cfg and fills in defaults.
+func (cfg Config) withDefaults() (Config, error) {
+	if cfg.Identity == nil {
+		return cfg, errors.New("secure: Config.Identity is required")
+	}
+	if cfg.RemoteRecord != nil {
+		if !cfg.Remote.IsZero() && cfg.Remote != cfg.RemoteRecord.ID {
+			return cfg, fmt.Errorf("%w: Config.Remote does not match Config.RemoteRecord", ErrUnexpectedPeer)
+		}
+		cfg.Remote = cfg.RemoteRecord.ID
+	}
+	if cfg.RekeyBytes <= 0 {
+		cfg.RekeyBytes = defaultRekeyBytes
+	}
+	if cfg.RekeyInterval <= 0 {
+		cfg.RekeyInterval = defaultRekeyInterval
+	}
+	return cfg, nil
+}
+
+// verify checks an authenticated remote record against the configuration.
+func (cfg Config) verify(rec *identity.Record) error {
+	if !cfg.Remote.IsZero() && rec.ID != cfg.Remote {
+		return ErrUnexpectedPeer
+	}
+	if cfg.VerifyPeer != nil {
+		return cfg.VerifyPeer(rec)
+	}
+	return nil
+}
+
+// withContext runs f, closing rwc to abort it when ctx is cancelled.
+func withContext(ctx context.Context, rwc io.Closer, f func() error) error {
+	stop := context.AfterFunc(ctx, func() { rwc.Close() })
+	defer stop()
+	if err := f(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// Transport wraps dial so that every connection it establishes is secured as a client with cfg, e.g. to
+// apply the same channel to WebTransport, WebSocket and SSE connections of a transport.Manager.
+func Transport(dial transport.DialFunc, cfg Config) transport.DialFunc {
+	return func(ctx context.Context) (transport.Conn, error) {
+		conn, err := dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return Client(ctx, msgconn.NetConn(conn, msgconn.Options{}), cfg)
+	}
+}
@@ -0,0 +1,246 @@
+package secure
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/memtransport"
+	"pkg.gfire.dev/supernet/noise"
+)
+
+// testTimeout bounds every handshake of a test
+const testTimeout = 10 * time.Second
+
+// newIdentity returns a new identity and its peer record.
+func newIdentity(t *testing.T) (*identity.Identity, *identity.Record) {
+	t.Helper()
+	id, err := identity.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := id.NewRecord(nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := identity.OpenRecord(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id, rec
+}
+
+// pipe returns the two ends of a buffered in-memory stream.
+func pipe() (net.Conn, net.Conn) {
+	a, b := memtransport.Pipe(memtransport.Config{})
+	return a.NetConn(), b.NetConn()
+}
+
+// tamperConn flips the last byte of every write once armed, as an attacker on the path would.
+type tamperConn struct {
+	net.Conn
+	armed atomic.Bool
+}
+
+// Write writes p, corrupted if the connection is armed.
+func (c *tamperConn) Write(p []byte) (int, error) {
+	if c.armed.Load() {
+		p = append([]byte(nil), p...)
+		p[len(p)-1] ^= 1
+	}
+	return c.Conn.Write(p)
+}
+
+// handshakePair establishes a channel over an in-memory pipe and returns both ends with their errors. The
+// client writes through the returned tamperConn.
+func handshakePair(t *testing.T, clientCfg, serverCfg Config) (client, server *Conn, clientErr, serverErr error, tamper *tamperConn) {
+	t.Helper()
+	ca, cb := pipe()
+	t.Cleanup(func() {
+		ca.Close()
+		cb.Close()
+	})
+	tamper = &tamperConn{Conn: ca}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server, serverErr = Server(ctx, cb, serverCfg)
+	}()
+	client, clientErr = Client(ctx, tamper, clientCfg)
+	if clientErr != nil {
+		ca.Close()
+	}
+	<-done
+	return client, server, clientErr, serverErr, tamper
+}
+
+func TestHandshake(t *testing.T) {
+	clientID, _ := newIdentity(t)
+	serverID, serverRec := newIdentity(t)
+	for name, cfg := range map[string]Config{
+		"noise xx": {Identity: clientID, Remote: serverID.ID()},
+		"noise ik": {Identity: clientID, RemoteRecord: serverRec},
+		"tls":      {Identity: clientID, Protocol: TLS, Remote: serverID.ID()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var verified identity.ID
+			client, server, err1, err2, _ := handshakePair(t, cfg, Config{Identity: serverID, VerifyPeer: func(rec *identity.Record) error {
+				verified = rec.ID
+				return nil
+			}})
+			if err := errors.Join(err1, err2); err != nil {
+				t.Fatal(err)
+			}
+
+			if client.RemoteID() != serverID.ID() || server.RemoteID() != clientID.ID() || verified != clientID.ID() {
+				t.Fatalf("authenticated %s and %s, verified %s", client.RemoteID(), server.RemoteID(), verified)
+			}
+			if client.Protocol() != cfg.Protocol || server.Protocol() != cfg.Protocol {
+				t.Fatalf("protocols %s and %s, want %s", client.Protocol(), server.Protocol(), cfg.Protocol)
+			}
+			if len(client.ChannelBinding()) == 0 || !bytes.Equal(client.ChannelBinding(), server.ChannelBinding()) {
+				t.Fatal("channel bindings differ")
+			}
+
+			// Writes larger than a record are split and reassembled
+			data := bytes.Repeat([]byte("0123456789abcdef"), 10<<10)
+			go func() {
+				if _, err := client.Write(data); err != nil {
+					t.Error(err)
+				}
+			}()
+			got := make([]byte, len(data))
+			if _, err := io.ReadFull(server, got); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatal("received data differs")
+			}
+
+			go server.Send([]byte("message"))
+			if msg, err := client.NextMessage(); err != nil || string(msg) != "message" {
+				t.Fatalf("NextMessage: %q, %v", msg, err)
+			}
+			if err := client.Send(make([]byte, MaxMessage+1)); err != ErrMessageTooLarge {
+				t.Fatalf("Send of an oversized message: %v, want ErrMessageTooLarge", err)
+			}
+		})
+	}
+}
+
+func TestRekey(t *testing.T) {
+	clientID, _ := newIdentity(t)
+	serverID, _ := newIdentity(t)
+	client, server, err1, err2, _ := handshakePair(t, Config{Identity: clientID, RekeyBytes: 1}, Config{Identity: serverID})
+	if err := errors.Join(err1, err2); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every message rotates the key, and the receiver follows the rekey records
+	for _, msg := range []string{"one", "two", "three"} {
+		go client.Send([]byte(msg))
+		if got, err := server.NextMessage(); err != nil || string(got) != msg {
+			t.Fatalf("NextMessage: %q, %v, want %q", got, err, msg)
+		}
+	}
+}
+
+func TestPeerMismatch(t *testing.T) {
+	clientID, _ := newIdentity(t)
+	serverID, _ := newIdentity(t)
+	otherID, otherRec := newIdentity(t)
+
+	for name, cfg := range map[string]Config{
+		"noise": {Identity: clientID, Remote: otherID.ID()},
+		"tls":   {Identity: clientID, Protocol: TLS, Remote: otherID.ID()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err, _, _ := handshakePair(t, cfg, Config{Identity: serverID}); !errors.Is(err, ErrUnexpectedPeer) {
+				t.Fatalf("Client: %v, want ErrUnexpectedPeer", err)
+			}
+		})
+	}
+
+	t.Run("record of another peer", func(t *testing.T) {
+		// The client knows the record of a different peer, so the server cannot decrypt the IK handshake
+		if _, _, _, err, _ := handshakePair(t, Config{Identity: clientID, RemoteRecord: otherRec}, Config{Identity: serverID}); !errors.Is(err, noise.ErrDecrypt) {
+			t.Fatalf("Server: %v, want noise.ErrDecrypt", err)
+		}
+	})
+
+	t.Run("record of another key", func(t *testing.T) {
+		ca, cb := pipe()
+		defer ca.Close()
+		defer cb.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+		defer cancel()
+		errc := make(chan error, 1)
+		go func() {
+			_, err := Server(ctx, cb, Config{Identity: serverID})
+			errc <- err
+		}()
+
+		// A client presenting another peer's record with its own static key
+		raw, err := otherID.NewRecord(nil, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prologue := append(append([]byte(nil), magic...), byte(modeNoiseXX))
+		go func() {
+			if _, err := ca.Write(prologue); err != nil {
+				return
+			}
+			noise.Client(ctx, ca, noise.Config{StaticKey: clientID.StaticKey(), Prologue: prologue, Payload: raw})
+		}()
+		if err := <-errc; !errors.Is(err, ErrPeerMismatch) {
+			t.Fatalf("Server: %v, want ErrPeerMismatch", err)
+		}
+	})
+
+	var conn net.Conn
+	if _, err := Client(context.Background(), conn, Config{Identity: clientID, Remote: serverID.ID(), RemoteRecord: otherRec}); !errors.Is(err, ErrUnexpectedPeer) {
+		t.Fatalf("Client with conflicting Remote and RemoteRecord: %v, want ErrUnexpectedPeer", err)
+	}
+}
+
+func TestTampered(t *testing.T) {
+	clientID, _ := newIdentity(t)
+	serverID, _ := newIdentity(t)
+	for name, protocol := range map[string]Protocol{"noise": Noise, "tls": TLS} {
+		t.Run(name, func(t *testing.T) {
+			client, server, err1, err2, tamper := handshakePair(t, Config{Identity: clientID, Protocol: protocol}, Config{Identity: serverID})
+			if err := errors.Join(err1, err2); err != nil {
+				t.Fatal(err)
+			}
+
+			tamper.armed.Store(true)
+			go client.Send([]byte("hello"))
+			if msg, err := server.NextMessage(); err == nil {
+				t.Fatalf("tampered record received as %q", msg)
+			} else if protocol == Noise && !errors.Is(err, noise.ErrDecrypt) {
+				t.Fatalf("NextMessage: %v, want noise.ErrDecrypt", err)
+			}
+		})
+	}
+
+	t.Run("record type", func(t *testing.T) {
+		client, server, err1, err2, _ := handshakePair(t, Config{Identity: clientID}, Config{Identity: serverID})
+		if err := errors.Join(err1, err2); err != nil {
+			t.Fatal(err)
+		}
+		// An authenticated record the protocol does not define
+		go client.writeRecord(recordType(9), nil)
+		if _, err := server.NextMessage(); !errors.Is(err, ErrProtocol) {
+			t.Fatalf("NextMessage: %v, want ErrProtocol", err)
+		}
+	})
+}
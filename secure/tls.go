@@ -0,0 +1,151 @@
+package secure
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"time"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/msgconn"
+)
+
+// recordExtension is the private certificate extension carrying the signed peer record
+var recordExtension = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+
+// tlsHandshake runs a TLS 1.3 handshake in which both sides present a certificate for their identity.
+func tlsHandshake(ctx context.Context, rwc io.ReadWriteCloser, cfg Config, initiator bool) (*Conn, error) {
+	cert, err := certificate(cfg.Identity)
+	if err != nil {
+		return nil, err
+	}
+
+	var remote *identity.Record
+	tcfg := &tls.Config{
+		MinVersion:   tls.VersionTLS13,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{string(magic)},
+		ClientAuth:   tls.RequireAnyClientCert,
+		// Resumed sessions would skip certificate verification and leave the peer unauthenticated
+		SessionTicketsDisabled: true,
+		// Certificates are self-signed; the peer is authenticated against its identity key instead
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(certs [][]byte, _ [][]*x509.Certificate) error {
+			rec, err := verifyCertificate(certs)
+			if err != nil {
+				return err
+			}
+			if err := cfg.verify(rec); err != nil {
+				return err
+			}
+			remote = rec
+			return nil
+		},
+	}
+
+	nc, ok := rwc.(net.Conn)
+	if !ok {
+		nc = streamConn{rwc}
+	}
+	var conn *tls.Conn
+	if initiator {
+		conn = tls.Client(nc, tcfg)
+	} else {
+		conn = tls.Server(nc, tcfg)
+	}
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return nil, err
+	}
+	state := conn.ConnectionState()
+	binding, err := state.ExportKeyingMaterial("supernet-secure-binding", nil, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newConn(rwc, cfg, remote, binding)
+	c.protocol = TLS
+	c.tls = conn
+	return c, nil
+}
+
+// certificate creates a self-signed certificate for the identity's Ed25519 key, embedding its peer record.
+func certificate(self *identity.Identity) (tls.Certificate, error) {
+	record, err := self.NewRecord(nil, 0)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:    serial,
+		NotBefore:       now.Add(-time.Hour),
+		NotAfter:        now.Add(identity.DefaultRecordTTL),
+		ExtraExtensions: []pkix.Extension{{Id: recordExtension, Value: record}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, self.PublicKey(), self.PrivateKey())
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: self.PrivateKey()}, nil
+}
+
+// verifyCertificate checks a peer certificate chain and returns the peer record it carries.
+func verifyCertificate(certs [][]byte) (*identity.Record, error) {
+	if len(certs) != 1 {
+		return nil, errors.New("secure: expected exactly one certificate")
+	}
+	cert, err := x509.ParseCertificate(certs[0])
+	if err != nil {
+		return nil, err
+	}
+	if now := time.Now(); now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return nil, errors.New("secure: certificate not valid at this time")
+	}
+	if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+		return nil, err
+	}
+	pub, ok := cert.PublicKey.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("secure: certificate key is not Ed25519")
+	}
+
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(recordExtension) {
+			continue
+		}
+		rec, err := identity.OpenRecord(ext.Value)
+		if err != nil {
+			return nil, err
+		}
+		// TLS proved possession of the certificate key; the record must belong to the same identity
+		if !bytes.Equal(rec.PublicKey, pub) {
+			return nil, ErrPeerMismatch
+		}
+		return rec, nil
+	}
+	return nil, errors.New("secure: certificate carries no peer record")
+}
+
+// streamConn adapts a plain stream to the net.Conn crypto/tls requires. Deadlines are not supported.
+type streamConn struct {
+	io.ReadWriteCloser
+}
+
+func (streamConn) LocalAddr() net.Addr              { return msgconn.Addr{Net: "secure"} }
+func (streamConn) RemoteAddr() net.Addr             { return msgconn.Addr{Net: "secure"} }
+func (streamConn) SetDeadline(time.Time) error      { return nil }
+func (streamConn) SetReadDeadline(time.Time) error  { return nil }
+func (streamConn) SetWriteDeadline(time.Time) error { return nil }
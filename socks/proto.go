@@ -0,0 +1,186 @@
+//go:build !js
+
+package socks
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"syscall"
+
+	"pkg.gfire.dev/supernet/routing"
+)
+
+var (
+	// ErrVersion is returned when a client does not speak SOCKS5
+	ErrVersion = errors.New("socks: unsupported protocol version")
+	// ErrAuthFailed is returned when a client offers no acceptable method or fails to authenticate
+	ErrAuthFailed = errors.New("socks: authentication failed")
+	// ErrCommand is returned for requests other than CONNECT
+	ErrCommand = errors.New("socks: unsupported command")
+	// ErrAddressType is returned for requests with an unknown address type
+	ErrAddressType = errors.New("socks: unsupported address type")
+)
+
+const (
+	version         = 5
+	passwordVersion = 1 // Version of the username/password subnegotiation
+
+	methodNoAuth       = 0x00
+	methodPassword     = 0x02
+	methodNoAcceptable = 0xff
+
+	passwordSuccess = 0x00
+	passwordFailure = 0x01
+
+	cmdConnect = 0x01
+
+	atypIPv4   = 0x01
+	atypDomain = 0x03
+	atypIPv6   = 0x04
+)
+
+// Reply codes
+const (
+	replySucceeded   = 0x00
+	replyFailure     = 0x01
+	replyNotAllowed  = 0x02
+	replyNetwork     = 0x03
+	replyHost        = 0x04
+	replyRefused     = 0x05
+	replyCommand     = 0x07
+	replyAddressType = 0x08
+)
+
+// readGreeting reads the methods offered by a client.
+func readGreeting(r io.Reader) (map[byte]bool, error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	if hdr[0] != version {
+		return nil, ErrVersion
+	}
+	list := make([]byte, hdr[1])
+	if _, err := io.ReadFull(r, list); err != nil {
+		return nil, err
+	}
+	methods := make(map[byte]bool, len(list))
+	for _, m := range list {
+		methods[m] = true
+	}
+	return methods, nil
+}
+
+// readCredentials reads a username/password subnegotiation request.
+func readCredentials(r io.Reader) (username, password string, err error) {
+	var ver [1]byte
+	if _, err := io.ReadFull(r, ver[:]); err != nil {
+		return "", "", err
+	}
+	if ver[0] != passwordVersion {
+		return "", "", ErrVersion
+	}
+	if username, err = readString(r); err != nil {
+		return "", "", err
+	}
+	if password, err = readString(r); err != nil {
+		return "", "", err
+	}
+	return username, password, nil
+}
+
+// readRequest reads a request and returns its command and target address.
+func readRequest(r io.Reader) (byte, string, error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, "", err
+	}
+	if hdr[0] != version {
+		return 0, "", ErrVersion
+	}
+
+	var host string
+	switch hdr[3] {
+	case atypIPv4:
+		var ip [4]byte
+		if _, err := io.ReadFull(r, ip[:]); err != nil {
+			return 0, "", err
+		}
+		host = netip.AddrFrom4(ip).String()
+	case atypIPv6:
+		var ip [16]byte
+		if _, err := io.ReadFull(r, ip[:]); err != nil {
+			return 0, "", err
+		}
+		host = netip.AddrFrom16(ip).String()
+	case atypDomain:
+		name, err := readString(r)
+		if err != nil {
+			return 0, "", err
+		}
+		host = name
+	default:
+		return 0, "", ErrAddressType
+	}
+
+	var port [2]byte
+	if _, err := io.ReadFull(r, port[:]); err != nil {
+		return 0, "", err
+	}
+	return hdr[1], net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port[:])))), nil
+}
+
+// readString reads a string prefixed by its length in one byte.
+func readString(r io.Reader) (string, error) {
+	var n [1]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return "", err
+	}
+	b := make([]byte, n[0])
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// writeReply sends a reply with the bound address, or the unspecified IPv4 address if addr is not an
+// IP address, as for tunnels through relays.
+func writeReply(w io.Writer, code byte, addr net.Addr) error {
+	ap := netip.AddrPortFrom(netip.IPv4Unspecified(), 0)
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		ap = tcp.AddrPort()
+	}
+
+	b := []byte{version, code, 0}
+	if ip := ap.Addr().Unmap(); ip.Is4() {
+		b = append(b, atypIPv4)
+		b = append(b, ip.AsSlice()...)
+	} else {
+		b = append(b, atypIPv6)
+		b = append(b, ip.AsSlice()...)
+	}
+	b = binary.BigEndian.AppendUint16(b, ap.Port())
+	_, err := w.Write(b)
+	return err
+}
+
+// replyCode maps a dial error to a reply code.
+func replyCode(err error) byte {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(err, routing.ErrRejected):
+		return replyNotAllowed
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return replyRefused
+	case errors.Is(err, syscall.ENETUNREACH):
+		return replyNetwork
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.As(err, &dnsErr):
+		return replyHost
+	default:
+		return replyFailure
+	}
+}
@@ -0,0 +1,236 @@
+//go:build !js
+
+// Package socks exposes the overlay to native applications through a local SOCKS5 proxy (RFC 1928), so
+// tools such as curl, browsers or IDEs reach relayed, browser-hosted or overlay-only services without
+// knowing about supernet.
+//
+// Every CONNECT request is dialed through Config.Dial, typically the DialContext method of a
+// supernet.Dialer whose rules route overlay destinations to relays or peers. Host names are passed on
+// unresolved, so names that only exist inside the overlay work as long as clients leave resolution to
+// the proxy (socks5h in curl). UDP ASSOCIATE and BIND are not supported.
+package socks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"pkg.gfire.dev/supernet/logging"
+)
+
+// ErrServerClosed is returned by Serve after Close
+var ErrServerClosed = errors.New("socks: server closed")
+
+// defaultHandshakeTimeout is the default time limit for a client to complete its request
+const defaultHandshakeTimeout = 10 * time.Second
+
+// Config configures a Server.
+type Config struct {
+	// Dial connects to the requested targets. Defaults to a net.Dialer, which only reaches the local
+	// network; pass a supernet.Dialer's DialContext to reach the overlay.
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	// Authenticate checks the username and password of clients (RFC 1929). When nil, clients need no
+	// authentication, so the server should only listen on loopback.
+	Authenticate func(username, password string) bool
+	// HandshakeTimeout limits the time until a client has sent its request (default 10s).
+	HandshakeTimeout time.Duration
+	// Logger receives connection events. Defaults to logging.For("socks").
+	Logger *slog.Logger
+}
+
+// Server is a SOCKS5 proxy server.
+type Server struct {
+	cfg Config
+
+	// ctx is cancelled by Close, tearing down all connections
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// listeners are the listeners being served
+	listeners map[net.Listener]struct{}
+}
+
+// NewServer creates a SOCKS5 server.
+func NewServer(cfg Config) *Server {
+	if cfg.Dial == nil {
+		var d net.Dialer
+		cfg.Dial = d.DialContext
+	}
+	if cfg.HandshakeTimeout <= 0 {
+		cfg.HandshakeTimeout = defaultHandshakeTimeout
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logging.For("socks")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		cfg:       cfg,
+		ctx:       ctx,
+		cancel:    cancel,
+		listeners: make(map[net.Listener]struct{}),
+	}
+}
+
+// ListenAndServe listens on the TCP address addr, e.g. "127.0.0.1:1080", and serves clients.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts clients on l until it fails or the server is closed. It closes l when done.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.ctx.Err() != nil {
+		s.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, l)
+		s.mu.Unlock()
+		l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return ErrServerClosed
+			}
+			return err
+		}
+		go s.ServeConn(s.ctx, conn)
+	}
+}
+
+// Close stops all listeners and closes all connections.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cancel()
+	for l := range s.listeners {
+		l.Close()
+	}
+	return nil
+}
+
+// ServeConn serves a single client connection until it is done or ctx ends, and closes it.
+func (s *Server) ServeConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	log := s.cfg.Logger.With("client", conn.RemoteAddr().String())
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	conn.SetDeadline(time.Now().Add(s.cfg.HandshakeTimeout))
+	addr, err := s.handshake(conn)
+	if err != nil {
+		log.Debug("socks handshake failed", "err", err)
+		return
+	}
+	log = log.With("target", addr)
+
+	target, err := s.cfg.Dial(ctx, "tcp", addr)
+	if err != nil {
+		log.Debug("socks target unreachable", "err", err)
+		writeReply(conn, replyCode(err), nil)
+		return
+	}
+	defer target.Close()
+	if err := writeReply(conn, replySucceeded, target.LocalAddr()); err != nil {
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	log.Debug("socks connection opened")
+	pipe(conn, target)
+	log.Debug("socks connection closed")
+}
+
+// handshake negotiates authentication and reads the request, returning the target address of a
+// CONNECT request. Failures are reported to the client where the protocol allows.
+func (s *Server) handshake(conn net.Conn) (string, error) {
+	methods, err := readGreeting(conn)
+	if err != nil {
+		return "", err
+	}
+
+	method := byte(methodNoAuth)
+	if s.cfg.Authenticate != nil {
+		method = methodPassword
+	}
+	if !methods[method] {
+		conn.Write([]byte{version, methodNoAcceptable})
+		return "", ErrAuthFailed
+	}
+	if _, err := conn.Write([]byte{version, method}); err != nil {
+		return "", err
+	}
+	if method == methodPassword {
+		username, password, err := readCredentials(conn)
+		if err != nil {
+			return "", err
+		}
+		if !s.cfg.Authenticate(username, password) {
+			conn.Write([]byte{passwordVersion, passwordFailure})
+			return "", ErrAuthFailed
+		}
+		if _, err := conn.Write([]byte{passwordVersion, passwordSuccess}); err != nil {
+			return "", err
+		}
+	}
+
+	cmd, addr, err := readRequest(conn)
+	if err != nil {
+		if errors.Is(err, ErrAddressType) {
+			writeReply(conn, replyAddressType, nil)
+		}
+		return "", err
+	}
+	if cmd != cmdConnect {
+		writeReply(conn, replyCommand, nil)
+		return "", ErrCommand
+	}
+	return addr, nil
+}
+
+// pipe copies between the client and the target until both directions are done, half-closing each
+// side once the other finished sending. A failure in either direction closes both.
+func pipe(client, target net.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	forward := func(dst, src net.Conn) {
+		defer wg.Done()
+		if _, err := io.Copy(dst, src); err != nil {
+			// A failed direction aborts the other one
+			client.Close()
+			target.Close()
+			return
+		}
+		if cw, ok := dst.(interface{ CloseWrite() error }); ok {
+			cw.CloseWrite()
+		} else {
+			dst.Close()
+		}
+	}
+	go forward(target, client)
+	go forward(client, target)
+	wg.Wait()
+}
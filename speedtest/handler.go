@@ -0,0 +1,89 @@
+package speedtest
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// handlerSize is the virtual size of the resource served by Handler
+	handlerSize = 1 << 40
+	// maxUploadSize bounds the body accepted by Handler per request
+	maxUploadSize = 64 << 20
+)
+
+// Handler returns an http.Handler serving as a speed test endpoint for both URL and UploadURL.
+// GET requests receive a virtual resource of pseudo-random bytes that honors single Range requests,
+// and POST requests have their bodies discarded. Responses are not cacheable and allow any origin,
+// so browsers can run tests against it cross-origin.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Cache-Control", "no-store")
+		h.Set("Access-Control-Allow-Origin", "*")
+		h.Set("Access-Control-Allow-Headers", "Range, Content-Type, Cache-Control")
+		h.Set("Access-Control-Expose-Headers", "Content-Range")
+
+		switch r.Method {
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodGet, http.MethodHead:
+			// http.ServeContent parses Range headers and writes Content-Range against the virtual size
+			http.ServeContent(w, r, "", time.Time{}, &virtualResource{size: handlerSize})
+		case http.MethodPost:
+			n, err := io.Copy(io.Discard, http.MaxBytesReader(w, r.Body, maxUploadSize))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			h.Set("Content-Type", "text/plain")
+			io.WriteString(w, strconv.FormatInt(n, 10))
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// virtualResource is an io.ReadSeeker over size pseudo-random bytes that are generated on the fly.
+type virtualResource struct {
+	size   int64
+	offset int64
+}
+
+// Read fills p with bytes derived from their offset, which keeps content incompressible enough
+// to defeat transparent compression while requiring no state.
+func (v *virtualResource) Read(p []byte) (int, error) {
+	if v.offset >= v.size {
+		return 0, io.EOF
+	}
+	if remaining := v.size - v.offset; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	for i := range p {
+		x := uint64(v.offset + int64(i))
+		x ^= x >> 33
+		x *= 0xff51afd7ed558ccd
+		x ^= x >> 33
+		p[i] = byte(x)
+	}
+	v.offset += int64(len(p))
+	return len(p), nil
+}
+
+// Seek implements io.Seeker.
+func (v *virtualResource) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += v.offset
+	case io.SeekEnd:
+		offset += v.size
+	}
+	if offset < 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	v.offset = offset
+	return offset, nil
+}
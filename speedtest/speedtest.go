@@ -0,0 +1,360 @@
+// Package speedtest measures throughput and latency to an HTTP endpoint using parallel ranged
+// downloads and streamed uploads, reporting progressive results while the test runs.
+//
+// It works with any http.Client, including the fetch-backed default client under js/wasm, and pairs
+// with Handler on the server side.
+package speedtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrNoEndpoint is returned when no URL is configured for a measurement
+	ErrNoEndpoint = errors.New("speedtest: no endpoint configured")
+	// ErrNoData is returned when no bytes could be transferred during a measurement
+	ErrNoData = errors.New("speedtest: no data transferred")
+)
+
+const (
+	// defaultStreams is the default number of parallel transfers
+	defaultStreams = 4
+	// defaultDuration is the default duration of each direction
+	defaultDuration = 10 * time.Second
+	// defaultChunkSize is the default size of each ranged download or upload request
+	defaultChunkSize = 4 << 20
+	// defaultInterval is the default interval between progress samples
+	defaultInterval = 250 * time.Millisecond
+	// latencyProbes is the number of round trips averaged for the latency measurement
+	latencyProbes = 5
+)
+
+// Direction identifies the direction of a measurement.
+type Direction int
+
+const (
+	// Download measures throughput from the endpoint.
+	Download Direction = iota
+	// Upload measures throughput to the endpoint.
+	Upload
+)
+
+// String returns "download" or "upload".
+func (d Direction) String() string {
+	if d == Upload {
+		return "upload"
+	}
+	return "download"
+}
+
+// Config configures a speed test.
+type Config struct {
+	// URL is the download endpoint. It should serve a large resource and honor Range requests,
+	// but endpoints ignoring Range work as well. Also used for latency probes.
+	URL string
+	// UploadURL accepts POST requests and discards their bodies. Uploads are skipped if empty.
+	UploadURL string
+	// Client performs the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+	// Streams is the number of parallel transfers per direction (default 4).
+	Streams int
+	// Duration bounds each direction (default 10s).
+	Duration time.Duration
+	// ChunkSize is the number of bytes per request (default 4 MiB).
+	ChunkSize int64
+	// Interval is the interval between progress samples (default 250ms).
+	Interval time.Duration
+	// Progress, if set, receives a sample every Interval while a direction is measured.
+	Progress func(Sample)
+}
+
+// Sample is a progressive measurement.
+type Sample struct {
+	Direction  Direction     // Direction being measured
+	Elapsed    time.Duration // Time since the direction started
+	Bytes      int64         // Bytes transferred since the direction started
+	Throughput float64       // Throughput over the last interval in bits per second
+}
+
+// Measurement is the outcome of one direction.
+type Measurement struct {
+	Bytes      int64         // Bytes transferred
+	Duration   time.Duration // Duration of the measurement
+	Throughput float64       // Average throughput in bits per second
+	Peak       float64       // Highest interval throughput in bits per second
+}
+
+// Mbps returns the average throughput in megabits per second.
+func (m Measurement) Mbps() float64 {
+	return m.Throughput / 1e6
+}
+
+// Result is the outcome of a complete speed test.
+type Result struct {
+	Latency  time.Duration // Mean round trip time of small requests
+	Jitter   time.Duration // Mean absolute difference between consecutive round trips
+	Download Measurement   // Downlink throughput
+	Upload   Measurement   // Uplink throughput, zero if UploadURL is empty
+}
+
+// Run measures latency, downlink and uplink throughput in sequence.
+func Run(ctx context.Context, cfg Config) (*Result, error) {
+	var result Result
+	var err error
+
+	if result.Latency, result.Jitter, err = MeasureLatency(ctx, cfg); err != nil {
+		return nil, err
+	}
+	if result.Download, err = MeasureDownload(ctx, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.UploadURL != "" {
+		if result.Upload, err = MeasureUpload(ctx, cfg); err != nil {
+			return nil, err
+		}
+	}
+	return &result, nil
+}
+
+// MeasureLatency measures the round trip time of single-byte range requests to cfg.URL.
+func MeasureLatency(ctx context.Context, cfg Config) (latency, jitter time.Duration, err error) {
+	cfg = cfg.withDefaults()
+	if cfg.URL == "" {
+		return 0, 0, ErrNoEndpoint
+	}
+
+	var total, totalJitter, prev time.Duration
+	for i := 0; i < latencyProbes; i++ {
+		start := time.Now()
+		if _, err := fetchRange(ctx, cfg.Client, cfg.URL, 0, 1, nil); err != nil {
+			return 0, 0, err
+		}
+		rtt := time.Since(start)
+
+		total += rtt
+		if i > 0 {
+			totalJitter += (rtt - prev).Abs()
+		}
+		prev = rtt
+	}
+	return total / latencyProbes, totalJitter / (latencyProbes - 1), nil
+}
+
+// MeasureDownload measures downlink throughput with parallel ranged requests to cfg.URL.
+func MeasureDownload(ctx context.Context, cfg Config) (Measurement, error) {
+	cfg = cfg.withDefaults()
+	if cfg.URL == "" {
+		return Measurement{}, ErrNoEndpoint
+	}
+
+	// size is the resource size learned from Content-Range, used to wrap range offsets
+	var size, next atomic.Int64
+	return measure(ctx, cfg, Download, func(ctx context.Context, counter *atomic.Int64) error {
+		offset := next.Add(cfg.ChunkSize) - cfg.ChunkSize
+		if s := size.Load(); s > 0 {
+			offset %= s
+		}
+		total, err := fetchRange(ctx, cfg.Client, cfg.URL, offset, cfg.ChunkSize, counter)
+		if total > 0 {
+			size.Store(total)
+		}
+		return err
+	})
+}
+
+// MeasureUpload measures uplink throughput with parallel streamed POST requests to cfg.UploadURL.
+func MeasureUpload(ctx context.Context, cfg Config) (Measurement, error) {
+	cfg = cfg.withDefaults()
+	if cfg.UploadURL == "" {
+		return Measurement{}, ErrNoEndpoint
+	}
+
+	return measure(ctx, cfg, Upload, func(ctx context.Context, counter *atomic.Int64) error {
+		body := &countingReader{r: io.LimitReader(zeroReader{}, cfg.ChunkSize), counter: counter}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.UploadURL, body)
+		if err != nil {
+			return err
+		}
+		req.ContentLength = cfg.ChunkSize
+		req.Header.Set("Content-Type", "application/octet-stream")
+
+		resp, err := cfg.Client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("speedtest: upload failed: %s", resp.Status)
+		}
+		return nil
+	})
+}
+
+// measure runs transfer repeatedly on cfg.Streams goroutines for cfg.Duration and samples the byte counter.
+func measure(ctx context.Context, cfg Config, dir Direction, transfer func(context.Context, *atomic.Int64) error) (Measurement, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	var counter atomic.Int64
+	var wg sync.WaitGroup
+	errCh := make(chan error, cfg.Streams)
+
+	start := time.Now()
+	for i := 0; i < cfg.Streams; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				if err := transfer(ctx, &counter); err != nil && ctx.Err() == nil {
+					errCh <- err
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var m Measurement
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	lastBytes, lastTime := int64(0), start
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		case now := <-ticker.C:
+			bytes := counter.Load()
+			rate := float64(bytes-lastBytes) * 8 / now.Sub(lastTime).Seconds()
+			lastBytes, lastTime = bytes, now
+			if rate > m.Peak {
+				m.Peak = rate
+			}
+			if cfg.Progress != nil {
+				cfg.Progress(Sample{Direction: dir, Elapsed: now.Sub(start), Bytes: bytes, Throughput: rate})
+			}
+		}
+	}
+
+	m.Bytes = counter.Load()
+	m.Duration = time.Since(start)
+	if m.Duration > cfg.Duration {
+		m.Duration = cfg.Duration
+	}
+	m.Throughput = float64(m.Bytes) * 8 / m.Duration.Seconds()
+
+	select {
+	case err := <-errCh:
+		if m.Bytes == 0 {
+			return m, err
+		}
+	default:
+	}
+	if m.Bytes == 0 {
+		return m, ErrNoData
+	}
+	return m, nil
+}
+
+// fetchRange downloads length bytes at offset, adding received bytes to counter.
+// It returns the total resource size if the server reported one in Content-Range.
+func fetchRange(ctx context.Context, client *http.Client, url string, offset, length int64, counter *atomic.Int64) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	req.Header.Set("Cache-Control", "no-store")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("speedtest: download failed: %s", resp.Status)
+	}
+
+	// Servers ignoring Range send the whole resource; read only the requested amount
+	var r io.Reader = io.LimitReader(resp.Body, length)
+	if counter != nil {
+		r = &countingReader{r: r, counter: counter}
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return 0, err
+	}
+	return contentRangeSize(resp.Header.Get("Content-Range")), nil
+}
+
+// contentRangeSize extracts the complete length from a Content-Range header, or 0 if unknown.
+func contentRangeSize(header string) int64 {
+	_, total, ok := strings.Cut(header, "/")
+	if !ok {
+		return 0
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// withDefaults fills in unset configuration values.
+func (cfg Config) withDefaults() Config {
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.Streams <= 0 {
+		cfg.Streams = defaultStreams
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = defaultDuration
+	}
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = defaultChunkSize
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	return cfg
+}
+
+// countingReader adds the number of bytes read to a shared counter.
+type countingReader struct {
+	r       io.Reader
+	counter *atomic.Int64
+}
+
+// Read implements io.Reader.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.counter.Add(int64(n))
+	return n, err
+}
+
+// zeroReader is an endless source of zero bytes.
+type zeroReader struct{}
+
+// Read implements io.Reader.
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -0,0 +1,263 @@
+// Package sse implements a message transport for clients that can use neither WebTransport nor WebSockets,
+// such as browsers behind proxies that strip upgrade requests. Downstream messages are delivered as
+// server-sent events and upstream messages as ordinary POST requests:
+//
+//   - GET with "Accept: text/event-stream" opens a session. The first event is "session" carrying the
+//     session ID; every following "message" event carries one base64-encoded message. An event "close"
+//     ends the session.
+//   - POST ?session=ID with the raw message as body sends one message upstream. Clients send one request
+//     at a time to preserve ordering.
+//   - DELETE ?session=ID closes the session.
+//
+// Handler is the server side; web/wasmlib/ssejs provides the browser client.
+package sse
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrClosed is returned when using a closed connection
+	ErrClosed = errors.New("sse connection closed")
+)
+
+const (
+	// defaultMaxMessage is the default limit of upstream message bodies
+	defaultMaxMessage = 1 << 20
+	// keepaliveInterval is how often a comment line is written to keep intermediaries from timing out
+	keepaliveInterval = 15 * time.Second
+	// upstreamBuffer is the number of upstream messages queued per connection before POSTs block
+	upstreamBuffer = 64
+)
+
+// Handler accepts SSE sessions and passes each to Accept as a Conn.
+type Handler struct {
+	// Accept is called in its own goroutine for every new session.
+	Accept func(conn *Conn)
+	// MaxMessage limits the size of upstream messages (default 1 MiB).
+	MaxMessage int64
+
+	mu       sync.Mutex
+	sessions map[string]*Conn
+}
+
+// NewHandler creates a handler calling accept for every new session.
+func NewHandler(accept func(conn *Conn)) *Handler {
+	return &Handler{Accept: accept}
+}
+
+// ServeHTTP dispatches session, message and close requests.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.serveStream(w, r)
+	case http.MethodPost:
+		conn := h.lookup(r)
+		if conn == nil {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		limit := h.MaxMessage
+		if limit <= 0 {
+			limit = defaultMaxMessage
+		}
+		msg, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err := conn.deliver(r, msg); err != nil {
+			http.Error(w, err.Error(), http.StatusGone)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		if conn := h.lookup(r); conn != nil {
+			conn.Close()
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// serveStream runs the event stream of a new session until it ends.
+func (h *Handler) serveStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	conn := newConn(w, flusher)
+	h.mu.Lock()
+	if h.sessions == nil {
+		h.sessions = make(map[string]*Conn)
+	}
+	h.sessions[conn.id] = conn
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.sessions, conn.id)
+		h.mu.Unlock()
+		conn.Close()
+
+		conn.writeMu.Lock()
+		conn.finished = true
+		conn.writeMu.Unlock()
+	}()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-store")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := conn.writeEvent("session", conn.id); err != nil {
+		return
+	}
+	go h.Accept(conn)
+
+	ticker := time.NewTicker(keepaliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-conn.closed:
+			conn.writeEvent("close", "close")
+			return
+		case <-ticker.C:
+			if err := conn.writeComment("ping"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// lookup returns the session named by the request's session parameter.
+func (h *Handler) lookup(r *http.Request) *Conn {
+	id := r.URL.Query().Get("session")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sessions[id]
+}
+
+// Conn is the server side of an SSE session, implementing the NextMessage/Send/Close contract.
+type Conn struct {
+	id string
+
+	// writeMu serializes writes to the event stream
+	writeMu sync.Mutex
+	w       io.Writer
+	flusher http.Flusher
+	// finished is set once the handler returned and w may no longer be used
+	finished bool
+
+	// upstream delivers messages received through POST requests
+	upstream chan []byte
+	// closed is closed when the session ends
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// newConn creates a session with a random ID.
+func newConn(w io.Writer, flusher http.Flusher) *Conn {
+	var b [16]byte
+	rand.Read(b[:])
+	return &Conn{
+		id:       hex.EncodeToString(b[:]),
+		w:        w,
+		flusher:  flusher,
+		upstream: make(chan []byte, upstreamBuffer),
+		closed:   make(chan struct{}),
+	}
+}
+
+// ID returns the session ID.
+func (c *Conn) ID() string {
+	return c.id
+}
+
+// NextMessage blocks until the client sends a message.
+func (c *Conn) NextMessage() ([]byte, error) {
+	select {
+	case msg := <-c.upstream:
+		return msg, nil
+	case <-c.closed:
+		// Deliver messages that arrived before the close
+		select {
+		case msg := <-c.upstream:
+			return msg, nil
+		default:
+			return nil, ErrClosed
+		}
+	}
+}
+
+// Send delivers a message to the client as a server-sent event.
+func (c *Conn) Send(data []byte) error {
+	select {
+	case <-c.closed:
+		return ErrClosed
+	default:
+	}
+	return c.writeEvent("message", base64.StdEncoding.EncodeToString(data))
+}
+
+// Close ends the session. Safe to call multiple times.
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
+
+// deliver queues an upstream message, blocking while the queue is full.
+func (c *Conn) deliver(r *http.Request, msg []byte) error {
+	select {
+	case c.upstream <- msg:
+		return nil
+	case <-c.closed:
+		return ErrClosed
+	case <-r.Context().Done():
+		return r.Context().Err()
+	}
+}
+
+// writeEvent writes and flushes a single event.
+func (c *Conn) writeEvent(event, data string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.finished {
+		return ErrClosed
+	}
+	if _, err := io.WriteString(c.w, "event: "+event+"\ndata: "+data+"\n\n"); err != nil {
+		return err
+	}
+	c.flusher.Flush()
+	return nil
+}
+
+// writeComment writes and flushes a comment line.
+func (c *Conn) writeComment(text string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.finished {
+		return ErrClosed
+	}
+	if _, err := io.WriteString(c.w, ": "+text+"\n\n"); err != nil {
+		return err
+	}
+	c.flusher.Flush()
+	return nil
+}
@@ -0,0 +1,73 @@
+package swarm
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+)
+
+// ErrInvalidManifest is returned when a manifest is inconsistent
+var ErrInvalidManifest = errors.New("swarm: invalid manifest")
+
+// DefaultPieceSize is the default piece size of NewManifest.
+const DefaultPieceSize = 256 << 10
+
+// Manifest describes a resource split into verifiable pieces.
+type Manifest struct {
+	Size      int64               // Resource size in bytes
+	PieceSize int                 // Size of every piece except the last
+	Hashes    [][sha256.Size]byte // SHA-256 of every piece
+}
+
+// NewManifest hashes the content of r in pieces of pieceSize bytes (DefaultPieceSize if 0).
+func NewManifest(r io.ReaderAt, size int64, pieceSize int) (*Manifest, error) {
+	if pieceSize <= 0 {
+		pieceSize = DefaultPieceSize
+	}
+	m := &Manifest{Size: size, PieceSize: pieceSize}
+	m.Hashes = make([][sha256.Size]byte, m.pieces())
+
+	buf := make([]byte, pieceSize)
+	for i := range m.Hashes {
+		off, n := m.Piece(i)
+		if _, err := r.ReadAt(buf[:n], off); err != nil && err != io.EOF {
+			return nil, err
+		}
+		m.Hashes[i] = sha256.Sum256(buf[:n])
+	}
+	return m, nil
+}
+
+// NumPieces returns the number of pieces.
+func (m *Manifest) NumPieces() int {
+	return len(m.Hashes)
+}
+
+// Piece returns the byte range of piece i.
+func (m *Manifest) Piece(i int) (off int64, n int) {
+	off = int64(i) * int64(m.PieceSize)
+	n = m.PieceSize
+	if rest := m.Size - off; rest < int64(n) {
+		n = int(rest)
+	}
+	return off, n
+}
+
+// Verify reports whether data is the content of piece i.
+func (m *Manifest) Verify(i int, data []byte) bool {
+	_, n := m.Piece(i)
+	return len(data) == n && sha256.Sum256(data) == m.Hashes[i]
+}
+
+// validate checks the manifest for consistency.
+func (m *Manifest) validate() error {
+	if m.Size < 0 || m.PieceSize <= 0 || int64(len(m.Hashes)) != m.pieces() {
+		return ErrInvalidManifest
+	}
+	return nil
+}
+
+// pieces computes the number of pieces from the size.
+func (m *Manifest) pieces() int64 {
+	return (m.Size + int64(m.PieceSize) - 1) / int64(m.PieceSize)
+}
@@ -0,0 +1,173 @@
+package swarm
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Peer protocol: requests are [id u32][offset u64][length u32], responses are [id u32][status u8][data].
+const (
+	requestSize        = 16
+	responseHeaderSize = 5
+
+	statusOK          byte = 0
+	statusUnavailable byte = 1
+	statusError       byte = 2
+
+	// maxRequestLength bounds the length of a single peer request
+	maxRequestLength = 4 << 20
+)
+
+var (
+	// ErrPeerClosed is returned by a PeerSource whose connection has failed
+	ErrPeerClosed = errors.New("swarm: peer connection closed")
+	// errPeerFailed is returned when a peer reports an error reading a range
+	errPeerFailed = errors.New("swarm: peer failed to read range")
+)
+
+// Conn is a message-oriented connection to a peer, such as a webrtcjs.DataChannel or p2p.Conn.
+// Messages must be delivered reliably.
+type Conn interface {
+	NextMessage() ([]byte, error)
+	Send(data []byte) error
+}
+
+// PeerSource fetches ranges from a peer serving them with Serve. Requests are pipelined over one connection.
+type PeerSource struct {
+	name string
+	conn Conn
+
+	mu      sync.Mutex
+	nextID  uint32
+	pending map[uint32]chan []byte
+	err     error
+}
+
+// NewPeerSource starts reading responses from conn.
+func NewPeerSource(name string, conn Conn) *PeerSource {
+	p := &PeerSource{
+		name:    name,
+		conn:    conn,
+		pending: make(map[uint32]chan []byte),
+	}
+	go p.readLoop()
+	return p
+}
+
+// Name returns the peer name.
+func (p *PeerSource) Name() string {
+	return p.name
+}
+
+// Fetch implements Source.
+func (p *PeerSource) Fetch(ctx context.Context, off int64, n int) ([]byte, error) {
+	if n > maxRequestLength {
+		return nil, ErrUnavailable
+	}
+
+	ch := make(chan []byte, 1)
+	p.mu.Lock()
+	if p.err != nil {
+		p.mu.Unlock()
+		return nil, p.err
+	}
+	id := p.nextID
+	p.nextID++
+	p.pending[id] = ch
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, id)
+		p.mu.Unlock()
+	}()
+
+	req := make([]byte, requestSize)
+	binary.BigEndian.PutUint32(req[0:], id)
+	binary.BigEndian.PutUint64(req[4:], uint64(off))
+	binary.BigEndian.PutUint32(req[12:], uint32(n))
+	if err := p.conn.Send(req); err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, ErrPeerClosed
+		}
+		switch resp[4] {
+		case statusOK:
+			return resp[responseHeaderSize:], nil
+		case statusUnavailable:
+			return nil, ErrUnavailable
+		default:
+			return nil, errPeerFailed
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// readLoop dispatches responses to waiting requests.
+func (p *PeerSource) readLoop() {
+	for {
+		resp, err := p.conn.NextMessage()
+		if err != nil {
+			p.mu.Lock()
+			p.err = ErrPeerClosed
+			for id, ch := range p.pending {
+				close(ch)
+				delete(p.pending, id)
+			}
+			p.mu.Unlock()
+			return
+		}
+		if len(resp) < responseHeaderSize {
+			continue
+		}
+
+		p.mu.Lock()
+		if ch, ok := p.pending[binary.BigEndian.Uint32(resp)]; ok {
+			ch <- resp
+		}
+		p.mu.Unlock()
+	}
+}
+
+// Serve answers range requests from a peer with content until the connection fails or ctx is done.
+// have, if set, reports whether a range is available locally, which lets peers that are still
+// downloading serve the pieces they already verified.
+func Serve(ctx context.Context, conn Conn, content io.ReaderAt, size int64, have func(off int64, n int) bool) error {
+	for ctx.Err() == nil {
+		req, err := conn.NextMessage()
+		if err != nil {
+			return err
+		}
+		if len(req) != requestSize {
+			continue
+		}
+
+		off := int64(binary.BigEndian.Uint64(req[4:]))
+		n := int(binary.BigEndian.Uint32(req[12:]))
+
+		resp := make([]byte, responseHeaderSize, responseHeaderSize+n)
+		copy(resp, req[:4])
+		switch {
+		case off < 0 || n > maxRequestLength || off+int64(n) > size || (have != nil && !have(off, n)):
+			resp[4] = statusUnavailable
+		default:
+			resp = resp[:responseHeaderSize+n]
+			if _, err := content.ReadAt(resp[responseHeaderSize:], off); err != nil && err != io.EOF {
+				resp = resp[:responseHeaderSize]
+				resp[4] = statusError
+			}
+		}
+		if err := conn.Send(resp); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
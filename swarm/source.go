@@ -0,0 +1,70 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrUnavailable is returned by sources that do not hold a requested range.
+// It excludes the piece from further requests to that source without counting as a failure.
+var ErrUnavailable = errors.New("swarm: range unavailable at source")
+
+// Source provides byte ranges of a resource.
+type Source interface {
+	// Name identifies the source in progress reports and errors.
+	Name() string
+	// Fetch returns n bytes at offset off.
+	Fetch(ctx context.Context, off int64, n int) ([]byte, error)
+}
+
+// HTTPSource fetches ranges from an HTTP server or CDN with Range requests.
+type HTTPSource struct {
+	URL    string       // Resource URL
+	Client *http.Client // Client performing the requests, http.DefaultClient if nil
+}
+
+// Name returns the URL.
+func (s *HTTPSource) Name() string {
+	return s.URL
+}
+
+// Fetch implements Source.
+func (s *HTTPSource) Fetch(ctx context.Context, off int64, n int) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+int64(n)-1))
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// The server ignored the range; skip to the offset
+		if _, err := io.CopyN(io.Discard, resp.Body, off); err != nil {
+			return nil, err
+		}
+	case http.StatusRequestedRangeNotSatisfiable, http.StatusNotFound:
+		return nil, ErrUnavailable
+	default:
+		return nil, fmt.Errorf("swarm: %s: %s", s.URL, resp.Status)
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(resp.Body, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
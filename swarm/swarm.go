@@ -0,0 +1,342 @@
+// Package swarm downloads a resource in verified pieces from several sources at once, such as HTTP range
+// sources and peers, to offload CDNs.
+//
+// Every source runs a number of workers that claim missing pieces in order. Pieces failing verification are
+// retried elsewhere and sources failing repeatedly are dropped. Once every missing piece is in flight, idle
+// workers enter endgame mode and request in-flight pieces redundantly; the first verified copy wins and
+// the other requests are canceled, so a slow source cannot stall the end of the download.
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var (
+	// ErrNoSources is returned when all sources failed before the download completed
+	ErrNoSources = errors.New("swarm: no usable sources left")
+	// ErrVerification is returned by a source whose data did not match the manifest
+	ErrVerification = errors.New("swarm: piece failed verification")
+)
+
+const (
+	// defaultConcurrency is the default number of parallel requests per source
+	defaultConcurrency = 4
+	// defaultMaxFailures is the default number of failures after which a source is dropped
+	defaultMaxFailures = 3
+)
+
+// Options configures a download.
+type Options struct {
+	// Concurrency is the number of parallel requests per source (default 4).
+	Concurrency int
+	// MaxFailures is the number of failed or corrupt pieces after which a source is dropped (default 3).
+	MaxFailures int
+	// Have marks pieces that are already present in the destination, e.g. from an earlier attempt.
+	Have func(piece int) bool
+	// Progress, if set, is called after every verified piece.
+	Progress func(Progress)
+}
+
+// Progress reports the state of a download.
+type Progress struct {
+	Piece     int    // Piece that was just verified
+	Source    string // Name of the source that delivered it
+	Done      int    // Number of verified pieces
+	Total     int    // Number of pieces
+	Bytes     int64  // Verified bytes
+	Endgame   bool   // Whether the download is in endgame mode
+	Sources   int    // Number of sources still in use
+	Redundant int64  // Bytes downloaded redundantly in endgame mode so far
+}
+
+// pieceState tracks the download of one piece.
+type pieceState struct {
+	done    bool
+	fetches map[*sourceState]context.CancelFunc // In-flight requests by source
+}
+
+// sourceState tracks one source.
+type sourceState struct {
+	src         Source
+	failures    int
+	dropped     bool
+	unavailable map[int]bool // Pieces the source reported as unavailable
+	lastErr     error
+}
+
+// download is the state of a running download.
+type download struct {
+	m    *Manifest
+	dst  io.WriterAt
+	opts Options
+
+	mu        sync.Mutex
+	pieces    []pieceState
+	done      int
+	bytes     int64
+	redundant int64
+	sources   []*sourceState
+	active    int           // Sources not dropped
+	changed   chan struct{} // Closed and replaced on every state change
+	err       error         // Write error that aborts the download
+}
+
+// Download fetches all pieces described by m from sources, verifies them and writes them to dst.
+func Download(ctx context.Context, m *Manifest, dst io.WriterAt, sources []Source, opts Options) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultConcurrency
+	}
+	if opts.MaxFailures <= 0 {
+		opts.MaxFailures = defaultMaxFailures
+	}
+
+	d := &download{
+		m:       m,
+		dst:     dst,
+		opts:    opts,
+		pieces:  make([]pieceState, m.NumPieces()),
+		changed: make(chan struct{}),
+	}
+	for i := range d.pieces {
+		d.pieces[i].fetches = make(map[*sourceState]context.CancelFunc)
+		if opts.Have != nil && opts.Have(i) {
+			d.pieces[i].done = true
+			d.done++
+		}
+	}
+	if d.done == len(d.pieces) {
+		return nil
+	}
+	if len(sources) == 0 {
+		return ErrNoSources
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, src := range sources {
+		s := &sourceState{src: src, unavailable: make(map[int]bool)}
+		d.sources = append(d.sources, s)
+		d.active++
+		for i := 0; i < opts.Concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.worker(ctx, s)
+			}()
+		}
+	}
+	wg.Wait()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch {
+	case d.err != nil:
+		return d.err
+	case d.done == len(d.pieces):
+		return nil
+	case ctx.Err() != nil:
+		return ctx.Err()
+	}
+	var lastErr error
+	for _, s := range d.sources {
+		if s.lastErr != nil {
+			lastErr = fmt.Errorf("%s: %w", s.src.Name(), s.lastErr)
+		}
+	}
+	if lastErr != nil {
+		return fmt.Errorf("%w: %w", ErrNoSources, lastErr)
+	}
+	return ErrNoSources
+}
+
+// worker repeatedly claims and fetches pieces for one source.
+func (d *download) worker(ctx context.Context, s *sourceState) {
+	for {
+		i, fetchCtx, changed, ok := d.claim(ctx, s)
+		if !ok {
+			return
+		}
+		if i < 0 {
+			// Nothing to do right now; wait for pieces to fail back or the download to end
+			select {
+			case <-changed:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		off, n := d.m.Piece(i)
+		data, err := s.src.Fetch(fetchCtx, off, n)
+		if err == nil && !d.m.Verify(i, data) {
+			err = ErrVerification
+		}
+		d.finish(ctx, s, i, data, err)
+	}
+}
+
+// claim picks the next piece for source s. It returns -1 if there is nothing to fetch at the moment,
+// and false if the worker should exit.
+func (d *download) claim(ctx context.Context, s *sourceState) (int, context.Context, chan struct{}, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if s.dropped || d.done == len(d.pieces) || d.err != nil || ctx.Err() != nil {
+		return 0, nil, nil, false
+	}
+
+	// Prefer the first piece nobody is fetching
+	pick := -1
+	for i := range d.pieces {
+		p := &d.pieces[i]
+		if !p.done && len(p.fetches) == 0 && !s.unavailable[i] {
+			pick = i
+			break
+		}
+	}
+
+	// Endgame: duplicate the in-flight piece with the fewest requests
+	if pick < 0 {
+		fewest := 0
+		for i := range d.pieces {
+			p := &d.pieces[i]
+			if p.done || s.unavailable[i] || p.fetches[s] != nil {
+				continue
+			}
+			if pick < 0 || len(p.fetches) < fewest {
+				pick, fewest = i, len(p.fetches)
+			}
+		}
+	}
+
+	if pick < 0 {
+		// No source can provide the remaining pieces any more
+		if d.unreachable() {
+			d.err = fmt.Errorf("%w: remaining pieces unavailable", ErrNoSources)
+			d.notify()
+			return 0, nil, nil, false
+		}
+		return -1, nil, d.changed, true
+	}
+
+	fetchCtx, cancel := context.WithCancel(ctx)
+	d.pieces[pick].fetches[s] = cancel
+	return pick, fetchCtx, nil, true
+}
+
+// finish records the outcome of a fetch.
+func (d *download) finish(ctx context.Context, s *sourceState, i int, data []byte, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	defer d.notify()
+
+	p := &d.pieces[i]
+	if cancel, ok := p.fetches[s]; ok {
+		cancel()
+		delete(p.fetches, s)
+	}
+
+	if p.done {
+		// Another source won the race in endgame mode
+		if err == nil {
+			d.redundant += int64(len(data))
+		}
+		return
+	}
+
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrUnavailable):
+		s.unavailable[i] = true
+		return
+	case ctx.Err() != nil:
+		return
+	default:
+		s.lastErr = err
+		s.failures++
+		if s.failures >= d.opts.MaxFailures && !s.dropped {
+			s.dropped = true
+			d.active--
+			for j := range d.pieces {
+				if cancel, ok := d.pieces[j].fetches[s]; ok {
+					cancel()
+					delete(d.pieces[j].fetches, s)
+				}
+			}
+		}
+		return
+	}
+
+	off, _ := d.m.Piece(i)
+	if _, err := d.dst.WriteAt(data, off); err != nil {
+		d.err = err
+		return
+	}
+
+	p.done = true
+	d.done++
+	d.bytes += int64(len(data))
+	for _, cancel := range p.fetches {
+		cancel()
+	}
+
+	if d.opts.Progress != nil {
+		d.opts.Progress(Progress{
+			Piece:     i,
+			Source:    s.src.Name(),
+			Done:      d.done,
+			Total:     len(d.pieces),
+			Bytes:     d.bytes,
+			Endgame:   d.endgame(),
+			Sources:   d.active,
+			Redundant: d.redundant,
+		})
+	}
+}
+
+// endgame reports whether every missing piece is in flight. Must be called with d.mu held.
+func (d *download) endgame() bool {
+	for i := range d.pieces {
+		if !d.pieces[i].done && len(d.pieces[i].fetches) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// unreachable reports whether some missing piece is neither in flight nor available from any active source.
+// Must be called with d.mu held.
+func (d *download) unreachable() bool {
+	for i := range d.pieces {
+		p := &d.pieces[i]
+		if p.done || len(p.fetches) > 0 {
+			continue
+		}
+		available := false
+		for _, s := range d.sources {
+			if !s.dropped && !s.unavailable[i] {
+				available = true
+				break
+			}
+		}
+		if !available {
+			return true
+		}
+	}
+	return false
+}
+
+// notify wakes waiting workers. Must be called with d.mu held.
+func (d *download) notify() {
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
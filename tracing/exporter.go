@@ -0,0 +1,273 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// ErrInvalidEndpoint is returned for endpoints that are not absolute http or https URLs
+	ErrInvalidEndpoint = errors.New("tracing: invalid endpoint")
+	// ErrExportFailed is returned when the collector does not accept exported spans
+	ErrExportFailed = errors.New("tracing: export failed")
+)
+
+// exportTimeout bounds a single export request
+const exportTimeout = 10 * time.Second
+
+// Exporter is an sdktrace.SpanExporter sending spans to an OTLP/HTTP collector in the JSON encoding,
+// which keeps the protobuf and gRPC dependencies of the official exporter out of wasm binaries.
+type Exporter struct {
+	endpoint string
+	headers  map[string]string
+	stopped  atomic.Bool
+}
+
+// NewExporter creates an exporter posting to endpoint, the full URL of the traces endpoint, with the
+// additional headers.
+func NewExporter(endpoint string, headers map[string]string) (*Exporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidEndpoint, endpoint)
+	}
+	return &Exporter{endpoint: endpoint, headers: headers}, nil
+}
+
+// ExportSpans sends spans to the collector.
+func (e *Exporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if e.stopped.Load() || len(spans) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(encodeSpans(spans))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+	return post(ctx, e.endpoint, e.headers, body)
+}
+
+// Shutdown stops exporting. Spans passed to ExportSpans afterwards are dropped.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	e.stopped.Store(true)
+	return nil
+}
+
+// The types below are the OTLP/JSON encoding of an ExportTraceServiceRequest. Trace and span IDs are
+// hex strings and 64-bit integers decimal strings, as the OTLP specification requires for JSON.
+
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resourceJSON `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resourceJSON struct {
+	Attributes []keyValue `json:"attributes,omitempty"`
+}
+
+type scopeSpans struct {
+	Scope scopeJSON  `json:"scope"`
+	Spans []spanJSON `json:"spans"`
+}
+
+type scopeJSON struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type spanJSON struct {
+	TraceID           string      `json:"traceId"`
+	SpanID            string      `json:"spanId"`
+	TraceState        string      `json:"traceState,omitempty"`
+	ParentSpanID      string      `json:"parentSpanId,omitempty"`
+	Name              string      `json:"name"`
+	Kind              int         `json:"kind"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+	Attributes        []keyValue  `json:"attributes,omitempty"`
+	Events            []eventJSON `json:"events,omitempty"`
+	Links             []linkJSON  `json:"links,omitempty"`
+	Status            statusJSON  `json:"status"`
+}
+
+type eventJSON struct {
+	TimeUnixNano string     `json:"timeUnixNano"`
+	Name         string     `json:"name"`
+	Attributes   []keyValue `json:"attributes,omitempty"`
+}
+
+type linkJSON struct {
+	TraceID    string     `json:"traceId"`
+	SpanID     string     `json:"spanId"`
+	Attributes []keyValue `json:"attributes,omitempty"`
+}
+
+type statusJSON struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string     `json:"stringValue,omitempty"`
+	BoolValue   *bool       `json:"boolValue,omitempty"`
+	IntValue    *string     `json:"intValue,omitempty"`
+	DoubleValue *float64    `json:"doubleValue,omitempty"`
+	ArrayValue  *arrayValue `json:"arrayValue,omitempty"`
+}
+
+type arrayValue struct {
+	Values []anyValue `json:"values"`
+}
+
+// encodeSpans groups spans by resource and instrumentation scope.
+func encodeSpans(spans []sdktrace.ReadOnlySpan) exportRequest {
+	var req exportRequest
+	resources := make(map[*resource.Resource]int)
+	scopes := make(map[*resource.Resource]map[instrumentation.Scope]int)
+	for _, s := range spans {
+		res := s.Resource()
+		ri, ok := resources[res]
+		if !ok {
+			ri = len(req.ResourceSpans)
+			resources[res] = ri
+			scopes[res] = make(map[instrumentation.Scope]int)
+			req.ResourceSpans = append(req.ResourceSpans, resourceSpans{
+				Resource: resourceJSON{Attributes: encodeAttributes(res.Attributes())},
+			})
+		}
+		rs := &req.ResourceSpans[ri]
+
+		scope := s.InstrumentationScope()
+		scope.Attributes = attribute.Set{} // not comparable across spans and not exported
+		si, ok := scopes[res][scope]
+		if !ok {
+			si = len(rs.ScopeSpans)
+			scopes[res][scope] = si
+			rs.ScopeSpans = append(rs.ScopeSpans, scopeSpans{Scope: scopeJSON{Name: scope.Name, Version: scope.Version}})
+		}
+		rs.ScopeSpans[si].Spans = append(rs.ScopeSpans[si].Spans, encodeSpan(s))
+	}
+	return req
+}
+
+// encodeSpan encodes one span.
+func encodeSpan(s sdktrace.ReadOnlySpan) spanJSON {
+	sc := s.SpanContext()
+	out := spanJSON{
+		TraceID:           traceID(sc.TraceID()),
+		SpanID:            spanID(sc.SpanID()),
+		TraceState:        sc.TraceState().String(),
+		Name:              s.Name(),
+		Kind:              int(s.SpanKind()), // trace.SpanKind values match the OTLP enum
+		StartTimeUnixNano: unixNano(s.StartTime()),
+		EndTimeUnixNano:   unixNano(s.EndTime()),
+		Attributes:        encodeAttributes(s.Attributes()),
+	}
+	if p := s.Parent(); p.IsValid() {
+		out.ParentSpanID = spanID(p.SpanID())
+	}
+	for _, ev := range s.Events() {
+		out.Events = append(out.Events, eventJSON{
+			TimeUnixNano: unixNano(ev.Time),
+			Name:         ev.Name,
+			Attributes:   encodeAttributes(ev.Attributes),
+		})
+	}
+	for _, l := range s.Links() {
+		out.Links = append(out.Links, linkJSON{
+			TraceID:    traceID(l.SpanContext.TraceID()),
+			SpanID:     spanID(l.SpanContext.SpanID()),
+			Attributes: encodeAttributes(l.Attributes),
+		})
+	}
+	// OTLP numbers the status codes differently from the codes package
+	switch st := s.Status(); st.Code {
+	case codes.Ok:
+		out.Status = statusJSON{Code: 1}
+	case codes.Error:
+		out.Status = statusJSON{Code: 2, Message: st.Description}
+	}
+	return out
+}
+
+// encodeAttributes encodes attributes as OTLP key-value pairs.
+func encodeAttributes(attrs []attribute.KeyValue) []keyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	out := make([]keyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		out = append(out, keyValue{Key: string(kv.Key), Value: encodeValue(kv.Value)})
+	}
+	return out
+}
+
+// encodeValue encodes an attribute value.
+func encodeValue(v attribute.Value) anyValue {
+	switch v.Type() {
+	case attribute.BOOL:
+		b := v.AsBool()
+		return anyValue{BoolValue: &b}
+	case attribute.INT64:
+		i := strconv.FormatInt(v.AsInt64(), 10)
+		return anyValue{IntValue: &i}
+	case attribute.FLOAT64:
+		f := v.AsFloat64()
+		return anyValue{DoubleValue: &f}
+	case attribute.BOOLSLICE:
+		return encodeArray(v.AsBoolSlice(), attribute.BoolValue)
+	case attribute.INT64SLICE:
+		return encodeArray(v.AsInt64Slice(), attribute.Int64Value)
+	case attribute.FLOAT64SLICE:
+		return encodeArray(v.AsFloat64Slice(), attribute.Float64Value)
+	case attribute.STRINGSLICE:
+		return encodeArray(v.AsStringSlice(), attribute.StringValue)
+	default:
+		s := v.Emit()
+		return anyValue{StringValue: &s}
+	}
+}
+
+// encodeArray encodes a slice attribute value.
+func encodeArray[T any](values []T, value func(T) attribute.Value) anyValue {
+	arr := &arrayValue{Values: make([]anyValue, 0, len(values))}
+	for _, v := range values {
+		arr.Values = append(arr.Values, encodeValue(value(v)))
+	}
+	return anyValue{ArrayValue: arr}
+}
+
+func traceID(id trace.TraceID) string {
+	return hex.EncodeToString(id[:])
+}
+
+func spanID(id trace.SpanID) string {
+	return hex.EncodeToString(id[:])
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,30 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/httpjs"
+)
+
+// post sends an export request with fetch. The request itself is not traced, which would otherwise
+// produce a span for every export. Fetch is aborted when ctx ends.
+func post(ctx context.Context, endpoint string, headers map[string]string, body []byte) error {
+	req := httpjs.NewRequest(http.MethodPost, endpoint)
+	req.Untraced = true
+	req.SetHeader("Content-Type", "application/json")
+	for k, v := range headers {
+		req.SetHeader(k, v)
+	}
+	req.SetBody(body)
+	resp, err := req.DoContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%w: %d %s", ErrExportFailed, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Package tracing sets up OpenTelemetry tracing for supernet programs, in the browser as well as natively.
+//
+// The supernet packages create spans through the global OpenTelemetry API: dials of supernet.Dialer,
+// relay tunnels on both ends and httpjs requests. Without a tracer provider these are no-ops. Setup
+// installs one that exports spans with OTLP/HTTP, together with the W3C trace context propagator, which
+// carries the trace of a browser request through relay frames and HTTP headers so it can be followed end
+// to end:
+//
+//	shutdown, err := tracing.Setup(tracing.Config{
+//		ServiceName: "webapp",
+//		Endpoint:    "https://collector.example.com/v1/traces",
+//	})
+//	defer shutdown(context.Background())
+//
+// Under js/wasm spans are exported with httpjs, so the collector must allow the page's origin with CORS.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Config configures Setup.
+type Config struct {
+	// ServiceName identifies the program in the exported traces.
+	ServiceName string
+	// Endpoint is the URL of the OTLP/HTTP traces endpoint, e.g. "http://localhost:4318/v1/traces".
+	Endpoint string
+	// Headers are added to every export request, e.g. for authentication.
+	Headers map[string]string
+	// SampleRatio is the fraction of new traces recorded (default 1). Traces continued from a remote
+	// parent follow the parent's sampling decision.
+	SampleRatio float64
+}
+
+// Setup installs a global tracer provider exporting to cfg.Endpoint and the W3C trace context and baggage
+// propagators. The returned function flushes pending spans and stops the provider.
+func Setup(cfg Config) (shutdown func(context.Context) error, err error) {
+	if cfg.SampleRatio <= 0 {
+		cfg.SampleRatio = 1
+	}
+	exp, err := NewExporter(cfg.Endpoint, cfg.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tp.Shutdown, nil
+}
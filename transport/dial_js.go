@@ -0,0 +1,64 @@
+package transport
+
+import (
+	"context"
+	"net/url"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/caps"
+	"pkg.gfire.dev/supernet/web/wasmlib/ssejs"
+	"pkg.gfire.dev/supernet/web/wasmlib/webtransportjs"
+	"pkg.gfire.dev/supernet/web/wasmlib/wsjs"
+)
+
+// Dialers returns browser dial functions for an endpoint given as an https URL. All transports use the same
+// URL; the server tells them apart by request type (HTTP/3 CONNECT, WebSocket upgrade, or an event stream
+// request). Transports the browser lacks are left out.
+func Dialers(endpoint string) (map[Kind]DialFunc, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	wsURL := *u
+	switch u.Scheme {
+	case "https":
+		wsURL.Scheme = "wss"
+	case "http":
+		wsURL.Scheme = "ws"
+	}
+
+	dialers := map[Kind]DialFunc{
+		WebSocket: func(ctx context.Context) (Conn, error) {
+			return dialWebSocket(ctx, wsURL.String())
+		},
+		SSE: func(ctx context.Context) (Conn, error) {
+			return ssejs.Dial(ctx, endpoint)
+		},
+	}
+	if caps.Has(caps.WebTransport) && u.Scheme == "https" {
+		dialers[WebTransport] = func(ctx context.Context) (Conn, error) {
+			return webtransportjs.DialConn(ctx, endpoint, webtransportjs.Options{})
+		}
+	}
+	return dialers, nil
+}
+
+// DialEndpoint connects to endpoint over the best transport the browser supports. Fields of cfg other than
+// Dialers are honored.
+func DialEndpoint(ctx context.Context, endpoint string, cfg Config) (*Manager, error) {
+	dialers, err := Dialers(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Dialers = dialers
+	return Dial(ctx, cfg)
+}
+
+// dialWebSocket connects to uri with the browser's WebSocket API, closing the socket if ctx is done first.
+func dialWebSocket(ctx context.Context, uri string) (Conn, error) {
+	conn, err := wsjs.DialContext(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
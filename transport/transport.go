@@ -0,0 +1,356 @@
+// Package transport establishes a message connection over the best transport available, falling back
+// through a configurable order such as WebTransport, WebSocket and server-sent events. The Manager presents
+// a single connection to the application, reconnects with backoff when the transport fails and upgrades in
+// the background once a preferred transport becomes reachable.
+//
+// A transport change means a new connection to the server. Applications that need every message delivered
+// exactly once across changes should layer the outbox or session migration on top.
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"pkg.gfire.dev/supernet/retry"
+)
+
+var (
+	// ErrClosed is returned when using a closed manager
+	ErrClosed = errors.New("transport manager closed")
+	// ErrNoTransport is returned when no configured transport could be established
+	ErrNoTransport = errors.New("no transport available")
+)
+
+const (
+	// defaultDialTimeout is the default time limit of a single dial attempt
+	defaultDialTimeout = 10 * time.Second
+	// defaultUpgradeInterval is the default interval between attempts to reach a preferred transport
+	defaultUpgradeInterval = time.Minute
+	// defaultUpgradeGrace is the default time an old connection keeps delivering messages after an upgrade
+	defaultUpgradeGrace = 2 * time.Second
+)
+
+// Kind names a transport.
+type Kind string
+
+const (
+	WebTransport Kind = "webtransport" // HTTP/3 WebTransport session
+	WebSocket    Kind = "websocket"    // WebSocket connection
+	SSE          Kind = "sse"          // Server-sent events downstream with fetch upstream
+)
+
+// DefaultOrder is the default preference order of transports.
+var DefaultOrder = []Kind{WebTransport, WebSocket, SSE}
+
+// Conn is a message-oriented connection. It matches the NextMessage/Send/Close contract of wsjs.Conn,
+// webrtcjs.DataChannel and mux.Stream.
+type Conn interface {
+	// NextMessage blocks until the next message is received or the connection is closed.
+	NextMessage() ([]byte, error)
+	// Send sends a single message.
+	Send(data []byte) error
+	// Close closes the connection.
+	Close() error
+}
+
+// DialFunc establishes a connection over one transport.
+type DialFunc func(ctx context.Context) (Conn, error)
+
+// Config configures a Manager.
+type Config struct {
+	// Dialers maps transports to dial functions. Transports without a dialer are skipped.
+	Dialers map[Kind]DialFunc
+	// Order is the preference order of transports (default DefaultOrder).
+	Order []Kind
+	// DialTimeout limits each dial attempt (default 10s).
+	DialTimeout time.Duration
+	// Reconnect is the backoff between reconnection rounds after the connection failed. When its attempt or
+	// time limit is reached the manager closes with ErrNoTransport.
+	Reconnect retry.Policy
+	// UpgradeInterval is the interval between attempts to reach a preferred transport while connected over
+	// a fallback (default 1m). A negative value disables upgrades.
+	UpgradeInterval time.Duration
+	// UpgradeGrace is how long the previous connection keeps delivering messages after an upgrade (default 2s).
+	UpgradeGrace time.Duration
+	// OnChange is called whenever a connection is established, with the transport it uses.
+	OnChange func(kind Kind)
+}
+
+// Manager maintains a connection over the best available transport.
+type Manager struct {
+	cfg Config
+
+	// ctx is cancelled when the manager is closed
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// conn is the current connection, nil while reconnecting
+	conn Conn
+	// kind is the transport of conn
+	kind Kind
+	// gen increments whenever conn is replaced
+	gen uint64
+	// reconnecting is set while a reconnection round is running
+	reconnecting bool
+	// err is set once the manager is closed
+	err error
+	// changed is closed and replaced whenever conn or err changes
+	changed chan struct{}
+}
+
+// Dial connects over the first transport in order that can be established and returns a manager for it.
+func Dial(ctx context.Context, cfg Config) (*Manager, error) {
+	if len(cfg.Order) == 0 {
+		cfg.Order = DefaultOrder
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = defaultDialTimeout
+	}
+	if cfg.UpgradeInterval == 0 {
+		cfg.UpgradeInterval = defaultUpgradeInterval
+	}
+	if cfg.UpgradeGrace <= 0 {
+		cfg.UpgradeGrace = defaultUpgradeGrace
+	}
+
+	m := &Manager{cfg: cfg, changed: make(chan struct{})}
+	conn, kind, err := m.dial(ctx, cfg.Order)
+	if err != nil {
+		return nil, err
+	}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.install(conn, kind, false)
+
+	if cfg.UpgradeInterval > 0 {
+		go m.upgradeLoop()
+	}
+	return m, nil
+}
+
+// Kind returns the transport currently in use, or "" while reconnecting.
+func (m *Manager) Kind() Kind {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn == nil {
+		return ""
+	}
+	return m.kind
+}
+
+// NextMessage blocks until the next message is received. Reconnections are transparent; an error is only
+// returned once the manager is closed.
+func (m *Manager) NextMessage() ([]byte, error) {
+	for {
+		conn, gen, err := m.current()
+		if err != nil {
+			return nil, err
+		}
+		msg, err := conn.NextMessage()
+		if err == nil {
+			return msg, nil
+		}
+		m.broken(gen)
+	}
+}
+
+// Send sends a message, waiting for a reconnection if the current transport fails.
+func (m *Manager) Send(data []byte) error {
+	for {
+		conn, gen, err := m.current()
+		if err != nil {
+			return err
+		}
+		if err := conn.Send(data); err == nil {
+			return nil
+		}
+		m.broken(gen)
+	}
+}
+
+// Close closes the manager and its connection.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	if m.err != nil {
+		m.mu.Unlock()
+		return nil
+	}
+	m.err = ErrClosed
+	conn := m.conn
+	m.conn = nil
+	m.broadcast()
+	m.mu.Unlock()
+
+	m.cancel()
+	if conn != nil {
+		return conn.Close()
+	}
+	return nil
+}
+
+// current waits for a connection and returns it with its generation.
+func (m *Manager) current() (Conn, uint64, error) {
+	for {
+		m.mu.Lock()
+		conn, gen, err, changed := m.conn, m.gen, m.err, m.changed
+		m.mu.Unlock()
+
+		if err != nil {
+			return nil, 0, err
+		}
+		if conn != nil {
+			return conn, gen, nil
+		}
+		<-changed
+	}
+}
+
+// broken reports that the connection of generation gen failed and starts reconnecting.
+// Failures of connections that were already replaced are ignored.
+func (m *Manager) broken(gen uint64) {
+	m.mu.Lock()
+	if gen != m.gen || m.err != nil || m.reconnecting {
+		m.mu.Unlock()
+		return
+	}
+	conn := m.conn
+	m.conn = nil
+	m.reconnecting = true
+	m.mu.Unlock()
+
+	if conn != nil {
+		conn.Close()
+	}
+	go m.reconnect()
+}
+
+// reconnect dials transports in order with backoff until one succeeds or the policy gives up.
+func (m *Manager) reconnect() {
+	backoff := m.cfg.Reconnect.Backoff()
+	for {
+		conn, kind, err := m.dial(m.ctx, m.cfg.Order)
+		if err == nil {
+			if !m.install(conn, kind, false) {
+				conn.Close()
+			}
+			return
+		}
+		if !backoff.Wait(m.ctx) {
+			break
+		}
+	}
+
+	m.mu.Lock()
+	m.reconnecting = false
+	if m.err == nil {
+		m.err = ErrNoTransport
+		m.broadcast()
+	}
+	m.mu.Unlock()
+	m.cancel()
+}
+
+// upgradeLoop periodically tries transports preferred over the current one.
+func (m *Manager) upgradeLoop() {
+	ticker := time.NewTicker(m.cfg.UpgradeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.mu.Lock()
+		conn, kind := m.conn, m.kind
+		m.mu.Unlock()
+		if conn == nil {
+			continue
+		}
+
+		better := m.cfg.Order[:m.rank(kind)]
+		if len(better) == 0 {
+			continue
+		}
+		next, nextKind, err := m.dial(m.ctx, better)
+		if err != nil {
+			continue
+		}
+		if !m.install(next, nextKind, true) {
+			next.Close()
+			continue
+		}
+		// Let messages already in flight on the old connection drain before closing it
+		time.AfterFunc(m.cfg.UpgradeGrace, func() {
+			conn.Close()
+		})
+	}
+}
+
+// install makes conn the current connection. Upgrades only replace a working connection; otherwise
+// install ends the reconnection round. It returns false if conn was not installed.
+func (m *Manager) install(conn Conn, kind Kind, upgrade bool) bool {
+	m.mu.Lock()
+	if upgrade && (m.conn == nil || m.reconnecting) {
+		m.mu.Unlock()
+		return false
+	}
+	m.reconnecting = false
+	if m.err != nil {
+		m.mu.Unlock()
+		return false
+	}
+	m.conn, m.kind = conn, kind
+	m.gen++
+	m.broadcast()
+	m.mu.Unlock()
+
+	if m.cfg.OnChange != nil {
+		m.cfg.OnChange(kind)
+	}
+	return true
+}
+
+// dial tries the given transports in order and returns the first connection established.
+func (m *Manager) dial(ctx context.Context, order []Kind) (Conn, Kind, error) {
+	var errs []error
+	for _, kind := range order {
+		dial, ok := m.cfg.Dialers[kind]
+		if !ok || dial == nil {
+			continue
+		}
+
+		dialCtx, cancel := context.WithTimeout(ctx, m.cfg.DialTimeout)
+		conn, err := dial(dialCtx)
+		cancel()
+		if err == nil {
+			return conn, kind, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", kind, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, "", fmt.Errorf("%w: %w", ErrNoTransport, errors.Join(errs...))
+}
+
+// rank returns the position of kind in the preference order.
+func (m *Manager) rank(kind Kind) int {
+	for i, k := range m.cfg.Order {
+		if k == kind {
+			return i
+		}
+	}
+	return len(m.cfg.Order)
+}
+
+// broadcast wakes goroutines waiting for a connection. Must be called with mu held.
+func (m *Manager) broadcast() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
@@ -0,0 +1,697 @@
+// Package turn manages TURN relays for supernet clients.
+//
+// WebRTC connections between peers behind restrictive NATs only succeed through a TURN relay. Handler
+// issues short-lived credentials for a TURN server operated alongside the supernet relay, and a Source
+// fetches them for p2p.WebRTCTransport, whose ICE agent falls back to the relay when no direct path is
+// found:
+//
+//	http.Handle("/turn", turn.Handler(turn.HandlerConfig{
+//		URLs:   []string{"turn:turn.example.com:3478"},
+//		Secret: secret, // static-auth-secret of the TURN server
+//	}))
+//
+//	transport := &p2p.WebRTCTransport{TURN: &turn.Source{URL: "https://relay.example.com/turn"}}
+//
+// Allocate is a TURN client for everything else: it requests a relayed UDP address over any connection
+// to the TURN server, including TCP connections tunneled through a supernet relay from the browser, and
+// uses it as a net.PacketConn.
+package turn
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pkg.gfire.dev/supernet/logging"
+)
+
+var (
+	// ErrClosed is returned when using a closed allocation
+	ErrClosed = errors.New("turn: allocation closed")
+	// ErrMalformed is returned for messages from the server that cannot be decoded or fail the integrity check
+	ErrMalformed = errors.New("turn: malformed message")
+	// ErrRequestFailed is returned when the server answers a request with an error
+	ErrRequestFailed = errors.New("turn: request failed")
+	// ErrNoResponse is returned when a request over a datagram connection stays unanswered
+	ErrNoResponse = errors.New("turn: no response")
+	// ErrInvalidAddr is returned by WriteTo for addresses that are not IP addresses with a port
+	ErrInvalidAddr = errors.New("turn: invalid peer address")
+)
+
+const (
+	// defaultLifetime is the default allocation lifetime requested from the server
+	defaultLifetime = 10 * time.Minute
+	// permissionRefresh is the interval of refreshing permissions and channel bindings, which expire
+	// after 5 and 10 minutes
+	permissionRefresh = 4 * time.Minute
+	// requestTimeout bounds requests made without a deadline of the caller
+	requestTimeout = 10 * time.Second
+	// retransmitTimeout is the initial retransmission timeout of requests over datagram connections,
+	// doubled for every retransmission
+	retransmitTimeout = 500 * time.Millisecond
+	// maxRetransmits is the number of retransmissions before giving up on a request
+	maxRetransmits = 6
+	// minChannel and maxChannel bound the channel numbers bound to peers; further peers are reached
+	// with Send indications
+	minChannel = 0x4000
+	maxChannel = 0x4FFF
+	// packetBacklog is the number of received packets waiting for ReadFrom before new ones are dropped
+	packetBacklog = 256
+	// maxPacket is the largest message read from a datagram connection
+	maxPacket = 64 << 10
+)
+
+// Config configures an allocation.
+type Config struct {
+	// Username and Password are the long-term credentials of the TURN server, e.g. from Credentials.
+	Username string
+	Password string
+	// Lifetime is the allocation lifetime requested from the server (default 10 minutes). The
+	// allocation is refreshed before it expires.
+	Lifetime time.Duration
+	// Datagram tells that the connection preserves message boundaries, as UDP does. It is detected for
+	// connections implementing net.PacketConn; other connections are treated as byte streams such as TCP,
+	// TLS or a WebSocket tunnel.
+	Datagram bool
+	// Logger receives refresh failures. Defaults to logging.For("turn").
+	Logger *slog.Logger
+}
+
+// Stats are the usage statistics of an allocation.
+type Stats struct {
+	PacketsSent     uint64 // Packets relayed to peers
+	BytesSent       uint64 // Payload bytes relayed to peers
+	PacketsReceived uint64 // Packets received from peers
+	BytesReceived   uint64 // Payload bytes received from peers
+	Dropped         uint64 // Received packets dropped because ReadFrom fell behind
+	Peers           int    // Peers with a permission
+	Channels        int    // Peers reached over a channel
+}
+
+// Allocation is a relayed UDP address on a TURN server, used as a net.PacketConn: packets written to a
+// peer leave the server from the relayed address, and packets of peers sent to it are read with ReadFrom.
+//
+// Permissions and channel bindings are created on the first packet to a peer and refreshed while the
+// allocation is open.
+type Allocation struct {
+	conn     net.Conn
+	cfg      Config
+	datagram bool
+	log      *slog.Logger
+	// stream reads the messages of stream connections
+	stream *bufio.Reader
+
+	// relayed is the relayed address, mapped the client's address as seen by the server
+	relayed netip.AddrPort
+	mapped  netip.AddrPort
+
+	mu sync.Mutex
+	// realm, nonce and key authenticate requests once the server has challenged the first one
+	realm, nonce string
+	key          []byte
+	// lifetime is the lifetime granted by the server
+	lifetime time.Duration
+	// pending routes responses to their requests by transaction ID
+	pending map[[12]byte]chan response
+	// peers holds the permissions by peer address, channels the peers by channel number
+	peers       map[netip.AddrPort]*peer
+	channels    map[uint16]netip.AddrPort
+	nextChannel uint16
+
+	// writeMu keeps messages from interleaving on stream connections
+	writeMu sync.Mutex
+
+	// packets queues received packets for ReadFrom
+	packets chan packet
+
+	deadlineMu    sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+
+	packetsSent, bytesSent         atomic.Uint64
+	packetsReceived, bytesReceived atomic.Uint64
+	dropped                        atomic.Uint64
+
+	// done is closed when the allocation ends, err tells why
+	done      chan struct{}
+	err       error
+	closeOnce sync.Once
+}
+
+// peer is the permission of a peer.
+type peer struct {
+	// channel is the bound channel number, 0 for peers reached with Send indications
+	channel uint16
+	// ready is closed when the permission is installed or failed with err
+	ready chan struct{}
+	err   error
+}
+
+// packet is a packet received from a peer.
+type packet struct {
+	data []byte
+	from netip.AddrPort
+}
+
+// response is a response to a request with its encoding, needed for the integrity check.
+type response struct {
+	msg *message
+	raw []byte
+}
+
+// Allocate requests a UDP allocation from the TURN server at the other end of conn. The connection may
+// be UDP, TCP, TLS or any byte stream reaching the server, e.g. a supernet.Dialer connection through a
+// relay in the browser, where UDP is not available otherwise. The allocation owns conn and closes it with
+// Close, or when the allocation fails.
+func Allocate(ctx context.Context, conn net.Conn, cfg Config) (*Allocation, error) {
+	if cfg.Lifetime <= 0 {
+		cfg.Lifetime = defaultLifetime
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logging.For("turn")
+	}
+	_, isPacket := conn.(net.PacketConn)
+
+	a := &Allocation{
+		conn:        conn,
+		stream:      bufio.NewReader(conn),
+		cfg:         cfg,
+		datagram:    cfg.Datagram || isPacket,
+		log:         cfg.Logger,
+		pending:     make(map[[12]byte]chan response),
+		peers:       make(map[netip.AddrPort]*peer),
+		channels:    make(map[uint16]netip.AddrPort),
+		nextChannel: minChannel,
+		packets:     make(chan packet, packetBacklog),
+		done:        make(chan struct{}),
+	}
+	go a.read()
+
+	resp, err := a.request(ctx, methodAllocate,
+		attr{attrRequestedTransport, []byte{protoUDP, 0, 0, 0}},
+		attr{attrLifetime, uint32Attr(uint32(cfg.Lifetime / time.Second))},
+	)
+	if err != nil {
+		a.fail(err)
+		return nil, err
+	}
+	relayed, ok := resp.address(attrXORRelayedAddress)
+	if !ok {
+		a.fail(ErrMalformed)
+		return nil, ErrMalformed
+	}
+	a.relayed = relayed
+	a.mapped, _ = resp.address(attrXORMappedAddress)
+	a.lifetime = lifetimeOf(resp, cfg.Lifetime)
+
+	go a.refresh()
+	return a, nil
+}
+
+// RelayedAddr returns the relayed address peers send packets to.
+func (a *Allocation) RelayedAddr() *net.UDPAddr {
+	return udpAddr(a.relayed)
+}
+
+// MappedAddr returns the client's address as seen by the server, its server-reflexive address, or nil
+// when the server did not report it.
+func (a *Allocation) MappedAddr() *net.UDPAddr {
+	if !a.mapped.IsValid() {
+		return nil
+	}
+	return udpAddr(a.mapped)
+}
+
+// Stats returns the usage statistics of the allocation.
+func (a *Allocation) Stats() Stats {
+	s := Stats{
+		PacketsSent:     a.packetsSent.Load(),
+		BytesSent:       a.bytesSent.Load(),
+		PacketsReceived: a.packetsReceived.Load(),
+		BytesReceived:   a.bytesReceived.Load(),
+		Dropped:         a.dropped.Load(),
+	}
+	a.mu.Lock()
+	s.Peers = len(a.peers)
+	s.Channels = len(a.channels)
+	a.mu.Unlock()
+	return s
+}
+
+// ReadFrom reads the next packet of a peer.
+func (a *Allocation) ReadFrom(p []byte) (int, net.Addr, error) {
+	a.deadlineMu.Lock()
+	deadline := a.readDeadline
+	a.deadlineMu.Unlock()
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case pkt := <-a.packets:
+		return copy(p, pkt.data), udpAddr(pkt.from), nil
+	case <-a.done:
+		return 0, nil, a.err
+	case <-timeout:
+		return 0, nil, os.ErrDeadlineExceeded
+	}
+}
+
+// WriteTo relays p to the peer at addr. The first packet to a peer waits for its permission.
+func (a *Allocation) WriteTo(p []byte, addr net.Addr) (int, error) {
+	to, err := addrPort(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	a.deadlineMu.Lock()
+	deadline := a.writeDeadline
+	a.deadlineMu.Unlock()
+	if deadline.IsZero() {
+		deadline = time.Now().Add(requestTimeout)
+	} else if time.Now().After(deadline) {
+		return 0, os.ErrDeadlineExceeded
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	pr, err := a.permit(ctx, to)
+	if err != nil {
+		return 0, err
+	}
+
+	var frame []byte
+	if pr.channel != 0 {
+		frame = appendChannelData(make([]byte, 0, channelHeaderSize+len(p)+3), pr.channel, p, !a.datagram)
+	} else {
+		m := newMessage(methodSend | classIndication)
+		m.addAddress(attrXORPeerAddress, to)
+		m.add(attrData, p)
+		frame = m.encode(nil)
+	}
+	if err := a.write(frame); err != nil {
+		return 0, err
+	}
+	a.packetsSent.Add(1)
+	a.bytesSent.Add(uint64(len(p)))
+	return len(p), nil
+}
+
+// Close deletes the allocation on the server and closes the connection. Safe to call multiple times.
+func (a *Allocation) Close() error {
+	select {
+	case <-a.done:
+		return nil
+	default:
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	a.request(ctx, methodRefresh, attr{attrLifetime, uint32Attr(0)})
+	a.fail(ErrClosed)
+	return nil
+}
+
+// LocalAddr returns the relayed address.
+func (a *Allocation) LocalAddr() net.Addr {
+	return a.RelayedAddr()
+}
+
+// SetDeadline sets both the read and write deadlines.
+func (a *Allocation) SetDeadline(t time.Time) error {
+	a.SetReadDeadline(t)
+	return a.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for future ReadFrom calls.
+func (a *Allocation) SetReadDeadline(t time.Time) error {
+	a.deadlineMu.Lock()
+	defer a.deadlineMu.Unlock()
+	a.readDeadline = t
+	return nil
+}
+
+// SetWriteDeadline sets the deadline for future WriteTo calls, bounding the wait for new permissions.
+func (a *Allocation) SetWriteDeadline(t time.Time) error {
+	a.deadlineMu.Lock()
+	defer a.deadlineMu.Unlock()
+	a.writeDeadline = t
+	return nil
+}
+
+// permit returns the permission of a peer, creating it on first use.
+func (a *Allocation) permit(ctx context.Context, to netip.AddrPort) (*peer, error) {
+	a.mu.Lock()
+	pr, ok := a.peers[to]
+	if !ok {
+		pr = &peer{ready: make(chan struct{})}
+		if a.nextChannel <= maxChannel {
+			pr.channel = a.nextChannel
+			a.nextChannel++
+		}
+		a.peers[to] = pr
+		a.mu.Unlock()
+
+		pr.err = a.bind(ctx, to, pr.channel)
+		if pr.err == nil && pr.channel != 0 {
+			a.mu.Lock()
+			a.channels[pr.channel] = to
+			a.mu.Unlock()
+		}
+		if pr.err != nil {
+			a.mu.Lock()
+			delete(a.peers, to)
+			a.mu.Unlock()
+		}
+		close(pr.ready)
+		return pr, pr.err
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-pr.ready:
+		return pr, pr.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// bind installs or refreshes the permission of a peer, binding it to channel unless that is 0.
+func (a *Allocation) bind(ctx context.Context, to netip.AddrPort, channel uint16) error {
+	peerAttr := newMessage(0)
+	peerAttr.addAddress(attrXORPeerAddress, to)
+	if channel == 0 {
+		_, err := a.request(ctx, methodCreatePermission, peerAttr.attrs...)
+		return err
+	}
+	_, err := a.request(ctx, methodChannelBind, append(peerAttr.attrs, attr{attrChannelNumber, channelAttr(channel)})...)
+	return err
+}
+
+// refresh keeps the allocation and its permissions alive until it is closed.
+func (a *Allocation) refresh() {
+	a.mu.Lock()
+	lifetime := a.lifetime
+	a.mu.Unlock()
+	allocation := time.NewTimer(lifetime / 2)
+	defer allocation.Stop()
+	permissions := time.NewTicker(permissionRefresh)
+	defer permissions.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-allocation.C:
+			ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+			resp, err := a.request(ctx, methodRefresh, attr{attrLifetime, uint32Attr(uint32(a.cfg.Lifetime / time.Second))})
+			cancel()
+			if err != nil {
+				a.log.Warn("turn allocation refresh failed", "relayed", a.relayed.String(), "err", err)
+				a.fail(err)
+				return
+			}
+			lifetime = lifetimeOf(resp, a.cfg.Lifetime)
+			a.mu.Lock()
+			a.lifetime = lifetime
+			a.mu.Unlock()
+			allocation.Reset(lifetime / 2)
+		case <-permissions.C:
+			a.mu.Lock()
+			peers := make(map[netip.AddrPort]uint16, len(a.peers))
+			for addr, pr := range a.peers {
+				select {
+				case <-pr.ready:
+					if pr.err == nil {
+						peers[addr] = pr.channel
+					}
+				default:
+				}
+			}
+			a.mu.Unlock()
+			for addr, channel := range peers {
+				ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+				if err := a.bind(ctx, addr, channel); err != nil {
+					a.log.Debug("turn permission refresh failed", "peer", addr.String(), "err", err)
+				}
+				cancel()
+			}
+		}
+	}
+}
+
+// request sends an authenticated request with attrs and waits for its success response. A challenge
+// of the server, or a stale nonce, is answered by sending the request again with fresh credentials.
+func (a *Allocation) request(ctx context.Context, method uint16, attrs ...attr) (*message, error) {
+	for attempt := 0; ; attempt++ {
+		m := newMessage(method | classRequest)
+		m.attrs = append(m.attrs, attrs...)
+		a.mu.Lock()
+		key := a.key
+		if key != nil {
+			m.add(attrUsername, []byte(a.cfg.Username))
+			m.add(attrRealm, []byte(a.realm))
+			m.add(attrNonce, []byte(a.nonce))
+		}
+		a.mu.Unlock()
+
+		resp, err := a.roundTrip(ctx, m, m.encode(key))
+		if err != nil {
+			return nil, err
+		}
+		if key != nil {
+			if _, signed := resp.msg.get(attrMessageIntegrity); signed && !checkIntegrity(resp.raw, key) {
+				return nil, ErrMalformed
+			}
+		}
+		if resp.msg.class() == classSuccess {
+			return resp.msg, nil
+		}
+
+		code, reason := resp.msg.errorCode()
+		if (code == 401 || code == 438) && attempt < 2 {
+			realm, _ := resp.msg.get(attrRealm)
+			nonce, hasNonce := resp.msg.get(attrNonce)
+			if hasNonce {
+				a.mu.Lock()
+				if len(realm) > 0 {
+					a.realm = string(realm)
+				}
+				a.nonce = string(nonce)
+				a.key = longTermKey(a.cfg.Username, a.realm, a.cfg.Password)
+				a.mu.Unlock()
+				continue
+			}
+		}
+		return nil, fmt.Errorf("%w: %d %s", ErrRequestFailed, code, reason)
+	}
+}
+
+// roundTrip sends the encoded request b of m and waits for the response, retransmitting it over
+// datagram connections.
+func (a *Allocation) roundTrip(ctx context.Context, m *message, b []byte) (response, error) {
+	ch := make(chan response, 1)
+	a.mu.Lock()
+	a.pending[m.txID] = ch
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		delete(a.pending, m.txID)
+		a.mu.Unlock()
+	}()
+
+	if err := a.write(b); err != nil {
+		return response{}, err
+	}
+
+	var retransmit <-chan time.Time
+	rto, retransmits := retransmitTimeout, 0
+	if a.datagram {
+		timer := time.NewTimer(rto)
+		defer timer.Stop()
+		retransmit = timer.C
+		for {
+			select {
+			case resp := <-ch:
+				return resp, nil
+			case <-retransmit:
+				if retransmits == maxRetransmits {
+					return response{}, ErrNoResponse
+				}
+				retransmits++
+				if err := a.write(b); err != nil {
+					return response{}, err
+				}
+				rto *= 2
+				timer.Reset(rto)
+			case <-a.done:
+				return response{}, a.err
+			case <-ctx.Done():
+				return response{}, ctx.Err()
+			}
+		}
+	}
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-a.done:
+		return response{}, a.err
+	case <-ctx.Done():
+		return response{}, ctx.Err()
+	}
+}
+
+// write sends a message to the server.
+func (a *Allocation) write(b []byte) error {
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+	if _, err := a.conn.Write(b); err != nil {
+		select {
+		case <-a.done:
+			return a.err
+		default:
+		}
+		return err
+	}
+	return nil
+}
+
+// read dispatches the messages of the server until the connection fails.
+func (a *Allocation) read() {
+	next := a.readStream
+	if a.datagram {
+		buf := make([]byte, maxPacket)
+		next = func() ([]byte, error) {
+			n, err := a.conn.Read(buf)
+			return buf[:n], err
+		}
+	}
+	for {
+		b, err := next()
+		if err != nil {
+			a.fail(err)
+			return
+		}
+		a.dispatch(b)
+	}
+}
+
+// readStream returns the next STUN or ChannelData message of a stream connection.
+func (a *Allocation) readStream() ([]byte, error) {
+	head, err := a.stream.Peek(4)
+	if err != nil {
+		return nil, err
+	}
+	length := int(binary.BigEndian.Uint16(head[2:]))
+	if head[0]&0xC0 == 0x40 {
+		length += channelHeaderSize + pad(length)
+	} else {
+		length += headerSize
+	}
+	b := make([]byte, length)
+	if _, err := io.ReadFull(a.stream, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// dispatch handles a message of the server.
+func (a *Allocation) dispatch(b []byte) {
+	if len(b) >= channelHeaderSize && b[0]&0xC0 == 0x40 {
+		channel, n := binary.BigEndian.Uint16(b), int(binary.BigEndian.Uint16(b[2:]))
+		if len(b) < channelHeaderSize+n {
+			return
+		}
+		a.mu.Lock()
+		from, ok := a.channels[channel]
+		a.mu.Unlock()
+		if ok {
+			a.deliver(packet{data: append([]byte(nil), b[channelHeaderSize:channelHeaderSize+n]...), from: from})
+		}
+		return
+	}
+
+	m, err := decodeMessage(b)
+	if err != nil {
+		return
+	}
+	switch m.class() {
+	case classSuccess, classError:
+		a.mu.Lock()
+		ch, ok := a.pending[m.txID]
+		a.mu.Unlock()
+		if ok {
+			select {
+			case ch <- response{msg: m, raw: append([]byte(nil), b...)}:
+			default:
+			}
+		}
+	case classIndication:
+		if m.method() != methodData {
+			return
+		}
+		from, ok := m.address(attrXORPeerAddress)
+		data, hasData := m.get(attrData)
+		if ok && hasData {
+			a.deliver(packet{data: append([]byte(nil), data...), from: from})
+		}
+	}
+}
+
+// deliver queues a received packet for ReadFrom.
+func (a *Allocation) deliver(pkt packet) {
+	select {
+	case a.packets <- pkt:
+		a.packetsReceived.Add(1)
+		a.bytesReceived.Add(uint64(len(pkt.data)))
+	default:
+		a.dropped.Add(1)
+	}
+}
+
+// fail ends the allocation with err and closes the connection.
+func (a *Allocation) fail(err error) {
+	a.closeOnce.Do(func() {
+		a.err = err
+		close(a.done)
+		a.conn.Close()
+	})
+}
+
+// lifetimeOf returns the LIFETIME of a response, or def when it has none.
+func lifetimeOf(m *message, def time.Duration) time.Duration {
+	if v, ok := m.get(attrLifetime); ok && len(v) == 4 {
+		if d := time.Duration(binary.BigEndian.Uint32(v)) * time.Second; d > 0 {
+			return d
+		}
+	}
+	return def
+}
+
+// addrPort converts a peer address to a netip.AddrPort.
+func addrPort(addr net.Addr) (netip.AddrPort, error) {
+	if u, ok := addr.(*net.UDPAddr); ok {
+		if ap := u.AddrPort(); ap.Addr().IsValid() {
+			return netip.AddrPortFrom(ap.Addr().Unmap(), ap.Port()), nil
+		}
+	}
+	ap, err := netip.ParseAddrPort(addr.String())
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("%w: %s", ErrInvalidAddr, addr)
+	}
+	return netip.AddrPortFrom(ap.Addr().Unmap(), ap.Port()), nil
+}
@@ -0,0 +1,105 @@
+package turn
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pkg.gfire.dev/supernet/internal/cors"
+)
+
+// defaultTTL is the default validity period of issued credentials
+const defaultTTL = time.Hour
+
+// ICEServer describes a STUN or TURN server of a WebRTC configuration. It converts to
+// webrtcjs.ICEServer, and its JSON form is the RTCIceServer dictionary.
+type ICEServer struct {
+	URLs       []string `json:"urls"`                 // Server URLs, e.g. "turn:turn.example.com:3478?transport=udp"
+	Username   string   `json:"username,omitempty"`   // TURN username
+	Credential string   `json:"credential,omitempty"` // TURN password
+}
+
+// Credentials issues time-limited credentials for a TURN server sharing secret, following the TURN REST
+// API draft implemented by coturn (use-auth-secret) and most other servers: the username is the expiry
+// time in Unix seconds and the user, the password its HMAC-SHA1 under the secret.
+func Credentials(secret []byte, user string, expires time.Time) (username, password string) {
+	username = strconv.FormatInt(expires.Unix(), 10)
+	if user != "" {
+		username += ":" + user
+	}
+	mac := hmac.New(sha1.New, secret)
+	mac.Write([]byte(username))
+	return username, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// HandlerConfig configures Handler.
+type HandlerConfig struct {
+	// URLs are the TURN server URLs handed out with credentials, e.g. "turn:turn.example.com:3478" and
+	// "turns:turn.example.com:5349?transport=tcp".
+	URLs []string
+	// STUN are STUN server URLs handed out without credentials.
+	STUN []string
+	// Secret is the authentication secret shared with the TURN server.
+	Secret []byte
+	// TTL is the validity period of issued credentials (default 1 hour).
+	TTL time.Duration
+	// Authenticate returns the identity of the client, which becomes the user of its credentials, see
+	// relay.TicketAuth. Without it, credentials are issued to anyone.
+	Authenticate func(r *http.Request) (identity string, err error)
+	// AllowedOrigins are the host patterns of web pages on other origins allowed to fetch credentials,
+	// as in websocket.AcceptOptions.OriginPatterns.
+	AllowedOrigins []string
+}
+
+// iceConfig is the JSON response of Handler.
+type iceConfig struct {
+	ICEServers []ICEServer `json:"iceServers"`
+	// TTL is the validity period of the credentials in seconds
+	TTL int64 `json:"ttl"`
+}
+
+// Handler returns an HTTP handler issuing TURN credentials to WebRTC clients, to be served next to the
+// relay. A Source fetches them.
+func Handler(cfg HandlerConfig) http.Handler {
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultTTL
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cors.Allow(w, r, cfg.AllowedOrigins, "Authorization")
+		switch r.Method {
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case http.MethodGet:
+		default:
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		var user string
+		if cfg.Authenticate != nil {
+			id, err := cfg.Authenticate(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			user = id
+		}
+
+		resp := iceConfig{TTL: int64(cfg.TTL / time.Second)}
+		if len(cfg.STUN) > 0 {
+			resp.ICEServers = append(resp.ICEServers, ICEServer{URLs: cfg.STUN})
+		}
+		if len(cfg.URLs) > 0 {
+			username, password := Credentials(cfg.Secret, user, time.Now().Add(cfg.TTL))
+			resp.ICEServers = append(resp.ICEServers, ICEServer{URLs: cfg.URLs, Username: username, Credential: password})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(resp)
+	})
+}
@@ -0,0 +1,31 @@
+package turn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/httpjs"
+)
+
+// fetch gets the JSON document at url into v with fetch. Fetch is aborted when ctx ends.
+func fetch(ctx context.Context, url string, header map[string]string, v any) error {
+	req := httpjs.NewRequest(http.MethodGet, url)
+	for k, val := range header {
+		req.SetHeader(k, val)
+	}
+	resp, err := req.DoContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	body, err := resp.ReadAll()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
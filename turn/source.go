@@ -0,0 +1,46 @@
+package turn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrFetchFailed is returned when a Handler does not answer with ICE servers
+var ErrFetchFailed = errors.New("turn: fetching ICE servers failed")
+
+// Source fetches ICE servers with TURN credentials from a Handler and caches them until three quarters
+// of their validity period have passed, so connections always start with credentials that outlive them
+// for a while.
+type Source struct {
+	// URL is the URL of the Handler.
+	URL string
+	// Header holds additional request headers, e.g. an "Authorization: Bearer" relay ticket.
+	Header map[string]string
+
+	mu      sync.Mutex
+	servers []ICEServer
+	renew   time.Time
+}
+
+// ICEServers returns the cached ICE servers, fetching new ones when needed.
+func (s *Source) ICEServers(ctx context.Context) ([]ICEServer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.servers != nil && time.Now().Before(s.renew) {
+		return s.servers, nil
+	}
+
+	var cfg iceConfig
+	if err := fetch(ctx, s.URL, s.Header, &cfg); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFetchFailed, err)
+	}
+	if cfg.ICEServers == nil {
+		cfg.ICEServers = []ICEServer{}
+	}
+	s.servers = cfg.ICEServers
+	s.renew = time.Now().Add(time.Duration(cfg.TTL) * time.Second * 3 / 4)
+	return s.servers, nil
+}
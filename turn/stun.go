@@ -0,0 +1,262 @@
+package turn
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"net"
+	"net/netip"
+)
+
+// magicCookie is the fixed value of every STUN header, also the key of XOR-encoded addresses
+const magicCookie = 0x2112A442
+
+const (
+	// headerSize is the size of a STUN message header
+	headerSize = 20
+	// channelHeaderSize is the size of a ChannelData header
+	channelHeaderSize = 4
+	// integritySize is the size of a MESSAGE-INTEGRITY attribute including its header
+	integritySize = 4 + sha1.Size
+)
+
+// Methods and classes of the STUN messages used by TURN. All methods are below 0x10, so a message type is
+// the method ORed with the class.
+const (
+	methodAllocate         = 0x003
+	methodRefresh          = 0x004
+	methodSend             = 0x006
+	methodData             = 0x007
+	methodCreatePermission = 0x008
+	methodChannelBind      = 0x009
+
+	classRequest    = 0x000
+	classIndication = 0x010
+	classSuccess    = 0x100
+	classError      = 0x110
+	classMask       = 0x110
+)
+
+// Attribute types.
+const (
+	attrUsername           = 0x0006
+	attrMessageIntegrity   = 0x0008
+	attrErrorCode          = 0x0009
+	attrChannelNumber      = 0x000C
+	attrLifetime           = 0x000D
+	attrXORPeerAddress     = 0x0012
+	attrData               = 0x0013
+	attrRealm              = 0x0014
+	attrNonce              = 0x0015
+	attrXORRelayedAddress  = 0x0016
+	attrRequestedTransport = 0x0019
+	attrXORMappedAddress   = 0x0020
+)
+
+// protoUDP is the REQUESTED-TRANSPORT value of UDP allocations
+const protoUDP = 17
+
+// message is a STUN message.
+type message struct {
+	typ   uint16
+	txID  [12]byte
+	attrs []attr
+}
+
+// attr is a STUN attribute.
+type attr struct {
+	typ   uint16
+	value []byte
+}
+
+// newMessage returns a message of the given type with a random transaction ID.
+func newMessage(typ uint16) *message {
+	m := &message{typ: typ}
+	rand.Read(m.txID[:])
+	return m
+}
+
+func (m *message) method() uint16 { return m.typ &^ classMask }
+func (m *message) class() uint16  { return m.typ & classMask }
+
+// add appends an attribute.
+func (m *message) add(typ uint16, value []byte) {
+	m.attrs = append(m.attrs, attr{typ, value})
+}
+
+// get returns the value of the first attribute of type typ.
+func (m *message) get(typ uint16) ([]byte, bool) {
+	for _, a := range m.attrs {
+		if a.typ == typ {
+			return a.value, true
+		}
+	}
+	return nil, false
+}
+
+// encode encodes the message, appending MESSAGE-INTEGRITY when key is set.
+func (m *message) encode(key []byte) []byte {
+	b := make([]byte, headerSize, 256)
+	binary.BigEndian.PutUint16(b[0:], m.typ)
+	binary.BigEndian.PutUint32(b[4:], magicCookie)
+	copy(b[8:], m.txID[:])
+	for _, a := range m.attrs {
+		b = binary.BigEndian.AppendUint16(b, a.typ)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(a.value)))
+		b = append(b, a.value...)
+		b = append(b, make([]byte, pad(len(a.value)))...)
+	}
+	if key != nil {
+		// The integrity covers the header with a length that already includes the attribute
+		binary.BigEndian.PutUint16(b[2:], uint16(len(b)-headerSize+integritySize))
+		mac := hmac.New(sha1.New, key)
+		mac.Write(b)
+		b = binary.BigEndian.AppendUint16(b, attrMessageIntegrity)
+		b = binary.BigEndian.AppendUint16(b, sha1.Size)
+		b = mac.Sum(b)
+	}
+	binary.BigEndian.PutUint16(b[2:], uint16(len(b)-headerSize))
+	return b
+}
+
+// decodeMessage decodes a STUN message. Attributes following MESSAGE-INTEGRITY are ignored, as the
+// specification requires.
+func decodeMessage(b []byte) (*message, error) {
+	if len(b) < headerSize || b[0]&0xC0 != 0 || binary.BigEndian.Uint32(b[4:]) != magicCookie {
+		return nil, ErrMalformed
+	}
+	length := int(binary.BigEndian.Uint16(b[2:]))
+	if length%4 != 0 || len(b) < headerSize+length {
+		return nil, ErrMalformed
+	}
+	m := &message{typ: binary.BigEndian.Uint16(b[0:])}
+	copy(m.txID[:], b[8:20])
+	rest := b[headerSize : headerSize+length]
+	for len(rest) > 0 {
+		if len(rest) < 4 {
+			return nil, ErrMalformed
+		}
+		typ, n := binary.BigEndian.Uint16(rest[0:]), int(binary.BigEndian.Uint16(rest[2:]))
+		if len(rest) < 4+n {
+			return nil, ErrMalformed
+		}
+		m.add(typ, rest[4:4+n])
+		if typ == attrMessageIntegrity {
+			break
+		}
+		rest = rest[min(len(rest), 4+n+pad(n)):]
+	}
+	return m, nil
+}
+
+// checkIntegrity verifies the MESSAGE-INTEGRITY attribute of the encoded message b.
+func checkIntegrity(b []byte, key []byte) bool {
+	rest := b[headerSize:]
+	for off := headerSize; len(rest) >= 4; {
+		typ, n := binary.BigEndian.Uint16(rest[0:]), int(binary.BigEndian.Uint16(rest[2:]))
+		if typ == attrMessageIntegrity {
+			if n != sha1.Size || len(rest) < 4+n {
+				return false
+			}
+			signed := append([]byte(nil), b[:off]...)
+			binary.BigEndian.PutUint16(signed[2:], uint16(off-headerSize+integritySize))
+			mac := hmac.New(sha1.New, key)
+			mac.Write(signed)
+			return hmac.Equal(mac.Sum(nil), rest[4:4+n])
+		}
+		skip := min(len(rest), 4+n+pad(n))
+		off += skip
+		rest = rest[skip:]
+	}
+	return false
+}
+
+// errorCode returns the code and reason of an ERROR-CODE attribute.
+func (m *message) errorCode() (int, string) {
+	v, ok := m.get(attrErrorCode)
+	if !ok || len(v) < 4 {
+		return 0, ""
+	}
+	return int(v[2]&0x7)*100 + int(v[3]), string(v[4:])
+}
+
+// addAddress appends an XOR-encoded address attribute.
+func (m *message) addAddress(typ uint16, addr netip.AddrPort) {
+	ip := addr.Addr().Unmap()
+	v := make([]byte, 4, 20)
+	v[1] = 1
+	if ip.Is6() {
+		v[1] = 2
+	}
+	binary.BigEndian.PutUint16(v[2:], addr.Port()^(magicCookie>>16))
+	v = append(v, ip.AsSlice()...)
+	m.xor(v[4:])
+	m.add(typ, v)
+}
+
+// address decodes an XOR-encoded address attribute.
+func (m *message) address(typ uint16) (netip.AddrPort, bool) {
+	v, ok := m.get(typ)
+	if !ok || len(v) < 8 {
+		return netip.AddrPort{}, false
+	}
+	raw := append([]byte(nil), v[4:]...)
+	if (v[1] == 1 && len(raw) != 4) || (v[1] == 2 && len(raw) != 16) {
+		return netip.AddrPort{}, false
+	}
+	m.xor(raw)
+	ip, ok := netip.AddrFromSlice(raw)
+	if !ok {
+		return netip.AddrPort{}, false
+	}
+	return netip.AddrPortFrom(ip, binary.BigEndian.Uint16(v[2:])^(magicCookie>>16)), true
+}
+
+// xor applies the XOR mask of addresses, the magic cookie followed by the transaction ID, to ip.
+func (m *message) xor(ip []byte) {
+	var mask [16]byte
+	binary.BigEndian.PutUint32(mask[:], magicCookie)
+	copy(mask[4:], m.txID[:])
+	for i := range ip {
+		ip[i] ^= mask[i]
+	}
+}
+
+// uint32Attr encodes a 32-bit attribute value.
+func uint32Attr(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
+
+// channelAttr encodes a CHANNEL-NUMBER value.
+func channelAttr(ch uint16) []byte {
+	return []byte{byte(ch >> 8), byte(ch), 0, 0}
+}
+
+// appendChannelData appends a ChannelData message, padded for stream transports.
+func appendChannelData(b []byte, ch uint16, data []byte, padded bool) []byte {
+	b = binary.BigEndian.AppendUint16(b, ch)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+	b = append(b, data...)
+	if padded {
+		b = append(b, make([]byte, pad(len(data)))...)
+	}
+	return b
+}
+
+// longTermKey derives the key of the long-term credential mechanism.
+func longTermKey(username, realm, password string) []byte {
+	sum := md5.Sum([]byte(username + ":" + realm + ":" + password))
+	return sum[:]
+}
+
+// pad returns the padding after n bytes to the next multiple of 4.
+func pad(n int) int {
+	return (4 - n%4) % 4
+}
+
+// udpAddr converts addr to a net.UDPAddr.
+func udpAddr(addr netip.AddrPort) *net.UDPAddr {
+	return net.UDPAddrFromAddrPort(addr)
+}
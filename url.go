@@ -0,0 +1,115 @@
+package supernet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"pkg.gfire.dev/supernet/identity"
+	"pkg.gfire.dev/supernet/naming"
+	"pkg.gfire.dev/supernet/p2p"
+	"pkg.gfire.dev/supernet/routing"
+)
+
+var (
+	// ErrUnsupportedScheme is returned for URLs whose scheme Dial does not understand
+	ErrUnsupportedScheme = errors.New("unsupported URL scheme")
+	// ErrInvalidURL is returned for network URLs that do not consist of a host and port only
+	ErrInvalidURL = errors.New("invalid URL")
+)
+
+// Dial connects to the destination named by rawURL using d, or a Dialer without rules if d is nil.
+// The URL is one of:
+//
+//   - "tcp://host:port" or "udp://host:port" (also tcp4, tcp6, udp4 and udp6) connects using the route
+//     the dialer's rules select for the destination.
+//   - "<transport>+<network>://host:port", e.g. "ws+tcp://example.com:22", connects over the named
+//     transport of d.Transports regardless of the rules. Rules rejecting the destination still apply.
+//   - "peer://<id or name>/<service>" connects to a service of an overlay peer through d.Peer, resolving
+//     names with d.Resolver.
+func Dial(ctx context.Context, rawURL string, d *Dialer) (net.Conn, error) {
+	if d == nil {
+		d = &Dialer{}
+	}
+	return d.DialURL(ctx, rawURL)
+}
+
+// DialURL connects to the destination named by rawURL, see Dial.
+func (d *Dialer) DialURL(ctx context.Context, rawURL string) (net.Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == naming.Scheme {
+		return d.dialPeer(ctx, rawURL)
+	}
+
+	transport, network, forced := strings.Cut(u.Scheme, "+")
+	if !forced {
+		transport, network = "", transport
+	}
+	if !validNetwork(network) {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedScheme, u.Scheme)
+	}
+	if u.Host == "" || u.Port() == "" || (u.Path != "" && u.Path != "/") || u.User != nil {
+		return nil, fmt.Errorf("%w: %q, want %s://host:port", ErrInvalidURL, rawURL, u.Scheme)
+	}
+	if !forced {
+		return d.DialContext(ctx, network, u.Host)
+	}
+
+	if d.Rules.RouteAddr(u.Host).Reject {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: routing.ErrRejected}
+	}
+	dial, err := d.transport(transport)
+	if err != nil {
+		return nil, err
+	}
+	return dial(ctx, network, u.Host)
+}
+
+// dialPeer connects to the peer and service named by a peer URL.
+func (d *Dialer) dialPeer(ctx context.Context, rawURL string) (conn net.Conn, err error) {
+	ctx, span := startSpan(ctx, "supernet.DialPeer", attribute.String("url.full", rawURL))
+	defer func() { endSpan(span, err) }()
+
+	if d.Peer == nil {
+		return nil, fmt.Errorf("%w: no peer dialer for %q", ErrUnknownTransport, rawURL)
+	}
+
+	name, service, err := naming.ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	var id identity.ID
+	if d.Resolver != nil {
+		id, err = d.Resolver.Resolve(ctx, name)
+	} else {
+		id, err = p2p.ParseID(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("supernet.peer", id.String()))
+	conn, err = d.Peer(ctx, id, service)
+	if err != nil && d.Resolver != nil {
+		// The name may have moved to another peer
+		d.Resolver.Forget(name)
+	}
+	return conn, err
+}
+
+// validNetwork reports whether network is a TCP or UDP network name.
+func validNetwork(network string) bool {
+	switch network {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+		return true
+	}
+	return false
+}
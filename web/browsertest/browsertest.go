@@ -0,0 +1,346 @@
+//go:build !js
+
+// Package browsertest runs the js/wasm tests of a package in a headless browser and reports their output
+// and result to the calling go test, so packages such as httpjs, wsjs and streamjs can be tested in CI
+// against real browser APIs.
+//
+// Run builds the package's test binary for js/wasm, serves it together with the Fixtures on a local HTTP
+// server, and opens it in a headless Chrome or Chromium. The wasm tests find the fixtures through
+// FixtureURL. A package typically pairs its js tests with a native test that starts them:
+//
+//	//go:build !js
+//
+//	func TestBrowser(t *testing.T) {
+//		browsertest.Run(t, browsertest.Config{Args: []string{"-test.v"}})
+//	}
+//
+// Tests are skipped when no browser is found; set SUPERNET_BROWSER to the browser executable to pick one.
+package browsertest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ErrNoBrowser is returned when no headless browser could be found
+var ErrNoBrowser = errors.New("browsertest: no browser found")
+
+const (
+	// defaultTimeout is the default time limit of a whole browser test run
+	defaultTimeout = 5 * time.Minute
+	// browserEnv names the environment variable selecting the browser executable
+	browserEnv = "SUPERNET_BROWSER"
+	// harnessPrefix is the path under which the harness serves its own files
+	harnessPrefix = "/_browsertest/"
+)
+
+// browsers are the executables searched for when browserEnv is not set
+var browsers = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome"}
+
+// Config configures a browser test run.
+type Config struct {
+	// Package is the package whose tests are run (default ".").
+	Package string
+	// Args are passed to the test binary, e.g. "-test.v" or "-test.run=TestFetch".
+	Args []string
+	// BuildFlags are passed to go test -c, e.g. "-tags=integration".
+	BuildFlags []string
+	// Browser is the browser executable. Defaults to SUPERNET_BROWSER or the first Chrome or Chromium
+	// found on PATH.
+	Browser string
+	// BrowserArgs are additional browser command line arguments.
+	BrowserArgs []string
+	// Fixtures serves the requests of the tests outside of the harness paths (default Fixtures()).
+	Fixtures http.Handler
+	// Timeout limits the whole run including the build (default 5m).
+	Timeout time.Duration
+}
+
+// Result is the outcome of a browser test run.
+type Result struct {
+	ExitCode int
+	Output   []byte
+}
+
+// Run runs the wasm tests of cfg.Package in a headless browser, logging their output to t and failing t
+// if they fail. It skips t when no browser is available.
+func Run(t testing.TB, cfg Config) {
+	t.Helper()
+	res, err := Exec(context.Background(), cfg)
+	if errors.Is(err, ErrNoBrowser) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	for line := range strings.Lines(string(res.Output)) {
+		t.Log(strings.TrimSuffix(line, "\n"))
+	}
+	if res.ExitCode != 0 {
+		t.Fatalf("browser tests failed with exit code %d", res.ExitCode)
+	}
+}
+
+// Exec builds and runs the wasm tests of cfg.Package in a headless browser and returns their result.
+func Exec(ctx context.Context, cfg Config) (*Result, error) {
+	if cfg.Package == "" {
+		cfg.Package = "."
+	}
+	if cfg.Fixtures == nil {
+		cfg.Fixtures = Fixtures()
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	browser, err := findBrowser(cfg.Browser)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "browsertest")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	binary := filepath.Join(dir, "test.wasm")
+	if err := build(ctx, cfg, binary); err != nil {
+		return nil, err
+	}
+	execJS, err := wasmExec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	h := &harness{
+		binary:   binary,
+		execJS:   execJS,
+		args:     cfg.Args,
+		fixtures: cfg.Fixtures,
+		origin:   "http://" + l.Addr().String(),
+		done:     make(chan struct{}),
+	}
+	srv := &http.Server{Handler: h}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	args := append([]string{
+		"--headless=new",
+		"--disable-gpu",
+		"--no-first-run",
+		"--no-default-browser-check",
+		"--user-data-dir=" + filepath.Join(dir, "profile"),
+	}, cfg.BrowserArgs...)
+	if os.Geteuid() == 0 {
+		// Chrome refuses to run as root with its sandbox, as in most CI containers
+		args = append(args, "--no-sandbox")
+	}
+	args = append(args, h.origin+harnessPrefix)
+	cmd := exec.CommandContext(ctx, browser, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	select {
+	case <-h.done:
+		return h.result(), nil
+	case err := <-exited:
+		return nil, fmt.Errorf("browsertest: browser exited before the tests finished: %v\n%s", err, stderr.Bytes())
+	case <-ctx.Done():
+		res := h.result()
+		return nil, fmt.Errorf("browsertest: %w, output so far:\n%s", ctx.Err(), res.Output)
+	}
+}
+
+// findBrowser returns the browser executable to use.
+func findBrowser(browser string) (string, error) {
+	if browser == "" {
+		browser = os.Getenv(browserEnv)
+	}
+	if browser != "" {
+		return exec.LookPath(browser)
+	}
+	for _, name := range browsers {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", ErrNoBrowser
+}
+
+// build compiles the test binary of cfg.Package for js/wasm.
+func build(ctx context.Context, cfg Config, binary string) error {
+	args := append([]string{"test", "-c", "-o", binary}, cfg.BuildFlags...)
+	args = append(args, cfg.Package)
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("browsertest: building %s: %v\n%s", cfg.Package, err, out)
+	}
+	if _, err := os.Stat(binary); err != nil {
+		// go test -c writes nothing for packages without tests
+		return fmt.Errorf("browsertest: %s has no js/wasm tests", cfg.Package)
+	}
+	return nil
+}
+
+// wasmExec returns the wasm_exec.js support file of the Go toolchain.
+func wasmExec(ctx context.Context) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, "go", "env", "GOROOT").Output()
+	if err != nil {
+		return nil, fmt.Errorf("browsertest: locating GOROOT: %w", err)
+	}
+	root := strings.TrimSpace(string(out))
+	// Go 1.24 moved the file from misc/wasm to lib/wasm
+	for _, dir := range []string{"lib/wasm", "misc/wasm"} {
+		if data, err := os.ReadFile(filepath.Join(root, dir, "wasm_exec.js")); err == nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("browsertest: wasm_exec.js not found in %s", root)
+}
+
+// harness serves the test page, the test binary and the fixtures, and collects the test output.
+type harness struct {
+	binary   string
+	execJS   []byte
+	args     []string
+	fixtures http.Handler
+	origin   string
+
+	// done is closed when the tests exited
+	done     chan struct{}
+	doneOnce sync.Once
+
+	// mu protects the fields below
+	mu       sync.Mutex
+	output   bytes.Buffer
+	exitCode int
+}
+
+// ServeHTTP serves the harness paths and passes everything else to the fixtures.
+func (h *harness) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path, ok := strings.CutPrefix(r.URL.Path, harnessPrefix)
+	if !ok {
+		h.fixtures.ServeHTTP(w, r)
+		return
+	}
+
+	switch path {
+	case "":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, indexHTML)
+	case "wasm_exec.js":
+		w.Header().Set("Content-Type", "text/javascript")
+		w.Write(h.execJS)
+	case "test.wasm":
+		w.Header().Set("Content-Type", "application/wasm")
+		http.ServeFile(w, r, h.binary)
+	case "config":
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"args":%s,"env":{%q:%q}}`, jsonStrings(h.args), fixturesEnv, h.origin)
+	case "output":
+		data, _ := io.ReadAll(r.Body)
+		h.mu.Lock()
+		h.output.Write(data)
+		h.mu.Unlock()
+	case "exit":
+		code, err := strconv.Atoi(r.URL.Query().Get("code"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.mu.Lock()
+		h.exitCode = code
+		h.mu.Unlock()
+		h.doneOnce.Do(func() {
+			close(h.done)
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// result returns the output collected so far and the exit code.
+func (h *harness) result() *Result {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return &Result{ExitCode: h.exitCode, Output: bytes.Clone(h.output.Bytes())}
+}
+
+// jsonStrings encodes a list of strings as a JSON array.
+func jsonStrings(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = strconv.Quote(s)
+	}
+	return "[" + strings.Join(quoted, ",") + "]"
+}
+
+// indexHTML is the test page. It runs the test binary with its output forwarded to the harness in order,
+// then reports the exit code.
+const indexHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<script src="wasm_exec.js"></script>
+<script>
+(async () => {
+	let chain = Promise.resolve();
+	const post = (path, body) => {
+		chain = chain.then(() => fetch(path, {method: "POST", body}).catch(() => {}));
+		return chain;
+	};
+	const decoder = new TextDecoder();
+	globalThis.fs.writeSync = (fd, buf) => {
+		post("output", decoder.decode(buf));
+		return buf.length;
+	};
+
+	try {
+		const config = await (await fetch("config")).json();
+		const go = new Go();
+		go.argv = ["test.wasm", ...config.args];
+		go.env = config.env;
+		go.exit = (code) => post("exit?code=" + code);
+		const { instance } = await WebAssembly.instantiateStreaming(fetch("test.wasm"), go.importObject);
+		await go.run(instance);
+	} catch (err) {
+		post("output", "browsertest: " + err + "\n");
+		post("exit?code=2");
+	}
+})();
+</script>
+</head>
+<body></body>
+</html>
+`
@@ -0,0 +1,132 @@
+//go:build !js
+
+package browsertest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// maxFixtureMessage is the largest WebSocket message echoed by the fixtures
+const maxFixtureMessage = 16 << 20
+
+// Fixtures returns the default fixture server. Tests reach it through FixtureURL:
+//
+//   - /ws/echo is a WebSocket endpoint echoing every message with its type.
+//   - /http/echo answers with the request body, its Content-Type and the request method in X-Method;
+//     request headers starting with X- are echoed as well.
+//   - /http/status/{code} answers with the status code.
+//   - /http/delay/{ms} answers after the delay, or not at all if the request is aborted first.
+//   - /http/stream?n=&interval= sends n numbered lines, flushing each after the interval (ms).
+//   - /sse?n=&interval= sends n server-sent events in the same way.
+func Fixtures() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/echo", wsEcho)
+	mux.HandleFunc("/http/echo", httpEcho)
+	mux.HandleFunc("/http/status/{code}", func(w http.ResponseWriter, r *http.Request) {
+		code, err := strconv.Atoi(r.PathValue("code"))
+		if err != nil || code < 100 || code > 999 {
+			http.Error(w, "invalid status code", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(code)
+	})
+	mux.HandleFunc("/http/delay/{ms}", func(w http.ResponseWriter, r *http.Request) {
+		ms, err := strconv.Atoi(r.PathValue("ms"))
+		if err != nil {
+			http.Error(w, "invalid delay", http.StatusBadRequest)
+			return
+		}
+		select {
+		case <-time.After(time.Duration(ms) * time.Millisecond):
+			io.WriteString(w, "ok")
+		case <-r.Context().Done():
+		}
+	})
+	mux.HandleFunc("/http/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		stream(w, r, func(i int) string {
+			return strconv.Itoa(i) + "\n"
+		})
+	})
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		stream(w, r, func(i int) string {
+			return fmt.Sprintf("id: %d\ndata: %d\n\n", i, i)
+		})
+	})
+	return mux
+}
+
+// wsEcho echoes WebSocket messages until the client disconnects.
+func wsEcho(w http.ResponseWriter, r *http.Request) {
+	ws, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer ws.CloseNow()
+	ws.SetReadLimit(maxFixtureMessage)
+
+	ctx := r.Context()
+	for {
+		typ, data, err := ws.Read(ctx)
+		if err != nil {
+			return
+		}
+		if err := ws.Write(ctx, typ, data); err != nil {
+			return
+		}
+	}
+}
+
+// httpEcho answers with the request body.
+func httpEcho(w http.ResponseWriter, r *http.Request) {
+	for name, values := range r.Header {
+		if len(name) > 2 && name[:2] == "X-" {
+			w.Header()[name] = values
+		}
+	}
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.Header().Set("X-Method", r.Method)
+	w.Header().Set("Access-Control-Expose-Headers", "*")
+	io.Copy(w, r.Body)
+}
+
+// stream writes the n chunks requested by r, produced by chunk, flushing each after the interval.
+func stream(w http.ResponseWriter, r *http.Request, chunk func(i int) string) {
+	n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+	interval, _ := strconv.Atoi(r.URL.Query().Get("interval"))
+	flusher, _ := w.(http.Flusher)
+	for i := range n {
+		if i > 0 && !sleep(r.Context(), time.Duration(interval)*time.Millisecond) {
+			return
+		}
+		if _, err := io.WriteString(w, chunk(i)); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// sleep waits for d, returning false if ctx ends first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
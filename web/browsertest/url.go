@@ -0,0 +1,22 @@
+package browsertest
+
+import (
+	"os"
+	"strings"
+)
+
+// fixturesEnv is the environment variable through which the harness passes the fixture origin to tests
+const fixturesEnv = "BROWSERTEST_FIXTURES"
+
+// FixtureURL returns the URL of a fixture path such as "/http/echo" for tests running under Run, with
+// the scheme replaced by ws for WebSocket paths. It returns "" outside of the harness, so tests can skip.
+func FixtureURL(path string) string {
+	origin := os.Getenv(fixturesEnv)
+	if origin == "" {
+		return ""
+	}
+	if strings.HasPrefix(path, "/ws/") {
+		origin = "ws" + strings.TrimPrefix(origin, "http")
+	}
+	return origin + path
+}
@@ -0,0 +1,53 @@
+// Package bluetoothjs provides bindings for the Web Bluetooth API: requesting devices, reading and writing
+// GATT characteristics, and message connections over a pair of characteristics. Outside the browser
+// RequestDevice returns ErrUnsupported.
+package bluetoothjs
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnsupported is returned when the Web Bluetooth API is not available in the current context
+	ErrUnsupported = errors.New("web bluetooth not supported")
+	// ErrNotConnected is returned when accessing GATT services of a disconnected device
+	ErrNotConnected = errors.New("bluetooth device not connected")
+	// ErrRequestFailed is returned when a Web Bluetooth operation fails without a reason
+	ErrRequestFailed = errors.New("bluetooth request failed")
+)
+
+// UUID16 returns the full 128-bit UUID string of a 16-bit Bluetooth SIG assigned number,
+// e.g. UUID16(0x180d) for the Heart Rate service.
+func UUID16(n uint16) string {
+	return fmt.Sprintf("%08x-0000-1000-8000-00805f9b34fb", uint32(n))
+}
+
+// Filter restricts the devices offered to the user in RequestDevice.
+// A device matches a filter if it satisfies every non-empty field.
+type Filter struct {
+	Services   []string // Service UUIDs or names the device must advertise
+	Name       string   // Exact device name
+	NamePrefix string   // Device name prefix
+}
+
+// RequestOptions configures the device chooser shown by RequestDevice.
+type RequestOptions struct {
+	Filters          []Filter // Filters matched against advertising devices
+	AcceptAllDevices bool     // Show all devices instead of using Filters
+	OptionalServices []string // Additional services the application wants to access
+}
+
+// Device represents a Bluetooth device granted by the user.
+type Device struct {
+	// device holds the JavaScript BluetoothDevice object
+	device handle
+	// server holds the BluetoothRemoteGATTServer once connected
+	server handle
+}
+
+// Characteristic represents a GATT characteristic of a connected device.
+type Characteristic struct {
+	// char holds the JavaScript BluetoothRemoteGATTCharacteristic object
+	char handle
+}
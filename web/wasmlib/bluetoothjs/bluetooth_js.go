@@ -0,0 +1,200 @@
+package bluetoothjs
+
+import (
+	"context"
+	"errors"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+var (
+	// _bluetooth is a cached reference to navigator.bluetooth, undefined outside of supporting browsers
+	_bluetooth = js.Global().Get("navigator").Get("bluetooth")
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+	// _Array is a cached reference to the JavaScript Array constructor for array operations
+	_Array = js.Global().Get("Array")
+	// _Uint8Array is a cached reference to the JavaScript Uint8Array constructor for typed array operations
+	_Uint8Array = js.Global().Get("Uint8Array")
+)
+
+// handle is the type of the JavaScript Web Bluetooth objects
+type handle = js.Value
+
+// RequestDevice prompts the user to select a Bluetooth device.
+// Every service accessed later must be listed in a filter or in OptionalServices.
+// Browsers only allow this call during a user gesture such as a click handler.
+func RequestDevice(opts RequestOptions) (*Device, error) {
+	if !supported() {
+		return nil, ErrUnsupported
+	}
+
+	jsOpts := _Object.New()
+	if opts.AcceptAllDevices {
+		jsOpts.Set("acceptAllDevices", true)
+	} else {
+		jsFilters := _Array.New()
+		for _, f := range opts.Filters {
+			jsFilter := _Object.New()
+			if len(f.Services) > 0 {
+				jsFilter.Set("services", toArray(f.Services))
+			}
+			if f.Name != "" {
+				jsFilter.Set("name", f.Name)
+			}
+			if f.NamePrefix != "" {
+				jsFilter.Set("namePrefix", f.NamePrefix)
+			}
+			jsFilters.Call("push", jsFilter)
+		}
+		jsOpts.Set("filters", jsFilters)
+	}
+	if len(opts.OptionalServices) > 0 {
+		jsOpts.Set("optionalServices", toArray(opts.OptionalServices))
+	}
+
+	device, err := await(context.Background(), _bluetooth.Call("requestDevice", jsOpts))
+	if err != nil {
+		return nil, err
+	}
+	return &Device{device: device}, nil
+}
+
+// ID returns the browser-assigned opaque identifier of the device.
+func (d *Device) ID() string {
+	return d.device.Get("id").String()
+}
+
+// Name returns the advertised name of the device, or "" if unknown.
+func (d *Device) Name() string {
+	name := d.device.Get("name")
+	if name.Type() != js.TypeString {
+		return ""
+	}
+	return name.String()
+}
+
+// Connected reports whether the GATT server of the device is connected.
+func (d *Device) Connected() bool {
+	return d.device.Get("gatt").Get("connected").Bool()
+}
+
+// Connect connects to the GATT server of the device.
+func (d *Device) Connect() error {
+	return d.ConnectContext(context.Background())
+}
+
+// ConnectContext is Connect bounded by ctx. Once ctx is done the connection attempt is aborted by
+// disconnecting the device.
+func (d *Device) ConnectContext(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, d.Disconnect)
+	server, err := await(ctx, d.device.Get("gatt").Call("connect"))
+	if !stop() {
+		return ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
+	d.server = server
+	return nil
+}
+
+// Disconnect disconnects from the GATT server of the device.
+func (d *Device) Disconnect() {
+	d.device.Get("gatt").Call("disconnect")
+}
+
+// Characteristic looks up a characteristic of a primary service on the connected device.
+func (d *Device) Characteristic(service, characteristic string) (*Characteristic, error) {
+	return d.CharacteristicContext(context.Background(), service, characteristic)
+}
+
+// CharacteristicContext is Characteristic bounded by ctx.
+func (d *Device) CharacteristicContext(ctx context.Context, service, characteristic string) (*Characteristic, error) {
+	if d.server.IsUndefined() || !d.Connected() {
+		return nil, ErrNotConnected
+	}
+
+	jsService, err := await(ctx, d.server.Call("getPrimaryService", service))
+	if err != nil {
+		return nil, err
+	}
+	jsChar, err := await(ctx, jsService.Call("getCharacteristic", characteristic))
+	if err != nil {
+		return nil, err
+	}
+	return &Characteristic{char: jsChar}, nil
+}
+
+// UUID returns the UUID of the characteristic.
+func (c *Characteristic) UUID() string {
+	return c.char.Get("uuid").String()
+}
+
+// ReadValue reads the current value of the characteristic.
+func (c *Characteristic) ReadValue() ([]byte, error) {
+	return c.ReadValueContext(context.Background())
+}
+
+// ReadValueContext is ReadValue bounded by ctx. Web Bluetooth cannot cancel a read, so once ctx is done it
+// goes on in the browser and its value is discarded; disconnecting the device ends it.
+func (c *Characteristic) ReadValueContext(ctx context.Context) ([]byte, error) {
+	view, err := await(ctx, c.char.Call("readValue"))
+	if err != nil {
+		return nil, err
+	}
+	return dataViewBytes(view), nil
+}
+
+// WriteValue writes data to the characteristic.
+// When withResponse is true the write is acknowledged by the device before WriteValue returns.
+func (c *Characteristic) WriteValue(data []byte, withResponse bool) error {
+	return c.WriteValueContext(context.Background(), data, withResponse)
+}
+
+// WriteValueContext is WriteValue bounded by ctx. Once ctx is done the write goes on in the browser, so the
+// device may still receive data.
+func (c *Characteristic) WriteValueContext(ctx context.Context, data []byte, withResponse bool) error {
+	array := _Uint8Array.New(len(data))
+	js.CopyBytesToJS(array, data)
+
+	method := "writeValueWithoutResponse"
+	if withResponse {
+		method = "writeValueWithResponse"
+	}
+	_, err := await(ctx, c.char.Call(method, array))
+	return err
+}
+
+// dataViewBytes copies the contents of a JavaScript DataView into a Go byte slice.
+func dataViewBytes(view js.Value) []byte {
+	array := _Uint8Array.New(view.Get("buffer"), view.Get("byteOffset"), view.Get("byteLength"))
+	data := make([]byte, array.Get("byteLength").Int())
+	js.CopyBytesToGo(data, array)
+	return data
+}
+
+// toArray converts a Go string slice to a JavaScript array.
+func toArray(values []string) js.Value {
+	array := _Array.New()
+	for _, v := range values {
+		array.Call("push", v)
+	}
+	return array
+}
+
+// supported reports whether navigator.bluetooth is available.
+func supported() bool {
+	return !_bluetooth.IsUndefined() && !_bluetooth.IsNull()
+}
+
+// await blocks until the given JavaScript promise settles or ctx is done and returns its value or rejection
+// reason. Rejections without a reason are reported as ErrRequestFailed.
+func await(ctx context.Context, promise js.Value) (js.Value, error) {
+	v, err := promisejs.Await(ctx, promise)
+	if errors.Is(err, promisejs.ErrRejected) {
+		return v, ErrRequestFailed
+	}
+	return v, err
+}
@@ -0,0 +1,97 @@
+//go:build !js
+
+package bluetoothjs
+
+import "context"
+
+// handle is empty outside the browser, where no devices can be requested.
+type handle = struct{}
+
+// binding is empty outside the browser.
+type binding struct{}
+
+// RequestDevice returns ErrUnsupported outside the browser.
+func RequestDevice(opts RequestOptions) (*Device, error) {
+	return nil, ErrUnsupported
+}
+
+// ID returns the empty string.
+func (d *Device) ID() string {
+	return ""
+}
+
+// Name returns the empty string.
+func (d *Device) Name() string {
+	return ""
+}
+
+// Connected reports false.
+func (d *Device) Connected() bool {
+	return false
+}
+
+// Connect returns ErrUnsupported.
+func (d *Device) Connect() error {
+	return ErrUnsupported
+}
+
+// ConnectContext returns ErrUnsupported.
+func (d *Device) ConnectContext(ctx context.Context) error {
+	return ErrUnsupported
+}
+
+// Disconnect does nothing.
+func (d *Device) Disconnect() {}
+
+// Characteristic returns ErrUnsupported.
+func (d *Device) Characteristic(service, characteristic string) (*Characteristic, error) {
+	return nil, ErrUnsupported
+}
+
+// CharacteristicContext returns ErrUnsupported.
+func (d *Device) CharacteristicContext(ctx context.Context, service, characteristic string) (*Characteristic, error) {
+	return nil, ErrUnsupported
+}
+
+// UUID returns the empty string.
+func (c *Characteristic) UUID() string {
+	return ""
+}
+
+// ReadValue returns ErrUnsupported.
+func (c *Characteristic) ReadValue() ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+// ReadValueContext returns ErrUnsupported.
+func (c *Characteristic) ReadValueContext(ctx context.Context) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+// WriteValue returns ErrUnsupported.
+func (c *Characteristic) WriteValue(data []byte, withResponse bool) error {
+	return ErrUnsupported
+}
+
+// WriteValueContext returns ErrUnsupported.
+func (c *Characteristic) WriteValueContext(ctx context.Context, data []byte, withResponse bool) error {
+	return ErrUnsupported
+}
+
+// Dial returns ErrUnsupported outside the browser.
+func Dial(device *Device, opts DialOptions) (*Conn, error) {
+	return nil, ErrUnsupported
+}
+
+// DialContext returns ErrUnsupported outside the browser.
+func DialContext(ctx context.Context, device *Device, opts DialOptions) (*Conn, error) {
+	return nil, ErrUnsupported
+}
+
+// Close marks the connection closed. Safe to call multiple times.
+func (conn *Conn) Close() error {
+	conn.closeOnce.Do(func() {
+		close(conn.closeChan)
+	})
+	return nil
+}
@@ -0,0 +1,101 @@
+package bluetoothjs
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+var (
+	// ErrClosed is returned when attempting to use a closed characteristic connection
+	ErrClosed = errors.New("bluetooth connection closed")
+	// ErrMessageTooLarge is returned when sending a message larger than a single GATT write allows
+	ErrMessageTooLarge = errors.New("bluetooth message exceeds maximum attribute size")
+)
+
+// MaxMessageSize is the largest value a single GATT characteristic write may carry.
+const MaxMessageSize = 512
+
+// DialOptions selects the characteristics used by a Conn.
+// Notify and Write may name the same characteristic for devices using a single bidirectional one.
+type DialOptions struct {
+	Service      string // Primary service UUID or name
+	Notify       string // Characteristic delivering incoming messages via notifications
+	Write        string // Characteristic receiving outgoing messages
+	WithResponse bool   // Use acknowledged writes for Send
+}
+
+// Conn is a message-oriented connection over a pair of GATT characteristics.
+// Every notification is delivered as one message and every Send is written as one value,
+// so Conn offers the same NextMessage/Send/Close contract as wsjs.Conn.
+type Conn struct {
+	device *Device
+	notify *Characteristic
+	write  *Characteristic
+
+	withResponse bool
+
+	// messageChan buffers incoming notifications (up to 128 messages)
+	messageChan chan []byte
+	// closeChan signals when the connection has been closed or the device disconnected
+	closeChan chan struct{}
+	closeOnce sync.Once
+
+	// writeMu serializes GATT writes, which fail when issued concurrently
+	writeMu sync.Mutex
+
+	binding
+}
+
+// NextMessage retrieves the next notification value received from the device.
+// It blocks until a message is available or the connection is closed.
+// Returns ErrClosed if the connection has been closed before or during the wait.
+func (conn *Conn) NextMessage() ([]byte, error) {
+	return conn.NextMessageContext(context.Background())
+}
+
+// NextMessageContext is NextMessage bounded by ctx. It returns ctx.Err() once ctx is done, leaving the
+// connection open and the messages queued.
+func (conn *Conn) NextMessageContext(ctx context.Context) ([]byte, error) {
+	jsguard.Check("bluetoothjs.Conn.NextMessage")
+	select {
+	case msg := <-conn.messageChan:
+		return msg, nil
+	case <-conn.closeChan:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// NextMessageAsync is NextMessageContext in a new goroutine, for JavaScript callbacks, which must not block.
+func (conn *Conn) NextMessageAsync(ctx context.Context) <-chan jsguard.Result[[]byte] {
+	return jsguard.Go(func() ([]byte, error) {
+		return conn.NextMessageContext(ctx)
+	})
+}
+
+// Send writes a message to the Write characteristic as a single value.
+// Returns ErrMessageTooLarge if data exceeds MaxMessageSize.
+func (conn *Conn) Send(data []byte) error {
+	return conn.SendContext(context.Background(), data)
+}
+
+// SendContext is Send bounded by ctx, see Characteristic.WriteValueContext.
+func (conn *Conn) SendContext(ctx context.Context, data []byte) error {
+	if len(data) > MaxMessageSize {
+		return ErrMessageTooLarge
+	}
+
+	select {
+	case <-conn.closeChan:
+		return ErrClosed
+	default:
+	}
+
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
+	return conn.write.WriteValueContext(ctx, data, conn.withResponse)
+}
@@ -0,0 +1,99 @@
+package bluetoothjs
+
+import (
+	"context"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+// binding holds the event listeners of a Conn.
+type binding struct {
+	// onValueChanged and onDisconnected are the registered event listeners
+	onValueChanged js.Func
+	onDisconnected js.Func
+}
+
+// Dial connects to the device's GATT server if necessary, subscribes to notifications of the
+// Notify characteristic and returns a Conn ready for sending and receiving messages.
+func Dial(device *Device, opts DialOptions) (*Conn, error) {
+	return DialContext(context.Background(), device, opts)
+}
+
+// DialContext is Dial bounded by ctx, which only applies to setting up the Conn.
+func DialContext(ctx context.Context, device *Device, opts DialOptions) (*Conn, error) {
+	if !device.Connected() {
+		if err := device.ConnectContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	notify, err := device.CharacteristicContext(ctx, opts.Service, opts.Notify)
+	if err != nil {
+		return nil, err
+	}
+	write := notify
+	if opts.Write != opts.Notify {
+		write, err = device.CharacteristicContext(ctx, opts.Service, opts.Write)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	conn := &Conn{
+		device:       device,
+		notify:       notify,
+		write:        write,
+		withResponse: opts.WithResponse,
+		messageChan:  make(chan []byte, 128),
+		closeChan:    make(chan struct{}),
+	}
+
+	conn.onValueChanged = jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		data := dataViewBytes(args[0].Get("target").Get("value"))
+		select {
+		case conn.messageChan <- data:
+		case <-conn.closeChan:
+		}
+		return nil
+	})
+
+	conn.onDisconnected = jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		// Releasing from within the callback is not allowed, so tear down asynchronously
+		go conn.Close()
+		return nil
+	})
+
+	jsguard.AddEventListener(notify.char, "characteristicvaluechanged", conn.onValueChanged)
+	jsguard.AddEventListener(device.device, "gattserverdisconnected", conn.onDisconnected)
+
+	if _, err := await(ctx, notify.char.Call("startNotifications")); err != nil {
+		conn.removeListeners()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// Close stops notifications, removes event listeners and releases all associated resources.
+// The GATT connection of the device is left open so other characteristics remain usable.
+// It does not wait for the device to stop notifications. Safe to call multiple times.
+func (conn *Conn) Close() error {
+	conn.closeOnce.Do(func() {
+		close(conn.closeChan)
+		if conn.device.Connected() {
+			promisejs.Then(conn.notify.char.Call("stopNotifications"), func(js.Value, error) {})
+		}
+		conn.removeListeners()
+	})
+	return nil
+}
+
+// removeListeners unregisters and releases the event listeners of the connection.
+func (conn *Conn) removeListeners() {
+	jsguard.RemoveEventListener(conn.notify.char, "characteristicvaluechanged", conn.onValueChanged)
+	jsguard.RemoveEventListener(conn.device.device, "gattserverdisconnected", conn.onDisconnected)
+	jsguard.Release(conn.onValueChanged)
+	jsguard.Release(conn.onDisconnected)
+}
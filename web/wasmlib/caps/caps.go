@@ -0,0 +1,113 @@
+// Package caps probes the JavaScript environment for optional platform features, so that packages can
+// choose a code path up front instead of failing at runtime. Outside the browser no capabilities are found.
+package caps
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// Capability is a single optional platform feature.
+type Capability uint32
+
+const (
+	// FetchUploadStreaming is support for streaming request bodies with fetch (duplex: "half").
+	FetchUploadStreaming Capability = 1 << iota
+	// RequestStreamBody is support for ReadableStream as a Request body.
+	RequestStreamBody
+	// WebTransport is the WebTransport API.
+	WebTransport
+	// WebSocketStream is the stream-based WebSocketStream API.
+	WebSocketStream
+	// CompressionStream is the CompressionStream and DecompressionStream API.
+	CompressionStream
+	// OPFS is the origin private file system (navigator.storage.getDirectory).
+	OPFS
+	// SharedArrayBuffer is a usable SharedArrayBuffer, which requires cross-origin isolation.
+	SharedArrayBuffer
+	// WebRTC is RTCPeerConnection with data channels.
+	WebRTC
+	// IndexedDB is the IndexedDB API.
+	IndexedDB
+	// ServiceWorker is service worker registration (navigator.serviceWorker).
+	ServiceWorker
+	// SharedWorker is the SharedWorker constructor.
+	SharedWorker
+	// BroadcastChannel is the BroadcastChannel API.
+	BroadcastChannel
+	// WebLocks is the Web Locks API (navigator.locks).
+	WebLocks
+	// CookieStore is the asynchronous Cookie Store API.
+	CookieStore
+	// ByteStreams is readable byte streams with BYOB readers.
+	ByteStreams
+
+	// lastCapability marks the end of the list
+	lastCapability
+)
+
+// names holds the capability names in bit order.
+var names = [...]string{
+	"fetch-upload-streaming",
+	"request-stream-body",
+	"webtransport",
+	"websocketstream",
+	"compression-stream",
+	"opfs",
+	"shared-array-buffer",
+	"webrtc",
+	"indexeddb",
+	"service-worker",
+	"shared-worker",
+	"broadcast-channel",
+	"web-locks",
+	"cookie-store",
+	"byte-streams",
+}
+
+// String returns the capability name, e.g. "webtransport".
+func (c Capability) String() string {
+	if c == 0 || c&(c-1) != 0 || c >= lastCapability {
+		return "unknown"
+	}
+	return names[bits.TrailingZeros32(uint32(c))]
+}
+
+// Set is a set of capabilities.
+type Set uint32
+
+// Has reports whether all capabilities in c are present.
+func (s Set) Has(c Capability) bool {
+	return Capability(s)&c == c
+}
+
+// With returns the set with c added.
+func (s Set) With(c Capability) Set {
+	return s | Set(c)
+}
+
+// Without returns the set with c removed.
+func (s Set) Without(c Capability) Set {
+	return s &^ Set(c)
+}
+
+// List returns the capabilities in the set.
+func (s Set) List() []Capability {
+	var list []Capability
+	for c := Capability(1); c < lastCapability; c <<= 1 {
+		if s.Has(c) {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// String returns the capability names separated by commas.
+func (s Set) String() string {
+	list := s.List()
+	parts := make([]string, len(list))
+	for i, c := range list {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, ",")
+}
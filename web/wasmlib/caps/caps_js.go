@@ -0,0 +1,136 @@
+package caps
+
+import (
+	"sync"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+var (
+	// _global is a cached reference to the global scope (window or worker)
+	_global = js.Global()
+	// _Object is a cached reference to the JavaScript Object constructor for property definitions
+	_Object = js.Global().Get("Object")
+)
+
+var (
+	detectOnce sync.Once
+	detected   Set
+)
+
+// Detect probes the environment once and returns the capabilities found. Later calls return the cached set.
+func Detect() Set {
+	detectOnce.Do(func() {
+		detected = probe()
+	})
+	return detected
+}
+
+// Has reports whether the environment provides c.
+func Has(c Capability) bool {
+	return Detect().Has(c)
+}
+
+// probe runs all feature tests.
+func probe() Set {
+	var s Set
+
+	requestStream, duplex := probeRequestStreams()
+	if requestStream {
+		s = s.With(RequestStreamBody)
+	}
+	if duplex {
+		s = s.With(FetchUploadStreaming)
+	}
+
+	if isFunction(_global.Get("WebTransport")) {
+		s = s.With(WebTransport)
+	}
+	if isFunction(_global.Get("WebSocketStream")) {
+		s = s.With(WebSocketStream)
+	}
+	if isFunction(_global.Get("CompressionStream")) && isFunction(_global.Get("DecompressionStream")) {
+		s = s.With(CompressionStream)
+	}
+	if isFunction(_global.Get("SharedArrayBuffer")) && _global.Get("crossOriginIsolated").Truthy() {
+		s = s.With(SharedArrayBuffer)
+	}
+	if isFunction(_global.Get("RTCPeerConnection")) {
+		s = s.With(WebRTC)
+	}
+	if _global.Get("indexedDB").Truthy() {
+		s = s.With(IndexedDB)
+	}
+	if isFunction(_global.Get("SharedWorker")) {
+		s = s.With(SharedWorker)
+	}
+	if isFunction(_global.Get("BroadcastChannel")) {
+		s = s.With(BroadcastChannel)
+	}
+	if _global.Get("cookieStore").Truthy() {
+		s = s.With(CookieStore)
+	}
+	if isFunction(_global.Get("ReadableByteStreamController")) && isFunction(_global.Get("ReadableStreamBYOBReader")) {
+		s = s.With(ByteStreams)
+	}
+
+	if navigator := _global.Get("navigator"); navigator.Truthy() {
+		if storage := navigator.Get("storage"); storage.Truthy() && isFunction(storage.Get("getDirectory")) {
+			s = s.With(OPFS)
+		}
+		if navigator.Get("serviceWorker").Truthy() {
+			s = s.With(ServiceWorker)
+		}
+		if navigator.Get("locks").Truthy() {
+			s = s.With(WebLocks)
+		}
+	}
+	return s
+}
+
+// probeRequestStreams checks whether a Request accepts a ReadableStream body and reads the duplex option.
+// Browsers without stream bodies stringify the stream and add a text/plain Content-Type; browsers without
+// upload streaming never read the duplex option.
+func probeRequestStreams() (requestStream, duplex bool) {
+	_Request := _global.Get("Request")
+	_ReadableStream := _global.Get("ReadableStream")
+	if !isFunction(_Request) || !isFunction(_ReadableStream) {
+		return false, false
+	}
+
+	duplexAccessed := false
+	getter := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		duplexAccessed = true
+		return "half"
+	})
+	defer jsguard.Release(getter)
+
+	init := _Object.New()
+	init.Set("method", "POST")
+	init.Set("body", _ReadableStream.New())
+	descriptor := _Object.New()
+	descriptor.Set("get", getter)
+	descriptor.Set("enumerable", true)
+	_Object.Call("defineProperty", init, "duplex", descriptor)
+
+	defer func() {
+		// Constructing the Request may throw in restricted contexts
+		if recover() != nil {
+			requestStream, duplex = false, false
+		}
+	}()
+
+	base := "https://example.invalid/"
+	if location := _global.Get("location"); location.Truthy() {
+		base = location.Get("href").String()
+	}
+	req := _Request.New(base, init)
+	requestStream = !req.Get("headers").Call("has", "Content-Type").Bool()
+	return requestStream, requestStream && duplexAccessed
+}
+
+// isFunction reports whether v is a callable JavaScript value.
+func isFunction(v js.Value) bool {
+	return v.Type() == js.TypeFunction
+}
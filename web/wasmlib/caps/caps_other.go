@@ -0,0 +1,13 @@
+//go:build !js
+
+package caps
+
+// Detect returns the empty set outside the browser, where none of the platform features exist.
+func Detect() Set {
+	return 0
+}
+
+// Has reports false outside the browser.
+func Has(c Capability) bool {
+	return false
+}
@@ -0,0 +1,204 @@
+// Package compressjs compresses and decompresses data with gzip, deflate and deflate-raw. In the browser the
+// native CompressionStream API is used when available; elsewhere, and for encodings the browser lacks, the
+// Go standard library implementations are used. Encoding names match HTTP content codings, and Negotiate
+// picks a common encoding from an Accept-Encoding style list.
+package compressjs
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownEncoding is returned for encodings other than gzip, deflate and deflate-raw
+var ErrUnknownEncoding = errors.New("unknown compression encoding")
+
+// Encoding is a compression format, named as in CompressionStream and HTTP content codings.
+type Encoding string
+
+const (
+	// Identity means no compression.
+	Identity Encoding = "identity"
+	// Gzip is the gzip format (RFC 1952).
+	Gzip Encoding = "gzip"
+	// Deflate is the zlib format (RFC 1950), which HTTP calls deflate.
+	Deflate Encoding = "deflate"
+	// DeflateRaw is a raw DEFLATE stream (RFC 1951) without header or checksum.
+	DeflateRaw Encoding = "deflate-raw"
+)
+
+// Encodings lists the supported encodings in order of preference.
+var Encodings = []Encoding{Gzip, Deflate, DeflateRaw}
+
+// Compress compresses data with enc. Identity returns data unchanged.
+func Compress(ctx context.Context, enc Encoding, data []byte) ([]byte, error) {
+	if enc == Identity {
+		return data, nil
+	}
+	if !valid(enc) {
+		return nil, ErrUnknownEncoding
+	}
+	return compress(ctx, enc, data)
+}
+
+// Decompress decompresses data with enc. Identity returns data unchanged.
+func Decompress(ctx context.Context, enc Encoding, data []byte) ([]byte, error) {
+	if enc == Identity {
+		return data, nil
+	}
+	if !valid(enc) {
+		return nil, ErrUnknownEncoding
+	}
+	return decompress(ctx, enc, data)
+}
+
+// NewWriter returns a streaming compressor writing to w. It always uses the Go implementation, since
+// CompressionStream cannot be driven synchronously.
+func NewWriter(w io.Writer, enc Encoding) (io.WriteCloser, error) {
+	switch enc {
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Deflate:
+		return zlib.NewWriter(w), nil
+	case DeflateRaw:
+		return flate.NewWriter(w, flate.DefaultCompression)
+	case Identity:
+		return nopWriteCloser{w}, nil
+	}
+	return nil, ErrUnknownEncoding
+}
+
+// NewReader returns a streaming decompressor reading from r, using the Go implementation.
+func NewReader(r io.Reader, enc Encoding) (io.ReadCloser, error) {
+	switch enc {
+	case Gzip:
+		return gzip.NewReader(r)
+	case Deflate:
+		return zlib.NewReader(r)
+	case DeflateRaw:
+		return flate.NewReader(r), nil
+	case Identity:
+		return io.NopCloser(r), nil
+	}
+	return nil, ErrUnknownEncoding
+}
+
+// Advertise formats encodings as an Accept-Encoding value, e.g. "gzip, deflate".
+// With no arguments all supported encodings are listed.
+func Advertise(encs ...Encoding) string {
+	if len(encs) == 0 {
+		encs = Encodings
+	}
+	parts := make([]string, len(encs))
+	for i, enc := range encs {
+		parts[i] = string(enc)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Negotiate picks the encoding to use for a peer that advertised accept, an Accept-Encoding style list with
+// optional q-values such as "gzip;q=1.0, deflate;q=0.5, *;q=0". Among the encodings in offered (all supported
+// encodings when empty), the one with the highest q-value wins, ties broken by the order of offered.
+// Identity is returned when no compressed encoding is acceptable.
+func Negotiate(accept string, offered ...Encoding) Encoding {
+	if len(offered) == 0 {
+		offered = Encodings
+	}
+
+	weights := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(key), "q") {
+				if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+		weights[name] = q
+	}
+
+	type candidate struct {
+		enc Encoding
+		q   float64
+	}
+	var candidates []candidate
+	for _, enc := range offered {
+		if !valid(enc) {
+			continue
+		}
+		q, ok := weights[string(enc)]
+		if !ok {
+			q = wildcard
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{enc, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return Identity
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].enc
+}
+
+// valid reports whether enc is a supported compressed encoding.
+func valid(enc Encoding) bool {
+	return enc == Gzip || enc == Deflate || enc == DeflateRaw
+}
+
+// compressGo compresses data with the Go implementation.
+func compressGo(enc Encoding, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, enc)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressGo decompresses data with the Go implementation.
+func decompressGo(enc Encoding, data []byte) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(data), enc)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// nopWriteCloser adds a no-op Close to an io.Writer.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close does nothing.
+func (nopWriteCloser) Close() error {
+	return nil
+}
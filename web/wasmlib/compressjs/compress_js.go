@@ -0,0 +1,80 @@
+package compressjs
+
+import (
+	"context"
+	"sync"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/caps"
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+var (
+	// _CompressionStream is a cached reference to the JavaScript CompressionStream constructor
+	_CompressionStream = js.Global().Get("CompressionStream")
+	// _DecompressionStream is a cached reference to the JavaScript DecompressionStream constructor
+	_DecompressionStream = js.Global().Get("DecompressionStream")
+	// _Blob is a cached reference to the JavaScript Blob constructor for wrapping input data
+	_Blob = js.Global().Get("Blob")
+	// _Response is a cached reference to the JavaScript Response constructor for collecting stream output
+	_Response = js.Global().Get("Response")
+	// _Uint8Array is a cached reference to the JavaScript Uint8Array constructor for typed array operations
+	_Uint8Array = js.Global().Get("Uint8Array")
+	// _Array is a cached reference to the JavaScript Array constructor for array operations
+	_Array = js.Global().Get("Array")
+)
+
+var (
+	nativeOnce sync.Once
+	// native records which encodings CompressionStream accepts; deflate-raw arrived later than the others
+	native map[Encoding]bool
+)
+
+// Native reports whether enc is handled by the browser's CompressionStream.
+func Native(enc Encoding) bool {
+	nativeOnce.Do(func() {
+		native = make(map[Encoding]bool)
+		if !caps.Has(caps.CompressionStream) {
+			return
+		}
+		for _, e := range Encodings {
+			_, err := promisejs.Try(func() js.Value {
+				return _CompressionStream.New(string(e))
+			})
+			native[e] = err == nil
+		}
+	})
+	return native[enc]
+}
+
+// compress uses CompressionStream when it supports enc and the Go implementation otherwise.
+func compress(ctx context.Context, enc Encoding, data []byte) ([]byte, error) {
+	if !Native(enc) {
+		return compressGo(enc, data)
+	}
+	return transform(ctx, _CompressionStream.New(string(enc)), data)
+}
+
+// decompress uses DecompressionStream when it supports enc and the Go implementation otherwise.
+func decompress(ctx context.Context, enc Encoding, data []byte) ([]byte, error) {
+	if !Native(enc) {
+		return decompressGo(enc, data)
+	}
+	return transform(ctx, _DecompressionStream.New(string(enc)), data)
+}
+
+// transform pipes data through a transform stream and collects the output.
+func transform(ctx context.Context, stream js.Value, data []byte) ([]byte, error) {
+	input := _Uint8Array.New(len(data))
+	js.CopyBytesToJS(input, data)
+	readable := _Blob.New(_Array.New(input)).Call("stream").Call("pipeThrough", stream)
+
+	buffer, err := promisejs.Await(ctx, _Response.New(readable).Call("arrayBuffer"))
+	if err != nil {
+		return nil, err
+	}
+	output := _Uint8Array.New(buffer)
+	out := make([]byte, output.Get("byteLength").Int())
+	js.CopyBytesToGo(out, output)
+	return out, nil
+}
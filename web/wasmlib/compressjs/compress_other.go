@@ -0,0 +1,20 @@
+//go:build !js
+
+package compressjs
+
+import "context"
+
+// compress uses the Go implementation outside the browser.
+func compress(ctx context.Context, enc Encoding, data []byte) ([]byte, error) {
+	return compressGo(enc, data)
+}
+
+// decompress uses the Go implementation outside the browser.
+func decompress(ctx context.Context, enc Encoding, data []byte) ([]byte, error) {
+	return decompressGo(enc, data)
+}
+
+// Native reports whether enc is handled by the browser's CompressionStream; always false outside the browser.
+func Native(enc Encoding) bool {
+	return false
+}
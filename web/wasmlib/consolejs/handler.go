@@ -0,0 +1,86 @@
+// Package consolejs provides a log/slog Handler that writes structured records to the browser console.
+// Outside the browser the handler writes text records to standard error instead.
+package consolejs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// HandlerOptions configures a Handler.
+type HandlerOptions struct {
+	// Level is the minimum level to log. Defaults to slog.LevelInfo.
+	Level slog.Leveler
+	// AddSource adds a "source" attribute with the file and line of the log call.
+	AddSource bool
+	// Prefix is prepended to every message, e.g. "[relay]", to tell components apart in a shared console.
+	Prefix string
+	// ReplaceAttr rewrites or drops (by returning an empty Attr) non-group attributes before output,
+	// with the same semantics as slog.HandlerOptions.ReplaceAttr.
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+}
+
+// Handler is a slog.Handler writing to console.debug, console.info, console.warn and console.error
+// according to the record level, so that records can be filtered with the devtools level selector.
+//
+// Each record is logged as its message followed by an object holding its attributes, with groups
+// as nested objects, which devtools renders as an expandable tree. The record time is omitted since
+// devtools timestamps console output itself. Outside the browser records are written to standard error
+// in the format of slog.TextHandler.
+type Handler struct {
+	opts   HandlerOptions
+	goas   []groupOrAttrs // Groups and attributes added with WithGroup and WithAttrs, in order
+	groups []string       // Names of all open groups, passed to ReplaceAttr
+
+	mu *sync.Mutex // Serializes console output of handlers derived from the same root
+}
+
+// groupOrAttrs is either a group name or a list of attributes.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewHandler creates a console handler. opts may be nil.
+func NewHandler(opts *HandlerOptions) *Handler {
+	h := &Handler{mu: new(sync.Mutex)}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.Level == nil {
+		h.opts.Level = slog.LevelInfo
+	}
+	return h
+}
+
+// Enabled reports whether records at level are logged.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.opts.Level.Level()
+}
+
+// WithAttrs returns a handler that adds attrs to every record.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.with(groupOrAttrs{attrs: attrs})
+}
+
+// WithGroup returns a handler that nests subsequent attributes under name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(groupOrAttrs{group: name})
+}
+
+// with returns a copy of the handler with goa appended.
+func (h *Handler) with(goa groupOrAttrs) *Handler {
+	h2 := *h
+	h2.goas = append(h.goas[:len(h.goas):len(h.goas)], goa)
+	if goa.group != "" {
+		h2.groups = append(h.groups[:len(h.groups):len(h.groups)], goa.group)
+	}
+	return &h2
+}
@@ -0,0 +1,166 @@
+package consolejs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsconv"
+)
+
+var (
+	// _console is a cached reference to the JavaScript console object
+	_console = js.Global().Get("console")
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+	// _Date is a cached reference to the JavaScript Date constructor for time attributes
+	_Date = js.Global().Get("Date")
+)
+
+// Handle writes the record to the console.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	root := _Object.New()
+	empty := true
+
+	if h.opts.AddSource && r.PC != 0 {
+		frames := runtime.CallersFrames([]uintptr{r.PC})
+		f, _ := frames.Next()
+		empty = !h.setAttr(root, nil, slog.String(slog.SourceKey, fmt.Sprintf("%s:%d", f.File, f.Line))) && empty
+	}
+
+	// Attributes from WithAttrs, nested under the groups open at the time they were added
+	obj := root
+	var groups []string
+	var parents []js.Value
+	for _, goa := range h.goas {
+		if goa.group != "" {
+			child := _Object.New()
+			obj.Set(goa.group, child)
+			parents = append(parents, obj)
+			obj = child
+			groups = append(groups, goa.group)
+			continue
+		}
+		for _, a := range goa.attrs {
+			empty = !h.setAttr(obj, groups, a) && empty
+		}
+	}
+
+	// Attributes of the record itself go into the innermost group
+	r.Attrs(func(a slog.Attr) bool {
+		empty = !h.setAttr(obj, groups, a) && empty
+		return true
+	})
+
+	// Drop groups that ended up without attributes, innermost first
+	for i := len(parents) - 1; i >= 0; i-- {
+		if _Object.Call("keys", obj).Length() > 0 {
+			break
+		}
+		parents[i].Delete(groups[i])
+		obj = parents[i]
+	}
+
+	msg := r.Message
+	if h.opts.Prefix != "" {
+		msg = h.opts.Prefix + " " + msg
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	method := consoleMethod(r.Level)
+	if empty {
+		_console.Call(method, msg)
+	} else {
+		_console.Call(method, msg, root)
+	}
+	return nil
+}
+
+// setAttr resolves and stores an attribute on obj. It reports whether anything was stored.
+func (h *Handler) setAttr(obj js.Value, groups []string, a slog.Attr) bool {
+	a.Value = a.Value.Resolve()
+
+	if a.Value.Kind() != slog.KindGroup && h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return false
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return false
+		}
+
+		// Attributes of groups with an empty key are inlined
+		target := obj
+		if a.Key != "" {
+			target = _Object.New()
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		stored := false
+		for _, ga := range attrs {
+			stored = h.setAttr(target, groups, ga) || stored
+		}
+		if stored && a.Key != "" {
+			obj.Set(a.Key, target)
+		}
+		return stored
+	}
+
+	obj.Set(a.Key, toJS(a.Value))
+	return true
+}
+
+// toJS converts a resolved non-group slog.Value into a JavaScript value.
+func toJS(v slog.Value) js.Value {
+	switch v.Kind() {
+	case slog.KindString:
+		return js.ValueOf(v.String())
+	case slog.KindInt64:
+		return js.ValueOf(float64(v.Int64()))
+	case slog.KindUint64:
+		return js.ValueOf(float64(v.Uint64()))
+	case slog.KindFloat64:
+		return js.ValueOf(v.Float64())
+	case slog.KindBool:
+		return js.ValueOf(v.Bool())
+	case slog.KindDuration:
+		return js.ValueOf(v.Duration().String())
+	case slog.KindTime:
+		return _Date.New(float64(v.Time().UnixMilli()))
+	}
+
+	switch x := v.Any().(type) {
+	case error:
+		return js.ValueOf(x.Error())
+	case fmt.Stringer:
+		return js.ValueOf(x.String())
+	case js.Value:
+		return x
+	}
+	if value, err := jsconv.Marshal(v.Any()); err == nil {
+		return value
+	}
+	return js.ValueOf(fmt.Sprint(v.Any()))
+}
+
+// consoleMethod returns the console method used for a level.
+func consoleMethod(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "debug"
+	case level < slog.LevelWarn:
+		return "info"
+	case level < slog.LevelError:
+		return "warn"
+	default:
+		return "error"
+	}
+}
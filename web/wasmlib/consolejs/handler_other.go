@@ -0,0 +1,32 @@
+//go:build !js
+
+package consolejs
+
+import (
+	"context"
+	"log/slog"
+	"os"
+)
+
+// Handle writes the record to standard error.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	var th slog.Handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level:       h.opts.Level,
+		AddSource:   h.opts.AddSource,
+		ReplaceAttr: h.opts.ReplaceAttr,
+	})
+	for _, goa := range h.goas {
+		if goa.group != "" {
+			th = th.WithGroup(goa.group)
+		} else {
+			th = th.WithAttrs(goa.attrs)
+		}
+	}
+	if h.opts.Prefix != "" {
+		r.Message = h.opts.Prefix + " " + r.Message
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return th.Handle(ctx, r)
+}
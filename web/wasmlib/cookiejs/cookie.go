@@ -0,0 +1,106 @@
+// Package cookiejs reads and writes cookies through the asynchronous Cookie Store API, falling back to
+// document.cookie where the Cookie Store API is missing. The fallback is only available in window contexts.
+// Outside the browser there are no cookies and every operation returns ErrUnsupported.
+//
+// Names and values are passed through unchanged in both modes; callers that store arbitrary data should
+// encode it first.
+package cookiejs
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnsupported is returned when neither the Cookie Store API nor document.cookie is available
+	ErrUnsupported = errors.New("cookies not supported")
+	// ErrRequestFailed is returned when a cookie operation fails without a reason
+	ErrRequestFailed = errors.New("cookie request failed")
+)
+
+// PollInterval is how often Watch re-reads document.cookie when the Cookie Store API is unavailable.
+var PollInterval = time.Second
+
+// SameSite is the SameSite attribute of a cookie.
+type SameSite string
+
+const (
+	SameSiteStrict SameSite = "strict" // Only sent with same-site requests
+	SameSiteLax    SameSite = "lax"    // Also sent with top-level cross-site navigations
+	SameSiteNone   SameSite = "none"   // Sent with all requests, requires a secure context
+)
+
+// Cookie is a single cookie.
+// Reads through document.cookie only report Name and Value; the other attributes are not exposed there.
+type Cookie struct {
+	Name        string    // Cookie name
+	Value       string    // Cookie value
+	Domain      string    // Domain attribute, empty for a host-only cookie
+	Path        string    // Path attribute, "/" when empty
+	Expires     time.Time // Expiry time, zero for a session cookie
+	Secure      bool      // Only sent over secure connections
+	SameSite    SameSite  // SameSite attribute (default lax)
+	Partitioned bool      // Partitioned (CHIPS) cookie
+}
+
+// Change describes a cookie that was set or deleted.
+type Change struct {
+	Cookie  Cookie // The cookie, only Name (and Domain/Path where known) are set for deletions
+	Deleted bool   // Whether the cookie was deleted or expired
+}
+
+// parse splits a document.cookie string into name/value pairs.
+// A pair without "=" is a cookie with an empty name, matching how browsers serialize it.
+func parse(s string) []Cookie {
+	var cookies []Cookie
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			name, value = "", name
+		}
+		cookies = append(cookies, Cookie{Name: name, Value: value})
+	}
+	return cookies
+}
+
+// serialize formats c as a document.cookie assignment.
+func serialize(c Cookie) string {
+	var b strings.Builder
+	b.WriteString(c.Name)
+	b.WriteByte('=')
+	b.WriteString(c.Value)
+
+	path := c.Path
+	if path == "" {
+		path = "/"
+	}
+	b.WriteString("; Path=")
+	b.WriteString(path)
+	if c.Domain != "" {
+		b.WriteString("; Domain=")
+		b.WriteString(c.Domain)
+	}
+	if !c.Expires.IsZero() {
+		b.WriteString("; Expires=")
+		b.WriteString(c.Expires.UTC().Format(time.RFC1123))
+	}
+	sameSite := c.SameSite
+	if sameSite == "" {
+		sameSite = SameSiteLax
+	}
+	b.WriteString("; SameSite=")
+	b.WriteString(string(sameSite))
+	// The Cookie Store API always sets Secure; SameSite=None and Partitioned require it as well
+	if c.Secure || sameSite == SameSiteNone || c.Partitioned {
+		b.WriteString("; Secure")
+	}
+	if c.Partitioned {
+		b.WriteString("; Partitioned")
+	}
+	return b.String()
+}
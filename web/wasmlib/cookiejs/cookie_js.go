@@ -0,0 +1,280 @@
+package cookiejs
+
+import (
+	"context"
+	"errors"
+	"syscall/js"
+	"time"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/caps"
+	"pkg.gfire.dev/supernet/web/wasmlib/eventjs"
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+var (
+	// _cookieStore is a cached reference to the global cookieStore, undefined outside of supporting browsers
+	_cookieStore = js.Global().Get("cookieStore")
+	// _document is a cached reference to the global document, undefined in workers
+	_document = js.Global().Get("document")
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+)
+
+// Native reports whether the Cookie Store API is available. When false, the document.cookie fallback is used.
+func Native() bool {
+	return caps.Has(caps.CookieStore)
+}
+
+// Supported reports whether cookies can be accessed in the current context.
+func Supported() bool {
+	return Native() || _document.Truthy()
+}
+
+// Get returns the cookie with the given name, or nil if it is not set.
+func Get(ctx context.Context, name string) (*Cookie, error) {
+	if Native() {
+		v, err := await(ctx, _cookieStore.Call("get", name))
+		if err != nil {
+			return nil, err
+		}
+		if !v.Truthy() {
+			return nil, nil
+		}
+		c := fromJS(v)
+		return &c, nil
+	}
+
+	cookies, err := documentCookies()
+	if err != nil {
+		return nil, err
+	}
+	for i := range cookies {
+		if cookies[i].Name == name {
+			return &cookies[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// GetAll returns all cookies visible to the current context.
+func GetAll(ctx context.Context) ([]Cookie, error) {
+	if Native() {
+		v, err := await(ctx, _cookieStore.Call("getAll"))
+		if err != nil {
+			return nil, err
+		}
+		cookies := make([]Cookie, v.Length())
+		for i := range cookies {
+			cookies[i] = fromJS(v.Index(i))
+		}
+		return cookies, nil
+	}
+	return documentCookies()
+}
+
+// Set creates or replaces a cookie.
+func Set(ctx context.Context, c Cookie) error {
+	if Native() {
+		opts := _Object.New()
+		opts.Set("name", c.Name)
+		opts.Set("value", c.Value)
+		if c.Domain != "" {
+			opts.Set("domain", c.Domain)
+		}
+		if c.Path != "" {
+			opts.Set("path", c.Path)
+		}
+		if !c.Expires.IsZero() {
+			opts.Set("expires", c.Expires.UnixMilli())
+		}
+		if c.SameSite != "" {
+			opts.Set("sameSite", string(c.SameSite))
+		}
+		if c.Partitioned {
+			opts.Set("partitioned", true)
+		}
+		_, err := await(ctx, _cookieStore.Call("set", opts))
+		return err
+	}
+
+	if !_document.Truthy() {
+		return ErrUnsupported
+	}
+	_document.Set("cookie", serialize(c))
+	return nil
+}
+
+// Delete removes the cookie with the given name. Domain, Path and Partitioned of c must match the
+// attributes the cookie was set with; Value and Expires are ignored.
+func Delete(ctx context.Context, c Cookie) error {
+	if Native() {
+		opts := _Object.New()
+		opts.Set("name", c.Name)
+		if c.Domain != "" {
+			opts.Set("domain", c.Domain)
+		}
+		if c.Path != "" {
+			opts.Set("path", c.Path)
+		}
+		if c.Partitioned {
+			opts.Set("partitioned", true)
+		}
+		_, err := await(ctx, _cookieStore.Call("delete", opts))
+		return err
+	}
+
+	c.Value = ""
+	c.Expires = time.Unix(0, 0)
+	return Set(ctx, c)
+}
+
+// Watch reports cookie changes until ctx is cancelled, at which point the channel is closed.
+// With the Cookie Store API changes are delivered from change events; otherwise document.cookie is
+// polled every PollInterval and only name/value changes are visible.
+func Watch(ctx context.Context) (<-chan Change, error) {
+	if Native() {
+		return watchNative(ctx)
+	}
+	if !_document.Truthy() {
+		return nil, ErrUnsupported
+	}
+	return watchPoll(ctx)
+}
+
+// watchNative converts cookieStore change events into Changes.
+func watchNative(ctx context.Context) (<-chan Change, error) {
+	l, err := eventjs.Chan(_cookieStore, "change", 16, eventjs.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(chan Change)
+	go func() {
+		defer close(changes)
+		defer l.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-l.C:
+				var batch []Change
+				changed := event.Get("changed")
+				for i := 0; i < changed.Length(); i++ {
+					batch = append(batch, Change{Cookie: fromJS(changed.Index(i))})
+				}
+				deleted := event.Get("deleted")
+				for i := 0; i < deleted.Length(); i++ {
+					batch = append(batch, Change{Cookie: fromJS(deleted.Index(i)), Deleted: true})
+				}
+				for _, c := range batch {
+					select {
+					case changes <- c:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// watchPoll diffs document.cookie snapshots into Changes.
+func watchPoll(ctx context.Context) (<-chan Change, error) {
+	snapshot := func() map[string]string {
+		m := make(map[string]string)
+		cookies, _ := documentCookies()
+		for _, c := range cookies {
+			m[c.Name] = c.Value
+		}
+		return m
+	}
+
+	changes := make(chan Change)
+	prev := snapshot()
+	go func() {
+		defer close(changes)
+
+		ticker := time.NewTicker(PollInterval)
+		defer ticker.Stop()
+
+		send := func(c Change) bool {
+			select {
+			case changes <- c:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			cur := snapshot()
+			for name, value := range cur {
+				if old, ok := prev[name]; !ok || old != value {
+					if !send(Change{Cookie: Cookie{Name: name, Value: value}}) {
+						return
+					}
+				}
+			}
+			for name := range prev {
+				if _, ok := cur[name]; !ok {
+					if !send(Change{Cookie: Cookie{Name: name}, Deleted: true}) {
+						return
+					}
+				}
+			}
+			prev = cur
+		}
+	}()
+	return changes, nil
+}
+
+// documentCookies parses document.cookie.
+func documentCookies() ([]Cookie, error) {
+	if !_document.Truthy() {
+		return nil, ErrUnsupported
+	}
+	return parse(_document.Get("cookie").String()), nil
+}
+
+// fromJS converts a CookieListItem into a Cookie.
+func fromJS(v js.Value) Cookie {
+	c := Cookie{
+		Name:        stringOf(v.Get("name")),
+		Value:       stringOf(v.Get("value")),
+		Domain:      stringOf(v.Get("domain")),
+		Path:        stringOf(v.Get("path")),
+		SameSite:    SameSite(stringOf(v.Get("sameSite"))),
+		Secure:      v.Get("secure").Truthy(),
+		Partitioned: v.Get("partitioned").Truthy(),
+	}
+	if expires := v.Get("expires"); expires.Type() == js.TypeNumber {
+		c.Expires = time.UnixMilli(int64(expires.Float()))
+	}
+	return c
+}
+
+// stringOf returns the string value of v, or "" if v is null or undefined.
+func stringOf(v js.Value) string {
+	if v.Type() != js.TypeString {
+		return ""
+	}
+	return v.String()
+}
+
+// await blocks until the given JavaScript promise settles or ctx is done.
+// Rejections without a reason are reported as ErrRequestFailed.
+func await(ctx context.Context, promise js.Value) (js.Value, error) {
+	v, err := promisejs.Await(ctx, promise)
+	if errors.Is(err, promisejs.ErrRejected) {
+		return v, ErrRequestFailed
+	}
+	return v, err
+}
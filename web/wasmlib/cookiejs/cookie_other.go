@@ -0,0 +1,40 @@
+//go:build !js
+
+package cookiejs
+
+import "context"
+
+// Native reports false outside the browser.
+func Native() bool {
+	return false
+}
+
+// Supported reports false outside the browser.
+func Supported() bool {
+	return false
+}
+
+// Get returns ErrUnsupported outside the browser.
+func Get(ctx context.Context, name string) (*Cookie, error) {
+	return nil, ErrUnsupported
+}
+
+// GetAll returns ErrUnsupported outside the browser.
+func GetAll(ctx context.Context) ([]Cookie, error) {
+	return nil, ErrUnsupported
+}
+
+// Set returns ErrUnsupported outside the browser.
+func Set(ctx context.Context, c Cookie) error {
+	return ErrUnsupported
+}
+
+// Delete returns ErrUnsupported outside the browser.
+func Delete(ctx context.Context, c Cookie) error {
+	return ErrUnsupported
+}
+
+// Watch returns ErrUnsupported outside the browser.
+func Watch(ctx context.Context) (<-chan Change, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,20 @@
+// Package eventjs registers listeners on JavaScript EventTargets and guarantees that every listener is
+// removed and its js.Func released when it is closed, either individually or in bulk through a Group.
+// Listeners need the browser; elsewhere only the options and errors are declared.
+package eventjs
+
+import "errors"
+
+var (
+	// ErrClosed is returned when adding a listener to a closed group.
+	ErrClosed = errors.New("eventjs: group closed")
+	// ErrInvalidTarget is returned when the target does not implement addEventListener.
+	ErrInvalidTarget = errors.New("eventjs: target is not an EventTarget")
+)
+
+// Options configures a listener registration. It mirrors the options argument of addEventListener.
+type Options struct {
+	Capture bool // Dispatch during the capture phase
+	Passive bool // Promise not to call preventDefault, allowing the browser to optimize scrolling
+	Once    bool // Remove the listener after the first event
+}
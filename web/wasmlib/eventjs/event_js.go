@@ -0,0 +1,249 @@
+package eventjs
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+var (
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+	// _AbortController is a cached reference to the JavaScript AbortController constructor for bulk listener removal
+	_AbortController = js.Global().Get("AbortController")
+)
+
+// Listener is a registered event listener. Close removes it and releases its callback.
+type Listener struct {
+	// C receives events for listeners created with Chan; nil for handler listeners.
+	// Events are dropped when the channel is full, since the JavaScript event loop must not block.
+	// C is closed when the listener is closed.
+	C <-chan js.Value
+
+	target  js.Value // EventTarget the listener is registered on
+	typ     string   // Event type
+	capture bool     // Capture flag, needed to match removeEventListener
+	fn      js.Func  // Registered callback
+	c       chan js.Value
+	dropped atomic.Uint64
+
+	mu     sync.Mutex
+	closed bool
+	onDone func() // Called once after the listener is closed, used by groups for bookkeeping
+}
+
+// Listen registers handler for events of type typ on target.
+// handler runs on the JavaScript event loop; it may call preventDefault or stopPropagation, but must not block.
+func Listen(target js.Value, typ string, handler func(event js.Value), opts Options) (*Listener, error) {
+	return listen(target, typ, handler, nil, opts, js.Undefined())
+}
+
+// Chan registers a listener that delivers events of type typ on target to the channel C with the given buffer size.
+func Chan(target js.Value, typ string, size int, opts Options) (*Listener, error) {
+	return listen(target, typ, nil, make(chan js.Value, size), opts, js.Undefined())
+}
+
+// Once waits for a single event of type typ on target, or for ctx to be done.
+// The listener is removed in both cases.
+func Once(ctx context.Context, target js.Value, typ string) (js.Value, error) {
+	l, err := Chan(target, typ, 1, Options{Once: true})
+	if err != nil {
+		return js.Undefined(), err
+	}
+	defer l.Close()
+
+	select {
+	case event := <-l.C:
+		return event, nil
+	case <-ctx.Done():
+		return js.Undefined(), ctx.Err()
+	}
+}
+
+// listen registers a handler or channel listener, optionally bound to an AbortSignal.
+func listen(target js.Value, typ string, handler func(js.Value), c chan js.Value, opts Options, signal js.Value) (*Listener, error) {
+	if target.Type() != js.TypeObject || target.Get("addEventListener").Type() != js.TypeFunction {
+		return nil, ErrInvalidTarget
+	}
+
+	l := &Listener{
+		target:  target,
+		typ:     typ,
+		capture: opts.Capture,
+		c:       c,
+	}
+	if c != nil {
+		l.C = c
+	}
+
+	l.fn = jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		event := js.Undefined()
+		if len(args) > 0 {
+			event = args[0]
+		}
+
+		if handler != nil {
+			handler(event)
+		} else {
+			l.deliver(event)
+		}
+
+		// The browser has already removed once listeners, only release the callback
+		if opts.Once {
+			l.Close()
+		}
+		return nil
+	})
+
+	jsOpts := _Object.New()
+	jsOpts.Set("capture", opts.Capture)
+	jsOpts.Set("passive", opts.Passive)
+	jsOpts.Set("once", opts.Once)
+	if !signal.IsUndefined() {
+		jsOpts.Set("signal", signal)
+	}
+	jsguard.AddEventListener(target, typ, l.fn, jsOpts)
+
+	return l, nil
+}
+
+// deliver sends an event to the listener channel without blocking.
+func (l *Listener) deliver(event js.Value) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return
+	}
+	select {
+	case l.c <- event:
+	default:
+		l.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of events dropped because C was full.
+func (l *Listener) Dropped() uint64 {
+	return l.dropped.Load()
+}
+
+// Close removes the listener from its target, releases its callback and closes C.
+// It is safe to call Close more than once and from within the listener itself.
+func (l *Listener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	if l.c != nil {
+		close(l.c)
+	}
+	onDone := l.onDone
+	l.mu.Unlock()
+
+	jsguard.RemoveEventListener(l.target, l.typ, l.fn, l.capture)
+	jsguard.Release(l.fn)
+
+	if onDone != nil {
+		onDone()
+	}
+	return nil
+}
+
+// Group manages a set of listeners that are torn down together.
+// All listeners in a group share an AbortSignal, so closing the group removes them from their targets in one
+// operation before their callbacks are released.
+type Group struct {
+	controller js.Value // AbortController whose signal is attached to every listener
+
+	mu        sync.Mutex
+	listeners map[*Listener]struct{}
+	closed    bool
+	stop      func() bool // Stops the context watcher
+}
+
+// NewGroup creates a listener group that is closed when ctx is done.
+// Pass context.Background() to close the group only explicitly.
+func NewGroup(ctx context.Context) *Group {
+	g := &Group{
+		controller: _AbortController.New(),
+		listeners:  make(map[*Listener]struct{}),
+	}
+	g.stop = context.AfterFunc(ctx, func() {
+		g.Close()
+	})
+	return g
+}
+
+// Signal returns the group's AbortSignal. It aborts when the group is closed, so it can also be
+// passed to fetch or other APIs whose lifetime should match the group.
+func (g *Group) Signal() js.Value {
+	return g.controller.Get("signal")
+}
+
+// Listen registers a handler listener in the group. See Listen.
+func (g *Group) Listen(target js.Value, typ string, handler func(event js.Value), opts Options) (*Listener, error) {
+	return g.add(target, typ, handler, nil, opts)
+}
+
+// Chan registers a channel listener in the group. See Chan.
+func (g *Group) Chan(target js.Value, typ string, size int, opts Options) (*Listener, error) {
+	return g.add(target, typ, nil, make(chan js.Value, size), opts)
+}
+
+// add registers a listener bound to the group's signal.
+func (g *Group) add(target js.Value, typ string, handler func(js.Value), c chan js.Value, opts Options) (*Listener, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return nil, ErrClosed
+	}
+
+	l, err := listen(target, typ, handler, c, opts, g.Signal())
+	if err != nil {
+		return nil, err
+	}
+	l.onDone = func() {
+		g.mu.Lock()
+		delete(g.listeners, l)
+		g.mu.Unlock()
+	}
+	g.listeners[l] = struct{}{}
+	return l, nil
+}
+
+// Len returns the number of open listeners in the group.
+func (g *Group) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.listeners)
+}
+
+// Close aborts the group's signal, removing all of its listeners, and releases their callbacks.
+func (g *Group) Close() error {
+	g.mu.Lock()
+	if g.closed {
+		g.mu.Unlock()
+		return nil
+	}
+	g.closed = true
+	listeners := g.listeners
+	g.listeners = nil
+	g.mu.Unlock()
+
+	g.stop()
+	g.controller.Call("abort")
+
+	for l := range listeners {
+		l.mu.Lock()
+		l.onDone = nil
+		l.mu.Unlock()
+		l.Close()
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package httpjs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+// DoAll sends reqs within ctx with at most maxParallel of them in flight, e.g. to load the many resources of
+// a dashboard at once without queueing them all in the browser, which opens only 6 connections per host over
+// HTTP/1.1. A maxParallel below 1 sends all of them at once. The response to reqs[i] is responses[i], nil if
+// the request failed. The error joins the errors of the failed requests, each naming the request, and is nil
+// if all of them succeeded. The responses must be closed by the caller, also when the error is not nil.
+func DoAll(ctx context.Context, reqs []*Request, maxParallel int) ([]*Response, error) {
+	jsguard.Check("httpjs.DoAll")
+	if maxParallel < 1 || maxParallel > len(reqs) {
+		maxParallel = len(reqs)
+	}
+
+	responses := make([]*Response, len(reqs))
+	errs := make([]error, len(reqs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range maxParallel {
+		wg.Go(func() {
+			for i := range next {
+				resp, err := reqs[i].DoContext(ctx)
+				if err != nil {
+					err = fmt.Errorf("%s %s: %w", reqs[i].Method, reqs[i].URL, err)
+				}
+				responses[i], errs[i] = resp, err
+			}
+		})
+	}
+	for i := range reqs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return responses, errors.Join(errs...)
+}
@@ -0,0 +1,13 @@
+//go:build !js
+
+package httpjs
+
+import (
+	"testing"
+
+	"pkg.gfire.dev/supernet/web/browsertest"
+)
+
+func TestBrowser(t *testing.T) {
+	browsertest.Run(t, browsertest.Config{Args: []string{"-test.v"}})
+}
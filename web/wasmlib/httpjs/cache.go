@@ -0,0 +1,127 @@
+package httpjs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheStoredHeader carries the time a response was stored by a ResponseCache, since browsers hide the Date
+// header of cross-origin responses. It is removed from the responses returned.
+const cacheStoredHeader = "X-Httpjs-Stored"
+
+// ResponseCache stores responses in a cache of the Cache Storage API of the browser, which keeps them across
+// page loads, see Client.Cache. Responses are stored under their request URL and the request headers named
+// by their Vary header, which the browser matches.
+type ResponseCache struct {
+	cacheBinding
+}
+
+// doCached is Do for GET requests of a Client with a Cache.
+func (c *Client) doCached(ctx context.Context, r *Request) (*Response, error) {
+	cached, err := c.Cache.match(ctx, r)
+	if err != nil {
+		r.log().Debug("httpjs cache lookup failed", "url", r.URL, "err", err)
+	}
+	if cached != nil && fresh(cached.Header, time.Now()) {
+		cached.Header.Del(cacheStoredHeader)
+		return cached, nil
+	}
+
+	req := *r
+	req.cache = c.Cache
+	if cached != nil {
+		// Ask the server whether the stored response is still valid, which it answers with 304 Not Modified
+		req.Header = make(http.Header, len(r.Header)+2)
+		for key, values := range r.Header {
+			req.Header[key] = values
+		}
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := cached.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+		cached.Header.Del(cacheStoredHeader)
+	}
+
+	resp, err := c.send(ctx, &req)
+	switch {
+	case cached == nil:
+		return resp, err
+	case err != nil && errors.Is(err, ErrRequestFailed), err == nil && resp.StatusCode >= 500:
+		// The stored response is better than none while offline or while the server fails
+		if resp != nil {
+			resp.Close()
+		}
+		return cached, nil
+	case err != nil:
+		cached.Close()
+		return nil, err
+	case resp.StatusCode == http.StatusNotModified:
+		resp.Close()
+		for key, values := range resp.Header {
+			if key != "Content-Length" {
+				cached.Header[key] = values
+			}
+		}
+		if err := c.Cache.update(ctx, r, resp.Header); err != nil {
+			r.log().Debug("httpjs cache update failed", "url", r.URL, "err", err)
+		}
+		return cached, nil
+	}
+	cached.Close()
+	return resp, nil
+}
+
+// cacheable reports whether a response to a GET request may be stored: a 200 OK response that does not
+// forbid it and can be matched.
+func cacheable(status int, header http.Header) bool {
+	if status != http.StatusOK || strings.TrimSpace(header.Get("Vary")) == "*" {
+		return false
+	}
+	_, noStore := cacheControl(header)["no-store"]
+	return !noStore
+}
+
+// fresh reports whether a stored response may be returned at now without asking the server, by the max-age
+// directive of its Cache-Control header or its Expires header.
+func fresh(header http.Header, now time.Time) bool {
+	stored, err := http.ParseTime(header.Get(cacheStoredHeader))
+	if err != nil {
+		return false
+	}
+	// The response may have been stored by an intermediate cache before, see RFC 9111
+	age := now.Sub(stored)
+	if seconds, err := strconv.Atoi(header.Get("Age")); err == nil && seconds > 0 {
+		age += time.Duration(seconds) * time.Second
+	}
+
+	directives := cacheControl(header)
+	if _, ok := directives["no-cache"]; ok {
+		return false
+	}
+	if v, ok := directives["max-age"]; ok {
+		seconds, err := strconv.Atoi(v)
+		return err == nil && age < time.Duration(seconds)*time.Second
+	}
+	if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+		return now.Before(expires)
+	}
+	return false
+}
+
+// cacheControl returns the directives of the Cache-Control headers with their values, if any.
+func cacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, line := range header.Values("Cache-Control") {
+		for directive := range strings.SplitSeq(line, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return directives
+}
@@ -0,0 +1,134 @@
+package httpjs
+
+import (
+	"context"
+	"net/http"
+	"syscall/js"
+	"time"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+// _caches is a cached reference to the CacheStorage of the global scope, only exposed to secure contexts
+var _caches = js.Global().Get("caches")
+
+// cacheBinding holds the JavaScript side of a ResponseCache.
+type cacheBinding struct {
+	cache js.Value // The JavaScript Cache object
+}
+
+// OpenResponseCache opens the cache of the Cache Storage API with the given name, creating it if needed.
+// Returns ErrCacheUnsupported if the browser has no Cache Storage API, which is only available to pages
+// served over HTTPS and localhost.
+func OpenResponseCache(ctx context.Context, name string) (*ResponseCache, error) {
+	if !_caches.Truthy() {
+		return nil, ErrCacheUnsupported
+	}
+	cache, err := promisejs.Await(ctx, _caches.Call("open", name))
+	if err != nil {
+		return nil, err
+	}
+	return &ResponseCache{cacheBinding{cache: cache}}, nil
+}
+
+// key returns the JavaScript Request responses to r are stored under, with the headers of r for matching
+// the Vary header of the responses.
+func (c *ResponseCache) key(r *Request) (js.Value, error) {
+	return promisejs.Try(func() js.Value {
+		headers := _Headers.New()
+		for key, values := range r.Header {
+			for _, value := range values {
+				headers.Call("append", key, value)
+			}
+		}
+		init := _Object.New()
+		init.Set("headers", headers)
+		return _Request.New(r.URL, init)
+	})
+}
+
+// match returns the stored response to r, or nil if there is none.
+func (c *ResponseCache) match(ctx context.Context, r *Request) (*Response, error) {
+	key, err := c.key(r)
+	if err != nil {
+		return nil, err
+	}
+	jsResp, err := promisejs.Await(ctx, c.cache.Call("match", key))
+	if err != nil || jsResp.IsUndefined() {
+		return nil, err
+	}
+	resp := r.newResponse(ctx, jsResp)
+	// Responses stored by store have no URL of their own
+	if resp.URL == "" {
+		resp.URL = resolveURL(r.URL)
+	}
+	resp.Cached = true
+	return resp, nil
+}
+
+// store puts a copy of jsResp, the response to r, into the cache in the background if it may be stored. It
+// must be called before the body of jsResp is read.
+func (c *ResponseCache) store(r *Request, jsResp js.Value) {
+	jsHeaders := jsResp.Get("headers")
+	header := make(http.Header)
+	for _, name := range []string{"Cache-Control", "Vary"} {
+		if v := jsHeaders.Call("get", name); !v.IsNull() {
+			header.Set(name, v.String())
+		}
+	}
+	if !cacheable(jsResp.Get("status").Int(), header) {
+		return
+	}
+	key, err := c.key(r)
+	if err != nil {
+		return
+	}
+
+	headers := _Headers.New(jsHeaders)
+	headers.Call("set", cacheStoredHeader, time.Now().UTC().Format(http.TimeFormat))
+	stored := _Response.New(jsResp.Call("clone").Get("body"), responseInit(jsResp, headers))
+	log, url := r.log(), r.URL
+	promisejs.Then(c.cache.Call("put", key, stored), func(_ js.Value, err error) {
+		if err != nil {
+			log.Debug("httpjs cache store failed", "url", url, "err", err)
+		}
+	})
+}
+
+// update replaces the headers of the stored response to r by those of a 304 Not Modified response to it,
+// which also renews its storage time.
+func (c *ResponseCache) update(ctx context.Context, r *Request, header http.Header) error {
+	key, err := c.key(r)
+	if err != nil {
+		return err
+	}
+	stored, err := promisejs.Await(ctx, c.cache.Call("match", key))
+	if err != nil || stored.IsUndefined() {
+		return err
+	}
+
+	headers := _Headers.New(stored.Get("headers"))
+	for key, values := range header {
+		// A 304 response describes the stored body without having one
+		if key == "Content-Length" {
+			continue
+		}
+		headers.Call("delete", key)
+		for _, value := range values {
+			headers.Call("append", key, value)
+		}
+	}
+	headers.Call("set", cacheStoredHeader, time.Now().UTC().Format(http.TimeFormat))
+	renewed := _Response.New(stored.Get("body"), responseInit(stored, headers))
+	_, err = promisejs.Await(ctx, c.cache.Call("put", key, renewed))
+	return err
+}
+
+// responseInit returns the options of the Response constructor copying the status of jsResp with headers.
+func responseInit(jsResp, headers js.Value) js.Value {
+	init := _Object.New()
+	init.Set("status", jsResp.Get("status"))
+	init.Set("statusText", jsResp.Get("statusText"))
+	init.Set("headers", headers)
+	return init
+}
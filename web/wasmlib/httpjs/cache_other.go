@@ -0,0 +1,26 @@
+//go:build !js
+
+package httpjs
+
+import (
+	"context"
+	"net/http"
+)
+
+// cacheBinding is empty outside the browser.
+type cacheBinding struct{}
+
+// OpenResponseCache returns ErrCacheUnsupported outside the browser.
+func OpenResponseCache(ctx context.Context, name string) (*ResponseCache, error) {
+	return nil, ErrCacheUnsupported
+}
+
+// match finds no response outside the browser.
+func (c *ResponseCache) match(ctx context.Context, r *Request) (*Response, error) {
+	return nil, nil
+}
+
+// update does nothing outside the browser.
+func (c *ResponseCache) update(ctx context.Context, r *Request, header http.Header) error {
+	return nil
+}
@@ -0,0 +1,299 @@
+package httpjs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"pkg.gfire.dev/supernet/retry"
+)
+
+// defaultAttempts is the number of attempts of a Client whose policy sets no limit
+const defaultAttempts = 4
+
+// errRetryStatus marks responses whose status is retried
+var errRetryStatus = errors.New("retryable status")
+
+// TokenSource returns the bearer token authenticating a request of a Client, e.g. an OAuth 2.0 access token it
+// caches until it expires. refresh is set once the server rejected the previous token, asking for a new one
+// instead of the cached one. It is called from the goroutine sending the request.
+type TokenSource func(ctx context.Context, refresh bool) (string, error)
+
+// RoundTripFunc sends a single attempt of a request of a Client, see Client.Transport. Tests replace it to
+// answer requests without a server, building the responses with NewResponse.
+type RoundTripFunc func(ctx context.Context, r *Request) (*Response, error)
+
+// Client sends requests, retrying them after network errors and responses asking to try again later, so
+// callers on flaky networks do not have to. The zero value is ready to use, and a Client must not be copied
+// once it has sent a request.
+type Client struct {
+	// BaseURL, if set, is the absolute URL relative request URLs are resolved against like the links of a
+	// page, e.g. "users/1" against "https://api.example.com/v1/" is "https://api.example.com/v1/users/1".
+	// A path of BaseURL without a trailing slash loses its last segment, and request URLs starting with a
+	// slash replace the whole path. Absolute request URLs are sent unchanged.
+	BaseURL string
+	// DefaultHeaders are added to every request that does not set them itself, e.g. an API key or Accept.
+	DefaultHeaders http.Header
+	// Timeout is the Request.Timeout of requests that set none, bounding waiting for the response of every
+	// attempt. Zero waits indefinitely.
+	Timeout time.Duration
+	// Transport sends every attempt of a request; nil sends it with Request.DoContext. Requests passed to
+	// it have BaseURL, DefaultHeaders, Timeout and the token of TokenSource applied.
+	Transport RoundTripFunc
+	// Retry paces the attempts with exponential backoff and jitter, see retry.Policy. Without MaxAttempts
+	// and MaxElapsed a request is attempted 4 times. Classify defaults to retrying ErrRequestFailed, which
+	// is all a browser tells about network errors, and the statuses of RetryStatus. Requests that are not
+	// idempotent are attempted once, see Do.
+	Retry retry.Policy
+	// RetryStatus reports whether a response status is retried. The default retries 429 Too Many Requests
+	// and 5xx statuses except 501 Not Implemented and 505 HTTP Version Not Supported. A Retry-After
+	// header on the response replaces the backoff delay.
+	RetryStatus func(code int) bool
+	// TokenSource, if set, authenticates every attempt with a bearer token, replacing the Authorization
+	// header of the request. A request rejected with 401 Unauthorized is sent once more with a refreshed
+	// token, unless it has a BodyReader. Errors of TokenSource fail the request without retries.
+	TokenSource TokenSource
+	// Cache, if set, stores the responses to GET requests for revalidation and offline reads, see
+	// OpenResponseCache. A stored response is returned without sending the request while it is fresh by
+	// the max-age directive of its Cache-Control header or its Expires header. Otherwise the request asks
+	// the server whether it changed with If-None-Match and If-Modified-Since, which cross-origin servers must
+	// allow by exposing the ETag header, and the stored response is returned for 304 Not Modified, for 5xx
+	// statuses and if the request fails with ErrRequestFailed, e.g. offline. Returned stored responses have
+	// Cached set. Responses with status 200 are stored unless Cache-Control forbids it with no-store; those
+	// sent with XMLHttpRequest are not stored.
+	Cache *ResponseCache
+	// RateLimit, if positive, bounds the requests sent per second with a token bucket, e.g. to stay under
+	// the quota of a third-party API. Every attempt waits for a token, including retries, while stored
+	// responses returned by Cache take none. Waiting fails the request if it would outlast the deadline of ctx.
+	RateLimit float64
+	// RateBurst is the number of requests that may be sent at once above RateLimit (default one second's
+	// worth). RateLimit and RateBurst must not change once the client has sent a request.
+	RateBurst int
+
+	limiterOnce sync.Once
+	limiter     *rate.Limiter // Paces the attempts by RateLimit, nil when unlimited
+}
+
+// Do sends r within ctx, retrying as the client says. The client cannot tell whether a request that failed
+// reached the server, so only requests that may be sent twice are retried: those with the idempotent
+// methods GET, HEAD, OPTIONS, PUT and DELETE, and others marked with WithIdempotent or an Idempotency-Key
+// header. Requests with a BodyReader are only sent once, since the reader cannot be replayed. Once the
+// retries are exhausted the last response is returned if there was one, otherwise the last error. The span
+// of every attempt belongs to the trace of ctx.
+func (c *Client) Do(ctx context.Context, r *Request) (*Response, error) {
+	r, err := c.prepare(r)
+	if err != nil {
+		return nil, err
+	}
+	if c.Cache != nil && (r.Method == http.MethodGet || r.Method == "") {
+		return c.doCached(ctx, r)
+	}
+	return c.send(ctx, r)
+}
+
+// prepare returns a copy of r with the URL resolved against BaseURL, the DefaultHeaders it lacks and the
+// Timeout of the client, or r itself if the client changes nothing.
+func (c *Client) prepare(r *Request) (*Request, error) {
+	if c.BaseURL == "" && len(c.DefaultHeaders) == 0 && (c.Timeout == 0 || r.Timeout != 0) {
+		return r, nil
+	}
+	req := *r
+	if c.BaseURL != "" {
+		base, err := url.Parse(c.BaseURL)
+		if err != nil {
+			return nil, err
+		}
+		ref, err := url.Parse(r.URL)
+		if err != nil {
+			return nil, err
+		}
+		req.URL = base.ResolveReference(ref).String()
+	}
+	if len(c.DefaultHeaders) > 0 {
+		req.Header = make(http.Header, len(r.Header)+len(c.DefaultHeaders))
+		maps.Copy(req.Header, r.Header)
+		for key, values := range c.DefaultHeaders {
+			if len(req.Header.Values(key)) == 0 {
+				req.Header[http.CanonicalHeaderKey(key)] = values
+			}
+		}
+	}
+	if req.Timeout == 0 {
+		req.Timeout = c.Timeout
+	}
+	return &req, nil
+}
+
+// send sends r, retrying as the client says.
+func (c *Client) send(ctx context.Context, r *Request) (*Response, error) {
+	policy := c.Retry
+	if policy.MaxAttempts == 0 && policy.MaxElapsed == 0 {
+		policy.MaxAttempts = defaultAttempts
+	}
+	if r.BodyReader != nil || !idempotent(r) {
+		policy.MaxAttempts = 1
+	}
+	if policy.Classify == nil {
+		policy.Classify = classifyError
+	}
+
+	// last is the latest response with a retried status, closed when another attempt is made
+	var last *Response
+	resp, err := retry.DoValue(ctx, policy, func(ctx context.Context) (*Response, error) {
+		if last != nil {
+			last.Close()
+			last = nil
+		}
+
+		resp, err := c.do(ctx, r)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				// Only the attempt timed out, see Request.Timeout
+				return nil, &timeoutError{err: err}
+			}
+			return nil, err
+		}
+		if resp.Opaque || !c.retryStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		last = resp
+		err = fmt.Errorf("%w %d", errRetryStatus, resp.StatusCode)
+		if delay, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			err = retry.After(err, delay)
+		}
+		return nil, err
+	})
+	if err == nil {
+		return resp, nil
+	}
+	if last != nil {
+		if errors.Is(err, errRetryStatus) {
+			return last, nil
+		}
+		last.Close()
+	}
+	var timeout *timeoutError
+	if errors.As(err, &timeout) {
+		return nil, timeout.err
+	}
+	return nil, err
+}
+
+// do sends r once, authenticated by TokenSource if there is one.
+func (c *Client) do(ctx context.Context, r *Request) (*Response, error) {
+	if c.TokenSource == nil {
+		return c.roundTrip(ctx, r)
+	}
+	resp, err := c.doToken(ctx, r, false)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || r.BodyReader != nil {
+		return resp, err
+	}
+	resp.Close()
+	return c.doToken(ctx, r, true)
+}
+
+// doToken sends a copy of r with a bearer token of TokenSource, leaving the headers of r unchanged.
+func (c *Client) doToken(ctx context.Context, r *Request, refresh bool) (*Response, error) {
+	token, err := c.TokenSource(ctx, refresh)
+	if err != nil {
+		return nil, err
+	}
+	req := *r
+	req.Header = make(http.Header, len(r.Header)+1)
+	maps.Copy(req.Header, r.Header)
+	req.SetBearerToken(token)
+	return c.roundTrip(ctx, &req)
+}
+
+// roundTrip sends r once with the Transport of the client.
+func (c *Client) roundTrip(ctx context.Context, r *Request) (*Response, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	if c.Transport != nil {
+		return c.Transport(ctx, r)
+	}
+	return r.DoContext(ctx)
+}
+
+// wait waits until RateLimit allows another attempt.
+func (c *Client) wait(ctx context.Context) error {
+	c.limiterOnce.Do(func() {
+		if c.RateLimit <= 0 {
+			return
+		}
+		burst := c.RateBurst
+		if burst <= 0 {
+			burst = max(int(c.RateLimit), 1)
+		}
+		c.limiter = rate.NewLimiter(rate.Limit(c.RateLimit), burst)
+	})
+	if c.limiter == nil {
+		return nil
+	}
+	return c.limiter.Wait(ctx)
+}
+
+// idempotent reports whether r may be sent again after an attempt that may have reached the server.
+func idempotent(r *Request) bool {
+	switch strings.ToUpper(r.Method) {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return r.Idempotent || r.Header.Get("Idempotency-Key") != ""
+}
+
+// retryStatus reports whether a response status is retried.
+func (c *Client) retryStatus(code int) bool {
+	if c.RetryStatus != nil {
+		return c.RetryStatus(code)
+	}
+	switch {
+	case code == http.StatusTooManyRequests:
+		return true
+	case code == http.StatusNotImplemented, code == http.StatusHTTPVersionNotSupported:
+		return false
+	}
+	return code >= 500 && code < 600
+}
+
+// classifyError retries network errors, attempts that timed out and retried statuses.
+func classifyError(err error) retry.Class {
+	var timeout *timeoutError
+	if errors.Is(err, ErrRequestFailed) || errors.Is(err, errRetryStatus) || errors.As(err, &timeout) {
+		return retry.Retryable
+	}
+	return retry.Permanent
+}
+
+// timeoutError is the error of an attempt that exceeded Request.Timeout, hiding context.DeadlineExceeded
+// from retry, which never retries context errors.
+type timeoutError struct {
+	err error
+}
+
+func (e *timeoutError) Error() string { return e.err.Error() }
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,615 @@
+// Package httpjs performs HTTP requests with the fetch API of the browser and serves Go handlers to
+// JavaScript fetch events. Outside the browser requests are sent with net/http instead, so code issuing
+// them builds and runs everywhere. Transport plugs fetch into an http.Client.
+package httpjs
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"pkg.gfire.dev/supernet/logging"
+	"pkg.gfire.dev/supernet/web/wasmlib/jserr"
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+	"pkg.gfire.dev/supernet/web/wasmlib/perfjs"
+	"pkg.gfire.dev/supernet/web/wasmlib/streamjs"
+)
+
+var (
+	// ErrRequestFailed is returned when the HTTP fetch operation fails due to network or other issues
+	ErrRequestFailed = jserr.New(jserr.ErrNetwork, "request failed")
+	// ErrAborted is returned when the HTTP request is aborted before completion
+	ErrAborted = jserr.New(jserr.ErrAborted, "request aborted")
+	// ErrOpaqueResponse is returned by Transport for opaque responses, whose status, headers and body are hidden
+	ErrOpaqueResponse = jserr.New(jserr.ErrProtocol, "opaque response")
+	// ErrBodyTooLarge is returned by Response.ReadAllLimit for bodies exceeding its limit
+	ErrBodyTooLarge = jserr.New(jserr.ErrQuota, "response body too large")
+	// ErrCacheUnsupported is returned by OpenResponseCache where the Cache Storage API is unavailable
+	ErrCacheUnsupported = errors.New("cache storage not supported")
+	// ErrUnsupported is returned where fetch is unavailable for requests using options XMLHttpRequest lacks
+	ErrUnsupported = errors.New("request unsupported without fetch")
+)
+
+// tracerName is the instrumentation scope of the spans created for requests
+const tracerName = "pkg.gfire.dev/supernet/web/wasmlib/httpjs"
+
+// logger receives failed requests with the reason given by the browser, e.g. a CORS or network error
+var logger = logging.For("httpjs")
+
+// RedirectMode is the way a request handles redirects, the redirect option of fetch.
+type RedirectMode string
+
+const (
+	// RedirectFollow follows redirects, the default.
+	RedirectFollow RedirectMode = "follow"
+	// RedirectError fails the request with ErrRequestFailed if it is redirected.
+	RedirectError RedirectMode = "error"
+	// RedirectManual returns redirect responses instead of following them, for clients handling redirects
+	// themselves like http.Client.CheckRedirect. Browsers hide redirect responses: they have StatusCode 0
+	// and no headers or body, see Response.Opaque. Outside the browser they are returned as received.
+	RedirectManual RedirectMode = "manual"
+)
+
+// CredentialsMode selects whether a request sends and stores credentials, i.e. cookies and HTTP
+// authentication, the credentials option of fetch.
+type CredentialsMode string
+
+const (
+	// CredentialsOmit never sends or stores credentials.
+	CredentialsOmit CredentialsMode = "omit"
+	// CredentialsSameOrigin only sends and stores credentials for same-origin requests, the default.
+	CredentialsSameOrigin CredentialsMode = "same-origin"
+	// CredentialsInclude also sends and stores credentials for cross-origin requests, e.g. for APIs
+	// authenticated with cookies. The server must allow it with CORS, naming the origin of the page.
+	CredentialsInclude CredentialsMode = "include"
+)
+
+// CacheMode selects how a request uses the HTTP cache of the browser, the cache option of fetch.
+type CacheMode string
+
+const (
+	// CacheDefault uses fresh cached responses and revalidates stale ones, the default.
+	CacheDefault CacheMode = "default"
+	// CacheNoStore bypasses the cache and does not store the response.
+	CacheNoStore CacheMode = "no-store"
+	// CacheReload bypasses the cache and stores the response.
+	CacheReload CacheMode = "reload"
+	// CacheNoCache revalidates cached responses, even fresh ones.
+	CacheNoCache CacheMode = "no-cache"
+	// CacheForceCache uses cached responses, even stale ones, and only sends the request without one.
+	CacheForceCache CacheMode = "force-cache"
+	// CacheOnlyIfCached uses cached responses, even stale ones, and fails with ErrRequestFailed without one.
+	// Browsers only allow it for same-origin requests.
+	CacheOnlyIfCached CacheMode = "only-if-cached"
+)
+
+// RequestMode selects which cross-origin requests are allowed and what their responses expose, the mode
+// option of fetch.
+type RequestMode string
+
+const (
+	// ModeCORS allows cross-origin requests permitted by the server with CORS, the default.
+	ModeCORS RequestMode = "cors"
+	// ModeNoCORS allows cross-origin requests without CORS, limited to the methods GET, HEAD and POST and to
+	// simple headers. Their responses are opaque, see Response.Opaque, so it is meant for requests whose
+	// response is not needed, e.g. beacons.
+	ModeNoCORS RequestMode = "no-cors"
+	// ModeSameOrigin fails cross-origin requests with ErrRequestFailed.
+	ModeSameOrigin RequestMode = "same-origin"
+)
+
+// ReferrerPolicy selects how much of the referrer a request reveals in its Referer header, the
+// referrerPolicy option of fetch.
+type ReferrerPolicy string
+
+const (
+	// ReferrerPolicyNoReferrer never sends the Referer header.
+	ReferrerPolicyNoReferrer ReferrerPolicy = "no-referrer"
+	// ReferrerPolicyNoReferrerWhenDowngrade sends the full referrer, except from HTTPS to HTTP.
+	ReferrerPolicyNoReferrerWhenDowngrade ReferrerPolicy = "no-referrer-when-downgrade"
+	// ReferrerPolicyOrigin only sends the origin of the referrer.
+	ReferrerPolicyOrigin ReferrerPolicy = "origin"
+	// ReferrerPolicyOriginWhenCrossOrigin sends the full referrer to the same origin and its origin otherwise.
+	ReferrerPolicyOriginWhenCrossOrigin ReferrerPolicy = "origin-when-cross-origin"
+	// ReferrerPolicySameOrigin sends the full referrer to the same origin and nothing otherwise.
+	ReferrerPolicySameOrigin ReferrerPolicy = "same-origin"
+	// ReferrerPolicyStrictOrigin sends the origin of the referrer, except from HTTPS to HTTP.
+	ReferrerPolicyStrictOrigin ReferrerPolicy = "strict-origin"
+	// ReferrerPolicyStrictOriginWhenCrossOrigin sends the full referrer to the same origin and its origin
+	// otherwise, except from HTTPS to HTTP. It is the default of browsers.
+	ReferrerPolicyStrictOriginWhenCrossOrigin ReferrerPolicy = "strict-origin-when-cross-origin"
+	// ReferrerPolicyUnsafeURL always sends the full referrer.
+	ReferrerPolicyUnsafeURL ReferrerPolicy = "unsafe-url"
+)
+
+// ResponseType tells what a response exposes, depending on where it comes from, the type of a fetch
+// Response.
+type ResponseType string
+
+const (
+	// ResponseBasic is a same-origin response, exposing all headers except Set-Cookie. Outside the browser
+	// every response is basic.
+	ResponseBasic ResponseType = "basic"
+	// ResponseCORS is a cross-origin response permitted by the server with CORS, exposing the CORS-safelisted
+	// headers and those the server lists in Access-Control-Expose-Headers.
+	ResponseCORS ResponseType = "cors"
+	// ResponseDefault is a response not received by fetch, e.g. one created with NewResponse or returned
+	// by the Cache of a Client.
+	ResponseDefault ResponseType = "default"
+	// ResponseOpaque is the response to a ModeNoCORS request, which hides its status, headers and body.
+	ResponseOpaque ResponseType = "opaque"
+	// ResponseOpaqueRedirect is a redirect response returned by RedirectManual, which hides its status,
+	// headers and body.
+	ResponseOpaqueRedirect ResponseType = "opaqueredirect"
+)
+
+// Request represents an HTTP request that will be executed via the JavaScript fetch API.
+// Supports custom headers and binary, streamed, form, JSON or multipart request bodies. Use SetHeader,
+// SetBody, SetBodyReader, SetFormBody, SetJSONBody and SetMultipartBody to configure.
+//
+// Every request is recorded as an OpenTelemetry client span, see package tracing, and carries the trace
+// context in its headers. Cross-origin servers must allow the traceparent header with CORS.
+type Request struct {
+	Method             string          // HTTP method (GET, POST, PUT, DELETE, etc.)
+	URL                string          // Target URL for the request
+	Header             http.Header     // Custom HTTP headers to include in the request
+	Body               []byte          // Request body as binary data (optional)
+	BodyReader         io.Reader       // Request body streamed instead of Body if set, see SetBodyReader
+	Redirect           RedirectMode    // Handling of redirects; empty follows them
+	Credentials        CredentialsMode // Sending of cookies and HTTP authentication; empty for same-origin only
+	Cache              CacheMode       // Use of the HTTP cache of the browser; empty for CacheDefault
+	Mode               RequestMode     // Cross-origin requests allowed; empty for ModeCORS
+	Referrer           string          // URL sent as the referrer, of the origin of the page; empty for the page
+	ReferrerPolicy     ReferrerPolicy  // Limits the Referer header; empty for the policy of the page
+	Integrity          string          // Subresource integrity metadata the body must match, see WithIntegrity
+	KeepAlive          bool            // Lets the request outlive the page, see WithKeepAlive
+	XHR                bool            // Sends the request with XMLHttpRequest instead of fetch, see WithXHR
+	OnUploadProgress   ProgressFunc    // Receives the progress of sending the body, see WithUploadProgress
+	OnDownloadProgress ProgressFunc    // Receives the progress of reading the response body, see WithDownloadProgress
+	Untraced           bool            // Skips the span and trace context headers, e.g. for requests exporting traces
+	Timeout            time.Duration   // Bounds waiting for the response, not reading its body; zero waits indefinitely
+	Idempotent         bool            // Lets a Client retry a method that is not idempotent, see WithIdempotent
+	Logger             *slog.Logger    // Receives failed requests; defaults to logging.For("httpjs")
+
+	cache *ResponseCache // Stores the response, for requests of a Client with a Cache
+}
+
+// Response represents an HTTP response received from the fetch API.
+// The body is provided as a JavaScript ReadableStream for efficient streaming of large responses, and
+// Response itself is an io.ReadCloser reading it from Go.
+//
+// Browsers hide responses to ModeNoCORS requests and redirects returned by RedirectManual from the page.
+// These opaque responses have Opaque set, StatusCode 0 and no headers or body, so whether the request
+// succeeded is unknown; Type tells them from responses that are empty indeed.
+//
+// Bodies decoded from their ContentEncoding have Uncompressed set, and their Content-Encoding and
+// Content-Length headers are removed like net/http does. Browsers decode every encoding they support and
+// cannot be asked for the encoded bytes; outside the browser only gzip bodies are decoded, unless the
+// request sets Accept-Encoding itself.
+type Response struct {
+	StatusCode      int                      // HTTP status code (200, 404, 500, etc.)
+	Status          string                   // Reason phrase of the status, e.g. "OK"; browsers give none over HTTP/2
+	Header          http.Header              // Response headers; browsers join repeated ones except Set-Cookie with commas
+	Body            *streamjs.ReadableStream // Streaming response body wrapped as a ReadableStream
+	URL             string                   // Final URL of the response, after redirects
+	Redirected      bool                     // Whether redirects were followed to get the response
+	Type            ResponseType             // What the response exposes, e.g. ResponseCORS for cross-origin ones
+	Opaque          bool                     // Status, headers and body hidden by the browser
+	ContentEncoding string                   // Encoding the body was sent with, e.g. "gzip"; empty if none
+	Uncompressed    bool                     // Whether the body was decoded from ContentEncoding
+	Cached          bool                     // Whether the response was stored by the Cache of a Client
+
+	binding
+	bodyReader io.ReadCloser // The underlying reader for bulk reading via ReadAll
+	release    func()        // Releases the context of the request, nil if there is nothing to release
+	timing     timingKey     // Identifies the resource timing entry of the response, zero outside the browser
+}
+
+// timingKey identifies the resource timing entry of a response among those of other requests for the same
+// URL: the entry of the request started between the call of fetch and the arrival of the response.
+type timingKey struct {
+	name     string        // Resolved URL of the request, naming the entry
+	start    time.Duration // perfjs.Now when fetch was called
+	received time.Duration // perfjs.Now when the response arrived
+}
+
+// match reports whether t is the entry of the response.
+func (k timingKey) match(t perfjs.ResourceTiming) bool {
+	return t.Name == k.name && t.StartTime >= k.start && t.StartTime <= k.received
+}
+
+// bodiless reports whether a response with status to a request with method has no body, which fetch gives a
+// null body: responses to HEAD requests and those with a null body status, e.g. 204 No Content.
+func bodiless(method string, status int) bool {
+	switch status {
+	case http.StatusSwitchingProtocols, http.StatusEarlyHints, http.StatusNoContent, http.StatusResetContent,
+		http.StatusNotModified:
+		return true
+	}
+	return strings.EqualFold(method, "HEAD")
+}
+
+// NewResponse returns a response with the given status, headers and body, e.g. for answering requests in
+// tests, see Client.Transport. body may be nil for an empty body, and is closed with the response if it is
+// an io.Closer.
+func NewResponse(statusCode int, header http.Header, body io.Reader) *Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	resp := &Response{
+		StatusCode: statusCode,
+		Status:     http.StatusText(statusCode),
+		Header:     header,
+		Type:       ResponseDefault,
+	}
+	if body != nil {
+		resp.bodyReader = readCloser(body)
+		resp.Body = streamjs.NewReadableStream(resp.bodyReader, streamjs.WithHighWaterMark(0))
+	}
+	return resp
+}
+
+// NewRequest creates a new HTTP request with the specified method and URL, configured by opts.
+// Without options the request has empty headers and body; use SetHeader and SetBody to configure.
+func NewRequest(method, url string, opts ...Option) *Request {
+	r := &Request{
+		Method: method,
+		URL:    url,
+		Header: make(http.Header),
+	}
+	r.apply(opts)
+	return r
+}
+
+// SetHeader sets or overwrites an HTTP request header with the given key and value.
+// Header names are case-insensitive, see http.Header.
+func (r *Request) SetHeader(key, value string) {
+	r.Header.Set(key, value)
+}
+
+// AddHeader adds a value to an HTTP request header, keeping the values it already has.
+func (r *Request) AddHeader(key, value string) {
+	r.Header.Add(key, value)
+}
+
+// SetBasicAuth sets the Authorization header to HTTP Basic authentication with the given username and
+// password, like http.Request.SetBasicAuth. The credentials are only encoded, not encrypted, so they should
+// only be sent over HTTPS.
+func (r *Request) SetBasicAuth(username, password string) {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	r.Header.Set("Authorization", "Basic "+credentials)
+}
+
+// SetBearerToken sets the Authorization header to the bearer token, e.g. an OAuth 2.0 access token. See
+// Client.TokenSource for tokens that expire.
+func (r *Request) SetBearerToken(token string) {
+	r.Header.Set("Authorization", "Bearer "+token)
+}
+
+// SetBody sets the request body from a byte slice.
+// The body will be transmitted as binary data (ArrayBuffer) to the server.
+// For requests without a body (GET, DELETE), this can be left unset.
+func (r *Request) SetBody(body []byte) {
+	r.Body = body
+}
+
+// SetFormBody sets a URL-encoded form body of values, together with its Content-Type header, like
+// http.PostForm.
+func (r *Request) SetFormBody(values url.Values) {
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Body = []byte(values.Encode())
+}
+
+// SetJSONBody sets the JSON encoding of v as the body, together with its Content-Type header. The body is
+// left unchanged if v cannot be encoded.
+func (r *Request) SetJSONBody(v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	r.Body = body
+	return nil
+}
+
+// SetBodyReader sets a request body streamed during the upload instead of being buffered first, for large
+// bodies. It takes precedence over Body and is closed after the request if it is an io.Closer.
+//
+// Fetch sends it as a ReadableStream with chunked encoding, which Chromium only allows over HTTP/2 and
+// later, failing the request with ErrRequestFailed over HTTP/1.1. Browsers unable to stream request bodies
+// get it read into memory before the request is sent.
+func (r *Request) SetBodyReader(body io.Reader) {
+	r.BodyReader = body
+}
+
+// Do executes the HTTP request asynchronously and returns a Response.
+// Blocks until the response is received or an error occurs.
+// The response body is provided as a ReadableStream for memory-efficient handling of large responses.
+func (r *Request) Do() (*Response, error) {
+	return r.do(context.Background())
+}
+
+// DoContext is Do bounded by ctx, which also carries the trace the request's span belongs to. Cancelling
+// ctx aborts the request, also while its body is being read, so it must stay alive until the response was
+// consumed; Timeout only bounds waiting for the response.
+func (r *Request) DoContext(ctx context.Context) (*Response, error) {
+	return r.do(ctx)
+}
+
+// DoAsync is DoContext in a new goroutine, for JavaScript callbacks, which must not block.
+func (r *Request) DoAsync(ctx context.Context) <-chan jsguard.Result[*Response] {
+	return jsguard.Go(func() (*Response, error) {
+		return r.do(ctx)
+	})
+}
+
+// do executes the request within the trace of ctx.
+func (r *Request) do(ctx context.Context) (*Response, error) {
+	jsguard.Check("httpjs.Request.Do")
+	if r.Untraced {
+		return r.fetch(ctx, r.Header)
+	}
+
+	ctx, span, headers := r.startSpan(ctx)
+	defer span.End()
+
+	resp, err := r.fetch(ctx, headers)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	if resp.Opaque {
+		// The status is unknown, which is neither a success nor an error
+		span.SetAttributes(attribute.Bool("http.response.opaque", true))
+		return resp, nil
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// Send starts the request without waiting for its response, which is discarded. It is meant for JavaScript
+// callbacks that must not block and cannot rely on a goroutine to run before they return either, such as
+// pagehide handlers, see package lifecyclejs; together with KeepAlive the request outlives the page. Its
+// span ends once the request was started. Errors of the request itself are only logged.
+func (r *Request) Send() error {
+	if r.Untraced {
+		return r.send(r.Header)
+	}
+
+	_, span, headers := r.startSpan(context.Background())
+	defer span.End()
+
+	if err := r.send(headers); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+// startSpan starts the span of the request within the trace of ctx and returns the request headers with
+// its trace context.
+func (r *Request) startSpan(ctx context.Context) (context.Context, trace.Span, http.Header) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, r.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.full", r.URL),
+		),
+	)
+
+	// Propagate the trace without modifying the caller's headers
+	headers := make(http.Header, len(r.Header)+2)
+	for key, values := range r.Header {
+		headers[key] = values
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(headers))
+	return ctx, span, headers
+}
+
+// ReadAll reads the entire response body into a byte slice.
+// This is a convenience method for small responses; for large bodies, prefer streaming with the Body field.
+// Returns an empty slice if no body was present in the response.
+func (resp *Response) ReadAll() ([]byte, error) {
+	if resp.bodyReader == nil {
+		return []byte{}, nil
+	}
+
+	// The body reader of fetch writes whole chunks to buf (see streamjs.Reader.WriteTo) instead of copying
+	// them through a small buffer
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, resp.bodyReader); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ReadAllLimit is ReadAll for bodies of at most max bytes, protecting memory from servers sending unbounded
+// bodies. A larger body fails with ErrBodyTooLarge and closes the response, cancelling the rest of the body,
+// without being read in full: a Content-Length above max fails before reading anything.
+func (resp *Response) ReadAllLimit(max int64) ([]byte, error) {
+	if resp.bodyReader == nil {
+		return []byte{}, nil
+	}
+	if n, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil && n > max {
+		resp.Close()
+		return nil, ErrBodyTooLarge
+	}
+
+	// Reading one byte more than max tells a body of max bytes from a larger one
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.LimitReader(resp.bodyReader, max+1)); err != nil {
+		return nil, err
+	}
+	if int64(buf.Len()) > max {
+		resp.Close()
+		return nil, ErrBodyTooLarge
+	}
+	return buf.Bytes(), nil
+}
+
+// Timing returns the resource timing the browser recorded for the request, e.g. the time spent resolving
+// the host, connecting and waiting for the first byte, and the bytes transferred. The browser records it once
+// the body has been read in full, so Timing waits for it until ctx is done and should be called after reading
+// the body. Cross-origin responses only expose detailed timings and sizes if the server allows it with the
+// Timing-Allow-Origin header. Outside the browser it returns perfjs.ErrUnsupported.
+func (resp *Response) Timing(ctx context.Context) (perfjs.ResourceTiming, error) {
+	if resp.timing.name == "" {
+		return perfjs.ResourceTiming{}, perfjs.ErrUnsupported
+	}
+	for _, t := range perfjs.EntriesByName(resp.timing.name) {
+		if resp.timing.match(t) {
+			return t, nil
+		}
+	}
+
+	// No JavaScript runs between looking up the entries and observing new ones, so none is missed
+	observer, err := perfjs.Observe(false)
+	if err != nil {
+		return perfjs.ResourceTiming{}, err
+	}
+	defer observer.Close()
+	for {
+		select {
+		case t := <-observer.Entries():
+			if resp.timing.match(t) {
+				return t, nil
+			}
+		case <-ctx.Done():
+			return perfjs.ResourceTiming{}, ctx.Err()
+		}
+	}
+}
+
+// Read reads the response body, making Response an io.ReadCloser that can be passed to io.Copy or
+// json.NewDecoder. It shares the body with Body and ReadAll, so only one of them should be used. Returns
+// io.EOF if no body was present in the response.
+func (resp *Response) Read(p []byte) (int, error) {
+	if resp.bodyReader == nil {
+		return 0, io.EOF
+	}
+	return resp.bodyReader.Read(p)
+}
+
+// WriteTo implements io.WriterTo, keeping the whole chunk copies of the body reader of fetch for io.Copy.
+func (resp *Response) WriteTo(w io.Writer) (int64, error) {
+	if resp.bodyReader == nil {
+		return 0, nil
+	}
+	return io.Copy(w, resp.bodyReader)
+}
+
+// DecodeJSON decodes the JSON body into v while it is read, without buffering it like ReadAll. A missing or
+// empty body returns io.EOF. The rest of the body is left unread, and the response must still be closed.
+func (resp *Response) DecodeJSON(v any) error {
+	return json.NewDecoder(resp).Decode(v)
+}
+
+// Close closes the response body stream and releases associated resources.
+// Should be called when finished consuming the response to free up resources.
+// Safe to call multiple times.
+func (resp *Response) Close() error {
+	if resp.Body != nil {
+		resp.Body.Close()
+	}
+	if resp.release != nil {
+		resp.release()
+	}
+	return nil
+}
+
+// Get performs a GET request to the specified URL and returns the response.
+// This is a convenience function for simple GET requests; opts configure the request.
+func Get(url string, opts ...Option) (*Response, error) {
+	req := NewRequest("GET", url, opts...)
+	return req.Do()
+}
+
+// Post performs a POST request to the specified URL with the given body.
+// The contentType parameter specifies the Content-Type header; if empty, no Content-Type header is sent.
+func Post(url string, contentType string, body []byte, opts ...Option) (*Response, error) {
+	req := NewRequest("POST", url)
+	if contentType != "" {
+		req.SetHeader("Content-Type", contentType)
+	}
+	req.SetBody(body)
+	req.apply(opts)
+	return req.Do()
+}
+
+// PostForm performs a POST request to the specified URL with values URL-encoded as the body, like
+// http.PostForm. opts configure the request.
+func PostForm(url string, values url.Values, opts ...Option) (*Response, error) {
+	req := NewRequest("POST", url)
+	req.SetFormBody(values)
+	req.apply(opts)
+	return req.Do()
+}
+
+// Put performs a PUT request to the specified URL with the given body.
+// The contentType parameter specifies the Content-Type header; if empty, no Content-Type header is sent.
+func Put(url string, contentType string, body []byte, opts ...Option) (*Response, error) {
+	req := NewRequest("PUT", url)
+	if contentType != "" {
+		req.SetHeader("Content-Type", contentType)
+	}
+	req.SetBody(body)
+	req.apply(opts)
+	return req.Do()
+}
+
+// Patch performs a PATCH request to the specified URL with the given body, a partial update of the resource.
+// The contentType parameter specifies the Content-Type header; if empty, no Content-Type header is sent.
+func Patch(url string, contentType string, body []byte, opts ...Option) (*Response, error) {
+	req := NewRequest("PATCH", url)
+	if contentType != "" {
+		req.SetHeader("Content-Type", contentType)
+	}
+	req.SetBody(body)
+	req.apply(opts)
+	return req.Do()
+}
+
+// Head performs a HEAD request to the specified URL, e.g. to check that a resource exists or to learn its
+// size and type before downloading it. The response has no body, while its headers such as Content-Length
+// describe the body a GET request would return; opts configure the request.
+func Head(url string, opts ...Option) (*Response, error) {
+	req := NewRequest("HEAD", url, opts...)
+	return req.Do()
+}
+
+// Options performs an OPTIONS request to the specified URL, asking which methods it allows in the Allow
+// header of the response. Cross-origin, the browser sends a CORS preflight OPTIONS request of its own first
+// and only exposes the Allow and Access-Control-Allow-* headers of the response if the server lists them in
+// Access-Control-Expose-Headers. opts configure the request.
+func Options(url string, opts ...Option) (*Response, error) {
+	req := NewRequest("OPTIONS", url, opts...)
+	return req.Do()
+}
+
+// Delete performs a DELETE request to the specified URL.
+// This is a convenience function for simple DELETE requests; opts configure the request.
+func Delete(url string, opts ...Option) (*Response, error) {
+	req := NewRequest("DELETE", url, opts...)
+	return req.Do()
+}
@@ -3,357 +3,374 @@ package httpjs
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/textproto"
 	"strings"
+	"sync"
 	"syscall/js"
 
+	"pkg.gfire.dev/supernet/web/wasmlib/compressjs"
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+	"pkg.gfire.dev/supernet/web/wasmlib/perfjs"
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
 	"pkg.gfire.dev/supernet/web/wasmlib/streamjs"
 )
 
-var (
-	// ErrRequestFailed is returned when the HTTP fetch operation fails due to network or other issues
-	ErrRequestFailed = errors.New("request failed")
-	// ErrAborted is returned when the HTTP request is aborted before completion
-	ErrAborted = errors.New("request aborted")
-)
-
 var (
 	// _fetch is a cached reference to the JavaScript fetch function for HTTP requests
 	_fetch = js.Global().Get("fetch")
 	// _Headers is a cached reference to the JavaScript Headers constructor for managing HTTP headers
 	_Headers = js.Global().Get("Headers")
+	// _Request is a cached reference to the JavaScript Request constructor for detecting fetch features
+	_Request = js.Global().Get("Request")
+	// _ReadableStream is a cached reference to the JavaScript ReadableStream constructor for detecting fetch features
+	_ReadableStream = js.Global().Get("ReadableStream")
 	// _Response is a cached reference to the JavaScript Response constructor for creating response objects
 	_Response = js.Global().Get("Response")
 	// _ArrayBuffer is a cached reference to the JavaScript ArrayBuffer constructor for binary data handling
 	_ArrayBuffer = js.Global().Get("ArrayBuffer")
 	// _Uint8Array is a cached reference to the JavaScript Uint8Array constructor for typed array operations
 	_Uint8Array = js.Global().Get("Uint8Array")
-	// _Promise is a cached reference to the JavaScript Promise constructor for async operations
-	_Promise = js.Global().Get("Promise")
 	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
 	_Object = js.Global().Get("Object")
 	// _Array is a cached reference to the JavaScript Array constructor for array operations
 	_Array = js.Global().Get("Array")
-	// _Error is a cached reference to the JavaScript Error constructor for creating error objects
-	_Error = js.Global().Get("Error")
+	// _AbortController is a cached reference to the JavaScript AbortController constructor for cancelling requests
+	_AbortController = js.Global().Get("AbortController")
 )
 
-// Request represents an HTTP request that will be executed via the JavaScript fetch API.
-// Supports custom headers and binary request bodies. Use SetHeader and SetBody to configure.
-type Request struct {
-	Method  string            // HTTP method (GET, POST, PUT, DELETE, etc.)
-	URL     string            // Target URL for the request
-	Headers map[string]string // Custom HTTP headers to include in the request
-	Body    []byte            // Request body as binary data (optional)
-}
-
-// Response represents an HTTP response received from the fetch API.
-// The body is provided as a JavaScript ReadableStream for efficient streaming of large responses.
-type Response struct {
-	StatusCode int                        // HTTP status code (200, 404, 500, etc.)
-	Headers    map[string]string          // Response headers as key-value pairs
-	Body       *streamjs.ReadableStream   // Streaming response body wrapped as a ReadableStream
-
-	jsResponse js.Value       // The underlying JavaScript Response object
-	bodyReader io.ReadCloser  // The underlying reader for bulk reading via ReadAll
-}
-
-// NewRequest creates a new HTTP request with the specified method and URL.
-// The request is initialized with empty headers and body; use SetHeader and SetBody to configure.
-func NewRequest(method, url string) *Request {
-	return &Request{
-		Method:  method,
-		URL:     url,
-		Headers: make(map[string]string),
+// uploadChunkSize is the size of the chunks streamed request bodies are read in, larger than the default of
+// streamjs to cross between Go and JavaScript less often on large uploads
+const uploadChunkSize = 64 << 10
+
+// supportsUploadStreams reports whether fetch can stream a ReadableStream request body. Browsers lacking it
+// ignore the duplex option and send the stream converted to a string, which gives it a Content-Type.
+var supportsUploadStreams = sync.OnceValue(func() bool {
+	duplexRead := false
+	getter := jsguard.FuncOf(func(this js.Value, args []js.Value) any {
+		duplexRead = true
+		return "half"
+	})
+	defer jsguard.Release(getter)
+
+	init := _Object.New()
+	init.Set("method", "POST")
+	init.Set("body", _ReadableStream.New())
+	descriptor := _Object.New()
+	descriptor.Set("get", getter)
+	_Object.Call("defineProperty", init, "duplex", descriptor)
+	hasContentType := _Request.New("data:,", init).Get("headers").Call("has", "Content-Type").Bool()
+	return duplexRead && !hasContentType
+})
+
+// fetch invokes the fetch API with the given headers and wraps its response.
+func (r *Request) fetch(ctx context.Context, headers http.Header) (*Response, error) {
+	if r.useXHR() {
+		return r.xhr(ctx, headers)
+	}
+	opts, upload, err := r.init(headers)
+	if err != nil {
+		return nil, err
 	}
-}
-
-// SetHeader sets or overwrites an HTTP request header with the given key and value.
-// Header names are case-sensitive and should follow HTTP header conventions.
-func (r *Request) SetHeader(key, value string) {
-	r.Headers[key] = value
-}
-
-// SetBody sets the request body from a byte slice.
-// The body will be transmitted as binary data (ArrayBuffer) to the server.
-// For requests without a body (GET, DELETE), this can be left unset.
-func (r *Request) SetBody(body []byte) {
-	r.Body = body
-}
-
-// Do executes the HTTP request asynchronously and returns a Response.
-// Blocks until the response is received or an error occurs.
-// The response body is provided as a ReadableStream for memory-efficient handling of large responses.
-func (r *Request) Do() (*Response, error) {
-	// Create fetch options object to pass to the JavaScript fetch API
-	opts := _Object.New()
-	opts.Set("method", r.Method)
 
-	// Configure request headers if any were specified
-	if len(r.Headers) > 0 {
-		jsHeaders := _Headers.New()
-		for key, value := range r.Headers {
-			jsHeaders.Call("append", key, value)
+	// Cancelling ctx aborts the request, including reading the response body
+	controller := _AbortController.New()
+	opts.Set("signal", controller.Get("signal"))
+	abort := func(reason error) {
+		controller.Call("abort", promisejs.ErrorValue(reason))
+	}
+	stopAbort := context.AfterFunc(ctx, func() {
+		abort(ctx.Err())
+	})
+	// The upload is over once the response has been closed or the request failed
+	stop := func() {
+		stopAbort()
+		if upload != nil {
+			upload.Close()
 		}
-		opts.Set("headers", jsHeaders)
 	}
 
-	// Convert request body to JavaScript ArrayBuffer if present
-	if len(r.Body) > 0 {
-		buffer := _ArrayBuffer.New(len(r.Body))
-		array := _Uint8Array.New(buffer)
-		js.CopyBytesToJS(array, r.Body)
-		opts.Set("body", buffer)
+	// Invoke the JavaScript fetch API with configured options and wait for the response
+	waitCtx := ctx
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
 	}
-
-	// Create channels to synchronously wait for the asynchronous fetch result
-	resultCh := make(chan *Response, 1)
-	errCh := make(chan error, 1)
-
-	// Invoke the JavaScript fetch API with configured options
-	fetchPromise := _fetch.Invoke(r.URL, opts)
-
-	// Define promise handlers for success and failure cases
-	var thenFunc, catchFunc js.Func
-
-	thenFunc = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		defer thenFunc.Release()
-
-		jsResp := args[0]
-
-		// Parse the JavaScript Response object into a Go Response struct
-		resp := &Response{
-			StatusCode: jsResp.Get("status").Int(),
-			Headers:    make(map[string]string),
-			jsResponse: jsResp,
+	start := perfjs.Now()
+	jsResp, err := promisejs.Await(waitCtx, _fetch.Invoke(r.URL, opts))
+	if err != nil {
+		if waitCtx.Err() != nil {
+			abort(waitCtx.Err())
 		}
-
-		// Extract all response headers from the JavaScript Headers object
-		jsHeaders := jsResp.Get("headers")
-		entriesIter := jsHeaders.Call("entries")
-
-		for {
-			next := entriesIter.Call("next")
-			if next.Get("done").Bool() {
-				break
-			}
-			entry := next.Get("value")
-			key := entry.Index(0).String()
-			value := entry.Index(1).String()
-			resp.Headers[key] = value
+		stop()
+		r.log().Debug("httpjs request failed", "method", r.Method, "url", r.URL, "err", err)
+		if waitCtx.Err() != nil {
+			return nil, waitCtx.Err()
 		}
+		return nil, fetchError(err)
+	}
 
-		// Wrap the JavaScript ReadableStream body for Go consumption
-		jsBody := jsResp.Get("body")
-		if !jsBody.IsNull() && !jsBody.IsUndefined() {
-			// Create a Go reader adapter that wraps the JavaScript ReadableStream
-			reader := &jsStreamReader{
-				jsReader: jsBody.Call("getReader"),
-			}
-			resp.bodyReader = reader
-			resp.Body = streamjs.NewReadableStream(reader)
+	// A body sent from memory is only known to be uploaded once the response has arrived
+	if r.OnUploadProgress != nil && upload == nil {
+		if body := opts.Get("body"); !body.IsUndefined() {
+			n := int64(body.Get("byteLength").Int())
+			r.OnUploadProgress(n, n)
 		}
+	}
 
-		resultCh <- resp
-		return nil
-	})
+	received := perfjs.Now()
+	if r.cache != nil {
+		// The copy for the cache must be taken before the body is read
+		r.cache.store(r, jsResp)
+	}
+	resp := r.newResponse(ctx, jsResp)
+	resp.release = stop
+	resp.timing = timingKey{name: resolveURL(r.URL), start: start, received: received}
+	return resp, nil
+}
 
-	catchFunc = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		defer catchFunc.Release()
+// newResponse wraps a JavaScript Response object, whose body is read within ctx.
+func (r *Request) newResponse(ctx context.Context, jsResp js.Value) *Response {
+	// Parse the JavaScript Response object into a Go Response struct
+	typ := ResponseType(jsResp.Get("type").String())
+	resp := &Response{
+		StatusCode: jsResp.Get("status").Int(),
+		Status:     jsResp.Get("statusText").String(),
+		Header:     make(http.Header),
+		URL:        jsResp.Get("url").String(),
+		Redirected: jsResp.Get("redirected").Bool(),
+		Type:       typ,
+		Opaque:     typ == ResponseOpaque || typ == ResponseOpaqueRedirect,
+		binding:    binding{jsResponse: jsResp},
+	}
 
-		// Extract error message from the JavaScript error if available
-		if len(args) > 0 {
-			errMsg := args[0].Get("message").String()
-			errCh <- errors.New(errMsg)
-		} else {
-			errCh <- ErrRequestFailed
+	// Extract all response headers from the JavaScript Headers object, which joins repeated headers with
+	// commas but returns every Set-Cookie header on its own
+	jsHeaders := jsResp.Get("headers")
+	entriesIter := jsHeaders.Call("entries")
+
+	for {
+		next := entriesIter.Call("next")
+		if next.Get("done").Bool() {
+			break
 		}
-		return nil
-	})
+		entry := next.Get("value")
+		key := entry.Index(0).String()
+		value := entry.Index(1).String()
+		resp.Header.Add(key, value)
+	}
 
-	// Attach promise handlers to the fetch promise
-	fetchPromise.Call("then", thenFunc).Call("catch", catchFunc)
+	resp.decoded()
 
-	// Block until response is received or error occurs
-	select {
-	case resp := <-resultCh:
-		return resp, nil
-	case err := <-errCh:
-		return nil, err
+	// Wrap the JavaScript ReadableStream body for Go consumption
+	jsBody := jsResp.Get("body")
+	if !jsBody.IsNull() && !jsBody.IsUndefined() {
+		// Create a Go reader adapter that wraps the JavaScript ReadableStream, reading the byte stream into a
+		// reused buffer
+		reader := r.download(bodyReader{Reader: streamjs.NewBYOBReader(jsBody), ctx: ctx}, resp.Header)
+		resp.bodyReader = reader
+		// Body shares the reader with ReadAll and Transport, so it must not read ahead of its consumer
+		resp.Body = streamjs.NewReadableStream(reader, streamjs.WithHighWaterMark(0))
 	}
+	return resp
 }
 
-// jsStreamReader implements io.ReadCloser by reading from a JavaScript ReadableStream.
-// It adapts JavaScript's push-based stream model to Go's pull-based io.Reader model.
-type jsStreamReader struct {
-	// jsReader holds the JavaScript ReadableStreamDefaultReader object obtained from getReader()
-	jsReader js.Value
-	// closed tracks whether the reader has been closed to prevent further reads
-	closed bool
+// resolveURL returns url resolved against the location of the page like XMLHttpRequest does, or url itself
+// if it cannot be resolved.
+func resolveURL(url string) (resolved string) {
+	defer func() {
+		if recover() != nil {
+			resolved = url
+		}
+	}()
+	base := js.Undefined()
+	if location := js.Global().Get("location"); location.Truthy() {
+		base = location.Get("href")
+	}
+	return js.Global().Get("URL").New(url, base).Call("toString").String()
 }
 
-// Read reads data from the JavaScript ReadableStream into the provided buffer.
-// Blocks until data is available or the stream ends. Returns io.EOF when the stream is fully consumed.
-func (r *jsStreamReader) Read(p []byte) (n int, err error) {
-	if r.closed {
-		return 0, io.EOF
+// decoded records the encoding of the body, which the browser has decoded while the header still claims it
+// to be encoded.
+func (resp *Response) decoded() {
+	resp.ContentEncoding = resp.Header.Get("Content-Encoding")
+	if resp.ContentEncoding != "" && !strings.EqualFold(resp.ContentEncoding, "identity") {
+		resp.Uncompressed = true
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
 	}
+}
 
-	// Create channel to receive the async read result from the promise handler
-	resultCh := make(chan readResult, 1)
-
-	// Invoke read() on the JavaScript ReadableStreamDefaultReader
-	readPromise := r.jsReader.Call("read")
-
-	var thenFunc js.Func
-	thenFunc = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		defer thenFunc.Release()
-
-		result := args[0]
-		done := result.Get("done").Bool()
-
-		// Stream is exhausted when done flag is true
-		if done {
-			resultCh <- readResult{n: 0, err: io.EOF}
-			return nil
-		}
-
-		// Extract the chunk (Uint8Array) from the read result
-		chunk := result.Get("value")
-		if chunk.IsNull() || chunk.IsUndefined() {
-			resultCh <- readResult{n: 0, err: nil}
-			return nil
+// send invokes the fetch API with the given headers and discards its response.
+func (r *Request) send(headers http.Header) error {
+	if r.useXHR() {
+		if r.needsFetch() {
+			return ErrUnsupported
 		}
-
-		// Get the number of bytes available in the chunk
-		length := chunk.Get("byteLength").Int()
-		if length == 0 {
-			resultCh <- readResult{n: 0, err: nil}
-			return nil
+		req := *r
+		go func() {
+			resp, err := req.xhr(context.Background(), headers)
+			if err == nil {
+				// Closing the response before its body has arrived would abort the request
+				io.Copy(io.Discard, resp.bodyReader)
+				resp.Close()
+			}
+		}()
+		return nil
+	}
+	opts, upload, err := r.init(headers)
+	if err != nil {
+		return err
+	}
+	// The request may change once Send has returned
+	log, method, url := r.log(), r.Method, r.URL
+	promisejs.Then(_fetch.Invoke(url, opts), func(jsResp js.Value, err error) {
+		if upload != nil {
+			upload.Close()
 		}
-
-		// Determine how many bytes we can actually copy (min of chunk size and buffer size)
-		copyLen := length
-		if copyLen > len(p) {
-			copyLen = len(p)
+		if err != nil {
+			log.Debug("httpjs request failed", "method", method, "url", url, "err", err)
+			return
 		}
-
-		// Create a temporary Uint8Array view if we need to copy only partial data
-		// This avoids copying more data than requested
-		if copyLen < length {
-			chunk = _Uint8Array.New(chunk.Get("buffer"), chunk.Get("byteOffset"), copyLen)
+		// Cancelling the unread body frees the connection
+		if jsBody := jsResp.Get("body"); !jsBody.IsNull() && !jsBody.IsUndefined() {
+			promisejs.Then(jsBody.Call("cancel"), func(js.Value, error) {})
 		}
-
-		// Copy bytes from JavaScript Uint8Array to Go buffer
-		js.CopyBytesToGo(p[:copyLen], chunk)
-		resultCh <- readResult{n: copyLen, err: nil}
-		return nil
 	})
-
-	// Attach the then handler to the promise returned by read()
-	readPromise.Call("then", thenFunc)
-
-	// Wait for the promise to resolve and return the result
-	res := <-resultCh
-	return res.n, res.err
+	return nil
 }
 
-// Close closes the JavaScript reader and cancels further reads from the stream.
-// Safe to call multiple times. Subsequent Read calls will return io.EOF.
-func (r *jsStreamReader) Close() error {
-	if r.closed {
-		return nil
+// init returns the options of fetch for the request with the given headers, and the stream uploading the
+// body reader if any, which must be closed once the request is over.
+func (r *Request) init(headers http.Header) (js.Value, *streamjs.ReadableStream, error) {
+	// Create fetch options object to pass to the JavaScript fetch API
+	opts := _Object.New()
+	opts.Set("method", r.Method)
+	if r.Redirect != "" {
+		opts.Set("redirect", string(r.Redirect))
 	}
-	r.closed = true
-
-	// Call cancel() on the JavaScript ReadableStreamDefaultReader to release the lock
-	if !r.jsReader.IsNull() && !r.jsReader.IsUndefined() {
-		r.jsReader.Call("cancel")
+	if r.Credentials != "" {
+		opts.Set("credentials", string(r.Credentials))
 	}
-	return nil
-}
-
-// readResult is a helper struct to pass both the read count and error through a channel
-type readResult struct {
-	n   int   // Number of bytes successfully read
-	err error // Error that occurred, or nil on success
-}
-
-// ReadAll reads the entire response body into a byte slice.
-// This is a convenience method for small responses; for large bodies, prefer streaming with the Body field.
-// Returns an empty slice if no body was present in the response.
-func (resp *Response) ReadAll() ([]byte, error) {
-	if resp.bodyReader == nil {
-		return []byte{}, nil
+	if r.Cache != "" {
+		opts.Set("cache", string(r.Cache))
+	}
+	if r.Mode != "" {
+		opts.Set("mode", string(r.Mode))
+	}
+	if r.Referrer != "" {
+		opts.Set("referrer", r.Referrer)
+	}
+	if r.ReferrerPolicy != "" {
+		opts.Set("referrerPolicy", string(r.ReferrerPolicy))
+	}
+	if r.Integrity != "" {
+		opts.Set("integrity", r.Integrity)
+	}
+	if r.KeepAlive {
+		opts.Set("keepalive", true)
 	}
 
-	var buf bytes.Buffer
-	buffer := make([]byte, 4096)
-
-	// Read from the underlying reader in 4KB chunks until EOF
-	for {
-		n, err := resp.bodyReader.Read(buffer)
-		if n > 0 {
-			buf.Write(buffer[:n])
-		}
-		if err == io.EOF {
-			break
+	// Configure request headers if any were specified
+	if len(headers) > 0 {
+		jsHeaders := _Headers.New()
+		for key, values := range headers {
+			for _, value := range values {
+				jsHeaders.Call("append", key, value)
+			}
 		}
-		if err != nil {
-			return nil, err
+		opts.Set("headers", jsHeaders)
+	}
+
+	// Stream the body reader, or convert the request body to a JavaScript ArrayBuffer if present
+	body := r.Body
+	var upload *streamjs.ReadableStream
+	if r.BodyReader != nil {
+		// Requests outliving the page cannot stream their body
+		if supportsUploadStreams() && !r.KeepAlive {
+			reader := newProgressReader(r.BodyReader, readerLen(r.BodyReader), r.OnUploadProgress)
+			upload = streamjs.NewReadableStream(reader, streamjs.WithChunkSize(uploadChunkSize))
+			opts.Set("body", upload.Value)
+			opts.Set("duplex", "half")
+		} else {
+			var err error
+			if body, err = r.readBody(); err != nil {
+				return js.Value{}, nil, err
+			}
 		}
 	}
+	if upload == nil && len(body) > 0 {
+		buffer := _ArrayBuffer.New(len(body))
+		array := _Uint8Array.New(buffer)
+		js.CopyBytesToJS(array, body)
+		opts.Set("body", buffer)
+	}
+	return opts, upload, nil
+}
 
-	return buf.Bytes(), nil
+// readBody returns the body of the request, reading the body reader into memory and closing it if there is
+// one.
+func (r *Request) readBody() ([]byte, error) {
+	if r.BodyReader == nil {
+		return r.Body, nil
+	}
+	body, err := io.ReadAll(r.BodyReader)
+	if c, ok := r.BodyReader.(io.Closer); ok {
+		c.Close()
+	}
+	return body, err
 }
 
-// Close closes the response body stream and releases associated resources.
-// Should be called when finished consuming the response to free up resources.
-// Safe to call multiple times.
-func (resp *Response) Close() error {
-	if resp.Body != nil {
-		resp.Body.Close()
+// fetchError converts a rejection of fetch into an error of the package, keeping the JavaScript error as its
+// cause. Browsers reject with an AbortError for aborted requests and a TypeError for any network failure.
+func fetchError(err error) error {
+	var jsErr *promisejs.Error
+	switch {
+	case !errors.As(err, &jsErr):
+		return err
+	case errors.Is(err, promisejs.ErrRejected):
+		return ErrRequestFailed
+	case jsErr.Name == "AbortError":
+		return fmt.Errorf("%w: %w", ErrAborted, err)
 	}
-	return nil
+	return fmt.Errorf("%w: %w", ErrRequestFailed, err)
 }
 
-// Get performs a GET request to the specified URL and returns the response.
-// This is a convenience function for simple GET requests without custom headers.
-func Get(url string) (*Response, error) {
-	req := NewRequest("GET", url)
-	return req.Do()
+// bodyReader reads a response body, reporting reads failed by the abort of the request as the error of
+// its context like net/http.
+type bodyReader struct {
+	*streamjs.Reader
+	ctx context.Context
 }
 
-// Post performs a POST request to the specified URL with the given body.
-// The contentType parameter specifies the Content-Type header; if empty, no Content-Type header is sent.
-func Post(url string, contentType string, body []byte) (*Response, error) {
-	req := NewRequest("POST", url)
-	if contentType != "" {
-		req.SetHeader("Content-Type", contentType)
-	}
-	req.SetBody(body)
-	return req.Do()
+// Read implements io.Reader.
+func (b bodyReader) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	return n, b.err(err)
 }
 
-// Put performs a PUT request to the specified URL with the given body.
-// The contentType parameter specifies the Content-Type header; if empty, no Content-Type header is sent.
-func Put(url string, contentType string, body []byte) (*Response, error) {
-	req := NewRequest("PUT", url)
-	if contentType != "" {
-		req.SetHeader("Content-Type", contentType)
+// WriteTo implements io.WriterTo, keeping the whole chunk copies of streamjs.Reader.
+func (b bodyReader) WriteTo(w io.Writer) (int64, error) {
+	n, err := b.Reader.WriteTo(w)
+	return n, b.err(err)
+}
+
+// err replaces a read error by the error of the context once it is done.
+func (b bodyReader) err(err error) error {
+	if err != nil && err != io.EOF && b.ctx.Err() != nil {
+		return b.ctx.Err()
 	}
-	req.SetBody(body)
-	return req.Do()
+	return err
 }
 
-// Delete performs a DELETE request to the specified URL.
-// This is a convenience function for simple DELETE requests without custom headers or body.
-func Delete(url string) (*Response, error) {
-	req := NewRequest("DELETE", url)
-	return req.Do()
+// binding holds the JavaScript side of a Response.
+type binding struct {
+	jsResponse js.Value // The underlying JavaScript Response object
 }
 
 // JSRequestToHTTPRequest converts a JavaScript Request object into a Go net/http.Request.
@@ -370,42 +387,16 @@ func JSRequestToHTTPRequest(jsReq js.Value) (*http.Request, error) {
 
 	if !jsBody.IsNull() && !jsBody.IsUndefined() {
 		// Call arrayBuffer() to get the request body as a Promise<ArrayBuffer>
-		bodyPromise := jsReq.Call("arrayBuffer")
-
-		bodyChan := make(chan []byte, 1)
-		errChan := make(chan error, 1)
-
-		var successFunc, failFunc js.Func
-		successFunc = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-			defer successFunc.Release()
-
-			// Convert the ArrayBuffer to a Go byte slice
-			jsBodyArray := _Uint8Array.New(args[0])
-			bodyBuffer := make([]byte, jsBodyArray.Get("byteLength").Int())
-			js.CopyBytesToGo(bodyBuffer, jsBodyArray)
-			bodyChan <- bodyBuffer
-			return nil
-		})
-
-		failFunc = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-			defer failFunc.Release()
-
-			if len(args) > 0 {
-				errChan <- errors.New(args[0].String())
-			} else {
-				errChan <- errors.New("failed to read request body")
-			}
-			return nil
-		})
-
-		bodyPromise.Call("then", successFunc).Call("catch", failFunc)
-
-		select {
-		case body := <-bodyChan:
-			bodyReader = bytes.NewReader(body)
-		case err := <-errChan:
-			return nil, err
+		jsBuffer, err := promisejs.Await(context.Background(), jsReq.Call("arrayBuffer"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
 		}
+
+		// Convert the ArrayBuffer to a Go byte slice
+		jsBodyArray := _Uint8Array.New(jsBuffer)
+		bodyBuffer := make([]byte, jsBodyArray.Get("byteLength").Int())
+		js.CopyBytesToGo(bodyBuffer, jsBodyArray)
+		bodyReader = bytes.NewReader(bodyBuffer)
 	} else {
 		// No body present - use empty reader
 		bodyReader = bytes.NewReader([]byte{})
@@ -454,11 +445,11 @@ func JSRequestToHTTPRequest(jsReq js.Value) (*http.Request, error) {
 // The response body is wrapped in a ReadableStream for efficient streaming to JavaScript consumers.
 // Returns a JavaScript Response that can be returned from a WebWorker or server handler.
 func HTTPResponseToJSResponse(httpResp *http.Response) js.Value {
-	// Create a JavaScript headers object from the Go http.Header
-	jsHeaders := _Object.New()
+	// Create a JavaScript Headers object from the Go http.Header, keeping repeated headers
+	jsHeaders := _Headers.New()
 	for key, values := range httpResp.Header {
-		if len(values) > 0 {
-			jsHeaders.Set(key, values[0])
+		for _, value := range values {
+			jsHeaders.Call("append", key, value)
 		}
 	}
 
@@ -482,72 +473,181 @@ func HTTPResponseToJSResponse(httpResp *http.Response) js.Value {
 	return jsResp
 }
 
+// ServeOption configures ServeHTTPAsyncWithStreaming.
+type ServeOption func(*serveOptions)
+
+// serveOptions holds the configuration of ServeHTTPAsyncWithStreaming.
+type serveOptions struct {
+	preserveEncoding bool
+}
+
+// PreserveEncoding passes response bodies encoded by the handler, e.g. with gzip, to JavaScript as they are,
+// with their Content-Encoding header, for callers decoding or forwarding them themselves.
+func PreserveEncoding() ServeOption {
+	return func(o *serveOptions) {
+		o.preserveEncoding = true
+	}
+}
+
 // ServeHTTPAsyncWithStreaming handles an HTTP request asynchronously using the provided handler
 // and returns a Promise that resolves to a JavaScript Response with streaming body support.
 // This function safely executes the handler in a goroutine and streams the response back to JavaScript
 // without blocking the JS thread. Panics in the handler are caught and converted to error responses.
-func ServeHTTPAsyncWithStreaming(handler http.Handler, jsReq js.Value) js.Value {
-	return _Promise.New(js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		resolve := args[0]
-		reject := args[1]
+//
+// Browsers never decode the body of a Response created by JavaScript, so bodies the handler encoded with
+// gzip, deflate or deflate-raw, e.g. precompressed files or upstream bytes, are decoded, and their
+// Content-Encoding and Content-Length headers removed, unless PreserveEncoding is given. Other encodings
+// are passed as they are.
+//
+// The context of the http.Request is cancelled with the cause ErrAborted once the signal of jsReq aborts,
+// e.g. when the page aborts a fetch served by a service worker, so long-running handlers can stop. Writes of
+// the handler fail from then on, and the Promise is rejected with ErrAborted unless it has resolved before.
+func ServeHTTPAsyncWithStreaming(handler http.Handler, jsReq js.Value, opts ...ServeOption) js.Value {
+	var o serveOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
 
-		go func() {
-			// Convert the JavaScript Request to a Go net/http.Request
-			httpReq, err := JSRequestToHTTPRequest(jsReq)
-			if err != nil {
-				reject.Invoke(_Error.New(err.Error()))
-				return
-			}
+	return promisejs.New(func() (js.Value, error) {
+		// Convert the JavaScript Request to a Go net/http.Request
+		httpReq, err := JSRequestToHTTPRequest(jsReq)
+		if err != nil {
+			return js.Undefined(), err
+		}
 
-			// Create an io.Pipe to stream the response body from the handler to JavaScript
-			pr, pw := io.Pipe()
+		ctx, stopSignal := signalContext(jsReq.Get("signal"))
+		httpReq = httpReq.WithContext(ctx)
 
-			// Create custom ResponseWriter that captures headers and pipes the body
-			respWriter := &streamingResponseWriter{
-				pipeWriter:      pw,
-				header:          make(http.Header),
-				statusCode:      200,
-				wroteHeaderChan: make(chan struct{}, 1),
-			}
+		// Create an io.Pipe to stream the response body from the handler to JavaScript
+		pr, pw := io.Pipe()
+		// Aborting also fails the writes of handlers not watching their context
+		stopAbort := context.AfterFunc(ctx, func() {
+			pr.CloseWithError(ErrAborted)
+		})
 
-			// Execute the handler in a separate goroutine to avoid blocking
-			go func() {
-				defer pw.Close()
-				defer func() {
-					if r := recover(); r != nil {
-						// Recover from panic in handler and return an error response
-						respWriter.statusCode = http.StatusInternalServerError
-						pw.CloseWithError(errors.New("internal server error"))
-					}
-				}()
-
-				handler.ServeHTTP(respWriter, httpReq)
-
-				// Ensure headers were written (required for valid HTTP response)
-				if !respWriter.wroteHeader {
-					respWriter.WriteHeader(http.StatusBadGateway)
-					http.Error(respWriter, "Bad Gateway\n\nUpstream server error", http.StatusBadGateway)
+		// Create custom ResponseWriter that captures headers and pipes the body
+		respWriter := &streamingResponseWriter{
+			pipeWriter:      pw,
+			header:          make(http.Header),
+			statusCode:      200,
+			wroteHeaderChan: make(chan struct{}, 1),
+		}
+
+		// Execute the handler in a separate goroutine to avoid blocking
+		go func() {
+			defer stopSignal()
+			defer stopAbort()
+			defer pw.Close()
+			defer func() {
+				if r := recover(); r != nil {
+					// Recover from panic in handler and return an error response, unless the headers were
+					// written already
+					respWriter.WriteHeader(http.StatusInternalServerError)
+					pw.CloseWithError(errors.New("internal server error"))
 				}
 			}()
 
-			// Wait for the handler to write headers before returning response to JavaScript
-			<-respWriter.wroteHeaderChan
+			handler.ServeHTTP(respWriter, httpReq)
 
-			// Construct an http.Response with the handler's status and headers, and streaming body
-			httpResp := &http.Response{
-				StatusCode: respWriter.statusCode,
-				Status:     http.StatusText(respWriter.statusCode),
-				Header:     respWriter.header,
-				Body:       pr,
+			// Ensure headers were written (required for valid HTTP response)
+			if !respWriter.wroteHeader {
+				respWriter.WriteHeader(http.StatusBadGateway)
+				http.Error(respWriter, "Bad Gateway\n\nUpstream server error", http.StatusBadGateway)
 			}
-
-			// Convert the Go response to a JavaScript Response object and resolve the promise
-			jsResp := HTTPResponseToJSResponse(httpResp)
-			resolve.Invoke(jsResp)
 		}()
 
+		// Wait for the handler to write headers before returning response to JavaScript
+		select {
+		case <-respWriter.wroteHeaderChan:
+		case <-ctx.Done():
+			if context.Cause(ctx) == ErrAborted {
+				return js.Undefined(), ErrAborted
+			}
+			// The handler has returned, writing the headers before
+			<-respWriter.wroteHeaderChan
+		}
+
+		// Construct an http.Response with the handler's status and headers, and streaming body
+		httpResp := &http.Response{
+			StatusCode: respWriter.statusCode,
+			Status:     http.StatusText(respWriter.statusCode),
+			Header:     respWriter.header,
+			Body:       pr,
+		}
+		if !o.preserveEncoding {
+			decodeResponse(httpResp)
+		}
+
+		// Convert the Go response to a JavaScript Response object and resolve the promise
+		return HTTPResponseToJSResponse(httpResp), nil
+	})
+}
+
+// signalContext returns a context cancelled with the cause ErrAborted once signal, an optional AbortSignal,
+// aborts. The returned function cancels it and stops listening to signal.
+func signalContext(signal js.Value) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	if signal.Type() != js.TypeObject || signal.Get("aborted").Type() != js.TypeBoolean {
+		return ctx, func() { cancel(nil) }
+	}
+	if signal.Get("aborted").Bool() {
+		cancel(ErrAborted)
+		return ctx, func() {}
+	}
+
+	onAbort := jsguard.FuncOf(func(this js.Value, args []js.Value) any {
+		cancel(ErrAborted)
 		return nil
-	}))
+	})
+	jsguard.AddEventListener(signal, "abort", onAbort)
+	return ctx, func() {
+		cancel(nil)
+		jsguard.RemoveEventListener(signal, "abort", onAbort)
+		jsguard.Release(onAbort)
+	}
+}
+
+// decodeResponse replaces the body of resp encoded with an encoding of compressjs by its decoding, and
+// removes the headers describing the encoded body.
+func decodeResponse(resp *http.Response) {
+	enc := compressjs.Encoding(strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))))
+	switch enc {
+	case compressjs.Gzip, compressjs.Deflate, compressjs.DeflateRaw:
+	default:
+		return
+	}
+	resp.Header = resp.Header.Clone()
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.Body = &decodingReader{body: resp.Body, enc: enc}
+}
+
+// decodingReader decodes a response body. The decoder is created by the first Read, since it reads the
+// header of the encoding, which the handler may write long after the response headers.
+type decodingReader struct {
+	body io.ReadCloser
+	enc  compressjs.Encoding
+	r    io.ReadCloser
+	err  error
+}
+
+// Read implements io.Reader.
+func (d *decodingReader) Read(p []byte) (int, error) {
+	if d.r == nil && d.err == nil {
+		d.r, d.err = compressjs.NewReader(d.body, d.enc)
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.r.Read(p)
+}
+
+// Close closes the encoded body.
+func (d *decodingReader) Close() error {
+	if d.r != nil {
+		d.r.Close()
+	}
+	return d.body.Close()
 }
 
 // streamingResponseWriter implements http.ResponseWriter interface for streaming HTTP responses.
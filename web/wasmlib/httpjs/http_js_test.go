@@ -0,0 +1,125 @@
+package httpjs
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"pkg.gfire.dev/supernet/web/browsertest"
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+// benchmarkBodySize is the size of the response body fetched per benchmark iteration
+const benchmarkBodySize = 1 << 20
+
+func TestMain(m *testing.M) {
+	jsguard.Track(true)
+	os.Exit(m.Run())
+}
+
+// fixtureURL returns the URL of a browsertest fixture, skipping t outside of the harness.
+func fixtureURL(t *testing.T, path string) string {
+	t.Helper()
+	url := browsertest.FixtureURL(path)
+	if url == "" {
+		t.Skip("no browsertest fixtures")
+	}
+	return url
+}
+
+func TestFetchDataURL(t *testing.T) {
+	defer jsguard.VerifyNone(t, jsguard.Mark())
+
+	resp, err := Get("data:text/plain;base64," + base64.StdEncoding.EncodeToString([]byte("hello")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Close()
+	body, err := resp.ReadAll()
+	if err != nil || string(body) != "hello" {
+		t.Fatalf("body %q, %v", body, err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/plain" {
+		t.Fatalf("status %d, Content-Type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+}
+
+func TestFetchEcho(t *testing.T) {
+	defer jsguard.VerifyNone(t, jsguard.Mark())
+
+	req := NewRequest(http.MethodPost, fixtureURL(t, "/http/echo"))
+	req.SetHeader("Content-Type", "text/plain")
+	req.SetHeader("X-Test", "value")
+	req.SetBody([]byte("request body"))
+	resp, err := req.Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Close()
+	body, err := resp.ReadAll()
+	if err != nil || string(body) != "request body" {
+		t.Fatalf("body %q, %v", body, err)
+	}
+	if resp.Header.Get("X-Method") != http.MethodPost || resp.Header.Get("X-Test") != "value" {
+		t.Fatalf("echoed headers %v", resp.Header)
+	}
+}
+
+func TestFetchStatus(t *testing.T) {
+	defer jsguard.VerifyNone(t, jsguard.Mark())
+
+	resp, err := Get(fixtureURL(t, "/http/status/404"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestFetchCancel(t *testing.T) {
+	defer jsguard.VerifyNone(t, jsguard.Mark())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	resp, err := NewRequest(http.MethodGet, fixtureURL(t, "/http/delay/5000")).DoContext(ctx)
+	if err == nil {
+		resp.Close()
+		t.Fatal("request outlived its context")
+	}
+	if !errors.Is(err, ErrAborted) && !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DoContext: %v, want ErrAborted or context.DeadlineExceeded", err)
+	}
+}
+
+func TestFetchFailure(t *testing.T) {
+	defer jsguard.VerifyNone(t, jsguard.Mark())
+
+	// Nothing listens on port 1
+	if _, err := Get("http://127.0.0.1:1/"); !errors.Is(err, ErrRequestFailed) {
+		t.Fatalf("Get: %v, want ErrRequestFailed", err)
+	}
+}
+
+// BenchmarkFetchBody fetches a body from a data: URL, which needs no server, and reads it with ReadAll.
+func BenchmarkFetchBody(b *testing.B) {
+	url := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(make([]byte, benchmarkBodySize))
+	b.SetBytes(benchmarkBodySize)
+	b.ReportAllocs()
+	for b.Loop() {
+		resp, err := Get(url)
+		if err != nil {
+			b.Fatal(err)
+		}
+		body, err := resp.ReadAll()
+		resp.Close()
+		if err != nil || len(body) != benchmarkBodySize {
+			b.Fatalf("read %d bytes: %v", len(body), err)
+		}
+	}
+}
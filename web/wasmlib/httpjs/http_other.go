@@ -0,0 +1,184 @@
+//go:build !js
+
+package httpjs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/streamjs"
+)
+
+// binding is empty outside the browser.
+type binding struct{}
+
+var (
+	// errRedirected fails requests redirected with RedirectError
+	errRedirected = errors.New("redirected")
+	// errIntegrity fails requests whose body does not match their integrity metadata
+	errIntegrity = errors.New("integrity mismatch")
+)
+
+// integrityHashes are the hash algorithms of integrity metadata, the strongest first
+var integrityHashes = []struct {
+	name string
+	sum  func([]byte) []byte
+}{
+	{"sha512", func(b []byte) []byte { s := sha512.Sum512(b); return s[:] }},
+	{"sha384", func(b []byte) []byte { s := sha512.Sum384(b); return s[:] }},
+	{"sha256", func(b []byte) []byte { s := sha256.Sum256(b); return s[:] }},
+}
+
+// fetch sends the request with the default net/http client and wraps its response.
+func (r *Request) fetch(ctx context.Context, headers http.Header) (*Response, error) {
+	var body io.Reader
+	switch {
+	case r.BodyReader != nil:
+		body = newProgressReader(r.BodyReader, readerLen(r.BodyReader), r.OnUploadProgress)
+	case len(r.Body) > 0:
+		body = newProgressReader(bytes.NewReader(r.Body), int64(len(r.Body)), r.OnUploadProgress)
+	}
+	// ctx bounds the whole request including reading the body, Timeout only waiting for the response
+	reqCtx, cancel := context.WithCancelCause(ctx)
+	req, err := http.NewRequestWithContext(reqCtx, r.Method, r.URL, body)
+	if err != nil {
+		cancel(nil)
+		return nil, err
+	}
+	for key, values := range headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if r.Referrer != "" && r.ReferrerPolicy != ReferrerPolicyNoReferrer {
+		req.Header.Set("Referer", r.Referrer)
+	}
+
+	var timer *time.Timer
+	if r.Timeout > 0 {
+		timer = time.AfterFunc(r.Timeout, func() {
+			cancel(context.DeadlineExceeded)
+		})
+	}
+	httpResp, err := r.client().Do(req)
+	if err == nil && r.Integrity != "" {
+		// Like fetch, the response is only returned once its body was verified
+		err = verifyBody(httpResp, r.Integrity)
+	}
+	if timer != nil && !timer.Stop() && err == nil {
+		// The timeout elapsed as the response arrived and cancelled its body
+		httpResp.Body.Close()
+		err = context.DeadlineExceeded
+	}
+	if err != nil {
+		cause := context.Cause(reqCtx)
+		cancel(nil)
+		r.log().Debug("httpjs request failed", "method", r.Method, "url", r.URL, "err", err)
+		if cause != nil {
+			return nil, cause
+		}
+		return nil, fmt.Errorf("%w: %w", ErrRequestFailed, err)
+	}
+
+	resp := &Response{
+		StatusCode:      httpResp.StatusCode,
+		Status:          strings.TrimSpace(strings.TrimPrefix(httpResp.Status, strconv.Itoa(httpResp.StatusCode))),
+		Type:            ResponseBasic,
+		ContentEncoding: httpResp.Header.Get("Content-Encoding"),
+		Uncompressed:    httpResp.Uncompressed,
+		Header:          httpResp.Header,
+		URL:             httpResp.Request.URL.String(),
+		Redirected:      httpResp.Request != req,
+		release:         func() { cancel(nil) },
+	}
+	if bodiless(r.Method, resp.StatusCode) {
+		// Like fetch, which gives these responses a null body
+		httpResp.Body.Close()
+	} else {
+		resp.bodyReader = r.download(httpResp.Body, httpResp.Header)
+		resp.Body = streamjs.NewReadableStream(resp.bodyReader)
+	}
+	if resp.Uncompressed {
+		// net/http only decodes gzip and removes the header
+		resp.ContentEncoding = "gzip"
+	}
+	return resp, nil
+}
+
+// client returns the default net/http client, changed to handle redirects as the request asks.
+func (r *Request) client() *http.Client {
+	var check func(*http.Request, []*http.Request) error
+	switch r.Redirect {
+	case RedirectError:
+		check = func(*http.Request, []*http.Request) error {
+			return errRedirected
+		}
+	case RedirectManual:
+		check = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	default:
+		return http.DefaultClient
+	}
+	c := *http.DefaultClient
+	c.CheckRedirect = check
+	return &c
+}
+
+// verifyBody reads the body of resp and checks it against the integrity metadata, replacing the body with
+// what was read. The body is closed if it does not match.
+func verifyBody(resp *http.Response, metadata string) error {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	if !matchIntegrity(metadata, body) {
+		return errIntegrity
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return nil
+}
+
+// matchIntegrity reports whether body matches one of the digests of the strongest algorithm in metadata,
+// a list of "algorithm-digest?options" fields like https://www.w3.org/TR/SRI/. Metadata without a
+// supported algorithm matches any body.
+func matchIntegrity(metadata string, body []byte) bool {
+	fields := strings.Fields(metadata)
+	for _, h := range integrityHashes {
+		var digests []string
+		for _, f := range fields {
+			if name, digest, ok := strings.Cut(f, "-"); ok && name == h.name {
+				digest, _, _ = strings.Cut(digest, "?")
+				digests = append(digests, digest)
+			}
+		}
+		if len(digests) > 0 {
+			return slices.Contains(digests, base64.StdEncoding.EncodeToString(h.sum(body)))
+		}
+	}
+	return true
+}
+
+// send sends a copy of the request in a new goroutine and discards the response.
+func (r *Request) send(headers http.Header) error {
+	req := *r
+	go func() {
+		resp, err := req.fetch(context.Background(), headers)
+		if err == nil {
+			resp.Close()
+		}
+	}()
+	return nil
+}
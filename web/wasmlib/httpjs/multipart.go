@@ -0,0 +1,133 @@
+package httpjs
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"sync"
+)
+
+// Part is a field of a multipart/form-data body, see Request.SetMultipartBody.
+type Part struct {
+	Name        string    // Name of the form field
+	Value       string    // Value of a text field, ignored if Reader is set
+	FileName    string    // File name of a file field, sent with Reader
+	ContentType string    // Content type of a file field; empty for application/octet-stream
+	Reader      io.Reader // Contents of a file field, read during the upload and closed after if an io.Closer
+}
+
+// FieldPart returns a text field of a form.
+func FieldPart(name, value string) Part {
+	return Part{Name: name, Value: value}
+}
+
+// FilePart returns a file field of a form, whose contents are read from r.
+func FilePart(name, fileName, contentType string, r io.Reader) Part {
+	return Part{Name: name, FileName: fileName, ContentType: contentType, Reader: r}
+}
+
+// SetMultipartBody sets a multipart/form-data body of parts, like a form submitted with FormData, together
+// with its Content-Type header. The body is written as it is uploaded, so files are streamed from their
+// readers instead of being buffered first. It is a BodyReader, which a Client sends only once and which
+// browsers may only stream over HTTP/2, see SetBodyReader; SetMultipartBytes builds a body that can be
+// retried.
+func (r *Request) SetMultipartBody(parts ...Part) {
+	body := newMultipartReader(parts)
+	r.Header.Set("Content-Type", body.w.FormDataContentType())
+	r.BodyReader = body
+}
+
+// SetMultipartBytes is SetMultipartBody reading the parts into memory, closing their readers, so the
+// request can be retried like any other. The body is left unchanged if reading a part fails.
+func (r *Request) SetMultipartBytes(parts ...Part) error {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := writeParts(w, parts); err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	r.Body = buf.Bytes()
+	return nil
+}
+
+// multipartReader reads a multipart/form-data body written by a goroutine started by the first Read, so
+// requests never sent do not leave it behind.
+type multipartReader struct {
+	parts []Part
+	pr    *io.PipeReader
+	pw    *io.PipeWriter
+	w     *multipart.Writer
+	start sync.Once
+}
+
+// newMultipartReader returns a reader of a multipart/form-data body of parts with a random boundary.
+func newMultipartReader(parts []Part) *multipartReader {
+	pr, pw := io.Pipe()
+	return &multipartReader{parts: parts, pr: pr, pw: pw, w: multipart.NewWriter(pw)}
+}
+
+// Read implements io.Reader.
+func (m *multipartReader) Read(p []byte) (int, error) {
+	m.start.Do(func() {
+		go m.write()
+	})
+	return m.pr.Read(p)
+}
+
+// Close stops writing the body and closes the readers of the parts not written yet.
+func (m *multipartReader) Close() error {
+	m.start.Do(func() {
+		closeParts(m.parts)
+	})
+	return m.pr.Close()
+}
+
+// write writes the parts to the pipe, failing the body with the first error, which also fails it when the
+// reader is closed.
+func (m *multipartReader) write() {
+	m.pw.CloseWithError(writeParts(m.w, m.parts))
+}
+
+// writeParts writes parts and the closing boundary to w, closing the readers of the parts. The first error
+// stops writing.
+func writeParts(w *multipart.Writer, parts []Part) error {
+	for i, part := range parts {
+		if err := writePart(w, part); err != nil {
+			closeParts(parts[i+1:])
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// writePart writes a part, closing its reader once it was copied.
+func writePart(mw *multipart.Writer, part Part) error {
+	if part.Reader == nil {
+		return mw.WriteField(part.Name, part.Value)
+	}
+	defer closeParts([]Part{part})
+
+	contentType := part.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", multipart.FileContentDisposition(part.Name, part.FileName))
+	header.Set("Content-Type", contentType)
+	w, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, part.Reader)
+	return err
+}
+
+// closeParts closes the readers of parts that are io.Closers.
+func closeParts(parts []Part) {
+	for _, part := range parts {
+		if c, ok := part.Reader.(io.Closer); ok {
+			c.Close()
+		}
+	}
+}
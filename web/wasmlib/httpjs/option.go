@@ -0,0 +1,209 @@
+package httpjs
+
+import (
+	"io"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+// Option configures a Request created by NewRequest or one of the helpers such as Get. Options are applied in
+// order and set the exported fields of the Request, so a later option overrides an earlier one setting the
+// same field, and fields set after NewRequest override both; WithHeader adds to the headers instead. The
+// helpers apply their options after the content type and body they were given.
+type Option func(*Request)
+
+// WithHeader sets a request header.
+func WithHeader(key, value string) Option {
+	return func(r *Request) {
+		r.Header.Set(key, value)
+	}
+}
+
+// WithBasicAuth sets HTTP Basic authentication, see Request.SetBasicAuth.
+func WithBasicAuth(username, password string) Option {
+	return func(r *Request) {
+		r.SetBasicAuth(username, password)
+	}
+}
+
+// WithBearerToken sets a bearer token, see Request.SetBearerToken.
+func WithBearerToken(token string) Option {
+	return func(r *Request) {
+		r.SetBearerToken(token)
+	}
+}
+
+// WithBody sets the request body.
+func WithBody(body []byte) Option {
+	return func(r *Request) {
+		r.Body = body
+	}
+}
+
+// WithFormBody sets a URL-encoded form body of values, see Request.SetFormBody.
+func WithFormBody(values url.Values) Option {
+	return func(r *Request) {
+		r.SetFormBody(values)
+	}
+}
+
+// WithBodyReader sets a request body streamed during the upload, see Request.SetBodyReader.
+func WithBodyReader(body io.Reader) Option {
+	return func(r *Request) {
+		r.BodyReader = body
+	}
+}
+
+// WithMultipartBody sets a multipart/form-data body of parts, see Request.SetMultipartBody.
+func WithMultipartBody(parts ...Part) Option {
+	return func(r *Request) {
+		r.SetMultipartBody(parts...)
+	}
+}
+
+// WithRedirect sets the handling of redirects.
+func WithRedirect(mode RedirectMode) Option {
+	return func(r *Request) {
+		r.Redirect = mode
+	}
+}
+
+// WithCredentials sets whether the request sends and stores credentials. Outside the browser there are no
+// stored credentials and it has no effect.
+func WithCredentials(mode CredentialsMode) Option {
+	return func(r *Request) {
+		r.Credentials = mode
+	}
+}
+
+// WithCache sets how the request uses the HTTP cache of the browser. Outside the browser there is no cache
+// and it has no effect.
+func WithCache(mode CacheMode) Option {
+	return func(r *Request) {
+		r.Cache = mode
+	}
+}
+
+// WithMode sets which cross-origin requests are allowed. Outside the browser there is no same-origin policy
+// and it has no effect.
+func WithMode(mode RequestMode) Option {
+	return func(r *Request) {
+		r.Mode = mode
+	}
+}
+
+// WithReferrer sets the referrer of the request, a URL of the origin of the page; browsers replace other
+// URLs by the page. Outside the browser it is sent as the Referer header unless ReferrerPolicy is
+// ReferrerPolicyNoReferrer.
+func WithReferrer(url string) Option {
+	return func(r *Request) {
+		r.Referrer = url
+	}
+}
+
+// WithReferrerPolicy sets how much of the referrer the request reveals, e.g. ReferrerPolicyNoReferrer to
+// strip the Referer header.
+func WithReferrerPolicy(policy ReferrerPolicy) Option {
+	return func(r *Request) {
+		r.ReferrerPolicy = policy
+	}
+}
+
+// WithIntegrity sets the subresource integrity metadata of the request, e.g. "sha384-<base64 digest>": hashes
+// of which the response body must match one, using the strongest of the SHA-256, SHA-384 and SHA-512
+// algorithms given. The body is verified before the response is returned, so it is read into memory
+// first, and a mismatch fails the request with ErrRequestFailed.
+func WithIntegrity(metadata string) Option {
+	return func(r *Request) {
+		r.Integrity = metadata
+	}
+}
+
+// WithKeepAlive lets the request complete after the page was closed, for small requests such as telemetry
+// sent while the page unloads, usually with Request.Send. Browsers limit the bodies of such requests in
+// flight to 64 KiB in total, and a body reader is read into memory since it cannot be streamed. Outside the
+// browser it has no effect.
+func WithKeepAlive() Option {
+	return func(r *Request) {
+		r.KeepAlive = true
+	}
+}
+
+// WithXHR sends the request with XMLHttpRequest instead of fetch, e.g. to report the progress of uploading a
+// body in memory while it is sent rather than once it was. Requests are sent with XMLHttpRequest anyway where
+// fetch is unavailable, and when OnUploadProgress is set for a body fetch cannot stream.
+//
+// XMLHttpRequest buffers the response body, which can only be read once it has arrived in full, and reports
+// download progress as it arrives instead. It always follows redirects and lacks the options Cache, Mode,
+// Referrer, ReferrerPolicy, Integrity, KeepAlive and CredentialsOmit; requests using them are sent with fetch
+// regardless, or fail with ErrUnsupported without it. Reporting upload progress makes cross-origin requests
+// preflighted with CORS. Outside the browser WithXHR has no effect.
+func WithXHR() Option {
+	return func(r *Request) {
+		r.XHR = true
+	}
+}
+
+// WithUploadProgress sets the function receiving the progress of sending the request body. A body reader
+// streamed by fetch reports it as it is read; in the browser other bodies are sent with XMLHttpRequest to
+// report it as they are sent, see WithXHR. Requests using options only fetch has report it at once when the
+// response has arrived, since fetch does not tell how much of a body was sent.
+func WithUploadProgress(fn ProgressFunc) Option {
+	return func(r *Request) {
+		r.OnUploadProgress = fn
+	}
+}
+
+// WithDownloadProgress sets the function receiving the progress of reading the response body, out of its
+// Content-Length. Browsers decode compressed bodies, whose length is then unknown.
+func WithDownloadProgress(fn ProgressFunc) Option {
+	return func(r *Request) {
+		r.OnDownloadProgress = fn
+	}
+}
+
+// WithTimeout bounds waiting for the response. Reading the body is not limited by it.
+func WithTimeout(d time.Duration) Option {
+	return func(r *Request) {
+		r.Timeout = d
+	}
+}
+
+// WithIdempotent marks the request as safe to send more than once, letting a Client retry it although its
+// method is not idempotent, e.g. a POST the server deduplicates. Setting an Idempotency-Key header does so
+// as well.
+func WithIdempotent() Option {
+	return func(r *Request) {
+		r.Idempotent = true
+	}
+}
+
+// WithLogger sets the logger receiving failed requests instead of the package's logger.
+func WithLogger(l *slog.Logger) Option {
+	return func(r *Request) {
+		r.Logger = l
+	}
+}
+
+// WithoutTracing skips the span and trace context headers of the request.
+func WithoutTracing() Option {
+	return func(r *Request) {
+		r.Untraced = true
+	}
+}
+
+// apply applies opts to the request.
+func (r *Request) apply(opts []Option) {
+	for _, opt := range opts {
+		opt(r)
+	}
+}
+
+// log returns the logger receiving failed requests.
+func (r *Request) log() *slog.Logger {
+	if r.Logger != nil {
+		return r.Logger
+	}
+	return logger
+}
@@ -0,0 +1,98 @@
+package httpjs
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// ProgressFunc receives the number of bytes of a body transferred so far out of total, which is -1 if
+// unknown. It is called from the goroutine reading the body and must return quickly.
+type ProgressFunc func(transferred, total int64)
+
+// progressReader reports the bytes read through it to a ProgressFunc.
+type progressReader struct {
+	r      io.Reader
+	n      int64
+	total  int64
+	report ProgressFunc
+}
+
+// newProgressReader returns r reporting its progress out of total to report, or r itself if report is nil.
+// The returned reader closes r if it is an io.Closer.
+func newProgressReader(r io.Reader, total int64, report ProgressFunc) io.ReadCloser {
+	if report == nil {
+		return readCloser(r)
+	}
+	return &progressReader{r: r, total: total, report: report}
+}
+
+// Read implements io.Reader.
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.add(n)
+	return n, err
+}
+
+// WriteTo implements io.WriterTo, keeping the whole chunk copies of the body reader of fetch.
+func (p *progressReader) WriteTo(w io.Writer) (int64, error) {
+	return io.Copy(progressWriter{w: w, p: p}, p.r)
+}
+
+// Close closes the underlying reader if it is an io.Closer.
+func (p *progressReader) Close() error {
+	if c, ok := p.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// add reports n more bytes transferred.
+func (p *progressReader) add(n int) {
+	if n > 0 {
+		p.n += int64(n)
+		p.report(p.n, p.total)
+	}
+}
+
+// progressWriter reports the bytes written through it on behalf of a progressReader.
+type progressWriter struct {
+	w io.Writer
+	p *progressReader
+}
+
+// Write implements io.Writer.
+func (pw progressWriter) Write(b []byte) (int, error) {
+	n, err := pw.w.Write(b)
+	pw.p.add(n)
+	return n, err
+}
+
+// readCloser returns r as an io.ReadCloser, closing r if it is an io.Closer.
+func readCloser(r io.Reader) io.ReadCloser {
+	if rc, ok := r.(io.ReadCloser); ok {
+		return rc
+	}
+	return io.NopCloser(r)
+}
+
+// readerLen returns the number of bytes left in r if it tells, like bytes.Reader, or -1.
+func readerLen(r io.Reader) int64 {
+	if l, ok := r.(interface{ Len() int }); ok {
+		return int64(l.Len())
+	}
+	return -1
+}
+
+// download returns body reporting its progress to OnDownloadProgress, out of the Content-Length of header,
+// which decoded bodies lack.
+func (r *Request) download(body io.ReadCloser, header http.Header) io.ReadCloser {
+	if r.OnDownloadProgress == nil {
+		return body
+	}
+	total := int64(-1)
+	if n, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil && n >= 0 {
+		total = n
+	}
+	return newProgressReader(body, total, r.OnDownloadProgress)
+}
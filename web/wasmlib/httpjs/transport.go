@@ -0,0 +1,104 @@
+package httpjs
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+// Transport is an http.RoundTripper sending requests with fetch, so an http.Client, and the libraries
+// built on one, work in the browser:
+//
+//	client := &http.Client{Transport: &httpjs.Transport{}}
+//
+// Requests go through Request.DoContext with the context of the http.Request, so they are traced and
+// cancelled like any other. Response bodies are streamed and must be closed. Fetch follows redirects
+// itself unless WithRedirect says otherwise, and decodes compressed bodies, see Response.Uncompressed.
+//
+// Opaque responses, see Response.Opaque, fail with ErrOpaqueResponse since an http.Response has no way to
+// tell them from a real one. Outside the browser requests are sent with net/http like Request.Do.
+type Transport struct {
+	// Options configure every request, e.g. WithoutTracing. The method, URL, headers and body come from the
+	// http.Request.
+	Options []Option
+	// StreamBodies streams request bodies instead of reading them before the request is sent, see
+	// Request.SetBodyReader for the limits of browsers.
+	StreamBodies bool
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := NewRequest(req.Method, req.URL.String(), t.Options...)
+	for key, values := range req.Header {
+		r.Header[key] = slices.Clone(values)
+	}
+	switch {
+	case req.Body == nil || req.Body == http.NoBody:
+	case t.StreamBodies:
+		r.BodyReader = req.Body
+	default:
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+
+	resp, err := r.DoContext(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	if resp.Opaque {
+		resp.Close()
+		return nil, ErrOpaqueResponse
+	}
+	return resp.httpResponse(req)
+}
+
+// httpResponse converts the response to the request req into an http.Response whose body closes resp.
+func (resp *Response) httpResponse(req *http.Request) (*http.Response, error) {
+	header := resp.Header.Clone()
+
+	length := int64(-1)
+	if v := header.Get("Content-Length"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			resp.Close()
+			return nil, fmt.Errorf("%w: invalid Content-Length %q", ErrRequestFailed, v)
+		}
+		length = n
+	}
+
+	// Like net/http, the request of a redirected response is the last one sent
+	if resp.Redirected {
+		if u, err := url.Parse(resp.URL); err == nil {
+			req = req.Clone(req.Context())
+			req.URL = u
+		}
+	}
+
+	var body io.ReadCloser = http.NoBody
+	if resp.bodyReader != nil {
+		body = resp
+	} else {
+		resp.Close()
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.StatusCode, cmp.Or(resp.Status, http.StatusText(resp.StatusCode))),
+		StatusCode:    resp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          body,
+		ContentLength: length,
+		Uncompressed:  resp.Uncompressed,
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,238 @@
+package httpjs
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+	"pkg.gfire.dev/supernet/web/wasmlib/perfjs"
+	"pkg.gfire.dev/supernet/web/wasmlib/streamjs"
+)
+
+// _XMLHttpRequest is a cached reference to the JavaScript XMLHttpRequest constructor, the fallback of fetch
+var _XMLHttpRequest = js.Global().Get("XMLHttpRequest")
+
+// xhrHeadersReceived is the readyState of an XMLHttpRequest whose response headers have arrived
+const xhrHeadersReceived = 2
+
+// useXHR reports whether the request is sent with XMLHttpRequest instead of fetch.
+func (r *Request) useXHR() bool {
+	if _fetch.Type() != js.TypeFunction {
+		return true
+	}
+	if r.needsFetch() || !_XMLHttpRequest.Truthy() {
+		return false
+	}
+	if r.XHR {
+		return true
+	}
+	// Fetch only reports the upload of a body it cannot stream once it is over
+	streamed := r.BodyReader != nil && supportsUploadStreams()
+	return r.OnUploadProgress != nil && (len(r.Body) > 0 || r.BodyReader != nil) && !streamed
+}
+
+// needsFetch reports whether the request uses options of fetch that XMLHttpRequest lacks.
+func (r *Request) needsFetch() bool {
+	return (r.Redirect != "" && r.Redirect != RedirectFollow) ||
+		(r.Cache != "" && r.Cache != CacheDefault) ||
+		(r.Mode != "" && r.Mode != ModeCORS) ||
+		r.Credentials == CredentialsOmit ||
+		r.Referrer != "" || r.ReferrerPolicy != "" || r.Integrity != "" || r.KeepAlive
+}
+
+// xhr sends the request with XMLHttpRequest and wraps its response like fetch. The response is returned once
+// its headers have arrived, while its body, which XMLHttpRequest buffers, is read once it has arrived in full.
+func (r *Request) xhr(ctx context.Context, headers http.Header) (*Response, error) {
+	if r.needsFetch() {
+		return nil, ErrUnsupported
+	}
+	body, err := r.readBody()
+	if err != nil {
+		return nil, err
+	}
+
+	x := _XMLHttpRequest.New()
+	x.Call("open", r.Method, r.URL)
+	x.Set("responseType", "arraybuffer")
+	if r.Credentials == CredentialsInclude {
+		x.Set("withCredentials", true)
+	}
+	for key, values := range headers {
+		for _, value := range values {
+			x.Call("setRequestHeader", key, value)
+		}
+	}
+
+	// The events of the request, buffered as they are sent from JavaScript callbacks, which must not block
+	headersReceived := make(chan struct{})
+	done := make(chan error, 1)
+	var funcs []js.Func
+	on := func(target js.Value, event string, fn func(js.Value)) {
+		f := jsguard.FuncOf(func(this js.Value, args []js.Value) any {
+			fn(args[0])
+			return nil
+		})
+		funcs = append(funcs, f)
+		jsguard.AddEventListener(target, event, f)
+	}
+	on(x, "readystatechange", func(js.Value) {
+		if x.Get("readyState").Int() == xhrHeadersReceived {
+			close(headersReceived)
+		}
+	})
+	on(x, "load", func(js.Value) { done <- nil })
+	on(x, "error", func(js.Value) { done <- ErrRequestFailed })
+	on(x, "abort", func(js.Value) { done <- ErrAborted })
+	// Listening to upload events makes cross-origin requests preflighted, so only when asked to
+	if r.OnUploadProgress != nil && len(body) > 0 {
+		on(x.Get("upload"), "progress", progressListener(r.OnUploadProgress))
+	}
+	if r.OnDownloadProgress != nil {
+		on(x, "progress", progressListener(r.OnDownloadProgress))
+	}
+
+	// Cancelling ctx aborts the request, including reading the response body
+	stopAbort := context.AfterFunc(ctx, func() {
+		x.Call("abort")
+	})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			stopAbort()
+			// Aborting a finished request does nothing, and one in progress fires its events synchronously
+			x.Call("abort")
+			for _, f := range funcs {
+				jsguard.Release(f)
+			}
+		})
+	}
+
+	start := perfjs.Now()
+	if len(body) > 0 {
+		array := _Uint8Array.New(len(body))
+		js.CopyBytesToJS(array, body)
+		x.Call("send", array)
+	} else {
+		x.Call("send")
+	}
+
+	waitCtx := ctx
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	select {
+	case <-headersReceived:
+	case err = <-done:
+	case <-waitCtx.Done():
+		err = waitCtx.Err()
+	}
+	if err != nil {
+		stop()
+		r.log().Debug("httpjs request failed", "method", r.Method, "url", r.URL, "err", err)
+		if waitCtx.Err() != nil {
+			return nil, waitCtx.Err()
+		}
+		return nil, err
+	}
+
+	url, requestURL := x.Get("responseURL").String(), resolveURL(r.URL)
+	resp := &Response{
+		StatusCode: x.Get("status").Int(),
+		Status:     x.Get("statusText").String(),
+		Header:     parseXHRHeaders(x.Call("getAllResponseHeaders").String()),
+		URL:        url,
+		Redirected: url != "" && url != requestURL,
+		Type:       xhrType(cmp.Or(url, requestURL)),
+		release:    stop,
+		timing:     timingKey{name: requestURL, start: start, received: perfjs.Now()},
+	}
+	resp.decoded()
+	// Closing the response aborts the request, failing a pending read
+	reader := readCloser(&xhrBody{x: x, done: done, ctx: ctx})
+	resp.bodyReader = reader
+	resp.Body = streamjs.NewReadableStream(reader, streamjs.WithHighWaterMark(0))
+	return resp, nil
+}
+
+// xhrType returns the type fetch gives the response from url, which XMLHttpRequest does not tell: basic for
+// the origin of the page and CORS for others.
+func xhrType(rawURL string) ResponseType {
+	location := js.Global().Get("location")
+	if !location.Truthy() {
+		return ResponseBasic
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme+"://"+u.Host == location.Get("origin").String() {
+		return ResponseBasic
+	}
+	return ResponseCORS
+}
+
+// progressListener returns a listener of ProgressEvents reporting them to fn.
+func progressListener(fn ProgressFunc) func(js.Value) {
+	return func(event js.Value) {
+		total := int64(-1)
+		if event.Get("lengthComputable").Bool() {
+			total = int64(event.Get("total").Float())
+		}
+		fn(int64(event.Get("loaded").Float()), total)
+	}
+}
+
+// parseXHRHeaders parses the headers returned by getAllResponseHeaders, one "name: value" per line.
+func parseXHRHeaders(s string) http.Header {
+	header := make(http.Header)
+	for line := range strings.SplitSeq(s, "\r\n") {
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			header.Add(strings.TrimSpace(key), strings.TrimSpace(value))
+		}
+	}
+	return header
+}
+
+// xhrBody reads the body of an XMLHttpRequest response once it has arrived, or fails with the error of the
+// request.
+type xhrBody struct {
+	x    js.Value
+	done <-chan error
+	ctx  context.Context
+	r    *bytes.Reader
+	err  error
+}
+
+// Read implements io.Reader.
+func (b *xhrBody) Read(p []byte) (int, error) {
+	if b.r == nil && b.err == nil {
+		b.wait()
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.r.Read(p)
+}
+
+// wait waits for the body to arrive, copying it out of JavaScript.
+func (b *xhrBody) wait() {
+	if err := <-b.done; err != nil {
+		b.err = err
+		if b.ctx.Err() != nil {
+			b.err = b.ctx.Err()
+		}
+		return
+	}
+	var body []byte
+	if buffer := b.x.Get("response"); buffer.Truthy() {
+		array := _Uint8Array.New(buffer)
+		body = make([]byte, array.Length())
+		js.CopyBytesToGo(body, array)
+	}
+	b.r = bytes.NewReader(body)
+}
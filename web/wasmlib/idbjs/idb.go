@@ -0,0 +1,49 @@
+// Package idbjs provides bindings for the IndexedDB API.
+//
+// IndexedDB commits a transaction as soon as it has no pending requests at the end of a JavaScript task,
+// and resuming a goroutine after a request completes may take longer than that. Issue all requests of a
+// transaction before waiting on any of them, then wait for the results or for Tx.Done.
+//
+// Outside the browser Open returns ErrUnsupported, and the store and transaction APIs, which deal in
+// JavaScript values, are not available.
+package idbjs
+
+import (
+	"errors"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jserr"
+)
+
+var (
+	// ErrUnsupported is returned when IndexedDB is not available in the current context
+	ErrUnsupported = errors.New("indexeddb not supported")
+	// ErrBlocked is returned when opening or deleting a database is blocked by connections in other tabs
+	ErrBlocked = errors.New("indexeddb request blocked by another connection")
+	// ErrAborted is returned when waiting on a transaction that was aborted
+	ErrAborted = jserr.New(jserr.ErrAborted, "indexeddb transaction aborted")
+	// ErrRequestFailed is returned when an IndexedDB request fails without a reason
+	ErrRequestFailed = errors.New("indexeddb request failed")
+)
+
+// Mode is the access mode of a transaction.
+type Mode string
+
+const (
+	ReadOnly  Mode = "readonly"  // Read-only transaction, may run concurrently with other readers
+	ReadWrite Mode = "readwrite" // Read-write transaction
+)
+
+// UpgradeFunc creates or migrates object stores while a database is upgraded to a new version.
+// It runs synchronously inside the upgradeneeded event and must not block.
+type UpgradeFunc func(db *DB, oldVersion, newVersion int) error
+
+// StoreOptions configures a new object store.
+type StoreOptions struct {
+	KeyPath       string // Property used as the key of stored objects; out-of-line keys if empty
+	AutoIncrement bool   // Generate keys from a key generator
+}
+
+// DB is an open IndexedDB database connection.
+type DB struct {
+	value handle // The underlying IDBDatabase
+}
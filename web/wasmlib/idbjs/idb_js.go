@@ -0,0 +1,426 @@
+package idbjs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+var (
+	// _indexedDB is a cached reference to the global IDBFactory, undefined outside of supporting contexts
+	_indexedDB = js.Global().Get("indexedDB")
+	// _IDBKeyRange is a cached reference to the IDBKeyRange interface for building key ranges
+	_IDBKeyRange = js.Global().Get("IDBKeyRange")
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+	// _Array is a cached reference to the JavaScript Array constructor for array operations
+	_Array = js.Global().Get("Array")
+)
+
+// handle is the type of the JavaScript database handle
+type handle = js.Value
+
+// Open opens the named database at version, calling upgrade if the stored version is older.
+// If the upgrade is blocked by connections in other tabs, Open returns ErrBlocked; the connection
+// is closed once it eventually opens.
+func Open(ctx context.Context, name string, version int, upgrade UpgradeFunc) (*DB, error) {
+	if !Supported() {
+		return nil, ErrUnsupported
+	}
+
+	req := _indexedDB.Call("open", name, version)
+
+	var upgradeErr error
+	onUpgrade := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if upgrade == nil {
+			return nil
+		}
+		db := &DB{value: req.Get("result")}
+		if err := upgrade(db, args[0].Get("oldVersion").Int(), args[0].Get("newVersion").Int()); err != nil {
+			upgradeErr = err
+			req.Get("transaction").Call("abort")
+		}
+		return nil
+	})
+	req.Set("onupgradeneeded", onUpgrade)
+
+	result, err := waitBlocked(ctx, req, func(r *Request) {
+		jsguard.Release(onUpgrade)
+		if r.err == nil && r.abandoned.Load() {
+			r.result.Call("close")
+		}
+	})
+	if upgradeErr != nil {
+		return nil, upgradeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &DB{value: result}, nil
+}
+
+// DeleteDatabase deletes the named database.
+// If the deletion is blocked by connections in other tabs, DeleteDatabase returns ErrBlocked;
+// the database is deleted once those connections close.
+func DeleteDatabase(ctx context.Context, name string) error {
+	if !Supported() {
+		return ErrUnsupported
+	}
+
+	_, err := waitBlocked(ctx, _indexedDB.Call("deleteDatabase", name), nil)
+	return err
+}
+
+// Supported reports whether IndexedDB is available.
+func Supported() bool {
+	return _indexedDB.Truthy()
+}
+
+// Value returns the underlying IDBDatabase.
+func (db *DB) Value() js.Value {
+	return db.value
+}
+
+// Version returns the version of the database.
+func (db *DB) Version() int {
+	return db.value.Get("version").Int()
+}
+
+// StoreNames returns the names of the object stores in the database.
+func (db *DB) StoreNames() []string {
+	list := db.value.Get("objectStoreNames")
+	names := make([]string, list.Length())
+	for i := range names {
+		names[i] = list.Index(i).String()
+	}
+	return names
+}
+
+// HasStore reports whether the database contains the named object store.
+func (db *DB) HasStore(name string) bool {
+	return db.value.Get("objectStoreNames").Call("contains", name).Bool()
+}
+
+// CreateStore creates an object store. It may only be called from an UpgradeFunc.
+func (db *DB) CreateStore(name string, opts StoreOptions) (*Store, error) {
+	jsOpts := _Object.New()
+	if opts.KeyPath != "" {
+		jsOpts.Set("keyPath", opts.KeyPath)
+	}
+	jsOpts.Set("autoIncrement", opts.AutoIncrement)
+
+	store, err := promisejs.Try(func() js.Value {
+		return db.value.Call("createObjectStore", name, jsOpts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{value: store}, nil
+}
+
+// DeleteStore deletes an object store. It may only be called from an UpgradeFunc.
+func (db *DB) DeleteStore(name string) error {
+	_, err := promisejs.Try(func() js.Value {
+		return db.value.Call("deleteObjectStore", name)
+	})
+	return err
+}
+
+// Transaction starts a transaction over the named object stores.
+func (db *DB) Transaction(mode Mode, stores ...string) (*Tx, error) {
+	names := _Array.New(len(stores))
+	for i, name := range stores {
+		names.SetIndex(i, name)
+	}
+
+	tx, err := promisejs.Try(func() js.Value {
+		return db.value.Call("transaction", names, string(mode))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newTx(tx), nil
+}
+
+// Close closes the connection once all pending transactions have completed.
+func (db *DB) Close() error {
+	db.value.Call("close")
+	return nil
+}
+
+// Tx is an IndexedDB transaction.
+type Tx struct {
+	value js.Value      // The underlying IDBTransaction
+	done  chan struct{} // Closed when the transaction completes, fails or aborts
+	err   error         // Outcome of the transaction, valid after done is closed
+
+	funcsToBeReleased []js.Func
+}
+
+// newTx wraps an IDBTransaction and starts watching for its outcome.
+func newTx(value js.Value) *Tx {
+	tx := &Tx{value: value, done: make(chan struct{})}
+
+	finish := func(err error) {
+		select {
+		case <-tx.done:
+			return
+		default:
+		}
+		tx.err = err
+		close(tx.done)
+		for _, fn := range tx.funcsToBeReleased {
+			jsguard.Release(fn)
+		}
+	}
+
+	onComplete := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		finish(nil)
+		return nil
+	})
+	onError := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		finish(domError(tx.value.Get("error")))
+		return nil
+	})
+	onAbort := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		err := domError(tx.value.Get("error"))
+		if errors.Is(err, ErrRequestFailed) {
+			err = ErrAborted
+		}
+		finish(err)
+		return nil
+	})
+	tx.funcsToBeReleased = append(tx.funcsToBeReleased, onComplete, onError, onAbort)
+	value.Set("oncomplete", onComplete)
+	value.Set("onerror", onError)
+	value.Set("onabort", onAbort)
+
+	return tx
+}
+
+// Store returns the named object store of the transaction.
+func (tx *Tx) Store(name string) (*Store, error) {
+	store, err := promisejs.Try(func() js.Value {
+		return tx.value.Call("objectStore", name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{value: store}, nil
+}
+
+// Commit commits the transaction without waiting for it to become inactive.
+func (tx *Tx) Commit() {
+	if tx.value.Get("commit").Type() == js.TypeFunction {
+		tx.value.Call("commit")
+	}
+}
+
+// Abort rolls back the transaction.
+func (tx *Tx) Abort() {
+	promisejs.Try(func() js.Value {
+		return tx.value.Call("abort")
+	})
+}
+
+// Done waits for the transaction to complete and returns its error, if any.
+func (tx *Tx) Done(ctx context.Context) error {
+	select {
+	case <-tx.done:
+		return tx.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Store is an object store within a transaction.
+type Store struct {
+	value js.Value // The underlying IDBObjectStore
+}
+
+// Put stores value, replacing any existing value with the same key. key must be undefined for stores with a key path.
+func (s *Store) Put(value, key js.Value) *Request {
+	if key.IsUndefined() {
+		return s.call("put", value)
+	}
+	return s.call("put", value, key)
+}
+
+// Add stores value, failing if the key already exists. key must be undefined for stores with a key path.
+func (s *Store) Add(value, key js.Value) *Request {
+	if key.IsUndefined() {
+		return s.call("add", value)
+	}
+	return s.call("add", value, key)
+}
+
+// Get retrieves the value stored under key, undefined if there is none.
+func (s *Store) Get(key js.Value) *Request {
+	return s.call("get", key)
+}
+
+// GetAll retrieves up to count values with keys in query (a key or KeyRange); undefined query matches all keys
+// and a count of 0 means no limit.
+func (s *Store) GetAll(query js.Value, count int) *Request {
+	if count > 0 {
+		return s.call("getAll", query, count)
+	}
+	return s.call("getAll", query)
+}
+
+// Delete removes the values with keys in query (a key or KeyRange).
+func (s *Store) Delete(query js.Value) *Request {
+	return s.call("delete", query)
+}
+
+// Clear removes all values.
+func (s *Store) Clear() *Request {
+	return s.call("clear")
+}
+
+// Count counts the values with keys in query; undefined query counts all values.
+func (s *Store) Count(query js.Value) *Request {
+	return s.call("count", query)
+}
+
+// CreateIndex creates an index on keyPath. It may only be called from an UpgradeFunc.
+func (s *Store) CreateIndex(name, keyPath string, unique bool) error {
+	opts := _Object.New()
+	opts.Set("unique", unique)
+	_, err := promisejs.Try(func() js.Value {
+		return s.value.Call("createIndex", name, keyPath, opts)
+	})
+	return err
+}
+
+// call issues a request, capturing synchronous exceptions in the returned Request.
+func (s *Store) call(method string, args ...interface{}) *Request {
+	req, err := promisejs.Try(func() js.Value {
+		return s.value.Call(method, args...)
+	})
+	if err != nil {
+		return &Request{err: err}
+	}
+	return newRequest(req)
+}
+
+// Request is a pending IndexedDB request.
+type Request struct {
+	done   chan struct{} // Closed when the request succeeds or fails
+	result js.Value      // Result of the request, valid after done is closed
+	err    error         // Error of the request, valid after done is closed
+
+	// abandoned is set when the caller stopped waiting, so results owning resources can be discarded
+	abandoned atomic.Bool
+}
+
+// abandon marks the request as no longer awaited.
+func (r *Request) abandon() {
+	r.abandoned.Store(true)
+}
+
+// newRequest wraps an IDBRequest and starts watching for its outcome.
+func newRequest(req js.Value) *Request {
+	r := &Request{done: make(chan struct{})}
+
+	var onSuccess, onError js.Func
+	onSuccess = jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer jsguard.Release(onSuccess)
+		defer jsguard.Release(onError)
+
+		r.result = req.Get("result")
+		close(r.done)
+		return nil
+	})
+	onError = jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer jsguard.Release(onSuccess)
+		defer jsguard.Release(onError)
+
+		r.err = domError(req.Get("error"))
+		close(r.done)
+		return nil
+	})
+	req.Set("onsuccess", onSuccess)
+	req.Set("onerror", onError)
+
+	return r
+}
+
+// Wait waits for the request to finish and returns its result.
+func (r *Request) Wait(ctx context.Context) (js.Value, error) {
+	if r.done == nil {
+		return js.Undefined(), r.err
+	}
+	select {
+	case <-r.done:
+		return r.result, r.err
+	case <-ctx.Done():
+		return js.Undefined(), ctx.Err()
+	}
+}
+
+// Only returns a key range matching a single key.
+func Only(key interface{}) js.Value {
+	return _IDBKeyRange.Call("only", key)
+}
+
+// LowerBound returns a key range of keys greater than (or equal to, unless open) lower.
+func LowerBound(lower interface{}, open bool) js.Value {
+	return _IDBKeyRange.Call("lowerBound", lower, open)
+}
+
+// UpperBound returns a key range of keys less than (or equal to, unless open) upper.
+func UpperBound(upper interface{}, open bool) js.Value {
+	return _IDBKeyRange.Call("upperBound", upper, open)
+}
+
+// Bound returns a key range between lower and upper.
+func Bound(lower, upper interface{}, lowerOpen, upperOpen bool) js.Value {
+	return _IDBKeyRange.Call("bound", lower, upper, lowerOpen, upperOpen)
+}
+
+// waitBlocked waits for an open or delete request, failing with ErrBlocked if it is blocked.
+// cleanup, if set, runs once the request has finished, even if waitBlocked returned earlier.
+func waitBlocked(ctx context.Context, req js.Value, cleanup func(*Request)) (js.Value, error) {
+	blocked := make(chan struct{}, 1)
+	onBlocked := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		select {
+		case blocked <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	req.Set("onblocked", onBlocked)
+
+	r := newRequest(req)
+	go func() {
+		<-r.done
+		jsguard.Release(onBlocked)
+		if cleanup != nil {
+			cleanup(r)
+		}
+	}()
+
+	select {
+	case <-r.done:
+		return r.result, r.err
+	case <-blocked:
+		r.abandon()
+		return js.Undefined(), ErrBlocked
+	case <-ctx.Done():
+		r.abandon()
+		return js.Undefined(), ctx.Err()
+	}
+}
+
+// domError converts a DOMException into an error.
+func domError(value js.Value) error {
+	if value.IsUndefined() || value.IsNull() {
+		return ErrRequestFailed
+	}
+	return promisejs.NewError(value)
+}
@@ -0,0 +1,48 @@
+//go:build !js
+
+package idbjs
+
+import "context"
+
+// handle is empty outside the browser, where no database can be opened.
+type handle = struct{}
+
+// Open returns ErrUnsupported outside the browser.
+func Open(ctx context.Context, name string, version int, upgrade UpgradeFunc) (*DB, error) {
+	return nil, ErrUnsupported
+}
+
+// DeleteDatabase returns ErrUnsupported outside the browser.
+func DeleteDatabase(ctx context.Context, name string) error {
+	return ErrUnsupported
+}
+
+// Supported reports false outside the browser.
+func Supported() bool {
+	return false
+}
+
+// Version returns 0.
+func (db *DB) Version() int {
+	return 0
+}
+
+// StoreNames returns nil.
+func (db *DB) StoreNames() []string {
+	return nil
+}
+
+// HasStore reports false.
+func (db *DB) HasStore(name string) bool {
+	return false
+}
+
+// DeleteStore returns ErrUnsupported.
+func (db *DB) DeleteStore(name string) error {
+	return ErrUnsupported
+}
+
+// Close does nothing.
+func (db *DB) Close() error {
+	return nil
+}
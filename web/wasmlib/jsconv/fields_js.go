@@ -0,0 +1,126 @@
+package jsconv
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// field describes a struct field mapped to a JavaScript property.
+type field struct {
+	name      string // JavaScript property name
+	index     []int  // Index sequence for reflect.Value.FieldByIndex
+	omitEmpty bool   // Whether zero values are left out when marshaling
+}
+
+// fieldCache caches the field list of each struct type.
+var fieldCache sync.Map // map[reflect.Type][]field
+
+// structFields returns the JavaScript-visible fields of struct type t.
+//
+// Fields are named by their `js` tag, or by the Go field name with its leading upper case run
+// lowered ("Name" → "name", "URL" → "url", "HTTPPort" → "httpPort"). A tag of "-" skips the field and
+// the "omitempty" option leaves zero values out. Untagged embedded structs are flattened; fields of the
+// outer struct take precedence over promoted fields with the same name.
+func structFields(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var fields []field
+	seen := make(map[string]bool)
+	collectFields(t, nil, seen, &fields, 0)
+
+	cached, _ := fieldCache.LoadOrStore(t, fields)
+	return cached.([]field)
+}
+
+// collectFields appends the fields of t breadth-first so that shallower fields shadow deeper ones.
+func collectFields(t reflect.Type, index []int, seen map[string]bool, fields *[]field, depth int) {
+	var embedded []reflect.StructField
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("js")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, sf)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = lowerCamel(sf.Name)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		*fields = append(*fields, field{
+			name:      name,
+			index:     append(append([]int(nil), index...), i),
+			omitEmpty: hasOption(opts, "omitempty"),
+		})
+	}
+
+	// Guard against pathological recursive embedding
+	if depth >= 8 {
+		return
+	}
+	for _, sf := range embedded {
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		collectFields(ft, append(append([]int(nil), index...), sf.Index...), seen, fields, depth+1)
+	}
+}
+
+// hasOption reports whether the comma separated tag options contain opt.
+func hasOption(opts, opt string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = strings.Cut(opts, ",")
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerCamel lowers the leading upper case run of a Go identifier, keeping the last letter of the run
+// upper case when it starts the next word.
+func lowerCamel(s string) string {
+	runes := []rune(s)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	switch {
+	case n == 0:
+		return s
+	case n == 1 || n == len(runes):
+		// "Name" → "name", "URL" → "url"
+	case unicode.IsLower(runes[n]):
+		// "HTTPPort" → "httpPort"
+		n--
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
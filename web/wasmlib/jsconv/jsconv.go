@@ -0,0 +1,16 @@
+// Package jsconv converts between Go values and JavaScript values.
+//
+// Structs map to plain objects, slices and arrays to Arrays, string-keyed maps to objects, time.Time to Date,
+// []byte to Uint8Array and slices of other fixed-size numbers to the matching typed array. Types implementing
+// Marshaler or Unmarshaler control their own conversion, and js.Value passes through unchanged.
+// Conversions need the browser; elsewhere only the errors are declared.
+package jsconv
+
+import "errors"
+
+var (
+	// ErrUnsupportedType is returned for Go types that have no JavaScript representation (channels, funcs, complex numbers).
+	ErrUnsupportedType = errors.New("jsconv: unsupported type")
+	// ErrInvalidTarget is returned when Unmarshal is given a nil or non-pointer target.
+	ErrInvalidTarget = errors.New("jsconv: target must be a non-nil pointer")
+)
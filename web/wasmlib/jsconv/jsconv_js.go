@@ -0,0 +1,528 @@
+package jsconv
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"syscall/js"
+	"time"
+	"unsafe"
+)
+
+var (
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+	// _Array is a cached reference to the JavaScript Array constructor for array operations
+	_Array = js.Global().Get("Array")
+	// _Date is a cached reference to the JavaScript Date constructor for time values
+	_Date = js.Global().Get("Date")
+	// _ArrayBuffer is a cached reference to the JavaScript ArrayBuffer constructor for binary data
+	_ArrayBuffer = js.Global().Get("ArrayBuffer")
+	// _Uint8Array is a cached reference to the JavaScript Uint8Array constructor for byte arrays
+	_Uint8Array = js.Global().Get("Uint8Array")
+)
+
+// typedArrays maps element kinds of numeric slices to the JavaScript typed array constructor with the same layout.
+var typedArrays = map[reflect.Kind]js.Value{
+	reflect.Int8:    js.Global().Get("Int8Array"),
+	reflect.Int16:   js.Global().Get("Int16Array"),
+	reflect.Int32:   js.Global().Get("Int32Array"),
+	reflect.Int64:   js.Global().Get("BigInt64Array"),
+	reflect.Uint16:  js.Global().Get("Uint16Array"),
+	reflect.Uint32:  js.Global().Get("Uint32Array"),
+	reflect.Uint64:  js.Global().Get("BigUint64Array"),
+	reflect.Float32: js.Global().Get("Float32Array"),
+	reflect.Float64: js.Global().Get("Float64Array"),
+}
+
+var (
+	marshalerType     = reflect.TypeFor[Marshaler]()
+	unmarshalerType   = reflect.TypeFor[Unmarshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	jsValueType       = reflect.TypeFor[js.Value]()
+	timeType          = reflect.TypeFor[time.Time]()
+)
+
+// Marshaler is implemented by types that convert themselves into a JavaScript value.
+type Marshaler interface {
+	MarshalJS() (js.Value, error)
+}
+
+// Unmarshaler is implemented by types that decode themselves from a JavaScript value.
+type Unmarshaler interface {
+	UnmarshalJS(js.Value) error
+}
+
+// UnmarshalTypeError describes a JavaScript value that cannot be stored in a Go value.
+type UnmarshalTypeError struct {
+	JSType js.Type      // Type of the JavaScript value
+	GoType reflect.Type // Type of the Go target
+	Path   string       // Property path to the value, e.g. "options.filters[2]"
+}
+
+// Error implements the error interface.
+func (e *UnmarshalTypeError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("jsconv: cannot unmarshal %s into Go value of type %s", e.JSType, e.GoType)
+	}
+	return fmt.Sprintf("jsconv: cannot unmarshal %s into Go value of type %s at %s", e.JSType, e.GoType, e.Path)
+}
+
+// Marshal converts v into a JavaScript value. nil pointers, slices, maps and interfaces become null.
+// Integers are converted to numbers and lose precision beyond 2^53, except in typed arrays.
+func Marshal(v interface{}) (js.Value, error) {
+	if v == nil {
+		return js.Null(), nil
+	}
+	return marshal(reflect.ValueOf(v))
+}
+
+// MustMarshal is like Marshal but panics on error. It is intended for values whose types are known to convert.
+func MustMarshal(v interface{}) js.Value {
+	value, err := Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// Unmarshal decodes a JavaScript value into the Go value pointed to by v.
+// Object properties without a matching field are ignored and fields without a matching property are left unchanged.
+// undefined leaves the target unchanged and null sets pointers, slices, maps and interfaces to nil.
+func Unmarshal(value js.Value, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return ErrInvalidTarget
+	}
+	return unmarshal(value, rv.Elem(), "")
+}
+
+// To decodes a JavaScript value into a new value of type T.
+func To[T any](value js.Value) (T, error) {
+	var v T
+	err := Unmarshal(value, &v)
+	return v, err
+}
+
+// marshal converts a reflected Go value.
+func marshal(rv reflect.Value) (js.Value, error) {
+	if !rv.IsValid() {
+		return js.Null(), nil
+	}
+
+	t := rv.Type()
+	switch {
+	case t == jsValueType:
+		return rv.Interface().(js.Value), nil
+	case t == timeType:
+		tm := rv.Interface().(time.Time)
+		if tm.IsZero() {
+			return js.Null(), nil
+		}
+		return _Date.New(float64(tm.UnixMilli())), nil
+	case t.Implements(marshalerType):
+		if t.Kind() == reflect.Pointer && rv.IsNil() {
+			return js.Null(), nil
+		}
+		return rv.Interface().(Marshaler).MarshalJS()
+	case rv.CanAddr() && reflect.PointerTo(t).Implements(marshalerType):
+		return rv.Addr().Interface().(Marshaler).MarshalJS()
+	case t.Kind() != reflect.String && t.Implements(textMarshalerType):
+		if t.Kind() == reflect.Pointer && rv.IsNil() {
+			return js.Null(), nil
+		}
+		text, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return js.Undefined(), err
+		}
+		return js.ValueOf(string(text)), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return js.ValueOf(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return js.ValueOf(float64(rv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return js.ValueOf(float64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return js.ValueOf(rv.Float()), nil
+	case reflect.String:
+		return js.ValueOf(rv.String()), nil
+
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return js.Null(), nil
+		}
+		return marshal(rv.Elem())
+
+	case reflect.Slice:
+		if rv.IsNil() {
+			return js.Null(), nil
+		}
+		fallthrough
+	case reflect.Array:
+		if arr, ok := marshalTypedArray(rv); ok {
+			return arr, nil
+		}
+		arr := _Array.New(rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			elem, err := marshal(rv.Index(i))
+			if err != nil {
+				return js.Undefined(), fmt.Errorf("[%d]: %w", i, err)
+			}
+			arr.SetIndex(i, elem)
+		}
+		return arr, nil
+
+	case reflect.Map:
+		if rv.IsNil() {
+			return js.Null(), nil
+		}
+		if t.Key().Kind() != reflect.String {
+			return js.Undefined(), fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+		}
+		obj := _Object.New()
+		iter := rv.MapRange()
+		for iter.Next() {
+			elem, err := marshal(iter.Value())
+			if err != nil {
+				return js.Undefined(), fmt.Errorf("%s: %w", iter.Key().String(), err)
+			}
+			obj.Set(iter.Key().String(), elem)
+		}
+		return obj, nil
+
+	case reflect.Struct:
+		obj := _Object.New()
+		for _, f := range structFields(t) {
+			fv, ok := fieldByIndex(rv, f.index, false)
+			if !ok || (f.omitEmpty && fv.IsZero()) {
+				continue
+			}
+			elem, err := marshal(fv)
+			if err != nil {
+				return js.Undefined(), fmt.Errorf("%s: %w", f.name, err)
+			}
+			obj.Set(f.name, elem)
+		}
+		return obj, nil
+	}
+
+	return js.Undefined(), fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+}
+
+// marshalTypedArray converts slices and arrays of fixed-size numbers into typed arrays.
+func marshalTypedArray(rv reflect.Value) (js.Value, bool) {
+	kind := rv.Type().Elem().Kind()
+	if kind == reflect.Uint8 {
+		arr := _Uint8Array.New(rv.Len())
+		js.CopyBytesToJS(arr, bytesOf(rv))
+		return arr, true
+	}
+
+	ctor, ok := typedArrays[kind]
+	if !ok || ctor.IsUndefined() {
+		return js.Value{}, false
+	}
+	b := bytesOf(rv)
+	buf := _Uint8Array.New(len(b))
+	js.CopyBytesToJS(buf, b)
+	return ctor.New(buf.Get("buffer")), true
+}
+
+// unmarshal decodes value into the settable rv. path locates the value for error messages.
+func unmarshal(value js.Value, rv reflect.Value, path string) error {
+	if value.IsUndefined() {
+		return nil
+	}
+
+	t := rv.Type()
+	switch {
+	case t == jsValueType:
+		rv.Set(reflect.ValueOf(value))
+		return nil
+	case reflect.PointerTo(t).Implements(unmarshalerType):
+		return rv.Addr().Interface().(Unmarshaler).UnmarshalJS(value)
+	}
+
+	if value.IsNull() {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			rv.SetZero()
+		}
+		if t == timeType {
+			rv.SetZero()
+		}
+		return nil
+	}
+
+	if t == timeType {
+		switch {
+		case value.Type() == js.TypeObject && value.InstanceOf(_Date):
+			rv.Set(reflect.ValueOf(time.UnixMilli(int64(value.Call("getTime").Float()))))
+			return nil
+		case value.Type() == js.TypeNumber:
+			rv.Set(reflect.ValueOf(time.UnixMilli(int64(value.Float()))))
+			return nil
+		case value.Type() == js.TypeString:
+			tm, err := time.Parse(time.RFC3339Nano, value.String())
+			if err != nil {
+				return fmt.Errorf("jsconv: %s: %w", path, err)
+			}
+			rv.Set(reflect.ValueOf(tm))
+			return nil
+		}
+		return typeError(value, t, path)
+	}
+
+	if t.Kind() != reflect.String && reflect.PointerTo(t).Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+		if value.Type() != js.TypeString {
+			return typeError(value, t, path)
+		}
+		return rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value.String()))
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if value.Type() != js.TypeBoolean {
+			return typeError(value, t, path)
+		}
+		rv.SetBool(value.Bool())
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value.Type() != js.TypeNumber {
+			return typeError(value, t, path)
+		}
+		f := value.Float()
+		if f != math.Trunc(f) || rv.OverflowInt(int64(f)) || math.Abs(f) > 1<<63 {
+			return typeError(value, t, path)
+		}
+		rv.SetInt(int64(f))
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if value.Type() != js.TypeNumber {
+			return typeError(value, t, path)
+		}
+		f := value.Float()
+		if f != math.Trunc(f) || f < 0 || f >= 1<<64 || rv.OverflowUint(uint64(f)) {
+			return typeError(value, t, path)
+		}
+		rv.SetUint(uint64(f))
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		if value.Type() != js.TypeNumber {
+			return typeError(value, t, path)
+		}
+		rv.SetFloat(value.Float())
+		return nil
+
+	case reflect.String:
+		if value.Type() != js.TypeString {
+			return typeError(value, t, path)
+		}
+		rv.SetString(value.String())
+		return nil
+
+	case reflect.Pointer:
+		if rv.IsNil() {
+			rv.Set(reflect.New(t.Elem()))
+		}
+		return unmarshal(value, rv.Elem(), path)
+
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			return typeError(value, t, path)
+		}
+		rv.Set(reflect.ValueOf(toInterface(value)))
+		return nil
+
+	case reflect.Slice, reflect.Array:
+		return unmarshalList(value, rv, path)
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String || value.Type() != js.TypeObject {
+			return typeError(value, t, path)
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMap(t))
+		}
+		keys := _Object.Call("keys", value)
+		for i := 0; i < keys.Length(); i++ {
+			key := keys.Index(i).String()
+			elem := reflect.New(t.Elem()).Elem()
+			if err := unmarshal(value.Get(key), elem, path+"."+key); err != nil {
+				return err
+			}
+			rv.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), elem)
+		}
+		return nil
+
+	case reflect.Struct:
+		if value.Type() != js.TypeObject {
+			return typeError(value, t, path)
+		}
+		for _, f := range structFields(t) {
+			prop := value.Get(f.name)
+			if prop.IsUndefined() {
+				continue
+			}
+			fv, _ := fieldByIndex(rv, f.index, true)
+			if err := unmarshal(prop, fv, joinPath(path, f.name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+}
+
+// unmarshalList decodes an Array or typed array into a slice or array.
+func unmarshalList(value js.Value, rv reflect.Value, path string) error {
+	t := rv.Type()
+	if value.Type() != js.TypeObject || value.Get("length").Type() != js.TypeNumber {
+		return typeError(value, t, path)
+	}
+	n := value.Length()
+
+	if t.Kind() == reflect.Slice {
+		rv.Set(reflect.MakeSlice(t, n, n))
+	} else if n > rv.Len() {
+		return typeError(value, t, path)
+	}
+
+	// Copy typed arrays with a matching element layout in bulk
+	if unmarshalTypedArray(value, rv) {
+		return nil
+	}
+
+	for i := 0; i < n; i++ {
+		if err := unmarshal(value.Index(i), rv.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unmarshalTypedArray copies a typed array into a slice or array of the same element layout.
+func unmarshalTypedArray(value js.Value, rv reflect.Value) bool {
+	if rv.Len() == 0 {
+		return false
+	}
+	kind := rv.Type().Elem().Kind()
+	ctor := _Uint8Array
+	if kind != reflect.Uint8 {
+		var ok bool
+		if ctor, ok = typedArrays[kind]; !ok || ctor.IsUndefined() {
+			return false
+		}
+	}
+	if !value.InstanceOf(ctor) {
+		return false
+	}
+
+	b := bytesOf(rv)
+	view := _Uint8Array.New(value.Get("buffer"), value.Get("byteOffset"), value.Get("byteLength"))
+	js.CopyBytesToGo(b, view)
+	return true
+}
+
+// toInterface converts a JavaScript value into its generic Go form: nil, bool, float64, string, time.Time,
+// []byte for Uint8Array and ArrayBuffer, []interface{} for arrays and map[string]interface{} for objects.
+func toInterface(value js.Value) interface{} {
+	switch value.Type() {
+	case js.TypeBoolean:
+		return value.Bool()
+	case js.TypeNumber:
+		return value.Float()
+	case js.TypeString:
+		return value.String()
+	case js.TypeObject:
+		switch {
+		case value.InstanceOf(_Date):
+			return time.UnixMilli(int64(value.Call("getTime").Float()))
+		case value.InstanceOf(_Uint8Array):
+			b := make([]byte, value.Length())
+			js.CopyBytesToGo(b, value)
+			return b
+		case value.InstanceOf(_ArrayBuffer):
+			view := _Uint8Array.New(value)
+			b := make([]byte, view.Length())
+			js.CopyBytesToGo(b, view)
+			return b
+		case _Array.Call("isArray", value).Bool():
+			list := make([]interface{}, value.Length())
+			for i := range list {
+				list[i] = toInterface(value.Index(i))
+			}
+			return list
+		}
+		obj := make(map[string]interface{})
+		keys := _Object.Call("keys", value)
+		for i := 0; i < keys.Length(); i++ {
+			key := keys.Index(i).String()
+			obj[key] = toInterface(value.Get(key))
+		}
+		return obj
+	case js.TypeFunction, js.TypeSymbol:
+		return value
+	}
+	return nil
+}
+
+// fieldByIndex walks an index sequence, allocating nil embedded pointers when alloc is set.
+// It reports false if a nil embedded pointer was found and alloc is unset.
+func fieldByIndex(rv reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+// bytesOf returns the memory of a slice or array of fixed-size numbers as bytes.
+// Arrays that are not addressable are copied first.
+func bytesOf(rv reflect.Value) []byte {
+	if rv.Kind() == reflect.Array && !rv.CanAddr() {
+		tmp := reflect.New(rv.Type()).Elem()
+		tmp.Set(rv)
+		rv = tmp
+	}
+	n := rv.Len() * int(rv.Type().Elem().Size())
+	if n == 0 {
+		return nil
+	}
+	var ptr unsafe.Pointer
+	if rv.Kind() == reflect.Slice {
+		ptr = rv.UnsafePointer()
+	} else {
+		ptr = rv.Addr().UnsafePointer()
+	}
+	return unsafe.Slice((*byte)(ptr), n)
+}
+
+// typeError builds an UnmarshalTypeError.
+func typeError(value js.Value, t reflect.Type, path string) error {
+	return &UnmarshalTypeError{JSType: value.Type(), GoType: t, Path: path}
+}
+
+// joinPath appends a property name to a path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
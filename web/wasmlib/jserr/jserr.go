@@ -0,0 +1,99 @@
+// Package jserr is the error model shared by the wasmlib packages. Every failure belongs to one of a few
+// classes — network, timeout, aborted, protocol and quota — matched with errors.Is, whether the failure was
+// reported by the browser or by Go code:
+//
+//	if errors.Is(err, jserr.ErrNetwork) {
+//		// retry later
+//	}
+//
+// The sentinel errors of the packages match their class, and JavaScript exceptions and rejections, see
+// promisejs.Error, match the class of their error name, e.g. a QuotaExceededError matches ErrQuota. The
+// originating JavaScript error stays in the chain and is found with errors.As.
+package jserr
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrNetwork is the class of failures to reach a peer or server, or of connections lost midway
+	ErrNetwork = errors.New("network error")
+	// ErrTimeout is the class of operations that took longer than allowed
+	ErrTimeout = errors.New("timed out")
+	// ErrAborted is the class of operations cancelled before completion
+	ErrAborted = errors.New("aborted")
+	// ErrProtocol is the class of malformed or unexpected data received from a peer
+	ErrProtocol = errors.New("protocol error")
+	// ErrQuota is the class of operations exceeding a storage or resource quota
+	ErrQuota = errors.New("quota exceeded")
+)
+
+// classes maps JavaScript error names to failure classes
+var classes = map[string]error{
+	"NetworkError":       ErrNetwork,
+	"WebTransportError":  ErrNetwork,
+	"TimeoutError":       ErrTimeout,
+	"AbortError":         ErrAborted,
+	"DataError":          ErrProtocol,
+	"EncodingError":      ErrProtocol,
+	"QuotaExceededError": ErrQuota,
+}
+
+// names maps failure classes to the JavaScript error names they are reported with
+var names = map[error]string{
+	ErrNetwork:  "NetworkError",
+	ErrTimeout:  "TimeoutError",
+	ErrAborted:  "AbortError",
+	ErrProtocol: "DataError",
+	ErrQuota:    "QuotaExceededError",
+}
+
+// sentinel is an error of a failure class.
+type sentinel struct {
+	text  string
+	class error
+}
+
+// Error returns the text of the error.
+func (e *sentinel) Error() string {
+	return e.text
+}
+
+// Unwrap returns the class of the error.
+func (e *sentinel) Unwrap() error {
+	return e.class
+}
+
+// New returns an error with the given text that matches class, for declaring the sentinel errors of a package.
+func New(class error, text string) error {
+	return &sentinel{text: text, class: class}
+}
+
+// ForName returns the failure class of a JavaScript error name, e.g. ErrAborted for "AbortError", or nil.
+func ForName(name string) error {
+	return classes[name]
+}
+
+// Name returns the JavaScript error name of the failure class of err, e.g. "AbortError" for errors matching
+// ErrAborted, or the empty string.
+func Name(err error) string {
+	return names[Class(err)]
+}
+
+// Class returns the failure class of err, or nil if it has none. The errors of package context are classified
+// as well: context.DeadlineExceeded as ErrTimeout and context.Canceled as ErrAborted.
+func Class(err error) error {
+	for _, class := range []error{ErrNetwork, ErrTimeout, ErrAborted, ErrProtocol, ErrQuota} {
+		if errors.Is(err, class) {
+			return class
+		}
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrAborted
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Package jsguard holds the debug facilities of the wasmlib packages: it detects blocking calls made from
+// JavaScript callbacks and tracks callbacks and event listeners to find leaks.
+//
+// A callback created with js.FuncOf runs on the JavaScript event loop, which stays suspended until it
+// returns, so a callback waiting for a response, message or stream chunk waits for an event that can never
+// be delivered and the program deadlocks.
+//
+// Detection is off by default, as it inspects the stack of every blocking call. A debug build turns it on
+// with
+//
+//	jsguard.SetMode(jsguard.Panic)
+//
+// after which Request.Do, NextMessage, stream reads and promise awaits of the wasmlib packages fail
+// loudly when called from a callback. Callbacks use their Async variants instead, which start the call and
+// return a channel delivering its Result to a goroutine:
+//
+//	results := conn.NextMessageAsync(ctx) // in the callback
+//	go func() {
+//		r := <-results
+//		...
+//	}()
+//
+// The wasmlib packages create callbacks with FuncOf and register listeners with AddEventListener. With
+// Track(true) every live one is recorded with the stack that created it, to be listed by Live or checked by
+// tests:
+//
+//	func TestMain(m *testing.M) {
+//		jsguard.Track(true)
+//		os.Exit(m.Run())
+//	}
+//
+//	func TestDial(t *testing.T) {
+//		defer jsguard.VerifyNone(t, jsguard.Mark())
+//		...
+//	}
+package jsguard
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"pkg.gfire.dev/supernet/logging"
+)
+
+// Mode selects what happens when a blocking call is made from a JavaScript callback.
+type Mode int32
+
+const (
+	// Off skips detection
+	Off Mode = iota
+	// Log logs the call with its stack, once per operation, and lets it block
+	Log
+	// Panic panics with the diagnostic instead of deadlocking
+	Panic
+)
+
+// callbackFrame is the frame of the syscall/js function calling the Go function of a js.Func
+const callbackFrame = "syscall/js.handleEvent("
+
+var (
+	// mode holds the current Mode
+	mode atomic.Int32
+	// reported holds the operations already logged in Log mode
+	reported sync.Map // map[string]struct{}
+	// logger receives the diagnostics of Log mode
+	logger = logging.For("jsguard")
+)
+
+// SetMode sets what happens when a blocking call is made from a JavaScript callback.
+func SetMode(m Mode) {
+	mode.Store(int32(m))
+}
+
+// Check reports op, a blocking operation about to wait, if it is called from a JavaScript callback. It is
+// called by the blocking functions of the wasmlib packages and costs an atomic load when detection is off.
+func Check(op string) {
+	m := Mode(mode.Load())
+	if m == Off {
+		return
+	}
+	buf := make([]byte, 16<<10)
+	stack := buf[:runtime.Stack(buf, false)]
+	if !bytes.Contains(stack, []byte(callbackFrame)) {
+		return
+	}
+
+	msg := fmt.Sprintf("jsguard: %s blocks in a JavaScript callback, which deadlocks the event loop; "+
+		"call it from a goroutine or use its Async variant", op)
+	if m == Panic {
+		panic(msg + "\n\n" + string(stack))
+	}
+	if _, dup := reported.LoadOrStore(op, struct{}{}); !dup {
+		logger.Error(msg, "stack", string(stack))
+	}
+}
+
+// Result is the outcome of an asynchronous call.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// Go calls fn in a new goroutine and returns a channel delivering its result. The channel is buffered, so
+// the goroutine ends even if the result is never received.
+func Go[T any](fn func() (T, error)) <-chan Result[T] {
+	ch := make(chan Result[T], 1)
+	go func() {
+		v, err := fn()
+		ch <- Result[T]{Value: v, Err: err}
+	}()
+	return ch
+}
@@ -0,0 +1,196 @@
+package jsguard
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// leakTimeout bounds how long VerifyNone waits for resources released asynchronously, e.g. after a close event
+const leakTimeout = time.Second
+
+// Kind is the kind of a tracked resource.
+type Kind int
+
+const (
+	// Func is a callback created with FuncOf and not yet released
+	Func Kind = iota
+	// Listener is an event listener registered with AddEventListener, live until it is removed or its
+	// callback is released
+	Listener
+)
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	if k == Listener {
+		return "listener"
+	}
+	return "func"
+}
+
+// Resource is a tracked callback or event listener.
+type Resource struct {
+	// Seq orders resources by creation; resources created after a Mark have a greater Seq
+	Seq uint64
+	// Kind is the kind of the resource
+	Kind Kind
+	// Type is the event type of a listener
+	Type string
+	// Stack is the stack of the call creating the resource
+	Stack string
+
+	// fn is the Seq of the callback of a listener
+	fn uint64
+}
+
+// String describes the resource and where it was created.
+func (r Resource) String() string {
+	if r.Kind == Listener {
+		return fmt.Sprintf("%s %q #%d created at\n%s", r.Kind, r.Type, r.Seq, r.Stack)
+	}
+	return fmt.Sprintf("%s #%d created at\n%s", r.Kind, r.Seq, r.Stack)
+}
+
+var (
+	// tracking is set while resources are tracked
+	tracking atomic.Bool
+	// trackMu protects the variables below
+	trackMu sync.Mutex
+	// seq is the Seq of the last tracked resource
+	seq uint64
+	// live holds the live resources by Seq
+	live = make(map[uint64]Resource)
+)
+
+// Track turns tracking of callbacks and event listeners on or off. Tracking records the stack of every
+// FuncOf and AddEventListener of the wasmlib packages, so it is meant for tests and debug builds. Resources
+// created while tracking was off are never reported.
+func Track(enabled bool) {
+	tracking.Store(enabled)
+}
+
+// Counts returns the number of live callbacks and event listeners.
+func Counts() (funcs, listeners int) {
+	trackMu.Lock()
+	defer trackMu.Unlock()
+	for _, r := range live {
+		if r.Kind == Listener {
+			listeners++
+		} else {
+			funcs++
+		}
+	}
+	return funcs, listeners
+}
+
+// Live returns the live resources in creation order.
+func Live() []Resource {
+	return Since(0)
+}
+
+// Mark returns a mark for Since and VerifyNone: resources created from now on are reported.
+func Mark() uint64 {
+	trackMu.Lock()
+	defer trackMu.Unlock()
+	return seq
+}
+
+// Since returns the live resources created after mark, in creation order.
+func Since(mark uint64) []Resource {
+	trackMu.Lock()
+	defer trackMu.Unlock()
+	var rs []Resource
+	for _, r := range live {
+		if r.Seq > mark {
+			rs = append(rs, r)
+		}
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Seq < rs[j].Seq })
+	return rs
+}
+
+// TB is the part of testing.TB used by VerifyNone.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// VerifyNone fails t for every resource created after mark that is still live, waiting up to a second for
+// resources released asynchronously:
+//
+//	mark := jsguard.Mark()
+//	defer jsguard.VerifyNone(t, mark)
+//
+// Tracking must be on while the resources are created, e.g. by calling Track(true) in TestMain.
+func VerifyNone(t TB, mark uint64) {
+	t.Helper()
+	deadline := time.Now().Add(leakTimeout)
+	leaked := Since(mark)
+	for len(leaked) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		leaked = Since(mark)
+	}
+	for _, r := range leaked {
+		t.Errorf("jsguard: leaked %s", r)
+	}
+}
+
+// track records a new resource and returns its Seq, or 0 if tracking is off.
+func track(kind Kind, typ string, fn uint64) uint64 {
+	if !tracking.Load() {
+		return 0
+	}
+	stack := callers()
+	trackMu.Lock()
+	defer trackMu.Unlock()
+	seq++
+	live[seq] = Resource{Seq: seq, Kind: kind, Type: typ, Stack: stack, fn: fn}
+	return seq
+}
+
+// untrackFunc forgets the callback with the given Seq and the listeners it was registered as.
+func untrackFunc(fn uint64) {
+	trackMu.Lock()
+	defer trackMu.Unlock()
+	delete(live, fn)
+	for s, r := range live {
+		if r.Kind == Listener && r.fn == fn {
+			delete(live, s)
+		}
+	}
+}
+
+// untrackListener forgets one listener of type typ registered with the callback with the given Seq.
+func untrackListener(typ string, fn uint64) {
+	trackMu.Lock()
+	defer trackMu.Unlock()
+	for s, r := range live {
+		if r.Kind == Listener && r.fn == fn && r.Type == typ {
+			delete(live, s)
+			return
+		}
+	}
+}
+
+// callers formats the stack of the caller of the exported jsguard function, one "function\n\tfile:line"
+// pair per frame like a goroutine trace.
+func callers() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "pkg.gfire.dev/supernet/web/wasmlib/jsguard.") {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package jsguard
+
+import "syscall/js"
+
+// idProperty is the property of tracked JavaScript functions holding their Seq
+const idProperty = "__jsguardSeq"
+
+// FuncOf is js.FuncOf, tracked while tracking is on. Callbacks created with it must be released with
+// Release.
+func FuncOf(fn func(this js.Value, args []js.Value) any) js.Func {
+	f := js.FuncOf(fn)
+	if s := track(Func, "", 0); s != 0 {
+		f.Set(idProperty, s)
+	}
+	return f
+}
+
+// Release releases f like f.Release and forgets it together with the listeners it was registered as.
+func Release(f js.Func) {
+	if s := seqOf(f); s != 0 {
+		untrackFunc(s)
+	}
+	f.Release()
+}
+
+// AddEventListener registers f as a listener for events of type typ on target, passing the optional
+// capture flag or options object on to addEventListener. The listener is tracked until it is removed with
+// RemoveEventListener or f is released.
+func AddEventListener(target js.Value, typ string, f js.Func, opts ...any) {
+	target.Call("addEventListener", append([]any{typ, f}, opts...)...)
+	if s := seqOf(f); s != 0 {
+		track(Listener, typ, s)
+	}
+}
+
+// RemoveEventListener removes a listener registered with AddEventListener, passing the optional capture
+// flag on to removeEventListener.
+func RemoveEventListener(target js.Value, typ string, f js.Func, opts ...any) {
+	target.Call("removeEventListener", append([]any{typ, f}, opts...)...)
+	if s := seqOf(f); s != 0 {
+		untrackListener(typ, s)
+	}
+}
+
+// seqOf returns the Seq of a tracked callback, or 0.
+func seqOf(f js.Func) uint64 {
+	v := f.Get(idProperty)
+	if v.Type() != js.TypeNumber {
+		return 0
+	}
+	return uint64(v.Float())
+}
@@ -0,0 +1,73 @@
+// Package leaderjs elects a single leader among the tabs and workers of an origin, so that singleton work
+// such as holding a connection or running background sync happens exactly once. It uses the Web Locks API
+// where available and falls back to a heartbeat protocol over BroadcastChannel. Outside the browser the
+// leader is elected among the goroutines of the process.
+package leaderjs
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrUnsupported is returned when neither Web Locks nor BroadcastChannel is available
+var ErrUnsupported = errors.New("leader election not supported")
+
+// Lease is held by the elected leader.
+type Lease struct {
+	// done is closed when leadership ends
+	done chan struct{}
+	// release gives up leadership
+	release   func()
+	closeOnce sync.Once
+}
+
+// newLease creates a lease that runs release when it ends.
+func newLease(release func()) *Lease {
+	return &Lease{done: make(chan struct{}), release: release}
+}
+
+// Done returns a channel that is closed when leadership is lost or released.
+func (l *Lease) Done() <-chan struct{} {
+	return l.done
+}
+
+// Release gives up leadership, letting another tab take over. Safe to call multiple times.
+func (l *Lease) Release() {
+	l.end()
+}
+
+// end closes the lease.
+func (l *Lease) end() {
+	l.closeOnce.Do(func() {
+		close(l.done)
+		l.release()
+	})
+}
+
+// Run repeatedly campaigns for name and calls fn while leader, with a context that is cancelled when
+// leadership ends. It returns when ctx is done.
+func Run(ctx context.Context, name string, fn func(ctx context.Context)) error {
+	for {
+		lease, err := Campaign(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		leaderCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-lease.Done():
+			case <-leaderCtx.Done():
+			}
+			cancel()
+		}()
+		fn(leaderCtx)
+		cancel()
+		lease.Release()
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
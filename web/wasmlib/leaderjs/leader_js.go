@@ -0,0 +1,222 @@
+package leaderjs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"syscall/js"
+	"time"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/caps"
+	"pkg.gfire.dev/supernet/web/wasmlib/eventjs"
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+var (
+	// _global is a cached reference to the global scope (window or worker)
+	_global = js.Global()
+	// _BroadcastChannel is a cached reference to the JavaScript BroadcastChannel constructor
+	_BroadcastChannel = js.Global().Get("BroadcastChannel")
+	// _AbortController is a cached reference to the JavaScript AbortController constructor
+	_AbortController = js.Global().Get("AbortController")
+	// _Promise is a cached reference to the JavaScript Promise constructor for holding locks
+	_Promise = js.Global().Get("Promise")
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+)
+
+const (
+	// heartbeatInterval is how often a fallback leader announces itself
+	heartbeatInterval = time.Second
+	// leaderTimeout is how long followers wait without a heartbeat before campaigning
+	leaderTimeout = 3 * heartbeatInterval
+	// claimWait is how long a candidate waits for competing claims before assuming leadership
+	claimWait = 500 * time.Millisecond
+)
+
+// Campaign blocks until the caller becomes leader for name, or ctx is done.
+// Leadership is lost when the tab closes, the lease is released or, with the fallback, when another
+// leader is discovered after a network partition between tabs (which cannot happen with Web Locks).
+func Campaign(ctx context.Context, name string) (*Lease, error) {
+	if caps.Has(caps.WebLocks) {
+		return campaignLocks(ctx, name)
+	}
+	if caps.Has(caps.BroadcastChannel) {
+		return campaignBroadcast(ctx, name)
+	}
+	return nil, ErrUnsupported
+}
+
+// campaignLocks acquires an exclusive Web Lock named name and holds it until the lease ends.
+func campaignLocks(ctx context.Context, name string) (*Lease, error) {
+	controller := _AbortController.New()
+	acquired := make(chan struct{})
+
+	// The lock is held for as long as the promise returned from the callback is pending
+	var releaseHold js.Value
+	executor := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		releaseHold = args[0]
+		return nil
+	})
+	hold := _Promise.New(executor)
+	jsguard.Release(executor)
+	onGranted := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		close(acquired)
+		return hold
+	})
+
+	opts := _Object.New()
+	opts.Set("mode", "exclusive")
+	opts.Set("signal", controller.Get("signal"))
+	request := _global.Get("navigator").Get("locks").Call("request", name, opts, onGranted)
+
+	settled := make(chan error, 1)
+	promisejs.Then(request, func(_ js.Value, err error) {
+		jsguard.Release(onGranted)
+		settled <- err
+	})
+
+	select {
+	case <-acquired:
+	case err := <-settled:
+		return nil, err
+	case <-ctx.Done():
+		controller.Call("abort")
+		// The lock may have been granted concurrently with the abort, in which case it must be handed back
+		select {
+		case <-acquired:
+			releaseHold.Invoke()
+		case <-settled:
+		}
+		return nil, ctx.Err()
+	}
+
+	lease := newLease(func() {
+		releaseHold.Invoke()
+	})
+	go func() {
+		// The request settles once the hold is released or the lock was stolen
+		<-settled
+		lease.end()
+	}()
+	return lease, nil
+}
+
+// Fallback protocol message types.
+const (
+	msgHeartbeat = "heartbeat"
+	msgClaim     = "claim"
+	msgResign    = "resign"
+)
+
+// campaignBroadcast runs the heartbeat protocol over a BroadcastChannel. Conflicts are resolved in favour of
+// the lower candidate ID.
+func campaignBroadcast(ctx context.Context, name string) (*Lease, error) {
+	channel := _BroadcastChannel.New("supernet-leader:" + name)
+	messages, err := eventjs.Chan(channel, "message", 64, eventjs.Options{})
+	if err != nil {
+		channel.Call("close")
+		return nil, err
+	}
+	cleanup := func() {
+		messages.Close()
+		channel.Call("close")
+	}
+
+	self := randomID()
+	post := func(typ string) {
+		msg := _Object.New()
+		msg.Set("type", typ)
+		msg.Set("id", self)
+		channel.Call("postMessage", msg)
+	}
+
+	// Follow until the current leader goes quiet, then claim and wait for objections
+	timer := time.NewTimer(leaderTimeout)
+	defer timer.Stop()
+	claiming := false
+	for {
+		select {
+		case <-ctx.Done():
+			cleanup()
+			return nil, ctx.Err()
+
+		case event := <-messages.C:
+			typ, id := event.Get("data").Get("type").String(), event.Get("data").Get("id").String()
+			switch {
+			case typ == msgHeartbeat, typ == msgClaim && id < self:
+				claiming = false
+				timer.Reset(leaderTimeout)
+			case typ == msgResign:
+				// Campaign immediately instead of waiting for the timeout
+				timer.Reset(0)
+			}
+			continue
+
+		case <-timer.C:
+		}
+
+		if !claiming {
+			claiming = true
+			post(msgClaim)
+			timer.Reset(claimWait)
+			continue
+		}
+		break
+	}
+
+	leadCtx, stop := context.WithCancel(context.Background())
+	lease := newLease(func() {
+		stop()
+		post(msgResign)
+		cleanup()
+	})
+	post(msgHeartbeat)
+
+	// Resign on page unload so followers take over without waiting for the timeout
+	unload, _ := eventjs.Listen(_global, "pagehide", func(js.Value) {
+		lease.end()
+	}, eventjs.Options{Once: true})
+
+	go func() {
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		defer unload.Close()
+
+		for {
+			select {
+			case <-leadCtx.Done():
+				return
+			case <-ticker.C:
+				post(msgHeartbeat)
+			case event, ok := <-messages.C:
+				if !ok {
+					return
+				}
+				typ, id := event.Get("data").Get("type").String(), event.Get("data").Get("id").String()
+				if typ == msgHeartbeat && id < self {
+					// Another leader with priority exists, step down silently
+					stop()
+					lease.closeOnce.Do(func() {
+						close(lease.done)
+						cleanup()
+					})
+					return
+				}
+				if typ == msgClaim {
+					// Answer claims right away so the candidate backs off
+					post(msgHeartbeat)
+				}
+			}
+		}
+	}()
+	return lease, nil
+}
+
+// randomID returns a random candidate ID.
+func randomID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
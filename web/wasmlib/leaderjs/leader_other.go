@@ -0,0 +1,36 @@
+//go:build !js
+
+package leaderjs
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	// locksMu protects locks
+	locksMu sync.Mutex
+	// locks holds a semaphore per election name, held by the leader
+	locks = make(map[string]chan struct{})
+)
+
+// Campaign blocks until the caller becomes leader for name among the goroutines of the process, or ctx
+// is done. Leadership is lost when the lease is released.
+func Campaign(ctx context.Context, name string) (*Lease, error) {
+	locksMu.Lock()
+	lock, ok := locks[name]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		locks[name] = lock
+	}
+	locksMu.Unlock()
+
+	select {
+	case lock <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return newLease(func() {
+		<-lock
+	}), nil
+}
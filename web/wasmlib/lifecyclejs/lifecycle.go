@@ -0,0 +1,198 @@
+// Package lifecyclejs tracks the Page Lifecycle state of the current document and coordinates connection
+// components around it: heartbeats and timers are suspended while the page is frozen, state is persisted
+// before the page may be discarded, and components resume with knowledge of how long they were asleep
+// instead of treating the gap as a dead peer. Outside the browser the state stays Active and wake locks are
+// unsupported.
+package lifecyclejs
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnsupported is returned when the Screen Wake Lock API is not available
+	ErrUnsupported = errors.New("wake lock not supported")
+	// ErrClosed is returned when using a closed manager
+	ErrClosed = errors.New("lifecycle manager closed")
+)
+
+// State is a Page Lifecycle state.
+type State int
+
+const (
+	// Active pages are visible and have input focus.
+	Active State = iota
+	// Passive pages are visible without input focus.
+	Passive
+	// Hidden pages are not visible but may still run.
+	Hidden
+	// Frozen pages have their tasks suspended by the browser, e.g. in the back/forward cache.
+	Frozen
+	// Terminated pages are being unloaded.
+	Terminated
+)
+
+// String returns the lower case state name.
+func (s State) String() string {
+	switch s {
+	case Active:
+		return "active"
+	case Passive:
+		return "passive"
+	case Hidden:
+		return "hidden"
+	case Frozen:
+		return "frozen"
+	case Terminated:
+		return "terminated"
+	default:
+		return "unknown"
+	}
+}
+
+// Transition describes a lifecycle state change.
+type Transition struct {
+	From State     // Previous state
+	To   State     // New state
+	At   time.Time // Time the change was observed
+}
+
+// Participant is a component that reacts to the page being frozen and resumed, such as a transport with
+// keepalive timers.
+type Participant interface {
+	// Suspend is called before the page is frozen. Heartbeats and timers should be stopped.
+	Suspend()
+	// Resume is called after the page was resumed with the time it spent suspended. Connections should be
+	// probed rather than assumed dead.
+	Resume(suspended time.Duration)
+}
+
+// Persister is implemented by participants that save state which must survive the page being discarded.
+type Persister interface {
+	// Persist saves state. It is called when the page is hidden, frozen or unloaded, and must be quick.
+	Persist()
+}
+
+// Manager observes the page lifecycle and drives registered participants.
+type Manager struct {
+	binding
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// state is the current lifecycle state
+	state State
+	// suspendedAt is the time the page was frozen, zero while running
+	suspendedAt time.Time
+	// participants are notified of freeze and resume
+	participants map[*registration]struct{}
+	// subscribers receive transitions
+	subscribers map[chan Transition]struct{}
+	// closed is set once Close was called
+	closed bool
+}
+
+// registration wraps a participant so the same value can be registered more than once.
+type registration struct {
+	p Participant
+}
+
+// State returns the current lifecycle state.
+func (m *Manager) State() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// Register adds a participant and returns a function that removes it. If p also implements Persister, it
+// is asked to persist whenever the page may be discarded.
+func (m *Manager) Register(p Participant) (unregister func()) {
+	r := &registration{p: p}
+
+	m.mu.Lock()
+	m.participants[r] = struct{}{}
+	m.mu.Unlock()
+
+	return func() {
+		m.mu.Lock()
+		delete(m.participants, r)
+		m.mu.Unlock()
+	}
+}
+
+// Subscribe returns a channel receiving state transitions and a function to unsubscribe.
+// Transitions are dropped if the channel buffer is full.
+func (m *Manager) Subscribe(buffer int) (<-chan Transition, func()) {
+	ch := make(chan Transition, buffer)
+
+	m.mu.Lock()
+	m.subscribers[ch] = struct{}{}
+	m.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			m.mu.Lock()
+			delete(m.subscribers, ch)
+			m.mu.Unlock()
+		})
+	}
+}
+
+// transition moves to a new state and notifies participants and subscribers.
+// Participant callbacks run synchronously inside the event handler, because the browser freezes the page
+// as soon as the freeze and pagehide handlers return.
+func (m *Manager) transition(to State) {
+	m.mu.Lock()
+	from := m.state
+	if m.closed || from == to {
+		m.mu.Unlock()
+		return
+	}
+	m.state = to
+	now := time.Now()
+
+	var suspended time.Duration
+	suspending := to >= Frozen && from < Frozen
+	resuming := from == Frozen && to < Frozen
+	if suspending {
+		m.suspendedAt = now
+	}
+	if resuming && !m.suspendedAt.IsZero() {
+		suspended = now.Sub(m.suspendedAt)
+		m.suspendedAt = time.Time{}
+	}
+
+	participants := make([]Participant, 0, len(m.participants))
+	for r := range m.participants {
+		participants = append(participants, r.p)
+	}
+	t := Transition{From: from, To: to, At: now}
+	for ch := range m.subscribers {
+		select {
+		case ch <- t:
+		default:
+		}
+	}
+	m.mu.Unlock()
+
+	// Hidden pages may be frozen and then discarded without further events, so persist early
+	if to >= Hidden && from < Hidden || suspending {
+		for _, p := range participants {
+			if persister, ok := p.(Persister); ok {
+				persister.Persist()
+			}
+		}
+	}
+	if suspending {
+		for _, p := range participants {
+			p.Suspend()
+		}
+	}
+	if resuming {
+		for _, p := range participants {
+			p.Resume(suspended)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package lifecyclejs
+
+import (
+	"context"
+	"sync"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/eventjs"
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+var (
+	// _global is a cached reference to the global scope (window or worker)
+	_global = js.Global()
+	// _document is a cached reference to the global document, undefined in workers
+	_document = js.Global().Get("document")
+)
+
+// binding holds the lifecycle event listeners of a Manager.
+type binding struct {
+	group *eventjs.Group
+}
+
+// WasDiscarded reports whether the page was reloaded after the browser discarded it, in which case
+// persisted state should be restored.
+func WasDiscarded() bool {
+	return _document.Truthy() && _document.Get("wasDiscarded").Truthy()
+}
+
+// NewManager starts observing lifecycle events. In workers, which have no document, the state stays Active.
+func NewManager() *Manager {
+	m := &Manager{
+		binding:      binding{group: eventjs.NewGroup(context.Background())},
+		state:        currentState(),
+		participants: make(map[*registration]struct{}),
+		subscribers:  make(map[chan Transition]struct{}),
+	}
+	if !_document.Truthy() {
+		return m
+	}
+
+	update := func(js.Value) {
+		m.transition(currentState())
+	}
+	opts := eventjs.Options{Capture: true}
+	m.group.Listen(_document, "visibilitychange", update, opts)
+	m.group.Listen(_global, "focus", update, opts)
+	m.group.Listen(_global, "blur", update, opts)
+	m.group.Listen(_document, "freeze", func(js.Value) {
+		m.transition(Frozen)
+	}, opts)
+	m.group.Listen(_document, "resume", update, opts)
+	m.group.Listen(_global, "pageshow", update, opts)
+	m.group.Listen(_global, "pagehide", func(event js.Value) {
+		// Persisted pages enter the back/forward cache and may come back
+		if event.Get("persisted").Truthy() {
+			m.transition(Frozen)
+		} else {
+			m.transition(Terminated)
+		}
+	}, opts)
+	return m
+}
+
+// Close stops observing lifecycle events.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+	return m.group.Close()
+}
+
+// currentState derives the state from the document's visibility and focus.
+func currentState() State {
+	if !_document.Truthy() {
+		return Active
+	}
+	if _document.Get("visibilityState").String() != "visible" {
+		return Hidden
+	}
+	if _document.Call("hasFocus").Bool() {
+		return Active
+	}
+	return Passive
+}
+
+// WakeLock keeps the screen on while held, preventing the device from sleeping and suspending the page.
+// Browsers release wake locks when the page is hidden; WakeLock reacquires it when the page becomes
+// visible again until Release is called.
+type WakeLock struct {
+	// sentinel holds the current WakeLockSentinel, undefined while not held
+	sentinel js.Value
+	// visibility reacquires the lock when the page becomes visible
+	visibility *eventjs.Listener
+
+	mu       sync.Mutex
+	released bool
+}
+
+// RequestWakeLock acquires a screen wake lock. The page must be visible.
+func RequestWakeLock(ctx context.Context) (*WakeLock, error) {
+	wakeLock := _global.Get("navigator").Get("wakeLock")
+	if !wakeLock.Truthy() {
+		return nil, ErrUnsupported
+	}
+
+	sentinel, err := promisejs.Await(ctx, wakeLock.Call("request", "screen"))
+	if err != nil {
+		return nil, err
+	}
+
+	w := &WakeLock{sentinel: sentinel}
+	w.visibility, _ = eventjs.Listen(_document, "visibilitychange", func(js.Value) {
+		if _document.Get("visibilityState").String() != "visible" {
+			return
+		}
+		// Event handlers must not block, so reacquire asynchronously
+		promisejs.Then(wakeLock.Call("request", "screen"), func(v js.Value, err error) {
+			if err != nil {
+				return
+			}
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			if w.released {
+				v.Call("release")
+				return
+			}
+			w.sentinel = v
+		})
+	}, eventjs.Options{})
+	return w, nil
+}
+
+// Held reports whether the wake lock is currently active.
+func (w *WakeLock) Held() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !w.released && w.sentinel.Truthy() && !w.sentinel.Get("released").Bool()
+}
+
+// Release releases the wake lock and stops reacquiring it. Safe to call multiple times.
+func (w *WakeLock) Release() error {
+	w.mu.Lock()
+	if w.released {
+		w.mu.Unlock()
+		return nil
+	}
+	w.released = true
+	sentinel := w.sentinel
+	w.mu.Unlock()
+
+	if w.visibility != nil {
+		w.visibility.Close()
+	}
+	if sentinel.Truthy() && !sentinel.Get("released").Bool() {
+		_, err := promisejs.Await(context.Background(), sentinel.Call("release"))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+//go:build !js
+
+package lifecyclejs
+
+import "context"
+
+// binding is empty outside the browser, which has no lifecycle events.
+type binding struct{}
+
+// WasDiscarded reports false outside the browser.
+func WasDiscarded() bool {
+	return false
+}
+
+// NewManager returns a manager whose state stays Active, as there is no page outside the browser.
+func NewManager() *Manager {
+	return &Manager{
+		state:        Active,
+		participants: make(map[*registration]struct{}),
+		subscribers:  make(map[chan Transition]struct{}),
+	}
+}
+
+// Close stops the manager.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+	return nil
+}
+
+// WakeLock keeps the screen on while held. Wake locks are unsupported outside the browser.
+type WakeLock struct{}
+
+// RequestWakeLock returns ErrUnsupported outside the browser.
+func RequestWakeLock(ctx context.Context) (*WakeLock, error) {
+	return nil, ErrUnsupported
+}
+
+// Held reports false.
+func (w *WakeLock) Held() bool {
+	return false
+}
+
+// Release does nothing.
+func (w *WakeLock) Release() error {
+	return nil
+}
@@ -0,0 +1,56 @@
+// Package opfsjs provides bindings for the Origin Private File System, the sandboxed per-origin file system
+// reachable through navigator.storage.getDirectory(). Outside the browser Supported reports false and Root
+// returns ErrUnsupported.
+package opfsjs
+
+import (
+	"context"
+	"errors"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/caps"
+)
+
+var (
+	// ErrUnsupported is returned when the Origin Private File System is not available in the current context
+	ErrUnsupported = errors.New("origin private file system not supported")
+	// ErrNotFound is returned when a file or directory does not exist
+	ErrNotFound = errors.New("opfs entry not found")
+	// ErrRequestFailed is returned when a file system operation fails without a reason
+	ErrRequestFailed = errors.New("opfs request failed")
+)
+
+// Dir is a directory handle.
+type Dir struct {
+	value handle // The underlying FileSystemDirectoryHandle
+}
+
+// Supported reports whether the origin private file system is available.
+func Supported() bool {
+	return caps.Has(caps.OPFS)
+}
+
+// Entry is an entry of a directory.
+type Entry struct {
+	Name string // Name of the entry
+	Dir  bool   // Whether the entry is a directory
+}
+
+// File is a file handle. It implements io.ReaderAt over the committed file contents.
+type File struct {
+	value handle // The underlying FileSystemFileHandle
+}
+
+// ReadAt reads len(p) bytes at offset off. Writes through a Writable become visible once it is closed.
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	return f.ReadAtContext(context.Background(), p, off)
+}
+
+// Writable is a writable file stream. It implements io.WriterAt and io.Closer.
+type Writable struct {
+	value handle // The underlying FileSystemWritableFileStream
+}
+
+// WriteAt writes p at offset off, extending the file if needed.
+func (w *Writable) WriteAt(p []byte, off int64) (int, error) {
+	return w.WriteAtContext(context.Background(), p, off)
+}
@@ -0,0 +1,213 @@
+package opfsjs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"syscall/js"
+	"time"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+var (
+	// _storage is a cached reference to navigator.storage, undefined outside of supporting contexts
+	_storage = js.Global().Get("navigator").Get("storage")
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+	// _Uint8Array is a cached reference to the JavaScript Uint8Array constructor for binary data
+	_Uint8Array = js.Global().Get("Uint8Array")
+)
+
+// handle is the type of the JavaScript file system handles
+type handle = js.Value
+
+// Root returns the root directory of the origin private file system.
+func Root(ctx context.Context) (*Dir, error) {
+	if !Supported() {
+		return nil, ErrUnsupported
+	}
+	v, err := await(ctx, _storage.Call("getDirectory"))
+	if err != nil {
+		return nil, err
+	}
+	return &Dir{value: v}, nil
+}
+
+// Name returns the name of the directory, empty for the root.
+func (d *Dir) Name() string {
+	return d.value.Get("name").String()
+}
+
+// Dir returns the named subdirectory, creating it if create is set.
+func (d *Dir) Dir(ctx context.Context, name string, create bool) (*Dir, error) {
+	opts := _Object.New()
+	opts.Set("create", create)
+	v, err := await(ctx, d.value.Call("getDirectoryHandle", name, opts))
+	if err != nil {
+		return nil, err
+	}
+	return &Dir{value: v}, nil
+}
+
+// File returns the named file, creating it if create is set.
+func (d *Dir) File(ctx context.Context, name string, create bool) (*File, error) {
+	opts := _Object.New()
+	opts.Set("create", create)
+	v, err := await(ctx, d.value.Call("getFileHandle", name, opts))
+	if err != nil {
+		return nil, err
+	}
+	return &File{value: v}, nil
+}
+
+// Remove deletes the named entry. Non-empty directories are only removed if recursive is set.
+func (d *Dir) Remove(ctx context.Context, name string, recursive bool) error {
+	opts := _Object.New()
+	opts.Set("recursive", recursive)
+	_, err := await(ctx, d.value.Call("removeEntry", name, opts))
+	return err
+}
+
+// Names lists the names of the entries in the directory.
+func (d *Dir) Names(ctx context.Context) ([]string, error) {
+	iter := d.value.Call("keys")
+
+	var names []string
+	for {
+		next, err := await(ctx, iter.Call("next"))
+		if err != nil {
+			return nil, err
+		}
+		if next.Get("done").Bool() {
+			return names, nil
+		}
+		names = append(names, next.Get("value").String())
+	}
+}
+
+// Entries lists the entries of the directory.
+func (d *Dir) Entries(ctx context.Context) ([]Entry, error) {
+	iter := d.value.Call("values")
+
+	var entries []Entry
+	for {
+		next, err := await(ctx, iter.Call("next"))
+		if err != nil {
+			return nil, err
+		}
+		if next.Get("done").Bool() {
+			return entries, nil
+		}
+		v := next.Get("value")
+		entries = append(entries, Entry{Name: v.Get("name").String(), Dir: v.Get("kind").String() == "directory"})
+	}
+}
+
+// Name returns the name of the file.
+func (f *File) Name() string {
+	return f.value.Get("name").String()
+}
+
+// Size returns the committed size of the file.
+func (f *File) Size(ctx context.Context) (int64, error) {
+	file, err := await(ctx, f.value.Call("getFile"))
+	if err != nil {
+		return 0, err
+	}
+	return int64(file.Get("size").Float()), nil
+}
+
+// ModTime returns the time the file was last modified.
+func (f *File) ModTime(ctx context.Context) (time.Time, error) {
+	file, err := await(ctx, f.value.Call("getFile"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(int64(file.Get("lastModified").Float())), nil
+}
+
+// ReadAtContext is like ReadAt but bounded by ctx.
+func (f *File) ReadAtContext(ctx context.Context, p []byte, off int64) (int, error) {
+	file, err := await(ctx, f.value.Call("getFile"))
+	if err != nil {
+		return 0, err
+	}
+
+	size := int64(file.Get("size").Float())
+	if off >= size {
+		return 0, io.EOF
+	}
+	end := off + int64(len(p))
+	if end > size {
+		end = size
+	}
+
+	buf, err := await(ctx, file.Call("slice", float64(off), float64(end)).Call("arrayBuffer"))
+	if err != nil {
+		return 0, err
+	}
+	n := js.CopyBytesToGo(p, _Uint8Array.New(buf))
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Writable opens a writable stream to the file. Unless keepExisting is set, the file starts out empty.
+// Writes are applied to a temporary copy and committed atomically when the Writable is closed.
+func (f *File) Writable(ctx context.Context, keepExisting bool) (*Writable, error) {
+	opts := _Object.New()
+	opts.Set("keepExistingData", keepExisting)
+	v, err := await(ctx, f.value.Call("createWritable", opts))
+	if err != nil {
+		return nil, err
+	}
+	return &Writable{value: v}, nil
+}
+
+// WriteAtContext is like WriteAt but bounded by ctx.
+func (w *Writable) WriteAtContext(ctx context.Context, p []byte, off int64) (int, error) {
+	data := _Uint8Array.New(len(p))
+	js.CopyBytesToJS(data, p)
+
+	params := _Object.New()
+	params.Set("type", "write")
+	params.Set("position", float64(off))
+	params.Set("data", data)
+	if _, err := await(ctx, w.value.Call("write", params)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Truncate resizes the file to size bytes.
+func (w *Writable) Truncate(ctx context.Context, size int64) error {
+	_, err := await(ctx, w.value.Call("truncate", float64(size)))
+	return err
+}
+
+// Close commits the written data to the file.
+func (w *Writable) Close() error {
+	_, err := await(context.Background(), w.value.Call("close"))
+	return err
+}
+
+// Abort discards the written data.
+func (w *Writable) Abort() error {
+	_, err := await(context.Background(), w.value.Call("abort"))
+	return err
+}
+
+// await waits for promise and maps NotFoundError rejections to ErrNotFound.
+func await(ctx context.Context, promise js.Value) (js.Value, error) {
+	v, err := promisejs.Await(ctx, promise)
+	var jsErr *promisejs.Error
+	switch {
+	case errors.As(err, &jsErr) && jsErr.Name == "NotFoundError":
+		return v, ErrNotFound
+	case errors.Is(err, promisejs.ErrRejected):
+		return v, ErrRequestFailed
+	}
+	return v, err
+}
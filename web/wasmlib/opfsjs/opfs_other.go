@@ -0,0 +1,91 @@
+//go:build !js
+
+package opfsjs
+
+import (
+	"context"
+	"time"
+)
+
+// handle is empty outside the browser, where no handles can be obtained.
+type handle = struct{}
+
+// Root returns ErrUnsupported outside the browser.
+func Root(ctx context.Context) (*Dir, error) {
+	return nil, ErrUnsupported
+}
+
+// Name returns the empty string.
+func (d *Dir) Name() string {
+	return ""
+}
+
+// Dir returns ErrUnsupported.
+func (d *Dir) Dir(ctx context.Context, name string, create bool) (*Dir, error) {
+	return nil, ErrUnsupported
+}
+
+// File returns ErrUnsupported.
+func (d *Dir) File(ctx context.Context, name string, create bool) (*File, error) {
+	return nil, ErrUnsupported
+}
+
+// Remove returns ErrUnsupported.
+func (d *Dir) Remove(ctx context.Context, name string, recursive bool) error {
+	return ErrUnsupported
+}
+
+// Names returns ErrUnsupported.
+func (d *Dir) Names(ctx context.Context) ([]string, error) {
+	return nil, ErrUnsupported
+}
+
+// Entries returns ErrUnsupported.
+func (d *Dir) Entries(ctx context.Context) ([]Entry, error) {
+	return nil, ErrUnsupported
+}
+
+// Name returns the empty string.
+func (f *File) Name() string {
+	return ""
+}
+
+// Size returns ErrUnsupported.
+func (f *File) Size(ctx context.Context) (int64, error) {
+	return 0, ErrUnsupported
+}
+
+// ModTime returns ErrUnsupported.
+func (f *File) ModTime(ctx context.Context) (time.Time, error) {
+	return time.Time{}, ErrUnsupported
+}
+
+// ReadAtContext returns ErrUnsupported.
+func (f *File) ReadAtContext(ctx context.Context, p []byte, off int64) (int, error) {
+	return 0, ErrUnsupported
+}
+
+// Writable returns ErrUnsupported.
+func (f *File) Writable(ctx context.Context, keepExisting bool) (*Writable, error) {
+	return nil, ErrUnsupported
+}
+
+// WriteAtContext returns ErrUnsupported.
+func (w *Writable) WriteAtContext(ctx context.Context, p []byte, off int64) (int, error) {
+	return 0, ErrUnsupported
+}
+
+// Truncate returns ErrUnsupported.
+func (w *Writable) Truncate(ctx context.Context, size int64) error {
+	return ErrUnsupported
+}
+
+// Close returns ErrUnsupported.
+func (w *Writable) Close() error {
+	return ErrUnsupported
+}
+
+// Abort returns ErrUnsupported.
+func (w *Writable) Abort() error {
+	return ErrUnsupported
+}
@@ -0,0 +1,132 @@
+// Package perfjs reads resource timing entries of the browser Performance API and observes them as they are
+// recorded. Outside the browser Now and TimeOrigin measure from process start and no entries are recorded.
+package perfjs
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnsupported is returned when the Performance or PerformanceObserver APIs are unavailable
+	ErrUnsupported = errors.New("performance observer not supported")
+	// ErrClosed is returned when reading from an observer that has been closed
+	ErrClosed = errors.New("performance observer closed")
+)
+
+// ResourceTiming holds the timing and size information of a single fetched resource,
+// as reported by a PerformanceResourceTiming entry.
+// All timestamps are relative to the time origin of the current document or worker.
+type ResourceTiming struct {
+	Name            string // Resolved URL of the fetched resource
+	InitiatorType   string // Type of the API that initiated the fetch (fetch, xmlhttprequest, script, ...)
+	NextHopProtocol string // ALPN protocol used for the fetch (http/1.1, h2, h3)
+	ResponseStatus  int    // HTTP status code, or 0 when not exposed by the browser
+
+	StartTime             time.Duration // Time the fetch started
+	Duration              time.Duration // Total time from StartTime to ResponseEnd
+	RedirectStart         time.Duration // Start of the first redirect, or 0 without redirects
+	RedirectEnd           time.Duration // End of the last redirect, or 0 without redirects
+	FetchStart            time.Duration // Time the browser was ready to fetch the resource
+	DomainLookupStart     time.Duration // Start of the DNS lookup
+	DomainLookupEnd       time.Duration // End of the DNS lookup
+	ConnectStart          time.Duration // Start of the transport connection establishment
+	SecureConnectionStart time.Duration // Start of the TLS handshake, or 0 for plain connections
+	ConnectEnd            time.Duration // End of the connection establishment including TLS
+	RequestStart          time.Duration // Time the request was sent
+	ResponseStart         time.Duration // Time the first byte of the response arrived
+	ResponseEnd           time.Duration // Time the last byte of the response arrived
+
+	TransferSize    int64 // Bytes on the wire including headers, 0 for cache hits or opaque cross-origin entries
+	EncodedBodySize int64 // Size of the body before content decoding
+	DecodedBodySize int64 // Size of the body after content decoding
+}
+
+// DNS returns the time spent resolving the host name of the resource.
+func (t *ResourceTiming) DNS() time.Duration {
+	return t.DomainLookupEnd - t.DomainLookupStart
+}
+
+// Connect returns the time spent establishing the transport connection, including the TLS handshake.
+func (t *ResourceTiming) Connect() time.Duration {
+	return t.ConnectEnd - t.ConnectStart
+}
+
+// TLS returns the time spent in the TLS handshake, or 0 if the connection was reused or not secure.
+func (t *ResourceTiming) TLS() time.Duration {
+	if t.SecureConnectionStart == 0 {
+		return 0
+	}
+	return t.ConnectEnd - t.SecureConnectionStart
+}
+
+// TTFB returns the time to first byte, measured from sending the request to receiving the first response byte.
+func (t *ResourceTiming) TTFB() time.Duration {
+	if t.ResponseStart == 0 {
+		return 0
+	}
+	return t.ResponseStart - t.RequestStart
+}
+
+// Download returns the time spent receiving the response body.
+func (t *ResourceTiming) Download() time.Duration {
+	return t.ResponseEnd - t.ResponseStart
+}
+
+// Redirect returns the time spent following redirects before the final fetch.
+func (t *ResourceTiming) Redirect() time.Duration {
+	return t.RedirectEnd - t.RedirectStart
+}
+
+// Cached reports whether the resource was most likely served from the browser cache.
+// Opaque cross-origin entries without Timing-Allow-Origin also report zero sizes and are not distinguishable.
+func (t *ResourceTiming) Cached() bool {
+	return t.TransferSize == 0 && t.DecodedBodySize > 0
+}
+
+// Observer delivers resource timing entries to Go as the browser records them.
+// It wraps a JavaScript PerformanceObserver subscribed to the "resource" entry type.
+type Observer struct {
+	binding
+
+	// entryChan buffers observed entries (up to 128 entries); entries are dropped when the buffer is full
+	entryChan chan ResourceTiming
+	// closeChan signals that the observer has been disconnected
+	closeChan chan struct{}
+	closeOnce sync.Once
+
+	// mu protects dropped
+	mu sync.Mutex
+	// dropped counts entries that were discarded because the consumer fell behind
+	dropped int
+}
+
+// NextEntry returns the next observed resource timing entry.
+// It blocks until an entry is available or the observer is closed, in which case ErrClosed is returned.
+func (o *Observer) NextEntry() (ResourceTiming, error) {
+	select {
+	case timing := <-o.entryChan:
+		return timing, nil
+	case <-o.closeChan:
+		return ResourceTiming{}, ErrClosed
+	}
+}
+
+// Entries returns a channel receiving observed entries. The channel is never closed;
+// use Done to detect when the observer has been closed.
+func (o *Observer) Entries() <-chan ResourceTiming {
+	return o.entryChan
+}
+
+// Done returns a channel that is closed when the observer is closed.
+func (o *Observer) Done() <-chan struct{} {
+	return o.closeChan
+}
+
+// Dropped returns the number of entries discarded because they were not consumed quickly enough.
+func (o *Observer) Dropped() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.dropped
+}
@@ -0,0 +1,198 @@
+package perfjs
+
+import (
+	"syscall/js"
+	"time"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+var (
+	// _performance is a cached reference to the JavaScript performance object of the current global scope
+	_performance = js.Global().Get("performance")
+	// _PerformanceObserver is a cached reference to the JavaScript PerformanceObserver constructor
+	_PerformanceObserver = js.Global().Get("PerformanceObserver")
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+)
+
+// Now returns the current high resolution time relative to the time origin.
+func Now() time.Duration {
+	if !supported() {
+		return 0
+	}
+	return millis(_performance.Call("now"))
+}
+
+// TimeOrigin returns the wall clock time at which the current document or worker was created.
+// All ResourceTiming timestamps are relative to this time.
+func TimeOrigin() time.Time {
+	if !supported() {
+		return time.Time{}
+	}
+	origin := _performance.Get("timeOrigin").Float()
+	return time.UnixMicro(int64(origin * 1000))
+}
+
+// Entries returns all resource timing entries currently held in the browser's performance buffer.
+func Entries() []ResourceTiming {
+	if !supported() {
+		return nil
+	}
+	return toTimings(_performance.Call("getEntriesByType", "resource"))
+}
+
+// EntriesByName returns the resource timing entries recorded for the given absolute URL.
+// A URL fetched multiple times yields one entry per fetch, ordered by start time.
+func EntriesByName(url string) []ResourceTiming {
+	if !supported() {
+		return nil
+	}
+	return toTimings(_performance.Call("getEntriesByName", url, "resource"))
+}
+
+// ClearEntries removes all resource timing entries from the browser's performance buffer.
+func ClearEntries() {
+	if !supported() {
+		return
+	}
+	_performance.Call("clearResourceTimings")
+}
+
+// SetBufferSize sets the maximum number of resource timing entries the browser keeps buffered.
+// The default is 250 entries; entries beyond the limit are only delivered to active observers.
+func SetBufferSize(n int) {
+	if !supported() {
+		return
+	}
+	_performance.Call("setResourceTimingBufferSize", n)
+}
+
+// binding holds the JavaScript side of an Observer.
+type binding struct {
+	// observer holds the JavaScript PerformanceObserver object
+	observer js.Value
+	// funcsToBeReleased tracks JavaScript function callbacks that must be released to prevent memory leaks
+	funcsToBeReleased []js.Func
+}
+
+// Observe starts observing resource timing entries.
+// When buffered is true, entries already present in the performance buffer are delivered first.
+// Returns ErrUnsupported if the environment has no PerformanceObserver.
+func Observe(buffered bool) (*Observer, error) {
+	if !supported() || _PerformanceObserver.IsUndefined() {
+		return nil, ErrUnsupported
+	}
+
+	o := &Observer{
+		entryChan: make(chan ResourceTiming, 128),
+		closeChan: make(chan struct{}),
+	}
+
+	// The callback runs on the JS event loop and must never block, so entries are dropped when the buffer is full
+	onEntries := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		for _, timing := range toTimings(args[0].Call("getEntries")) {
+			select {
+			case o.entryChan <- timing:
+			default:
+				o.mu.Lock()
+				o.dropped++
+				o.mu.Unlock()
+			}
+		}
+		return nil
+	})
+	o.funcsToBeReleased = append(o.funcsToBeReleased, onEntries)
+
+	o.observer = _PerformanceObserver.New(onEntries)
+
+	opts := _Object.New()
+	opts.Set("type", "resource")
+	opts.Set("buffered", buffered)
+	o.observer.Call("observe", opts)
+
+	return o, nil
+}
+
+// Close disconnects the observer and releases all associated resources.
+// Safe to call multiple times.
+func (o *Observer) Close() error {
+	o.closeOnce.Do(func() {
+		o.observer.Call("disconnect")
+		close(o.closeChan)
+		for _, f := range o.funcsToBeReleased {
+			jsguard.Release(f)
+		}
+	})
+	return nil
+}
+
+// supported reports whether the global scope exposes the Performance API.
+func supported() bool {
+	return !_performance.IsUndefined() && !_performance.IsNull()
+}
+
+// toTimings converts a JavaScript array of PerformanceResourceTiming entries into Go values.
+func toTimings(entries js.Value) []ResourceTiming {
+	n := entries.Length()
+	timings := make([]ResourceTiming, 0, n)
+	for i := 0; i < n; i++ {
+		timings = append(timings, toTiming(entries.Index(i)))
+	}
+	return timings
+}
+
+// toTiming converts a single JavaScript PerformanceResourceTiming entry into a ResourceTiming.
+func toTiming(entry js.Value) ResourceTiming {
+	return ResourceTiming{
+		Name:            stringField(entry, "name"),
+		InitiatorType:   stringField(entry, "initiatorType"),
+		NextHopProtocol: stringField(entry, "nextHopProtocol"),
+		ResponseStatus:  int(numberField(entry, "responseStatus")),
+
+		StartTime:             millis(entry.Get("startTime")),
+		Duration:              millis(entry.Get("duration")),
+		RedirectStart:         millis(entry.Get("redirectStart")),
+		RedirectEnd:           millis(entry.Get("redirectEnd")),
+		FetchStart:            millis(entry.Get("fetchStart")),
+		DomainLookupStart:     millis(entry.Get("domainLookupStart")),
+		DomainLookupEnd:       millis(entry.Get("domainLookupEnd")),
+		ConnectStart:          millis(entry.Get("connectStart")),
+		SecureConnectionStart: millis(entry.Get("secureConnectionStart")),
+		ConnectEnd:            millis(entry.Get("connectEnd")),
+		RequestStart:          millis(entry.Get("requestStart")),
+		ResponseStart:         millis(entry.Get("responseStart")),
+		ResponseEnd:           millis(entry.Get("responseEnd")),
+
+		TransferSize:    int64(numberField(entry, "transferSize")),
+		EncodedBodySize: int64(numberField(entry, "encodedBodySize")),
+		DecodedBodySize: int64(numberField(entry, "decodedBodySize")),
+	}
+}
+
+// millis converts a DOMHighResTimeStamp (fractional milliseconds) into a time.Duration.
+// Missing values are reported as 0.
+func millis(v js.Value) time.Duration {
+	if v.Type() != js.TypeNumber {
+		return 0
+	}
+	return time.Duration(v.Float() * float64(time.Millisecond))
+}
+
+// numberField returns the numeric property of v, or 0 if it is absent.
+func numberField(v js.Value, name string) float64 {
+	field := v.Get(name)
+	if field.Type() != js.TypeNumber {
+		return 0
+	}
+	return field.Float()
+}
+
+// stringField returns the string property of v, or "" if it is absent.
+func stringField(v js.Value, name string) string {
+	field := v.Get(name)
+	if field.Type() != js.TypeString {
+		return ""
+	}
+	return field.String()
+}
@@ -0,0 +1,50 @@
+//go:build !js
+
+package perfjs
+
+import "time"
+
+// origin is the time the process started, standing in for the time origin of a document.
+var origin = time.Now()
+
+// binding is empty outside the browser, where observers cannot be created.
+type binding struct{}
+
+// Now returns the time elapsed since the process started.
+func Now() time.Duration {
+	return time.Since(origin)
+}
+
+// TimeOrigin returns the wall clock time at which the process started.
+func TimeOrigin() time.Time {
+	return origin
+}
+
+// Entries returns nil; no resource timing entries are recorded outside the browser.
+func Entries() []ResourceTiming {
+	return nil
+}
+
+// EntriesByName returns nil; no resource timing entries are recorded outside the browser.
+func EntriesByName(url string) []ResourceTiming {
+	return nil
+}
+
+// ClearEntries does nothing outside the browser.
+func ClearEntries() {}
+
+// SetBufferSize does nothing outside the browser.
+func SetBufferSize(n int) {}
+
+// Observe returns ErrUnsupported outside the browser.
+func Observe(buffered bool) (*Observer, error) {
+	return nil, ErrUnsupported
+}
+
+// Close marks the observer closed.
+func (o *Observer) Close() error {
+	o.closeOnce.Do(func() {
+		close(o.closeChan)
+	})
+	return nil
+}
@@ -0,0 +1,49 @@
+// Package promisejs bridges JavaScript promises and Go: waiting for a promise from a goroutine with
+// context cancellation, and creating promises settled by Go code. Outside the browser only the error types
+// are available, so code matching promise rejections builds everywhere.
+package promisejs
+
+import (
+	"errors"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jserr"
+)
+
+var (
+	// ErrRejected is matched by errors for promises rejected with undefined or null.
+	ErrRejected = errors.New("promise rejected")
+)
+
+// Error is the rejection reason of a JavaScript promise or a thrown JavaScript exception. It matches the
+// failure class of its name, see package jserr, and its cause with errors.Is and errors.As.
+type Error struct {
+	Name    string // JavaScript error name (e.g. "NotFoundError"), empty if the reason was not an Error
+	Message string // Error message or string form of the reason, empty if the reason was undefined or null
+	Stack   string // JavaScript stack trace where the engine provides one
+	Cause   *Error // The cause property of the error, nil if it had none
+	Value   value  // The original rejection reason, a js.Value in the browser
+}
+
+// Error implements the error interface. It returns the JavaScript error message.
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return ErrRejected.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the failure class of the error name, the cause, and ErrRejected for promises rejected
+// without a reason.
+func (e *Error) Unwrap() []error {
+	var errs []error
+	if e.reasonless() {
+		errs = append(errs, ErrRejected)
+	}
+	if class := jserr.ForName(e.Name); class != nil {
+		errs = append(errs, class)
+	}
+	if e.Cause != nil {
+		errs = append(errs, e.Cause)
+	}
+	return errs
+}
@@ -0,0 +1,197 @@
+package promisejs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jserr"
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+var (
+	// _Promise is a cached reference to the JavaScript Promise constructor for async operations
+	_Promise = js.Global().Get("Promise")
+	// _Error is a cached reference to the JavaScript Error constructor for creating error objects
+	_Error = js.Global().Get("Error")
+	// _String is a cached reference to the JavaScript String function for converting rejection reasons
+	_String = js.Global().Get("String")
+)
+
+// value is the type of Error.Value in the browser
+type value = js.Value
+
+// maxCauseDepth bounds the chain of causes converted by NewError, which may be cyclic
+const maxCauseDepth = 8
+
+// reasonless reports whether the promise was rejected with undefined or null.
+func (e *Error) reasonless() bool {
+	return e.Value.IsUndefined() || e.Value.IsNull()
+}
+
+// NewError converts a rejection reason or thrown value into an *Error, including the chain of its causes.
+func NewError(reason js.Value) *Error {
+	return newError(reason, maxCauseDepth)
+}
+
+// newError converts reason and up to depth of its causes.
+func newError(reason js.Value, depth int) *Error {
+	e := &Error{Value: reason}
+	switch {
+	case reason.IsUndefined() || reason.IsNull():
+	case reason.Type() == js.TypeObject:
+		if name := reason.Get("name"); name.Type() == js.TypeString {
+			e.Name = name.String()
+		}
+		if msg := reason.Get("message"); msg.Type() == js.TypeString {
+			e.Message = msg.String()
+		} else {
+			e.Message = _String.Invoke(reason).String()
+		}
+		if stack := reason.Get("stack"); stack.Type() == js.TypeString {
+			e.Stack = stack.String()
+		}
+		if cause := reason.Get("cause"); depth > 0 && !cause.IsUndefined() && !cause.IsNull() {
+			e.Cause = newError(cause, depth-1)
+		}
+	default:
+		e.Message = _String.Invoke(reason).String()
+	}
+	return e
+}
+
+// IsThenable reports whether v is a promise or promise-like object.
+func IsThenable(v js.Value) bool {
+	return v.Type() == js.TypeObject && v.Get("then").Type() == js.TypeFunction
+}
+
+// Await blocks until v settles and returns its value, or returns an *Error with the rejection reason.
+// Values that are not thenable are returned as is. If ctx is done first, Await returns ctx.Err();
+// the handlers attached to the promise are released once it settles.
+// Must not be called from the JavaScript event loop goroutine (inside a js.Func callback), since
+// the promise can only settle after that callback returns.
+func Await(ctx context.Context, v js.Value) (js.Value, error) {
+	if !IsThenable(v) {
+		return v, nil
+	}
+	jsguard.Check("promisejs.Await")
+
+	resultCh := make(chan js.Value, 1)
+	errCh := make(chan error, 1)
+
+	Then(v, func(value js.Value, err error) {
+		if err != nil {
+			errCh <- err
+			return
+		}
+		resultCh <- value
+	})
+
+	select {
+	case value := <-resultCh:
+		return value, nil
+	case err := <-errCh:
+		return js.Undefined(), err
+	case <-ctx.Done():
+		return js.Undefined(), ctx.Err()
+	}
+}
+
+// Then calls fn exactly once when promise settles, with the value or an *Error, and releases its handlers.
+// fn runs on the JavaScript event loop and must not block.
+func Then(promise js.Value, fn func(js.Value, error)) {
+	var thenFunc, catchFunc js.Func
+	thenFunc = jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer jsguard.Release(thenFunc)
+		defer jsguard.Release(catchFunc)
+
+		fn(argument(args), nil)
+		return nil
+	})
+	catchFunc = jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		defer jsguard.Release(thenFunc)
+		defer jsguard.Release(catchFunc)
+
+		fn(js.Undefined(), NewError(argument(args)))
+		return nil
+	})
+
+	promise.Call("then", thenFunc, catchFunc)
+}
+
+// New returns a Promise settled by fn, which runs in a new goroutine.
+// A returned error rejects the Promise with an Error (see ErrorValue); a panic rejects it as well.
+func New(fn func() (js.Value, error)) js.Value {
+	var executor js.Func
+	executor = jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		// The executor runs synchronously inside the constructor
+		defer jsguard.Release(executor)
+
+		resolve, reject := args[0], args[1]
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					reject.Invoke(ErrorValue(fmt.Errorf("panic: %v", r)))
+				}
+			}()
+
+			v, err := fn()
+			if err != nil {
+				reject.Invoke(ErrorValue(err))
+				return
+			}
+			resolve.Invoke(v)
+		}()
+		return nil
+	})
+	return _Promise.New(executor)
+}
+
+// Resolve returns a Promise fulfilled with v.
+func Resolve(v js.Value) js.Value {
+	return _Promise.Call("resolve", v)
+}
+
+// Reject returns a Promise rejected with the JavaScript form of err.
+func Reject(err error) js.Value {
+	return _Promise.Call("reject", ErrorValue(err))
+}
+
+// ErrorValue converts a Go error into a JavaScript value suitable as a rejection reason.
+// An *Error carrying a JavaScript value yields that value; any other error yields a new Error with its message,
+// named after its failure class (e.g. "AbortError" for errors matching jserr.ErrAborted) if it has one.
+func ErrorValue(err error) js.Value {
+	var jsErr *Error
+	if errors.As(err, &jsErr) && !jsErr.Value.IsUndefined() {
+		return jsErr.Value
+	}
+	v := _Error.New(err.Error())
+	if name := jserr.Name(err); name != "" {
+		v.Set("name", name)
+	}
+	return v
+}
+
+// Try calls fn and converts a thrown JavaScript exception into an *Error.
+// Other panics are propagated.
+func Try(fn func() js.Value) (v js.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if jsErr, ok := r.(js.Error); ok {
+				v, err = js.Undefined(), NewError(jsErr.Value)
+				return
+			}
+			panic(r)
+		}
+	}()
+	return fn(), nil
+}
+
+// argument returns the first callback argument or undefined.
+func argument(args []js.Value) js.Value {
+	if len(args) == 0 {
+		return js.Undefined()
+	}
+	return args[0]
+}
@@ -0,0 +1,12 @@
+//go:build !js
+
+package promisejs
+
+// value is the type of Error.Value outside the browser, where there are no JavaScript values.
+type value = struct{}
+
+// reasonless reports whether the promise was rejected without a reason, which outside the browser is told by
+// the missing message.
+func (e *Error) reasonless() bool {
+	return e.Message == ""
+}
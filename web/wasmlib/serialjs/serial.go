@@ -0,0 +1,69 @@
+// Package serialjs provides bindings for the Web Serial API. Ports implement io.ReadWriteCloser once opened.
+// Outside the browser RequestPort and Ports return ErrUnsupported.
+package serialjs
+
+import "errors"
+
+var (
+	// ErrUnsupported is returned when the Web Serial API is not available in the current context
+	ErrUnsupported = errors.New("web serial not supported")
+	// ErrNotOpen is returned when reading from or writing to a port that has not been opened
+	ErrNotOpen = errors.New("serial port not open")
+	// ErrAlreadyOpen is returned when opening a port that is already open
+	ErrAlreadyOpen = errors.New("serial port already open")
+	// ErrRequestFailed is returned when a Web Serial operation fails without a reason
+	ErrRequestFailed = errors.New("serial request failed")
+)
+
+// Parity is the parity checking mode of a serial port.
+type Parity string
+
+const (
+	ParityNone Parity = "none" // No parity bit
+	ParityEven Parity = "even" // Even parity
+	ParityOdd  Parity = "odd"  // Odd parity
+)
+
+// FlowControl is the flow control mode of a serial port.
+type FlowControl string
+
+const (
+	FlowControlNone     FlowControl = "none"     // No flow control
+	FlowControlHardware FlowControl = "hardware" // RTS/CTS hardware flow control
+)
+
+// Options configures a serial port when it is opened.
+// Zero values leave the browser defaults in place, except BaudRate which is required.
+type Options struct {
+	BaudRate    int         // Baud rate of the connection, e.g. 115200 (required)
+	DataBits    int         // Number of data bits per frame, 7 or 8 (default 8)
+	StopBits    int         // Number of stop bits at the end of a frame, 1 or 2 (default 1)
+	Parity      Parity      // Parity mode (default none)
+	BufferSize  int         // Size of the read and write buffers in bytes (default 255)
+	FlowControl FlowControl // Flow control mode (default none)
+}
+
+// Filter restricts the ports offered to the user in RequestPort to USB devices with the given IDs.
+// A zero ProductID matches every product of the vendor.
+type Filter struct {
+	VendorID  uint16 // USB vendor ID
+	ProductID uint16 // USB product ID (optional)
+}
+
+// PortInfo describes the USB device backing a serial port, if any.
+type PortInfo struct {
+	VendorID  uint16 // USB vendor ID, or 0 for non-USB ports
+	ProductID uint16 // USB product ID, or 0 for non-USB ports
+}
+
+// Signals holds the state of the serial port control signals.
+type Signals struct {
+	DataTerminalReady bool // DTR output signal
+	RequestToSend     bool // RTS output signal
+	Break             bool // Break output signal
+
+	DataCarrierDetect bool // DCD input signal
+	ClearToSend       bool // CTS input signal
+	RingIndicator     bool // RI input signal
+	DataSetReady      bool // DSR input signal
+}
@@ -0,0 +1,297 @@
+package serialjs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+	"pkg.gfire.dev/supernet/web/wasmlib/streamjs"
+)
+
+var (
+	// _serial is a cached reference to navigator.serial, undefined outside of supporting browsers
+	_serial = js.Global().Get("navigator").Get("serial")
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+	// _Array is a cached reference to the JavaScript Array constructor for array operations
+	_Array = js.Global().Get("Array")
+)
+
+// Port represents a serial port granted by the user.
+// After Open succeeds, Port implements io.ReadWriteCloser over the port's readable and writable streams.
+type Port struct {
+	// port holds the JavaScript SerialPort object
+	port js.Value
+
+	// mu protects the stream adapters and the open state
+	mu sync.Mutex
+	// reader adapts port.readable to io.Reader, nil while the port is closed
+	reader *streamjs.Reader
+	// writer adapts port.writable to io.Writer, nil while the port is closed
+	writer *streamjs.Writer
+}
+
+// RequestPort prompts the user to select a serial port, optionally restricted by filters.
+// Browsers only allow this call during a user gesture such as a click handler.
+func RequestPort(filters ...Filter) (*Port, error) {
+	return RequestPortContext(context.Background(), filters...)
+}
+
+// RequestPortContext is RequestPort bounded by ctx. Once ctx is done the prompt stays open in the browser and
+// the port the user selects is discarded.
+func RequestPortContext(ctx context.Context, filters ...Filter) (*Port, error) {
+	if !supported() {
+		return nil, ErrUnsupported
+	}
+
+	opts := _Object.New()
+	if len(filters) > 0 {
+		jsFilters := _Array.New()
+		for _, f := range filters {
+			jsFilter := _Object.New()
+			jsFilter.Set("usbVendorId", f.VendorID)
+			if f.ProductID != 0 {
+				jsFilter.Set("usbProductId", f.ProductID)
+			}
+			jsFilters.Call("push", jsFilter)
+		}
+		opts.Set("filters", jsFilters)
+	}
+
+	port, err := await(ctx, _serial.Call("requestPort", opts))
+	if err != nil {
+		return nil, err
+	}
+	return &Port{port: port}, nil
+}
+
+// Ports returns the serial ports the user has previously granted access to.
+// Does not require a user gesture.
+func Ports() ([]*Port, error) {
+	return PortsContext(context.Background())
+}
+
+// PortsContext is Ports bounded by ctx.
+func PortsContext(ctx context.Context) ([]*Port, error) {
+	if !supported() {
+		return nil, ErrUnsupported
+	}
+
+	jsPorts, err := await(ctx, _serial.Call("getPorts"))
+	if err != nil {
+		return nil, err
+	}
+
+	ports := make([]*Port, 0, jsPorts.Length())
+	for i := 0; i < jsPorts.Length(); i++ {
+		ports = append(ports, &Port{port: jsPorts.Index(i)})
+	}
+	return ports, nil
+}
+
+// Info returns the USB identification of the port, if available.
+func (p *Port) Info() PortInfo {
+	info := p.port.Call("getInfo")
+	var pi PortInfo
+	if v := info.Get("usbVendorId"); v.Type() == js.TypeNumber {
+		pi.VendorID = uint16(v.Int())
+	}
+	if v := info.Get("usbProductId"); v.Type() == js.TypeNumber {
+		pi.ProductID = uint16(v.Int())
+	}
+	return pi
+}
+
+// Open opens the port with the given options and prepares it for reading and writing.
+func (p *Port) Open(opts Options) error {
+	return p.OpenContext(context.Background(), opts)
+}
+
+// OpenContext is Open bounded by ctx. Once ctx is done the port may still be opened by the browser; call
+// Close, which closes it in that case too, before opening it again.
+func (p *Port) OpenContext(ctx context.Context, opts Options) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.reader != nil {
+		return ErrAlreadyOpen
+	}
+
+	// Build the SerialOptions dictionary, only setting the fields that were specified
+	jsOpts := _Object.New()
+	jsOpts.Set("baudRate", opts.BaudRate)
+	if opts.DataBits != 0 {
+		jsOpts.Set("dataBits", opts.DataBits)
+	}
+	if opts.StopBits != 0 {
+		jsOpts.Set("stopBits", opts.StopBits)
+	}
+	if opts.Parity != "" {
+		jsOpts.Set("parity", string(opts.Parity))
+	}
+	if opts.BufferSize != 0 {
+		jsOpts.Set("bufferSize", opts.BufferSize)
+	}
+	if opts.FlowControl != "" {
+		jsOpts.Set("flowControl", string(opts.FlowControl))
+	}
+
+	if _, err := await(ctx, p.port.Call("open", jsOpts)); err != nil {
+		return err
+	}
+
+	// Lock the readable and writable streams to Go adapters for the lifetime of the open port
+	p.reader = streamjs.NewReader(p.port.Get("readable"))
+	p.writer = streamjs.NewWriter(p.port.Get("writable"))
+	return nil
+}
+
+// Read reads bytes received from the serial device.
+// Blocks until data is available. Returns io.EOF once the port has been closed.
+func (p *Port) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	reader := p.reader
+	p.mu.Unlock()
+
+	if reader == nil {
+		return 0, ErrNotOpen
+	}
+	return reader.Read(b)
+}
+
+// Write sends bytes to the serial device.
+// Blocks until the browser has accepted the data into its write buffer.
+func (p *Port) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	writer := p.writer
+	p.mu.Unlock()
+
+	if writer == nil {
+		return 0, ErrNotOpen
+	}
+	return writer.Write(b)
+}
+
+// ReadContext is Read bounded by ctx. Once ctx is done the readable stream is cancelled, discarding
+// buffered data, and the port must be closed and opened again to read.
+func (p *Port) ReadContext(ctx context.Context, b []byte) (int, error) {
+	p.mu.Lock()
+	reader := p.reader
+	p.mu.Unlock()
+
+	if reader == nil {
+		return 0, ErrNotOpen
+	}
+	return reader.ReadContext(ctx, b)
+}
+
+// WriteContext is Write bounded by ctx. Once ctx is done the writable stream is aborted and the port must
+// be closed and opened again to write.
+func (p *Port) WriteContext(ctx context.Context, b []byte) (int, error) {
+	p.mu.Lock()
+	writer := p.writer
+	p.mu.Unlock()
+
+	if writer == nil {
+		return 0, ErrNotOpen
+	}
+	return writer.WriteContext(ctx, b)
+}
+
+// SetSignals updates the DTR, RTS and break output signals of the port.
+// Only the output fields of Signals are used.
+func (p *Port) SetSignals(s Signals) error {
+	return p.SetSignalsContext(context.Background(), s)
+}
+
+// SetSignalsContext is SetSignals bounded by ctx. Once ctx is done the signals may still be set.
+func (p *Port) SetSignalsContext(ctx context.Context, s Signals) error {
+	jsSignals := _Object.New()
+	jsSignals.Set("dataTerminalReady", s.DataTerminalReady)
+	jsSignals.Set("requestToSend", s.RequestToSend)
+	jsSignals.Set("break", s.Break)
+
+	_, err := await(ctx, p.port.Call("setSignals", jsSignals))
+	return err
+}
+
+// Signals returns the current state of the DCD, CTS, RI and DSR input signals.
+func (p *Port) Signals() (Signals, error) {
+	return p.SignalsContext(context.Background())
+}
+
+// SignalsContext is Signals bounded by ctx.
+func (p *Port) SignalsContext(ctx context.Context) (Signals, error) {
+	jsSignals, err := await(ctx, p.port.Call("getSignals"))
+	if err != nil {
+		return Signals{}, err
+	}
+	return Signals{
+		DataCarrierDetect: jsSignals.Get("dataCarrierDetect").Bool(),
+		ClearToSend:       jsSignals.Get("clearToSend").Bool(),
+		RingIndicator:     jsSignals.Get("ringIndicator").Bool(),
+		DataSetReady:      jsSignals.Get("dataSetReady").Bool(),
+	}, nil
+}
+
+// Close cancels pending reads, flushes pending writes and closes the port.
+// The port may be reopened with Open afterwards. Safe to call multiple times.
+func (p *Port) Close() error {
+	return p.CloseContext(context.Background())
+}
+
+// CloseContext is Close bounded by ctx, e.g. to give up on flushing writes a device does not accept. Once ctx
+// is done pending writes are discarded and the port may still be closed by the browser.
+func (p *Port) CloseContext(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.reader == nil {
+		return nil
+	}
+
+	// Both streams must be unlocked before the port itself can be closed
+	p.reader.Close()
+	werr := p.writer.CloseContext(ctx)
+	p.reader, p.writer = nil, nil
+
+	if _, err := await(ctx, p.port.Call("close")); err != nil {
+		return err
+	}
+	return werr
+}
+
+// Forget revokes the permission the user granted for this port.
+// The port is closed first if it is open.
+func (p *Port) Forget() error {
+	return p.ForgetContext(context.Background())
+}
+
+// ForgetContext is Forget bounded by ctx. Once ctx is done the permission may still be revoked.
+func (p *Port) ForgetContext(ctx context.Context) error {
+	if err := p.CloseContext(ctx); err != nil {
+		return err
+	}
+	if p.port.Get("forget").IsUndefined() {
+		return ErrUnsupported
+	}
+	_, err := await(ctx, p.port.Call("forget"))
+	return err
+}
+
+// supported reports whether navigator.serial is available.
+func supported() bool {
+	return !_serial.IsUndefined() && !_serial.IsNull()
+}
+
+// await blocks until the given JavaScript promise settles or ctx is done and returns its value or rejection
+// reason. Rejections without a reason are reported as ErrRequestFailed.
+func await(ctx context.Context, promise js.Value) (js.Value, error) {
+	v, err := promisejs.Await(ctx, promise)
+	if errors.Is(err, promisejs.ErrRejected) {
+		return v, ErrRequestFailed
+	}
+	return v, err
+}
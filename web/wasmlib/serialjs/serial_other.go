@@ -0,0 +1,103 @@
+//go:build !js
+
+package serialjs
+
+import "context"
+
+// Port represents a serial port. No ports can be obtained outside the browser.
+type Port struct{}
+
+// RequestPort returns ErrUnsupported outside the browser.
+func RequestPort(filters ...Filter) (*Port, error) {
+	return nil, ErrUnsupported
+}
+
+// Ports returns ErrUnsupported outside the browser.
+func Ports() ([]*Port, error) {
+	return nil, ErrUnsupported
+}
+
+// Info returns the zero PortInfo.
+func (p *Port) Info() PortInfo {
+	return PortInfo{}
+}
+
+// Open returns ErrUnsupported.
+func (p *Port) Open(opts Options) error {
+	return ErrUnsupported
+}
+
+// Read returns ErrNotOpen.
+func (p *Port) Read(b []byte) (int, error) {
+	return 0, ErrNotOpen
+}
+
+// Write returns ErrNotOpen.
+func (p *Port) Write(b []byte) (int, error) {
+	return 0, ErrNotOpen
+}
+
+// ReadContext returns ErrNotOpen.
+func (p *Port) ReadContext(ctx context.Context, b []byte) (int, error) {
+	return 0, ErrNotOpen
+}
+
+// WriteContext returns ErrNotOpen.
+func (p *Port) WriteContext(ctx context.Context, b []byte) (int, error) {
+	return 0, ErrNotOpen
+}
+
+// SetSignals returns ErrNotOpen.
+func (p *Port) SetSignals(s Signals) error {
+	return ErrNotOpen
+}
+
+// Signals returns ErrNotOpen.
+func (p *Port) Signals() (Signals, error) {
+	return Signals{}, ErrNotOpen
+}
+
+// Close does nothing.
+func (p *Port) Close() error {
+	return nil
+}
+
+// Forget returns ErrUnsupported.
+func (p *Port) Forget() error {
+	return ErrUnsupported
+}
+
+// RequestPortContext returns ErrUnsupported outside the browser.
+func RequestPortContext(ctx context.Context, filters ...Filter) (*Port, error) {
+	return nil, ErrUnsupported
+}
+
+// PortsContext returns ErrUnsupported outside the browser.
+func PortsContext(ctx context.Context) ([]*Port, error) {
+	return nil, ErrUnsupported
+}
+
+// OpenContext returns ErrUnsupported.
+func (p *Port) OpenContext(ctx context.Context, opts Options) error {
+	return ErrUnsupported
+}
+
+// SetSignalsContext returns ErrNotOpen.
+func (p *Port) SetSignalsContext(ctx context.Context, s Signals) error {
+	return ErrNotOpen
+}
+
+// SignalsContext returns ErrNotOpen.
+func (p *Port) SignalsContext(ctx context.Context) (Signals, error) {
+	return Signals{}, ErrNotOpen
+}
+
+// CloseContext does nothing.
+func (p *Port) CloseContext(ctx context.Context) error {
+	return nil
+}
+
+// ForgetContext returns ErrUnsupported.
+func (p *Port) ForgetContext(ctx context.Context) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,181 @@
+// Package sharedworkerjs shares a single physical connection between all tabs of an origin. A SharedWorker
+// owns the connection and multiplexes it with package mux; every tab talks to the worker over a MessagePort
+// and gets its own mux stream. The server accepts one mux.Session per physical connection and sees each tab
+// as a stream, so the number of server connections no longer grows with the number of open tabs. Outside the
+// browser, a Hub serves tabs over any mux.Conn, but shared workers cannot be connected to or served.
+package sharedworkerjs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"pkg.gfire.dev/supernet/mux"
+)
+
+var (
+	// ErrHubClosed is returned when serving a tab on a closed hub
+	ErrHubClosed = errors.New("shared connection hub closed")
+	// ErrClosed is returned when using a closed port connection
+	ErrClosed = errors.New("message port closed")
+	// ErrUnsupported is returned when SharedWorker is not available; callers should dial directly instead
+	ErrUnsupported = errors.New("shared worker not supported")
+	// ErrNotWorker is returned when ServeWorker is called outside of a SharedWorker global scope
+	ErrNotWorker = errors.New("not running in a shared worker")
+)
+
+// DialFunc establishes the physical connection shared by all tabs.
+type DialFunc func(ctx context.Context) (mux.Conn, error)
+
+// HubConfig configures a Hub.
+type HubConfig struct {
+	// Mux configures the session over the physical connection. Server must be false; the server side of
+	// the connection runs the mux.Session with Server set.
+	Mux mux.Config
+	// IdleTimeout closes the physical connection after the last tab disconnects. Zero keeps it open.
+	IdleTimeout time.Duration
+}
+
+// Hub owns the shared physical connection and bridges tab connections onto its streams.
+// The connection is dialed when the first tab arrives and redialed on demand after it fails.
+type Hub struct {
+	dial DialFunc
+	cfg  HubConfig
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// session is the mux session over the current physical connection, nil when not connected
+	session *mux.Session
+	// tabs is the number of tabs currently served
+	tabs int
+	// idleTimer closes the session once the hub has been idle for IdleTimeout
+	idleTimer *time.Timer
+	// closed is set once Close was called
+	closed bool
+}
+
+// NewHub creates a hub that dials the physical connection with dial.
+func NewHub(dial DialFunc, cfg HubConfig) *Hub {
+	cfg.Mux.Server = false
+	return &Hub{dial: dial, cfg: cfg}
+}
+
+// Serve bridges a tab connection onto a new stream of the shared connection until either side closes.
+// The tab connection is closed when Serve returns.
+func (h *Hub) Serve(ctx context.Context, tab mux.Conn) error {
+	defer tab.Close()
+
+	st, err := h.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer h.release()
+	defer st.Close()
+
+	errc := make(chan error, 2)
+	go func() {
+		errc <- pipe(st, tab)
+	}()
+	go func() {
+		errc <- pipe(tab, st)
+	}()
+
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	// Closing both ends unblocks the other direction
+	tab.Close()
+	st.Close()
+	return err
+}
+
+// Tabs returns the number of tabs currently served.
+func (h *Hub) Tabs() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.tabs
+}
+
+// Close closes the shared connection. Tabs being served are disconnected.
+func (h *Hub) Close() error {
+	h.mu.Lock()
+	h.closed = true
+	session := h.session
+	h.session = nil
+	if h.idleTimer != nil {
+		h.idleTimer.Stop()
+	}
+	h.mu.Unlock()
+
+	if session != nil {
+		return session.Close()
+	}
+	return nil
+}
+
+// open returns a new stream on the shared connection, dialing it if necessary.
+func (h *Hub) open(ctx context.Context) (*mux.Stream, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrHubClosed
+	}
+	if h.idleTimer != nil {
+		h.idleTimer.Stop()
+		h.idleTimer = nil
+	}
+
+	if h.session == nil || h.session.Err() != nil {
+		// Dials are serialized by holding mu, so concurrent tabs share the result
+		conn, err := h.dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		h.session = mux.NewSession(conn, h.cfg.Mux)
+	}
+
+	st, err := h.session.OpenStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.tabs++
+	return st, nil
+}
+
+// release accounts for a disconnected tab and arms the idle timer.
+func (h *Hub) release() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.tabs--
+	if h.tabs > 0 || h.cfg.IdleTimeout <= 0 || h.session == nil {
+		return
+	}
+
+	session := h.session
+	h.idleTimer = time.AfterFunc(h.cfg.IdleTimeout, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.tabs == 0 && h.session == session {
+			h.session = nil
+			session.Close()
+		}
+	})
+}
+
+// pipe forwards messages from src to dst until either fails.
+func pipe(dst, src mux.Conn) error {
+	for {
+		msg, err := src.NextMessage()
+		if err != nil {
+			return err
+		}
+		if err := dst.Send(msg); err != nil {
+			return err
+		}
+	}
+}
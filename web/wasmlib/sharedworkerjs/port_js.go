@@ -0,0 +1,151 @@
+package sharedworkerjs
+
+import (
+	"context"
+	"sync"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+var (
+	// _ArrayBuffer is a cached reference to the JavaScript ArrayBuffer constructor for binary data
+	_ArrayBuffer = js.Global().Get("ArrayBuffer")
+	// _Uint8Array is a cached reference to the JavaScript Uint8Array constructor for typed array operations
+	_Uint8Array = js.Global().Get("Uint8Array")
+	// _Array is a cached reference to the JavaScript Array constructor for transfer lists
+	_Array = js.Global().Get("Array")
+)
+
+// closeMessage is posted to tell the other side of a port that the connection is closed.
+// Data messages are always ArrayBuffers, so a string cannot be confused with data.
+const closeMessage = "supernet:close"
+
+// Port is a message connection over a MessagePort, implementing the NextMessage/Send/Close contract.
+// Sent buffers are transferred rather than copied.
+type Port struct {
+	// port holds the JavaScript MessagePort object
+	port js.Value
+	// onMessage handles message events
+	onMessage js.Func
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// queue holds received messages; MessagePorts have no backpressure, so the queue is unbounded
+	queue [][]byte
+	// closed is set once either side closed the port
+	closed bool
+	// changed is closed and replaced whenever a message arrives or the port is closed
+	changed chan struct{}
+
+	closeOnce sync.Once
+}
+
+// NewPort wraps a MessagePort and starts receiving messages on it.
+func NewPort(port js.Value) *Port {
+	p := &Port{
+		port:    port,
+		changed: make(chan struct{}),
+	}
+	p.onMessage = jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		data := args[0].Get("data")
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.closed {
+			return nil
+		}
+		switch {
+		case data.Type() == js.TypeString && data.String() == closeMessage:
+			p.closed = true
+		case data.InstanceOf(_ArrayBuffer):
+			array := _Uint8Array.New(data)
+			msg := make([]byte, array.Get("byteLength").Int())
+			js.CopyBytesToGo(msg, array)
+			p.queue = append(p.queue, msg)
+		default:
+			return nil
+		}
+		close(p.changed)
+		p.changed = make(chan struct{})
+		return nil
+	})
+	jsguard.AddEventListener(port, "message", p.onMessage)
+	port.Call("start")
+	return p
+}
+
+// NextMessage blocks until the next message is received.
+// Returns ErrClosed once the port was closed by either side.
+func (p *Port) NextMessage() ([]byte, error) {
+	return p.NextMessageContext(context.Background())
+}
+
+// NextMessageContext is NextMessage bounded by ctx. It returns ctx.Err() once ctx is done, leaving the
+// port open and the messages queued.
+func (p *Port) NextMessageContext(ctx context.Context) ([]byte, error) {
+	jsguard.Check("sharedworkerjs.Port.NextMessage")
+	for {
+		p.mu.Lock()
+		if len(p.queue) > 0 {
+			msg := p.queue[0]
+			p.queue[0] = nil
+			p.queue = p.queue[1:]
+			p.mu.Unlock()
+			return msg, nil
+		}
+		closed, changed := p.closed, p.changed
+		p.mu.Unlock()
+
+		if closed {
+			return nil, ErrClosed
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// NextMessageAsync is NextMessageContext in a new goroutine, for JavaScript callbacks, which must not block.
+func (p *Port) NextMessageAsync(ctx context.Context) <-chan jsguard.Result[[]byte] {
+	return jsguard.Go(func() ([]byte, error) {
+		return p.NextMessageContext(ctx)
+	})
+}
+
+// Send posts data to the other side of the port.
+func (p *Port) Send(data []byte) error {
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+
+	buffer := _ArrayBuffer.New(len(data))
+	js.CopyBytesToJS(_Uint8Array.New(buffer), data)
+	p.port.Call("postMessage", buffer, _Array.New(buffer))
+	return nil
+}
+
+// Close notifies the other side and closes the port. Safe to call multiple times.
+func (p *Port) Close() error {
+	p.closeOnce.Do(func() {
+		p.mu.Lock()
+		if !p.closed {
+			p.closed = true
+			close(p.changed)
+			p.changed = make(chan struct{})
+		}
+		p.queue = nil
+		p.mu.Unlock()
+
+		p.port.Call("postMessage", closeMessage)
+		jsguard.RemoveEventListener(p.port, "message", p.onMessage)
+		p.port.Call("close")
+		jsguard.Release(p.onMessage)
+	})
+	return nil
+}
@@ -0,0 +1,70 @@
+package sharedworkerjs
+
+import (
+	"context"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/caps"
+	"pkg.gfire.dev/supernet/web/wasmlib/eventjs"
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+var (
+	// _global is a cached reference to the global scope (window or worker)
+	_global = js.Global()
+	// _SharedWorker is a cached reference to the JavaScript SharedWorker constructor
+	_SharedWorker = js.Global().Get("SharedWorker")
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+)
+
+// Connect starts or joins the SharedWorker at scriptURL and returns a connection to it. Tabs using the same
+// script URL and name share one worker, and therefore one physical connection.
+// The connection is closed automatically when the page is unloaded.
+func Connect(scriptURL, name string) (*Port, error) {
+	if !caps.Has(caps.SharedWorker) {
+		return nil, ErrUnsupported
+	}
+
+	opts := _Object.New()
+	if name != "" {
+		opts.Set("name", name)
+	}
+	worker, err := promisejs.Try(func() js.Value {
+		return _SharedWorker.New(scriptURL, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p := NewPort(worker.Get("port"))
+	// Without an explicit close the worker cannot tell that a tab went away
+	eventjs.Listen(_global, "pagehide", func(js.Value) {
+		p.Close()
+	}, eventjs.Options{Once: true})
+	return p, nil
+}
+
+// ServeWorker serves every tab connecting to the current SharedWorker through hub until ctx is cancelled.
+// It must be called from the worker script.
+func ServeWorker(ctx context.Context, hub *Hub) error {
+	if _global.Get("onconnect").IsUndefined() {
+		return ErrNotWorker
+	}
+
+	connects, err := eventjs.Chan(_global, "connect", 16, eventjs.Options{})
+	if err != nil {
+		return err
+	}
+	defer connects.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-connects.C:
+			port := NewPort(event.Get("ports").Index(0))
+			go hub.Serve(ctx, port)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+//go:build !js
+
+package sharedworkerjs
+
+import (
+	"context"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+// Port is a message connection over a MessagePort. Outside the browser no port can be obtained.
+type Port struct{}
+
+// Connect returns ErrUnsupported outside the browser.
+func Connect(scriptURL, name string) (*Port, error) {
+	return nil, ErrUnsupported
+}
+
+// ServeWorker returns ErrNotWorker outside the browser.
+func ServeWorker(ctx context.Context, hub *Hub) error {
+	return ErrNotWorker
+}
+
+// NextMessage returns ErrClosed.
+func (p *Port) NextMessage() ([]byte, error) {
+	return nil, ErrClosed
+}
+
+// NextMessageContext returns ErrClosed.
+func (p *Port) NextMessageContext(ctx context.Context) ([]byte, error) {
+	return nil, ErrClosed
+}
+
+// NextMessageAsync delivers ErrClosed.
+func (p *Port) NextMessageAsync(ctx context.Context) <-chan jsguard.Result[[]byte] {
+	return jsguard.Go(p.NextMessage)
+}
+
+// Send returns ErrClosed.
+func (p *Port) Send(data []byte) error {
+	return ErrClosed
+}
+
+// Close does nothing.
+func (p *Port) Close() error {
+	return nil
+}
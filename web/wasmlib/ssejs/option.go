@@ -0,0 +1,49 @@
+package ssejs
+
+import (
+	"context"
+	"time"
+)
+
+// Option configures a Conn opened by Dial. Options are applied in order, so a later option overrides an
+// earlier one setting the same value. The ctx given to Dial bounds opening the session independently of them.
+type Option func(*options)
+
+// options holds the configuration of a Conn.
+type options struct {
+	credentials bool
+	sendTimeout time.Duration
+}
+
+// newOptions returns the defaults overridden by opts.
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithCredentials sends cookies and HTTP authentication with the event stream and the posted messages of a
+// cross-origin server, which must allow credentials with CORS. It has no effect outside the browser.
+func WithCredentials() Option {
+	return func(o *options) {
+		o.credentials = true
+	}
+}
+
+// WithSendTimeout bounds every Send, which fails with context.DeadlineExceeded when it elapses. The message
+// may still reach the server. It applies within the ctx given to SendContext.
+func WithSendTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.sendTimeout = d
+	}
+}
+
+// sendContext returns the context bounding a Send within parent.
+func (o options) sendContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if o.sendTimeout > 0 {
+		return context.WithTimeout(parent, o.sendTimeout)
+	}
+	return context.WithCancel(parent)
+}
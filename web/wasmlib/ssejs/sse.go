@@ -0,0 +1,135 @@
+// Package ssejs is the browser client of the sse transport: messages are received over an EventSource and
+// sent with fetch POST requests, for networks where neither WebTransport nor WebSockets get through. Outside
+// the browser the event stream is read and messages are posted with net/http.
+package ssejs
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jserr"
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+var (
+	// ErrUnsupported is returned when EventSource is not available in the current context
+	ErrUnsupported = errors.New("server-sent events not supported")
+	// ErrClosed is returned when using a closed connection
+	ErrClosed = errors.New("sse connection closed")
+	// ErrFailedToDial is returned when the event stream cannot be established
+	ErrFailedToDial = jserr.New(jserr.ErrNetwork, "failed to open event stream")
+	// ErrSendFailed is returned when the server rejects an upstream message
+	ErrSendFailed = errors.New("sse send failed")
+)
+
+// Conn is an SSE session implementing the NextMessage/Send/Close contract.
+type Conn struct {
+	binding
+
+	// endpoint is the URL upstream messages are posted to, including the session parameter
+	endpoint string
+	// opts holds the configuration given to Dial
+	opts options
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// queue holds received messages; the event stream has no backpressure, so the queue is unbounded
+	queue [][]byte
+	// err is set once the session ended
+	err error
+	// changed is closed and replaced whenever a message arrives or the session ends
+	changed chan struct{}
+
+	// sendMu serializes POST requests so messages arrive in order
+	sendMu sync.Mutex
+}
+
+// NextMessage blocks until the next message is received.
+func (c *Conn) NextMessage() ([]byte, error) {
+	return c.NextMessageContext(context.Background())
+}
+
+// NextMessageContext is NextMessage bounded by ctx. It returns ctx.Err() once ctx is done, leaving the
+// session open and the messages queued.
+func (c *Conn) NextMessageContext(ctx context.Context) ([]byte, error) {
+	jsguard.Check("ssejs.Conn.NextMessage")
+	for {
+		c.mu.Lock()
+		if len(c.queue) > 0 {
+			msg := c.queue[0]
+			c.queue[0] = nil
+			c.queue = c.queue[1:]
+			c.mu.Unlock()
+			return msg, nil
+		}
+		err, changed := c.err, c.changed
+		c.mu.Unlock()
+
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// NextMessageAsync is NextMessageContext in a new goroutine, for JavaScript callbacks, which must not block.
+func (c *Conn) NextMessageAsync(ctx context.Context) <-chan jsguard.Result[[]byte] {
+	return jsguard.Go(func() ([]byte, error) {
+		return c.NextMessageContext(ctx)
+	})
+}
+
+// Close ends the session. Safe to call multiple times.
+func (c *Conn) Close() error {
+	c.finish(ErrClosed)
+	return nil
+}
+
+// finish ends the session with err and tells the server, unless it already ended.
+func (c *Conn) finish(err error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.err = err
+	c.broadcast()
+	endpoint := c.endpoint
+	c.mu.Unlock()
+
+	c.shutdown(endpoint)
+}
+
+// push queues a received message.
+func (c *Conn) push(msg []byte) {
+	c.mu.Lock()
+	if c.err == nil {
+		c.queue = append(c.queue, msg)
+		c.broadcast()
+	}
+	c.mu.Unlock()
+}
+
+// broadcast wakes blocked readers. Must be called with mu held.
+func (c *Conn) broadcast() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// sessionURL returns the URL upstream messages of session id are posted to.
+func sessionURL(rawURL, id string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("session", id)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
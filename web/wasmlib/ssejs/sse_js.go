@@ -0,0 +1,175 @@
+package ssejs
+
+import (
+	"context"
+	"encoding/base64"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/eventjs"
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+var (
+	// _global is a cached reference to the global scope (window or worker)
+	_global = js.Global()
+	// _EventSource is a cached reference to the JavaScript EventSource constructor
+	_EventSource = js.Global().Get("EventSource")
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+	// _Uint8Array is a cached reference to the JavaScript Uint8Array constructor for typed array operations
+	_Uint8Array = js.Global().Get("Uint8Array")
+	// _AbortController is a cached reference to the JavaScript AbortController constructor for cancelling sends
+	_AbortController = js.Global().Get("AbortController")
+)
+
+// binding holds the JavaScript side of a Conn.
+type binding struct {
+	// source holds the JavaScript EventSource object
+	source js.Value
+	// group holds the EventSource listeners
+	group *eventjs.Group
+}
+
+// Dial opens an SSE session with the server at rawURL, configured by opts.
+func Dial(ctx context.Context, rawURL string, opts ...Option) (*Conn, error) {
+	if _EventSource.Type() != js.TypeFunction {
+		return nil, ErrUnsupported
+	}
+
+	o := newOptions(opts)
+	init := _Object.New()
+	init.Set("withCredentials", o.credentials)
+	source, err := promisejs.Try(func() js.Value {
+		return _EventSource.New(rawURL, init)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Conn{
+		binding: binding{source: source, group: eventjs.NewGroup(context.Background())},
+		opts:    o,
+		changed: make(chan struct{}),
+	}
+	session := make(chan string, 1)
+	failed := make(chan struct{}, 1)
+
+	c.group.Listen(source, "session", func(event js.Value) {
+		select {
+		case session <- event.Get("data").String():
+		default:
+		}
+	}, eventjs.Options{})
+	c.group.Listen(source, "message", func(event js.Value) {
+		msg, err := base64.StdEncoding.DecodeString(event.Get("data").String())
+		if err != nil {
+			c.finish(err)
+			return
+		}
+		c.push(msg)
+	}, eventjs.Options{})
+	c.group.Listen(source, "close", func(js.Value) {
+		c.finish(ErrClosed)
+	}, eventjs.Options{})
+	c.group.Listen(source, "error", func(js.Value) {
+		// EventSource reconnects on its own, but a reconnect would start a new session
+		select {
+		case failed <- struct{}{}:
+		default:
+		}
+		c.finish(ErrClosed)
+	}, eventjs.Options{})
+
+	select {
+	case id := <-session:
+		endpoint, err := sessionURL(resolve(rawURL), id)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.endpoint = endpoint
+		return c, nil
+	case <-failed:
+		c.Close()
+		return nil, ErrFailedToDial
+	case <-ctx.Done():
+		c.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// Send posts a message to the server, blocking until it was accepted.
+func (c *Conn) Send(data []byte) error {
+	return c.SendContext(context.Background(), data)
+}
+
+// SendContext is Send bounded by ctx, which aborts the request when it is done. The message may still
+// reach the server.
+func (c *Conn) SendContext(ctx context.Context, data []byte) error {
+	c.mu.Lock()
+	err := c.err
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	body := _Uint8Array.New(len(data))
+	js.CopyBytesToJS(body, data)
+	init := _Object.New()
+	init.Set("method", "POST")
+	init.Set("body", body)
+	headers := _Object.New()
+	headers.Set("Content-Type", "application/octet-stream")
+	init.Set("headers", headers)
+	if c.opts.credentials {
+		init.Set("credentials", "include")
+	}
+
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	ctx, cancel := c.opts.sendContext(ctx)
+	defer cancel()
+	controller := _AbortController.New()
+	init.Set("signal", controller.Get("signal"))
+	stop := context.AfterFunc(ctx, func() {
+		controller.Call("abort", promisejs.ErrorValue(ctx.Err()))
+	})
+	defer stop()
+	resp, err := promisejs.Await(ctx, _global.Call("fetch", c.endpoint, init))
+	if err != nil {
+		return err
+	}
+	if !resp.Get("ok").Bool() {
+		if resp.Get("status").Int() == 404 || resp.Get("status").Int() == 410 {
+			c.finish(ErrClosed)
+			return ErrClosed
+		}
+		return ErrSendFailed
+	}
+	return nil
+}
+
+// shutdown closes the event stream and tells the server the session ended.
+func (c *Conn) shutdown(endpoint string) {
+	c.source.Call("close")
+	c.group.Close()
+	if endpoint != "" {
+		init := _Object.New()
+		init.Set("method", "DELETE")
+		init.Set("keepalive", true)
+		if c.opts.credentials {
+			init.Set("credentials", "include")
+		}
+		// Fire and forget; the server also ends the session when the event stream drops
+		promisejs.Then(_global.Call("fetch", endpoint, init), func(js.Value, error) {})
+	}
+}
+
+// resolve makes rawURL absolute against the current location.
+func resolve(rawURL string) string {
+	location := _global.Get("location")
+	if !location.Truthy() {
+		return rawURL
+	}
+	return js.Global().Get("URL").New(rawURL, location.Get("href")).Call("toString").String()
+}
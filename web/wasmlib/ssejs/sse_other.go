@@ -0,0 +1,190 @@
+//go:build !js
+
+package ssejs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// closeTimeout bounds the request telling the server a session ended
+const closeTimeout = 5 * time.Second
+
+// binding holds the event stream of a Conn outside the browser.
+type binding struct {
+	// cancel aborts the event stream request
+	cancel context.CancelFunc
+}
+
+// Dial opens an SSE session with the server at rawURL, configured by opts. ctx bounds opening the session,
+// not its lifetime.
+func Dial(ctx context.Context, rawURL string, opts ...Option) (*Conn, error) {
+	streamCtx, cancel := context.WithCancel(context.Background())
+	stop := context.AfterFunc(ctx, cancel)
+	fail := func(err error) (*Conn, error) {
+		stop()
+		cancel()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fail(err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fail(fmt.Errorf("%w: %w", ErrFailedToDial, err))
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return fail(fmt.Errorf("%w: status %d", ErrFailedToDial, resp.StatusCode))
+	}
+
+	events := bufio.NewReader(resp.Body)
+	event, id, err := nextEvent(events)
+	if err != nil || event != "session" {
+		resp.Body.Close()
+		return fail(ErrFailedToDial)
+	}
+	endpoint, err := sessionURL(rawURL, id)
+	if err != nil || !stop() {
+		resp.Body.Close()
+		return fail(err)
+	}
+
+	c := &Conn{
+		binding:  binding{cancel: cancel},
+		endpoint: endpoint,
+		opts:     newOptions(opts),
+		changed:  make(chan struct{}),
+	}
+	go c.readLoop(events, resp.Body)
+	return c, nil
+}
+
+// readLoop queues received messages until the event stream ends.
+func (c *Conn) readLoop(events *bufio.Reader, body io.Closer) {
+	defer body.Close()
+	for {
+		event, data, err := nextEvent(events)
+		if err != nil {
+			c.finish(ErrClosed)
+			return
+		}
+		switch event {
+		case "message":
+			msg, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				c.finish(err)
+				return
+			}
+			c.push(msg)
+		case "close":
+			c.finish(ErrClosed)
+			return
+		}
+	}
+}
+
+// nextEvent reads the next event of an event stream, skipping comments, and returns its type and data.
+func nextEvent(r *bufio.Reader) (event, data string, err error) {
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", "", err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if line == "" {
+			if lines == nil {
+				continue
+			}
+			if event == "" {
+				event = "message"
+			}
+			return event, strings.Join(lines, "\n"), nil
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			lines = append(lines, value)
+		}
+	}
+}
+
+// Send posts a message to the server, blocking until it was accepted.
+func (c *Conn) Send(data []byte) error {
+	return c.SendContext(context.Background(), data)
+}
+
+// SendContext is Send bounded by ctx, which aborts the request when it is done. The message may still
+// reach the server.
+func (c *Conn) SendContext(ctx context.Context, data []byte) error {
+	c.mu.Lock()
+	err := c.err
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	ctx, cancel := c.opts.sendContext(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+			c.finish(ErrClosed)
+			return ErrClosed
+		}
+		return ErrSendFailed
+	}
+	return nil
+}
+
+// shutdown aborts the event stream and tells the server the session ended.
+func (c *Conn) shutdown(endpoint string) {
+	c.cancel()
+	if endpoint == "" {
+		return
+	}
+	// Fire and forget; the server also ends the session when the event stream drops
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, endpoint, nil)
+		if err != nil {
+			return
+		}
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+}
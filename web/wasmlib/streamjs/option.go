@@ -0,0 +1,43 @@
+package streamjs
+
+// defaultChunkSize is the default size of the chunks a ReadableStream reads from its Go reader
+const defaultChunkSize = 4096
+
+// Option configures a ReadableStream created by NewReadableStream. Options are applied in order, so a later
+// option overrides an earlier one setting the same value. Outside the browser they have no effect.
+type Option func(*options)
+
+// options holds the configuration of a ReadableStream.
+type options struct {
+	chunkSize     int
+	highWaterMark int
+}
+
+// newOptions returns the defaults overridden by opts.
+func newOptions(opts []Option) options {
+	o := options{chunkSize: defaultChunkSize, highWaterMark: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithChunkSize sets the largest chunk read from the Go reader per pull (default 4096 bytes). Larger chunks
+// mean fewer calls between Go and JavaScript for bulk data.
+func WithChunkSize(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.chunkSize = n
+		}
+	}
+}
+
+// WithHighWaterMark sets the number of chunks the stream reads ahead of its JavaScript consumer (default 1).
+// Zero only reads when the consumer asks for data.
+func WithHighWaterMark(chunks int) Option {
+	return func(o *options) {
+		if chunks >= 0 {
+			o.highWaterMark = chunks
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package streamjs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+// byobChunkSize is the smallest read of a BYOB reader, which serves smaller reads from the rest of the chunk
+// like the chunks of other streams instead of waiting on the stream for each
+const byobChunkSize = 64 << 10
+
+// Reader implements io.ReadCloser by reading from a JavaScript ReadableStream.
+// It adapts JavaScript's promise-based stream model to Go's pull-based io.Reader model.
+// Chunks larger than the caller's buffer are retained and returned by subsequent reads.
+type Reader struct {
+	// jsReader holds the JavaScript ReadableStreamDefaultReader object obtained from getReader(), or the
+	// ReadableStreamBYOBReader of a Reader created by NewBYOBReader
+	jsReader js.Value
+	// byob reports whether jsReader is a ReadableStreamBYOBReader
+	byob bool
+	// buffer is the ArrayBuffer a BYOB reader reads into, handed back by the stream after every read
+	buffer js.Value
+
+	// mu serializes Read and Close calls
+	mu sync.Mutex
+	// pending holds the unread remainder of the last chunk as a Uint8Array view
+	pending js.Value
+	// pendingLen is the number of bytes remaining in pending
+	pendingLen int
+	// closed tracks whether the reader has been closed to prevent further reads
+	closed bool
+	// err is the error returned once the stream has ended: io.EOF or the reason it failed
+	err error
+	// cancelOnce guards cancelling the stream, which may happen while a Read is pending
+	cancelOnce sync.Once
+
+	// onRead and onError settle every read() promise, which creates no callback per chunk
+	onRead, onError js.Func
+	// results receives the outcome of the pending read() promise
+	results chan readResult
+	// releaseOnce guards releasing onRead and onError
+	releaseOnce sync.Once
+}
+
+// readResult is the outcome of a read() promise.
+type readResult struct {
+	// value is the ReadableStreamReadResult
+	value js.Value
+	err   error
+}
+
+// NewReader acquires a reader for the given JavaScript ReadableStream and wraps it as an io.ReadCloser.
+// The stream is locked to the returned Reader until Close is called.
+func NewReader(stream js.Value) *Reader {
+	return newReader(stream.Call("getReader"), false)
+}
+
+// NewBYOBReader is NewReader reading the stream with a ReadableStreamBYOBReader if it is a byte stream, like
+// the bodies of fetch: every chunk is read into a buffer reused across reads, sized to the larger of the Go
+// read and 64 KiB, instead of the stream allocating a chunk per read. Other streams are read like NewReader
+// does.
+func NewBYOBReader(stream js.Value) *Reader {
+	jsReader, ok := byobReader(stream)
+	if !ok {
+		return NewReader(stream)
+	}
+	return newReader(jsReader, true)
+}
+
+// byobReader acquires a ReadableStreamBYOBReader for stream, which getReader refuses with a TypeError for
+// streams that are not byte streams.
+func byobReader(stream js.Value) (jsReader js.Value, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	opts := _Object.New()
+	opts.Set("mode", "byob")
+	return stream.Call("getReader", opts), true
+}
+
+// newReader wraps jsReader, a BYOB reader if byob is set.
+func newReader(jsReader js.Value, byob bool) *Reader {
+	r := &Reader{
+		jsReader: jsReader,
+		byob:     byob,
+		results:  make(chan readResult, 1),
+	}
+
+	// Creating two callbacks per read() dominated the cost of small chunks, so they are created once.
+	r.onRead = jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		r.results <- readResult{value: args[0]}
+		return nil
+	})
+	r.onError = jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		reason := js.Undefined()
+		if len(args) > 0 {
+			reason = args[0]
+		}
+		var err error = promisejs.NewError(reason)
+		if errors.Is(err, promisejs.ErrRejected) {
+			err = ErrStreamFailed
+		}
+		r.results <- readResult{err: err}
+		return nil
+	})
+	return r
+}
+
+// Read reads data from the JavaScript ReadableStream into the provided buffer.
+// Blocks until data is available or the stream ends. Returns io.EOF when the stream is fully consumed.
+func (r *Reader) Read(p []byte) (int, error) {
+	jsguard.Check("streamjs.Reader.Read")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := r.fill(len(p)); err != nil {
+		return 0, err
+	}
+
+	// Copy as much of the pending chunk as fits, keeping a view of the remainder for the next call
+	n := copyFromChunk(p, r.pending, r.pendingLen)
+	r.pendingLen -= n
+	if r.pendingLen > 0 {
+		r.pending = r.pending.Call("subarray", n)
+	} else {
+		r.pending = js.Undefined()
+	}
+	return n, nil
+}
+
+// WriteTo writes the rest of the stream to w until it ends, copying every chunk whole instead of through
+// the buffer of a Read call. It implements io.WriterTo, which io.Copy prefers.
+func (r *Reader) WriteTo(w io.Writer) (int64, error) {
+	jsguard.Check("streamjs.Reader.WriteTo")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, nil
+	}
+	// buf is reused for every chunk, which is at most as large as the largest chunk of the stream
+	var (
+		buf   []byte
+		total int64
+	)
+	for {
+		if err := r.fill(byobChunkSize); err == io.EOF {
+			return total, nil
+		} else if err != nil {
+			return total, err
+		}
+
+		if cap(buf) < r.pendingLen {
+			buf = make([]byte, r.pendingLen)
+		}
+		n := js.CopyBytesToGo(buf[:r.pendingLen], r.pending)
+		r.pending = js.Undefined()
+		r.pendingLen = 0
+
+		written, err := w.Write(buf[:n])
+		total += int64(written)
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// ReadContext is Read bounded by ctx. A chunk cannot be put back into the stream, so once ctx is done the
+// stream is cancelled and ReadContext returns ctx.Err(); later reads return io.EOF.
+func (r *Reader) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		r.cancel(ctx.Err())
+	})
+	defer stop()
+
+	n, err := r.Read(p)
+	if err != nil && ctx.Err() != nil {
+		return n, ctx.Err()
+	}
+	return n, err
+}
+
+// ReadAsync is ReadContext in a new goroutine, for JavaScript callbacks, which must not block. p must not be
+// used until the result was received.
+func (r *Reader) ReadAsync(ctx context.Context, p []byte) <-chan jsguard.Result[int] {
+	return jsguard.Go(func() (int, error) {
+		return r.ReadContext(ctx, p)
+	})
+}
+
+// Close cancels the underlying stream and releases the reader lock. A pending Read returns io.EOF.
+// Safe to call multiple times. Subsequent Read calls will return io.EOF.
+func (r *Reader) Close() error {
+	// Cancelling first settles a pending read, which holds the lock
+	r.cancel(nil)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	r.pending = js.Undefined()
+	r.pendingLen = 0
+	r.freeFuncs()
+	return nil
+}
+
+// fill fetches the next non-empty chunk into pending if nothing is left over from the previous read. A BYOB
+// reader reads a chunk of at least size bytes. The caller must hold mu.
+func (r *Reader) fill(size int) error {
+	for r.pendingLen == 0 {
+		if r.err != nil {
+			return r.err
+		}
+		var result js.Value
+		var err error
+		if r.byob {
+			result, err = r.readInto(max(size, byobChunkSize))
+		} else {
+			result, err = r.read()
+		}
+		if err != nil {
+			r.end(err)
+			return err
+		}
+		if result.Get("done").Bool() {
+			r.end(io.EOF)
+			return io.EOF
+		}
+
+		chunk := result.Get("value")
+		if chunk.IsNull() || chunk.IsUndefined() {
+			continue
+		}
+		r.pending = chunk
+		r.pendingLen = chunk.Get("byteLength").Int()
+	}
+	return nil
+}
+
+// readInto reads up to n bytes with the BYOB reader into buffer, which the stream transfers to the view of
+// the result. The view holds the buffer for the next read, which is only issued once the view was consumed.
+// The caller must hold mu.
+func (r *Reader) readInto(n int) (js.Value, error) {
+	if r.buffer.IsUndefined() || r.buffer.Get("byteLength").Int() < n {
+		r.buffer = _ArrayBuffer.New(n)
+	}
+	result, err := r.read(_Uint8Array.New(r.buffer, 0, n))
+	if err != nil {
+		return result, err
+	}
+	if view := result.Get("value"); !view.IsUndefined() {
+		r.buffer = view.Get("buffer")
+	} else {
+		r.buffer = js.Undefined()
+	}
+	return result, nil
+}
+
+// read waits for the next ReadableStreamReadResult, of a read into view for a BYOB reader. The caller must
+// hold mu, so one read is pending at most.
+func (r *Reader) read(view ...any) (js.Value, error) {
+	r.jsReader.Call("read", view...).Call("then", r.onRead, r.onError)
+	result := <-r.results
+	return result.value, result.err
+}
+
+// end records err as the outcome of every later read and releases the callbacks, which no read settles
+// anymore. The caller must hold mu.
+func (r *Reader) end(err error) {
+	r.err = err
+	r.freeFuncs()
+}
+
+// freeFuncs releases onRead and onError. Only the first call has an effect.
+func (r *Reader) freeFuncs() {
+	r.releaseOnce.Do(func() {
+		jsguard.Release(r.onRead)
+		jsguard.Release(r.onError)
+	})
+}
+
+// cancel calls cancel() on the JavaScript ReadableStreamDefaultReader to stop the source, with reason unless
+// it is nil. Only the first call has an effect.
+func (r *Reader) cancel(reason error) {
+	r.cancelOnce.Do(func() {
+		if r.jsReader.IsNull() || r.jsReader.IsUndefined() {
+			return
+		}
+		var promise js.Value
+		if reason == nil {
+			promise = r.jsReader.Call("cancel")
+		} else {
+			promise = r.jsReader.Call("cancel", promisejs.ErrorValue(reason))
+		}
+		// The promise is rejected if the stream has failed, which must not be reported as unhandled
+		promisejs.Then(promise, func(js.Value, error) {})
+	})
+}
+
+// copyFromChunk copies up to len(p) bytes of the Uint8Array chunk (of length chunkLen) into p.
+func copyFromChunk(p []byte, chunk js.Value, chunkLen int) int {
+	copyLen := chunkLen
+	if copyLen > len(p) {
+		copyLen = len(p)
+		chunk = chunk.Call("subarray", 0, copyLen)
+	}
+	return js.CopyBytesToGo(p[:copyLen], chunk)
+}
+
+// await blocks until the given JavaScript promise settles and returns its value or rejection reason.
+// Rejections without a reason are reported as ErrStreamFailed.
+func await(promise js.Value) (js.Value, error) {
+	v, err := promisejs.Await(context.Background(), promise)
+	if errors.Is(err, promisejs.ErrRejected) {
+		return v, ErrStreamFailed
+	}
+	return v, err
+}
@@ -0,0 +1,13 @@
+// Package streamjs adapts JavaScript ReadableStreams and WritableStreams to io.Reader and io.Writer and
+// exposes Go readers as ReadableStreams. Outside the browser a ReadableStream only holds its reader, and the
+// stream adapters are not available.
+package streamjs
+
+import "errors"
+
+var (
+	// ErrStreamFailed is returned when a JavaScript stream operation fails without a reason
+	ErrStreamFailed = errors.New("stream operation failed")
+	// ErrWriterClosed is returned when writing to a Writer that has been closed
+	ErrWriterClosed = errors.New("stream writer closed")
+)
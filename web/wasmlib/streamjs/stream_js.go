@@ -4,6 +4,8 @@ import (
 	"io"
 	"sync"
 	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
 )
 
 var (
@@ -12,6 +14,7 @@ var (
 	_Promise        = js.Global().Get("Promise")
 	_Error          = js.Global().Get("Error")
 	_Uint8Array     = js.Global().Get("Uint8Array")
+	_ArrayBuffer    = js.Global().Get("ArrayBuffer")
 )
 
 type ReadableStream struct {
@@ -21,88 +24,94 @@ type ReadableStream struct {
 
 	// buffer is used to temporarily store data read from the underlying Go reader
 	buffer []byte
+	// resolve and reject settle the Promise of the pull in progress
+	resolve, reject js.Value
 
 	funcsToBeReleased []js.Func
 }
 
-// NewReadableStream wraps a Go io.ReadCloser into a JavaScript ReadableStream object.
+// NewReadableStream wraps a Go io.ReadCloser into a JavaScript ReadableStream object, configured by opts.
 // This allows streaming data from Go to JavaScript in an asynchronous, non-blocking manner.
-func NewReadableStream(r io.ReadCloser) *ReadableStream {
+func NewReadableStream(r io.ReadCloser, opts ...Option) *ReadableStream {
+	o := newOptions(opts)
+
 	// 1. First, create the Go wrapper struct that holds the reader and manages lifecycle.
 	rs := &ReadableStream{
 		r:      r,
-		buffer: make([]byte, 4096), // Initialize with 4KB buffer to minimize allocations
+		buffer: make([]byte, o.chunkSize), // Reused for every pull to minimize allocations
 	}
 
 	// 2. Define JS callback functions that will be invoked by the JavaScript ReadableStream.
 	// These functions capture the 'rs' pointer in their closure to access the reader.
-	var onStart, onPull, onCancel js.Func
+	var onStart, onPull, onCancel, executor js.Func
 
 	// onStart: Called when the stream is first created (typically left empty as no setup is needed)
-	onStart = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+	onStart = jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
 		// controller := args[0]
 		return nil
 	})
 
 	// onPull: Called when JavaScript requests more data from the stream (most critical callback).
 	// This is where actual I/O reading happens.
-	onPull = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+	onPull = jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
 		controller := args[0]
 
 		// 3. Create and return a Promise to handle the asynchronous data reading.
 		// We return a Promise to prevent blocking the JS thread during potentially blocking I/O.
-		// The actual reading happens in a separate goroutine.
-		var promiseFn js.Func
-		promiseFn = js.FuncOf(func(this js.Value, pArgs []js.Value) interface{} {
-			resolve := pArgs[0]
-			reject := pArgs[1]
-
-			// 4. Launch a goroutine to perform the potentially blocking Read operation.
-			// This ensures the JS thread is never blocked waiting for I/O.
-			go func() {
-				defer promiseFn.Release()
-
-				n, err := rs.r.Read(rs.buffer)
-
-				// 5. Handle errors that may occur during reading
-				if err != nil {
-					if err == io.EOF {
-						// 5a. End of file (EOF) reached - close the stream normally
-						controller.Call("close")
-					} else {
-						// 5b. Actual read error occurred - signal error to the stream and reject the promise
-						jsErr := _Error.New(err.Error())
-						controller.Call("error", jsErr)
-						reject.Invoke(jsErr) // Reject the promise with the error
-					}
-					resolve.Invoke() // Resolve promise to indicate pull operation is complete
-					return
+		// The executor runs synchronously inside the constructor, so resolve and reject are set below.
+		promise := _Promise.New(executor)
+		resolve, reject := rs.resolve, rs.reject
+
+		// 4. Launch a goroutine to perform the potentially blocking Read operation.
+		// This ensures the JS thread is never blocked waiting for I/O.
+		go func() {
+			n, err := rs.r.Read(rs.buffer)
+
+			// 5. Enqueue the data read for JavaScript to consume, even if the read also returned an error,
+			// as readers like gzip return the last bytes together with io.EOF
+			if n > 0 {
+				// 5a. Create a JavaScript Uint8Array with the exact number of bytes read
+				jsChunk := _Uint8Array.New(n)
+
+				// 5b. Copy bytes from Go buffer (rs.buffer[:n]) to JS Uint8Array
+				js.CopyBytesToJS(jsChunk, rs.buffer[:n])
+
+				// 5c. Add the chunk to the stream controller's queue for JavaScript to consume
+				controller.Call("enqueue", jsChunk)
+			}
+
+			// 6. Handle errors that may occur during reading
+			if err != nil {
+				if err == io.EOF {
+					// 6a. End of file (EOF) reached - close the stream normally
+					controller.Call("close")
+				} else {
+					// 6b. Actual read error occurred - signal error to the stream and reject the promise
+					jsErr := _Error.New(err.Error())
+					controller.Call("error", jsErr)
+					reject.Invoke(jsErr) // Reject the promise with the error
 				}
+				resolve.Invoke() // Resolve promise to indicate pull operation is complete
+				return
+			}
 
-				// 6. Successfully read data - process and enqueue it for JavaScript to consume
-				if n > 0 {
-					// 6a. Create a JavaScript Uint8Array with the exact number of bytes read
-					jsChunk := _Uint8Array.New(n)
-
-					// 6b. Copy bytes from Go buffer (rs.buffer[:n]) to JS Uint8Array
-					js.CopyBytesToJS(jsChunk, rs.buffer[:n])
-
-					// 6c. Add the chunk to the stream controller's queue for JavaScript to consume
-					controller.Call("enqueue", jsChunk)
-				}
+			// 7. Signal successful completion of the pull operation by resolving the promise
+			resolve.Invoke()
+		}()
 
-				// 7. Signal successful completion of the pull operation by resolving the promise
-				resolve.Invoke()
-			}()
-
-			return nil
-		})
+		return promise
+	})
 
-		return _Promise.New(promiseFn)
+	// executor: Stores the resolving functions of the Promise returned by onPull. The stream calls pull
+	// again only once that Promise is resolved, so one executor serves every pull instead of a callback
+	// per pull.
+	executor = jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		rs.resolve, rs.reject = args[0], args[1]
+		return nil
 	})
 
 	// onCancel: Called when JavaScript side cancels the stream (e.g., due to consumption stoppage)
-	onCancel = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+	onCancel = jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
 		// Close the Go reader and clean up resources when stream is cancelled
 		rs.closeOnce.Do(func() {
 			rs.r.Close()
@@ -116,12 +125,14 @@ func NewReadableStream(r io.ReadCloser) *ReadableStream {
 	underlyingSource.Set("pull", onPull)
 	underlyingSource.Set("cancel", onCancel)
 
-	// 9. Create the actual JavaScript ReadableStream instance with the underlying source
-	stream := _ReadableStream.New(underlyingSource)
+	// 9. Create the actual JavaScript ReadableStream instance with the underlying source and queuing strategy
+	strategy := _Object.New()
+	strategy.Set("highWaterMark", o.highWaterMark)
+	stream := _ReadableStream.New(underlyingSource, strategy)
 
 	// 10. Complete the Go wrapper struct by assigning the JS stream and tracking functions for cleanup
 	rs.Value = stream
-	rs.funcsToBeReleased = []js.Func{onStart, onPull, onCancel}
+	rs.funcsToBeReleased = []js.Func{onStart, onPull, onCancel, executor}
 
 	return rs
 }
@@ -131,7 +142,7 @@ func NewReadableStream(r io.ReadCloser) *ReadableStream {
 func (rs *ReadableStream) Close() {
 	// Release all JavaScript function callbacks to allow garbage collection
 	for _, f := range rs.funcsToBeReleased {
-		f.Release()
+		jsguard.Release(f)
 	}
 
 	// Also close the underlying Go reader to free associated resources.
@@ -0,0 +1,160 @@
+package streamjs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync/atomic"
+	"syscall/js"
+	"testing"
+	"time"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+const (
+	// benchmarkSize is the amount of data copied through a stream per benchmark iteration
+	benchmarkSize = 1 << 20
+	// benchmarkChunk is the chunk size of the benchmark streams
+	benchmarkChunk = 16 << 10
+)
+
+func TestMain(m *testing.M) {
+	jsguard.Track(true)
+	os.Exit(m.Run())
+}
+
+// closeRecorder is a reader recording whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+// Close records the call.
+func (c *closeRecorder) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	defer jsguard.VerifyNone(t, jsguard.Mark())
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), 4<<10)
+	for name, newReader := range map[string]func(js.Value) *Reader{"default": NewReader, "byob": NewBYOBReader} {
+		t.Run(name, func(t *testing.T) {
+			rs := NewReadableStream(io.NopCloser(bytes.NewReader(data)), WithChunkSize(1000))
+			defer rs.Close()
+			r := newReader(rs.Value)
+			defer r.Close()
+
+			// Reads smaller than a chunk keep the rest for the next read
+			got, err := io.ReadAll(io.LimitReader(r, 100))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rest, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got = append(got, rest...); !bytes.Equal(got, data) {
+				t.Fatalf("read %d bytes, want %d", len(got), len(data))
+			}
+			if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+				t.Fatalf("Read after the end: %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	defer jsguard.VerifyNone(t, jsguard.Mark())
+
+	src := &closeRecorder{Reader: bytes.NewReader(make([]byte, 1<<20))}
+	rs := NewReadableStream(src, WithChunkSize(1<<10))
+	defer rs.Close()
+	r := NewReader(rs.Value)
+	if _, err := r.Read(make([]byte, 10)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing the reader early cancels the stream, which closes the Go reader
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(make([]byte, 10)); err == nil {
+		t.Fatal("Read after Close succeeded")
+	}
+	for range 100 {
+		if src.closed.Load() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("cancelled stream did not close its source")
+}
+
+func TestWriterCloseContext(t *testing.T) {
+	// The sink never accepts a chunk, so closing waits for a queued write forever
+	stream := js.Global().Get("Function").New("return new WritableStream({write() { return new Promise(() => {}) }})").Invoke()
+	w := NewWriter(stream)
+	w.jsWriter.Call("write", _Uint8Array.New(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := w.CloseContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("CloseContext: %v, want context.DeadlineExceeded", err)
+	}
+	if _, err := w.Write([]byte("late")); err != ErrWriterClosed {
+		t.Fatalf("Write after CloseContext: %v, want ErrWriterClosed", err)
+	}
+}
+
+// benchmarkStream returns a Reader of a ReadableStream reading data from Go in benchmarkChunk chunks.
+func benchmarkStream(data []byte) (*ReadableStream, *Reader) {
+	rs := NewReadableStream(io.NopCloser(bytes.NewReader(data)), WithChunkSize(benchmarkChunk))
+	return rs, NewReader(rs.Value)
+}
+
+// BenchmarkStreamCopy copies from Go through a ReadableStream back to Go with io.Copy, which takes whole
+// chunks through Reader.WriteTo.
+func BenchmarkStreamCopy(b *testing.B) {
+	data := make([]byte, benchmarkSize)
+	b.SetBytes(benchmarkSize)
+	b.ReportAllocs()
+	for b.Loop() {
+		rs, r := benchmarkStream(data)
+		if n, err := io.Copy(io.Discard, r); err != nil || n != benchmarkSize {
+			b.Fatalf("copied %d bytes: %v", n, err)
+		}
+		r.Close()
+		rs.Close()
+	}
+}
+
+// BenchmarkStreamRead reads from Go through a ReadableStream back to Go one chunk per Read call.
+func BenchmarkStreamRead(b *testing.B) {
+	data := make([]byte, benchmarkSize)
+	buf := make([]byte, benchmarkChunk)
+	b.SetBytes(benchmarkSize)
+	b.ReportAllocs()
+	for b.Loop() {
+		rs, r := benchmarkStream(data)
+		total := 0
+		for {
+			n, err := r.Read(buf)
+			total += n
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		if total != benchmarkSize {
+			b.Fatalf("read %d bytes", total)
+		}
+		r.Close()
+		rs.Close()
+	}
+}
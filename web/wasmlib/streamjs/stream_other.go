@@ -0,0 +1,27 @@
+//go:build !js
+
+package streamjs
+
+import (
+	"io"
+	"sync"
+)
+
+// ReadableStream holds a Go reader meant to be exposed to JavaScript. Outside the browser there is no
+// JavaScript side, so it only closes the reader.
+type ReadableStream struct {
+	r         io.ReadCloser
+	closeOnce sync.Once
+}
+
+// NewReadableStream wraps a Go io.ReadCloser. The options only apply in the browser.
+func NewReadableStream(r io.ReadCloser, opts ...Option) *ReadableStream {
+	return &ReadableStream{r: r}
+}
+
+// Close closes the underlying reader. Safe to call multiple times.
+func (rs *ReadableStream) Close() {
+	rs.closeOnce.Do(func() {
+		rs.r.Close()
+	})
+}
@@ -0,0 +1,119 @@
+package streamjs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+// Writer implements io.WriteCloser by writing to a JavaScript WritableStream.
+// Each Write is copied into a fresh Uint8Array and blocks until the sink has accepted the chunk,
+// so backpressure from the JavaScript side is propagated to the Go writer.
+type Writer struct {
+	// jsWriter holds the JavaScript WritableStreamDefaultWriter object obtained from getWriter()
+	jsWriter js.Value
+
+	// mu serializes Write and Close calls
+	mu sync.Mutex
+	// closed tracks whether the writer has been closed to prevent further writes
+	closed bool
+}
+
+// NewWriter acquires a writer for the given JavaScript WritableStream and wraps it as an io.WriteCloser.
+// The stream is locked to the returned Writer until Close is called.
+func NewWriter(stream js.Value) *Writer {
+	return &Writer{
+		jsWriter: stream.Call("getWriter"),
+	}
+}
+
+// Write copies p into a JavaScript Uint8Array and writes it to the stream.
+// Blocks until the underlying sink has processed the chunk.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	// Copy the Go bytes into a new Uint8Array since the sink may hold on to the chunk
+	chunk := _Uint8Array.New(len(p))
+	js.CopyBytesToJS(chunk, p)
+
+	if _, err := await(w.jsWriter.Call("write", chunk)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteContext is Write bounded by ctx. A chunk cannot be taken back from the stream, so once ctx is done the
+// stream is aborted and WriteContext returns ctx.Err(); later writes fail.
+func (w *Writer) WriteContext(ctx context.Context, p []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		// Aborting rejects the pending write, which holds the lock
+		w.jsWriter.Call("abort", promisejs.ErrorValue(ctx.Err()))
+	})
+	defer stop()
+
+	n, err := w.Write(p)
+	if err != nil && ctx.Err() != nil {
+		return n, ctx.Err()
+	}
+	return n, err
+}
+
+// Close closes the underlying stream after all queued writes have completed and releases the writer lock.
+// Safe to call multiple times.
+func (w *Writer) Close() error {
+	return w.CloseContext(context.Background())
+}
+
+// CloseContext is Close bounded by ctx, e.g. to give up on a sink that no longer accepts chunks. Once ctx is
+// done the stream is aborted, discarding queued chunks, the writer lock is released and CloseContext returns
+// ctx.Err().
+func (w *Writer) CloseContext(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	closed := w.jsWriter.Call("close")
+	_, err := promisejs.Await(ctx, closed)
+	if err != nil && ctx.Err() != nil {
+		// The abort takes effect once a write in flight completes; the close fails then
+		w.jsWriter.Call("abort", promisejs.ErrorValue(ctx.Err()))
+		err = ctx.Err()
+	} else if errors.Is(err, promisejs.ErrRejected) {
+		err = ErrStreamFailed
+	}
+	w.jsWriter.Call("releaseLock")
+	return err
+}
+
+// Abort aborts the underlying stream, discarding any queued chunks, and releases the writer lock.
+func (w *Writer) Abort(reason string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	_, err := await(w.jsWriter.Call("abort", _Error.New(reason)))
+	w.jsWriter.Call("releaseLock")
+	return err
+}
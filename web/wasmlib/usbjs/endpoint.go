@@ -0,0 +1,234 @@
+package usbjs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrEndpointClosed is returned when using an endpoint stream after it has been closed
+var ErrEndpointClosed = errors.New("usb endpoint stream closed")
+
+// EndpointReader implements io.Reader over repeated bulk or interrupt IN transfers on a single endpoint.
+// Each underlying transfer requests TransferSize bytes; data that does not fit in the caller's buffer
+// is kept and returned by subsequent reads.
+type EndpointReader struct {
+	device   *Device
+	endpoint int
+
+	// TransferSize is the number of bytes requested per IN transfer.
+	// It should be a multiple of the endpoint's packet size to avoid babble errors.
+	TransferSize int
+
+	mu            sync.Mutex
+	currentBuffer []byte      // Remaining bytes from the last transfer that didn't fit in the read buffer
+	pending       *inTransfer // Transfer of a cancelled read, waited for by the next read
+	done          context.Context
+	close         context.CancelFunc // Cancels done, interrupting a pending read
+}
+
+// EndpointWriter implements io.Writer over bulk or interrupt OUT transfers on a single endpoint.
+// Each Write is sent as a single transfer; the browser splits it into packets.
+type EndpointWriter struct {
+	device   *Device
+	endpoint int
+
+	mu    sync.Mutex
+	done  context.Context
+	close context.CancelFunc // Cancels done, interrupting a pending write
+}
+
+// NewEndpointReader creates a reader for the IN endpoint with the given number.
+// transferSize is typically the endpoint's packet size or a multiple of it.
+func (d *Device) NewEndpointReader(endpoint, transferSize int) *EndpointReader {
+	done, cancel := context.WithCancel(context.Background())
+	return &EndpointReader{
+		device:       d,
+		endpoint:     endpoint,
+		TransferSize: transferSize,
+		done:         done,
+		close:        cancel,
+	}
+}
+
+// NewEndpointWriter creates a writer for the OUT endpoint with the given number.
+func (d *Device) NewEndpointWriter(endpoint int) *EndpointWriter {
+	done, cancel := context.WithCancel(context.Background())
+	return &EndpointWriter{
+		device:   d,
+		endpoint: endpoint,
+		done:     done,
+		close:    cancel,
+	}
+}
+
+// Read implements io.Reader by issuing IN transfers until data is received.
+// Zero length packets are skipped. A stalled endpoint is cleared once and reported as ErrStall.
+func (r *EndpointReader) Read(p []byte) (int, error) {
+	return r.ReadContext(context.Background(), p)
+}
+
+// ReadContext is Read bounded by ctx, e.g. to poll a device that may stay silent. The transfer of a read
+// cancelled by ctx goes on and is waited for by the next read, so no data is lost.
+func (r *EndpointReader) ReadContext(ctx context.Context, p []byte) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(r.done, cancel)
+	defer stop()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done.Err() != nil {
+		return 0, io.EOF
+	}
+
+	// If we have remaining buffered data from a previous transfer, use it first to avoid data loss
+	if len(r.currentBuffer) > 0 {
+		n := copy(p, r.currentBuffer)
+		r.currentBuffer = r.currentBuffer[n:]
+		return n, nil
+	}
+
+	for {
+		if r.pending == nil {
+			r.pending = r.device.startTransferIn(r.endpoint, r.TransferSize)
+		}
+		data, err := r.pending.wait(ctx)
+		if err != nil && ctx.Err() != nil {
+			if r.done.Err() != nil {
+				return 0, io.EOF
+			}
+			return 0, ctx.Err()
+		}
+		r.pending = nil
+		if err != nil {
+			if err == ErrStall {
+				r.device.ClearHaltContext(ctx, DirectionIn, r.endpoint)
+			}
+			return 0, err
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		n := copy(p, data)
+		if n < len(data) {
+			r.currentBuffer = data[n:]
+		}
+		return n, nil
+	}
+}
+
+// Close closes the reader, interrupting a pending read, which returns io.EOF like later ones. WebUSB cannot
+// cancel the transfer of a pending read; release the interface or close the device to stop it.
+func (r *EndpointReader) Close() error {
+	r.close()
+	return nil
+}
+
+// Write implements io.Writer by sending p as a single OUT transfer.
+// Returns io.ErrShortWrite if the device accepted fewer bytes than provided.
+func (w *EndpointWriter) Write(p []byte) (int, error) {
+	return w.WriteContext(context.Background(), p)
+}
+
+// WriteContext is Write bounded by ctx. Once ctx is done the transfer goes on in the browser, so the device
+// may still receive p.
+func (w *EndpointWriter) WriteContext(ctx context.Context, p []byte) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(w.done, cancel)
+	defer stop()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.done.Err() != nil {
+		return 0, ErrEndpointClosed
+	}
+
+	n, err := w.device.TransferOutContext(ctx, w.endpoint, p)
+	if err != nil {
+		if w.done.Err() != nil {
+			return 0, ErrEndpointClosed
+		}
+		if err == ErrStall {
+			w.device.ClearHaltContext(ctx, DirectionOut, w.endpoint)
+		}
+		return n, err
+	}
+	if n < len(p) {
+		return n, io.ErrShortWrite
+	}
+	return n, nil
+}
+
+// Close closes the writer, interrupting a pending write. Subsequent writes return ErrEndpointClosed.
+func (w *EndpointWriter) Close() error {
+	w.close()
+	return nil
+}
+
+// EndpointPipe combines an IN and an OUT endpoint of a claimed interface into an io.ReadWriteCloser.
+type EndpointPipe struct {
+	*EndpointReader
+	*EndpointWriter
+
+	device    *Device
+	iface     int
+	closeOnce sync.Once
+}
+
+// OpenPipe opens the device, claims the interface and returns a pipe over its first bulk or interrupt
+// IN/OUT endpoint pair. Closing the pipe releases the interface.
+func (d *Device) OpenPipe(iface int) (*EndpointPipe, error) {
+	if !d.Opened() {
+		if err := d.Open(); err != nil {
+			return nil, err
+		}
+	}
+	if err := d.ClaimInterface(iface); err != nil {
+		return nil, err
+	}
+
+	// Pick the first IN and OUT endpoints usable for bulk or interrupt transfers
+	var in, out *Endpoint
+	endpoints := d.Endpoints(iface)
+	for i := range endpoints {
+		ep := &endpoints[i]
+		if ep.Type != EndpointBulk && ep.Type != EndpointInterrupt {
+			continue
+		}
+		if ep.Direction == DirectionIn && in == nil {
+			in = ep
+		}
+		if ep.Direction == DirectionOut && out == nil {
+			out = ep
+		}
+	}
+	if in == nil || out == nil {
+		d.ReleaseInterface(iface)
+		return nil, errors.New("usb interface has no bulk or interrupt endpoint pair")
+	}
+
+	return &EndpointPipe{
+		EndpointReader: d.NewEndpointReader(in.Number, in.PacketSize),
+		EndpointWriter: d.NewEndpointWriter(out.Number),
+		device:         d,
+		iface:          iface,
+	}, nil
+}
+
+// Close closes both directions of the pipe and releases the claimed interface.
+// Safe to call multiple times.
+func (p *EndpointPipe) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		p.EndpointReader.Close()
+		p.EndpointWriter.Close()
+		err = p.device.ReleaseInterface(p.iface)
+	})
+	return err
+}
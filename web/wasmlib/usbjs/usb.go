@@ -0,0 +1,102 @@
+// Package usbjs provides bindings for the WebUSB API: device selection, configuration, transfers and
+// io.Reader/io.Writer adapters over endpoints. Outside the browser RequestDevice and Devices return
+// ErrUnsupported.
+package usbjs
+
+import (
+	"errors"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jserr"
+)
+
+var (
+	// ErrUnsupported is returned when the WebUSB API is not available in the current context
+	ErrUnsupported = errors.New("webusb not supported")
+	// ErrStall is returned when the device stalls an endpoint; use ClearHalt to recover
+	ErrStall = jserr.New(jserr.ErrProtocol, "usb endpoint stalled")
+	// ErrBabble is returned when the device sent more data than requested
+	ErrBabble = jserr.New(jserr.ErrProtocol, "usb babble: device sent more data than expected")
+	// ErrRequestFailed is returned when a WebUSB operation fails without a reason
+	ErrRequestFailed = errors.New("usb request failed")
+)
+
+// Direction is the direction of a USB endpoint or transfer.
+type Direction string
+
+const (
+	DirectionIn  Direction = "in"  // Device to host
+	DirectionOut Direction = "out" // Host to device
+)
+
+// EndpointType is the transfer type of a USB endpoint.
+type EndpointType string
+
+const (
+	EndpointBulk        EndpointType = "bulk"        // Bulk transfers, reliable and unbounded latency
+	EndpointInterrupt   EndpointType = "interrupt"   // Interrupt transfers, small and latency bounded
+	EndpointIsochronous EndpointType = "isochronous" // Isochronous transfers, not supported by the reader/writer pairs
+)
+
+// Filter restricts the devices offered to the user in RequestDevice.
+// Zero values are treated as wildcards.
+type Filter struct {
+	VendorID     uint16 // USB vendor ID
+	ProductID    uint16 // USB product ID
+	ClassCode    uint8  // Device or interface class code
+	SubclassCode uint8  // Device or interface subclass code
+	ProtocolCode uint8  // Device or interface protocol code
+	SerialNumber string // Device serial number
+}
+
+// DeviceInfo describes a USB device as reported by its device descriptor.
+type DeviceInfo struct {
+	VendorID         uint16 // USB vendor ID
+	ProductID        uint16 // USB product ID
+	ManufacturerName string // Manufacturer string descriptor, if any
+	ProductName      string // Product string descriptor, if any
+	SerialNumber     string // Serial number string descriptor, if any
+	ClassCode        uint8  // Device class code
+}
+
+// Endpoint describes an endpoint of the currently selected alternate setting of an interface.
+type Endpoint struct {
+	Number     int          // Endpoint number (without the direction bit)
+	Direction  Direction    // Transfer direction
+	Type       EndpointType // Transfer type
+	PacketSize int          // Maximum packet size in bytes
+}
+
+// ControlSetup describes the setup packet of a control transfer.
+type ControlSetup struct {
+	RequestType string // "standard", "class" or "vendor"
+	Recipient   string // "device", "interface", "endpoint" or "other"
+	Request     uint8  // bRequest field
+	Value       uint16 // wValue field
+	Index       uint16 // wIndex field
+}
+
+// Device represents a USB device granted by the user.
+type Device struct {
+	// device holds the JavaScript USBDevice object
+	device handle
+}
+
+// inTransfer is an IN transfer in progress, which can be waited for again after a wait was cancelled.
+type inTransfer struct {
+	// promise holds the JavaScript Promise of the USBInTransferResult
+	promise handle
+}
+
+// statusError maps a USBTransferStatus to a Go error.
+func statusError(status string) error {
+	switch status {
+	case "ok":
+		return nil
+	case "stall":
+		return ErrStall
+	case "babble":
+		return ErrBabble
+	default:
+		return ErrRequestFailed
+	}
+}
@@ -0,0 +1,349 @@
+package usbjs
+
+import (
+	"context"
+	"errors"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+var (
+	// _usb is a cached reference to navigator.usb, undefined outside of supporting browsers
+	_usb = js.Global().Get("navigator").Get("usb")
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+	// _Array is a cached reference to the JavaScript Array constructor for array operations
+	_Array = js.Global().Get("Array")
+	// _Uint8Array is a cached reference to the JavaScript Uint8Array constructor for typed array operations
+	_Uint8Array = js.Global().Get("Uint8Array")
+)
+
+// handle is the type of the JavaScript USBDevice object
+type handle = js.Value
+
+// RequestDevice prompts the user to select a USB device matching at least one of the filters.
+// Browsers only allow this call during a user gesture such as a click handler.
+func RequestDevice(filters ...Filter) (*Device, error) {
+	if !supported() {
+		return nil, ErrUnsupported
+	}
+
+	jsFilters := _Array.New()
+	for _, f := range filters {
+		jsFilter := _Object.New()
+		if f.VendorID != 0 {
+			jsFilter.Set("vendorId", f.VendorID)
+		}
+		if f.ProductID != 0 {
+			jsFilter.Set("productId", f.ProductID)
+		}
+		if f.ClassCode != 0 {
+			jsFilter.Set("classCode", f.ClassCode)
+		}
+		if f.SubclassCode != 0 {
+			jsFilter.Set("subclassCode", f.SubclassCode)
+		}
+		if f.ProtocolCode != 0 {
+			jsFilter.Set("protocolCode", f.ProtocolCode)
+		}
+		if f.SerialNumber != "" {
+			jsFilter.Set("serialNumber", f.SerialNumber)
+		}
+		jsFilters.Call("push", jsFilter)
+	}
+
+	opts := _Object.New()
+	opts.Set("filters", jsFilters)
+
+	device, err := await(context.Background(), _usb.Call("requestDevice", opts))
+	if err != nil {
+		return nil, err
+	}
+	return &Device{device: device}, nil
+}
+
+// Devices returns the USB devices the user has previously granted access to.
+// Does not require a user gesture.
+func Devices() ([]*Device, error) {
+	if !supported() {
+		return nil, ErrUnsupported
+	}
+
+	jsDevices, err := await(context.Background(), _usb.Call("getDevices"))
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]*Device, 0, jsDevices.Length())
+	for i := 0; i < jsDevices.Length(); i++ {
+		devices = append(devices, &Device{device: jsDevices.Index(i)})
+	}
+	return devices, nil
+}
+
+// Info returns the identification of the device.
+func (d *Device) Info() DeviceInfo {
+	return DeviceInfo{
+		VendorID:         uint16(d.device.Get("vendorId").Int()),
+		ProductID:        uint16(d.device.Get("productId").Int()),
+		ManufacturerName: optionalString(d.device.Get("manufacturerName")),
+		ProductName:      optionalString(d.device.Get("productName")),
+		SerialNumber:     optionalString(d.device.Get("serialNumber")),
+		ClassCode:        uint8(d.device.Get("deviceClass").Int()),
+	}
+}
+
+// Opened reports whether a session with the device is currently open.
+func (d *Device) Opened() bool {
+	return d.device.Get("opened").Bool()
+}
+
+// Open starts a session with the device. Must be called before any other operation.
+func (d *Device) Open() error {
+	_, err := await(context.Background(), d.device.Call("open"))
+	return err
+}
+
+// Close ends the session with the device, releasing all claimed interfaces.
+func (d *Device) Close() error {
+	if !d.Opened() {
+		return nil
+	}
+	_, err := await(context.Background(), d.device.Call("close"))
+	return err
+}
+
+// Forget revokes the permission the user granted for this device.
+func (d *Device) Forget() error {
+	if d.device.Get("forget").IsUndefined() {
+		return ErrUnsupported
+	}
+	_, err := await(context.Background(), d.device.Call("forget"))
+	return err
+}
+
+// Reset performs a USB port reset of the device.
+func (d *Device) Reset() error {
+	_, err := await(context.Background(), d.device.Call("reset"))
+	return err
+}
+
+// SelectConfiguration selects the device configuration with the given bConfigurationValue.
+func (d *Device) SelectConfiguration(value int) error {
+	_, err := await(context.Background(), d.device.Call("selectConfiguration", value))
+	return err
+}
+
+// ClaimInterface claims exclusive access to the interface with the given number.
+func (d *Device) ClaimInterface(number int) error {
+	// Devices start unconfigured on some platforms; select the first configuration in that case
+	if d.device.Get("configuration").IsNull() {
+		if err := d.SelectConfiguration(1); err != nil {
+			return err
+		}
+	}
+	_, err := await(context.Background(), d.device.Call("claimInterface", number))
+	return err
+}
+
+// ReleaseInterface releases a previously claimed interface.
+func (d *Device) ReleaseInterface(number int) error {
+	_, err := await(context.Background(), d.device.Call("releaseInterface", number))
+	return err
+}
+
+// SelectAlternateInterface selects an alternate setting of a claimed interface.
+func (d *Device) SelectAlternateInterface(number, alternate int) error {
+	_, err := await(context.Background(), d.device.Call("selectAlternateInterface", number, alternate))
+	return err
+}
+
+// Endpoints returns the endpoints of the active alternate setting of the given interface.
+// Returns nil if the device is unconfigured or the interface does not exist.
+func (d *Device) Endpoints(number int) []Endpoint {
+	config := d.device.Get("configuration")
+	if config.IsNull() || config.IsUndefined() {
+		return nil
+	}
+
+	interfaces := config.Get("interfaces")
+	for i := 0; i < interfaces.Length(); i++ {
+		iface := interfaces.Index(i)
+		if iface.Get("interfaceNumber").Int() != number {
+			continue
+		}
+
+		jsEndpoints := iface.Get("alternate").Get("endpoints")
+		endpoints := make([]Endpoint, 0, jsEndpoints.Length())
+		for j := 0; j < jsEndpoints.Length(); j++ {
+			ep := jsEndpoints.Index(j)
+			endpoints = append(endpoints, Endpoint{
+				Number:     ep.Get("endpointNumber").Int(),
+				Direction:  Direction(ep.Get("direction").String()),
+				Type:       EndpointType(ep.Get("type").String()),
+				PacketSize: ep.Get("packetSize").Int(),
+			})
+		}
+		return endpoints
+	}
+	return nil
+}
+
+// ClearHalt clears a stall condition on the given endpoint.
+func (d *Device) ClearHalt(direction Direction, endpoint int) error {
+	return d.ClearHaltContext(context.Background(), direction, endpoint)
+}
+
+// ClearHaltContext is ClearHalt bounded by ctx.
+func (d *Device) ClearHaltContext(ctx context.Context, direction Direction, endpoint int) error {
+	_, err := await(ctx, d.device.Call("clearHalt", string(direction), endpoint))
+	return err
+}
+
+// TransferIn performs a single bulk or interrupt IN transfer of up to length bytes from the endpoint.
+func (d *Device) TransferIn(endpoint int, length int) ([]byte, error) {
+	return d.TransferInContext(context.Background(), endpoint, length)
+}
+
+// TransferInContext is TransferIn bounded by ctx. WebUSB cannot cancel a transfer, so once ctx is done it
+// goes on in the browser until the device sends data, which is discarded, or the interface is released.
+// EndpointReader keeps such a transfer for its next read instead.
+func (d *Device) TransferInContext(ctx context.Context, endpoint int, length int) ([]byte, error) {
+	return d.startTransferIn(endpoint, length).wait(ctx)
+}
+
+// TransferOut performs a single bulk or interrupt OUT transfer of data to the endpoint.
+// Returns the number of bytes the device accepted.
+func (d *Device) TransferOut(endpoint int, data []byte) (int, error) {
+	return d.TransferOutContext(context.Background(), endpoint, data)
+}
+
+// TransferOutContext is TransferOut bounded by ctx. Once ctx is done the transfer goes on in the browser, so
+// the device may still receive the data.
+func (d *Device) TransferOutContext(ctx context.Context, endpoint int, data []byte) (int, error) {
+	result, err := await(ctx, d.device.Call("transferOut", endpoint, toUint8Array(data)))
+	if err != nil {
+		return 0, err
+	}
+	return outResult(result)
+}
+
+// ControlTransferIn performs a control transfer reading up to length bytes from the device.
+func (d *Device) ControlTransferIn(setup ControlSetup, length int) ([]byte, error) {
+	return d.ControlTransferInContext(context.Background(), setup, length)
+}
+
+// ControlTransferInContext is ControlTransferIn bounded by ctx. Once ctx is done the transfer goes on in the
+// browser and its data is discarded.
+func (d *Device) ControlTransferInContext(ctx context.Context, setup ControlSetup, length int) ([]byte, error) {
+	result, err := await(ctx, d.device.Call("controlTransferIn", setup.toJS(), length))
+	if err != nil {
+		return nil, err
+	}
+	return inResult(result)
+}
+
+// ControlTransferOut performs a control transfer sending data to the device.
+// Returns the number of bytes the device accepted.
+func (d *Device) ControlTransferOut(setup ControlSetup, data []byte) (int, error) {
+	return d.ControlTransferOutContext(context.Background(), setup, data)
+}
+
+// ControlTransferOutContext is ControlTransferOut bounded by ctx. Once ctx is done the transfer goes on in
+// the browser, so the device may still receive the data.
+func (d *Device) ControlTransferOutContext(ctx context.Context, setup ControlSetup, data []byte) (int, error) {
+	var result js.Value
+	var err error
+	if len(data) > 0 {
+		result, err = await(ctx, d.device.Call("controlTransferOut", setup.toJS(), toUint8Array(data)))
+	} else {
+		result, err = await(ctx, d.device.Call("controlTransferOut", setup.toJS()))
+	}
+	if err != nil {
+		return 0, err
+	}
+	return outResult(result)
+}
+
+// startTransferIn starts an IN transfer of up to length bytes from the endpoint.
+func (d *Device) startTransferIn(endpoint int, length int) *inTransfer {
+	return &inTransfer{promise: d.device.Call("transferIn", endpoint, length)}
+}
+
+// wait waits for the transfer to complete within ctx and returns the received bytes. It may be called again
+// after ctx was done.
+func (t *inTransfer) wait(ctx context.Context) ([]byte, error) {
+	result, err := await(ctx, t.promise)
+	if err != nil {
+		return nil, err
+	}
+	return inResult(result)
+}
+
+// toJS converts the setup packet into a USBControlTransferParameters dictionary.
+func (s ControlSetup) toJS() js.Value {
+	params := _Object.New()
+	params.Set("requestType", s.RequestType)
+	params.Set("recipient", s.Recipient)
+	params.Set("request", s.Request)
+	params.Set("value", s.Value)
+	params.Set("index", s.Index)
+	return params
+}
+
+// inResult extracts the received bytes from a USBInTransferResult.
+func inResult(result js.Value) ([]byte, error) {
+	if err := statusError(result.Get("status").String()); err != nil {
+		return nil, err
+	}
+
+	view := result.Get("data")
+	if view.IsNull() || view.IsUndefined() {
+		return []byte{}, nil
+	}
+
+	// data is a DataView; wrap the same memory region as a Uint8Array to copy it into Go
+	array := _Uint8Array.New(view.Get("buffer"), view.Get("byteOffset"), view.Get("byteLength"))
+	data := make([]byte, array.Get("byteLength").Int())
+	js.CopyBytesToGo(data, array)
+	return data, nil
+}
+
+// outResult extracts the number of written bytes from a USBOutTransferResult.
+func outResult(result js.Value) (int, error) {
+	if err := statusError(result.Get("status").String()); err != nil {
+		return 0, err
+	}
+	return result.Get("bytesWritten").Int(), nil
+}
+
+// toUint8Array copies a Go byte slice into a new JavaScript Uint8Array.
+func toUint8Array(data []byte) js.Value {
+	array := _Uint8Array.New(len(data))
+	js.CopyBytesToJS(array, data)
+	return array
+}
+
+// optionalString returns the string value of v, or "" if it is null or undefined.
+func optionalString(v js.Value) string {
+	if v.Type() != js.TypeString {
+		return ""
+	}
+	return v.String()
+}
+
+// supported reports whether navigator.usb is available.
+func supported() bool {
+	return !_usb.IsUndefined() && !_usb.IsNull()
+}
+
+// await blocks until the given JavaScript promise settles or ctx is done and returns its value or rejection
+// reason. Rejections without a reason are reported as ErrRequestFailed.
+func await(ctx context.Context, promise js.Value) (js.Value, error) {
+	v, err := promisejs.Await(ctx, promise)
+	if errors.Is(err, promisejs.ErrRejected) {
+		return v, ErrRequestFailed
+	}
+	return v, err
+}
@@ -0,0 +1,133 @@
+//go:build !js
+
+package usbjs
+
+import "context"
+
+// handle is empty outside the browser, where no devices can be requested.
+type handle = struct{}
+
+// RequestDevice returns ErrUnsupported outside the browser.
+func RequestDevice(filters ...Filter) (*Device, error) {
+	return nil, ErrUnsupported
+}
+
+// Devices returns ErrUnsupported outside the browser.
+func Devices() ([]*Device, error) {
+	return nil, ErrUnsupported
+}
+
+// Info returns the zero DeviceInfo.
+func (d *Device) Info() DeviceInfo {
+	return DeviceInfo{}
+}
+
+// Opened reports false.
+func (d *Device) Opened() bool {
+	return false
+}
+
+// Open returns ErrUnsupported.
+func (d *Device) Open() error {
+	return ErrUnsupported
+}
+
+// Close does nothing.
+func (d *Device) Close() error {
+	return nil
+}
+
+// Forget returns ErrUnsupported.
+func (d *Device) Forget() error {
+	return ErrUnsupported
+}
+
+// Reset returns ErrUnsupported.
+func (d *Device) Reset() error {
+	return ErrUnsupported
+}
+
+// SelectConfiguration returns ErrUnsupported.
+func (d *Device) SelectConfiguration(value int) error {
+	return ErrUnsupported
+}
+
+// ClaimInterface returns ErrUnsupported.
+func (d *Device) ClaimInterface(number int) error {
+	return ErrUnsupported
+}
+
+// ReleaseInterface returns ErrUnsupported.
+func (d *Device) ReleaseInterface(number int) error {
+	return ErrUnsupported
+}
+
+// SelectAlternateInterface returns ErrUnsupported.
+func (d *Device) SelectAlternateInterface(number, alternate int) error {
+	return ErrUnsupported
+}
+
+// Endpoints returns nil.
+func (d *Device) Endpoints(number int) []Endpoint {
+	return nil
+}
+
+// ClearHalt returns ErrUnsupported.
+func (d *Device) ClearHalt(direction Direction, endpoint int) error {
+	return ErrUnsupported
+}
+
+// ClearHaltContext returns ErrUnsupported.
+func (d *Device) ClearHaltContext(ctx context.Context, direction Direction, endpoint int) error {
+	return ErrUnsupported
+}
+
+// TransferIn returns ErrUnsupported.
+func (d *Device) TransferIn(endpoint int, length int) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+// TransferOut returns ErrUnsupported.
+func (d *Device) TransferOut(endpoint int, data []byte) (int, error) {
+	return 0, ErrUnsupported
+}
+
+// ControlTransferIn returns ErrUnsupported.
+func (d *Device) ControlTransferIn(setup ControlSetup, length int) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+// ControlTransferOut returns ErrUnsupported.
+func (d *Device) ControlTransferOut(setup ControlSetup, data []byte) (int, error) {
+	return 0, ErrUnsupported
+}
+
+// TransferInContext returns ErrUnsupported.
+func (d *Device) TransferInContext(ctx context.Context, endpoint int, length int) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+// TransferOutContext returns ErrUnsupported.
+func (d *Device) TransferOutContext(ctx context.Context, endpoint int, data []byte) (int, error) {
+	return 0, ErrUnsupported
+}
+
+// ControlTransferInContext returns ErrUnsupported.
+func (d *Device) ControlTransferInContext(ctx context.Context, setup ControlSetup, length int) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+// ControlTransferOutContext returns ErrUnsupported.
+func (d *Device) ControlTransferOutContext(ctx context.Context, setup ControlSetup, data []byte) (int, error) {
+	return 0, ErrUnsupported
+}
+
+// startTransferIn returns a transfer failing with ErrUnsupported.
+func (d *Device) startTransferIn(endpoint int, length int) *inTransfer {
+	return &inTransfer{}
+}
+
+// wait returns ErrUnsupported.
+func (t *inTransfer) wait(ctx context.Context) ([]byte, error) {
+	return nil, ErrUnsupported
+}
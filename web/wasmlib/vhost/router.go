@@ -0,0 +1,165 @@
+package vhost
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Router dispatches requests to handlers by host name and path prefix.
+// It is intended for service workers that serve several virtual origins at once, e.g. an app shell,
+// an API emulator and a proxy namespace, while letting other requests pass through to the network.
+//
+// Patterns have the form "[host]/path-prefix". The host may be an exact name ("api.example.com"),
+// a wildcard matching any subdomain ("*.example.com") or empty to match every host. A host without
+// a port matches requests on any port. Path prefixes match on segment boundaries: "/api" matches
+// "/api" and "/api/users" but not "/apiary". When several routes match, the most specific host wins,
+// then the longest path prefix, then the route registered first.
+type Router struct {
+	// Fallback handles requests that match no route. If nil, unmatched requests pass through.
+	Fallback http.Handler
+
+	mu     sync.RWMutex
+	routes []*route
+}
+
+// route is a registered pattern.
+type route struct {
+	host     string       // Host pattern without wildcard prefix, "" for any host
+	wildcard bool         // Whether host matches subdomains only
+	prefix   string       // Path prefix without trailing slash
+	handler  http.Handler // Handler for the route, nil for passthrough routes
+	order    int          // Registration order, used as final tie breaker
+}
+
+// NewRouter creates an empty router.
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle registers a handler for the given pattern.
+func (r *Router) Handle(pattern string, handler http.Handler) {
+	if handler == nil {
+		panic("vhost: nil handler")
+	}
+	r.add(pattern, handler)
+}
+
+// HandleFunc registers a handler function for the given pattern.
+func (r *Router) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	r.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// Passthrough registers a pattern whose requests are not handled, leaving them to the browser's network stack.
+// Passthrough routes take part in matching like any other route, so they can carve exceptions out of broader routes.
+func (r *Router) Passthrough(pattern string) {
+	r.add(pattern, nil)
+}
+
+// add parses and registers a pattern.
+func (r *Router) add(pattern string, handler http.Handler) {
+	host, prefix := pattern, "/"
+	if i := strings.IndexByte(pattern, '/'); i >= 0 {
+		host, prefix = pattern[:i], pattern[i:]
+	}
+
+	rt := &route{
+		host:    strings.ToLower(host),
+		prefix:  strings.TrimSuffix(prefix, "/"),
+		handler: handler,
+	}
+	if strings.HasPrefix(rt.host, "*.") {
+		rt.wildcard = true
+		rt.host = rt.host[1:]
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rt.order = len(r.routes)
+	r.routes = append(r.routes, rt)
+	sort.SliceStable(r.routes, func(i, j int) bool {
+		return r.routes[i].moreSpecific(r.routes[j])
+	})
+}
+
+// Match returns the handler responsible for the given host and path.
+// passthrough is true if the request should be left to the network, either because a passthrough route
+// matched or because nothing matched and there is no Fallback.
+func (r *Router) Match(host, path string) (handler http.Handler, passthrough bool) {
+	host = strings.ToLower(host)
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rt := range r.routes {
+		if rt.matchHost(host, hostname) && rt.matchPath(path) {
+			return rt.handler, rt.handler == nil
+		}
+	}
+	return r.Fallback, r.Fallback == nil
+}
+
+// ServeHTTP dispatches the request to the matching handler.
+// Requests that would pass through are answered with 404 Not Found, since there is no network to defer to.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	handler, passthrough := r.Match(req.Host, req.URL.Path)
+	if passthrough {
+		http.NotFound(w, req)
+		return
+	}
+	handler.ServeHTTP(w, req)
+}
+
+// matchHost reports whether the route accepts the request host (with port) or hostname (without port).
+func (rt *route) matchHost(host, hostname string) bool {
+	switch {
+	case rt.host == "":
+		return true
+	case rt.wildcard:
+		return strings.HasSuffix(hostname, rt.host) || strings.HasSuffix(host, rt.host)
+	default:
+		return host == rt.host || hostname == rt.host
+	}
+}
+
+// matchPath reports whether path falls under the route's prefix on a segment boundary.
+func (rt *route) matchPath(path string) bool {
+	if !strings.HasPrefix(path, rt.prefix) {
+		return false
+	}
+	rest := path[len(rt.prefix):]
+	return rest == "" || rest[0] == '/' || rt.prefix == ""
+}
+
+// moreSpecific orders routes for matching: exact hosts, then wildcard hosts, then any host;
+// longer host patterns and longer prefixes first; registration order last.
+func (rt *route) moreSpecific(other *route) bool {
+	if rank, otherRank := rt.hostRank(), other.hostRank(); rank != otherRank {
+		return rank > otherRank
+	}
+	if len(rt.host) != len(other.host) {
+		return len(rt.host) > len(other.host)
+	}
+	if len(rt.prefix) != len(other.prefix) {
+		return len(rt.prefix) > len(other.prefix)
+	}
+	return rt.order < other.order
+}
+
+// hostRank ranks host pattern kinds by specificity.
+func (rt *route) hostRank() int {
+	switch {
+	case rt.host == "":
+		return 0
+	case rt.wildcard:
+		return 1
+	default:
+		return 2
+	}
+}
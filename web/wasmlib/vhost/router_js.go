@@ -0,0 +1,38 @@
+package vhost
+
+import (
+	"net/url"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/eventjs"
+	"pkg.gfire.dev/supernet/web/wasmlib/httpjs"
+)
+
+// HandleFetchEvent routes a service worker FetchEvent.
+// If a handler matches, the event is answered with a streaming response produced by the handler and true is returned.
+// Otherwise the event is left untouched so the browser fetches the request from the network.
+// Must be called synchronously from the fetch event listener, since respondWith cannot be deferred.
+func (r *Router) HandleFetchEvent(event js.Value) bool {
+	jsReq := event.Get("request")
+
+	u, err := url.Parse(jsReq.Get("url").String())
+	if err != nil {
+		return false
+	}
+
+	handler, passthrough := r.Match(u.Host, u.Path)
+	if passthrough {
+		return false
+	}
+
+	event.Call("respondWith", httpjs.ServeHTTPAsyncWithStreaming(handler, jsReq))
+	return true
+}
+
+// Listen registers the router as a fetch event listener on the global scope of the service worker.
+// Closing the returned listener removes it and releases its resources.
+func (r *Router) Listen() (*eventjs.Listener, error) {
+	return eventjs.Listen(js.Global(), "fetch", func(event js.Value) {
+		r.HandleFetchEvent(event)
+	}, eventjs.Options{})
+}
@@ -0,0 +1,22 @@
+// Package wasmrpc exposes Go functions to JavaScript as promise-returning functions and lets Go
+// call functions registered from JavaScript, with arguments and results marshaled automatically. Servers need the browser;
+// elsewhere only the errors are declared.
+package wasmrpc
+
+import (
+	"errors"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+var (
+	// ErrClosed is returned when using a server that has been closed.
+	ErrClosed = errors.New("wasmrpc: server closed")
+	// ErrNotFound is returned when calling a JavaScript function that has not been registered.
+	ErrNotFound = errors.New("wasmrpc: function not found")
+	// ErrDuplicate is returned when registering a name that is already taken.
+	ErrDuplicate = errors.New("wasmrpc: function already registered")
+)
+
+// Error is an error raised on the JavaScript side of a call.
+type Error = promisejs.Error
@@ -0,0 +1,232 @@
+package wasmrpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsconv"
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+var (
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+	// _Error is a cached reference to the JavaScript Error constructor for creating error objects
+	_Error = js.Global().Get("Error")
+	// _AbortController is a cached reference to the JavaScript AbortController constructor for cancellation
+	_AbortController = js.Global().Get("AbortController")
+)
+
+// Server is a namespace object installed on the JavaScript global scope.
+//
+// Go functions registered with Register appear as methods of the namespace object. Each call returns a
+// Promise and accepts an optional AbortSignal as its second argument that cancels the Go context.
+// JavaScript code registers functions for Go with ns.register(name, fn) and removes them with ns.unregister(name).
+type Server struct {
+	name      string   // Global property name of the namespace object
+	namespace js.Value // The namespace object
+
+	mu      sync.Mutex
+	jsFuncs map[string]js.Value // Functions registered from JavaScript
+	closed  bool
+
+	funcsToBeReleased []js.Func // Callbacks released on Close
+}
+
+// NewServer installs a namespace object under globalThis[name].
+func NewServer(name string) *Server {
+	s := &Server{
+		name:      name,
+		namespace: _Object.New(),
+		jsFuncs:   make(map[string]js.Value),
+	}
+
+	register := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) < 2 || args[1].Type() != js.TypeFunction {
+			return _Error.New("wasmrpc: register(name, fn) requires a function")
+		}
+		if err := s.registerJS(args[0].String(), args[1]); err != nil {
+			return _Error.New(err.Error())
+		}
+		return nil
+	})
+	unregister := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) > 0 {
+			s.mu.Lock()
+			delete(s.jsFuncs, args[0].String())
+			s.mu.Unlock()
+		}
+		return nil
+	})
+	s.funcsToBeReleased = append(s.funcsToBeReleased, register, unregister)
+	s.namespace.Set("register", register)
+	s.namespace.Set("unregister", unregister)
+
+	js.Global().Set(name, s.namespace)
+	return s
+}
+
+// Namespace returns the JavaScript namespace object.
+func (s *Server) Namespace() js.Value {
+	return s.namespace
+}
+
+// Close removes the namespace object from the global scope and releases all registered functions.
+// Pending calls keep running, but their results are discarded by JavaScript once released.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	js.Global().Delete(s.name)
+	for _, fn := range s.funcsToBeReleased {
+		jsguard.Release(fn)
+	}
+	s.funcsToBeReleased = nil
+	s.jsFuncs = nil
+	return nil
+}
+
+// registerJS stores a function registered from JavaScript.
+func (s *Server) registerJS(name string, fn js.Value) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if _, ok := s.jsFuncs[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicate, name)
+	}
+	s.jsFuncs[name] = fn
+	return nil
+}
+
+// Register exposes fn to JavaScript as namespace[name](args, signal?) returning a Promise of the result.
+// args is decoded into A and the result is encoded from R with jsconv; a returned error rejects the Promise with an Error.
+// A panic in fn rejects the Promise instead of crashing the program.
+func Register[A, R any](s *Server, name string, fn func(ctx context.Context, args A) (R, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if name == "register" || name == "unregister" || s.namespace.Get(name).Truthy() {
+		return fmt.Errorf("%w: %s", ErrDuplicate, name)
+	}
+
+	jsFunc := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		var arg, signal js.Value = js.Undefined(), js.Undefined()
+		if len(args) > 0 {
+			arg = args[0]
+		}
+		if len(args) > 1 {
+			signal = args[1]
+		}
+
+		var a A
+		if err := jsconv.Unmarshal(arg, &a); err != nil {
+			return promisejs.Reject(fmt.Errorf("wasmrpc: %s: invalid arguments: %w", name, err))
+		}
+
+		return promisejs.New(func() (js.Value, error) {
+			ctx, cancel := signalContext(signal)
+			defer cancel()
+
+			r, err := fn(ctx, a)
+			if err != nil {
+				return js.Undefined(), err
+			}
+			return jsconv.Marshal(r)
+		})
+	})
+	s.funcsToBeReleased = append(s.funcsToBeReleased, jsFunc)
+	s.namespace.Set(name, jsFunc)
+	return nil
+}
+
+// Call invokes the JavaScript function registered under name with args and decodes its result into R.
+// The function receives the encoded args and an AbortSignal that is aborted when ctx is done.
+// If the function returns a Promise, Call waits for it to settle or for ctx to be done.
+func Call[R any](ctx context.Context, s *Server, name string, args interface{}) (R, error) {
+	var r R
+
+	s.mu.Lock()
+	fn, ok := s.jsFuncs[name]
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return r, ErrClosed
+	}
+	if !ok {
+		return r, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	v, err := Invoke(ctx, fn, args)
+	if err != nil {
+		return r, err
+	}
+	if err := jsconv.Unmarshal(v, &r); err != nil {
+		return r, fmt.Errorf("wasmrpc: %s: invalid result: %w", name, err)
+	}
+	return r, nil
+}
+
+// Invoke calls an arbitrary JavaScript function with the encoded args and an AbortSignal bound to ctx,
+// waiting for the result if it is a Promise. Exceptions and rejections are returned as *Error.
+func Invoke(ctx context.Context, fn js.Value, args interface{}) (js.Value, error) {
+	if err := ctx.Err(); err != nil {
+		return js.Undefined(), err
+	}
+
+	arg, err := jsconv.Marshal(args)
+	if err != nil {
+		return js.Undefined(), err
+	}
+
+	controller := _AbortController.New()
+	stop := context.AfterFunc(ctx, func() {
+		controller.Call("abort")
+	})
+	defer stop()
+
+	v, err := promisejs.Try(func() js.Value {
+		return fn.Invoke(arg, controller.Get("signal"))
+	})
+	if err != nil {
+		return js.Undefined(), err
+	}
+	return promisejs.Await(ctx, v)
+}
+
+// signalContext returns a context that is canceled when the optional AbortSignal aborts.
+func signalContext(signal js.Value) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if signal.Type() != js.TypeObject || signal.Get("aborted").Type() != js.TypeBoolean {
+		return ctx, cancel
+	}
+	if signal.Get("aborted").Bool() {
+		cancel()
+		return ctx, cancel
+	}
+
+	onAbort := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		cancel()
+		return nil
+	})
+	jsguard.AddEventListener(signal, "abort", onAbort)
+
+	return ctx, func() {
+		cancel()
+		jsguard.RemoveEventListener(signal, "abort", onAbort)
+		jsguard.Release(onAbort)
+	}
+}
@@ -0,0 +1,229 @@
+package webrtcjs
+
+import (
+	"context"
+	"sync"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+const (
+	// highWaterMark is the amount of buffered outgoing data above which Send blocks
+	highWaterMark = 1 << 20
+	// lowWaterMark is the buffered amount at which a blocked Send resumes
+	lowWaterMark = 256 << 10
+)
+
+// toJS converts the options into an RTCDataChannelInit dictionary.
+func (o DataChannelOptions) toJS() js.Value {
+	init := _Object.New()
+	init.Set("ordered", !o.Unordered)
+	if o.Unreliable {
+		if o.MaxPacketLifeTime > 0 {
+			init.Set("maxPacketLifeTime", o.MaxPacketLifeTime.Milliseconds())
+		} else {
+			init.Set("maxRetransmits", o.MaxRetransmits)
+		}
+	}
+	if o.Protocol != "" {
+		init.Set("protocol", o.Protocol)
+	}
+	return init
+}
+
+// DataChannel is a message-oriented connection over an RTCDataChannel.
+// It offers the same NextMessage/Send/Close contract as wsjs.Conn.
+type DataChannel struct {
+	// dc holds the JavaScript RTCDataChannel object
+	dc js.Value
+
+	// messageChan buffers incoming messages (up to 128 messages)
+	messageChan chan []byte
+	// openChan is closed once the channel is open
+	openChan chan struct{}
+	openOnce sync.Once
+	// closeChan is closed once the channel is closed
+	closeChan chan struct{}
+	closeOnce sync.Once
+	// releaseOnce guards releasing the registered callbacks
+	releaseOnce sync.Once
+	// lowChan is signalled when the buffered amount drops below lowWaterMark
+	lowChan chan struct{}
+
+	// sendMu serializes Send calls so backpressure waits are not interleaved
+	sendMu sync.Mutex
+
+	// funcsToBeReleased tracks JavaScript function callbacks that must be released to prevent memory leaks
+	funcsToBeReleased []js.Func
+}
+
+// newDataChannel wraps a JavaScript RTCDataChannel and registers its event listeners.
+func newDataChannel(dc js.Value) *DataChannel {
+	dc.Set("binaryType", "arraybuffer")
+	dc.Set("bufferedAmountLowThreshold", lowWaterMark)
+
+	channel := &DataChannel{
+		dc:          dc,
+		messageChan: make(chan []byte, 128),
+		openChan:    make(chan struct{}),
+		closeChan:   make(chan struct{}),
+		lowChan:     make(chan struct{}, 1),
+	}
+
+	onOpen := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		channel.openOnce.Do(func() { close(channel.openChan) })
+		return nil
+	})
+
+	onMessage := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		var data []byte
+		jsData := args[0].Get("data")
+		if jsData.Type() == js.TypeString {
+			// Handle text message: convert JavaScript string to Go byte slice
+			data = []byte(jsData.String())
+		} else if jsData.InstanceOf(_ArrayBuffer) {
+			// Handle binary message: convert JavaScript ArrayBuffer to Go byte slice
+			array := _Uint8Array.New(jsData)
+			data = make([]byte, array.Get("byteLength").Int())
+			js.CopyBytesToGo(data, array)
+		} else {
+			return nil
+		}
+
+		select {
+		case channel.messageChan <- data:
+		case <-channel.closeChan:
+		}
+		return nil
+	})
+
+	onBufferedAmountLow := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		select {
+		case channel.lowChan <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+
+	onClose := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		channel.closeOnce.Do(func() { close(channel.closeChan) })
+		// A closed channel fires no more events, so the callbacks are released even if Close is never called
+		channel.freeFuncs()
+		return nil
+	})
+
+	channel.funcsToBeReleased = append(channel.funcsToBeReleased, onOpen, onMessage, onBufferedAmountLow, onClose)
+
+	jsguard.AddEventListener(dc, "open", onOpen)
+	jsguard.AddEventListener(dc, "message", onMessage)
+	jsguard.AddEventListener(dc, "bufferedamountlow", onBufferedAmountLow)
+	jsguard.AddEventListener(dc, "close", onClose)
+
+	// Channels received through the datachannel event may already be open
+	if dc.Get("readyState").String() == "open" {
+		channel.openOnce.Do(func() { close(channel.openChan) })
+	}
+
+	return channel
+}
+
+// Label returns the label the channel was created with.
+func (c *DataChannel) Label() string {
+	return c.dc.Get("label").String()
+}
+
+// BufferedAmount returns the number of bytes queued for sending.
+func (c *DataChannel) BufferedAmount() int {
+	return c.dc.Get("bufferedAmount").Int()
+}
+
+// WaitOpen blocks until the channel is open. Returns ErrClosed if it closes first.
+func (c *DataChannel) WaitOpen() error {
+	return c.WaitOpenContext(context.Background())
+}
+
+// WaitOpenContext is WaitOpen bounded by ctx. It returns ctx.Err() once ctx is done.
+func (c *DataChannel) WaitOpenContext(ctx context.Context) error {
+	select {
+	case <-c.openChan:
+		return nil
+	case <-c.closeChan:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// NextMessage retrieves the next message received on the channel.
+// It blocks until a message is available or the channel is closed.
+// Returns ErrClosed if the channel has been closed before or during the wait.
+func (c *DataChannel) NextMessage() ([]byte, error) {
+	return c.NextMessageContext(context.Background())
+}
+
+// NextMessageContext is NextMessage bounded by ctx. It returns ctx.Err() once ctx is done, leaving the
+// channel open and the messages queued.
+func (c *DataChannel) NextMessageContext(ctx context.Context) ([]byte, error) {
+	jsguard.Check("webrtcjs.DataChannel.NextMessage")
+	select {
+	case msg := <-c.messageChan:
+		return msg, nil
+	case <-c.closeChan:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// NextMessageAsync is NextMessageContext in a new goroutine, for JavaScript callbacks, which must not block.
+func (c *DataChannel) NextMessageAsync(ctx context.Context) <-chan jsguard.Result[[]byte] {
+	return jsguard.Go(func() ([]byte, error) {
+		return c.NextMessageContext(ctx)
+	})
+}
+
+// Send sends a message as binary data, waiting for the channel to open if necessary.
+// Blocks while more than 1 MiB of outgoing data is buffered so fast senders do not exhaust memory.
+func (c *DataChannel) Send(data []byte) error {
+	if err := c.WaitOpen(); err != nil {
+		return err
+	}
+
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	for c.BufferedAmount() > highWaterMark {
+		select {
+		case <-c.lowChan:
+		case <-c.closeChan:
+			return ErrClosed
+		}
+	}
+
+	// Convert Go byte slice to JavaScript ArrayBuffer for transmission
+	buffer := _ArrayBuffer.New(len(data))
+	array := _Uint8Array.New(buffer)
+	js.CopyBytesToJS(array, data)
+
+	c.dc.Call("send", buffer)
+	return nil
+}
+
+// Close closes the data channel and releases all associated resources.
+// Safe to call multiple times.
+func (c *DataChannel) Close() error {
+	c.dc.Call("close")
+	c.closeOnce.Do(func() { close(c.closeChan) })
+	c.freeFuncs()
+	return nil
+}
+
+// freeFuncs releases the event listeners of the channel. Only the first call has an effect.
+func (c *DataChannel) freeFuncs() {
+	c.releaseOnce.Do(func() {
+		for _, f := range c.funcsToBeReleased {
+			jsguard.Release(f)
+		}
+	})
+}
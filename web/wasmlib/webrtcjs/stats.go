@@ -0,0 +1,80 @@
+package webrtcjs
+
+import "time"
+
+// CandidateStats describes an ICE candidate of the selected candidate pair.
+type CandidateStats struct {
+	Type          string // Candidate type: "host", "srflx", "prflx" or "relay"
+	Protocol      string // Transport protocol, "udp" or "tcp"
+	Address       string // Candidate address, may be empty or an mDNS name when hidden by the browser
+	Port          int    // Candidate port
+	RelayProtocol string // Protocol used to reach the TURN server, for relay candidates
+	NetworkType   string // Network interface type where exposed, e.g. "wifi"
+}
+
+// CandidatePairStats describes the candidate pair currently carrying traffic.
+type CandidatePairStats struct {
+	State                    string         // ICE check state, e.g. "succeeded"
+	Local                    CandidateStats // Local candidate
+	Remote                   CandidateStats // Remote candidate
+	CurrentRoundTripTime     time.Duration  // Latest STUN consent round trip time
+	AvailableOutgoingBitrate float64        // Estimated available send bandwidth in bits per second, 0 if unknown
+	AvailableIncomingBitrate float64        // Estimated available receive bandwidth in bits per second, 0 if unknown
+	BytesSent                uint64         // Payload bytes sent over the pair
+	BytesReceived            uint64         // Payload bytes received over the pair
+}
+
+// Relayed reports whether the pair carries traffic through a TURN server, on either side.
+func (p *CandidatePairStats) Relayed() bool {
+	return p.Local.Type == "relay" || p.Remote.Type == "relay"
+}
+
+// Stats is a typed snapshot of the parts of an RTCStatsReport relevant to connection quality.
+type Stats struct {
+	Timestamp        time.Time           // Time the report was generated
+	Pair             *CandidatePairStats // Selected candidate pair, nil before ICE has connected
+	PacketsLost      int64               // Packets lost across all inbound RTP streams
+	PacketsReceived  uint64              // Packets received across all inbound RTP streams
+	MessagesSent     uint64              // Messages sent across all data channels
+	MessagesReceived uint64              // Messages received across all data channels
+}
+
+// Loss returns the fraction of inbound RTP packets lost between prev and s, or 0 without media traffic.
+func (s Stats) Loss(prev Stats) float64 {
+	lost := s.PacketsLost - prev.PacketsLost
+	received := int64(s.PacketsReceived) - int64(prev.PacketsReceived)
+	if lost <= 0 || lost+received <= 0 {
+		return 0
+	}
+	return float64(lost) / float64(lost+received)
+}
+
+// Metric identifies a thresholded statistic.
+type Metric string
+
+const (
+	MetricRTT     Metric = "rtt"     // Round trip time of the selected candidate pair
+	MetricBitrate Metric = "bitrate" // Available outgoing bitrate
+	MetricLoss    Metric = "loss"    // Inbound RTP packet loss
+	MetricPath    Metric = "path"    // Selected candidate pair changed
+)
+
+// Thresholds configures when the stats monitor reports degraded quality. Zero values disable a check.
+type Thresholds struct {
+	MaxRTT     time.Duration // Report when the round trip time exceeds this
+	MinBitrate float64       // Report when the available outgoing bitrate drops below this (bits per second)
+	MaxLoss    float64       // Report when the packet loss fraction between polls exceeds this
+}
+
+// StatsEvent reports a threshold being crossed in either direction, or a change of the selected path.
+type StatsEvent struct {
+	Metric   Metric // Statistic that changed
+	Degraded bool   // Whether the threshold is now exceeded; always false for MetricPath
+	Stats    Stats  // Snapshot that triggered the event
+}
+
+// StatsMonitorConfig configures a StatsMonitor.
+type StatsMonitorConfig struct {
+	Interval   time.Duration // Interval between getStats polls (default 2s)
+	Thresholds Thresholds    // Quality thresholds
+}
@@ -0,0 +1,253 @@
+package webrtcjs
+
+import (
+	"context"
+	"sync"
+	"syscall/js"
+	"time"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+const (
+	// defaultStatsInterval is the default interval between getStats polls
+	defaultStatsInterval = 2 * time.Second
+)
+
+// Stats fetches the current statistics of the peer connection.
+func (pc *PeerConnection) Stats(ctx context.Context) (Stats, error) {
+	report, err := await(ctx, pc.pc.Call("getStats"))
+	if err != nil {
+		return Stats{}, err
+	}
+	return parseStats(report), nil
+}
+
+// parseStats converts an RTCStatsReport into Stats.
+func parseStats(report js.Value) Stats {
+	var (
+		s          Stats
+		entries    = make(map[string]js.Value)
+		selectedID string
+		fallbackID string
+	)
+
+	collect := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		entry := args[0]
+		id := entry.Get("id").String()
+		entries[id] = entry
+
+		if ts := entry.Get("timestamp"); ts.Type() == js.TypeNumber && s.Timestamp.IsZero() {
+			s.Timestamp = time.UnixMilli(int64(ts.Float()))
+		}
+
+		switch entry.Get("type").String() {
+		case "transport":
+			if v := entry.Get("selectedCandidatePairId"); v.Type() == js.TypeString {
+				selectedID = v.String()
+			}
+		case "candidate-pair":
+			// Firefox flags the selected pair directly instead of reporting it on the transport
+			if entry.Get("selected").Truthy() || (entry.Get("nominated").Truthy() && entry.Get("state").String() == "succeeded") {
+				fallbackID = id
+			}
+		case "inbound-rtp":
+			s.PacketsLost += int64(number(entry.Get("packetsLost")))
+			s.PacketsReceived += uint64(number(entry.Get("packetsReceived")))
+		case "data-channel":
+			s.MessagesSent += uint64(number(entry.Get("messagesSent")))
+			s.MessagesReceived += uint64(number(entry.Get("messagesReceived")))
+		}
+		return nil
+	})
+	defer jsguard.Release(collect)
+	report.Call("forEach", collect)
+
+	if selectedID == "" {
+		selectedID = fallbackID
+	}
+	if pair, ok := entries[selectedID]; ok {
+		s.Pair = &CandidatePairStats{
+			State:                    stringOf(pair.Get("state")),
+			Local:                    candidateOf(entries[stringOf(pair.Get("localCandidateId"))]),
+			Remote:                   candidateOf(entries[stringOf(pair.Get("remoteCandidateId"))]),
+			CurrentRoundTripTime:     time.Duration(number(pair.Get("currentRoundTripTime")) * float64(time.Second)),
+			AvailableOutgoingBitrate: number(pair.Get("availableOutgoingBitrate")),
+			AvailableIncomingBitrate: number(pair.Get("availableIncomingBitrate")),
+			BytesSent:                uint64(number(pair.Get("bytesSent"))),
+			BytesReceived:            uint64(number(pair.Get("bytesReceived"))),
+		}
+	}
+	return s
+}
+
+// candidateOf converts a local-candidate or remote-candidate stats entry.
+func candidateOf(v js.Value) CandidateStats {
+	if !v.Truthy() {
+		return CandidateStats{}
+	}
+	address := stringOf(v.Get("address"))
+	if address == "" {
+		address = stringOf(v.Get("ip"))
+	}
+	return CandidateStats{
+		Type:          stringOf(v.Get("candidateType")),
+		Protocol:      stringOf(v.Get("protocol")),
+		Address:       address,
+		Port:          int(number(v.Get("port"))),
+		RelayProtocol: stringOf(v.Get("relayProtocol")),
+		NetworkType:   stringOf(v.Get("networkType")),
+	}
+}
+
+// number returns the numeric value of v, or 0 if v is not a number.
+func number(v js.Value) float64 {
+	if v.Type() != js.TypeNumber {
+		return 0
+	}
+	return v.Float()
+}
+
+// stringOf returns the string value of v, or "" if v is not a string.
+func stringOf(v js.Value) string {
+	if v.Type() != js.TypeString {
+		return ""
+	}
+	return v.String()
+}
+
+// StatsMonitor polls a peer connection's statistics and publishes threshold events.
+type StatsMonitor struct {
+	pc  *PeerConnection
+	cfg StatsMonitorConfig
+
+	// cancel stops the polling loop
+	cancel context.CancelFunc
+	// done is closed when the polling loop exits
+	done chan struct{}
+
+	// mu protects the fields below
+	mu sync.Mutex
+	// latest holds the most recent snapshot
+	latest Stats
+	// hasLatest reports whether latest is valid
+	hasLatest bool
+	// degraded holds the current threshold state per metric
+	degraded map[Metric]bool
+	// subscribers receive events
+	subscribers map[chan StatsEvent]struct{}
+}
+
+// NewStatsMonitor starts polling the statistics of pc. The monitor stops when Close is called or pc is closed.
+func NewStatsMonitor(pc *PeerConnection, cfg StatsMonitorConfig) *StatsMonitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultStatsInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &StatsMonitor{
+		pc:          pc,
+		cfg:         cfg,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+		degraded:    make(map[Metric]bool),
+		subscribers: make(map[chan StatsEvent]struct{}),
+	}
+	go m.loop(ctx)
+	return m
+}
+
+// Latest returns the most recent statistics, if any poll has completed.
+func (m *StatsMonitor) Latest() (Stats, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.latest, m.hasLatest
+}
+
+// Subscribe returns a channel receiving events and a function to unsubscribe.
+// Events are dropped if the channel buffer is full.
+func (m *StatsMonitor) Subscribe(buffer int) (<-chan StatsEvent, func()) {
+	ch := make(chan StatsEvent, buffer)
+
+	m.mu.Lock()
+	m.subscribers[ch] = struct{}{}
+	m.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			m.mu.Lock()
+			delete(m.subscribers, ch)
+			m.mu.Unlock()
+		})
+	}
+}
+
+// Close stops polling. Safe to call multiple times.
+func (m *StatsMonitor) Close() error {
+	m.cancel()
+	<-m.done
+	return nil
+}
+
+// loop polls getStats until the monitor or the peer connection is closed.
+func (m *StatsMonitor) loop(ctx context.Context) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.pc.closeChan:
+			return
+		case <-ticker.C:
+		}
+
+		s, err := m.pc.Stats(ctx)
+		if err != nil {
+			continue
+		}
+		m.update(s)
+	}
+}
+
+// update stores a snapshot and publishes the events it triggers.
+func (m *StatsMonitor) update(s Stats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prev, hadPrev := m.latest, m.hasLatest
+	m.latest, m.hasLatest = s, true
+
+	var events []StatsEvent
+	check := func(metric Metric, enabled, degraded bool) {
+		if !enabled || m.degraded[metric] == degraded {
+			return
+		}
+		m.degraded[metric] = degraded
+		events = append(events, StatsEvent{Metric: metric, Degraded: degraded, Stats: s})
+	}
+
+	t := m.cfg.Thresholds
+	if s.Pair != nil {
+		check(MetricRTT, t.MaxRTT > 0, s.Pair.CurrentRoundTripTime > t.MaxRTT)
+		check(MetricBitrate, t.MinBitrate > 0 && s.Pair.AvailableOutgoingBitrate > 0, s.Pair.AvailableOutgoingBitrate < t.MinBitrate)
+	}
+	if hadPrev {
+		check(MetricLoss, t.MaxLoss > 0, s.Loss(prev) > t.MaxLoss)
+		if prev.Pair != nil && s.Pair != nil && (prev.Pair.Local != s.Pair.Local || prev.Pair.Remote != s.Pair.Remote) {
+			events = append(events, StatsEvent{Metric: MetricPath, Stats: s})
+		}
+	}
+
+	for _, e := range events {
+		for ch := range m.subscribers {
+			select {
+			case ch <- e:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Package webrtcjs provides bindings for RTCPeerConnection and RTCDataChannel, connecting browsers directly
+// with data channels. Peer connections need the browser; elsewhere only the configuration, statistics types
+// and errors are declared.
+package webrtcjs
+
+import (
+	"errors"
+	"time"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jserr"
+)
+
+var (
+	// ErrUnsupported is returned when RTCPeerConnection is not available in the current context
+	ErrUnsupported = errors.New("webrtc not supported")
+	// ErrClosed is returned when using a closed peer connection or data channel
+	ErrClosed = errors.New("webrtc connection closed")
+	// ErrConnectionFailed is returned when ICE fails to establish a connection
+	ErrConnectionFailed = jserr.New(jserr.ErrNetwork, "webrtc connection failed")
+	// ErrRequestFailed is returned when a WebRTC operation fails without a reason
+	ErrRequestFailed = errors.New("webrtc request failed")
+)
+
+// ICEServer describes a STUN or TURN server used for ICE candidate gathering.
+type ICEServer struct {
+	URLs       []string // Server URLs, e.g. "stun:stun.l.google.com:19302" or "turn:turn.example.com"
+	Username   string   // TURN username (optional)
+	Credential string   // TURN credential (optional)
+}
+
+// Config configures a PeerConnection.
+type Config struct {
+	ICEServers []ICEServer // STUN/TURN servers; an empty list limits connectivity to host candidates
+	RelayOnly  bool        // Only use TURN relay candidates (iceTransportPolicy "relay")
+}
+
+// DataChannelOptions configures a data channel created with CreateDataChannel.
+// The zero value creates an ordered, reliable channel.
+type DataChannelOptions struct {
+	Unordered         bool          // Allow out-of-order delivery
+	Unreliable        bool          // Limit retransmissions; see MaxRetransmits and MaxPacketLifeTime
+	MaxRetransmits    int           // Maximum retransmissions of an unreliable message (default 0)
+	MaxPacketLifeTime time.Duration // Maximum retransmission time of an unreliable message, overrides MaxRetransmits
+	Protocol          string        // Sub-protocol name
+}
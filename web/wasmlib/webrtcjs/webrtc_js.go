@@ -0,0 +1,259 @@
+package webrtcjs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+)
+
+var (
+	// _RTCPeerConnection is a cached reference to the JavaScript RTCPeerConnection constructor
+	_RTCPeerConnection = js.Global().Get("RTCPeerConnection")
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+	// _Array is a cached reference to the JavaScript Array constructor for array operations
+	_Array = js.Global().Get("Array")
+	// _ArrayBuffer is a cached reference to the JavaScript ArrayBuffer constructor for binary data
+	_ArrayBuffer = js.Global().Get("ArrayBuffer")
+	// _Uint8Array is a cached reference to the JavaScript Uint8Array constructor for typed array operations
+	_Uint8Array = js.Global().Get("Uint8Array")
+)
+
+// PeerConnection wraps a JavaScript RTCPeerConnection.
+// Signaling uses complete (non-trickle) session descriptions: CreateOffer and AcceptOffer wait for ICE
+// gathering to finish, so a single offer/answer round trip over any channel establishes the connection.
+type PeerConnection struct {
+	// pc holds the JavaScript RTCPeerConnection object
+	pc js.Value
+
+	// dataChannelChan delivers data channels opened by the remote peer (up to 16 channels)
+	dataChannelChan chan *DataChannel
+	// gatheredChan is signalled each time ICE gathering reaches the complete state
+	gatheredChan chan struct{}
+	// failedChan is closed when the connection state becomes failed
+	failedChan chan struct{}
+	failOnce   sync.Once
+	// closeChan is closed when the peer connection is closed
+	closeChan chan struct{}
+	closeOnce sync.Once
+
+	// funcsToBeReleased tracks JavaScript function callbacks that must be released to prevent memory leaks
+	funcsToBeReleased []js.Func
+}
+
+// NewPeerConnection creates a new RTCPeerConnection with the given configuration.
+func NewPeerConnection(cfg Config) (*PeerConnection, error) {
+	if _RTCPeerConnection.IsUndefined() {
+		return nil, ErrUnsupported
+	}
+
+	jsServers := _Array.New()
+	for _, server := range cfg.ICEServers {
+		jsServer := _Object.New()
+		urls := _Array.New()
+		for _, u := range server.URLs {
+			urls.Call("push", u)
+		}
+		jsServer.Set("urls", urls)
+		if server.Username != "" {
+			jsServer.Set("username", server.Username)
+			jsServer.Set("credential", server.Credential)
+		}
+		jsServers.Call("push", jsServer)
+	}
+
+	jsConfig := _Object.New()
+	jsConfig.Set("iceServers", jsServers)
+	if cfg.RelayOnly {
+		jsConfig.Set("iceTransportPolicy", "relay")
+	}
+
+	pc := &PeerConnection{
+		pc:              _RTCPeerConnection.New(jsConfig),
+		dataChannelChan: make(chan *DataChannel, 16),
+		gatheredChan:    make(chan struct{}, 1),
+		failedChan:      make(chan struct{}),
+		closeChan:       make(chan struct{}),
+	}
+
+	onDataChannel := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		dc := newDataChannel(args[0].Get("channel"))
+		select {
+		case pc.dataChannelChan <- dc:
+		default:
+			// Nobody is accepting channels; refuse the channel instead of blocking the event loop
+			go dc.Close()
+		}
+		return nil
+	})
+
+	onGatheringStateChange := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if pc.pc.Get("iceGatheringState").String() == "complete" {
+			select {
+			case pc.gatheredChan <- struct{}{}:
+			default:
+			}
+		}
+		return nil
+	})
+
+	onConnectionStateChange := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if pc.pc.Get("connectionState").String() == "failed" {
+			pc.failOnce.Do(func() { close(pc.failedChan) })
+		}
+		return nil
+	})
+
+	pc.funcsToBeReleased = append(pc.funcsToBeReleased, onDataChannel, onGatheringStateChange, onConnectionStateChange)
+
+	jsguard.AddEventListener(pc.pc, "datachannel", onDataChannel)
+	jsguard.AddEventListener(pc.pc, "icegatheringstatechange", onGatheringStateChange)
+	jsguard.AddEventListener(pc.pc, "connectionstatechange", onConnectionStateChange)
+
+	return pc, nil
+}
+
+// Value returns the underlying JavaScript RTCPeerConnection object.
+func (pc *PeerConnection) Value() js.Value {
+	return pc.pc
+}
+
+// ConnectionState returns the aggregate connection state ("new", "connecting", "connected",
+// "disconnected", "failed" or "closed").
+func (pc *PeerConnection) ConnectionState() string {
+	return pc.pc.Get("connectionState").String()
+}
+
+// CreateDataChannel creates a new data channel negotiated in-band.
+// Channels created before CreateOffer are included in the offer.
+func (pc *PeerConnection) CreateDataChannel(label string, opts DataChannelOptions) *DataChannel {
+	return newDataChannel(pc.pc.Call("createDataChannel", label, opts.toJS()))
+}
+
+// CreateOffer creates an SDP offer, applies it as the local description and waits for ICE gathering
+// to complete. The returned SDP contains all local candidates and must be passed to AcceptOffer on the remote peer.
+func (pc *PeerConnection) CreateOffer() (string, error) {
+	return pc.CreateOfferContext(context.Background())
+}
+
+// CreateOfferContext is CreateOffer bounded by ctx. The connection is left half negotiated when ctx is
+// done and should be closed.
+func (pc *PeerConnection) CreateOfferContext(ctx context.Context) (string, error) {
+	offer, err := await(ctx, pc.pc.Call("createOffer"))
+	if err != nil {
+		return "", err
+	}
+	if _, err := await(ctx, pc.pc.Call("setLocalDescription", offer)); err != nil {
+		return "", err
+	}
+	if err := pc.waitGathered(ctx); err != nil {
+		return "", err
+	}
+	return pc.pc.Get("localDescription").Get("sdp").String(), nil
+}
+
+// AcceptOffer applies a remote SDP offer and returns the complete SDP answer to send back.
+func (pc *PeerConnection) AcceptOffer(offer string) (string, error) {
+	return pc.AcceptOfferContext(context.Background(), offer)
+}
+
+// AcceptOfferContext is AcceptOffer bounded by ctx. The connection is left half negotiated when ctx is
+// done and should be closed.
+func (pc *PeerConnection) AcceptOfferContext(ctx context.Context, offer string) (string, error) {
+	if err := pc.setRemote(ctx, "offer", offer); err != nil {
+		return "", err
+	}
+	answer, err := await(ctx, pc.pc.Call("createAnswer"))
+	if err != nil {
+		return "", err
+	}
+	if _, err := await(ctx, pc.pc.Call("setLocalDescription", answer)); err != nil {
+		return "", err
+	}
+	if err := pc.waitGathered(ctx); err != nil {
+		return "", err
+	}
+	return pc.pc.Get("localDescription").Get("sdp").String(), nil
+}
+
+// AcceptAnswer applies the remote SDP answer to a previously created offer.
+func (pc *PeerConnection) AcceptAnswer(answer string) error {
+	return pc.setRemote(context.Background(), "answer", answer)
+}
+
+// AcceptDataChannel waits for the remote peer to open a data channel.
+// Returns ErrClosed if the peer connection is closed and ErrConnectionFailed if ICE fails.
+func (pc *PeerConnection) AcceptDataChannel() (*DataChannel, error) {
+	return pc.AcceptDataChannelContext(context.Background())
+}
+
+// AcceptDataChannelContext is AcceptDataChannel bounded by ctx. It returns ctx.Err() once ctx is done; a
+// channel opened later is still returned by the next call.
+func (pc *PeerConnection) AcceptDataChannelContext(ctx context.Context) (*DataChannel, error) {
+	select {
+	case dc := <-pc.dataChannelChan:
+		return dc, nil
+	case <-pc.failedChan:
+		return nil, ErrConnectionFailed
+	case <-pc.closeChan:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Failed returns a channel that is closed when the connection state becomes failed.
+func (pc *PeerConnection) Failed() <-chan struct{} {
+	return pc.failedChan
+}
+
+// Close closes the peer connection and all of its data channels and releases associated resources.
+// Safe to call multiple times.
+func (pc *PeerConnection) Close() error {
+	pc.closeOnce.Do(func() {
+		pc.pc.Call("close")
+		close(pc.closeChan)
+		for _, f := range pc.funcsToBeReleased {
+			jsguard.Release(f)
+		}
+	})
+	return nil
+}
+
+// setRemote applies a remote session description of the given type, bounded by ctx.
+func (pc *PeerConnection) setRemote(ctx context.Context, typ, sdp string) error {
+	desc := _Object.New()
+	desc.Set("type", typ)
+	desc.Set("sdp", sdp)
+	_, err := await(ctx, pc.pc.Call("setRemoteDescription", desc))
+	return err
+}
+
+// waitGathered blocks until ICE candidate gathering has completed or ctx is done.
+func (pc *PeerConnection) waitGathered(ctx context.Context) error {
+	if pc.pc.Get("iceGatheringState").String() == "complete" {
+		return nil
+	}
+	select {
+	case <-pc.gatheredChan:
+		return nil
+	case <-pc.closeChan:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// await blocks until the given JavaScript promise settles or ctx is done and returns its value or rejection
+// reason. Rejections without a reason are reported as ErrRequestFailed.
+func await(ctx context.Context, promise js.Value) (js.Value, error) {
+	v, err := promisejs.Await(ctx, promise)
+	if errors.Is(err, promisejs.ErrRejected) {
+		return v, ErrRequestFailed
+	}
+	return v, err
+}
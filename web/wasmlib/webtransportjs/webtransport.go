@@ -0,0 +1,165 @@
+// Package webtransportjs provides bindings for the WebTransport API: HTTP/3 sessions carrying reliable
+// bidirectional streams and unreliable datagrams. Outside the browser, sessions are dialed with a native
+// HTTP/3 client.
+package webtransportjs
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+
+	"pkg.gfire.dev/supernet/codec"
+	"pkg.gfire.dev/supernet/web/wasmlib/jserr"
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+var (
+	// ErrUnsupported is returned when WebTransport is not available in the current context
+	ErrUnsupported = errors.New("webtransport not supported")
+	// ErrClosed is returned when using a closed session or stream
+	ErrClosed = errors.New("webtransport session closed")
+	// ErrRequestFailed is returned when a WebTransport operation fails without a reason
+	ErrRequestFailed = errors.New("webtransport request failed")
+	// ErrMessageTooLarge is returned when a framed message exceeds the connection's limit
+	ErrMessageTooLarge = jserr.New(jserr.ErrProtocol, "webtransport message too large")
+)
+
+const (
+	// maxMessageSize bounds messages read by Conn
+	maxMessageSize = 16 << 20
+)
+
+// Options configures a WebTransport session.
+type Options struct {
+	// CertificateHashes pins self-signed server certificates by SHA-256 hash, for servers without a
+	// publicly trusted certificate. Such certificates must be valid for at most two weeks.
+	CertificateHashes [][]byte
+	// CongestionControl hints the congestion control algorithm: "default", "throughput" or "low-latency".
+	CongestionControl string
+}
+
+// Session is a WebTransport session.
+type Session struct {
+	binding
+
+	// closed is closed when the session ends
+	closed    chan struct{}
+	closeOnce sync.Once
+	// err is the reason the session ended
+	err error
+}
+
+// Done returns a channel that is closed when the session ends.
+func (s *Session) Done() <-chan struct{} {
+	return s.closed
+}
+
+// Err returns the reason the session ended, or nil while it is open.
+func (s *Session) Err() error {
+	select {
+	case <-s.closed:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// finish records the end of the session.
+func (s *Session) finish(err error) {
+	s.closeOnce.Do(func() {
+		if err == nil {
+			err = ErrClosed
+		}
+		s.err = err
+		close(s.closed)
+	})
+}
+
+// Conn carries length-prefixed messages over a single stream, implementing the NextMessage/Send/Close
+// contract. Each message is preceded by its length as a uvarint.
+type Conn struct {
+	session *Session
+	stream  *Stream
+	reader  *bufio.Reader
+
+	// sendMu keeps concurrent messages from interleaving
+	sendMu sync.Mutex
+}
+
+// DialConn establishes a session with url and opens a single message stream on it.
+func DialConn(ctx context.Context, url string, opts Options) (*Conn, error) {
+	s, err := Dial(ctx, url, opts)
+	if err != nil {
+		return nil, err
+	}
+	st, err := s.OpenStream(ctx)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	return NewConn(s, st), nil
+}
+
+// NewConn frames messages over st. Closing the Conn closes the session.
+func NewConn(s *Session, st *Stream) *Conn {
+	return &Conn{session: s, stream: st, reader: bufio.NewReader(st)}
+}
+
+// Session returns the underlying session.
+func (c *Conn) Session() *Session {
+	return c.session
+}
+
+// NextMessage blocks until the next message is received.
+func (c *Conn) NextMessage() ([]byte, error) {
+	jsguard.Check("webtransportjs.Conn.NextMessage")
+	msg, err := codec.ReadLengthPrefixed(c.reader, maxMessageSize)
+	switch {
+	case err == io.EOF:
+		return nil, ErrClosed
+	case errors.Is(err, codec.ErrMessageTooLarge):
+		return nil, ErrMessageTooLarge
+	}
+	return msg, err
+}
+
+// NextMessageContext is NextMessage bounded by ctx. Once ctx is done the receiving side of the stream is
+// cancelled, as a message may have been read in part, so the Conn can only send afterwards.
+func (c *Conn) NextMessageContext(ctx context.Context) ([]byte, error) {
+	stop := context.AfterFunc(ctx, c.stream.cancelRead)
+	msg, err := c.NextMessage()
+	if err != nil && !stop() {
+		return nil, ctx.Err()
+	}
+	stop()
+	return msg, err
+}
+
+// NextMessageAsync is NextMessageContext in a new goroutine, for JavaScript callbacks, which must not block.
+func (c *Conn) NextMessageAsync(ctx context.Context) <-chan jsguard.Result[[]byte] {
+	return jsguard.Go(func() ([]byte, error) {
+		return c.NextMessageContext(ctx)
+	})
+}
+
+// Send sends a single message.
+func (c *Conn) Send(data []byte) error {
+	if len(data) > maxMessageSize {
+		return ErrMessageTooLarge
+	}
+	frame := codec.AppendLengthPrefixed(make([]byte, 0, binary.MaxVarintLen64+len(data)), data)
+
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	_, err := c.stream.Write(frame)
+	return err
+}
+
+// Close closes the stream and the session.
+func (c *Conn) Close() error {
+	c.stream.Close()
+	return c.session.Close()
+}
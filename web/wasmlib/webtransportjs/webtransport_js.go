@@ -0,0 +1,190 @@
+package webtransportjs
+
+import (
+	"context"
+	"errors"
+	"syscall/js"
+
+	"pkg.gfire.dev/supernet/web/wasmlib/caps"
+	"pkg.gfire.dev/supernet/web/wasmlib/promisejs"
+	"pkg.gfire.dev/supernet/web/wasmlib/streamjs"
+)
+
+var (
+	// _WebTransport is a cached reference to the JavaScript WebTransport constructor
+	_WebTransport = js.Global().Get("WebTransport")
+	// _Object is a cached reference to the JavaScript Object constructor for creating plain objects
+	_Object = js.Global().Get("Object")
+	// _Array is a cached reference to the JavaScript Array constructor for array operations
+	_Array = js.Global().Get("Array")
+	// _Uint8Array is a cached reference to the JavaScript Uint8Array constructor for typed array operations
+	_Uint8Array = js.Global().Get("Uint8Array")
+)
+
+// binding holds the JavaScript objects of a Session.
+type binding struct {
+	// wt holds the JavaScript WebTransport object
+	wt js.Value
+
+	// incoming reads the incomingBidirectionalStreams stream
+	incoming js.Value
+	// datagrams reads incoming datagrams
+	datagrams js.Value
+	// datagramWriter writes outgoing datagrams
+	datagramWriter js.Value
+}
+
+// Dial establishes a WebTransport session with url, which must use the https scheme.
+func Dial(ctx context.Context, url string, opts Options) (*Session, error) {
+	if !caps.Has(caps.WebTransport) {
+		return nil, ErrUnsupported
+	}
+
+	jsOpts := _Object.New()
+	if len(opts.CertificateHashes) > 0 {
+		hashes := _Array.New()
+		for _, h := range opts.CertificateHashes {
+			value := _Uint8Array.New(len(h))
+			js.CopyBytesToJS(value, h)
+			hash := _Object.New()
+			hash.Set("algorithm", "sha-256")
+			hash.Set("value", value)
+			hashes.Call("push", hash)
+		}
+		jsOpts.Set("serverCertificateHashes", hashes)
+	}
+	if opts.CongestionControl != "" {
+		jsOpts.Set("congestionControl", opts.CongestionControl)
+	}
+
+	wt, err := promisejs.Try(func() js.Value {
+		return _WebTransport.New(url, jsOpts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := await(ctx, wt.Get("ready")); err != nil {
+		wt.Call("close")
+		return nil, err
+	}
+
+	s := &Session{
+		binding: binding{
+			wt:             wt,
+			incoming:       wt.Get("incomingBidirectionalStreams").Call("getReader"),
+			datagrams:      wt.Get("datagrams").Get("readable").Call("getReader"),
+			datagramWriter: wt.Get("datagrams").Get("writable").Call("getWriter"),
+		},
+		closed: make(chan struct{}),
+	}
+	promisejs.Then(wt.Get("closed"), func(_ js.Value, err error) {
+		s.finish(err)
+	})
+	return s, nil
+}
+
+// OpenStream opens a bidirectional stream.
+func (s *Session) OpenStream(ctx context.Context) (*Stream, error) {
+	v, err := await(ctx, s.wt.Call("createBidirectionalStream"))
+	if err != nil {
+		return nil, err
+	}
+	return newStream(v), nil
+}
+
+// AcceptStream waits for a bidirectional stream opened by the server.
+func (s *Session) AcceptStream(ctx context.Context) (*Stream, error) {
+	result, err := await(ctx, s.incoming.Call("read"))
+	if err != nil {
+		return nil, err
+	}
+	if result.Get("done").Bool() {
+		return nil, ErrClosed
+	}
+	return newStream(result.Get("value")), nil
+}
+
+// SendDatagram sends an unreliable datagram. Datagrams larger than MaxDatagramSize are dropped.
+func (s *Session) SendDatagram(data []byte) error {
+	chunk := _Uint8Array.New(len(data))
+	js.CopyBytesToJS(chunk, data)
+	_, err := await(context.Background(), s.datagramWriter.Call("write", chunk))
+	return err
+}
+
+// ReceiveDatagram waits for the next datagram.
+func (s *Session) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	result, err := await(ctx, s.datagrams.Call("read"))
+	if err != nil {
+		return nil, err
+	}
+	if result.Get("done").Bool() {
+		return nil, ErrClosed
+	}
+	value := result.Get("value")
+	data := make([]byte, value.Get("byteLength").Int())
+	js.CopyBytesToGo(data, value)
+	return data, nil
+}
+
+// MaxDatagramSize returns the largest datagram the session can currently send.
+func (s *Session) MaxDatagramSize() int {
+	v := s.wt.Get("datagrams").Get("maxDatagramSize")
+	if v.Type() != js.TypeNumber {
+		return 0
+	}
+	return v.Int()
+}
+
+// Close closes the session and all of its streams.
+func (s *Session) Close() error {
+	s.wt.Call("close")
+	s.finish(nil)
+	return nil
+}
+
+// Stream is a reliable, ordered bidirectional byte stream.
+type Stream struct {
+	*streamjs.Reader
+	writer *streamjs.Writer
+}
+
+// newStream wraps a WebTransportBidirectionalStream.
+func newStream(v js.Value) *Stream {
+	return &Stream{
+		Reader: streamjs.NewReader(v.Get("readable")),
+		writer: streamjs.NewWriter(v.Get("writable")),
+	}
+}
+
+// Write writes p to the stream.
+func (st *Stream) Write(p []byte) (int, error) {
+	return st.writer.Write(p)
+}
+
+// CloseWrite finishes the sending side; the peer reads io.EOF after the data written so far.
+func (st *Stream) CloseWrite() error {
+	return st.writer.Close()
+}
+
+// Close closes both directions of the stream.
+func (st *Stream) Close() error {
+	werr := st.writer.Close()
+	st.Reader.Close()
+	return werr
+}
+
+// cancelRead cancels the receiving side of the stream, failing pending reads.
+func (st *Stream) cancelRead() {
+	st.Reader.Close()
+}
+
+// await blocks until the given JavaScript promise settles or ctx is done.
+// Rejections without a reason are reported as ErrRequestFailed.
+func await(ctx context.Context, promise js.Value) (js.Value, error) {
+	v, err := promisejs.Await(ctx, promise)
+	if errors.Is(err, promisejs.ErrRejected) {
+		return v, ErrRequestFailed
+	}
+	return v, err
+}
@@ -0,0 +1,154 @@
+//go:build !js
+
+package webtransportjs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+
+	"github.com/quic-go/webtransport-go"
+)
+
+// binding holds the native session of a Session outside the browser.
+type binding struct {
+	session *webtransport.Session
+}
+
+// Dial establishes a WebTransport session with url, which must use the https scheme. The congestion control
+// hint is ignored outside the browser.
+func Dial(ctx context.Context, url string, opts Options) (*Session, error) {
+	d := &webtransport.Dialer{TLSClientConfig: tlsConfig(opts.CertificateHashes)}
+	_, session, err := d.Dial(ctx, url, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	s := &Session{
+		binding: binding{session: session},
+		closed:  make(chan struct{}),
+	}
+	context.AfterFunc(session.Context(), func() {
+		s.finish(context.Cause(session.Context()))
+	})
+	return s, nil
+}
+
+// tlsConfig returns the TLS configuration verifying servers by certificate hash if any are given, or against
+// the system roots otherwise.
+func tlsConfig(hashes [][]byte) *tls.Config {
+	if len(hashes) == 0 {
+		return nil
+	}
+	return &tls.Config{
+		// The pinned hashes replace the chain verification
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("webtransport: no server certificate")
+			}
+			sum := sha256.Sum256(rawCerts[0])
+			for _, h := range hashes {
+				if bytes.Equal(h, sum[:]) {
+					return nil
+				}
+			}
+			return errors.New("webtransport: server certificate does not match a pinned hash")
+		},
+	}
+}
+
+// OpenStream opens a bidirectional stream.
+func (s *Session) OpenStream(ctx context.Context) (*Stream, error) {
+	st, err := s.session.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, s.wrap(ctx, err)
+	}
+	return &Stream{stream: st}, nil
+}
+
+// AcceptStream waits for a bidirectional stream opened by the server.
+func (s *Session) AcceptStream(ctx context.Context) (*Stream, error) {
+	st, err := s.session.AcceptStream(ctx)
+	if err != nil {
+		return nil, s.wrap(ctx, err)
+	}
+	return &Stream{stream: st}, nil
+}
+
+// SendDatagram sends an unreliable datagram. Datagrams larger than MaxDatagramSize are dropped.
+func (s *Session) SendDatagram(data []byte) error {
+	if err := s.session.SendDatagram(data); err != nil {
+		return s.wrap(context.Background(), err)
+	}
+	return nil
+}
+
+// ReceiveDatagram waits for the next datagram.
+func (s *Session) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	data, err := s.session.ReceiveDatagram(ctx)
+	if err != nil {
+		return nil, s.wrap(ctx, err)
+	}
+	return data, nil
+}
+
+// MaxDatagramSize returns 0, as the native session does not expose the limit; oversized datagrams fail to
+// send instead.
+func (s *Session) MaxDatagramSize() int {
+	return 0
+}
+
+// Close closes the session and all of its streams.
+func (s *Session) Close() error {
+	s.finish(nil)
+	return s.session.CloseWithError(0, "")
+}
+
+// wrap reports errors of an ended session as ErrClosed and those of a done ctx as its error.
+func (s *Session) wrap(ctx context.Context, err error) error {
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case s.session.Context().Err() != nil:
+		return ErrClosed
+	}
+	return err
+}
+
+// Stream is a reliable, ordered bidirectional byte stream.
+type Stream struct {
+	stream *webtransport.Stream
+}
+
+// Read reads from the stream.
+func (st *Stream) Read(p []byte) (int, error) {
+	return st.stream.Read(p)
+}
+
+// Write writes p to the stream.
+func (st *Stream) Write(p []byte) (int, error) {
+	return st.stream.Write(p)
+}
+
+// CloseWrite finishes the sending side; the peer reads io.EOF after the data written so far.
+func (st *Stream) CloseWrite() error {
+	return st.stream.Close()
+}
+
+// Close closes both directions of the stream.
+func (st *Stream) Close() error {
+	st.stream.CancelRead(0)
+	return st.stream.Close()
+}
+
+// cancelRead cancels the receiving side of the stream, failing pending reads.
+func (st *Stream) cancelRead() {
+	st.stream.CancelRead(0)
+}
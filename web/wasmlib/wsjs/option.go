@@ -0,0 +1,65 @@
+package wsjs
+
+import (
+	"log/slog"
+	"time"
+)
+
+// defaultBufferSize is the default number of received messages buffered by a Conn
+const defaultBufferSize = 128
+
+// Option configures a Conn opened by Dial. Options are applied in order, so a later option overrides an
+// earlier one setting the same value.
+type Option func(*options)
+
+// options holds the configuration of a Conn.
+type options struct {
+	protocols  []string
+	bufferSize int
+	timeout    time.Duration
+	logger     *slog.Logger
+}
+
+// newOptions returns the defaults overridden by opts.
+func newOptions(opts []Option) options {
+	o := options{bufferSize: defaultBufferSize, logger: logger}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithProtocols offers subprotocols to the server, in order of preference. Conn.Protocol returns the one
+// the server selected.
+func WithProtocols(protocols ...string) Option {
+	return func(o *options) {
+		o.protocols = protocols
+	}
+}
+
+// WithBufferSize sets the number of received messages buffered for NextMessage (default 128). Once the
+// buffer is full, receiving waits for the reader.
+func WithBufferSize(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.bufferSize = n
+		}
+	}
+}
+
+// WithTimeout bounds opening the connection. Dial fails with ErrFailedToDial wrapping
+// context.DeadlineExceeded when it elapses.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithLogger sets the logger receiving connection failures instead of the package's logger.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		if l != nil {
+			o.logger = l
+		}
+	}
+}
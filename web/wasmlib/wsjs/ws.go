@@ -0,0 +1,62 @@
+// Package wsjs connects to WebSocket servers with the browser WebSocket API, or with
+// github.com/coder/websocket outside the browser, and adapts connections to io.ReadWriteCloser.
+package wsjs
+
+import (
+	"context"
+	"errors"
+
+	"pkg.gfire.dev/supernet/logging"
+	"pkg.gfire.dev/supernet/web/wasmlib/jserr"
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+var (
+	// ErrFailedToDial is returned when the WebSocket connection fails to establish
+	ErrFailedToDial = jserr.New(jserr.ErrNetwork, "failed to dial websocket")
+	// ErrClosed is returned when attempting to use a closed WebSocket connection
+	ErrClosed = errors.New("websocket connection closed")
+)
+
+// logger receives connection failures, which browsers report to the page without any detail besides
+// the close code and reason
+var logger = logging.For("wsjs")
+
+// Conn represents a managed WebSocket connection with proper resource cleanup.
+// It handles both text and binary messages, converting them to Go byte slices for consumption.
+type Conn struct {
+	binding
+
+	// messageChan buffers incoming messages from the WebSocket (128 messages unless set by WithBufferSize)
+	messageChan chan []byte
+	// closeChan signals when the WebSocket connection has been closed
+	closeChan chan struct{}
+}
+
+// NextMessage retrieves the next message from the WebSocket connection.
+// It blocks until a message is available or the connection is closed.
+// Returns ErrClosed if the connection has been closed before or during the wait.
+func (conn *Conn) NextMessage() ([]byte, error) {
+	return conn.NextMessageContext(context.Background())
+}
+
+// NextMessageContext is NextMessage bounded by ctx. It returns ctx.Err() once ctx is done, leaving the
+// connection open and the messages queued.
+func (conn *Conn) NextMessageContext(ctx context.Context) ([]byte, error) {
+	jsguard.Check("wsjs.Conn.NextMessage")
+	select {
+	case msg := <-conn.messageChan:
+		return msg, nil
+	case <-conn.closeChan:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// NextMessageAsync is NextMessageContext in a new goroutine, for JavaScript callbacks, which must not block.
+func (conn *Conn) NextMessageAsync(ctx context.Context) <-chan jsguard.Result[[]byte] {
+	return jsguard.Go(func() ([]byte, error) {
+		return conn.NextMessageContext(ctx)
+	})
+}
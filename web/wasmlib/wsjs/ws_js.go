@@ -1,15 +1,12 @@
 package wsjs
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	"syscall/js"
-)
+	"time"
 
-var (
-	// ErrFailedToDial is returned when the WebSocket connection fails to establish
-	ErrFailedToDial = errors.New("failed to dial websocket")
-	// ErrClosed is returned when attempting to use a closed WebSocket connection
-	ErrClosed = errors.New("websocket connection closed")
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
 )
 
 var (
@@ -19,57 +16,71 @@ var (
 	_ArrayBuffer = js.Global().Get("ArrayBuffer")
 	// _Uint8Array is a cached reference to the JavaScript Uint8Array constructor for typed array operations
 	_Uint8Array = js.Global().Get("Uint8Array")
+	// _Array is a cached reference to the JavaScript Array constructor for the subprotocol list
+	_Array = js.Global().Get("Array")
 )
 
-// Conn represents a managed WebSocket connection with proper resource cleanup.
-// It handles both text and binary messages, converting them to Go byte slices for consumption.
-type Conn struct {
+// binding holds the JavaScript side of a Conn.
+type binding struct {
 	// ws holds the JavaScript WebSocket object
 	ws js.Value
 
-	// messageChan buffers incoming messages from the WebSocket (up to 128 messages)
-	messageChan chan []byte
-	// closeChan signals when the WebSocket connection has been closed
-	closeChan chan struct{}
-
 	// funcsToBeReleased tracks JavaScript function callbacks that must be released to prevent memory leaks
 	funcsToBeReleased []js.Func
 }
 
 // freeFuncs releases all registered JavaScript function callbacks to allow garbage collection.
-// This must be called when the connection is closed to prevent memory leaks.
+// It is called by the close event, after which the WebSocket fires no more events.
 func (conn *Conn) freeFuncs() {
 	for _, f := range conn.funcsToBeReleased {
-		f.Release()
+		jsguard.Release(f)
 	}
 }
 
-// Dial establishes a WebSocket connection to the specified URI.
+// Dial establishes a WebSocket connection to the specified URI, configured by opts.
 // Returns a Conn ready for use or an error if the connection fails.
 // The connection is ready for receiving and sending messages after this call succeeds.
-func Dial(uri string) (*Conn, error) {
+func Dial(uri string, opts ...Option) (*Conn, error) {
+	return DialContext(context.Background(), uri, opts...)
+}
+
+// DialContext is Dial bounded by ctx, which closes the socket still being opened when it is done; the
+// error then wraps ctx.Err(). ctx does not bound the lifetime of the returned connection.
+func DialContext(ctx context.Context, uri string, opts ...Option) (*Conn, error) {
+	o := newOptions(opts)
 	errCh := make(chan error, 1)
 
-	ws := _WebSocket.New(uri)
+	protocols := _Array.New()
+	for _, p := range o.protocols {
+		protocols.Call("push", p)
+	}
+	ws := _WebSocket.New(uri, protocols)
 	ws.Set("binaryType", "arraybuffer")
 
 	conn := &Conn{
-		ws:          ws,
-		messageChan: make(chan []byte, 128),
+		binding:     binding{ws: ws},
+		messageChan: make(chan []byte, o.bufferSize),
 		closeChan:   make(chan struct{}, 1),
 	}
 
-	onOpen := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		errCh <- nil
+	// Only the first open or error event decides the dial; later errors are followed by the close event
+	onOpen := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		select {
+		case errCh <- nil:
+		default:
+		}
 		return nil
 	})
 
-	onError := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		errCh <- ErrFailedToDial
+	onError := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		select {
+		case errCh <- ErrFailedToDial:
+		default:
+		}
 		return nil
 	})
 
-	onMessage := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+	onMessage := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
 		jsData := args[0].Get("data")
 		if jsData.Type() == js.TypeString {
 			// Handle text frame: convert JavaScript string to Go byte slice
@@ -87,58 +98,77 @@ func Dial(uri string) (*Conn, error) {
 		return nil
 	})
 
-	onClose := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+	onClose := jsguard.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		ev := args[0]
+		o.logger.Debug("wsjs connection closed", "url", uri,
+			"code", ev.Get("code").Int(), "reason", ev.Get("reason").String(), "clean", ev.Get("wasClean").Bool())
 		close(conn.closeChan)
+		conn.freeFuncs()
 		return nil
 	})
 
 	conn.funcsToBeReleased = append(conn.funcsToBeReleased, onOpen, onError, onMessage, onClose)
 
-	conn.ws.Call("addEventListener", "open", onOpen)
-	conn.ws.Call("addEventListener", "error", onError)
-	conn.ws.Call("addEventListener", "message", onMessage)
-	conn.ws.Call("addEventListener", "close", onClose)
+	jsguard.AddEventListener(conn.ws, "open", onOpen)
+	jsguard.AddEventListener(conn.ws, "error", onError)
+	jsguard.AddEventListener(conn.ws, "message", onMessage)
+	jsguard.AddEventListener(conn.ws, "close", onClose)
 
-	err := <-errCh
+	var timeout <-chan time.Time
+	if o.timeout > 0 {
+		timer := time.NewTimer(o.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var err error
+	select {
+	case err = <-errCh:
+	case <-timeout:
+		err = fmt.Errorf("%w: %w", ErrFailedToDial, context.DeadlineExceeded)
+	case <-ctx.Done():
+		err = fmt.Errorf("%w: %w", ErrFailedToDial, ctx.Err())
+	}
 	if err != nil {
-		conn.freeFuncs()
+		o.logger.Debug("wsjs dial failed", "url", uri, "err", err)
+		// The callbacks stay registered until the close event that follows a failed or aborted opening
+		conn.Close()
 		return nil, err
 	}
 
 	return conn, nil
 }
 
+// Protocol returns the subprotocol selected by the server, or the empty string if none was.
+func (conn *Conn) Protocol() string {
+	return conn.ws.Get("protocol").String()
+}
+
 // Close closes the WebSocket connection and releases all associated resources.
 // It waits for the close event to be received before returning.
 // Subsequent calls to Close are safe and will not cause errors.
 func (conn *Conn) Close() error {
 	conn.ws.Call("close")
 	<-conn.closeChan
-	conn.freeFuncs()
 	return nil
 }
 
-// NextMessage retrieves the next message from the WebSocket connection.
-// It blocks until a message is available or the connection is closed.
-// Returns ErrClosed if the connection has been closed before or during the wait.
-func (conn *Conn) NextMessage() ([]byte, error) {
-	select {
-	case msg := <-conn.messageChan:
-		return msg, nil
-	case <-conn.closeChan:
-		return nil, ErrClosed
-	}
-}
-
 // Send sends a message to the WebSocket connection as binary data.
 // The provided byte slice is converted to a JavaScript ArrayBuffer and sent immediately.
 // Returns an error only if the underlying connection operation fails.
 func (conn *Conn) Send(data []byte) error {
-	// Convert Go byte slice to JavaScript ArrayBuffer for transmission
-	buffer := _ArrayBuffer.New(len(data))
-	array := _Uint8Array.New(buffer)
+	// send accepts the Uint8Array itself, which saves creating its ArrayBuffer separately
+	array := _Uint8Array.New(len(data))
 	js.CopyBytesToJS(array, data)
 
-	conn.ws.Call("send", buffer)
+	conn.ws.Call("send", array)
 	return nil
 }
+
+// SendContext is Send failing with ctx.Err() if ctx is already done. The browser queues the message
+// without blocking, so ctx cannot take it back once it was handed over.
+func (conn *Conn) SendContext(ctx context.Context, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return conn.Send(data)
+}
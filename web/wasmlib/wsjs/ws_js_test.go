@@ -0,0 +1,96 @@
+package wsjs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"pkg.gfire.dev/supernet/web/browsertest"
+	"pkg.gfire.dev/supernet/web/wasmlib/jsguard"
+)
+
+// benchmarkMessageSize is the size of the messages echoed by BenchmarkWebSocketEcho
+const benchmarkMessageSize = 16 << 10
+
+func TestMain(m *testing.M) {
+	jsguard.Track(true)
+	os.Exit(m.Run())
+}
+
+func TestEcho(t *testing.T) {
+	url := browsertest.FixtureURL("/ws/echo")
+	if url == "" {
+		t.Skip("no browsertest fixtures")
+	}
+	defer jsguard.VerifyNone(t, jsguard.Mark())
+
+	conn, err := Dial(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range [][]byte{[]byte("hello"), {}, bytes.Repeat([]byte{0xff}, 64<<10)} {
+		if err := conn.Send(msg); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		echo, err := conn.NextMessageContext(ctx)
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(echo, msg) {
+			t.Fatalf("echoed %d bytes, want %d", len(echo), len(msg))
+		}
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.NextMessage(); err != ErrClosed {
+		t.Fatalf("NextMessage after Close: %v, want ErrClosed", err)
+	}
+}
+
+func TestDialRefused(t *testing.T) {
+	// Node.js fires no close event after a failed connection, which Dial waits for to release its callbacks
+	if browsertest.FixtureURL("/") == "" {
+		t.Skip("needs a browser, see browsertest.Run")
+	}
+	defer jsguard.VerifyNone(t, jsguard.Mark())
+
+	// Nothing listens on port 1
+	if _, err := Dial("ws://127.0.0.1:1/"); !errors.Is(err, ErrFailedToDial) {
+		t.Fatalf("Dial: %v, want ErrFailedToDial", err)
+	}
+}
+
+// BenchmarkWebSocketEcho sends binary messages to the echo fixture of browsertest and waits for each to
+// come back. It is skipped unless it runs under browsertest.Run or BROWSERTEST_FIXTURES points at a
+// fixture server.
+func BenchmarkWebSocketEcho(b *testing.B) {
+	url := browsertest.FixtureURL("/ws/echo")
+	if url == "" {
+		b.Skip("no browsertest fixtures")
+	}
+	conn, err := Dial(url)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer conn.Close()
+
+	msg := make([]byte, benchmarkMessageSize)
+	b.SetBytes(benchmarkMessageSize)
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := conn.Send(msg); err != nil {
+			b.Fatal(err)
+		}
+		echo, err := conn.NextMessage()
+		if err != nil || len(echo) != benchmarkMessageSize {
+			b.Fatalf("echoed %d bytes: %v", len(echo), err)
+		}
+	}
+}
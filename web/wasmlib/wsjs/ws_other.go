@@ -0,0 +1,102 @@
+//go:build !js
+
+package wsjs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/coder/websocket"
+)
+
+// binding holds the connection of a Conn outside the browser.
+type binding struct {
+	// ws holds the github.com/coder/websocket connection
+	ws *websocket.Conn
+	// cancel stops the read loop
+	cancel context.CancelFunc
+}
+
+// Dial establishes a WebSocket connection to the specified URI, configured by opts.
+// Returns a Conn ready for use or an error if the connection fails.
+// Like browsers, the connection accepts messages of any size.
+func Dial(uri string, opts ...Option) (*Conn, error) {
+	return DialContext(context.Background(), uri, opts...)
+}
+
+// DialContext is Dial bounded by ctx, which aborts the opening handshake when it is done; the error then
+// wraps ctx.Err(). ctx does not bound the lifetime of the returned connection.
+func DialContext(ctx context.Context, uri string, opts ...Option) (*Conn, error) {
+	o := newOptions(opts)
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+	ws, _, err := websocket.Dial(ctx, uri, &websocket.DialOptions{Subprotocols: o.protocols})
+	if err != nil {
+		o.logger.Debug("wsjs dial failed", "url", uri, "err", err)
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %w", ErrFailedToDial, err)
+	}
+	ws.SetReadLimit(-1)
+
+	readCtx, cancel := context.WithCancel(context.Background())
+	conn := &Conn{
+		binding:     binding{ws: ws, cancel: cancel},
+		messageChan: make(chan []byte, o.bufferSize),
+		closeChan:   make(chan struct{}),
+	}
+	go conn.readLoop(readCtx, uri, o.logger)
+	return conn, nil
+}
+
+// readLoop delivers received messages until the connection is closed.
+func (conn *Conn) readLoop(ctx context.Context, uri string, logger *slog.Logger) {
+	defer close(conn.closeChan)
+	for {
+		_, data, err := conn.ws.Read(ctx)
+		if err != nil {
+			logger.Debug("wsjs connection closed", "url", uri, "code", websocket.CloseStatus(err), "err", err)
+			return
+		}
+		select {
+		case conn.messageChan <- data:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Protocol returns the subprotocol selected by the server, or the empty string if none was.
+func (conn *Conn) Protocol() string {
+	return conn.ws.Subprotocol()
+}
+
+// Close closes the WebSocket connection and waits for the read loop to end.
+// Subsequent calls to Close are safe and will not cause errors.
+func (conn *Conn) Close() error {
+	conn.ws.Close(websocket.StatusNormalClosure, "")
+	conn.cancel()
+	<-conn.closeChan
+	return nil
+}
+
+// Send sends a message to the WebSocket connection as binary data.
+func (conn *Conn) Send(data []byte) error {
+	return conn.SendContext(context.Background(), data)
+}
+
+// SendContext is Send bounded by ctx. A write interrupted by ctx closes the connection, as the peer would
+// otherwise receive a partial message.
+func (conn *Conn) SendContext(ctx context.Context, data []byte) error {
+	select {
+	case <-conn.closeChan:
+		return ErrClosed
+	default:
+	}
+	return conn.ws.Write(ctx, websocket.MessageBinary, data)
+}
@@ -8,9 +8,9 @@ import (
 // It handles thread-safe reading and writing with proper buffering for messages that don't fit in a single read.
 type WsStream struct {
 	conn          *Conn
-	currentBuffer []byte // Remaining bytes from the last message read that didn't fit in the buffer
-	readMu        sync.Mutex  // Protects concurrent Read operations
-	writeMu       sync.Mutex  // Protects concurrent Write operations
+	currentBuffer []byte     // Remaining bytes from the last message read that didn't fit in the buffer
+	readMu        sync.Mutex // Protects concurrent Read operations
+	writeMu       sync.Mutex // Protects concurrent Write operations
 }
 
 // NewWsStream creates a new WsStream adapter from an existing WebSocket connection.
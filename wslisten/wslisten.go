@@ -0,0 +1,233 @@
+//go:build !js
+
+// Package wslisten serves browser clients through a net.Listener. The Listener is also an http.Handler;
+// every WebSocket connection it accepts is handed out by Accept as a net.Conn carrying a byte stream in
+// binary messages, so existing servers (gRPC, SSH, custom protocols) can serve WASM clients unchanged:
+//
+//	ln := wslisten.New(wslisten.Config{})
+//	http.Handle("/ws", ln)
+//	go grpcServer.Serve(ln)
+//
+// Other transports, such as WebTransport sessions, can feed the same listener through Offer.
+package wslisten
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/coder/websocket"
+
+	"pkg.gfire.dev/supernet/logging"
+	"pkg.gfire.dev/supernet/msgconn"
+)
+
+// ErrBacklogFull is returned by Offer when connections arrive faster than they are accepted
+var ErrBacklogFull = errors.New("listener backlog full")
+
+// defaultBacklog is the default number of connections waiting for Accept
+const defaultBacklog = 128
+
+// Config configures a Listener.
+type Config struct {
+	// Addr is returned by Listener.Addr, usually the address of the HTTP server.
+	// Defaults to a placeholder address on the "websocket" network.
+	Addr net.Addr
+	// Backlog is the number of connections waiting for Accept before new ones are refused (default 128).
+	Backlog int
+	// AcceptOptions configures the WebSocket handshake, e.g. allowed origins and subprotocols.
+	AcceptOptions *websocket.AcceptOptions
+	// Authorize decides whether a client may connect, see policy.Enforcer.Authorizer. Returning an error
+	// refuses the request with 403 Forbidden.
+	Authorize func(r *http.Request) error
+	// Observer receives connection events, e.g. for metrics.
+	Observer Observer
+	// Logger receives refused connections. Defaults to logging.For("wslisten").
+	Logger *slog.Logger
+}
+
+// Observer receives listener events. Its methods are called concurrently and must not block.
+type Observer interface {
+	// Opened is called when a connection was queued for Accept, Closed when it was closed.
+	Opened()
+	Closed()
+	// Refused is called when a connection was refused, e.g. with ErrBacklogFull, or its WebSocket
+	// handshake failed.
+	Refused(err error)
+}
+
+// Listener is a net.Listener over incoming WebSocket connections.
+type Listener struct {
+	// addr is the listener address
+	addr net.Addr
+	// opts configures the WebSocket handshake
+	opts *websocket.AcceptOptions
+	// authorize decides whether a client may connect, nil to accept everyone
+	authorize func(r *http.Request) error
+	// conns queues connections waiting for Accept
+	conns chan net.Conn
+	// observer receives connection events, nil if unobserved
+	observer Observer
+	// log receives refused connections
+	log *slog.Logger
+
+	// mu guards closed against concurrent Offer calls
+	mu sync.RWMutex
+	// closed is set once Close has been called
+	closed bool
+	// done is closed by Close to wake pending Accept calls
+	done chan struct{}
+}
+
+// New creates a Listener. It does not listen on its own; mount it on an HTTP server.
+func New(cfg Config) *Listener {
+	if cfg.Addr == nil {
+		cfg.Addr = msgconn.Addr{Net: "websocket", Addr: "wslisten"}
+	}
+	if cfg.Backlog <= 0 {
+		cfg.Backlog = defaultBacklog
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logging.For("wslisten")
+	}
+	return &Listener{
+		addr:      cfg.Addr,
+		opts:      cfg.AcceptOptions,
+		authorize: cfg.Authorize,
+		conns:     make(chan net.Conn, cfg.Backlog),
+		observer:  cfg.Observer,
+		log:       cfg.Logger,
+		done:      make(chan struct{}),
+	}
+}
+
+// ServeHTTP upgrades the request to a WebSocket and queues it for Accept.
+// Requests are refused with 403 Forbidden when Config.Authorize denies them, and with 503 Service Unavailable
+// once the listener is closed or its backlog is full.
+func (l *Listener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if l.authorize != nil {
+		if err := l.authorize(r); err != nil {
+			l.refused(err)
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+	if l.full() {
+		l.refused(ErrBacklogFull)
+		http.Error(w, ErrBacklogFull.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	ws, err := websocket.Accept(w, r, l.opts)
+	if err != nil {
+		l.refused(err)
+		return
+	}
+	// The connection outlives the handler, so it must not be bound to the request context
+	conn := websocket.NetConn(context.WithoutCancel(r.Context()), ws, websocket.MessageBinary)
+	if err := l.Offer(conn); err != nil {
+		ws.Close(websocket.StatusTryAgainLater, err.Error())
+	}
+}
+
+// Offer queues a connection established elsewhere for Accept.
+// Returns net.ErrClosed after Close and ErrBacklogFull when the backlog is full; the connection is not
+// closed in either case.
+func (l *Listener) Offer(conn net.Conn) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.closed {
+		l.refused(net.ErrClosed)
+		return net.ErrClosed
+	}
+	if l.observer != nil {
+		conn = &observedConn{Conn: conn, observer: l.observer}
+	}
+	select {
+	case l.conns <- conn:
+		if l.observer != nil {
+			l.observer.Opened()
+		}
+		return nil
+	default:
+		l.refused(ErrBacklogFull)
+		return ErrBacklogFull
+	}
+}
+
+// Accept waits for and returns the next connection.
+// Returns net.ErrClosed after Close, which servers such as net/http and gRPC treat as a clean shutdown.
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close stops accepting connections and closes those still waiting for Accept.
+// Connections already returned by Accept are unaffected. Safe to call multiple times.
+func (l *Listener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	close(l.done)
+	l.mu.Unlock()
+
+	// No Offer can queue after closed is set, so draining empties the backlog for good
+	for {
+		select {
+		case conn := <-l.conns:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// Addr returns the listener's address.
+func (l *Listener) Addr() net.Addr {
+	return l.addr
+}
+
+// refused reports a refused connection to the observer.
+func (l *Listener) refused(err error) {
+	// A full backlog means the server does not keep up, unlike failed handshakes of single clients
+	level := slog.LevelDebug
+	if errors.Is(err, ErrBacklogFull) {
+		level = slog.LevelWarn
+	}
+	l.log.Log(context.Background(), level, "wslisten connection refused", "err", err)
+	if l.observer != nil {
+		l.observer.Refused(err)
+	}
+}
+
+// full reports whether new connections would be refused, checked before the handshake to avoid
+// upgrading connections that cannot be queued.
+func (l *Listener) full() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.closed || len(l.conns) == cap(l.conns)
+}
+
+// observedConn reports its closing to the listener's observer.
+type observedConn struct {
+	net.Conn
+	observer Observer
+	once     sync.Once
+}
+
+// Close closes the connection.
+func (c *observedConn) Close() error {
+	c.once.Do(c.observer.Closed)
+	return c.Conn.Close()
+}
@@ -0,0 +1,203 @@
+//go:build !js
+
+package wtlisten
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/quic-go/webtransport-go"
+
+	"pkg.gfire.dev/supernet/codec"
+	"pkg.gfire.dev/supernet/msgconn"
+)
+
+var (
+	// ErrClosed is returned when using a closed connection or session
+	ErrClosed = errors.New("webtransport session closed")
+	// ErrMessageTooLarge is returned for messages larger than MaxMessage
+	ErrMessageTooLarge = errors.New("webtransport message too large")
+)
+
+// MaxMessage is the largest message a Conn sends or receives, matching webtransportjs
+const MaxMessage = 16 << 20
+
+// Network is the network name of connection addresses
+const Network = "webtransport"
+
+// Session is an accepted WebTransport session.
+type Session struct {
+	session *webtransport.Session
+	// request is the CONNECT request that established the session
+	request *http.Request
+
+	datagramsOnce sync.Once
+	datagrams     *DatagramConn
+}
+
+// Request returns the request that established the session, e.g. to authenticate its client.
+// Its body must not be used.
+func (s *Session) Request() *http.Request {
+	return s.request
+}
+
+// AcceptConn waits for the client to open a bidirectional stream, such as the one opened by
+// webtransportjs.DialConn, and returns it as a message connection.
+func (s *Session) AcceptConn(ctx context.Context) (*Conn, error) {
+	st, err := s.session.AcceptStream(ctx)
+	if err != nil {
+		return nil, s.err(err)
+	}
+	return newConn(s, st), nil
+}
+
+// OpenConn opens a bidirectional stream as a message connection; the client accepts it with
+// webtransportjs.Session.AcceptStream and webtransportjs.NewConn.
+func (s *Session) OpenConn(ctx context.Context) (*Conn, error) {
+	st, err := s.session.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, s.err(err)
+	}
+	return newConn(s, st), nil
+}
+
+// Datagrams returns the unreliable message connection over the session's datagrams. Closing it closes
+// the session.
+func (s *Session) Datagrams() *DatagramConn {
+	s.datagramsOnce.Do(func() {
+		ctx, cancel := context.WithCancel(s.session.Context())
+		s.datagrams = &DatagramConn{session: s, ctx: ctx, cancel: cancel}
+	})
+	return s.datagrams
+}
+
+// Done returns a channel that is closed when the session ends.
+func (s *Session) Done() <-chan struct{} {
+	return s.session.Context().Done()
+}
+
+// LocalAddr returns the server's UDP address.
+func (s *Session) LocalAddr() net.Addr {
+	return s.session.LocalAddr()
+}
+
+// RemoteAddr returns the client's UDP address.
+func (s *Session) RemoteAddr() net.Addr {
+	return s.session.RemoteAddr()
+}
+
+// Close closes the session and all of its streams.
+func (s *Session) Close() error {
+	return s.session.CloseWithError(0, "")
+}
+
+// err reports errors of an ended session as ErrClosed.
+func (s *Session) err(err error) error {
+	if s.session.Context().Err() != nil {
+		return ErrClosed
+	}
+	return err
+}
+
+// Conn carries length-prefixed messages over a bidirectional stream, implementing the
+// NextMessage/Send/Close contract. It is the counterpart of webtransportjs.Conn.
+type Conn struct {
+	session *Session
+	stream  *webtransport.Stream
+	reader  *bufio.Reader
+
+	// sendMu keeps concurrent messages from interleaving
+	sendMu sync.Mutex
+}
+
+// newConn frames messages over st.
+func newConn(s *Session, st *webtransport.Stream) *Conn {
+	return &Conn{session: s, stream: st, reader: bufio.NewReader(st)}
+}
+
+// Session returns the session the connection belongs to.
+func (c *Conn) Session() *Session {
+	return c.session
+}
+
+// NextMessage blocks until the next message is received.
+func (c *Conn) NextMessage() ([]byte, error) {
+	msg, err := codec.ReadLengthPrefixed(c.reader, MaxMessage)
+	switch {
+	case err == io.EOF:
+		return nil, ErrClosed
+	case errors.Is(err, codec.ErrMessageTooLarge):
+		return nil, ErrMessageTooLarge
+	case err != nil:
+		return nil, c.session.err(err)
+	}
+	return msg, nil
+}
+
+// Send sends a single message.
+func (c *Conn) Send(data []byte) error {
+	if len(data) > MaxMessage {
+		return ErrMessageTooLarge
+	}
+	frame := codec.AppendLengthPrefixed(nil, data)
+
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if _, err := c.stream.Write(frame); err != nil {
+		return c.session.err(err)
+	}
+	return nil
+}
+
+// Close closes the stream. The session stays open for other streams; close it with Session.Close.
+func (c *Conn) Close() error {
+	c.stream.CancelRead(0)
+	return c.stream.Close()
+}
+
+// NetConn returns the connection as a net.Conn carrying a byte stream, like the connections of a
+// wslisten.Listener.
+func (c *Conn) NetConn() net.Conn {
+	return msgconn.NetConn(c, msgconn.Options{
+		LocalAddr:  msgconn.Addr{Net: Network, Addr: c.session.LocalAddr().String()},
+		RemoteAddr: msgconn.Addr{Net: Network, Addr: c.session.RemoteAddr().String()},
+		MaxMessage: MaxMessage,
+	})
+}
+
+// DatagramConn carries messages as WebTransport datagrams, implementing the NextMessage/Send/Close
+// contract. Datagrams may be lost or reordered and must fit into a single QUIC packet.
+type DatagramConn struct {
+	session *Session
+	// ctx ends with the session or Close, unblocking NextMessage
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NextMessage blocks until the next datagram is received.
+func (d *DatagramConn) NextMessage() ([]byte, error) {
+	msg, err := d.session.session.ReceiveDatagram(d.ctx)
+	if err != nil {
+		return nil, ErrClosed
+	}
+	return msg, nil
+}
+
+// Send sends data as a single datagram.
+func (d *DatagramConn) Send(data []byte) error {
+	if err := d.session.session.SendDatagram(data); err != nil {
+		return d.session.err(err)
+	}
+	return nil
+}
+
+// Close closes the session.
+func (d *DatagramConn) Close() error {
+	d.cancel()
+	return d.session.Close()
+}
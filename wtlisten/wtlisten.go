@@ -0,0 +1,218 @@
+//go:build !js
+
+// Package wtlisten serves browser clients over WebTransport (HTTP/3), the server counterpart of
+// webtransportjs. Accepted sessions expose their bidirectional streams as message connections framed
+// like webtransportjs.Conn, and their datagrams as an unreliable message connection, so they plug into
+// mux, relay and p2p just like WebSocket connections:
+//
+//	l, err := wtlisten.Listen(wtlisten.Config{Addr: ":443", TLSConfig: tlsConf, Path: "/wt"})
+//	for {
+//		sess, err := l.Accept(ctx)
+//		...
+//		go func() {
+//			conn, err := sess.AcceptConn(ctx) // the stream opened by webtransportjs.DialConn
+//			...
+//			relayServer.Serve(ctx, conn, rules, identity)
+//		}()
+//	}
+//
+// Conn.NetConn adapts a connection to net.Conn, e.g. to feed a wslisten.Listener through Offer.
+package wtlisten
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
+
+	"pkg.gfire.dev/supernet/logging"
+)
+
+var (
+	// ErrBacklogFull is reported to clients whose session arrives while the backlog is full
+	ErrBacklogFull = errors.New("listener backlog full")
+	// ErrNoTLS is returned by Listen without a TLS configuration, which HTTP/3 requires
+	ErrNoTLS = errors.New("wtlisten: TLS configuration required")
+)
+
+const (
+	// defaultBacklog is the default number of sessions waiting for Accept
+	defaultBacklog = 128
+	// defaultPath is the default URL path of the WebTransport endpoint
+	defaultPath = "/"
+)
+
+// Config configures a Listener.
+type Config struct {
+	// Addr is the UDP address to listen on (default ":443").
+	Addr string
+	// TLSConfig holds the server certificates. Browsers only accept certificates of a public CA, or
+	// short-lived self-signed ones pinned with webtransportjs.Options.
+	TLSConfig *tls.Config
+	// Path is the URL path WebTransport sessions are accepted at (default "/").
+	Path string
+	// Handler serves other HTTP/3 requests, which are answered with 404 Not Found when nil.
+	Handler http.Handler
+	// CheckOrigin decides whether a web page may connect. When nil, only pages of the same origin as the
+	// request's host may.
+	CheckOrigin func(r *http.Request) bool
+	// Authorize decides whether a client may open a session, see policy.Enforcer.Authorizer. Returning an
+	// error refuses the request with 403 Forbidden.
+	Authorize func(r *http.Request) error
+	// Backlog is the number of sessions waiting for Accept before new ones are refused (default 128).
+	Backlog int
+	// Logger receives refused sessions. Defaults to logging.For("wtlisten").
+	Logger *slog.Logger
+}
+
+// Listener accepts WebTransport sessions.
+type Listener struct {
+	// server runs the HTTP/3 server and upgrades requests to sessions
+	server *webtransport.Server
+	// conn is the UDP socket the server listens on
+	conn net.PacketConn
+	// sessions queues sessions waiting for Accept
+	sessions chan *Session
+	// authorize decides whether a client may open a session, nil to accept everyone
+	authorize func(r *http.Request) error
+	// log receives refused sessions
+	log *slog.Logger
+
+	// done is closed by Close to wake pending Accept calls
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Listen starts an HTTP/3 server on cfg.Addr accepting WebTransport sessions at cfg.Path.
+func Listen(cfg Config) (*Listener, error) {
+	if cfg.TLSConfig == nil {
+		return nil, ErrNoTLS
+	}
+	if cfg.Addr == "" {
+		cfg.Addr = ":443"
+	}
+	if cfg.Path == "" {
+		cfg.Path = defaultPath
+	}
+	if cfg.Backlog <= 0 {
+		cfg.Backlog = defaultBacklog
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logging.For("wtlisten")
+	}
+
+	conn, err := net.ListenPacket("udp", cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Listener{
+		conn:      conn,
+		sessions:  make(chan *Session, cfg.Backlog),
+		authorize: cfg.Authorize,
+		log:       cfg.Logger,
+		done:      make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(cfg.Path, l.upgrade)
+	if cfg.Handler != nil && cfg.Path != "/" {
+		mux.Handle("/", cfg.Handler)
+	}
+	l.server = &webtransport.Server{
+		H3: &http3.Server{
+			TLSConfig: http3.ConfigureTLSConfig(cfg.TLSConfig),
+			Handler:   mux,
+		},
+		CheckOrigin: cfg.CheckOrigin,
+	}
+	webtransport.ConfigureHTTP3Server(l.server.H3)
+
+	go func() {
+		err := l.server.Serve(conn)
+		select {
+		case <-l.done:
+		default:
+			l.log.Error("wtlisten server failed", "err", err)
+		}
+	}()
+	return l, nil
+}
+
+// upgrade turns a CONNECT request into a session and queues it for Accept.
+func (l *Listener) upgrade(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-l.done:
+		http.Error(w, net.ErrClosed.Error(), http.StatusServiceUnavailable)
+		return
+	default:
+	}
+	if l.authorize != nil {
+		if err := l.authorize(r); err != nil {
+			l.log.Debug("wtlisten session refused", "err", err, "client", r.RemoteAddr)
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+	if len(l.sessions) == cap(l.sessions) {
+		l.log.Warn("wtlisten session refused", "err", ErrBacklogFull)
+		http.Error(w, ErrBacklogFull.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	ws, err := l.server.Upgrade(w, r)
+	if err != nil {
+		l.log.Debug("wtlisten session refused", "err", err, "client", r.RemoteAddr)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	sess := &Session{session: ws, request: r}
+	select {
+	case l.sessions <- sess:
+	default:
+		l.log.Warn("wtlisten session refused", "err", ErrBacklogFull)
+		ws.CloseWithError(0, ErrBacklogFull.Error())
+	}
+}
+
+// Accept waits for and returns the next session. Returns net.ErrClosed after Close.
+func (l *Listener) Accept(ctx context.Context) (*Session, error) {
+	select {
+	case sess := <-l.sessions:
+		return sess, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Addr returns the UDP address the listener serves on.
+func (l *Listener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+// Close stops the server, closing the sessions still waiting for Accept. Sessions accepted before stay
+// open until closed by their owner or the client.
+func (l *Listener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+		l.server.Close()
+		l.conn.Close()
+		for {
+			select {
+			case sess := <-l.sessions:
+				sess.Close()
+			default:
+				return
+			}
+		}
+	})
+	return nil
+}